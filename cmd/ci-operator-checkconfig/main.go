@@ -31,6 +31,7 @@ type options struct {
 	config.Options
 
 	resolver                 registry.Resolver
+	versions                 registry.Versions
 	ciOPConfigAgent          agents.ConfigAgent
 	clusterProfiles          api.ClusterProfilesMap
 	clusterClaimOwners       api.ClusterClaimOwnersMap
@@ -141,7 +142,8 @@ func (o *options) loadResolver(path string) error {
 	if err != nil {
 		return err
 	}
-	o.resolver = registry.NewResolver(refs, chains, workflows, observers, clusterProfiles)
+	o.versions = load.NewGitRegistryVersions(path, load.RegistryFlag(0))
+	o.resolver = registry.NewVersionedResolver(refs, chains, workflows, observers, clusterProfiles, o.versions)
 	return nil
 }
 
@@ -151,6 +153,9 @@ func (o *options) validateConfiguration(
 	configuration api.ReleaseBuildConfiguration,
 ) error {
 	if o.resolver != nil {
+		if err := o.validatePinnedVersions(configuration); err != nil {
+			return err
+		}
 		if c, err := registry.ResolveConfig(o.resolver, configuration); err != nil {
 			return err
 		} else if err := validator.IsValidResolvedConfiguration(&c); err != nil {
@@ -173,6 +178,24 @@ func (o *options) validateConfiguration(
 	return nil
 }
 
+// validatePinnedVersions verifies that the registry versions tests are pinned to
+// still exist, warning about those which are deprecated
+func (o *options) validatePinnedVersions(configuration api.ReleaseBuildConfiguration) error {
+	for _, test := range configuration.Tests {
+		if test.MultiStageTestConfiguration == nil {
+			continue
+		}
+		deprecations, err := registry.ValidatePinnedVersions(o.versions, *test.MultiStageTestConfiguration)
+		if err != nil {
+			return fmt.Errorf("test %s: %w", test.As, err)
+		}
+		for _, deprecation := range deprecations {
+			logrus.WithField("config", configuration.Metadata.RelativePath()).WithField("test", test.As).Warn(deprecation)
+		}
+	}
+	return nil
+}
+
 func validateTags(seen tagSet) []error {
 	var dupes []error
 	for tag, infos := range seen {
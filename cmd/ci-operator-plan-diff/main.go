@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/diffs"
+	"github.com/openshift/ci-tools/pkg/load"
+)
+
+type options struct {
+	releaseRepoPath string
+	baseRef         string
+	headRef         string
+	pullRequest     int
+	remote          string
+
+	org     string
+	repo    string
+	branch  string
+	variant string
+
+	output string
+}
+
+func gatherOptions() (*options, error) {
+	o := &options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.releaseRepoPath, "release-repo", ".", "Path to a git clone of openshift/release.")
+	fs.StringVar(&o.baseRef, "base-ref", "HEAD", "Revision of the release repository to compare against.")
+	fs.StringVar(&o.headRef, "head-ref", "", "Revision of the release repository with the changes. Mutually exclusive with --pull-request.")
+	fs.IntVar(&o.pullRequest, "pull-request", 0, "Number of a pull request to the release repository to fetch and use as the head revision.")
+	fs.StringVar(&o.remote, "remote", "origin", "Remote to fetch the pull request from.")
+	fs.StringVar(&o.org, "org", "", "Only compare configurations for this organization.")
+	fs.StringVar(&o.repo, "repo", "", "Only compare configurations for this repository.")
+	fs.StringVar(&o.branch, "branch", "", "Only compare configurations for this branch.")
+	fs.StringVar(&o.variant, "variant", "", "Only compare configurations for this variant.")
+	fs.StringVar(&o.output, "output", "text", "Output format, one of: text, json.")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return nil, fmt.Errorf("failed to parse flags: %w", err)
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	if o.headRef != "" && o.pullRequest != 0 {
+		return errors.New("--head-ref and --pull-request are mutually exclusive")
+	}
+	if o.headRef == "" && o.pullRequest == 0 {
+		return errors.New("one of --head-ref or --pull-request is required")
+	}
+	if o.output != "text" && o.output != "json" {
+		return fmt.Errorf("invalid --output %q, must be one of: text, json", o.output)
+	}
+	return nil
+}
+
+func git(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, string(out))
+	}
+	return nil
+}
+
+// loadRevision checks out the revision into a temporary worktree and loads
+// ci-operator configuration and the step registry from it.
+func (o *options) loadRevision(ref string) (diffs.Revision, error) {
+	var revision diffs.Revision
+	dir, err := os.MkdirTemp("", "plan-diff")
+	if err != nil {
+		return revision, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() {
+		if err := git(o.releaseRepoPath, "worktree", "remove", "--force", dir); err != nil {
+			logrus.WithError(err).Warn("Failed to remove temporary worktree.")
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			logrus.WithError(err).Warn("Failed to remove temporary directory.")
+		}
+	}()
+	if err := git(o.releaseRepoPath, "worktree", "add", "--detach", dir, ref); err != nil {
+		return revision, fmt.Errorf("failed to check out %s: %w", ref, err)
+	}
+
+	configs, err := config.LoadDataByFilename(filepath.Join(dir, config.CiopConfigInRepoPath))
+	if err != nil {
+		return revision, fmt.Errorf("failed to load ci-operator configuration at %s: %w", ref, err)
+	}
+	revision.Configs = config.DataByFilename{}
+	for filename, data := range configs {
+		if o.matches(data.Info) {
+			revision.Configs[filename] = data
+		}
+	}
+
+	refs, chains, workflows, profiles, _, _, observers, err := load.Registry(filepath.Join(dir, config.RegistryPath), load.RegistryFlag(0))
+	if err != nil {
+		return revision, fmt.Errorf("failed to load step registry at %s: %w", ref, err)
+	}
+	revision.Registry = diffs.Registry{
+		References:      refs,
+		Chains:          chains,
+		Workflows:       workflows,
+		Observers:       observers,
+		ClusterProfiles: profiles,
+	}
+	return revision, nil
+}
+
+func (o *options) matches(info config.Info) bool {
+	return (o.org == "" || info.Org == o.org) &&
+		(o.repo == "" || info.Repo == o.repo) &&
+		(o.branch == "" || info.Branch == o.branch) &&
+		(o.variant == "" || info.Variant == o.variant)
+}
+
+func main() {
+	o, err := gatherOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to gather options")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+
+	headRef := o.headRef
+	if o.pullRequest != 0 {
+		if err := git(o.releaseRepoPath, "fetch", o.remote, fmt.Sprintf("pull/%d/head", o.pullRequest)); err != nil {
+			logrus.WithError(err).Fatalf("failed to fetch pull request %d", o.pullRequest)
+		}
+		headRef = "FETCH_HEAD"
+	}
+
+	base, err := o.loadRevision(o.baseRef)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load base revision")
+	}
+	head, err := o.loadRevision(headRef)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load head revision")
+	}
+
+	diff, err := diffs.DiffPlans(base, head)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to compare execution plans")
+	}
+	switch o.output {
+	case "json":
+		raw, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to marshal plan differences")
+		}
+		fmt.Println(string(raw))
+	default:
+		if err := diff.WriteText(os.Stdout); err != nil {
+			logrus.WithError(err).Fatal("failed to write plan differences")
+		}
+	}
+	if len(diff.Errors) > 0 {
+		logrus.Fatalf("failed to compare execution plans of %d configurations", len(diff.Errors))
+	}
+}
@@ -167,13 +167,17 @@ func main() {
 	}
 	// "i just don't want spam"
 	klog.LogToStderr(false)
-	logrus.Infof("%s version %s", version.Name, version.Version)
 	flagSet := flag.NewFlagSet("", flag.ExitOnError)
 	opt := bindOptions(flagSet)
 	opt.censor = censor
 	if err := flagSet.Parse(os.Args[1:]); err != nil {
 		logrus.WithError(err).Fatal("failed to parse flags")
 	}
+	if opt.dryRunPlan != "" {
+		// the plan is printed to stdout and must remain machine-parseable
+		consoleHook.writer = os.Stderr
+	}
+	logrus.Infof("%s version %s", version.Name, version.Version)
 
 	ctrlruntimelog.SetLogger(logr.New(ctrlruntimelog.NullLogSink{}))
 	if opt.verbose {
@@ -221,11 +225,14 @@ func main() {
 	}
 
 	ctx := context.TODO()
-	opt.metricsAgent, err = metrics.NewMetricsAgent(ctx, opt.clusterConfig, opt.censor)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to create metrics agent...Skipping metrics.")
-	} else {
-		go opt.metricsAgent.Run()
+	// no metrics are collected when only printing the execution plan
+	if opt.dryRunPlan == "" {
+		opt.metricsAgent, err = metrics.NewMetricsAgent(ctx, opt.clusterConfig, opt.censor)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to create metrics agent...Skipping metrics.")
+		} else {
+			go opt.metricsAgent.Run()
+		}
 	}
 
 	opt.metricsAgent.Record(metrics.NewInsightsEvent(metrics.InsightStarted, metrics.Context{"job_spec": opt.jobSpec.MetricsData()}))
@@ -242,20 +249,30 @@ func main() {
 		logrus.Error("Some steps failed:")
 		logrus.Error(message.String())
 
-		opt.Report(defaulted...)
+		// nothing ran when only printing the execution plan, so there is nothing to report
+		if opt.dryRunPlan == "" {
+			opt.Report(defaulted...)
+		}
 
 		os.Exit(1)
 	}
+	if opt.dryRunPlan != "" {
+		return
+	}
 	opt.Report()
 }
 
+// consoleHook writes user-friendly logs to stdout, or to stderr when stdout
+// is reserved for the execution plan
+var consoleHook *formattingHook
+
 // setupLogger sets up logrus to print all logs to a file and user-friendly logs to stdout
 func setupLogger() (*secrets.DynamicCensor, io.Closer, error) {
 	logrus.SetLevel(logrus.TraceLevel)
 	censor := secrets.NewDynamicCensor()
 	logrus.SetFormatter(logrusutil.NewFormatterWithCensor(logrus.StandardLogger().Formatter, &censor))
 	logrus.SetOutput(io.Discard)
-	logrus.AddHook(&formattingHook{
+	consoleHook = &formattingHook{
 		formatter: logrusutil.NewFormatterWithCensor(&logrus.TextFormatter{
 			ForceColors:     true,
 			DisableQuote:    true,
@@ -270,7 +287,8 @@ func setupLogger() (*secrets.DynamicCensor, io.Closer, error) {
 			logrus.FatalLevel,
 			logrus.PanicLevel,
 		},
-	})
+	}
+	logrus.AddHook(consoleHook)
 	artifactDir, set := api.Artifacts()
 	if !set {
 		return &censor, nil, nil
@@ -335,6 +353,8 @@ type options struct {
 	verbose    bool
 	help       bool
 	printGraph bool
+	dryRunPlan string
+	resume     bool
 
 	writeParams string
 	artifactDir string
@@ -360,6 +380,8 @@ type options struct {
 	leaseServer                string
 	leaseServerCredentialsFile string
 	leaseAcquireTimeout        time.Duration
+	leasePriorityClasses       string
+	leasePriority              lease.PriorityPolicy
 	leaseClient                lease.Client
 	clusterProfiles            []metrics.ClusterProfileForTarget
 
@@ -370,6 +392,8 @@ type options struct {
 	resolverAddress string
 	resolverClient  server.ResolverClient
 
+	quarantineAddress string
+
 	registryPath string
 	org          string
 	repo         string
@@ -432,14 +456,18 @@ func bindOptions(flag *flag.FlagSet) *options {
 	// what we will run
 	flag.StringVar(&opt.nodeName, "node", "", "Restrict scheduling of pods to a single node in the cluster. Does not afffect indirectly created pods (e.g. builds).")
 	flag.DurationVar(&opt.podPendingTimeout, "pod-pending-timeout", 60*time.Minute, "Maximum amount of time created pods can spend before the running state. For test pods, this applies to each container. For builds, it applies to the build execution as a whole.")
-	flag.StringVar(&opt.leaseServer, "lease-server", leaseServerAddress, "Address of the server that manages leases. Required if any test is configured to acquire a lease.")
+	flag.StringVar(&opt.leaseServer, "lease-server", leaseServerAddress, "Address of the server that manages leases. Required if any test is configured to acquire a lease. Use file://<path> to manage leases in a local state file or configmap://<namespace>/<name> to manage them in a ConfigMap on the build cluster, neither of which require credentials.")
 	flag.StringVar(&opt.leaseServerCredentialsFile, "lease-server-credentials-file", "", "The path to credentials file used to access the lease server. The content is of the form <username>:<password>.")
 	flag.DurationVar(&opt.leaseAcquireTimeout, "lease-acquire-timeout", leaseAcquireTimeout, "Maximum amount of time to wait for lease acquisition")
+	flag.StringVar(&opt.leasePriorityClasses, "lease-priority-classes", "", "Priorities of lease requests by type of job, in the <type>=<priority>,... form. Requests of jobs with a higher priority are served first. Defaults to presubmit=3,batch=3,postsubmit=2,periodic=1.")
+	flag.DurationVar(&opt.leasePriority.Deferral, "lease-priority-deferral", 0, "How long requests to the lease server wait for every priority level below the highest one, and once more when the organization holds its fair share of the resources, before competing for scarce resources regardless. Defaults to 5m.")
 	flag.StringVar(&opt.registryPath, "registry", "", "Path to the step registry directory")
 	flag.StringVar(&opt.configSpecPath, "config", "", "The configuration file. If not specified the CONFIG_SPEC environment variable or the configresolver will be used.")
 	flag.StringVar(&opt.unresolvedConfigPath, "unresolved-config", "", "The configuration file, before resolution. If not specified the UNRESOLVED_CONFIG environment variable will be used, if set.")
 	flag.Var(&opt.targets, "target", "One or more targets in the configuration to build. Only steps that are required for this target will be run.")
 	flag.BoolVar(&opt.printGraph, "print-graph", opt.printGraph, "Print a directed graph of the build steps and exit. Intended for use with the golang digraph utility.")
+	flag.StringVar(&opt.dryRunPlan, "dry-run-plan", "", "Print the execution plan of the build steps for the targets in the given format (json or yaml) to stdout and exit, without touching a cluster. Logs are written to stderr.")
+	flag.BoolVar(&opt.resume, "resume", false, "Skip image builds that completed in a previous execution in the same namespace and whose images and provided parameters are still intact. Tests always run again.")
 
 	// add to the graph of things we run or create
 	flag.Var(&opt.secretDirectories, "secret-dir", "One or more directories that should converted into secrets in the test namespace. If the directory contains a single file with name .dockercfg or config.json it becomes a pull secret.")
@@ -474,6 +502,7 @@ func bindOptions(flag *flag.FlagSet) *options {
 
 	// flags needed for the configresolver
 	flag.StringVar(&opt.resolverAddress, "resolver-address", configResolverAddress, "Address of configresolver")
+	flag.StringVar(&opt.quarantineAddress, "quarantine-address", "", "Address of the flaky test quarantine service. If set, test steps mark the JUnit results of quarantined tests as informing and do not fail when only quarantined tests failed.")
 	flag.StringVar(&opt.org, "org", "", "Org of the project (used by configresolver)")
 	flag.StringVar(&opt.repo, "repo", "", "Repo of the project (used by configresolver)")
 	flag.StringVar(&opt.branch, "branch", "", "Branch of the project (used by configresolver)")
@@ -527,6 +556,12 @@ func (o *options) Complete() error {
 	o.jobSpec = jobSpec
 	o.jobSpec.Target = target
 
+	if o.leasePriorityClasses != "" {
+		if o.leasePriority.Classes, err = lease.ParsePriorityClasses(o.leasePriorityClasses); err != nil {
+			return fmt.Errorf("invalid --lease-priority-classes: %w", err)
+		}
+	}
+
 	info := o.getResolverInfo(jobSpec)
 	o.resolverClient = server.NewResolverClient(o.resolverAddress)
 
@@ -653,21 +688,29 @@ func (o *options) Complete() error {
 
 	o.getClusterProfileNamesFromTargets()
 
-	clusterConfig, err := util.LoadClusterConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load cluster config: %w", err)
-	}
+	switch o.dryRunPlan {
+	case "":
+		clusterConfig, err := util.LoadClusterConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load cluster config: %w", err)
+		}
 
-	if len(o.impersonateUser) > 0 {
-		clusterConfig.Impersonate = rest.ImpersonationConfig{UserName: o.impersonateUser}
-	}
+		if len(o.impersonateUser) > 0 {
+			clusterConfig.Impersonate = rest.ImpersonationConfig{UserName: o.impersonateUser}
+		}
 
-	if o.verbose {
-		clusterConfig.ContentType = "application/json"
-		clusterConfig.AcceptContentTypes = "application/json"
-	}
+		if o.verbose {
+			clusterConfig.ContentType = "application/json"
+			clusterConfig.AcceptContentTypes = "application/json"
+		}
 
-	o.clusterConfig = clusterConfig
+		o.clusterConfig = clusterConfig
+	case "json", "yaml":
+		// the plan is generated without ever touching a cluster
+		o.clusterConfig = &rest.Config{}
+	default:
+		return fmt.Errorf("--dry-run-plan must be one of json, yaml, not %q", o.dryRunPlan)
+	}
 
 	if o.pullSecretPath != "" {
 		if o.pullSecret, err = getDockerConfigSecret(api.RegistryPullCredentialsSecret, o.pullSecretPath); err != nil {
@@ -717,7 +760,64 @@ func (o *options) Complete() error {
 }
 
 func (o *options) isLeaseClientAvailable() bool {
-	return o.leaseServer != "" && o.leaseServerCredentialsFile != ""
+	return o.leaseServer != "" && (o.leaseServerCredentialsFile != "" || isLocalLeaseServer(o.leaseServer))
+}
+
+const (
+	fileLeaseServerPrefix      = "file://"
+	configMapLeaseServerPrefix = "configmap://"
+)
+
+// isLocalLeaseServer determines whether leases are managed by ci-operator
+// itself rather than by a Boskos server
+func isLocalLeaseServer(server string) bool {
+	return strings.HasPrefix(server, fileLeaseServerPrefix) || strings.HasPrefix(server, configMapLeaseServerPrefix)
+}
+
+// localLeaseBackend creates the lease backend for a file:// or configmap:// server
+func localLeaseBackend(owner, server string, clusterConfig *rest.Config, opts ...lease.LocalBackendOption) (lease.Backend, error) {
+	if path, ok := strings.CutPrefix(server, fileLeaseServerPrefix); ok {
+		if path == "" {
+			return nil, fmt.Errorf("invalid lease server %q: path is required", server)
+		}
+		return lease.NewFileBackend(owner, path, opts...), nil
+	}
+	namespace, name, ok := strings.Cut(strings.TrimPrefix(server, configMapLeaseServerPrefix), "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid lease server %q: expected %s<namespace>/<name>", server, configMapLeaseServerPrefix)
+	}
+	client, err := ctrlruntimeclient.New(clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for the lease ConfigMap: %w", err)
+	}
+	return lease.NewConfigMapBackend(owner, client, namespace, name, opts...), nil
+}
+
+// leaseOrg is the organization leases are requested for
+func (o *options) leaseOrg() string {
+	if o.jobSpec.Refs != nil {
+		return o.jobSpec.Refs.Org
+	} else if len(o.jobSpec.ExtraRefs) > 0 {
+		return o.jobSpec.ExtraRefs[0].Org
+	}
+	return ""
+}
+
+// leaseRequesterOptions identifies the job to the lease backend, which
+// prioritizes waiting requests by the type of job and the organization
+// it tests, and reports the position of requests in the queue as metrics
+func (o *options) leaseRequesterOptions() []lease.LocalBackendOption {
+	opts := []lease.LocalBackendOption{
+		lease.WithRequester(string(o.jobSpec.Type), o.leaseOrg()),
+		lease.WithQueuePositionReporter(func(rtype string, position, waiting int) {
+			logrus.Infof("Waiting for a %s lease, %d requests ahead of this one.", rtype, position)
+			o.metricsAgent.Record(&metrics.LeaseQueueMetricEvent{RawLeaseName: rtype, QueuePosition: position, QueueLength: waiting})
+		}),
+	}
+	if o.leasePriority.Classes != nil {
+		opts = append(opts, lease.WithPriorityClasses(o.leasePriority.Classes))
+	}
+	return opts
 }
 
 func (o *options) ToGraphConfig() *defaults.Config {
@@ -909,7 +1009,9 @@ func excludeContextCancelledErrors(errs []error) []error {
 func (o *options) Report(errs ...error) {
 	if len(errs) > 0 {
 		o.writeFailingJUnit(errs)
+		o.writeFailureRecord(errs)
 	}
+	o.censorArtifacts()
 
 	reporter, loadErr := o.resultsOptions.Reporter(o.jobSpec, o.consoleHost)
 	if loadErr != nil {
@@ -927,6 +1029,22 @@ func (o *options) Report(errs ...error) {
 	}
 }
 
+// censorArtifacts censors all secrets known to ci-operator in the artifacts
+// gathered from the test, as they will be uploaded once ci-operator exits
+func (o *options) censorArtifacts() {
+	artifactDir, set := api.Artifacts()
+	if !set || o.censor == nil {
+		return
+	}
+	redacted, err := o.censor.CensorArtifacts(artifactDir)
+	if err != nil {
+		logrus.WithError(err).Warn("Could not censor secrets in artifacts.")
+	}
+	for _, file := range redacted {
+		logrus.Warnf("Secrets were censored in artifact %s.", file)
+	}
+}
+
 // startHTTPServer start the HTTP server asynchronously and returns the address it
 // is listening to.
 func (o *options) startHTTPServer(cancel func(), srv *http.Server) (string, error) {
@@ -954,6 +1072,9 @@ func (o *options) startHTTPServer(cancel func(), srv *http.Server) (string, erro
 }
 
 func (o *options) Run() (errs []error) {
+	if o.dryRunPlan != "" {
+		return o.printPlan(context.Background(), os.Stdout)
+	}
 	start := time.Now()
 	var httpSrv *http.Server
 
@@ -1039,6 +1160,7 @@ func (o *options) Run() (errs []error) {
 	cfg.IntegratedStreams = streams
 	cfg.InjectedTest = o.injectTest != ""
 	cfg.GSMConfig = gsmConfig
+	cfg.QuarantineAddress = o.quarantineAddress
 	cfg.HTTPServerAddr = httpSrvAddr
 	cfg.HTTPServerMux = httpSrvMux
 	// load the graph from the configuration
@@ -1106,6 +1228,11 @@ func (o *options) Run() (errs []error) {
 		return
 	}
 	o.metricsAgent.Record(metrics.NewInsightsEvent(metrics.InsightNamespaceCreated, metrics.Context{"namespace": o.namespace}))
+	checkpointer, err := o.initializeCheckpointer(ctx)
+	if err != nil {
+		errs = append(errs, results.ForReason("initializing_namespace").WithError(err).Errorf("could not load checkpoint: %v", err))
+		return
+	}
 	info := o.getResolverInfo(o.jobSpec)
 	o.metricsAgent.RecordConfigurationInsight(o.targets.values, o.promote, info.Org, info.Repo, info.Branch, info.Variant, o.baseNamespace, o.consoleHost, o.nodeName, o.clusterProfiles)
 
@@ -1141,7 +1268,7 @@ func (o *options) Run() (errs []error) {
 		eventRecorder.Event(runtimeObject, coreapi.EventTypeNormal, "CiJobStarted", eventJobDescription(o.jobSpec, o.namespace))
 		o.metricsAgent.Record(metrics.NewInsightsEvent(metrics.InsightExecutionStarted, metrics.Context{"started_after": time.Since(start).Seconds()}))
 		// execute the graph
-		suites, graphDetails, errs := steps.Run(ctx, nodes, o.metricsAgent)
+		suites, graphDetails, errs := steps.Run(ctx, nodes, o.metricsAgent, checkpointer)
 		if err := o.writeJUnit(suites, "operator"); err != nil {
 			logrus.WithError(err).Warn("Unable to write JUnit result.")
 		}
@@ -1189,6 +1316,46 @@ func (o *options) Run() (errs []error) {
 	return
 }
 
+// printPlan resolves the execution graph for the targets and prints the plan
+// of every step in it, without creating anything in a cluster.
+func (o *options) printPlan(ctx context.Context, w io.Writer) []error {
+	// the namespace of an execution is derived from a hash of its inputs,
+	// a placeholder keeps plans of different revisions comparable
+	namespace := o.namespace
+	if namespace == "" {
+		namespace = "ci-op-{id}"
+	}
+	o.jobSpec.SetNamespace(strings.Replace(namespace, "{id}", "dry-run", -1))
+	cfg := o.ToGraphConfig()
+	cfg.DryRun = true
+	buildSteps, promotionSteps, err := defaults.FromConfig(ctx, cfg)
+	if err != nil {
+		return []error{results.ForReason("defaulting_config").WithError(err).Errorf("failed to generate steps from config: %v", err)}
+	}
+	nodes, err := api.BuildPartialGraph(buildSteps, o.targets.values)
+	if err != nil {
+		return []error{results.ForReason("building_graph").WithError(err).Errorf("could not build execution graph: %v", err)}
+	}
+	stepList, sortErrs := nodes.TopologicalSort()
+	if len(sortErrs) > 0 {
+		return append([]error{results.ForReason("building_graph").ForError(errors.New("could not sort nodes"))}, sortErrs...)
+	}
+	plan := steps.Plan(o.targets.values, stepList, promotionSteps)
+	var raw []byte
+	if o.dryRunPlan == "yaml" {
+		raw, err = yaml.Marshal(plan)
+	} else {
+		raw, err = json.MarshalIndent(plan, "", "  ")
+	}
+	if err != nil {
+		return []error{fmt.Errorf("could not marshal plan: %w", err)}
+	}
+	if _, err := fmt.Fprintln(w, string(raw)); err != nil {
+		return []error{fmt.Errorf("could not print plan: %w", err)}
+	}
+	return nil
+}
+
 // determineSkippedImages determines which images can be skipped when
 // build_images_if_affected is enabled and the [images] target is requested.
 func determineSkippedImages(config *api.ReleaseBuildConfiguration, jobSpec *api.JobSpec, targets []string) sets.Set[string] {
@@ -1383,6 +1550,21 @@ func (o *options) resolveInputs(steps []api.Step) error {
 	return nil
 }
 
+// initializeCheckpointer loads the completion state of steps persisted in the
+// namespace by previous executions, used to resume interrupted runs. No state
+// is loaded or persisted unless resuming was requested.
+func (o *options) initializeCheckpointer(ctx context.Context) (steps.Checkpointer, error) {
+	if !o.resume {
+		return nil, nil
+	}
+	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("could not get client for cluster config: %w", err)
+	}
+	logrus.Info("Resuming execution, images built in a previous execution will not be built again.")
+	return steps.NewCheckpointer(ctx, client, o.namespace)
+}
+
 func (o *options) initializeNamespace() error {
 	// We have to keep the project client because it return a project for a projectCreationRequest, ctrlruntimeclient can not do dark magic like that
 	projectGetter, err := projectclientset.NewForConfig(o.clusterConfig)
@@ -1995,6 +2177,18 @@ func (o *options) writeFailingJUnit(errs []error) {
 	}
 }
 
+// writeFailureRecord saves the classified failures of the job as an artifact
+func (o *options) writeFailureRecord(errs []error) {
+	data, err := json.MarshalIndent(results.NewFailureRecord(o.jobSpec, o.consoleHost, errs...), "", "  ")
+	if err != nil {
+		logrus.WithError(err).Warn("Could not marshal failure record.")
+		return
+	}
+	if err := api.SaveArtifact(o.censor, results.FailuresFilename, data); err != nil {
+		logrus.Trace("Unable to write failure record artifact")
+	}
+}
+
 func (o *options) writeJUnit(suites *junit.TestSuites, name string) error {
 	if suites == nil {
 		return nil
@@ -2086,6 +2280,14 @@ func loadLeaseCredentials(leaseServerCredentialsFile string) (string, func() []b
 func (o *options) initializeLeaseClient() error {
 	var err error
 	owner := o.namespace + "-" + o.jobSpec.UniqueHash()
+	if isLocalLeaseServer(o.leaseServer) {
+		backend, err := localLeaseBackend(owner, o.leaseServer, o.clusterConfig, o.leaseRequesterOptions()...)
+		if err != nil {
+			return err
+		}
+		o.leaseClient = lease.NewClientWithBackend(backend, 60, o.leaseAcquireTimeout)
+		return nil
+	}
 	username, passwordGetter, err := loadLeaseCredentials(o.leaseServerCredentialsFile)
 	if err != nil {
 		return fmt.Errorf("failed to load lease credentials: %w", err)
@@ -2093,7 +2295,15 @@ func (o *options) initializeLeaseClient() error {
 
 	o.metricsAgent.Record(metrics.NewInsightsEvent(metrics.InsightLeaseCredentials, metrics.Context{"lease_server": o.leaseServer, "username": username}))
 
-	if o.leaseClient, err = lease.NewClient(owner, o.leaseServer, username, passwordGetter, 60, o.leaseAcquireTimeout); err != nil {
+	// the lease server serves requests in order, the client defers those of
+	// lower priority and identifies the organization in the owner so that
+	// other clients can share resources fairly
+	org := o.leaseOrg()
+	o.leasePriority.ReportDeferral = func(rtype string, deferred time.Duration) {
+		logrus.Infof("Deferred the request for a %s lease by %s for requests of higher priority.", rtype, deferred.Round(time.Second))
+		o.metricsAgent.Record(&metrics.LeaseQueueMetricEvent{RawLeaseName: rtype, QueuePosition: -1, DeferralSeconds: deferred.Seconds()})
+	}
+	if o.leaseClient, err = lease.NewClient(lease.OwnerForOrg(owner, org), o.leaseServer, username, passwordGetter, 60, o.leaseAcquireTimeout, lease.WithPriority(string(o.jobSpec.Type), org, o.leasePriority)); err != nil {
 		return fmt.Errorf("failed to create the lease client: %w", err)
 	}
 	return nil
@@ -2531,7 +2741,8 @@ func (o *options) loadConfig(info *api.Metadata, gcsReader gcsFileReader) (*api.
 		if err != nil {
 			return nil, fmt.Errorf("failed to load registry: %w", err)
 		}
-		configSpec, err = registry.ResolveConfig(registry.NewResolver(refs, chains, workflows, observers, clusterProfiles), configSpec)
+		resolver := registry.NewVersionedResolver(refs, chains, workflows, observers, clusterProfiles, load.NewGitRegistryVersions(o.registryPath, load.RegistryFlag(0)))
+		configSpec, err = registry.ResolveConfig(resolver, configSpec)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve configuration: %w", err)
 		}
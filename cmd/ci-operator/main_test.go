@@ -17,6 +17,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/utils/diff"
 	"k8s.io/utils/pointer"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -1174,6 +1175,48 @@ func TestLoadLeaseCredentials(t *testing.T) {
 	}
 }
 
+func TestLocalLeaseBackend(t *testing.T) {
+	testCases := []struct {
+		name        string
+		server      string
+		expectedErr error
+	}{
+		{
+			name:   "file",
+			server: "file:///tmp/leases.json",
+		},
+		{
+			name:        "file without path",
+			server:      "file://",
+			expectedErr: errors.New(`invalid lease server "file://": path is required`),
+		},
+		{
+			name:        "ConfigMap without name",
+			server:      "configmap://ci",
+			expectedErr: errors.New(`invalid lease server "configmap://ci": expected configmap://<namespace>/<name>`),
+		},
+		{
+			name:        "ConfigMap with extra path elements",
+			server:      "configmap://ci/leases/extra",
+			expectedErr: errors.New(`invalid lease server "configmap://ci/leases/extra": expected configmap://<namespace>/<name>`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if !isLocalLeaseServer(tc.server) {
+				t.Errorf("expected %s to be a local lease server", tc.server)
+			}
+			_, err := localLeaseBackend("owner", tc.server, &rest.Config{})
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+	if isLocalLeaseServer("https://boskos.example.com") {
+		t.Error("expected a Boskos address not to be a local lease server")
+	}
+}
+
 func TestExcludeContextCancelledErrors(t *testing.T) {
 	testCases := []struct {
 		id       string
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/secretusage"
+)
+
+type options struct {
+	config.Options
+
+	bootstrapConfigPath string
+	gsmConfigPath       string
+	registryPath        string
+
+	rotateRaw flagutil.Strings
+	rotate    []secretusage.Node
+	unused    bool
+}
+
+func parseOptions() (*options, error) {
+	o := &options{}
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	fs.StringVar(&o.bootstrapConfigPath, "bootstrap-config", "", "Path to the ci-secret-bootstrap config file.")
+	fs.StringVar(&o.gsmConfigPath, "gsm-config", "", "Path to the Google Secret Manager config file.")
+	fs.StringVar(&o.registryPath, "registry", "", "Path to the step registry directory.")
+	fs.Var(&o.rotateRaw, "rotate", "Report everything that consumes this secret, as <kind>:<name>, e.g. vault:<item>/<field>, gsm:<collection>/<group>/<field> or secret:<namespace>/<name>. The field can be omitted to match all of them. Can be passed multiple times.")
+	fs.BoolVar(&o.unused, "unused", false, "Report the Vault and GSM fields which no step mounts.")
+	o.Options.Bind(fs)
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return nil, fmt.Errorf("failed to parse flags: %w", err)
+	}
+	return o, o.validate()
+}
+
+func (o *options) validate() error {
+	if o.bootstrapConfigPath == "" && o.gsmConfigPath == "" {
+		return errors.New("at least one of --bootstrap-config and --gsm-config is required")
+	}
+	if o.registryPath == "" {
+		return errors.New("--registry is required")
+	}
+	if len(o.rotateRaw.Strings()) == 0 && !o.unused {
+		return errors.New("at least one of --rotate and --unused is required")
+	}
+	for _, raw := range o.rotateRaw.Strings() {
+		node, err := secretusage.ParseNode(raw)
+		if err != nil {
+			return fmt.Errorf("invalid --rotate: %w", err)
+		}
+		o.rotate = append(o.rotate, node)
+	}
+	if err := o.Options.Validate(); err != nil {
+		return err
+	}
+	return o.Options.Complete()
+}
+
+func (o *options) loadGraph() (*secretusage.Graph, error) {
+	var bootstrap *secretbootstrap.Config
+	if o.bootstrapConfigPath != "" {
+		bootstrap = &secretbootstrap.Config{}
+		if err := secretbootstrap.LoadConfigFromFile(o.bootstrapConfigPath, bootstrap); err != nil {
+			return nil, fmt.Errorf("failed to load ci-secret-bootstrap config: %w", err)
+		}
+	}
+	var gsm *api.GSMConfig
+	if o.gsmConfigPath != "" {
+		gsm = &api.GSMConfig{}
+		if err := api.LoadGSMConfigFromFile(o.gsmConfigPath, gsm); err != nil {
+			return nil, fmt.Errorf("failed to load GSM config: %w", err)
+		}
+	}
+	refs, chains, workflows, clusterProfiles, _, _, observers, err := load.Registry(o.registryPath, load.RegistryFlag(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry: %w", err)
+	}
+	resolver := registry.NewResolver(refs, chains, workflows, observers, clusterProfiles)
+	var usages []secretusage.StepUsage
+	if err := o.OperateOnCIOperatorConfigDir(o.ConfigDir, func(configuration *api.ReleaseBuildConfiguration, info *config.Info) error {
+		resolved, err := registry.ResolveConfig(resolver, *configuration)
+		if err != nil {
+			logrus.WithError(err).WithFields(info.LogFields()).Warn("Failed to resolve configuration, ignoring it.")
+			return nil
+		}
+		usages = append(usages, secretusage.StepUsagesForTests(info.Metadata, resolved.Tests)...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to load ci-operator configuration: %w", err)
+	}
+	return secretusage.NewGraph(bootstrap, gsm, refs, usages), nil
+}
+
+func printImpact(out io.Writer, graph *secretusage.Graph, query secretusage.Node) {
+	matched := graph.Match(query)
+	if len(matched) == 0 {
+		fmt.Fprintf(out, "%s is not known\n\n", query)
+		return
+	}
+	var names []string
+	for _, node := range matched {
+		names = append(names, node.String())
+	}
+	impact := graph.Impact(matched...)
+	fmt.Fprintf(out, "Rotating %s affects:\n", strings.Join(names, ", "))
+	fmt.Fprintf(out, "  %d bundles\n", len(impact.Bundles))
+	for _, bundle := range impact.Bundles {
+		fmt.Fprintf(out, "    %s\n", bundle)
+	}
+	fmt.Fprintf(out, "  %d secrets\n", len(impact.Secrets))
+	for _, secret := range sortedKeys(impact.Secrets) {
+		fmt.Fprintf(out, "    %s on clusters: %s\n", secret, strings.Join(impact.Secrets[secret], ", "))
+	}
+	fmt.Fprintf(out, "  %d steps\n", len(impact.Steps))
+	for _, step := range impact.Steps {
+		fmt.Fprintf(out, "    %s\n", step)
+	}
+	fmt.Fprintf(out, "  %d jobs\n", len(impact.Jobs))
+	for _, job := range impact.Jobs {
+		fmt.Fprintf(out, "    %s\n", job)
+	}
+	fmt.Fprintln(out)
+}
+
+func printUnused(out io.Writer, graph *secretusage.Graph) {
+	unused := graph.Unused()
+	fmt.Fprintf(out, "%d fields are not mounted by any step, they may still be used outside of jobs:\n", len(unused))
+	for _, node := range unused {
+		var secrets []string
+		for secret, clusters := range graph.Impact(node).Secrets {
+			secrets = append(secrets, fmt.Sprintf("%s (%s)", secret, strings.Join(clusters, ", ")))
+		}
+		if len(secrets) == 0 {
+			fmt.Fprintf(out, "  %s\n", node)
+			continue
+		}
+		sort.Strings(secrets)
+		fmt.Fprintf(out, "  %s synced to %s\n", node, strings.Join(secrets, ", "))
+	}
+}
+
+func sortedKeys(m map[string][]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func main() {
+	logrusutil.ComponentInit()
+	o, err := parseOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid options.")
+	}
+	graph, err := o.loadGraph()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to build the secret usage graph.")
+	}
+	for _, query := range o.rotate {
+		printImpact(os.Stdout, graph, query)
+	}
+	if o.unused {
+		printUnused(os.Stdout, graph)
+	}
+}
@@ -8,12 +8,14 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -26,11 +28,16 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/prow/pkg/flagutil"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/quarantine"
+	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/util"
 )
 
@@ -82,8 +89,12 @@ type options struct {
 	rwKubeconfig     bool
 	uploadKubeconfig bool
 	updateSharedDir  bool
+	secretDirs       flagutil.Strings
 	cmd              []string
 	client           coreclientset.SecretInterface
+
+	quarantineAddress string
+	quarantineClient  quarantine.Client
 }
 
 func bindOptions(flag *flag.FlagSet) *options {
@@ -92,6 +103,8 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.waitPath, "wait-for-file", "", "Wait for a file to appear at this path before starting the program")
 	flag.StringVar(&opt.waitTimeoutStr, "wait-timeout", "", "Used with --wait-for-file, maximum wait time before starting the program")
 	flag.StringVar(&opt.mode, "mode", manageKubeconfigMode, fmt.Sprintf("Set how kubeconfig should be managed. Allowed values are: %s, %s or %s", manageKubeconfigMode, skipKubeconfigMode, observerMode))
+	flag.Var(&opt.secretDirs, "censor-secret-dir", "Censor the content of files in this directory from artifacts. Can be passed multiple times.")
+	flag.StringVar(&opt.quarantineAddress, "quarantine-address", "", "Address of the flaky test quarantine service. If set, quarantined tests are marked as informing in the JUnit artifacts and the step does not fail when only they failed.")
 	return opt
 }
 
@@ -125,6 +138,9 @@ func (o *options) complete() error {
 	if err := o.validateMode(); err != nil {
 		return err
 	}
+	if o.quarantineAddress != "" {
+		o.quarantineClient = quarantine.NewClient(o.quarantineAddress, &http.Client{})
+	}
 
 	if !o.dry && o.mode != skipKubeconfigMode {
 		var err error
@@ -169,13 +185,24 @@ func (o *options) run() (exitCode int, err error) {
 	if o.uploadKubeconfig {
 		go uploadKubeconfig(ctx, o.client, o.name, o.dstPath, o.dry)
 	}
-	if exitCode, err = o.execCmd(); err != nil {
-		errs = append(errs, fmt.Errorf("failed to execute wrapped command: %w", err))
-	}
+	exitCode, cmdErr := o.execCmd()
 	// we will upload the secret from the post-execution state, so we know
 	// that the best-effort upload of the kubeconfig can exit now and so as
 	// not to race with the post-execution one
 	cancel()
+	var artifactErrs []error
+	if artifactDir, set := os.LookupEnv("ARTIFACT_DIR"); set && artifactDir != "" {
+		if err := o.censorArtifacts(artifactDir); err != nil {
+			artifactErrs = append(artifactErrs, fmt.Errorf("failed to censor artifacts: %w", err))
+		}
+		if o.forgiveQuarantinedFailures(artifactDir, cmdErr) {
+			exitCode, cmdErr = 0, nil
+		}
+	}
+	if cmdErr != nil {
+		errs = append(errs, fmt.Errorf("failed to execute wrapped command: %w", cmdErr))
+	}
+	errs = append(errs, artifactErrs...)
 	if o.updateSharedDir {
 		if err := createSecret(o.client, o.name, o.dstPath, o.dry); err != nil {
 			errs = append(errs, fmt.Errorf("failed to create/update secret: %w", err))
@@ -185,6 +212,142 @@ func (o *options) run() (exitCode int, err error) {
 	return exitCode, utilerrors.NewAggregate(errs)
 }
 
+// censorArtifacts censors the credentials the step had access to from its
+// artifacts before they are gathered: the contents of the secret directories,
+// the admin password and the credentials in kubeconfig files, including ones
+// created by the step in the shared directory
+func (o *options) censorArtifacts(artifactDir string) error {
+	if _, err := os.Stat(artifactDir); os.IsNotExist(err) {
+		return nil
+	}
+	censor := secrets.NewDynamicCensor()
+	var errs []error
+	for _, dir := range o.secretDirs.Strings() {
+		if err := addSecretsFromDir(&censor, dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, file := range []string{os.Getenv("KUBEADMIN_PASSWORD_FILE"), filepath.Join(o.dstPath, "kubeadmin-password")} {
+		if file == "" {
+			continue
+		}
+		if _, err := secrets.ReadFromFile(file, &censor); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	for _, file := range []string{os.Getenv("KUBECONFIG"), filepath.Join(o.dstPath, "kubeconfig"), filepath.Join(o.dstPath, "kubeconfig-minimal")} {
+		if err := addSecretsFromKubeconfig(&censor, file); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	redacted, err := censor.CensorArtifacts(artifactDir)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, file := range redacted {
+		fmt.Fprintf(os.Stderr, "secrets were censored in artifact %s\n", file)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// forgiveQuarantinedFailures determines whether the command failed only because
+// quarantined tests failed. Commands which were interrupted, killed by a signal
+// or exited with a code reserved for those fail the step regardless of their
+// JUnit artifacts, as the artifacts may not cover the tests they did not run.
+func (o *options) forgiveQuarantinedFailures(artifactDir string, cmdErr error) bool {
+	var exitErr *exec.ExitError
+	if o.quarantineClient == nil || !errors.As(cmdErr, &exitErr) {
+		return false
+	}
+	if !o.onlyQuarantinedTestsFailed(artifactDir) {
+		return false
+	}
+	if errors.Is(cmdErr, errInterrupted) || !exitedWithTestFailure(exitErr.ProcessState) {
+		fmt.Fprintf(os.Stderr, "the command did not exit normally (%v), the step fails\n", cmdErr)
+		return false
+	}
+	fmt.Fprintln(os.Stderr, "the step does not fail")
+	return true
+}
+
+// exitedWithTestFailure determines whether the process exited by itself with a
+// failure, rather than being killed by a signal or reporting one by an exit code
+// above 128, as shells do for the commands they run
+func exitedWithTestFailure(state *os.ProcessState) bool {
+	return state != nil && state.Exited() && state.ExitCode() > 0 && state.ExitCode() < 128
+}
+
+// onlyQuarantinedTestsFailed marks the quarantined tests as informing in the JUnit
+// artifacts and determines whether tests failed, but only quarantined ones. The
+// step does not fail then. Failing to reach the quarantine service leaves the
+// artifacts as they are.
+func (o *options) onlyQuarantinedTestsFailed(artifactDir string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	list, err := o.quarantineClient.List(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not get the quarantined tests, their failures will fail the step: %v\n", err)
+		return false
+	}
+	results, err := quarantine.MarkFiles(artifactDir, list.Tests())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not mark quarantined tests in the JUnit artifacts: %v\n", err)
+		return false
+	}
+	if results.Marked > 0 {
+		fmt.Fprintf(os.Stderr, "marked %d results of quarantined tests as informing\n", results.Marked)
+	}
+	if !results.OnlyQuarantinedFailed() {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "only quarantined tests failed: %s\n", strings.Join(sets.List(results.QuarantinedFailures), ", "))
+	return true
+}
+
+func addSecretsFromDir(censor *secrets.DynamicCensor, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read secret directory %q: %w", dir, err)
+	}
+	for _, entry := range entries {
+		// secrets are mounted as symbolic links to the current version
+		if strings.HasPrefix(entry.Name(), "..") || entry.IsDir() {
+			continue
+		}
+		if _, err := secrets.ReadFromFile(filepath.Join(dir, entry.Name()), censor); err != nil {
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+	}
+	return nil
+}
+
+// addSecretsFromKubeconfig censors the credentials in a kubeconfig, which may
+// be written to artifacts in a different format than the kubeconfig itself
+func addSecretsFromKubeconfig(censor *secrets.DynamicCensor, path string) error {
+	if path == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read kubeconfig %q: %w", path, err)
+	}
+	censor.AddSecrets(string(raw))
+	kubeconfig, err := clientcmd.Load(raw)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %q: %w", path, err)
+	}
+	for _, authInfo := range kubeconfig.AuthInfos {
+		censor.AddSecrets(authInfo.Token, authInfo.Password, string(authInfo.ClientKeyData))
+	}
+	return nil
+}
+
 func loadClient(namespace string) (coreclientset.SecretInterface, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
@@ -268,6 +431,10 @@ func waitForFile(path string, timeout time.Duration) error {
 	}
 }
 
+// errInterrupted marks failures of commands the wrapper forwarded a signal to,
+// e.g. when the step timed out
+var errInterrupted = errors.New("interrupted")
+
 func (o *options) execCmd() (exitCode int, err error) {
 	argv := o.cmd
 	proc := exec.Command(argv[0], argv[1:]...)
@@ -296,12 +463,14 @@ func (o *options) execCmd() (exitCode int, err error) {
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	var interrupted atomic.Bool
 	go func() {
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case s := <-sig:
+				interrupted.Store(true)
 				fmt.Fprintf(os.Stderr, "received signal %d, forwarding\n", s)
 				if err := proc.Process.Signal(s); err != nil {
 					logrus.WithError(err).Error("Failed to forward signal")
@@ -311,6 +480,9 @@ func (o *options) execCmd() (exitCode int, err error) {
 	}()
 	// we have to Wait() for the process before we can call ExitCode()
 	err = proc.Wait()
+	if err != nil && interrupted.Load() {
+		err = fmt.Errorf("%w: %w", errInterrupted, err)
+	}
 	return proc.ProcessState.ExitCode(), err
 }
 
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+
+	"sigs.k8s.io/prow/pkg/config/secret"
+	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+
+	"github.com/openshift/ci-tools/pkg/jira"
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+	"github.com/openshift/ci-tools/pkg/pagerdutyutil"
+	"github.com/openshift/ci-tools/pkg/quarantine"
+)
+
+type options struct {
+	port        int
+	logLevel    string
+	gracePeriod time.Duration
+
+	stateFile       string
+	jobs            prowflagutil.Strings
+	criteria        quarantine.Criteria
+	refreshInterval time.Duration
+
+	dataCoordinates *jobrunaggregatorlib.BigQueryDataCoordinates
+	authentication  *jobrunaggregatorlib.GoogleAuthenticationFlags
+
+	fileIssues       bool
+	reporter         string
+	slackTokenPath   string
+	jiraOptions      prowflagutil.JiraOptions
+	pagerDutyOptions pagerdutyutil.Options
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	o := options{
+		dataCoordinates: jobrunaggregatorlib.NewBigQueryDataCoordinates(),
+		authentication:  jobrunaggregatorlib.NewGoogleAuthenticationFlags(),
+	}
+	fs.IntVar(&o.port, "port", 8080, "Port to serve the quarantine list on.")
+	fs.StringVar(&o.logLevel, "log-level", "info", "Level at which to log output.")
+	fs.DurationVar(&o.gracePeriod, "grace-period", 10*time.Second, "Grace period for server shutdown.")
+
+	fs.StringVar(&o.stateFile, "state-file", "", "Path to the file the quarantine list is persisted to.")
+	fs.Var(&o.jobs, "job", "A job whose aggregated test runs decide which tests are quarantined. May be repeated.")
+	fs.Float64Var(&o.criteria.MaxPassPercentage, "max-pass-percentage", 95, "Tests that pass less often than this percentage of their runs, but do pass, are quarantined.")
+	fs.IntVar(&o.criteria.MinRuns, "min-runs", 20, "The number of runs a test needs before it can be quarantined.")
+	fs.DurationVar(&o.refreshInterval, "refresh-interval", time.Hour, "How often the quarantine list is recomputed.")
+
+	fs.StringVar(&o.dataCoordinates.ProjectID, "bigquery-project", o.dataCoordinates.ProjectID, "The BigQuery project holding the aggregated test runs.")
+	fs.StringVar(&o.dataCoordinates.DataSetID, "bigquery-dataset", o.dataCoordinates.DataSetID, "The BigQuery dataset holding the aggregated test runs.")
+	fs.StringVar(&o.authentication.GoogleServiceAccountCredentialFile, "google-service-account-credential-file", "", "Location of a credential file described by https://cloud.google.com/docs/authentication/production")
+
+	fs.BoolVar(&o.fileIssues, "file-issues", true, "File a Jira issue for every newly quarantined test.")
+	fs.StringVar(&o.reporter, "reporter", "", "The Slack user ID the Jira issues are reported for.")
+	fs.StringVar(&o.slackTokenPath, "slack-token-path", "", "Path to the file containing the Slack token used to resolve the reporter.")
+	o.jiraOptions.AddFlags(fs)
+	o.pagerDutyOptions.AddFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		logrus.WithError(err).Fatal("Could not parse args.")
+	}
+	return o
+}
+
+func (o *options) Validate() error {
+	if _, err := logrus.ParseLevel(o.logLevel); err != nil {
+		return fmt.Errorf("invalid --log-level: %w", err)
+	}
+	if o.stateFile == "" {
+		return fmt.Errorf("--state-file is required")
+	}
+	if len(o.jobs.Strings()) == 0 {
+		return fmt.Errorf("at least one --job is required")
+	}
+	if o.criteria.MaxPassPercentage <= 0 || o.criteria.MaxPassPercentage > 100 {
+		return fmt.Errorf("--max-pass-percentage must be between 0 and 100")
+	}
+	if err := o.authentication.Validate(); err != nil {
+		return err
+	}
+	if !o.fileIssues {
+		return nil
+	}
+	if o.slackTokenPath == "" {
+		return fmt.Errorf("--slack-token-path is required to file issues")
+	}
+	if err := o.jiraOptions.Validate(false); err != nil {
+		return err
+	}
+	return o.pagerDutyOptions.Validate(false)
+}
+
+// refresher recomputes the quarantine list from the aggregated test runs of the jobs
+type refresher struct {
+	client   jobrunaggregatorlib.AggregationJobClient
+	jobs     []string
+	criteria quarantine.Criteria
+	store    *quarantine.Store
+
+	filer    jira.IssueFiler
+	reporter string
+}
+
+func (r *refresher) refresh(ctx context.Context) error {
+	now := time.Now()
+	var rows []jobrunaggregatorapi.AggregatedTestRunRow
+	for _, job := range r.jobs {
+		jobRows, err := r.client.ListAggregatedTestRunsForJob(ctx, "ByOneWeek", job, now)
+		if err != nil {
+			return fmt.Errorf("failed to list aggregated test runs of %s: %w", job, err)
+		}
+		rows = append(rows, jobRows...)
+	}
+	list := quarantine.Update(r.store.Get(), rows, r.criteria, now)
+	logger := logrus.WithField("quarantined", len(list.Entries))
+	var fileErr error
+	if r.filer != nil {
+		// persist the issues that were filed even if others could not be
+		fileErr = quarantine.FileIssues(r.filer, r.reporter, list, logger)
+	}
+	if err := r.store.Set(list); err != nil {
+		return err
+	}
+	logger.Info("Updated the quarantine list.")
+	return fileErr
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+	level, _ := logrus.ParseLevel(o.logLevel)
+	logrus.SetLevel(level)
+
+	store, err := quarantine.NewStore(o.stateFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Could not load the quarantine list.")
+	}
+
+	ctx := interrupts.Context()
+	bigQueryClient, err := o.authentication.NewBigQueryClient(ctx, o.dataCoordinates.ProjectID)
+	if err != nil {
+		logrus.WithError(err).Fatal("Could not initialize BigQuery client.")
+	}
+	r := &refresher{
+		client: jobrunaggregatorlib.NewRetryingCIDataClient(
+			jobrunaggregatorlib.NewCIDataClient(*o.dataCoordinates, bigQueryClient),
+		),
+		jobs:     o.jobs.Strings(),
+		criteria: o.criteria,
+		store:    store,
+		reporter: o.reporter,
+	}
+
+	if o.fileIssues {
+		if err := secret.Add(o.slackTokenPath); err != nil {
+			logrus.WithError(err).Fatal("Error starting secrets agent.")
+		}
+		jiraClient, err := o.jiraOptions.Client()
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not initialize Jira client.")
+		}
+		pagerDutyClient, err := o.pagerDutyOptions.Client()
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not initialize PagerDuty client.")
+		}
+		slackClient := slack.New(string(secret.GetSecret(o.slackTokenPath)))
+		r.filer, err = jira.NewIssueFiler(slackClient, jiraClient.JiraClient(), pagerDutyClient)
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not initialize Jira issue filer.")
+		}
+	}
+
+	interrupts.TickLiteral(func() {
+		if err := r.refresh(ctx); err != nil {
+			logrus.WithError(err).Error("Failed to refresh the quarantine list.")
+		}
+	}, o.refreshInterval)
+
+	server := &http.Server{
+		Addr:    ":" + strconv.Itoa(o.port),
+		Handler: quarantine.Handler(store),
+	}
+	interrupts.ListenAndServe(server, o.gracePeriod)
+	interrupts.WaitForGracefulShutdown()
+}
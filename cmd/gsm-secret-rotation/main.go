@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/option"
+
+	"sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+
+	gsm "github.com/openshift/ci-tools/pkg/gsm-secrets"
+	"github.com/openshift/ci-tools/pkg/secrets"
+)
+
+type options struct {
+	collection    string
+	group         string
+	field         string
+	canaryCluster string
+	clusters      flagutil.Strings
+	gracePeriod   time.Duration
+
+	generateCommand string
+	syncCommand     string
+	validateCommand string
+
+	stateFile                string
+	rollback                 bool
+	logLevel                 string
+	gcpServiceAccountKeyFile string
+}
+
+func parseOptions() options {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.collection, "collection", "", "Collection of the secret to rotate")
+	fs.StringVar(&o.group, "group", "", "Group of the secret to rotate")
+	fs.StringVar(&o.field, "field", "", "Field of the secret to rotate")
+	fs.StringVar(&o.canaryCluster, "canary-cluster", "", "Cluster which receives and validates the new version first")
+	fs.Var(&o.clusters, "cluster", "Cluster the new version is rolled out to after the canary cluster, can be passed multiple times")
+	fs.DurationVar(&o.gracePeriod, "grace-period", 24*time.Hour, "How long the previous version stays enabled after the rollout")
+	fs.StringVar(&o.generateCommand, "generate-command", "", "Shell command which prints the new version of the secret")
+	fs.StringVar(&o.syncCommand, "sync-command", "", "Shell command which syncs the version of the secret in $VERSION to the cluster in $CLUSTER")
+	fs.StringVar(&o.validateCommand, "validate-command", "", "Shell command which verifies the secret synced to the cluster in $CLUSTER works")
+	fs.StringVar(&o.stateFile, "state-file", "", "Path to the file recording the progress of the rotation, used to resume it")
+	fs.BoolVar(&o.rollback, "rollback", false, "Roll back the rotation recorded in the state file")
+	fs.StringVar(&o.logLevel, "log-level", "info", "log level")
+	fs.StringVar(&o.gcpServiceAccountKeyFile, "gcp-service-account-key-file", "", "path to GCP service account key file (JSON format)")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		logrus.WithError(err).Fatal("could not parse args")
+	}
+	return o
+}
+
+func (o *options) Validate() error {
+	for name, value := range map[string]string{
+		"collection":                   o.collection,
+		"group":                        o.group,
+		"field":                        o.field,
+		"canary-cluster":               o.canaryCluster,
+		"generate-command":             o.generateCommand,
+		"sync-command":                 o.syncCommand,
+		"validate-command":             o.validateCommand,
+		"state-file":                   o.stateFile,
+		"gcp-service-account-key-file": o.gcpServiceAccountKeyFile,
+	} {
+		if value == "" {
+			return fmt.Errorf("--%s is required", name)
+		}
+	}
+	if _, err := logrus.ParseLevel(o.logLevel); err != nil {
+		return fmt.Errorf("invalid log level specified: %w", err)
+	}
+	return nil
+}
+
+// runCommand runs a shell command against a cluster and returns what it printed
+func runCommand(ctx context.Context, command, cluster string, env ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", command)
+	cmd.Env = append(append(os.Environ(), fmt.Sprintf("CLUSTER=%s", cluster)), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%q failed: %w: %s", command, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+func (o *options) hooks(censor *secrets.DynamicCensor) gsm.RotationHooks {
+	return gsm.RotationHooks{
+		Generate: func(ctx context.Context) ([]byte, error) {
+			payload, err := runCommand(ctx, o.generateCommand, "")
+			if err != nil {
+				return nil, err
+			}
+			censor.AddSecrets(string(payload))
+			return payload, nil
+		},
+		Sync: func(ctx context.Context, cluster, version string) error {
+			_, err := runCommand(ctx, o.syncCommand, cluster, fmt.Sprintf("VERSION=%s", version))
+			return err
+		},
+		Validate: func(ctx context.Context, cluster string) error {
+			_, err := runCommand(ctx, o.validateCommand, cluster)
+			return err
+		},
+	}
+}
+
+func main() {
+	o := parseOptions()
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Failed to validate options")
+	}
+	censor := secrets.NewDynamicCensor()
+	level, _ := logrus.ParseLevel(o.logLevel)
+	logrus.SetLevel(level)
+	logrus.SetFormatter(logrusutil.NewFormatterWithCensor(&logrus.JSONFormatter{}, &censor))
+
+	gcpCredentials, err := secrets.ReadFromFile(o.gcpServiceAccountKeyFile, &censor)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to read GCP credentials")
+	}
+	config, err := gsm.GetConfigFromEnv()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to get GCP project configuration")
+	}
+
+	ctx := context.Background()
+	client, err := secretmanager.NewClient(ctx,
+		option.WithCredentialsJSON([]byte(gcpCredentials)),
+		option.WithQuotaProject(config.ProjectIdNumber))
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create secrets client")
+	}
+	defer client.Close()
+
+	state, err := gsm.LoadRotationState(o.stateFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load rotation state")
+	}
+	rotation := gsm.Rotation{
+		Collection:    o.collection,
+		Group:         o.group,
+		Field:         o.field,
+		CanaryCluster: o.canaryCluster,
+		Clusters:      o.clusters.Strings(),
+		GracePeriod:   o.gracePeriod,
+	}
+	rotator := gsm.NewRotator(client, config, rotation, o.hooks(&censor), func(state *gsm.RotationState) error {
+		return gsm.SaveRotationState(o.stateFile, state)
+	})
+	if o.rollback {
+		if err := rotator.Rollback(ctx, state); err != nil {
+			logrus.WithError(err).Fatal("Failed to roll back the rotation")
+		}
+		logrus.Info("Rotation was rolled back")
+		return
+	}
+	if err := rotator.Run(ctx, state); err != nil {
+		logrus.WithError(err).Fatal("Rotation failed")
+	}
+	logrus.WithField("phase", state.Phase).Info("Rotation finished for now")
+}
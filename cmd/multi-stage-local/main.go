@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/prow/pkg/flagutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/steps/multi_stage/local"
+)
+
+type options struct {
+	configPath        string
+	registryPath      string
+	target            string
+	runtime           string
+	images            flagutil.Strings
+	env               flagutil.Strings
+	credentialsDir    string
+	clusterProfileDir string
+	workDir           string
+}
+
+func gatherOptions() (*options, error) {
+	o := &options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.configPath, "config", "", "Path to the ci-operator configuration file.")
+	fs.StringVar(&o.registryPath, "registry", "", "Path to the step registry, required unless the test is literal.")
+	fs.StringVar(&o.target, "target", "", "Name of the multi-stage test to run.")
+	fs.StringVar(&o.runtime, "runtime", "podman", "Container runtime binary, must accept the arguments of `podman run`.")
+	fs.Var(&o.images, "image", "Pull spec for an image used by steps, as NAME=PULLSPEC, e.g. src=quay.io/org/src:latest or release:latest=quay.io/org/release:4.19. Can be passed multiple times.")
+	fs.Var(&o.env, "env", "Value for a step parameter, as NAME=VALUE, overriding the test configuration. Can be passed multiple times.")
+	fs.StringVar(&o.credentialsDir, "credentials-dir", "", "Directory with a <namespace>/<name> or <collection>/<group> directory for every credential the steps use.")
+	fs.StringVar(&o.clusterProfileDir, "cluster-profile-dir", "", "Directory mounted as the cluster profile.")
+	fs.StringVar(&o.workDir, "work-dir", "", "Directory for the shared directory, artifacts and jUnit of the test. Defaults to a temporary directory.")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return nil, fmt.Errorf("failed to parse flags: %w", err)
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	if o.configPath == "" {
+		return errors.New("--config is required")
+	}
+	if o.target == "" {
+		return errors.New("--target is required")
+	}
+	for _, value := range append(o.images.Strings(), o.env.Strings()...) {
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("invalid value %q, expected NAME=VALUE", value)
+		}
+	}
+	return nil
+}
+
+func keyValues(values []string) map[string]string {
+	ret := map[string]string{}
+	for _, value := range values {
+		key, val, _ := strings.Cut(value, "=")
+		ret[key] = val
+	}
+	return ret
+}
+
+// resolveTest loads the configuration and resolves the target test to its literal form
+func (o *options) resolveTest() (*api.MultiStageTestConfigurationLiteral, error) {
+	raw, err := os.ReadFile(o.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	var config api.ReleaseBuildConfiguration
+	if err := yaml.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if o.registryPath != "" {
+		refs, chains, workflows, profiles, _, _, observers, err := load.Registry(o.registryPath, load.RegistryFlag(0))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load registry: %w", err)
+		}
+		if config, err = registry.ResolveConfig(registry.NewResolver(refs, chains, workflows, observers, profiles), config); err != nil {
+			return nil, fmt.Errorf("failed to resolve configuration: %w", err)
+		}
+	}
+	for _, test := range config.Tests {
+		if test.As != o.target {
+			continue
+		}
+		if test.MultiStageTestConfigurationLiteral == nil {
+			return nil, fmt.Errorf("test %s is not a resolved multi-stage test, is --registry set?", o.target)
+		}
+		literal := test.MultiStageTestConfigurationLiteral
+		if literal.Environment == nil {
+			literal.Environment = api.TestEnvironment{}
+		}
+		for name, value := range keyValues(o.env.Strings()) {
+			literal.Environment[name] = value
+		}
+		return literal, nil
+	}
+	return nil, fmt.Errorf("test %s not found in configuration", o.target)
+}
+
+func main() {
+	o, err := gatherOptions()
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to gather options")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	literal, err := o.resolveTest()
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to determine test to run")
+	}
+	if o.workDir == "" {
+		if o.workDir, err = os.MkdirTemp("", "multi-stage-local"); err != nil {
+			logrus.WithError(err).Fatal("failed to create work directory")
+		}
+	}
+	logrus.Infof("Using work directory %s", o.workDir)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	executor := local.NewExecutor(local.NewCLIRuntime(o.runtime), local.Config{
+		Images:            keyValues(o.images.Strings()),
+		CredentialsDir:    o.credentialsDir,
+		ClusterProfileDir: o.clusterProfileDir,
+		WorkDir:           o.workDir,
+	})
+	suite, runErr := executor.Run(ctx, o.target, *literal)
+	if suite != nil {
+		out, err := xml.MarshalIndent(&junit.TestSuites{Suites: []*junit.TestSuite{suite}}, "", "  ")
+		if err != nil {
+			logrus.WithError(err).Error("could not marshal jUnit XML")
+		} else if err := os.WriteFile(filepath.Join(o.workDir, fmt.Sprintf("junit_%s.xml", o.target)), out, 0644); err != nil {
+			logrus.WithError(err).Error("could not write jUnit XML")
+		}
+	}
+	if runErr != nil {
+		logrus.WithError(runErr).Fatalf("test %s failed", o.target)
+	}
+	logrus.Infof("Test %s succeeded.", o.target)
+}
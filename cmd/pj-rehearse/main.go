@@ -176,6 +176,9 @@ type options struct {
 	queueTimeoutMinutes   int
 	handlerTimeoutMinutes int
 
+	jobFlakinessPath string
+	jobFlakiness     rehearse.JobFlakiness
+
 	gcsBucket          string
 	gcsCredentialsFile string
 	gcsBrowserPrefix   string
@@ -213,6 +216,7 @@ func gatherOptions() (options, error) {
 	fs.IntVar(&o.maxQueuedHandlers, "max-queued-handlers", 50, "Maximum number of webhook handler requests queued while all handler slots are busy.")
 	fs.IntVar(&o.queueTimeoutMinutes, "queue-timeout-minutes", 5, "Maximum time in minutes a request can wait in the queue before being dropped.")
 	fs.IntVar(&o.handlerTimeoutMinutes, "handler-timeout-minutes", 15, "Maximum time in minutes a handler can execute before being considered timed out.")
+	fs.StringVar(&o.jobFlakinessPath, "job-flakiness-path", "", "Path to a JSON file mapping job names to the fraction of their recent runs that were flaky. Used to prefer reliable jobs when not all affected jobs can be rehearsed.")
 
 	fs.Var(&o.stickyLabelAuthors, "sticky-label-author", "PR Author for which the 'rehearsals-ack' label will not be removed upon a new push. Can be passed multiple times.")
 	fs.StringVar(&o.webhookSecretFile, "hmac-secret-file", "/etc/webhook/hmac", "Path to the file containing the GitHub HMAC secret.")
@@ -250,6 +254,13 @@ func (o *options) validate() error {
 	if o.handlerTimeoutMinutes < 1 {
 		errs = append(errs, errors.New("handler-timeout-minutes must be greater than zero"))
 	}
+	if o.jobFlakinessPath != "" {
+		flakiness, err := rehearse.LoadJobFlakiness(o.jobFlakinessPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid job-flakiness-path: %w", err))
+		}
+		o.jobFlakiness = flakiness
+	}
 
 	if o.dryRun {
 		errs = append(errs, o.dryRunOptions.validate())
@@ -310,6 +321,7 @@ func rehearsalConfigFromOptions(o options) rehearse.RehearsalConfig {
 		MoreLimit:          o.moreLimit,
 		MaxLimit:           o.maxLimit,
 		StickyLabelAuthors: o.stickyLabelAuthors.StringSet(),
+		JobFlakiness:       o.jobFlakiness,
 		GCSBucket:          o.gcsBucket,
 		GCSCredentialsFile: o.gcsCredentialsFile,
 		GCSBrowserPrefix:   o.gcsBrowserPrefix,
@@ -334,12 +346,12 @@ func dryRun(o options, logger *logrus.Entry) error {
 	candidatePath := dro.dryRunPath
 	candidate := rehearse.RehearsalCandidateFromPullRequest(pr, pr.Base.SHA)
 
-	prConfig, presubmits, periodics, _, err := rc.DetermineAffectedJobs(candidate, candidatePath, false, logger)
+	prConfig, presubmits, periodics, _, explanations, err := rc.DetermineAffectedJobs(candidate, candidatePath, false, logger)
 	if err != nil {
 		return fmt.Errorf("error determining affected jobs: %w: %s", err, "ERROR: pj-rehearse: misconfiguration")
 	}
 
-	prConfig, prRefs, presubmitsToRehearse, err := rc.SetupJobs(candidate, candidatePath, prConfig, presubmits, periodics, dro.limit, logger)
+	prConfig, prRefs, presubmitsToRehearse, err := rc.SetupJobs(candidate, candidatePath, prConfig, presubmits, periodics, dro.limit, explanations, logger)
 	if err != nil {
 		return fmt.Errorf("error setting up jobs: %w: %s", err, "ERROR: pj-rehearse: setup failure")
 	}
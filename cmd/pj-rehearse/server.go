@@ -11,6 +11,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	prowconfig "sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/github"
@@ -36,6 +37,7 @@ const (
 	rehearseAutoAck            = "/pj-rehearse auto-ack"
 	rehearseAbort              = "/pj-rehearse abort"
 	rehearseAllowNetworkAccess = "/pj-rehearse network-access-allowed"
+	rehearseExplain            = "/pj-rehearse explain"
 )
 
 var commentRegex = regexp.MustCompile(`(?m)^/pj-rehearse\f*.*$`)
@@ -128,6 +130,12 @@ func (s *server) helpProvider(_ []prowconfig.OrgRepo) (*pluginhelp.PluginHelp, e
 		WhoCanUse:   "Openshift org members that are not the author of the PR",
 		Examples:    []string{rehearseAllowNetworkAccess},
 	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       fmt.Sprintf("%s {test-name}", rehearseExplain),
+		Description: "Explain why one or more jobs were found to be affected by the PR, and why they will not be rehearsed if so",
+		WhoCanUse:   "Anyone can use on trusted PRs",
+		Examples:    []string{fmt.Sprintf("%s {some-test} {another-test}", rehearseExplain)},
+	})
 	return pluginHelp, nil
 }
 
@@ -182,7 +190,7 @@ func (s *server) respondToNewPR(pullRequest *github.PullRequest, logger *logrus.
 	repo := pullRequest.Base.Repo.Name
 	number := pullRequest.Number
 	user := pullRequest.User.Login
-	presubmits, periodics, disabledDueToNetworkAccessToggle, err := s.getAffectedJobs(pullRequest, logger)
+	presubmits, periodics, disabledDueToNetworkAccessToggle, explanations, err := s.getAffectedJobs(pullRequest, 0, logger)
 	if err != nil {
 		comment := "unable to determine affected jobs. This could be due to a branch that needs to be rebased."
 		s.reportFailure(comment, err, org, repo, user, number, false, true, logger)
@@ -193,11 +201,11 @@ func (s *server) respondToNewPR(pullRequest *github.PullRequest, logger *logrus.
 		s.acknowledgeRehearsals(org, repo, number, logger)
 	}
 
-	lines, jobCount := s.getJobsTableLines(presubmits, periodics, user)
+	lines, jobCount := s.getJobsTableLines(presubmits, periodics, explanations, user)
 	lines = append(lines, s.getDisabledRehearsalsLines(disabledDueToNetworkAccessToggle)...)
 	if foundJobsToRehearse {
 		if jobCount > s.rehearsalConfig.MaxLimit {
-			fileLocation := s.dumpAffectedJobsToGCS(pullRequest, presubmits, periodics, jobCount, logger)
+			fileLocation := s.dumpAffectedJobsToGCS(pullRequest, presubmits, periodics, explanations, jobCount, logger)
 			lines = append(lines, fmt.Sprintf("A full list of affected jobs can be found [here](%s%s)", s.rehearsalConfig.GCSBrowserPrefix, fileLocation))
 		}
 		lines = append(lines, []string{
@@ -258,7 +266,7 @@ func (s *server) commentAffectedJobsOnPR(pullRequest *github.PullRequest, logger
 		}
 	}
 
-	presubmits, periodics, disabledDueToNetworkAccessToggle, err := s.getAffectedJobs(pullRequest, logger)
+	presubmits, periodics, disabledDueToNetworkAccessToggle, explanations, err := s.getAffectedJobs(pullRequest, 0, logger)
 	user := pullRequest.User.Login
 	if err != nil {
 		comment := "unable to determine affected jobs. This could be due to a branch that needs to be rebased."
@@ -276,9 +284,9 @@ func (s *server) commentAffectedJobsOnPR(pullRequest *github.PullRequest, logger
 	} else {
 		s.acknowledgeRehearsals(org, repo, number, logger)
 	}
-	jobTableLines, jobCount := s.getJobsTableLines(presubmits, periodics, user)
+	jobTableLines, jobCount := s.getJobsTableLines(presubmits, periodics, explanations, user)
 	if jobCount > s.rehearsalConfig.MaxLimit {
-		fileLocation := s.dumpAffectedJobsToGCS(pullRequest, presubmits, periodics, jobCount, logger)
+		fileLocation := s.dumpAffectedJobsToGCS(pullRequest, presubmits, periodics, explanations, jobCount, logger)
 		jobTableLines = append(jobTableLines, fmt.Sprintf("A full list of affected jobs can be found [here](%s%s)", s.rehearsalConfig.GCSBrowserPrefix, fileLocation))
 	}
 	jobTableLines = append(jobTableLines, s.getDisabledRehearsalsLines(disabledDueToNetworkAccessToggle)...)
@@ -338,6 +346,10 @@ func (s *server) handlePotentialCommands(pullRequest *github.PullRequest, commen
 		for _, command := range pjRehearseComments {
 			command = strings.TrimSpace(command)
 			logger.Debugf("handling command: %s", command)
+			if jobs, isExplain := parseExplainCommand(command); isExplain {
+				s.explainJobs(pullRequest, jobs, user, logger)
+				continue
+			}
 			switch command {
 			case rehearseAck, rehearseSkip:
 				s.acknowledgeRehearsals(org, repo, number, logger)
@@ -429,7 +441,7 @@ func (s *server) handlePotentialCommands(pullRequest *github.PullRequest, commen
 				}
 
 				candidatePath := repoClient.Directory()
-				prConfig, presubmits, periodics, _, err := rc.DetermineAffectedJobs(candidate, candidatePath, networkAccessRehearsalsAllowed, logger)
+				prConfig, presubmits, periodics, _, explanations, err := rc.DetermineAffectedJobs(candidate, candidatePath, networkAccessRehearsalsAllowed, logger)
 				if err != nil {
 					logger.WithError(err).Error("couldn't determine affected jobs")
 					s.reportFailure("unable to determine affected jobs", err, org, repo, user, number, true, false, logger)
@@ -459,12 +471,24 @@ func (s *server) handlePotentialCommands(pullRequest *github.PullRequest, commen
 						limit = rc.MaxLimit
 					}
 
-					prConfig, prRefs, presubmitsToRehearse, err := rc.SetupJobs(candidate, candidatePath, prConfig, presubmits, periodics, limit, logger)
+					skippedBeforeSetup := sets.New[string](explanations.Skipped()...)
+					prConfig, prRefs, presubmitsToRehearse, err := rc.SetupJobs(candidate, candidatePath, prConfig, presubmits, periodics, limit, explanations, logger)
 					if err != nil {
 						logger.WithError(err).Error("couldn't set up jobs")
 						s.reportFailure("unable to set up jobs", err, org, repo, user, number, true, false, logger)
 						continue
 					}
+					var truncated []string
+					for _, job := range explanations.Skipped() {
+						if !skippedBeforeSetup.Has(job) {
+							truncated = append(truncated, job)
+						}
+					}
+					if lines := getTruncatedRehearsalsLines(truncated, explanations, user); len(lines) > 0 {
+						if err := s.ghc.CreateComment(org, repo, number, strings.Join(lines, "\n")); err != nil {
+							logger.WithError(err).Error("failed to create comment")
+						}
+					}
 
 					if err := prConfig.Prow.ValidateJobConfig(); err != nil {
 						logger.WithError(err).Error("validation of job config failed")
@@ -493,14 +517,52 @@ func (s *server) handlePotentialCommands(pullRequest *github.PullRequest, commen
 	}
 }
 
-func (s *server) getAffectedJobs(pullRequest *github.PullRequest, logger *logrus.Entry) (config.Presubmits, config.Periodics, []string, error) {
+// parseExplainCommand returns the jobs requested to be explained if the command is an explain command
+func parseExplainCommand(command string) ([]string, bool) {
+	jobs, ok := strings.CutPrefix(command, rehearseExplain)
+	if !ok || (jobs != "" && !strings.HasPrefix(jobs, " ")) {
+		return nil, false
+	}
+	return strings.Fields(jobs), true
+}
+
+// explainJobs replies with the causes that made the requested jobs affected by the PR
+func (s *server) explainJobs(pullRequest *github.PullRequest, jobs []string, user string, logger *logrus.Entry) {
+	org := pullRequest.Base.Repo.Owner.Login
+	repo := pullRequest.Base.Repo.Name
+	number := pullRequest.Number
+	if len(jobs) == 0 {
+		message := fmt.Sprintf("@%s: please specify the job(s) to explain, e.g. `%s {test-name}`", user, rehearseExplain)
+		if err := s.ghc.CreateComment(org, repo, number, message); err != nil {
+			logger.WithError(err).Error("failed to create comment")
+		}
+		return
+	}
+	// jobs are explained as they would be rehearsed by a normal rehearsal request
+	_, _, _, explanations, err := s.getAffectedJobs(pullRequest, s.rehearsalConfig.NormalLimit, logger)
+	if err != nil {
+		s.reportFailure("unable to determine affected jobs", err, org, repo, user, number, true, false, logger)
+		return
+	}
+	lines := []string{fmt.Sprintf("@%s:", user)}
+	for _, job := range jobs {
+		lines = append(lines, "", explanations.Explain(job))
+	}
+	if err := s.ghc.CreateComment(org, repo, number, strings.Join(lines, "\n")); err != nil {
+		logger.WithError(err).Error("failed to create comment")
+	}
+}
+
+// getAffectedJobs determines the jobs affected by the pull request. With a limit, the
+// explanations also record the affected jobs that the limit leaves out of rehearsals.
+func (s *server) getAffectedJobs(pullRequest *github.PullRequest, limit int, logger *logrus.Entry) (config.Presubmits, config.Periodics, []string, rehearse.Explanations, error) {
 	rc := s.rehearsalConfig
 	org := pullRequest.Base.Repo.Owner.Login
 	repo := pullRequest.Base.Repo.Name
 	repoClient, err := s.getRepoClient(org, repo)
 	if err != nil {
 		logger.WithError(err).Error("couldn't create repo client")
-		return nil, nil, nil, fmt.Errorf("couldn't create repo client: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("couldn't create repo client: %w", err)
 	}
 	defer func() {
 		if err := repoClient.Clean(); err != nil {
@@ -511,22 +573,30 @@ func (s *server) getAffectedJobs(pullRequest *github.PullRequest, logger *logrus
 	candidate, err := s.prepareCandidate(repoClient, pullRequest, logger)
 	if err != nil {
 		logger.WithError(err).Error("couldn't prepare candidate")
-		return nil, nil, nil, fmt.Errorf("couldn't prepare candidate: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("couldn't prepare candidate: %w", err)
 	}
 
 	shouldAnalyze, err := shouldAnalyzeRehearsals(repoClient, pullRequest.Base.Ref, !rc.NoRegistry, logger)
 	if err != nil {
 		logger.WithError(err).Error("couldn't determine changed files")
-		return nil, nil, nil, fmt.Errorf("couldn't determine changed files: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("couldn't determine changed files: %w", err)
 	}
 	if !shouldAnalyze {
 		logger.Debug("Skipping affected job analysis because no rehearsal-relevant paths changed")
-		return config.Presubmits{}, config.Periodics{}, nil, nil
+		return config.Presubmits{}, config.Periodics{}, nil, rehearse.Explanations{}, nil
 	}
 
 	candidatePath := repoClient.Directory()
-	_, presubmits, periodics, disabledDueToNetworkAccessToggle, err := rc.DetermineAffectedJobs(candidate, candidatePath, false, logger)
-	return presubmits, periodics, disabledDueToNetworkAccessToggle, err
+	prConfig, presubmits, periodics, disabledDueToNetworkAccessToggle, explanations, err := rc.DetermineAffectedJobs(candidate, candidatePath, false, logger)
+	if err != nil || limit == 0 || (len(presubmits) == 0 && len(periodics) == 0) {
+		return presubmits, periodics, disabledDueToNetworkAccessToggle, explanations, err
+	}
+	// setting up the rehearsals records the jobs truncated by the limit, nothing is uploaded in a dry run
+	rc.DryRun = true
+	if _, _, _, err := rc.SetupJobs(candidate, candidatePath, prConfig, presubmits, periodics, limit, explanations, logger); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("couldn't set up jobs: %w", err)
+	}
+	return presubmits, periodics, disabledDueToNetworkAccessToggle, explanations, nil
 }
 
 func shouldAnalyzeRehearsals(repoClient git.RepoClient, baseRef string, includeRegistryChanges bool, logger *logrus.Entry) (bool, error) {
@@ -639,7 +709,7 @@ func (s *server) prepareCandidate(repoClient git.RepoClient, pullRequest *github
 
 // getJobsTableLines returns a Markdown formatted table of all affected jobs in the form of a []string
 // and the total number of affected jobs
-func (s *server) getJobsTableLines(presubmits config.Presubmits, periodics config.Periodics, user string) ([]string, int) {
+func (s *server) getJobsTableLines(presubmits config.Presubmits, periodics config.Periodics, explanations rehearse.Explanations, user string) ([]string, int) {
 	if len(presubmits) == 0 && len(periodics) == 0 {
 		message := fmt.Sprintf("%s \n@%s: no rehearsable tests are affected by this change", rehearsalNotifier, user)
 		message += "\n\n"
@@ -655,7 +725,7 @@ func (s *server) getJobsTableLines(presubmits config.Presubmits, periodics confi
 	}
 
 	limitToList := s.rehearsalConfig.MaxLimit
-	affectedJobs := getAffectedJobFormattedList(presubmits, periodics)
+	affectedJobs := getAffectedJobFormattedList(presubmits, periodics, explanations)
 	for i, job := range affectedJobs {
 		if i >= limitToList {
 			break
@@ -672,19 +742,43 @@ func (s *server) getJobsTableLines(presubmits config.Presubmits, periodics confi
 	return append(lines, ""), jobCount
 }
 
-func getAffectedJobFormattedList(presubmits config.Presubmits, periodics config.Periodics) []string {
+func getAffectedJobFormattedList(presubmits config.Presubmits, periodics config.Periodics, explanations rehearse.Explanations) []string {
+	reason := func(job prowconfig.JobBase) string {
+		if summary := explanations.Summary(job.Name); summary != "" {
+			return summary
+		}
+		return config.GetSourceType(job.Labels).GetDisplayText()
+	}
 	var jobs []string
 	for repoName, tests := range presubmits {
 		for _, presubmit := range tests {
-			jobs = append(jobs, fmt.Sprintf("%s | %s | %s | %s", presubmit.Name, repoName, "presubmit", config.GetSourceType(presubmit.Labels).GetDisplayText()))
+			jobs = append(jobs, fmt.Sprintf("%s | %s | %s | %s", presubmit.Name, repoName, "presubmit", reason(presubmit.JobBase)))
 		}
 	}
 	for jobName, periodic := range periodics {
-		jobs = append(jobs, fmt.Sprintf("%s | N/A | %s | %s", jobName, "periodic", config.GetSourceType(periodic.Labels).GetDisplayText()))
+		jobs = append(jobs, fmt.Sprintf("%s | N/A | %s | %s", jobName, "periodic", reason(periodic.JobBase)))
 	}
 	return jobs
 }
 
+// getTruncatedRehearsalsLines returns a Markdown formatted table of the affected jobs that were
+// not rehearsed because of the rehearsal limit
+func getTruncatedRehearsalsLines(truncated []string, explanations rehearse.Explanations, user string) []string {
+	if len(truncated) == 0 {
+		return nil
+	}
+	lines := []string{
+		fmt.Sprintf("@%s: the following affected jobs were not rehearsed due to the rehearsal limit. They can be rehearsed with `%s` or by name:", user, rehearseMax),
+		"",
+		"Test name | Reason",
+		"--- | ---",
+	}
+	for _, job := range truncated {
+		lines = append(lines, fmt.Sprintf("%s | %s", job, explanations.Summary(job)))
+	}
+	return append(lines, "")
+}
+
 func (s *server) getUsageDetailsLines() []string {
 	rc := s.rehearsalConfig
 	return []string{
@@ -699,6 +793,7 @@ func (s *server) getUsageDetailsLines() []string {
 		fmt.Sprintf("Comment: `%s` to run up to %d rehearsals, and add the `%s` label on success", rehearseAutoAck, rc.NormalLimit, rehearse.RehearsalsAckLabel),
 		fmt.Sprintf("Comment: `%s` to get an up-to-date list of affected jobs", rehearseList),
 		fmt.Sprintf("Comment: `%s` to abort all active rehearsals", rehearseAbort),
+		fmt.Sprintf("Comment: `%s {test-name}` to explain why a job is affected, and why it will not be rehearsed if so", rehearseExplain),
 		fmt.Sprintf("Comment: `%s` to allow rehearsals of tests that have the `restrict_network_access` field set to `false`. This must be executed by an `openshift` org member who is **not** the PR author", rehearseAllowNetworkAccess),
 		"",
 		fmt.Sprintf("Once you are satisfied with the results of the rehearsals, comment: `%s` to unblock merge. When the `%s` label is present on your PR, merge will no longer be blocked by rehearsals.", rehearseAck, rehearse.RehearsalsAckLabel),
@@ -728,13 +823,13 @@ func (s *server) acknowledgeRehearsals(org, repo string, number int, logger *log
 	}
 }
 
-func (s *server) dumpAffectedJobsToGCS(pullRequest *github.PullRequest, presubmits config.Presubmits, periodics config.Periodics, jobCount int, logger *logrus.Entry) string {
+func (s *server) dumpAffectedJobsToGCS(pullRequest *github.PullRequest, presubmits config.Presubmits, periodics config.Periodics, explanations rehearse.Explanations, jobCount int, logger *logrus.Entry) string {
 	logger.WithField("jobCount", jobCount).Debugf("jobCount is above %d. cannot comment all jobs, writing out to file", s.rehearsalConfig.MaxLimit)
 	fileContent := []string{"Test Name | Repo | Type | Reason"}
 	fileLocation := fmt.Sprintf("%s/%s/%s/%d/%s", pjRehearse, pullRequest.Base.Repo.Owner.Login, pullRequest.Base.Repo.Name, pullRequest.Number, pullRequest.Head.SHA)
 	uploadTargets := map[string]gcs.UploadFunc{
 		fileLocation: gcs.DataUpload(func() (io.ReadCloser, error) {
-			fileContent = append(fileContent, getAffectedJobFormattedList(presubmits, periodics)...)
+			fileContent = append(fileContent, getAffectedJobFormattedList(presubmits, periodics, explanations)...)
 			return io.NopCloser(strings.NewReader(strings.Join(fileContent, "\n"))), nil
 		}),
 	}
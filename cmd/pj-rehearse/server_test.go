@@ -9,11 +9,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
 
+	prowconfig "sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/git/localgit"
 	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/github"
+
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/rehearse"
 )
 
 // testRepoClient wraps a real git.RepoClient and overrides FetchRef to work
@@ -241,6 +246,66 @@ func TestIsRehearsalRelevantPath(t *testing.T) {
 	}
 }
 
+func TestParseExplainCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		wantJobs    []string
+		wantExplain bool
+	}{
+		{name: "single job", command: "/pj-rehearse explain pull-ci-org-repo-master-e2e", wantJobs: []string{"pull-ci-org-repo-master-e2e"}, wantExplain: true},
+		{name: "multiple jobs", command: "/pj-rehearse explain job-a  job-b", wantJobs: []string{"job-a", "job-b"}, wantExplain: true},
+		{name: "no jobs", command: "/pj-rehearse explain", wantJobs: []string{}, wantExplain: true},
+		{name: "job with explain prefix is a rehearsal", command: "/pj-rehearse explainer"},
+		{name: "requested rehearsal", command: "/pj-rehearse explain-job"},
+		{name: "normal rehearsal", command: "/pj-rehearse"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			jobs, explain := parseExplainCommand(tc.command)
+			if explain != tc.wantExplain {
+				t.Errorf("parseExplainCommand(%q) explain = %v, want %v", tc.command, explain, tc.wantExplain)
+			}
+			if diff := cmp.Diff(tc.wantJobs, jobs); diff != "" {
+				t.Errorf("parseExplainCommand(%q) unexpected jobs: %s", tc.command, diff)
+			}
+		})
+	}
+}
+
+func TestGetAffectedJobFormattedList(t *testing.T) {
+	presubmits := config.Presubmits{"org/repo": {
+		{JobBase: prowconfig.JobBase{Name: "explained", Labels: map[string]string{config.SourceTypeLabel: string(config.ChangedRegistryContent)}}},
+		{JobBase: prowconfig.JobBase{Name: "unexplained", Labels: map[string]string{config.SourceTypeLabel: string(config.ChangedPresubmit)}}},
+	}}
+	explanations := rehearse.Explanations{"explained": {Causes: [][]string{{"registry reference ipi-install changed", "used by chain ipi"}}}}
+	want := []string{
+		"explained | org/repo | presubmit | registry reference ipi-install changed → used by chain ipi",
+		"unexplained | org/repo | presubmit | Presubmit changed",
+	}
+	if diff := cmp.Diff(want, getAffectedJobFormattedList(presubmits, config.Periodics{}, explanations)); diff != "" {
+		t.Errorf("unexpected list: %s", diff)
+	}
+}
+
+func TestGetTruncatedRehearsalsLines(t *testing.T) {
+	if lines := getTruncatedRehearsalsLines(nil, rehearse.Explanations{}, "user"); lines != nil {
+		t.Errorf("expected no lines without truncated jobs, got %v", lines)
+	}
+	explanations := rehearse.Explanations{"job": {Causes: [][]string{{"ci-operator config file org-repo-master.yaml changed"}}, Skipped: "truncated by the rehearsal limit, only 1 of the 2 affected jobs are rehearsed at once"}}
+	want := []string{
+		"@user: the following affected jobs were not rehearsed due to the rehearsal limit. They can be rehearsed with `/pj-rehearse max` or by name:",
+		"",
+		"Test name | Reason",
+		"--- | ---",
+		"job | ci-operator config file org-repo-master.yaml changed",
+		"",
+	}
+	if diff := cmp.Diff(want, getTruncatedRehearsalsLines([]string{"job"}, explanations, "user")); diff != "" {
+		t.Errorf("unexpected lines: %s", diff)
+	}
+}
+
 func TestDispatcherImmediateExecution(t *testing.T) {
 	d := newHandlerDispatcher(2, 5, time.Minute, 5*time.Second)
 	logger := logrus.NewEntry(logrus.StandardLogger())
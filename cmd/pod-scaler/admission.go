@@ -38,7 +38,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/steps"
 )
 
-func admit(port, healthPort int, certDir string, client buildclientv1.BuildV1Interface, kubeClient kubernetes.Interface, loaders map[string][]*cacheReloader, mutateResourceLimits bool, cpuCap int64, memoryCap string, cpuPriorityScheduling int64, percentageMeasured float64, measuredPodCPUIncrease float64, systemReservedCPU int64, authoritativeCPU, authoritativeMemory, authoritativeCPUDryRun, authoritativeMemoryDryRun bool, authoritativeCPUMaxReductionPercent, authoritativeMemoryMaxReductionPercent float64, escalations *escalationServer, reporter results.PodScalerReporter) {
+func admit(port, healthPort int, certDir string, client buildclientv1.BuildV1Interface, kubeClient kubernetes.Interface, loaders map[string][]*cacheReloader, mutateResourceLimits bool, cpuCap int64, memoryCap, ephemeralStorageCap string, cpuPriorityScheduling int64, percentageMeasured float64, measuredPodCPUIncrease float64, systemReservedCPU int64, authoritativeCPU, authoritativeMemory, authoritativeCPUDryRun, authoritativeMemoryDryRun bool, authoritativeCPUMaxReductionPercent, authoritativeMemoryMaxReductionPercent float64, escalations *escalationServer, reporter results.PodScalerReporter) {
 	logger := logrus.WithField("component", "pod-scaler admission")
 	logger.Infof("Initializing admission webhook server with %d loaders.", len(loaders))
 	if authoritativeCPUDryRun || authoritativeMemoryDryRun {
@@ -48,7 +48,7 @@ func admit(port, healthPort int, certDir string, client buildclientv1.BuildV1Int
 		}).Info("authoritative decrease dry-run enabled")
 	}
 	health := pjutil.NewHealthOnPort(healthPort)
-	resources := newResourceServer(loaders, health, cpuCap, memoryCap)
+	resources := newResourceServer(loaders, health, cpuCap, memoryCap, ephemeralStorageCap)
 	decoder := admission.NewDecoder(scheme.Scheme)
 
 	// Initialize node allocatable CPU cache
@@ -234,6 +234,18 @@ func mutatePodLabels(pod *corev1.Pod, build *buildv1.Build) {
 
 var authoritativeMinCPURequest = resource.MustParse("10m")
 
+// increaseDetermined inflates a determined amount before it is compared to what is configured.
+func increaseDetermined(field corev1.ResourceName, our *resource.Quantity) {
+	//TODO(sgoeddel): this is a temporary experiment to see what effect setting values that are 120% of what has
+	// been determined has on the rate of OOMKilled and similar termination of workloads
+	if field == corev1.ResourceCPU {
+		our.SetMilli(int64(float64(our.MilliValue()) * 1.2))
+	} else {
+		increased := our.AsApproximateFloat64() * 1.2
+		our.Set(int64(increased))
+	}
+}
+
 // useOursIfLarger updates fields in theirs when ours are larger.
 func useOursIfLarger(allOfOurs, allOfTheirs *corev1.ResourceRequirements, workloadName, workloadType string, isMeasured bool, workloadClass string, reporter results.PodScalerReporter, logger *logrus.Entry) {
 	for _, item := range []*corev1.ResourceRequirements{allOfOurs, allOfTheirs} {
@@ -251,19 +263,12 @@ func useOursIfLarger(allOfOurs, allOfTheirs *corev1.ResourceRequirements, worklo
 		{ours: &allOfOurs.Requests, theirs: &allOfTheirs.Requests, resource: "request"},
 		{ours: &allOfOurs.Limits, theirs: &allOfTheirs.Limits, resource: "limit"},
 	} {
-		for _, field := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		for _, field := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage} {
 			our := (*pair.ours)[field]
 			if our.IsZero() {
 				continue
 			}
-			//TODO(sgoeddel): this is a temporary experiment to see what effect setting values that are 120% of what has
-			// been determined has on the rate of OOMKilled and similar termination of workloads
-			if field == corev1.ResourceCPU {
-				our.SetMilli(int64(float64(our.MilliValue()) * 1.2))
-			} else {
-				increased := our.AsApproximateFloat64() * 1.2
-				our.Set(int64(increased))
-			}
+			increaseDetermined(field, &our)
 
 			their := (*pair.theirs)[field]
 			fieldLogger := logger.WithFields(logrus.Fields{
@@ -344,6 +349,18 @@ func preventUnschedulableWithCaps(resources *corev1.ResourceRequirements, cpuCap
 	}
 }
 
+// capEphemeralStorage keeps ephemeral storage requests below the cap, as nodes
+// cannot schedule pods requesting more local storage than they have
+func capEphemeralStorage(resources *corev1.ResourceRequirements, ephemeralStorageCap resource.Quantity, logger *logrus.Entry) {
+	if resources.Requests == nil || ephemeralStorageCap.IsZero() {
+		return
+	}
+	if request, ok := resources.Requests[corev1.ResourceEphemeralStorage]; ok && request.Cmp(ephemeralStorageCap) == 1 {
+		logger.Debugf("setting original ephemeral storage request of: %s to cap", request.String())
+		resources.Requests[corev1.ResourceEphemeralStorage] = ephemeralStorageCap
+	}
+}
+
 func mutatePodResources(pod *corev1.Pod, server *resourceServer, mutateResourceLimits bool, cpuCap int64, memoryCap string, isMeasured bool, nodeCache *nodeAllocatableCache, measuredPodCPUIncrease float64, authoritativeCPU, authoritativeMemory, authoritativeCPUDryRun, authoritativeMemoryDryRun bool, authoritativeCPUMaxReductionPercent, authoritativeMemoryMaxReductionPercent float64, escalations *escalationServer, reporter results.PodScalerReporter, logger *logrus.Entry) {
 	workloadClass := pod.Labels[ciWorkloadLabel]
 
@@ -371,8 +388,8 @@ func mutatePodResources(pod *corev1.Pod, server *resourceServer, mutateResourceL
 					Limits:   corev1.ResourceList{},
 				}
 
-				// Take maximum CPU and memory from both
-				for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				// Take maximum CPU, memory and ephemeral storage from both
+				for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage} {
 					var maxRequest *resource.Quantity
 					if measuredExists && measuredResources.Requests != nil {
 						if q, ok := measuredResources.Requests[resourceName]; ok {
@@ -405,6 +422,7 @@ func mutatePodResources(pod *corev1.Pod, server *resourceServer, mutateResourceL
 				clampRequestsToLimits(&containers[i].Resources)
 			}
 			preventUnschedulable(&containers[i].Resources, cpuCap, memoryCap, logger)
+			capEphemeralStorage(&containers[i].Resources, server.ephemeralStorageRequestCap, logger)
 		}
 	}
 	mutateResources(pod.Spec.InitContainers)
@@ -875,12 +893,7 @@ func applyAuthoritativeLimitDecrease(recommended, configured *corev1.ResourceReq
 		if our.IsZero() {
 			continue
 		}
-		if field == corev1.ResourceCPU {
-			our.SetMilli(int64(float64(our.MilliValue()) * 1.2))
-		} else {
-			increased := our.AsApproximateFloat64() * 1.2
-			our.Set(int64(increased))
-		}
+		increaseDetermined(field, &our)
 		their, ok := configured.Limits[field]
 		if !ok || their.IsZero() {
 			continue
@@ -949,7 +962,7 @@ func clampRequestsToLimits(resources *corev1.ResourceRequirements) {
 	if resources == nil || resources.Limits == nil || resources.Requests == nil {
 		return
 	}
-	for _, field := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+	for _, field := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage} {
 		limit, ok := resources.Limits[field]
 		if !ok || limit.IsZero() {
 			continue
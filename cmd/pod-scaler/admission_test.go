@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/sirupsen/logrus"
 
 	admissionv1 "k8s.io/api/admission/v1"
@@ -628,6 +629,98 @@ func TestMutatePodResources_ciWorkloadLabelDoesNotBreakCacheLookup(t *testing.T)
 	}
 }
 
+func TestMutatePodResources_ephemeralStorage(t *testing.T) {
+	meta := podscaler.FullMetadata{
+		Metadata:  api.Metadata{Org: "org", Repo: "repo", Branch: "branch"},
+		Target:    "target",
+		Step:      "step",
+		Pod:       "tomutate",
+		Container: "test",
+	}
+	measured := meta
+	measured.Measured = true
+	testCases := []struct {
+		name            string
+		recommendations map[podscaler.FullMetadata]corev1.ResourceRequirements
+		configured      corev1.ResourceRequirements
+		expected        corev1.ResourceRequirements
+	}{
+		{
+			name: "larger of measured and unmeasured recommendations is used",
+			recommendations: map[podscaler.FullMetadata]corev1.ResourceRequirements{
+				meta:     {Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("10Gi")}},
+				measured: {Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("20Gi")}},
+			},
+			expected: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("24Gi")},
+				Limits:   corev1.ResourceList{},
+			},
+		},
+		{
+			name: "configured request larger than recommendation is kept",
+			recommendations: map[podscaler.FullMetadata]corev1.ResourceRequirements{
+				meta: {Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("1Gi")}},
+			},
+			configured: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("5Gi")},
+			},
+			expected: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("5Gi")},
+				Limits:   corev1.ResourceList{},
+			},
+		},
+		{
+			name: "request does not exceed the configured limit",
+			recommendations: map[podscaler.FullMetadata]corev1.ResourceRequirements{
+				meta: {Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("20Gi")}},
+			},
+			configured: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("22Gi")},
+			},
+			expected: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("22Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("22Gi")},
+			},
+		},
+		{
+			name: "request is capped",
+			configured: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("200Gi")},
+			},
+			expected: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("100Gi")},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := logrus.WithField("test", tc.name)
+			server := &resourceServer{
+				logger:                     logger,
+				byMetaData:                 tc.recommendations,
+				ephemeralStorageRequestCap: resource.MustParse("100Gi"),
+			}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "tomutate",
+					Labels: map[string]string{
+						"ci.openshift.io/metadata.org":    "org",
+						"ci.openshift.io/metadata.repo":   "repo",
+						"ci.openshift.io/metadata.branch": "branch",
+						"ci.openshift.io/metadata.target": "target",
+						"ci.openshift.io/metadata.step":   "step",
+					},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "test", Resources: tc.configured}}},
+			}
+			mutatePodResources(pod, server, false, 10, "20Gi", false, nil, 50.0, false, false, false, false, 0.25, 0.25, nil, &defaultReporter, logger)
+			if diff := cmp.Diff(tc.expected, pod.Spec.Containers[0].Resources, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected resources: %s", diff)
+			}
+		})
+	}
+}
+
 func TestUseOursIfLarger(t *testing.T) {
 	var testCases = []struct {
 		name                   string
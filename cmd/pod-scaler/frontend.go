@@ -201,7 +201,7 @@ var (
 	static embed.FS
 )
 
-func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheReloader) {
+func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheReloader, settings recommendationSettings) {
 	logger := logrus.WithField("component", "pod-scaler frontend")
 	server := &frontendServer{
 		logger:   logger,
@@ -209,11 +209,14 @@ func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheRe
 		mappings: endpoints(),
 		indices:  map[string][]*IndexNode{},
 		dataDir:  dataDir,
+		usage:    map[podscaler.FullMetadata]map[corev1.ResourceName]*usageSummary{},
+		settings: settings,
 	}
 	health := pjutil.NewHealthOnPort(healthPort)
 	digestAll(loaders, map[string]digester{
 		MetricNameCPUUsage:         server.digestCPU,
 		MetricNameMemoryWorkingSet: server.digestMemory,
+		MetricNameFilesystemUsage:  server.digestEphemeralStorage,
 	}, health, logger)
 
 	var nodes []simplifypath.Node
@@ -230,6 +233,9 @@ func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheRe
 			l("indicies",
 				nodes...,
 			),
+			l("simulation",
+				nodes...,
+			),
 		),
 	))
 	handler := metrics.TraceHandler(simplifier, uiMetrics.HTTPRequestDuration, uiMetrics.HTTPResponseSize)
@@ -258,6 +264,7 @@ func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheRe
 	for name := range server.mappings {
 		mux.HandleFunc(fmt.Sprintf("/api/data/%s", name), handler(server.getData(name)).ServeHTTP)
 		mux.HandleFunc(fmt.Sprintf("/api/indices/%s", name), handler(server.getIndex(name)).ServeHTTP)
+		mux.HandleFunc(fmt.Sprintf("/api/simulation/%s", name), handler(server.getSimulation(name)).ServeHTTP)
 	}
 	httpServer := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: mux}
 	interrupts.ListenAndServe(httpServer, 5*time.Second)
@@ -323,6 +330,11 @@ type frontendServer struct {
 
 	// dataDir is where we hold sharded data by metadata identifier
 	dataDir string
+
+	// usage holds the data behind recommendations, to simulate them
+	usage map[podscaler.FullMetadata]map[corev1.ResourceName]*usageSummary
+	// settings are those the admission server uses to determine recommendations
+	settings recommendationSettings
 }
 
 // dataForDisplay caches precomputed values for displaying data
@@ -471,6 +483,11 @@ func (s *frontendServer) digestMemory(data *podscaler.CachedQuery) {
 	s.digestData(data, corev1.ResourceMemory, memRequestQuantile)
 }
 
+func (s *frontendServer) digestEphemeralStorage(data *podscaler.CachedQuery) {
+	s.logger.Debugf("Digesting new ephemeral storage consumption metrics.")
+	s.digestData(data, corev1.ResourceEphemeralStorage, ephemeralStorageRequestQuantile)
+}
+
 func (s *frontendServer) digestData(data *podscaler.CachedQuery, metric corev1.ResourceName, quantile float64) {
 	s.logger.Debugf("Digesting %d identifiers.", len(data.DataByMetaData))
 	for meta, fingerprintTimes := range data.DataByMetaData {
@@ -504,6 +521,10 @@ func (s *frontendServer) digestData(data *podscaler.CachedQuery, metric corev1.R
 		}); err != nil {
 			s.logger.WithError(err).Error("Could not record data.")
 		}
+		if _, ok := s.usage[meta]; !ok {
+			s.usage[meta] = map[corev1.ResourceName]*usageSummary{}
+		}
+		s.usage[meta][metric] = summarizeUsage(overall, fingerprintTimes)
 		s.lock.Unlock()
 	}
 
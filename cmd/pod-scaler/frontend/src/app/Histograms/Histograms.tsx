@@ -117,6 +117,21 @@ export const Histograms: React.FunctionComponent<HistogramsProps> = (
                 yAxisTitle: "Memory Used",
                 yAxisUnit: "MiB",
             }}/>}
+        {data["ephemeral-storage"] && <LogarithmicComparativePlot
+            {...data["ephemeral-storage"]}
+            canvasProps={{
+                title: "Ephemeral Storage Usage",
+                yAxisFormatter(value: number): string {
+                    const n: number = value / Math.pow(2, 20);
+                    if (value > 10) {
+                        Math.round(n).toString();
+                    }
+                    return n.toFixed(2);
+                },
+                yAxisMin: 10 * Math.pow(2, 20),
+                yAxisTitle: "Ephemeral Storage Used",
+                yAxisUnit: "MiB",
+            }}/>}
     </Flex>;
 };
 
@@ -115,7 +115,7 @@ export const Resources: React.FunctionComponent<ResourcesProps> = (props: Resour
           <TextContent>
             <Text component="h1">Resource Usage for {props.workload} Workloads</Text>
             <Text component="p">
-              Choose a workload to view the CPU and memory usage for recent executions.
+              Choose a workload to view the CPU, memory and ephemeral storage usage for recent executions.
             </Text>
           </TextContent>
         </PageSection>
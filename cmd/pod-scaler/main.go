@@ -72,6 +72,7 @@ type consumerOptions struct {
 	mutateResourceLimits                   bool
 	cpuCap                                 int64
 	memoryCap                              string
+	ephemeralStorageCap                    string
 	cpuPriorityScheduling                  int64
 	percentageMeasured                     float64
 	measuredPodCPUIncrease                 float64
@@ -104,6 +105,7 @@ func bindOptions(fs *flag.FlagSet) *options {
 	fs.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "File where GCS credentials are stored.")
 	fs.Int64Var(&o.cpuCap, "cpu-cap", 10, "The maximum CPU request value, ex: 10")
 	fs.StringVar(&o.memoryCap, "memory-cap", "20Gi", "The maximum memory request value, ex: '20Gi'")
+	fs.StringVar(&o.ephemeralStorageCap, "ephemeral-storage-cap", "100Gi", "The maximum ephemeral storage request value, ex: '100Gi'")
 	fs.Int64Var(&o.cpuPriorityScheduling, "cpu-priority-scheduling", 8, "Pods with CPU requests at, or above, this value will be admitted with priority scheduling")
 	fs.Float64Var(&o.percentageMeasured, "percentage-measured", 0, "Percentage of pods to mark as measured (0-100). Measured pods get increased CPU requests and anti-affinity rules.")
 	fs.Float64Var(&o.measuredPodCPUIncrease, "measured-pod-cpu-increase", 50, "Percentage increase in CPU requests for measured pods (default: 50%).")
@@ -126,6 +128,20 @@ const (
 	logStyleText = "text"
 )
 
+// validateRequestCaps ensures the caps on recommended requests are usable
+func (o *options) validateRequestCaps() error {
+	if cpuCap := resource.NewQuantity(o.cpuCap, resource.DecimalSI); cpuCap.Sign() <= 0 {
+		return errors.New("--cpu-cap must be greater than 0")
+	}
+	if memoryCap, err := resource.ParseQuantity(o.memoryCap); err != nil || memoryCap.Sign() <= 0 {
+		return errors.New("--memory-cap must be greater than 0")
+	}
+	if ephemeralStorageCap, err := resource.ParseQuantity(o.ephemeralStorageCap); err != nil || ephemeralStorageCap.Sign() <= 0 {
+		return errors.New("--ephemeral-storage-cap must be a quantity greater than 0")
+	}
+	return nil
+}
+
 func (o *options) validate() error {
 	switch o.mode {
 	case "producer":
@@ -137,6 +153,7 @@ func (o *options) validate() error {
 		if o.dataDir == "" {
 			return errors.New("--data-dir is required")
 		}
+		return o.validateRequestCaps()
 	case "consumer.admission":
 		if o.port == 0 {
 			return errors.New("--port is required")
@@ -144,11 +161,8 @@ func (o *options) validate() error {
 		if o.certDir == "" {
 			return errors.New("--serving-cert-dir is required")
 		}
-		if cpuCap := resource.NewQuantity(o.cpuCap, resource.DecimalSI); cpuCap.Sign() <= 0 {
-			return errors.New("--cpu-cap must be greater than 0")
-		}
-		if memoryCap := resource.MustParse(o.memoryCap); memoryCap.Sign() <= 0 {
-			return errors.New("--memory-cap must be greater than 0")
+		if err := o.validateRequestCaps(); err != nil {
+			return err
 		}
 		if o.percentageMeasured < 0 || o.percentageMeasured > 100 {
 			return errors.New("--percentage-measured must be between 0 and 100")
@@ -298,7 +312,7 @@ func mainProduce(opts *options, cache Cache) {
 }
 
 func mainUI(opts *options, cache Cache) {
-	go serveUI(opts.uiPort, opts.instrumentationOptions.HealthPort, opts.dataDir, loaders(cache))
+	go serveUI(opts.uiPort, opts.instrumentationOptions.HealthPort, opts.dataDir, loaders(cache), newRecommendationSettings(opts.cpuCap, opts.memoryCap, opts.ephemeralStorageCap))
 }
 
 func mainAdmission(opts *options, cache Cache) {
@@ -323,7 +337,7 @@ func mainAdmission(opts *options, cache Cache) {
 
 	escalations := newEscalationServer(cache, opts.failureEscalationFactor)
 
-	go admit(opts.port, opts.instrumentationOptions.HealthPort, opts.certDir, client, kubeClient, loaders(cache), opts.mutateResourceLimits, opts.cpuCap, opts.memoryCap, opts.cpuPriorityScheduling, opts.percentageMeasured, opts.measuredPodCPUIncrease, opts.systemReservedCPU, opts.authoritativeCPU, opts.authoritativeMemory, opts.authoritativeCPUDryRun, opts.authoritativeMemoryDryRun, opts.authoritativeCPUMaxReductionPercent, opts.authoritativeMemoryMaxReductionPercent, escalations, reporter)
+	go admit(opts.port, opts.instrumentationOptions.HealthPort, opts.certDir, client, kubeClient, loaders(cache), opts.mutateResourceLimits, opts.cpuCap, opts.memoryCap, opts.ephemeralStorageCap, opts.cpuPriorityScheduling, opts.percentageMeasured, opts.measuredPodCPUIncrease, opts.systemReservedCPU, opts.authoritativeCPU, opts.authoritativeMemory, opts.authoritativeCPUDryRun, opts.authoritativeMemoryDryRun, opts.authoritativeCPUMaxReductionPercent, opts.authoritativeMemoryMaxReductionPercent, escalations, reporter)
 }
 
 func loaders(cache Cache) map[string][]*cacheReloader {
@@ -331,6 +345,7 @@ func loaders(cache Cache) map[string][]*cacheReloader {
 	for _, prefix := range []string{ProwjobsCachePrefix, PodsCachePrefix, StepsCachePrefix} {
 		l[MetricNameCPUUsage] = append(l[MetricNameCPUUsage], newReloader(prefix+"/"+MetricNameCPUUsage, cache))
 		l[MetricNameMemoryWorkingSet] = append(l[MetricNameMemoryWorkingSet], newReloader(prefix+"/"+MetricNameMemoryWorkingSet, cache))
+		l[MetricNameFilesystemUsage] = append(l[MetricNameFilesystemUsage], newReloader(prefix+"/"+MetricNameFilesystemUsage, cache))
 	}
 	return l
 }
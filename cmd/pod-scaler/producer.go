@@ -23,6 +23,7 @@ import (
 const (
 	MetricNameCPUUsage         = `container_cpu_usage_seconds_total`
 	MetricNameMemoryWorkingSet = `container_memory_working_set_bytes`
+	MetricNameFilesystemUsage  = `container_fs_usage_bytes`
 
 	containerFilter = `{container!="POD",container!=""}`
 
@@ -72,6 +73,7 @@ func queriesByMetric() map[string]string {
 		for name, metric := range map[string]string{
 			MetricNameCPUUsage:         `rate(` + MetricNameCPUUsage + containerFilter + `[3m])`,
 			MetricNameMemoryWorkingSet: MetricNameMemoryWorkingSet + containerFilter,
+			MetricNameFilesystemUsage:  MetricNameFilesystemUsage + containerFilter,
 		} {
 			queries[fmt.Sprintf("%s/%s", info.prefix, name)] = queryFor(metric, info.selector, info.labels)
 		}
@@ -47,6 +47,35 @@ func TestQueriesByMetric(t *testing.T) {
     container
   ) (container_memory_working_set_bytes{container!="POD",container!=""})
   * on(namespace,pod) 
+  group_left(
+    label_ci_openshift_io_metadata_org,
+    label_ci_openshift_io_metadata_repo,
+    label_ci_openshift_io_metadata_branch,
+    label_ci_openshift_io_metadata_variant,
+    label_ci_openshift_io_metadata_target,
+    label_openshift_io_build_name,
+    label_ci_openshift_io_release,
+    label_app,
+    label_pod_scaler_openshift_io_measured
+  ) max by (
+    namespace,
+    pod,
+    label_ci_openshift_io_metadata_org,
+    label_ci_openshift_io_metadata_repo,
+    label_ci_openshift_io_metadata_branch,
+    label_ci_openshift_io_metadata_variant,
+    label_ci_openshift_io_metadata_target,
+    label_openshift_io_build_name,
+    label_ci_openshift_io_release,
+    label_app,
+    label_pod_scaler_openshift_io_measured
+  ) (kube_pod_labels{label_created_by_ci="true",label_ci_openshift_io_metadata_step=""})`,
+		"pods/container_fs_usage_bytes": `sum by (
+    namespace,
+    pod,
+    container
+  ) (container_fs_usage_bytes{container!="POD",container!=""})
+  * on(namespace,pod) 
   group_left(
     label_ci_openshift_io_metadata_org,
     label_ci_openshift_io_metadata_repo,
@@ -103,6 +132,33 @@ func TestQueriesByMetric(t *testing.T) {
     container
   ) (container_memory_working_set_bytes{container!="POD",container!=""})
   * on(namespace,pod) 
+  group_left(
+    label_created_by_prow,
+    label_prow_k8s_io_context,
+    label_prow_k8s_io_refs_org,
+    label_prow_k8s_io_refs_repo,
+    label_prow_k8s_io_refs_base_ref,
+    label_prow_k8s_io_job,
+    label_prow_k8s_io_type,
+    label_pod_scaler_openshift_io_measured
+  ) max by (
+    namespace,
+    pod,
+    label_created_by_prow,
+    label_prow_k8s_io_context,
+    label_prow_k8s_io_refs_org,
+    label_prow_k8s_io_refs_repo,
+    label_prow_k8s_io_refs_base_ref,
+    label_prow_k8s_io_job,
+    label_prow_k8s_io_type,
+    label_pod_scaler_openshift_io_measured
+  ) (kube_pod_labels{label_created_by_prow="true",label_prow_k8s_io_job!="",label_ci_openshift_org_rehearse=""})`,
+		"prowjobs/container_fs_usage_bytes": `sum by (
+    namespace,
+    pod,
+    container
+  ) (container_fs_usage_bytes{container!="POD",container!=""})
+  * on(namespace,pod) 
   group_left(
     label_created_by_prow,
     label_prow_k8s_io_context,
@@ -155,6 +211,31 @@ func TestQueriesByMetric(t *testing.T) {
     container
   ) (container_memory_working_set_bytes{container!="POD",container!=""})
   * on(namespace,pod) 
+  group_left(
+    label_ci_openshift_io_metadata_org,
+    label_ci_openshift_io_metadata_repo,
+    label_ci_openshift_io_metadata_branch,
+    label_ci_openshift_io_metadata_variant,
+    label_ci_openshift_io_metadata_target,
+    label_ci_openshift_io_metadata_step,
+    label_pod_scaler_openshift_io_measured
+  ) max by (
+    namespace,
+    pod,
+    label_ci_openshift_io_metadata_org,
+    label_ci_openshift_io_metadata_repo,
+    label_ci_openshift_io_metadata_branch,
+    label_ci_openshift_io_metadata_variant,
+    label_ci_openshift_io_metadata_target,
+    label_ci_openshift_io_metadata_step,
+    label_pod_scaler_openshift_io_measured
+  ) (kube_pod_labels{label_created_by_ci="true",label_ci_openshift_io_metadata_step!=""})`,
+		"steps/container_fs_usage_bytes": `sum by (
+    namespace,
+    pod,
+    container
+  ) (container_fs_usage_bytes{container!="POD",container!=""})
+  * on(namespace,pod) 
   group_left(
     label_ci_openshift_io_metadata_org,
     label_ci_openshift_io_metadata_repo,
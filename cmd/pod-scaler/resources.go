@@ -14,14 +14,15 @@ import (
 	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
 )
 
-func newResourceServer(loaders map[string][]*cacheReloader, health *pjutil.Health, cpuCapCores int64, memoryCapFlag string) *resourceServer {
+func newResourceServer(loaders map[string][]*cacheReloader, health *pjutil.Health, cpuCapCores int64, memoryCapFlag, ephemeralStorageCapFlag string) *resourceServer {
 	logger := logrus.WithField("component", "pod-scaler request server")
 	server := &resourceServer{
-		logger:           logger,
-		lock:             sync.RWMutex{},
-		byMetaData:       map[podscaler.FullMetadata]corev1.ResourceRequirements{},
-		cpuRequestCap:    *resource.NewQuantity(cpuCapCores, resource.DecimalSI),
-		memoryRequestCap: resource.MustParse(memoryCapFlag),
+		logger:                     logger,
+		lock:                       sync.RWMutex{},
+		byMetaData:                 map[podscaler.FullMetadata]corev1.ResourceRequirements{},
+		cpuRequestCap:              *resource.NewQuantity(cpuCapCores, resource.DecimalSI),
+		memoryRequestCap:           resource.MustParse(memoryCapFlag),
+		ephemeralStorageRequestCap: resource.MustParse(ephemeralStorageCapFlag),
 	}
 	digestAll(loaders, map[string]digester{
 		MetricNameCPUUsage: func(data *podscaler.CachedQuery) {
@@ -34,6 +35,11 @@ func newResourceServer(loaders map[string][]*cacheReloader, health *pjutil.Healt
 				return memoryRequestQuantityFromHistogram(hist, quantile, server.memoryRequestCap, server.logger)
 			})
 		},
+		MetricNameFilesystemUsage: func(data *podscaler.CachedQuery) {
+			server.digestRecommendations(data, corev1.ResourceEphemeralStorage, ephemeralStorageRequestQuantile, func(hist *circonusllhist.Histogram, quantile float64) corev1.ResourceList {
+				return ephemeralStorageRequestQuantityFromHistogram(hist, quantile, server.ephemeralStorageRequestCap, server.logger)
+			})
+		},
 	}, health, logger)
 
 	return server
@@ -46,9 +52,11 @@ type resourceServer struct {
 	// metadata labels.
 	byMetaData map[podscaler.FullMetadata]corev1.ResourceRequirements
 	// cpuRequestCap is parsed from --cpu-cap (whole cores). memoryRequestCap is parsed
-	// from --memory-cap (Kubernetes quantity string, e.g. 20Gi), not a raw float.
-	cpuRequestCap    resource.Quantity
-	memoryRequestCap resource.Quantity
+	// from --memory-cap (Kubernetes quantity string, e.g. 20Gi), not a raw float, as is
+	// ephemeralStorageRequestCap from --ephemeral-storage-cap.
+	cpuRequestCap              resource.Quantity
+	memoryRequestCap           resource.Quantity
+	ephemeralStorageRequestCap resource.Quantity
 }
 
 const (
@@ -61,6 +69,9 @@ const (
 const (
 	// memRequestQuantile is the quantile of memory usage data to use as the memory request
 	memRequestQuantile = 0.8
+	// ephemeralStorageRequestQuantile is the quantile of container filesystem usage data to use
+	// as the ephemeral storage request
+	ephemeralStorageRequestQuantile = 0.8
 )
 
 func quantileValueUsable(v float64) bool {
@@ -137,6 +148,21 @@ func memoryRequestQuantityFromHistogram(hist *circonusllhist.Histogram, quantile
 	return reqs.Requests
 }
 
+// ephemeralStorageRequestQuantityFromHistogram returns a capped ephemeral storage request, or nil.
+func ephemeralStorageRequestQuantityFromHistogram(hist *circonusllhist.Histogram, quantile float64, ephemeralStorageCap resource.Quantity, logger *logrus.Entry) corev1.ResourceList {
+	usage := recommendationValue(hist, quantile)
+	if usage == nil {
+		return nil
+	}
+	q := memoryQuantityFromBytes(*usage)
+	if q == nil {
+		return nil
+	}
+	reqs := &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: *q}}
+	capEphemeralStorage(reqs, ephemeralStorageCap, logger.WithField("stage", "digest"))
+	return reqs.Requests
+}
+
 func (s *resourceServer) digestRecommendations(
 	data *podscaler.CachedQuery,
 	resource corev1.ResourceName,
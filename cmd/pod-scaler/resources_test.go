@@ -190,6 +190,51 @@ func TestMemoryRequestQuantityFromHistogram(t *testing.T) {
 	}
 }
 
+func TestEphemeralStorageRequestQuantityFromHistogram(t *testing.T) {
+	ephemeralStorageCap := resource.MustParse("100Gi")
+
+	testCases := []struct {
+		name        string
+		sampleCount int
+		sampleValue float64
+		quantile    float64
+		want        corev1.ResourceList
+	}{
+		{
+			name:        "normal usage",
+			sampleCount: 20,
+			sampleValue: 1e10,
+			quantile:    0.8,
+			want:        corev1.ResourceList{corev1.ResourceEphemeralStorage: *resource.NewQuantity(10800000000, resource.BinarySI)},
+		},
+		{
+			name:        "capped at digest",
+			sampleCount: 20,
+			sampleValue: 200 * 1024 * 1024 * 1024,
+			quantile:    0.8,
+			want:        corev1.ResourceList{corev1.ResourceEphemeralStorage: ephemeralStorageCap},
+		},
+		{
+			name:     "empty histogram",
+			quantile: 0.8,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hist := circonusllhist.New()
+			for i := 0; i < tc.sampleCount; i++ {
+				if err := hist.RecordValue(tc.sampleValue); err != nil {
+					t.Fatalf("RecordValue: %v", err)
+				}
+			}
+			got := ephemeralStorageRequestQuantityFromHistogram(hist, tc.quantile, ephemeralStorageCap, logrus.WithField("test", tc.name))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("ephemeralStorageRequestQuantityFromHistogram differs from expected, diff:\n%s", diff)
+			}
+		})
+	}
+}
+
 func ptr(v float64) *float64 {
 	return &v
 }
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openhistogram/circonusllhist"
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/prow/pkg/metrics"
+
+	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+)
+
+// defaultSimulationQuantiles are reported when the request does not ask for specific quantiles
+var defaultSimulationQuantiles = []float64{0.5, 0.8, 0.9, 0.95, 0.99}
+
+// usageSummary describes the usage data a recommendation for a resource is derived from
+type usageSummary struct {
+	merged *circonusllhist.Histogram
+	// runs is the number of executions the data was gathered from
+	runs int
+	// earliest and latest are the times at which the oldest and newest data were sourced
+	earliest, latest time.Time
+}
+
+func summarizeUsage(merged *circonusllhist.Histogram, fingerprintTimes []podscaler.FingerprintTime) *usageSummary {
+	summary := &usageSummary{merged: merged, runs: len(fingerprintTimes)}
+	for _, fingerprintTime := range fingerprintTimes {
+		if summary.earliest.IsZero() || fingerprintTime.Added.Before(summary.earliest) {
+			summary.earliest = fingerprintTime.Added
+		}
+		if fingerprintTime.Added.After(summary.latest) {
+			summary.latest = fingerprintTime.Added
+		}
+	}
+	return summary
+}
+
+// recommenders turn usage histograms into capped requests, as the admission server does when digesting data
+var recommenders = map[corev1.ResourceName]func(*circonusllhist.Histogram, float64, resource.Quantity, *logrus.Entry) corev1.ResourceList{
+	corev1.ResourceCPU:              cpuRequestQuantityFromHistogram,
+	corev1.ResourceMemory:           memoryRequestQuantityFromHistogram,
+	corev1.ResourceEphemeralStorage: ephemeralStorageRequestQuantityFromHistogram,
+}
+
+// recommendationSettings are the knobs that determine the requests admission applies
+type recommendationSettings struct {
+	quantiles map[corev1.ResourceName]float64
+	caps      map[corev1.ResourceName]resource.Quantity
+}
+
+func newRecommendationSettings(cpuCapCores int64, memoryCapFlag, ephemeralStorageCapFlag string) recommendationSettings {
+	return recommendationSettings{
+		quantiles: map[corev1.ResourceName]float64{
+			corev1.ResourceCPU:              cpuRequestQuantile,
+			corev1.ResourceMemory:           memRequestQuantile,
+			corev1.ResourceEphemeralStorage: ephemeralStorageRequestQuantile,
+		},
+		caps: map[corev1.ResourceName]resource.Quantity{
+			corev1.ResourceCPU:              *resource.NewQuantity(cpuCapCores, resource.DecimalSI),
+			corev1.ResourceMemory:           resource.MustParse(memoryCapFlag),
+			corev1.ResourceEphemeralStorage: resource.MustParse(ephemeralStorageCapFlag),
+		},
+	}
+}
+
+func (s recommendationSettings) copy() recommendationSettings {
+	ret := recommendationSettings{quantiles: map[corev1.ResourceName]float64{}, caps: map[corev1.ResourceName]resource.Quantity{}}
+	for field, quantile := range s.quantiles {
+		ret.quantiles[field] = quantile
+	}
+	for field, limit := range s.caps {
+		ret.caps[field] = limit.DeepCopy()
+	}
+	return ret
+}
+
+// applied determines the request admission would set for a container given the usage of its
+// measured and unmeasured executions: the larger recommendation, increased and capped.
+func (s recommendationSettings) applied(field corev1.ResourceName, logger *logrus.Entry, summaries ...*usageSummary) *resource.Quantity {
+	var determined *resource.Quantity
+	for _, summary := range summaries {
+		if summary == nil {
+			continue
+		}
+		recommendation, ok := recommenders[field](summary.merged, s.quantiles[field], s.caps[field], logger)[field]
+		if !ok {
+			continue
+		}
+		if determined == nil || recommendation.Cmp(*determined) > 0 {
+			determined = &recommendation
+		}
+	}
+	if determined == nil {
+		return nil
+	}
+	increaseDetermined(field, determined)
+	if limit := s.caps[field]; determined.Cmp(limit) > 0 {
+		capped := limit.DeepCopy()
+		determined = &capped
+	}
+	return determined
+}
+
+// simulationWindow is the time range in which the data behind a recommendation was sourced
+type simulationWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// resourceSimulation describes the recommendation for one resource of a container
+type resourceSimulation struct {
+	// Applied is the request admission sets with the current configuration
+	Applied string `json:"applied"`
+	// Quantiles holds the request admission would set if recommendations used the quantile
+	Quantiles map[string]string `json:"quantiles"`
+	// Proposed is the request admission would set with the proposed configuration
+	Proposed string `json:"proposed,omitempty"`
+	// Samples is the number of usage samples behind the recommendation
+	Samples uint64 `json:"samples"`
+	// Runs is the number of executions the samples were gathered from
+	Runs   int              `json:"runs"`
+	Window simulationWindow `json:"window"`
+}
+
+// projection sums requests over all recent executions of all workloads, in cores for
+// CPU and in bytes for memory
+type projection struct {
+	Current        float64 `json:"current"`
+	Proposed       float64 `json:"proposed"`
+	Savings        float64 `json:"savings"`
+	SavingsPercent float64 `json:"savings_percent"`
+}
+
+type simulationResponse struct {
+	Metadata   podscaler.FullMetadata                     `json:"metadata"`
+	Resources  map[corev1.ResourceName]resourceSimulation `json:"resources"`
+	Projection map[corev1.ResourceName]projection         `json:"projection"`
+}
+
+// parseSimulationQuery determines the quantiles to report and the proposed settings from the request
+func parseSimulationQuery(r *http.Request, current recommendationSettings) ([]float64, recommendationSettings, error) {
+	values := r.URL.Query()
+	quantiles := defaultSimulationQuantiles
+	if raw := values.Get("quantiles"); raw != "" {
+		quantiles = nil
+		for _, item := range strings.Split(raw, ",") {
+			quantile, err := parseQuantile(item)
+			if err != nil {
+				return nil, recommendationSettings{}, fmt.Errorf("invalid quantiles: %w", err)
+			}
+			quantiles = append(quantiles, quantile)
+		}
+	}
+	proposed := current.copy()
+	for param, field := range map[string]corev1.ResourceName{"cpu_quantile": corev1.ResourceCPU, "memory_quantile": corev1.ResourceMemory} {
+		if raw := values.Get(param); raw != "" {
+			quantile, err := parseQuantile(raw)
+			if err != nil {
+				return nil, recommendationSettings{}, fmt.Errorf("invalid %s: %w", param, err)
+			}
+			proposed.quantiles[field] = quantile
+		}
+	}
+	if raw := values.Get("cpu_cap"); raw != "" {
+		cores, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || cores <= 0 {
+			return nil, recommendationSettings{}, fmt.Errorf("invalid cpu_cap %q: must be a number of cores greater than 0", raw)
+		}
+		proposed.caps[corev1.ResourceCPU] = *resource.NewQuantity(cores, resource.DecimalSI)
+	}
+	if raw := values.Get("memory_cap"); raw != "" {
+		memoryCap, err := resource.ParseQuantity(raw)
+		if err != nil || memoryCap.Sign() <= 0 {
+			return nil, recommendationSettings{}, fmt.Errorf("invalid memory_cap %q: must be a quantity greater than 0", raw)
+		}
+		proposed.caps[corev1.ResourceMemory] = memoryCap
+	}
+	return quantiles, proposed, nil
+}
+
+func parseQuantile(raw string) (float64, error) {
+	quantile, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || quantile <= 0 || quantile > 1 {
+		return 0, fmt.Errorf("quantile %q must be a number in (0, 1]", raw)
+	}
+	return quantile, nil
+}
+
+// simulate determines the recommendations for a container and projects how requests
+// across all workloads change with the proposed configuration.
+func (s *frontendServer) simulate(meta podscaler.FullMetadata, quantiles []float64, proposed recommendationSettings) (simulationResponse, bool) {
+	meta.Measured = false
+	measured := meta
+	measured.Measured = true
+	logger := s.logger.WithFields(meta.LogFields())
+	response := simulationResponse{
+		Metadata:   meta,
+		Resources:  map[corev1.ResourceName]resourceSimulation{},
+		Projection: map[corev1.ResourceName]projection{},
+	}
+	for field := range recommenders {
+		summaries := []*usageSummary{s.usage[meta][field], s.usage[measured][field]}
+		applied := s.settings.applied(field, logger, summaries...)
+		if applied == nil {
+			continue
+		}
+		simulation := resourceSimulation{Applied: applied.String(), Quantiles: map[string]string{}}
+		for _, quantile := range quantiles {
+			settings := s.settings.copy()
+			settings.quantiles[field] = quantile
+			if request := settings.applied(field, logger, summaries...); request != nil {
+				simulation.Quantiles[strconv.FormatFloat(quantile, 'f', -1, 64)] = request.String()
+			}
+		}
+		if request := proposed.applied(field, logger, summaries...); request != nil {
+			simulation.Proposed = request.String()
+		}
+		for _, summary := range summaries {
+			if summary == nil {
+				continue
+			}
+			simulation.Samples += summary.merged.Count()
+			simulation.Runs += summary.runs
+			if simulation.Window.Start.IsZero() || summary.earliest.Before(simulation.Window.Start) {
+				simulation.Window.Start = summary.earliest
+			}
+			if summary.latest.After(simulation.Window.End) {
+				simulation.Window.End = summary.latest
+			}
+		}
+		response.Resources[field] = simulation
+	}
+	if len(response.Resources) == 0 {
+		return simulationResponse{}, false
+	}
+
+	for _, field := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var total projection
+		for workload := range s.usage {
+			if workload.Measured {
+				// folded into the unmeasured workload below
+				if _, ok := s.usage[unmeasured(workload)]; ok {
+					continue
+				}
+			}
+			workload = unmeasured(workload)
+			workloadMeasured := workload
+			workloadMeasured.Measured = true
+			summaries := []*usageSummary{s.usage[workload][field], s.usage[workloadMeasured][field]}
+			runs := 0
+			for _, summary := range summaries {
+				if summary != nil {
+					runs += summary.runs
+				}
+			}
+			current, future := s.settings.applied(field, logger, summaries...), proposed.applied(field, logger, summaries...)
+			if current == nil || future == nil {
+				continue
+			}
+			total.Current += current.AsApproximateFloat64() * float64(runs)
+			total.Proposed += future.AsApproximateFloat64() * float64(runs)
+		}
+		total.Savings = total.Current - total.Proposed
+		if total.Current > 0 {
+			total.SavingsPercent = total.Savings / total.Current * 100
+		}
+		response.Projection[field] = total
+	}
+	return response, true
+}
+
+func unmeasured(meta podscaler.FullMetadata) podscaler.FullMetadata {
+	meta.Measured = false
+	return meta
+}
+
+func (s *frontendServer) getSimulation(index string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusNotImplemented)
+			_, _ = w.Write([]byte(http.StatusText(http.StatusNotImplemented)))
+			return
+		}
+		mapping := s.mappings[index]
+		meta, err := mapping.metadataFromQuery(w, r)
+		if err != nil {
+			metrics.RecordError("invalid query", uiMetrics.ErrorRate)
+			return
+		}
+		quantiles, proposed, err := parseSimulationQuery(r, s.settings)
+		if err != nil {
+			metrics.RecordError("invalid query", uiMetrics.ErrorRate)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, err.Error())
+			return
+		}
+		logger := logrus.WithFields(meta.LogFields())
+		s.lock.RLock()
+		response, found := s.simulate(meta, quantiles, proposed)
+		s.lock.RUnlock()
+		if !found {
+			metrics.RecordError("data not found", uiMetrics.ErrorRate)
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "no data available")
+			logger.Warning("No data found.")
+			return
+		}
+		raw, err := json.Marshal(response)
+		if err != nil {
+			metrics.RecordError("failed to marshal data", uiMetrics.ErrorRate)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "failed to marshal simulation to JSON: %v", err)
+			logger.WithError(err).Errorf("Failed to marshal simulation to JSON.")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(raw); err != nil {
+			logrus.WithError(err).Error("Failed to write response")
+		}
+	}
+}
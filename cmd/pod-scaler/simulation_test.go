@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openhistogram/circonusllhist"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+)
+
+func TestParseSimulationQuery(t *testing.T) {
+	current := newRecommendationSettings(10, "20Gi", "100Gi")
+	testCases := []struct {
+		name              string
+		query             string
+		expectedQuantiles []float64
+		expectedSettings  func() recommendationSettings
+		expectedErr       bool
+	}{
+		{
+			name:              "defaults",
+			expectedQuantiles: defaultSimulationQuantiles,
+			expectedSettings:  current.copy,
+		},
+		{
+			name:              "proposed quantiles and caps",
+			query:             "?quantiles=0.5,0.99&cpu_quantile=0.9&memory_quantile=0.95&cpu_cap=8&memory_cap=16Gi",
+			expectedQuantiles: []float64{0.5, 0.99},
+			expectedSettings: func() recommendationSettings {
+				settings := current.copy()
+				settings.quantiles[corev1.ResourceCPU] = 0.9
+				settings.quantiles[corev1.ResourceMemory] = 0.95
+				settings.caps[corev1.ResourceCPU] = *resource.NewQuantity(8, resource.DecimalSI)
+				settings.caps[corev1.ResourceMemory] = resource.MustParse("16Gi")
+				return settings
+			},
+		},
+		{
+			name:        "quantile out of range",
+			query:       "?quantiles=0.5,1.5",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid cap",
+			query:       "?memory_cap=lots",
+			expectedErr: true,
+		},
+		{
+			name:        "zero cap",
+			query:       "?cpu_cap=0",
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			quantiles, settings, err := parseSimulationQuery(httptest.NewRequest(http.MethodGet, "/api/simulation/steps"+tc.query, nil), current)
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.expectedErr, err)
+			}
+			if tc.expectedErr {
+				return
+			}
+			if diff := cmp.Diff(tc.expectedQuantiles, quantiles); diff != "" {
+				t.Errorf("unexpected quantiles: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedSettings(), settings, cmp.AllowUnexported(recommendationSettings{})); diff != "" {
+				t.Errorf("unexpected settings: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGetSimulation(t *testing.T) {
+	day := func(n int) time.Time {
+		return time.Date(2026, 10, n, 0, 0, 0, 0, time.UTC)
+	}
+	meta := func(container string, measured bool) podscaler.FullMetadata {
+		return podscaler.FullMetadata{
+			Metadata:  api.Metadata{Org: "org", Repo: "repo", Branch: "branch"},
+			Target:    "target",
+			Step:      "step",
+			Pod:       "target-step",
+			Container: container,
+			Measured:  measured,
+		}
+	}
+	type usage struct {
+		meta    podscaler.FullMetadata
+		value   float64
+		samples int64
+		added   []time.Time
+	}
+	query := func(usages ...usage) *podscaler.CachedQuery {
+		data := &podscaler.CachedQuery{
+			Data:           map[model.Fingerprint]*circonusllhist.HistogramWithoutLookups{},
+			DataByMetaData: map[podscaler.FullMetadata][]podscaler.FingerprintTime{},
+		}
+		fingerprint := model.Fingerprint(0)
+		for _, u := range usages {
+			for _, added := range u.added {
+				fingerprint++
+				hist := circonusllhist.New()
+				if err := hist.RecordValues(u.value, u.samples); err != nil {
+					t.Fatalf("failed to record values: %v", err)
+				}
+				data.Data[fingerprint] = circonusllhist.NewHistogramWithoutLookups(hist)
+				data.DataByMetaData[u.meta] = append(data.DataByMetaData[u.meta], podscaler.FingerprintTime{Fingerprint: fingerprint, Added: added})
+			}
+		}
+		return data
+	}
+
+	server := &frontendServer{
+		logger:   logrus.WithField("test", t.Name()),
+		lock:     sync.RWMutex{},
+		mappings: endpoints(),
+		indices:  map[string][]*IndexNode{},
+		dataDir:  t.TempDir(),
+		usage:    map[podscaler.FullMetadata]map[corev1.ResourceName]*usageSummary{},
+		settings: newRecommendationSettings(10, "20Gi", "100Gi"),
+	}
+	server.digestCPU(query(
+		usage{meta: meta("test", false), value: 2, samples: 20, added: []time.Time{day(1), day(2)}},
+		usage{meta: meta("test", true), value: 4, samples: 20, added: []time.Time{day(3)}},
+		usage{meta: meta("other", false), value: 12, samples: 20, added: []time.Time{day(1)}},
+	))
+	server.digestMemory(query(
+		usage{meta: meta("test", false), value: 1e9, samples: 20, added: []time.Time{day(1), day(2)}},
+	))
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       simulationResponse
+	}{
+		{
+			name:           "no data",
+			query:          "?org=org&repo=repo&branch=branch&target=target&step=step&container=missing",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing metadata",
+			query:          "?org=org",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "recommendations with proposed caps",
+			query:          "?org=org&repo=repo&branch=branch&target=target&step=step&container=test&quantiles=0.5&cpu_cap=4&memory_quantile=0.5",
+			expectedStatus: http.StatusOK,
+			expected: simulationResponse{
+				Metadata: meta("test", false),
+				Resources: map[corev1.ResourceName]resourceSimulation{
+					corev1.ResourceCPU: {
+						Applied:   "4896m",
+						Quantiles: map[string]string{"0.5": "4860m"},
+						Proposed:  "4",
+						Samples:   60,
+						Runs:      3,
+						Window:    simulationWindow{Start: day(1), End: day(3)},
+					},
+					corev1.ResourceMemory: {
+						Applied:   "1265625Ki",
+						Quantiles: map[string]string{"0.5": "1260000000"},
+						Proposed:  "1260000000",
+						Samples:   40,
+						Runs:      2,
+						Window:    simulationWindow{Start: day(1), End: day(2)},
+					},
+				},
+				Projection: map[corev1.ResourceName]projection{
+					// 3 runs at 4.896 cores and one at the cap of 10 cores, proposed to be capped at 4
+					corev1.ResourceCPU:    {Current: 24.688, Proposed: 16, Savings: 8.688, SavingsPercent: 35.191186001296174},
+					corev1.ResourceMemory: {Current: 2592000000, Proposed: 2520000000, Savings: 72000000, SavingsPercent: 2.7777777777777777},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			server.getSimulation("steps")(recorder, httptest.NewRequest(http.MethodGet, "/api/simulation/steps"+tc.query, nil))
+			if recorder.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var actual simulationResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &actual); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("unexpected response: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/prow/pkg/logrusutil"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/promotionhistory"
+	"github.com/openshift/ci-tools/pkg/util"
+)
+
+type options struct {
+	imageStream string
+	prowJobID   string
+	list        bool
+	force       bool
+	dryRun      bool
+	logLevel    string
+}
+
+func parseOptions() options {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.imageStream, "imagestream", "", "ImageStream to roll back, in namespace/name form")
+	fs.StringVar(&o.prowJobID, "prowjob-id", "", "ID of the ProwJob whose promotion is rolled back")
+	fs.BoolVar(&o.list, "list", false, "List the promotion history of the ImageStream instead of rolling back")
+	fs.BoolVar(&o.force, "force", false, "Roll back tags even if they have been promoted to again since")
+	fs.BoolVar(&o.dryRun, "dry-run", true, "Print what would be rolled back without updating the ImageStream")
+	fs.StringVar(&o.logLevel, "log-level", "info", "log level")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		logrus.WithError(err).Fatal("could not parse args")
+	}
+	return o
+}
+
+func (o *options) validate() (ctrlruntimeclient.ObjectKey, error) {
+	level, err := logrus.ParseLevel(o.logLevel)
+	if err != nil {
+		return ctrlruntimeclient.ObjectKey{}, fmt.Errorf("invalid log level specified: %w", err)
+	}
+	logrus.SetLevel(level)
+	namespace, name, ok := strings.Cut(o.imageStream, "/")
+	if !ok || namespace == "" || name == "" {
+		return ctrlruntimeclient.ObjectKey{}, fmt.Errorf("--imagestream must be in namespace/name form")
+	}
+	if !o.list && o.prowJobID == "" {
+		return ctrlruntimeclient.ObjectKey{}, fmt.Errorf("either --list or --prowjob-id is required")
+	}
+	return ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, nil
+}
+
+func printHistory(history []promotionhistory.Record) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tPROWJOB\tSOURCE\tTAGS")
+	for _, record := range history {
+		source := "-"
+		if record.RolledBack != "" {
+			source = "rollback"
+		} else if record.Source != nil {
+			source = fmt.Sprintf("%s/%s@%s", record.Source.Org, record.Source.Repo, record.Source.Commit)
+		}
+		var tags []string
+		for _, change := range record.Tags {
+			tags = append(tags, change.Tag)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", record.Time.UTC().Format(time.RFC3339), record.ProwJobID, source, strings.Join(tags, ","))
+	}
+	w.Flush()
+}
+
+// rollback reverts the promotion with a single update of the ImageStream, so either all or none
+// of its tags are rolled back; the update is retried on conflicts. Tags the promotion created are
+// deleted afterwards, every deletion is retried and all failed ones are reported.
+func rollback(ctx context.Context, client ctrlruntimeclient.Client, key ctrlruntimeclient.ObjectKey, prowJobID string, force, dryRun bool) error {
+	var record *promotionhistory.Record
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		is := &imagev1.ImageStream{}
+		if err := client.Get(ctx, key, is); err != nil {
+			return fmt.Errorf("could not get imagestream %s: %w", key, err)
+		}
+		var err error
+		if record, err = promotionhistory.Rollback(is, prowJobID, force, metav1.Now()); err != nil {
+			return err
+		}
+		if dryRun {
+			return nil
+		}
+		return client.Update(ctx, is)
+	}); err != nil {
+		return fmt.Errorf("could not roll back imagestream %s: %w", key, err)
+	}
+	for _, change := range record.Tags {
+		logger := logrus.WithFields(logrus.Fields{"tag": change.Tag, "from": change.Old})
+		if change.New == "" {
+			logger.Info("Deleting tag created by the promotion")
+		} else {
+			logger.WithField("to", change.New).Info("Rolling back tag")
+		}
+	}
+	if dryRun {
+		logrus.Info("Running in dry-run mode, not updating the imagestream")
+		return nil
+	}
+	var errs []error
+	for _, change := range record.Tags {
+		if change.New != "" {
+			continue
+		}
+		isTag := &imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: fmt.Sprintf("%s:%s", key.Name, change.Tag)}}
+		if err := retry.OnError(retry.DefaultBackoff, func(err error) bool { return !kerrors.IsNotFound(err) }, func() error {
+			return client.Delete(ctx, isTag)
+		}); ctrlruntimeclient.IgnoreNotFound(err) != nil {
+			errs = append(errs, fmt.Errorf("could not delete imagestreamtag %s/%s: %w", isTag.Namespace, isTag.Name, err))
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("imagestream %s was rolled back, but tags created by the promotion remain: %w", key, utilerrors.NewAggregate(errs))
+	}
+	logrus.WithField("prowjob", prowJobID).Info("Promotion rolled back")
+	return nil
+}
+
+func main() {
+	logrusutil.ComponentInit()
+	o := parseOptions()
+	key, err := o.validate()
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	config, err := util.LoadClusterConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load cluster config")
+	}
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		logrus.WithError(err).Fatal("Failed to add imagev1 to scheme")
+	}
+	client, err := ctrlruntimeclient.New(config, ctrlruntimeclient.Options{Scheme: scheme})
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create client")
+	}
+
+	ctx := context.Background()
+	if o.list {
+		is := &imagev1.ImageStream{}
+		if err := client.Get(ctx, key, is); err != nil {
+			logrus.WithError(err).Fatal("Failed to get imagestream")
+		}
+		history, err := promotionhistory.History(is)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to read promotion history")
+		}
+		printHistory(history)
+		return
+	}
+
+	if err := rollback(ctx, client, key, o.prowJobID, o.force, o.dryRun); err != nil {
+		logrus.WithError(err).Fatal("Failed to roll back promotion")
+	}
+}
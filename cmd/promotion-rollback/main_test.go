@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/promotionhistory"
+)
+
+func TestRollback(t *testing.T) {
+	key := ctrlruntimeclient.ObjectKey{Namespace: "ocp", Name: "4.22"}
+	imageStream := func() *imagev1.ImageStream {
+		is := &imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+		for tag, pullSpec := range map[string]string{"cli": "registry/cli@sha256:2", "tests": "registry/tests@sha256:1", "tools": "registry/tools@sha256:1"} {
+			is.Spec.Tags = append(is.Spec.Tags, imagev1.TagReference{Name: tag, From: &coreapi.ObjectReference{Kind: "DockerImage", Name: pullSpec}})
+			is.Status.Tags = append(is.Status.Tags, imagev1.NamedTagEventList{Tag: tag, Items: []imagev1.TagEvent{{DockerImageReference: pullSpec}}})
+		}
+		if err := promotionhistory.AddRecord(is, promotionhistory.Record{ProwJobID: "pj-1", Tags: []promotionhistory.TagChange{
+			{Tag: "cli", Old: "registry/cli@sha256:1", New: "registry/cli@sha256:2"},
+			{Tag: "tests", New: "registry/tests@sha256:1"},
+			{Tag: "tools", New: "registry/tools@sha256:1"},
+		}}); err != nil {
+			t.Fatalf("failed to add record: %v", err)
+		}
+		return is
+	}
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add imagev1 to scheme: %v", err)
+	}
+
+	testCases := []struct {
+		name            string
+		conflicts       int
+		failDeletion    bool
+		expectedDeletes []string
+		expectedErr     string
+	}{
+		{
+			name:            "promotion is rolled back",
+			expectedDeletes: []string{"4.22:tests", "4.22:tools"},
+		},
+		{
+			name:            "conflicting update is retried",
+			conflicts:       2,
+			expectedDeletes: []string{"4.22:tests", "4.22:tools"},
+		},
+		{
+			name:         "every failed deletion is reported",
+			failDeletion: true,
+			expectedErr:  "imagestream ocp/4.22 was rolled back, but tags created by the promotion remain: [could not delete imagestreamtag ocp/4.22:tests: injected, could not delete imagestreamtag ocp/4.22:tools: injected]",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var deletes []string
+			conflicts := tc.conflicts
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(imageStream()).WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(ctx context.Context, client ctrlruntimeclient.WithWatch, obj ctrlruntimeclient.Object, opts ...ctrlruntimeclient.UpdateOption) error {
+					if conflicts > 0 {
+						conflicts--
+						return kerrors.NewConflict(schema.GroupResource{Group: "image.openshift.io", Resource: "imagestreams"}, obj.GetName(), errors.New("injected"))
+					}
+					return client.Update(ctx, obj, opts...)
+				},
+				Delete: func(_ context.Context, _ ctrlruntimeclient.WithWatch, obj ctrlruntimeclient.Object, _ ...ctrlruntimeclient.DeleteOption) error {
+					if tc.failDeletion {
+						return errors.New("injected")
+					}
+					deletes = append(deletes, obj.GetName())
+					return nil
+				},
+			}).Build()
+
+			err := rollback(context.Background(), client, key, "pj-1", false, false)
+			if actual := errString(err); actual != tc.expectedErr {
+				t.Fatalf("expected error %q, got %q", tc.expectedErr, actual)
+			}
+			if diff := cmp.Diff(tc.expectedDeletes, deletes); diff != "" {
+				t.Errorf("unexpected deletions: %s", diff)
+			}
+			is := &imagev1.ImageStream{}
+			if err := client.Get(context.Background(), key, is); err != nil {
+				t.Fatalf("failed to get imagestream: %v", err)
+			}
+			var tags []string
+			for _, tag := range is.Spec.Tags {
+				tags = append(tags, tag.Name+"="+tag.From.Name)
+			}
+			if diff := cmp.Diff("cli=registry/cli@sha256:1", strings.Join(tags, ",")); diff != "" {
+				t.Errorf("unexpected tags: %s", diff)
+			}
+		})
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/fs"
@@ -60,6 +61,12 @@ type options struct {
 
 	prometheusDaysBefore int
 
+	capacityAware       bool
+	minFreeCapacity     float64
+	maxFailureRate      float64
+	healthCheckInterval time.Duration
+	rebalanceReportPath string
+
 	upstreamBranch string
 	createPR       bool
 	githubLogin    string
@@ -92,6 +99,11 @@ func gatherOptions() options {
 	fs.StringVar(&o.clusterConfigPath, "cluster-config-path", "core-services/sanitize-prow-jobs/_clusters.yaml", "Path to the config file (core-services/sanitize-prow-jobs/_clusters.yaml in openshift/release)")
 	fs.StringVar(&o.jobsStoragePath, "jobs-storage-path", "", "Path to the file holding only job assignments in Gob format")
 	fs.IntVar(&o.prometheusDaysBefore, "prometheus-days-before", 14, "Number [1,15] of days before. Time 00-00-00 of that day will be used as time to query Prometheus. E.g., 1 means 00-00-00 of yesterday.")
+	fs.BoolVar(&o.capacityAware, "capacity-aware", false, "Query Prometheus for the free capacity and failure rate of clusters and move jobs away from saturated or unhealthy clusters.")
+	fs.Float64Var(&o.minFreeCapacity, "min-free-capacity", 0.1, "Fraction [0,1] of the peak number of concurrently running jobs of the last week that is unused, below which a cluster is considered saturated. Only used with --capacity-aware.")
+	fs.Float64Var(&o.maxFailureRate, "max-failure-rate", 0.2, "Fraction [0,1] of jobs completed in the last three hours that errored, above which a cluster is considered unhealthy. Only used with --capacity-aware.")
+	fs.DurationVar(&o.healthCheckInterval, "health-check-interval", time.Hour, "How often to check the health of clusters. Only used with --capacity-aware.")
+	fs.StringVar(&o.rebalanceReportPath, "rebalance-report-path", "", "If passed, write the jobs moved between clusters by each dispatch, and why, to this file in JSON format.")
 
 	fs.BoolVar(&o.createPR, "create-pr", false, "Create a pull request to the change made with this tool.")
 	fs.StringVar(&o.upstreamBranch, "upstream-branch", upstreamBranch, "Upstream branch where the PR should be created")
@@ -129,6 +141,18 @@ func (o *options) validate() error {
 		return fmt.Errorf("--prometheus-days-before must be between 1 and 15")
 	}
 
+	if o.minFreeCapacity < 0 || o.minFreeCapacity > 1 {
+		return fmt.Errorf("--min-free-capacity must be between 0 and 1")
+	}
+
+	if o.maxFailureRate < 0 || o.maxFailureRate > 1 {
+		return fmt.Errorf("--max-failure-rate must be between 0 and 1")
+	}
+
+	if o.capacityAware && o.healthCheckInterval <= 0 {
+		return fmt.Errorf("--health-check-interval must be positive")
+	}
+
 	if o.clusterConfigPath == "" {
 		logrus.Fatal("mandatory argument --cluster-config-path wasn't set")
 	}
@@ -206,6 +230,8 @@ type clusterVolume struct {
 	blocked            sets.Set[string]
 	volumeDistribution map[string]float64
 	clusterMap         dispatcher.ClusterMap
+	// problems holds the reason clusters should not receive more jobs
+	problems map[string]string
 }
 
 // findClusterForJobConfig finds a cluster running on a preferred cloud provider for the jobs in a Prow job config.
@@ -222,8 +248,9 @@ func (cv *clusterVolume) findClusterForJobConfig(cloudProvider string, jc *prowc
 	}
 
 	mostUsedCluster := dispatcher.FindMostUsedCluster(jc)
+	determinedCloudProvider := config.IsInBuildFarm(api.Cluster(mostUsedCluster))
 	// TODO: 75% as we still have manual assignments and these are affecting even distribution, re-evaluate when manual assignments are gone
-	if determinedCloudProvider := config.IsInBuildFarm(api.Cluster(mostUsedCluster)); determinedCloudProvider != "" &&
+	if _, problem := cv.problems[mostUsedCluster]; !problem && determinedCloudProvider != "" &&
 		cv.clusterVolumeMap[string(determinedCloudProvider)][mostUsedCluster] < cv.volumeDistribution[mostUsedCluster]*0.75 {
 		cluster = mostUsedCluster
 	} else {
@@ -234,6 +261,9 @@ func (cv *clusterVolume) findClusterForJobConfig(cloudProvider string, jc *prowc
 				if cv.clusterMap[c].Capacity != 100 {
 					continue
 				}
+				if _, problem := cv.problems[c]; problem {
+					continue
+				}
 				if cloudProvider == "" || cloudProvider == cp {
 					if min < 0 || min > v {
 						min = v
@@ -398,7 +428,8 @@ type fileSizeInfo struct {
 //   - When all the e2e tests are targeting the same cloud provider, we run the test pod on the that cloud provider too.
 //   - When the e2e tests are targeting different cloud providers, or there is no e2e tests at all, we can run the tests
 //     on any cluster in the build farm. Those jobs are used to load balance the workload of clusters in the build farm.
-func dispatchJobs(prowJobConfigDir string, config *dispatcher.Config, jobVolumes map[string]float64, blocked sets.Set[string], volumeDistribution map[string]float64, cm dispatcher.ClusterMap) (map[string]dispatcher.ProwJobData, error) {
+//   - Clusters with problems, e.g. saturated ones, do not receive jobs that can run anywhere in the build farm.
+func dispatchJobs(prowJobConfigDir string, config *dispatcher.Config, jobVolumes map[string]float64, blocked sets.Set[string], volumeDistribution map[string]float64, cm dispatcher.ClusterMap, problems map[string]string) (map[string]dispatcher.ProwJobData, error) {
 	if config == nil {
 		return nil, fmt.Errorf("config is nil")
 	}
@@ -411,7 +442,8 @@ func dispatchJobs(prowJobConfigDir string, config *dispatcher.Config, jobVolumes
 		blocked:            blocked,
 		specialClusters:    map[string]float64{},
 		volumeDistribution: volumeDistribution,
-		clusterMap:         cm}
+		clusterMap:         cm,
+		problems:           problems}
 	for cloudProvider, v := range config.BuildFarm {
 		for cluster := range v {
 			cloudProviderString := string(cloudProvider)
@@ -633,7 +665,7 @@ func cleanup(directory string) {
 
 // createPR creates PR with config changes and sanitizer changes, it causes app to exit in
 // case of failure to trigger re-run of logic
-func createPR(o options, config *dispatcher.Config, pjs map[string]dispatcher.ProwJobData, cm dispatcher.ClusterMap) {
+func createPR(o options, config *dispatcher.Config, pjs map[string]dispatcher.ProwJobData, cm dispatcher.ClusterMap, movesSummary string) {
 	targetDirWithRelease := filepath.Join(o.targetDir, "/release")
 	cleanup(targetDirWithRelease)
 	defer cleanup(targetDirWithRelease)
@@ -655,12 +687,30 @@ func createPR(o options, config *dispatcher.Config, pjs map[string]dispatcher.Pr
 		logrus.WithError(err).Fatal("failed to determinize")
 	}
 
+	body := o.prBody
+	if movesSummary != "" {
+		body = strings.TrimSpace(body + "\n\n" + movesSummary)
+	}
 	title := fmt.Sprintf("%s at %s", matchTitle, time.Now().Format(time.RFC1123))
-	if err := o.PRCreationOptions.UpsertPR(targetDirWithRelease, githubOrg, githubRepo, o.upstreamBranch, title, prcreation.PrAssignee(o.assign), prcreation.MatchTitle(matchTitle), prcreation.AdditionalLabels([]string{rehearse.RehearsalsAckLabel, "priority/ci-critical"}), prcreation.PrBody(o.prBody)); err != nil {
+	if err := o.PRCreationOptions.UpsertPR(targetDirWithRelease, githubOrg, githubRepo, o.upstreamBranch, title, prcreation.PrAssignee(o.assign), prcreation.MatchTitle(matchTitle), prcreation.AdditionalLabels([]string{rehearse.RehearsalsAckLabel, "priority/ci-critical"}), prcreation.PrBody(body)); err != nil {
 		logrus.WithError(err).Fatal("failed to upsert PR")
 	}
 }
 
+// rebalanceReport lists the jobs moved between clusters by a dispatch
+type rebalanceReport struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Moves     []dispatcher.Move `json:"moves"`
+}
+
+func writeRebalanceReport(path string, moves []dispatcher.Move) error {
+	raw, err := json.MarshalIndent(rebalanceReport{Timestamp: time.Now(), Moves: moves}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rebalance report: %w", err)
+	}
+	return os.WriteFile(path, raw, 0644)
+}
+
 func sendSlackMessage(slackClient slackClient, channelId string) error {
 	blockMessage := slack.MsgOptionBlocks(
 		slack.NewSectionBlock(
@@ -734,6 +784,8 @@ func main() {
 
 	{
 		var mu sync.Mutex
+		// dispatchedProblems holds the problems of clusters during the last dispatch
+		var dispatchedProblems map[string]string
 		slackClient := slack.New(string(secret.GetSecret(o.slackTokenPath)))
 
 		dispatchDeltaWrapper = func() {
@@ -783,9 +835,24 @@ func main() {
 
 			newBlockedClusters := prowjobs.HasAnyOfClusters(blocked)
 
-			if (!forceDispatch && enabled.Len() == 0 && disabled.Len() == 0) && !newBlockedClusters {
+			var problems map[string]string
+			if o.capacityAware {
+				health, err := promVolumes.GetClusterHealth()
+				if err != nil {
+					logrus.WithError(err).Warn("failed to get cluster health, assuming it did not change")
+					problems = dispatchedProblems
+				} else {
+					problems = dispatcher.HealthThresholds{MinFreeCapacity: o.minFreeCapacity, MaxFailureRate: o.maxFailureRate}.Problems(health)
+				}
+			}
+			problemsChanged := !sets.KeySet(dispatchedProblems).Equal(sets.KeySet(problems))
+
+			if (!forceDispatch && enabled.Len() == 0 && disabled.Len() == 0) && !newBlockedClusters && !problemsChanged {
 				return
 			}
+			for cluster, problem := range problems {
+				logrus.WithField("cluster", cluster).WithField("problem", problem).Info("not dispatching relocatable jobs to cluster")
+			}
 
 			jobVolumes, err := promVolumes.GetJobVolumes()
 			if err != nil {
@@ -800,12 +867,24 @@ func main() {
 					}
 					return api.Cloud(info.Provider), nil
 				})
-			pjs, err := dispatchJobs(o.prowJobConfigDir, config, jobVolumes, blocked, promVolumes.CalculateVolumeDistribution(configClusterMap), configClusterMap)
+			previous := prowjobs.GetDataCopy()
+			pjs, err := dispatchJobs(o.prowJobConfigDir, config, jobVolumes, blocked, promVolumes.CalculateVolumeDistribution(configClusterMap), configClusterMap, problems)
 			if err != nil {
 				logrus.WithError(err).Error("failed to dispatch")
 				return
 			}
 			prowjobs.Regenerate(pjs)
+			dispatchedProblems = problems
+
+			moves := dispatcher.DiffAssignments(previous, pjs, problems)
+			for _, move := range moves {
+				logrus.WithField("job", move.Job).WithField("from", move.From).WithField("to", move.To).WithField("reason", move.Reason).Info("moved job to another cluster")
+			}
+			if o.rebalanceReportPath != "" {
+				if err := writeRebalanceReport(o.rebalanceReportPath, moves); err != nil {
+					logrus.WithError(err).Error("failed to write rebalance report")
+				}
+			}
 
 			ecd.Reset(clustersFromConfig.UnsortedList())
 
@@ -814,7 +893,7 @@ func main() {
 			}
 
 			if o.createPR {
-				createPR(o, config, pjs, configClusterMap)
+				createPR(o, config, pjs, configClusterMap, dispatcher.SummarizeMoves(moves))
 				if err := sendSlackMessage(slackClient, o.opsChannelId); err != nil {
 					logrus.WithError(err).Error("Failed to post message in ops channel")
 				}
@@ -843,6 +922,14 @@ func main() {
 		deltaTicker := time.NewTicker(5 * time.Minute)
 		defer deltaTicker.Stop()
 
+		// Without capacity awareness, the health ticker never fires
+		var healthTicks <-chan time.Time
+		if o.capacityAware {
+			healthTicker := time.NewTicker(o.healthCheckInterval)
+			defer healthTicker.Stop()
+			healthTicks = healthTicker.C
+		}
+
 		prevConfigClusterMap, prevBlocked, err := dispatcher.LoadClusterConfig(config)
 		if err != nil {
 			logrus.WithError(err).Fatal("failed to load initial cluster config")
@@ -870,6 +957,10 @@ func main() {
 
 			case <-deltaTicker.C:
 				dispatchDeltaWrapper()
+
+			case <-healthTicks:
+				// dispatches only if the set of clusters with problems changed
+				dispatchWrapper(false)
 			}
 		}
 	}(o.clusterConfigPath)
@@ -116,7 +116,7 @@ func TestDispatchJobs(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, actual := dispatchJobs(tc.prowJobConfigDir, tc.config, tc.jobVolumes, sets.New[string](), tc.distribution, tc.clusterMap)
+			_, actual := dispatchJobs(tc.prowJobConfigDir, tc.config, tc.jobVolumes, sets.New[string](), tc.distribution, tc.clusterMap, nil)
 			equalError(t, tc.expected, actual)
 			if tc.config != nil && !reflect.DeepEqual(tc.expectedBuildFarm, tc.config.BuildFarm) {
 				t.Errorf("%s: actual differs from expected:\n%s", t.Name(), cmp.Diff(tc.expectedBuildFarm, tc.config.BuildFarm))
@@ -171,6 +171,33 @@ func TestDispatchJobConfig(t *testing.T) {
 			},
 			expected: "build01",
 		},
+		{
+			name: "non e2e job avoids saturated build01",
+			cv: &clusterVolume{
+				clusterVolumeMap: map[string]map[string]float64{"aws": {"build01": 0}, "gcp": {"build02": 5}},
+				cloudProviders:   sets.New[string]("aws", "gcp"),
+				pjs:              map[string]dispatcher.ProwJobData{},
+				volumeDistribution: map[string]float64{
+					"build01": 21,
+					"build02": 21,
+				},
+				clusterMap: clusterMap,
+				problems:   map[string]string{"build01": "saturated"},
+			},
+			config: &c,
+			jc: &prowconfig.JobConfig{
+				PresubmitsStatic: map[string][]prowconfig.Presubmit{
+					"repo": {{JobBase: prowconfig.JobBase{Name: "job",
+						Spec: &corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Env: []corev1.EnvVar{{Name: "CLUSTER_TYPE", Value: "openstack"}}},
+							},
+						}}}},
+				},
+			},
+			path:     "repo-presubmits.yaml",
+			expected: "build02",
+		},
 		{
 			name: "basic case: aws e2e job chooses build01",
 			cv: &clusterVolume{
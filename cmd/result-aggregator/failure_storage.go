@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+// failureNameLayout prefixes the names of persisted failure records, it has
+// a fixed width so that names sort in the order the failures happened in
+const failureNameLayout = "20060102T150405.000000000Z"
+
+// failureRecordName determines the name a record is persisted under
+func failureRecordName(record results.FailureRecord) string {
+	return fmt.Sprintf("%s_%s_%s.json", record.Timestamp.UTC().Format(failureNameLayout), record.JobName, record.BuildID)
+}
+
+// failureBackend persists failure records so that they survive restarts and
+// are shared between replicas
+type failureBackend interface {
+	// write persists a record under the name
+	write(ctx context.Context, name string, record results.FailureRecord) error
+	// list returns the names of records that happened at or after since
+	list(ctx context.Context, since time.Time) ([]string, error)
+	// read loads a persisted record
+	read(ctx context.Context, name string) (results.FailureRecord, error)
+}
+
+// bucketFailureBackend persists records as objects in a GCS bucket; records
+// are never deleted, a lifecycle rule on the bucket should expire them
+type bucketFailureBackend struct {
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+func (b *bucketFailureBackend) write(ctx context.Context, name string, record results.FailureRecord) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal failure record: %w", err)
+	}
+	writer := b.bucket.Object(path.Join(b.prefix, name)).NewWriter(ctx)
+	writer.ContentType = "application/json"
+	if _, err := writer.Write(raw); err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("failed to write failure record %s: %w", name, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write failure record %s: %w", name, err)
+	}
+	return nil
+}
+
+func (b *bucketFailureBackend) list(ctx context.Context, since time.Time) ([]string, error) {
+	prefix := b.prefix + "/"
+	objects := b.bucket.Objects(ctx, &storage.Query{Prefix: prefix, StartOffset: prefix + since.UTC().Format(failureNameLayout)})
+	var names []string
+	for {
+		attrs, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list failure records: %w", err)
+		}
+		names = append(names, strings.TrimPrefix(attrs.Name, prefix))
+	}
+	return names, nil
+}
+
+func (b *bucketFailureBackend) read(ctx context.Context, name string) (results.FailureRecord, error) {
+	var record results.FailureRecord
+	reader, err := b.bucket.Object(path.Join(b.prefix, name)).NewReader(ctx)
+	if err != nil {
+		return record, fmt.Errorf("failed to read failure record %s: %w", name, err)
+	}
+	defer reader.Close()
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return record, fmt.Errorf("failed to read failure record %s: %w", name, err)
+	}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return record, fmt.Errorf("failed to unmarshal failure record %s: %w", name, err)
+	}
+	return record, nil
+}
+
+// dirFailureBackend persists records as files in a directory, e.g. on a
+// persistent volume shared by the replicas
+type dirFailureBackend struct {
+	dir string
+}
+
+func (d *dirFailureBackend) write(_ context.Context, name string, record results.FailureRecord) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal failure record: %w", err)
+	}
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create failure record directory: %w", err)
+	}
+	return os.WriteFile(filepath.Join(d.dir, name), raw, 0644)
+}
+
+func (d *dirFailureBackend) list(_ context.Context, since time.Time) ([]string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list failure records: %w", err)
+	}
+	cutoff := since.UTC().Format(failureNameLayout)
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && entry.Name() >= cutoff {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (d *dirFailureBackend) read(_ context.Context, name string) (results.FailureRecord, error) {
+	var record results.FailureRecord
+	raw, err := os.ReadFile(filepath.Join(d.dir, name))
+	if err != nil {
+		return record, fmt.Errorf("failed to read failure record %s: %w", name, err)
+	}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return record, fmt.Errorf("failed to unmarshal failure record %s: %w", name, err)
+	}
+	return record, nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+var failureRate = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "ci_operator_failures",
+		Help: "number of classified failures, sorted by label/type",
+	},
+	[]string{"type", "reason", "classification", "cluster"},
+)
+
+func init() {
+	prometheus.MustRegister(failureRate)
+}
+
+const (
+	defaultFailureQueryPeriod   = 24 * time.Hour
+	defaultFailureQueryInterval = time.Hour
+)
+
+// failureStore caches the failure records persisted within the retention
+// period; records received by other replicas are loaded on refresh. Without
+// a backend, records are only kept in memory and lost on restart.
+type failureStore struct {
+	lock      sync.RWMutex
+	retention time.Duration
+	now       func() time.Time
+	backend   failureBackend
+	records   map[string]results.FailureRecord
+}
+
+func newFailureStore(retention time.Duration, backend failureBackend) *failureStore {
+	return &failureStore{retention: retention, now: time.Now, backend: backend, records: map[string]results.FailureRecord{}}
+}
+
+// add persists the record before it is considered in queries
+func (s *failureStore) add(ctx context.Context, record results.FailureRecord) error {
+	name := failureRecordName(record)
+	if s.backend != nil {
+		if err := s.backend.write(ctx, name, record); err != nil {
+			return err
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.records[name] = record
+	s.prune()
+	return nil
+}
+
+// refresh loads the records persisted since the last refresh
+func (s *failureStore) refresh(ctx context.Context) error {
+	if s.backend == nil {
+		return nil
+	}
+	names, err := s.backend.list(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	s.lock.RLock()
+	var missing []string
+	for _, name := range names {
+		if _, loaded := s.records[name]; !loaded {
+			missing = append(missing, name)
+		}
+	}
+	s.lock.RUnlock()
+	loaded := map[string]results.FailureRecord{}
+	var errs []error
+	for _, name := range missing {
+		record, err := s.backend.read(ctx, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaded[name] = record
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	maps.Copy(s.records, loaded)
+	s.prune()
+	return utilerrors.NewAggregate(errs)
+}
+
+// prune drops records older than the retention, the caller must hold the lock
+func (s *failureStore) prune() {
+	cutoff := s.now().Add(-s.retention)
+	for name, r := range s.records {
+		if !r.Timestamp.After(cutoff) {
+			delete(s.records, name)
+		}
+	}
+}
+
+// failureQuery selects failures to aggregate
+type failureQuery struct {
+	// reason matches failures with this chain of reasons or chains it is a
+	// prefix of, e.g. `step_failed` matches `step_failed:acquiring_lease`
+	reason         string
+	classification results.Classification
+	jobName        string
+	since          time.Time
+	interval       time.Duration
+}
+
+func (q failureQuery) matches(record results.FailureRecord, failure results.Failure) bool {
+	return (q.reason == "" || failure.Reason == q.reason || strings.HasPrefix(failure.Reason, q.reason+":")) &&
+		(q.classification == "" || failure.Classification == q.classification) &&
+		(q.jobName == "" || record.JobName == q.jobName)
+}
+
+// failureBucket counts failures with a reason within an interval
+type failureBucket struct {
+	Start          time.Time              `json:"start"`
+	Reason         string                 `json:"reason"`
+	Classification results.Classification `json:"classification"`
+	Count          int                    `json:"count"`
+}
+
+type failureQueryResponse struct {
+	Since    time.Time       `json:"since"`
+	Interval string          `json:"interval"`
+	Buckets  []failureBucket `json:"buckets"`
+}
+
+// aggregate counts matching failures by reason in intervals since the start
+// of the query
+func (s *failureStore) aggregate(query failureQuery) []failureBucket {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	type key struct {
+		start          time.Time
+		reason         string
+		classification results.Classification
+	}
+	counts := map[key]int{}
+	for _, record := range s.records {
+		if record.Timestamp.Before(query.since) {
+			continue
+		}
+		start := query.since.Add(record.Timestamp.Sub(query.since).Truncate(query.interval))
+		for _, failure := range record.Failures {
+			if query.matches(record, failure) {
+				counts[key{start: start, reason: failure.Reason, classification: failure.Classification}]++
+			}
+		}
+	}
+	buckets := []failureBucket{}
+	for k, count := range counts {
+		buckets = append(buckets, failureBucket{Start: k.start, Reason: k.reason, Classification: k.classification, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if !buckets[i].Start.Equal(buckets[j].Start) {
+			return buckets[i].Start.Before(buckets[j].Start)
+		}
+		return buckets[i].Reason < buckets[j].Reason
+	})
+	return buckets
+}
+
+func validateFailureRecord(record *results.FailureRecord) error {
+	if record.Version != results.FailureRecordVersion {
+		return fmt.Errorf("unsupported version %q, expected %q", record.Version, results.FailureRecordVersion)
+	}
+	if record.JobName == "" {
+		return fmt.Errorf("job_name field in request is empty")
+	}
+	if record.Type == "" {
+		return fmt.Errorf("type field in request is empty")
+	}
+	if record.Cluster == "" {
+		return fmt.Errorf("cluster field in request is empty")
+	}
+	if len(record.Failures) == 0 {
+		return fmt.Errorf("failures field in request is empty")
+	}
+	for i, failure := range record.Failures {
+		if failure.Reason == "" {
+			return fmt.Errorf("reason field of failure %d in request is empty", i)
+		}
+	}
+	return nil
+}
+
+func handleFailureRecord(store *failureStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("Method %v not allowed, POST requests only.", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		bytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, fmt.Errorf("unable to read failure record request body: %w", err))
+			return
+		}
+
+		record := &results.FailureRecord{}
+		if err := json.Unmarshal(bytes, record); err != nil {
+			handleError(w, fmt.Errorf("unable to decode failure record request body: %w", err))
+			return
+		}
+
+		if err := validateFailureRecord(record); err != nil {
+			handleError(w, err)
+			return
+		}
+		if record.Timestamp.IsZero() {
+			record.Timestamp = store.now()
+		}
+
+		if err := store.add(r.Context(), *record); err != nil {
+			log.WithError(err).Error("Failed to persist failure record")
+			http.Error(w, fmt.Sprintf("failed to persist failure record: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for _, failure := range record.Failures {
+			failureRate.With(prometheus.Labels{
+				"type":           record.Type,
+				"reason":         failure.Reason,
+				"classification": string(failure.Classification),
+				"cluster":        record.Cluster,
+			}).Inc()
+		}
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{"job_name": record.JobName, "failures": len(record.Failures), "duration": time.Since(start).String()}).Info("Failure record processed")
+	}
+}
+
+func parseFailureQuery(r *http.Request, now time.Time) (failureQuery, error) {
+	values := r.URL.Query()
+	query := failureQuery{
+		reason:         values.Get("reason"),
+		classification: results.Classification(values.Get("classification")),
+		jobName:        values.Get("job_name"),
+		since:          now.Add(-defaultFailureQueryPeriod),
+		interval:       defaultFailureQueryInterval,
+	}
+	if raw := values.Get("since"); raw != "" {
+		period, err := time.ParseDuration(raw)
+		if err != nil {
+			return query, fmt.Errorf("parameter \"since\" is not a valid duration: %s", raw)
+		}
+		query.since = now.Add(-period)
+	}
+	if raw := values.Get("interval"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			return query, fmt.Errorf("parameter \"interval\" is not a valid duration: %s", raw)
+		}
+		query.interval = interval
+	}
+	return query, nil
+}
+
+// handleFailureQuery aggregates failures by reason over time
+func handleFailureQuery(store *failureStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, fmt.Sprintf("Method %v not allowed, GET requests only.", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		query, err := parseFailureQuery(r, store.now())
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		raw, err := json.Marshal(failureQueryResponse{
+			Since:    query.since,
+			Interval: query.interval.String(),
+			Buckets:  store.aggregate(query),
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to marshal response: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(raw); err != nil {
+			log.WithError(err).Warn("Failed to write failure query response")
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/results"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestValidateFailureRecord(t *testing.T) {
+	valid := func() results.FailureRecord {
+		return results.FailureRecord{
+			Version:  results.FailureRecordVersion,
+			JobName:  "job",
+			Type:     "presubmit",
+			Cluster:  "build01",
+			Failures: []results.Failure{{Reason: "step_failed", Classification: results.ClassificationTest}},
+		}
+	}
+	testCases := []struct {
+		name     string
+		mutate   func(*results.FailureRecord)
+		expected error
+	}{
+		{
+			name:   "valid",
+			mutate: func(*results.FailureRecord) {},
+		},
+		{
+			name:     "unsupported version",
+			mutate:   func(r *results.FailureRecord) { r.Version = "v0" },
+			expected: fmt.Errorf(`unsupported version "v0", expected "v1"`),
+		},
+		{
+			name:     "no failures",
+			mutate:   func(r *results.FailureRecord) { r.Failures = nil },
+			expected: fmt.Errorf("failures field in request is empty"),
+		},
+		{
+			name:     "failure without reason",
+			mutate:   func(r *results.FailureRecord) { r.Failures[0].Reason = "" },
+			expected: fmt.Errorf("reason field of failure 0 in request is empty"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			record := valid()
+			tc.mutate(&record)
+			if diff := cmp.Diff(tc.expected, validateFailureRecord(&record), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFailureRecordsAreAggregated(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	store := newFailureStore(48*time.Hour, &dirFailureBackend{dir: t.TempDir()})
+	store.now = func() time.Time { return now }
+
+	post := func(record results.FailureRecord) {
+		raw, err := json.Marshal(record)
+		if err != nil {
+			t.Fatalf("failed to marshal record: %v", err)
+		}
+		recorder := httptest.NewRecorder()
+		handleFailureRecord(store)(recorder, httptest.NewRequest(http.MethodPost, "/failure", strings.NewReader(string(raw))))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("unexpected status posting record: %d: %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	record := func(age time.Duration, job string, failures ...results.Failure) results.FailureRecord {
+		return results.FailureRecord{
+			Version:   results.FailureRecordVersion,
+			JobName:   job,
+			Type:      "presubmit",
+			Cluster:   "build01",
+			Timestamp: now.Add(-age),
+			Failures:  failures,
+		}
+	}
+	lease := results.Failure{Reason: "step_failed:acquiring_lease", Classification: results.ClassificationInfrastructure}
+	test := results.Failure{Reason: "step_failed:executing_multi_stage_test", Classification: results.ClassificationTest, Step: "e2e"}
+	post(record(72*time.Hour, "old", lease))
+	post(record(90*time.Minute, "a", lease, test))
+	post(record(80*time.Minute, "b", lease))
+	post(record(10*time.Minute, "a", test))
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected failureQueryResponse
+	}{
+		{
+			name:  "all failures in the last day by hour",
+			query: "",
+			expected: failureQueryResponse{
+				Since:    now.Add(-24 * time.Hour),
+				Interval: "1h0m0s",
+				Buckets: []failureBucket{
+					{Start: now.Add(-2 * time.Hour), Reason: "step_failed:acquiring_lease", Classification: results.ClassificationInfrastructure, Count: 2},
+					{Start: now.Add(-2 * time.Hour), Reason: "step_failed:executing_multi_stage_test", Classification: results.ClassificationTest, Count: 1},
+					{Start: now.Add(-time.Hour), Reason: "step_failed:executing_multi_stage_test", Classification: results.ClassificationTest, Count: 1},
+				},
+			},
+		},
+		{
+			name:  "infrastructure failures by reason prefix",
+			query: "?reason=step_failed&classification=infrastructure&since=4h&interval=4h",
+			expected: failureQueryResponse{
+				Since:    now.Add(-4 * time.Hour),
+				Interval: "4h0m0s",
+				Buckets: []failureBucket{
+					{Start: now.Add(-4 * time.Hour), Reason: "step_failed:acquiring_lease", Classification: results.ClassificationInfrastructure, Count: 2},
+				},
+			},
+		},
+		{
+			name:  "failures of a job",
+			query: "?job_name=b",
+			expected: failureQueryResponse{
+				Since:    now.Add(-24 * time.Hour),
+				Interval: "1h0m0s",
+				Buckets: []failureBucket{
+					{Start: now.Add(-2 * time.Hour), Reason: "step_failed:acquiring_lease", Classification: results.ClassificationInfrastructure, Count: 1},
+				},
+			},
+		},
+		{
+			name:  "no matches",
+			query: "?reason=step",
+			expected: failureQueryResponse{
+				Since:    now.Add(-24 * time.Hour),
+				Interval: "1h0m0s",
+				Buckets:  []failureBucket{},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handleFailureQuery(store)(recorder, httptest.NewRequest(http.MethodGet, "/failures"+tc.query, nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("unexpected status: %d: %s", recorder.Code, recorder.Body.String())
+			}
+			var actual failureQueryResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &actual); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected response: %s", diff)
+			}
+		})
+	}
+
+	if len(store.records) != 3 {
+		t.Errorf("expected records older than the retention to be dropped, got %d records", len(store.records))
+	}
+}
+
+func TestFailureQueryInvalidParameters(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	handleFailureQuery(newFailureStore(time.Hour, &dirFailureBackend{dir: t.TempDir()}))(recorder, httptest.NewRequest(http.MethodGet, "/failures?interval=0s", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected invalid interval to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestFailureStoreLoadsPersistedRecords(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	backend := &dirFailureBackend{dir: t.TempDir()}
+	newStore := func() *failureStore {
+		store := newFailureStore(48*time.Hour, backend)
+		store.now = func() time.Time { return now }
+		return store
+	}
+	record := func(age time.Duration, buildID string) results.FailureRecord {
+		return results.FailureRecord{
+			Version:   results.FailureRecordVersion,
+			JobName:   "job",
+			BuildID:   buildID,
+			Type:      "presubmit",
+			Cluster:   "build01",
+			Timestamp: now.Add(-age),
+			Failures:  []results.Failure{{Reason: "step_failed", Classification: results.ClassificationTest}},
+		}
+	}
+	ctx := context.Background()
+	first, second := newStore(), newStore()
+	for _, r := range []results.FailureRecord{record(72*time.Hour, "1"), record(time.Hour, "2")} {
+		if err := first.add(ctx, r); err != nil {
+			t.Fatalf("failed to add record: %v", err)
+		}
+	}
+	if err := second.add(ctx, record(time.Minute, "3")); err != nil {
+		t.Fatalf("failed to add record: %v", err)
+	}
+
+	// a replica loads records of others, a restarted one its own
+	for name, store := range map[string]*failureStore{"other replica": first, "restarted": newStore()} {
+		if err := store.refresh(ctx); err != nil {
+			t.Fatalf("%s: failed to refresh: %v", name, err)
+		}
+		var buildIDs []string
+		for _, r := range store.records {
+			buildIDs = append(buildIDs, r.BuildID)
+		}
+		sort.Strings(buildIDs)
+		if diff := cmp.Diff([]string{"2", "3"}, buildIDs); diff != "" {
+			t.Errorf("%s: unexpected records: %s", name, diff)
+		}
+	}
+}
+
+func TestFailureStoreWithoutBackend(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	store := newFailureStore(48*time.Hour, nil)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	record := results.FailureRecord{
+		Version:   results.FailureRecordVersion,
+		JobName:   "job",
+		BuildID:   "1",
+		Type:      "presubmit",
+		Cluster:   "build01",
+		Timestamp: now.Add(-time.Hour),
+		Failures:  []results.Failure{{Reason: "step_failed", Classification: results.ClassificationTest}},
+	}
+	if err := store.add(ctx, record); err != nil {
+		t.Fatalf("failed to add record: %v", err)
+	}
+	if err := store.refresh(ctx); err != nil {
+		t.Fatalf("failed to refresh: %v", err)
+	}
+	if len(store.records) != 1 {
+		t.Errorf("expected the record to be kept in memory, got %v", store.records)
+	}
+}
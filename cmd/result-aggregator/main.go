@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -11,9 +12,11 @@ import (
 	"os"
 	"time"
 
+	"cloud.google.com/go/storage"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/option"
 
 	prowConfig "sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/flagutil"
@@ -51,6 +54,12 @@ type options struct {
 	address     string
 	gracePeriod time.Duration
 	passwdFile  string
+
+	failureRetention   time.Duration
+	failureRefresh     time.Duration
+	failureBucket      string
+	failureDir         string
+	gcsCredentialsFile string
 }
 
 func gatherOptions() (options, error) {
@@ -60,6 +69,11 @@ func gatherOptions() (options, error) {
 	fs.StringVar(&o.address, "address", ":8080", "Address to run server on")
 	fs.DurationVar(&o.gracePeriod, "gracePeriod", time.Second*10, "Grace period for server shutdown")
 	fs.StringVar(&o.passwdFile, "passwd-file", "", "Authenticate against a file. Each line of the file is with the form `<username>:<password>`.")
+	fs.DurationVar(&o.failureRetention, "failure-retention", 14*24*time.Hour, "How long to keep failure records for queries.")
+	fs.DurationVar(&o.failureRefresh, "failure-refresh-interval", time.Minute, "How often to load failure records persisted by other replicas.")
+	fs.StringVar(&o.failureBucket, "failure-bucket", "", "GCS bucket to persist failure records in, shared by all replicas. Records are never deleted, configure a lifecycle rule on the bucket to expire them. Without it or --failure-dir, failure records are only kept in memory.")
+	fs.StringVar(&o.failureDir, "failure-dir", "", "Directory to persist failure records in, as an alternative to --failure-bucket.")
+	fs.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "File where GCS credentials are stored.")
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		return o, fmt.Errorf("failed to parse flags: %w", err)
 	}
@@ -74,9 +88,31 @@ func validateOptions(o options) error {
 	if o.passwdFile == "" {
 		return errors.New("--passwd-file must be specified")
 	}
+	if o.failureBucket != "" && o.failureDir != "" {
+		return errors.New("--failure-bucket and --failure-dir are mutually exclusive")
+	}
+	if o.failureBucket != "" && o.gcsCredentialsFile == "" {
+		return errors.New("--gcs-credentials-file must be specified with --failure-bucket")
+	}
 	return nil
 }
 
+// failureBackend creates the backend to persist failure records in, if any
+// was configured
+func (o options) failureBackend(ctx context.Context) (failureBackend, error) {
+	switch {
+	case o.failureDir != "":
+		return &dirFailureBackend{dir: o.failureDir}, nil
+	case o.failureBucket == "":
+		return nil, nil
+	}
+	client, err := storage.NewClient(ctx, option.WithCredentialsFile(o.gcsCredentialsFile))
+	if err != nil {
+		return nil, fmt.Errorf("could not create GCS client: %w", err)
+	}
+	return &bucketFailureBackend{bucket: client.Bucket(o.failureBucket), prefix: "failures"}, nil
+}
+
 func validateRequest(request *results.Request) error {
 	if request.Reason == "" {
 		return fmt.Errorf("reason field in request is empty")
@@ -242,6 +278,21 @@ func main() {
 
 	http.Handle("/result", loginHandler(validator, handleCIOperatorResult()))
 	http.Handle("/pod-scaler", loginHandler(validator, handlePodScalerResult()))
+	backend, err := o.failureBackend(interrupts.Context())
+	if err != nil {
+		log.WithError(err).Fatal("failed to create failure record backend")
+	}
+	failures := newFailureStore(o.failureRetention, backend)
+	if err := failures.refresh(interrupts.Context()); err != nil {
+		log.WithError(err).Fatal("failed to load failure records")
+	}
+	interrupts.TickLiteral(func() {
+		if err := failures.refresh(interrupts.Context()); err != nil {
+			log.WithError(err).Warn("Failed to refresh failure records")
+		}
+	}, o.failureRefresh)
+	http.Handle("/failure", loginHandler(validator, handleFailureRecord(failures)))
+	http.Handle("/failures", loginHandler(validator, handleFailureQuery(failures)))
 
 	metrics.ExposeMetrics("result-aggregator", prowConfig.PushGateway{}, flagutil.DefaultMetricsPort)
 
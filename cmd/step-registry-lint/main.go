@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry/lint"
+)
+
+type options struct {
+	registryDir       string
+	configDir         string
+	output            string
+	githubAnnotations bool
+	failOnFindings    bool
+}
+
+func parseOptions() options {
+	o := options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&o.registryDir, "registry", "", "Path to the step registry directory")
+	fs.StringVar(&o.configDir, "config-dir", "", "Path to the ci-operator configuration directory")
+	fs.StringVar(&o.output, "output", "", "Path to write the JSON report to, - for stdout")
+	fs.BoolVar(&o.githubAnnotations, "github-annotations", false, "Print the findings as GitHub Actions annotations")
+	fs.BoolVar(&o.failOnFindings, "fail-on-findings", false, "Exit with a non-zero code when there are findings")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		logrus.WithError(err).Fatal("could not parse args")
+	}
+	return o
+}
+
+func (o *options) validate() error {
+	if o.registryDir == "" {
+		return fmt.Errorf("--registry is required")
+	}
+	if o.configDir == "" {
+		return fmt.Errorf("--config-dir is required")
+	}
+	if o.output == "" && !o.githubAnnotations {
+		return fmt.Errorf("at least one of --output and --github-annotations is required")
+	}
+	return nil
+}
+
+func loadRegistry(root string) (lint.Registry, error) {
+	refs, chains, workflows, _, _, metadata, observers, err := load.Registry(root, load.RegistryMetadata)
+	if err != nil {
+		return lint.Registry{}, err
+	}
+	paths := map[string]string{}
+	for name, info := range metadata {
+		paths[name] = filepath.Join(root, info.Path)
+	}
+	return lint.Registry{References: refs, Chains: chains, Workflows: workflows, Observers: observers, Paths: paths}, nil
+}
+
+func loadTests(configDir string) ([]lint.Test, error) {
+	var tests []lint.Test
+	err := config.OperateOnCIOperatorConfigDir(configDir, func(configuration *api.ReleaseBuildConfiguration, info *config.Info) error {
+		for _, test := range configuration.Tests {
+			if test.MultiStageTestConfiguration == nil {
+				continue
+			}
+			tests = append(tests, lint.Test{
+				Name:   fmt.Sprintf("%s:%s", info.Metadata.AsString(), test.As),
+				File:   info.Filename,
+				Config: *test.MultiStageTestConfiguration,
+			})
+		}
+		return nil
+	})
+	return tests, err
+}
+
+func writeReport(path string, findings []lint.Finding) error {
+	if findings == nil {
+		findings = []lint.Finding{}
+	}
+	raw, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the report: %w", err)
+	}
+	raw = append(raw, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(raw)
+		return err
+	}
+	return os.WriteFile(path, raw, 0644)
+}
+
+// escapeData and escapeProperty follow the escaping GitHub Actions expects in workflow commands
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeData(s))
+}
+
+func writeAnnotations(w io.Writer, findings []lint.Finding) error {
+	for _, finding := range findings {
+		properties := []string{fmt.Sprintf("title=%s", escapeProperty(string(finding.Kind)))}
+		if finding.File != "" {
+			properties = append([]string{fmt.Sprintf("file=%s", escapeProperty(finding.File))}, properties...)
+		}
+		if _, err := fmt.Fprintf(w, "::warning %s::%s: %s\n", strings.Join(properties, ","), escapeData(finding.Component), escapeData(finding.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	o := parseOptions()
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+
+	reg, err := loadRegistry(o.registryDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load the step registry")
+	}
+	tests, err := loadTests(o.configDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to load ci-operator configurations")
+	}
+
+	findings := lint.Lint(reg, tests)
+	if o.output != "" {
+		if err := writeReport(o.output, findings); err != nil {
+			logrus.WithError(err).Fatal("failed to write the report")
+		}
+	}
+	if o.githubAnnotations {
+		if err := writeAnnotations(os.Stdout, findings); err != nil {
+			logrus.WithError(err).Fatal("failed to write annotations")
+		}
+	}
+	logrus.Infof("Found %d issues in %d tests and the step registry", len(findings), len(tests))
+	if o.failOnFindings && len(findings) != 0 {
+		os.Exit(1)
+	}
+}
@@ -13,6 +13,10 @@ const (
 	TooManyProwJobsBoundReason             = "TooManyProwJobsBound"
 	SecretsFetchFailureReason              = "SecretsFetchFailure"
 	CreateTestCompletedSecretFailureReason = "CreateTestCompletedSecretFailure"
+	TTLExpiredReason                       = "TTLExpired"
+	HibernationRequestedReason             = "HibernationRequested"
+	IdleReason                             = "Idle"
+	HibernationFailureReason               = "HibernationFailure"
 
 	CIOperatorNSNotFoundMsg = "ci-operator NS not found"
 	KubeconfigNotReadyMsg   = "kubeconfig not ready"
@@ -31,6 +35,10 @@ const (
 	ProwJobCompleted EphemeralClusterConditionType = "ProwJobCompleted"
 	// TestCompleted indicates test has completed and the ephemeral cluster isn't needed anymore.
 	TestCompleted EphemeralClusterConditionType = "TestCompleted"
+	// Expired indicates whether the cluster has outlived its TTL.
+	Expired EphemeralClusterConditionType = "Expired"
+	// Hibernated indicates whether the cluster has been stopped.
+	Hibernated EphemeralClusterConditionType = "Hibernated"
 )
 
 type ConditionStatus string
@@ -50,6 +58,8 @@ const (
 	EphemeralClusterProvisioning EphemeralClusterPhase = "Provisioning"
 	// EphemeralClusterReady means the cluster is running and the kubeconfig is available.
 	EphemeralClusterReady EphemeralClusterPhase = "Ready"
+	// EphemeralClusterHibernated means the cluster is still there but its worker machines have been stopped.
+	EphemeralClusterHibernated EphemeralClusterPhase = "Hibernated"
 	// EphemeralClusterDeprovisioning means that the deprovisioning procedures are happening.
 	EphemeralClusterDeprovisioning EphemeralClusterPhase = "Deprovisioning"
 	// EphemeralClusterDeprovisioning means that the cluster has been deprovisioned.
@@ -83,6 +93,37 @@ type EphemeralClusterSpec struct {
 	// When set to true, signals the controller that the ephemeral cluster is no longer needed,
 	// allowing decommissioning procedures to begin.
 	TearDownCluster bool `json:"tearDownCluster,omitempty"`
+	// TTL is how long the cluster is kept after the EphemeralCluster has been created. Once
+	// it expires, the cluster is torn down as if TearDownCluster was set.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// ExtensionHours extends the TTL by this many hours. Raise it to keep the cluster for longer;
+	// extensions beyond MaxExtensionHours are not granted.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=72
+	// +optional
+	ExtensionHours int `json:"extensionHours,omitempty"`
+	// Hibernation stops the worker machines of the cluster while it is not in use.
+	// +optional
+	Hibernation *HibernationSpec `json:"hibernation,omitempty"`
+}
+
+const (
+	// MaxExtensionHours caps how long the TTL of a cluster can be extended.
+	MaxExtensionHours = 72
+	// LastActivityAnnotation holds the last time, in RFC 3339 format, a client used the cluster.
+	LastActivityAnnotation = "ephemeralcluster.ci.openshift.io/last-activity"
+)
+
+// HibernationSpec determines when a cluster hibernates.
+type HibernationSpec struct {
+	// Hibernate stops the cluster right away. Setting it back to false resumes the cluster.
+	Hibernate bool `json:"hibernate,omitempty"`
+	// IdleTimeout stops the cluster once it has not been in use for this long. Clients report
+	// activity by setting the LastActivityAnnotation to the current time, which also resumes
+	// the cluster.
+	// +optional
+	IdleTimeout *metav1.Duration `json:"idleTimeout,omitempty"`
 }
 
 type PullRequestMeta struct {
@@ -118,6 +159,16 @@ type EphemeralClusterStatus struct {
 	// ephemeral cluster. The Secret is in the same namespace as the EphemeralCluster
 	// and contains a "kubeconfig" key and optionally a "kubeAdminPassword" key.
 	SecretRef string `json:"secretRef,omitempty"`
+	// ExpirationTime is when the cluster is going to be torn down, including the extensions granted.
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+	// GrantedExtensionHours is the extension of the TTL that has been granted.
+	GrantedExtensionHours int `json:"grantedExtensionHours,omitempty"`
+	// KubeconfigExpirationTime is when the kubeconfig stops being valid, either because its client
+	// certificate expires or because the cluster is torn down.
+	KubeconfigExpirationTime *metav1.Time `json:"kubeconfigExpirationTime,omitempty"`
+	// LastActivityTime is the last time the cluster has been reported in use through the
+	// LastActivityAnnotation. It is only recorded when the cluster hibernates when idle.
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
 }
 
 // EphemeralClusterCondition contains details for the current condition of this EphemeralCluster.
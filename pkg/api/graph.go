@@ -741,3 +741,39 @@ func LinkForImage(imageStream, tag string) StepLink {
 		return nil
 	}
 }
+
+// ImageStreamForLink determines which ImageStream in the test namespace
+// the link describes. The tag is empty when the link describes the whole
+// stream. Links that do not describe an ImageStream are not ok.
+func ImageStreamForLink(link StepLink) (stream, tag string, ok bool) {
+	switch l := link.(type) {
+	case *internalImageStreamTagLink:
+		return l.name, l.tag, true
+	case *internalImageStreamLink:
+		return l.name, "", true
+	default:
+		return "", "", false
+	}
+}
+
+// DescribeLink returns a short, human-readable description of the link.
+func DescribeLink(link StepLink) string {
+	switch l := link.(type) {
+	case *internalImageStreamTagLink:
+		return fmt.Sprintf("%s:%s", l.name, l.tag)
+	case *internalImageStreamLink:
+		return l.name
+	case *externalImageLink:
+		return fmt.Sprintf("%s/%s:%s", l.namespace, l.name, l.tag)
+	case allStepsLink:
+		return "[all steps]"
+	case *imagesReadyLink:
+		return "[images ready]"
+	case *rpmRepoLink:
+		return "[rpm repository]"
+	case *leaseProxyServerLink:
+		return "[lease proxy server]"
+	default:
+		return fmt.Sprintf("%T", link)
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ExecutionPlan describes the steps ci-operator would execute for a
+// configuration and what each of them consumes and creates, without
+// running any of them.
+// +k8s:deepcopy-gen=false
+type ExecutionPlan struct {
+	// Targets are the targets the plan was built for, all steps if empty.
+	Targets []string `json:"targets,omitempty"`
+	// Steps are ordered topologically.
+	Steps []StepPlan `json:"steps"`
+	// PromotionSteps run once all other steps succeeded.
+	PromotionSteps []StepPlan `json:"promotion_steps,omitempty"`
+}
+
+// StepPlan describes a single step in the execution plan.
+// +k8s:deepcopy-gen=false
+type StepPlan struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Inputs are the resolved inputs of the step, when they can be
+	// determined without a cluster. InputsError explains why not otherwise.
+	Inputs      InputDefinition `json:"inputs,omitempty"`
+	InputsError string          `json:"inputs_error,omitempty"`
+	Requires    []string        `json:"requires,omitempty"`
+	Creates     []string        `json:"creates,omitempty"`
+	// Dependencies are the names of the steps that must run first.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// Provides are the names of the parameters the step exposes.
+	Provides    []string              `json:"provides,omitempty"`
+	Leases      []StepLease           `json:"leases,omitempty"`
+	Credentials []CredentialReference `json:"credentials,omitempty"`
+	// Pods are the pods a multi-stage test will create, in order.
+	Pods      []PodPlan `json:"pods,omitempty"`
+	PodsError string    `json:"pods_error,omitempty"`
+}
+
+// PodPlan describes a pod created for a step of a multi-stage test.
+// +k8s:deepcopy-gen=false
+type PodPlan struct {
+	// Phase is one of pre, test or post.
+	Phase             string         `json:"phase"`
+	Step              string         `json:"step"`
+	BestEffort        bool           `json:"best_effort,omitempty"`
+	OptionalOnSuccess bool           `json:"optional_on_success,omitempty"`
+	Spec              corev1.PodSpec `json:"spec"`
+}
@@ -20,6 +20,8 @@ const (
 
 	PromotionStepName     = "promotion"
 	PromotionQuayStepName = "promotion-quay"
+	// PromotionRegistriesStepName pushes images to the registry targets of the promotion
+	PromotionRegistriesStepName = "promotion-registries"
 
 	PromotionExcludeImageWildcard = "*"
 )
@@ -33,6 +35,38 @@ func PromotionTargets(c *PromotionConfiguration) []PromotionTarget {
 	return c.Targets
 }
 
+// RegistryPromotionTargets returns the registry targets of the promotion configuration
+func RegistryPromotionTargets(c *PromotionConfiguration) []RegistryPromotionTarget {
+	if c == nil {
+		return nil
+	}
+	return c.RegistryTargets
+}
+
+// RegistryTargetImages returns the names of the images pushed to the registry target
+func RegistryTargetImages(target RegistryPromotionTarget, c *ReleaseBuildConfiguration) []string {
+	if len(target.Images) != 0 {
+		return target.Images
+	}
+	var images []string
+	for _, image := range c.Images.Items {
+		images = append(images, string(image.To))
+	}
+	return images
+}
+
+// PullSpecs returns the pull specs the image is pushed to, with the templates of the repository
+// and tags rendered for the image
+func (t RegistryPromotionTarget) PullSpecs(component, commit, date string) []string {
+	repository := fmt.Sprintf("%s/%s", t.Registry, strings.ReplaceAll(t.Repository, ComponentFormatReplacement, component))
+	replacer := strings.NewReplacer(ComponentFormatReplacement, component, CommitFormatReplacement, commit, DateFormatReplacement, date)
+	var pullSpecs []string
+	for _, tag := range t.Tags {
+		pullSpecs = append(pullSpecs, fmt.Sprintf("%s:%s", repository, replacer.Replace(tag)))
+	}
+	return pullSpecs
+}
+
 // ImageTargets returns image targets
 func ImageTargets(c *ReleaseBuildConfiguration) sets.Set[string] {
 	imageTargets := sets.New[string]()
@@ -197,6 +197,40 @@ func TestPromotionTargets(t *testing.T) {
 	}
 }
 
+func TestRegistryPromotionTargetPullSpecs(t *testing.T) {
+	var testCases = []struct {
+		name   string
+		target RegistryPromotionTarget
+		output []string
+	}{
+		{
+			name:   "static repository",
+			target: RegistryPromotionTarget{Registry: "quay.io", Repository: "org/images", Tags: []string{"${component}-latest", "${component}-${commit}"}},
+			output: []string{"quay.io/org/images:cli-latest", "quay.io/org/images:cli-abcdef"},
+		},
+		{
+			name:   "repository per component",
+			target: RegistryPromotionTarget{Registry: "registry.example.com:5000", Repository: "org/${component}", Tags: []string{"latest", "v1-${date}"}},
+			output: []string{"registry.example.com:5000/org/cli:latest", "registry.example.com:5000/org/cli:v1-20261017"},
+		},
+	}
+	for _, testCase := range testCases {
+		if diff := cmp.Diff(testCase.output, testCase.target.PullSpecs("cli", "abcdef", "20261017")); diff != "" {
+			t.Errorf("%s: incorrect pull specs: %v", testCase.name, diff)
+		}
+	}
+}
+
+func TestRegistryTargetImages(t *testing.T) {
+	config := &ReleaseBuildConfiguration{Images: ImageConfiguration{Items: []ProjectDirectoryImageBuildStepConfiguration{{To: "cli"}, {To: "operator"}}}}
+	if diff := cmp.Diff([]string{"cli", "operator"}, RegistryTargetImages(RegistryPromotionTarget{}, config)); diff != "" {
+		t.Errorf("incorrect images by default: %v", diff)
+	}
+	if diff := cmp.Diff([]string{"operator"}, RegistryTargetImages(RegistryPromotionTarget{Images: []string{"operator"}}, config)); diff != "" {
+		t.Errorf("incorrect images when listed: %v", diff)
+	}
+}
+
 func TestQuayCombinedMirrorFunc(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -0,0 +1,30 @@
+package api
+
+import "strings"
+
+// RegistryVersionSeparator separates the name of a registry component from the
+// version of the registry it is pinned to, e.g. ipi-install@v3
+const RegistryVersionSeparator = "@"
+
+// SplitRegistryVersion splits a reference to a registry component into the name
+// of the component and the registry version it is pinned to, which is empty when
+// the reference is to the current registry
+func SplitRegistryVersion(reference string) (string, string) {
+	name, version, _ := strings.Cut(reference, RegistryVersionSeparator)
+	return name, version
+}
+
+// PinRegistryVersion returns a reference to the component in the registry version
+func PinRegistryVersion(name, version string) string {
+	return name + RegistryVersionSeparator + version
+}
+
+// Version returns the named version
+func (c RegistryVersionsConfig) Version(name string) (RegistryVersion, bool) {
+	for _, version := range c.Versions {
+		if version.Name == name {
+			return version, true
+		}
+	}
+	return RegistryVersion{}, false
+}
@@ -608,6 +608,63 @@ type PromotionConfiguration struct {
 	// Cron generates promotion periodic alongside with promotion
 	// postsubmit
 	Cron string `json:"cron,omitempty"`
+
+	// RegistryTargets configure OCI registries outside of the
+	// OpenShift CI image streams that built images are pushed to
+	// directly, e.g. to publish artifacts of projects outside of
+	// the OpenShift organization.
+	RegistryTargets []RegistryPromotionTarget `json:"registry_targets,omitempty"`
+}
+
+// RegistryPromotionTarget describes a repository in an arbitrary OCI
+// registry that images are pushed to. Images are copied with their
+// manifest lists unchanged, so their digests stay the same as in CI.
+type RegistryPromotionTarget struct {
+	// Registry is the host of the registry, e.g. quay.io.
+	Registry string `json:"registry"`
+
+	// Repository is the repository in the registry images are
+	// pushed to. ${component} is replaced by the name of the image.
+	Repository string `json:"repository"`
+
+	// Tags are templates of the tags pushed for every image.
+	// ${component} is replaced by the name of the image, ${commit}
+	// by the commit the image was built from and ${date} by the
+	// date of the promotion in the YYYYMMDD form.
+	Tags []string `json:"tags"`
+
+	// Images are the names of the images to push. All images built
+	// by this configuration are pushed if not set.
+	Images []string `json:"images,omitempty"`
+
+	// Credentials references a kubernetes.io/dockerconfigjson secret
+	// holding the credentials to push into the registry. The secret
+	// must exist in the test-credentials namespace.
+	Credentials RegistryCredentials `json:"credentials"`
+
+	// PinDigest refuses to move a tag which already points to a
+	// different digest, so that published tags are immutable.
+	PinDigest bool `json:"pin_digest,omitempty"`
+
+	// CopySignatures copies the sigstore signatures of the images,
+	// stored in the sha256-<digest>.sig tags, when they exist.
+	CopySignatures bool `json:"copy_signatures,omitempty"`
+
+	// CopyAttestations copies the sigstore attestations of the
+	// images, stored in the sha256-<digest>.att tags, when they exist.
+	CopyAttestations bool `json:"copy_attestations,omitempty"`
+}
+
+// RegistryCredentialsNamespace is the only namespace secrets holding
+// credentials for registry targets can be read from.
+const RegistryCredentialsNamespace = "test-credentials"
+
+// RegistryCredentials references a secret on the build cluster.
+type RegistryCredentials struct {
+	// Namespace is where the secret exists.
+	Namespace string `json:"namespace"`
+	// Name is the name of the secret.
+	Name string `json:"name"`
 }
 
 type PromotionTarget struct {
@@ -1092,6 +1149,25 @@ type RegistryInfo struct {
 	Owners repoowners.Config `json:"owners,omitempty"`
 }
 
+// RegistryVersionsConfig lists the named versions of the registry. Tests can pin
+// registry components to one of these versions or to a commit of the registry.
+// +k8s:deepcopy-gen=false
+type RegistryVersionsConfig struct {
+	Versions []RegistryVersion `json:"versions,omitempty"`
+}
+
+// RegistryVersion names a commit of the repository holding the registry
+// +k8s:deepcopy-gen=false
+type RegistryVersion struct {
+	// Name is how tests refer to the version, e.g. v3
+	Name string `json:"name"`
+	// Commit is the commit the registry is loaded from for this version
+	Commit string `json:"commit"`
+	// Deprecated explains why the version should not be used anymore and
+	// what to use instead. Deprecated versions can still be used.
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
 // Observer is the configuration for an observer Pod that will run in parallel
 // with a multi-stage test job.
 type Observer struct {
@@ -1275,9 +1351,11 @@ func (s *LiteralTestStep) FromImageTag() (PipelineImageStreamTagReference, bool)
 type TestStep struct {
 	// LiteralTestStep is a full test step definition.
 	*LiteralTestStep `json:",inline,omitempty"`
-	// Reference is the name of a step reference.
+	// Reference is the name of a step reference. The name can be followed by
+	// `@` and a registry version to pin the step to that version, e.g. `ipi-install@v3`.
 	Reference *string `json:"ref,omitempty"`
-	// Chain is the name of a step chain reference.
+	// Chain is the name of a step chain reference. Like references, chains
+	// can be pinned to a registry version.
 	Chain *string `json:"chain,omitempty"`
 }
 
@@ -1296,6 +1374,8 @@ type MultiStageTestConfiguration struct {
 	Post []TestStep `json:"post,omitempty"`
 	// Workflow is the name of the workflow to be used for this configuration. For fields defined in both
 	// the config and the workflow, the fields from the config will override what is set in Workflow.
+	// The workflow can be pinned to a registry version, e.g. `ipi-aws@v3`, in which case all of its
+	// steps are taken from that version.
 	Workflow *string `json:"workflow,omitempty"`
 	// Environment has the values of parameters for the steps.
 	Environment TestEnvironment `json:"env,omitempty"`
@@ -1826,6 +1906,8 @@ const (
 	ReleaseImageStream = "release"
 
 	ComponentFormatReplacement = "${component}"
+	CommitFormatReplacement    = "${commit}"
+	DateFormatReplacement      = "${date}"
 )
 
 type MetadataWithTest struct {
@@ -1848,5 +1930,6 @@ type ClusterClaimOwnerDetails struct {
 }
 
 const (
-	EphemeralClusterTestDoneSignalSecretName = "test-done-signal"
+	EphemeralClusterTestDoneSignalSecretName  = "test-done-signal"
+	EphemeralClusterHibernateSignalSecretName = "hibernate-signal"
 )
@@ -1502,6 +1502,13 @@ func (in *PromotionConfiguration) DeepCopyInto(out *PromotionConfiguration) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RegistryTargets != nil {
+		in, out := &in.RegistryTargets, &out.RegistryTargets
+		*out = make([]RegistryPromotionTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionConfiguration.
@@ -1713,6 +1720,21 @@ func (in *RegistryChainConfig) DeepCopy() *RegistryChainConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryCredentials) DeepCopyInto(out *RegistryCredentials) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryCredentials.
+func (in *RegistryCredentials) DeepCopy() *RegistryCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegistryObserver) DeepCopyInto(out *RegistryObserver) {
 	*out = *in
@@ -1745,6 +1767,32 @@ func (in *RegistryObserverConfig) DeepCopy() *RegistryObserverConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryPromotionTarget) DeepCopyInto(out *RegistryPromotionTarget) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Credentials = in.Credentials
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryPromotionTarget.
+func (in *RegistryPromotionTarget) DeepCopy() *RegistryPromotionTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryPromotionTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegistryReference) DeepCopyInto(out *RegistryReference) {
 	*out = *in
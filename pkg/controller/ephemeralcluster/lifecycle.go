@@ -0,0 +1,179 @@
+package ephemeralcluster
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/ptr"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	ephemeralclusterv1 "github.com/openshift/ci-tools/pkg/api/ephemeralcluster/v1"
+)
+
+// observeExpiration computes when the cluster expires, granting the requested extension up to
+// the cap, and reports whether it has expired already. Clusters without a TTL never expire.
+func (r *reconciler) observeExpiration(log *logrus.Entry, ec *ephemeralclusterv1.EphemeralCluster, oldStatus, ecStatus *ephemeralclusterv1.EphemeralClusterStatus) bool {
+	if ec.Spec.TTL == nil {
+		return false
+	}
+
+	granted := max(0, min(ec.Spec.ExtensionHours, ephemeralclusterv1.MaxExtensionHours))
+	if granted != oldStatus.GrantedExtensionHours {
+		log = log.WithField("extension_hours", granted)
+		if granted < ec.Spec.ExtensionHours {
+			log.Warnf("Extension capped to %d hours", ephemeralclusterv1.MaxExtensionHours)
+		} else {
+			log.Info("Extension granted")
+		}
+	}
+	ecStatus.GrantedExtensionHours = granted
+
+	expiration := metav1.NewTime(ec.CreationTimestamp.Add(ec.Spec.TTL.Duration + time.Duration(granted)*time.Hour))
+	ecStatus.ExpirationTime = &expiration
+	if ecStatus.SecretRef != "" && (ecStatus.KubeconfigExpirationTime == nil || expiration.Before(ecStatus.KubeconfigExpirationTime)) {
+		ecStatus.KubeconfigExpirationTime = &expiration
+	}
+
+	if r.now().Before(expiration.Time) {
+		upsertCondition(ecStatus, ephemeralclusterv1.Expired, ephemeralclusterv1.ConditionFalse, r.now(), "", "")
+		return false
+	}
+
+	msg := "the cluster expired at " + expiration.UTC().Format(time.RFC3339)
+	upsertCondition(ecStatus, ephemeralclusterv1.Expired, ephemeralclusterv1.ConditionTrue, r.now(), ephemeralclusterv1.TTLExpiredReason, msg)
+	if !hasCondition(oldStatus, ephemeralclusterv1.Expired, ephemeralclusterv1.ConditionTrue, r.now(), ephemeralclusterv1.TTLExpiredReason, msg) {
+		log.Info("TTL expired, tearing down the cluster")
+	}
+	return true
+}
+
+// hibernationReason records the last activity on the cluster and returns why the cluster
+// should hibernate. An empty reason means the cluster should be running.
+func (r *reconciler) hibernationReason(log *logrus.Entry, ec *ephemeralclusterv1.EphemeralCluster, ecStatus *ephemeralclusterv1.EphemeralClusterStatus) string {
+	hibernation := ec.Spec.Hibernation
+	if hibernation == nil {
+		return ""
+	}
+	if hibernation.Hibernate {
+		return ephemeralclusterv1.HibernationRequestedReason
+	}
+	if hibernation.IdleTimeout == nil {
+		return ""
+	}
+
+	lastActivity := ec.CreationTimestamp
+	if raw, ok := ec.Annotations[ephemeralclusterv1.LastActivityAnnotation]; ok {
+		activity, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			log.WithError(err).Warnf("Ignoring invalid %s annotation", ephemeralclusterv1.LastActivityAnnotation)
+		} else if activity.After(lastActivity.Time) {
+			lastActivity = metav1.NewTime(activity)
+		}
+	}
+	ecStatus.LastActivityTime = &lastActivity
+
+	if r.now().Before(lastActivity.Add(hibernation.IdleTimeout.Duration)) {
+		return ""
+	}
+	return ephemeralclusterv1.IdleReason
+}
+
+// reconcileHibernation stops or resumes the cluster by creating or deleting the hibernation
+// signal secret in the ci-operator namespace. The wait-test-complete step acts upon it.
+func (r *reconciler) reconcileHibernation(ctx context.Context, log *logrus.Entry, reason string, oldECStatus, ecStatus *ephemeralclusterv1.EphemeralClusterStatus, pj *prowv1.ProwJob) error {
+	if reason == "" {
+		var hibernated *ephemeralclusterv1.EphemeralClusterCondition
+		for i := range oldECStatus.Conditions {
+			if oldECStatus.Conditions[i].Type == ephemeralclusterv1.Hibernated {
+				hibernated = &oldECStatus.Conditions[i]
+			}
+		}
+		// Nothing to resume from.
+		if hibernated == nil {
+			return nil
+		}
+		if hibernated.Status == ephemeralclusterv1.ConditionFalse && hibernated.Reason == "" {
+			ecStatus.Conditions = append(ecStatus.Conditions, *hibernated)
+			return nil
+		}
+	}
+
+	buildClient, err := r.buildClients.forCluster(pj.Spec.Cluster)
+	if err != nil {
+		log.WithField("cluster", pj.Spec.Cluster).WithError(err).Warn("Build client not found")
+		upsertCondition(ecStatus, ephemeralclusterv1.Hibernated, ephemeralclusterv1.ConditionFalse, r.now(), ephemeralclusterv1.HibernationFailureReason, err.Error())
+		return err
+	}
+
+	ns, err := r.findCIOperatorTestNS(ctx, buildClient, pj)
+	if err != nil {
+		upsertCondition(ecStatus, ephemeralclusterv1.Hibernated, ephemeralclusterv1.ConditionFalse, r.now(), ephemeralclusterv1.HibernationFailureReason, ephemeralclusterv1.CIOperatorNSNotFoundMsg)
+		return nil
+	}
+
+	log = log.WithFields(logrus.Fields{"namespace": ns, "secret": api.EphemeralClusterHibernateSignalSecretName})
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      api.EphemeralClusterHibernateSignalSecretName,
+		Namespace: ns,
+	}}
+
+	if reason == "" {
+		if err := buildClient.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			log.WithError(err).Warn("Failed to delete the secret")
+			upsertCondition(ecStatus, ephemeralclusterv1.Hibernated, ephemeralclusterv1.ConditionTrue, r.now(), ephemeralclusterv1.HibernationFailureReason, err.Error())
+			ecStatus.Phase = ephemeralclusterv1.EphemeralClusterHibernated
+			return fmt.Errorf("delete hibernation signal: %w", err)
+		}
+		upsertCondition(ecStatus, ephemeralclusterv1.Hibernated, ephemeralclusterv1.ConditionFalse, r.now(), "", "")
+		if !hasCondition(oldECStatus, ephemeralclusterv1.Hibernated, ephemeralclusterv1.ConditionFalse, r.now(), "", "") {
+			log.Info("Resuming the cluster")
+		}
+		return nil
+	}
+
+	if err := buildClient.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		log.WithError(err).Warn("Failed to create the secret")
+		upsertCondition(ecStatus, ephemeralclusterv1.Hibernated, ephemeralclusterv1.ConditionFalse, r.now(), ephemeralclusterv1.HibernationFailureReason, err.Error())
+		return fmt.Errorf("create hibernation signal: %w", err)
+	}
+	upsertCondition(ecStatus, ephemeralclusterv1.Hibernated, ephemeralclusterv1.ConditionTrue, r.now(), reason, "")
+	if !hasCondition(oldECStatus, ephemeralclusterv1.Hibernated, ephemeralclusterv1.ConditionTrue, r.now(), reason, "") {
+		log.WithField("reason", reason).Info("Hibernating the cluster")
+	}
+	ecStatus.Phase = ephemeralclusterv1.EphemeralClusterHibernated
+	return nil
+}
+
+// kubeconfigExpirationTime returns when the earliest client certificate in the kubeconfig
+// expires. Kubeconfigs that do not authenticate with client certificates have no expiration.
+func kubeconfigExpirationTime(kubeconfig []byte) *metav1.Time {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil
+	}
+	var expiration *metav1.Time
+	for _, authInfo := range config.AuthInfos {
+		block, _ := pem.Decode(authInfo.ClientCertificateData)
+		if block == nil {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if expiration == nil || cert.NotAfter.Before(expiration.Time) {
+			expiration = ptr.To(metav1.NewTime(cert.NotAfter))
+		}
+	}
+	return expiration
+}
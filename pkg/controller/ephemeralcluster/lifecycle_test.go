@@ -0,0 +1,167 @@
+package ephemeralcluster
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/utils/ptr"
+
+	ephemeralclusterv1 "github.com/openshift/ci-tools/pkg/api/ephemeralcluster/v1"
+)
+
+func TestObserveExpiration(t *testing.T) {
+	fakeNow := fakeNow(t)
+	created := metav1.NewTime(fakeNow.Add(-time.Hour))
+
+	for _, tc := range []struct {
+		name        string
+		spec        ephemeralclusterv1.EphemeralClusterSpec
+		status      ephemeralclusterv1.EphemeralClusterStatus
+		wantExpired bool
+		wantStatus  ephemeralclusterv1.EphemeralClusterStatus
+	}{
+		{
+			name: "No TTL, never expires",
+		},
+		{
+			name: "Extension keeps the cluster",
+			spec: ephemeralclusterv1.EphemeralClusterSpec{TTL: &metav1.Duration{Duration: time.Hour}, ExtensionHours: 1},
+			wantStatus: ephemeralclusterv1.EphemeralClusterStatus{
+				GrantedExtensionHours: 1,
+				ExpirationTime:        ptr.To(metav1.NewTime(fakeNow.Add(time.Hour))),
+				Conditions: []ephemeralclusterv1.EphemeralClusterCondition{{
+					Type:               ephemeralclusterv1.Expired,
+					Status:             ephemeralclusterv1.ConditionFalse,
+					LastTransitionTime: metav1.NewTime(fakeNow),
+				}},
+			},
+		},
+		{
+			name:   "Extension is capped",
+			spec:   ephemeralclusterv1.EphemeralClusterSpec{TTL: &metav1.Duration{Duration: time.Hour}, ExtensionHours: 100},
+			status: ephemeralclusterv1.EphemeralClusterStatus{SecretRef: "foo-credentials"},
+			wantStatus: ephemeralclusterv1.EphemeralClusterStatus{
+				SecretRef:                "foo-credentials",
+				GrantedExtensionHours:    ephemeralclusterv1.MaxExtensionHours,
+				ExpirationTime:           ptr.To(metav1.NewTime(fakeNow.Add(72 * time.Hour))),
+				KubeconfigExpirationTime: ptr.To(metav1.NewTime(fakeNow.Add(72 * time.Hour))),
+				Conditions: []ephemeralclusterv1.EphemeralClusterCondition{{
+					Type:               ephemeralclusterv1.Expired,
+					Status:             ephemeralclusterv1.ConditionFalse,
+					LastTransitionTime: metav1.NewTime(fakeNow),
+				}},
+			},
+		},
+		{
+			name: "Kubeconfig expiring earlier is kept",
+			spec: ephemeralclusterv1.EphemeralClusterSpec{TTL: &metav1.Duration{Duration: 3 * time.Hour}},
+			status: ephemeralclusterv1.EphemeralClusterStatus{
+				SecretRef:                "foo-credentials",
+				KubeconfigExpirationTime: ptr.To(metav1.NewTime(fakeNow.Add(time.Hour))),
+			},
+			wantStatus: ephemeralclusterv1.EphemeralClusterStatus{
+				SecretRef:                "foo-credentials",
+				ExpirationTime:           ptr.To(metav1.NewTime(fakeNow.Add(2 * time.Hour))),
+				KubeconfigExpirationTime: ptr.To(metav1.NewTime(fakeNow.Add(time.Hour))),
+				Conditions: []ephemeralclusterv1.EphemeralClusterCondition{{
+					Type:               ephemeralclusterv1.Expired,
+					Status:             ephemeralclusterv1.ConditionFalse,
+					LastTransitionTime: metav1.NewTime(fakeNow),
+				}},
+			},
+		},
+		{
+			name:        "TTL expired",
+			spec:        ephemeralclusterv1.EphemeralClusterSpec{TTL: &metav1.Duration{Duration: time.Hour}},
+			wantExpired: true,
+			wantStatus: ephemeralclusterv1.EphemeralClusterStatus{
+				ExpirationTime: ptr.To(metav1.NewTime(fakeNow)),
+				Conditions: []ephemeralclusterv1.EphemeralClusterCondition{{
+					Type:               ephemeralclusterv1.Expired,
+					Status:             ephemeralclusterv1.ConditionTrue,
+					Reason:             ephemeralclusterv1.TTLExpiredReason,
+					Message:            "the cluster expired at 2025-04-02T12:12:12Z",
+					LastTransitionTime: metav1.NewTime(fakeNow),
+				}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := reconciler{now: func() time.Time { return fakeNow }}
+			ec := &ephemeralclusterv1.EphemeralCluster{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created},
+				Spec:       tc.spec,
+			}
+
+			status := tc.status
+			expired := r.observeExpiration(logrus.NewEntry(logrus.StandardLogger()), ec, &ephemeralclusterv1.EphemeralClusterStatus{}, &status)
+			if expired != tc.wantExpired {
+				t.Errorf("want expired %t but got %t", tc.wantExpired, expired)
+			}
+			if diff := cmp.Diff(tc.wantStatus, status); diff != "" {
+				t.Errorf("unexpected status: %s", diff)
+			}
+		})
+	}
+}
+
+func TestKubeconfigExpirationTime(t *testing.T) {
+	notAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %s", err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "admin"}, NotAfter: notAfter}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %s", err)
+	}
+	kubeconfig := func(authInfo *clientcmdapi.AuthInfo) []byte {
+		config := clientcmdapi.NewConfig()
+		config.AuthInfos["admin"] = authInfo
+		raw, err := clientcmd.Write(*config)
+		if err != nil {
+			t.Fatalf("write kubeconfig: %s", err)
+		}
+		return raw
+	}
+
+	for _, tc := range []struct {
+		name       string
+		kubeconfig []byte
+		want       *metav1.Time
+	}{
+		{
+			name:       "Client certificate",
+			kubeconfig: kubeconfig(&clientcmdapi.AuthInfo{ClientCertificateData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}),
+			want:       ptr.To(metav1.NewTime(notAfter)),
+		},
+		{
+			name:       "Token",
+			kubeconfig: kubeconfig(&clientcmdapi.AuthInfo{Token: "token"}),
+		},
+		{
+			name:       "Not a kubeconfig",
+			kubeconfig: []byte("kubeconfig"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, kubeconfigExpirationTime(tc.kubeconfig)); diff != "" {
+				t.Errorf("unexpected expiration time: %s", diff)
+			}
+		})
+	}
+}
@@ -214,7 +214,10 @@ func (r *reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		return reconcile.Result{}, err
 	}
 
-	if ec.Spec.TearDownCluster {
+	expired := r.observeExpiration(log, ec, &oldStatus, &observedStatus)
+	hibernationReason := r.hibernationReason(log, ec, &observedStatus)
+
+	if ec.Spec.TearDownCluster || expired {
 		err := r.notifyTestComplete(ctx, log, &oldStatus, &observedStatus, &pj)
 		if err != nil {
 			if updateErr := r.updateEphemeralClusterStatus(ctx, ec, &observedStatus); updateErr != nil {
@@ -223,6 +226,14 @@ func (r *reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 			}
 			return reconcile.Result{}, err
 		}
+	} else if observedStatus.Phase == ephemeralclusterv1.EphemeralClusterReady {
+		if err := r.reconcileHibernation(ctx, log, hibernationReason, &oldStatus, &observedStatus, &pj); err != nil {
+			if updateErr := r.updateEphemeralClusterStatus(ctx, ec, &observedStatus); updateErr != nil {
+				msg := utilerrors.NewAggregate([]error{updateErr, err}).Error()
+				return reconcile.Result{}, errors.New(msg)
+			}
+			return reconcile.Result{}, err
+		}
 	}
 
 	var requeueAfter time.Duration
@@ -552,6 +563,7 @@ func (r *reconciler) fetchHiveSecrets(
 	}
 
 	ecStatus.SecretRef = credentialsSecretName(ec)
+	ecStatus.KubeconfigExpirationTime = kubeconfigExpirationTime(kubeconfig)
 	upsertCondition(ecStatus, ephemeralclusterv1.ClusterReady, ephemeralclusterv1.ConditionTrue, r.now(), "", "")
 	if !hasCondition(oldStatus, ephemeralclusterv1.ClusterReady, ephemeralclusterv1.ConditionTrue, r.now(), "", "") {
 		log.Info("Hive secrets fetched, the cluster is ready")
@@ -593,6 +605,7 @@ func (r *reconciler) fetchClusterKubeconfig(
 	}
 
 	ecStatus.SecretRef = credentialsSecretName(ec)
+	ecStatus.KubeconfigExpirationTime = kubeconfigExpirationTime(kubeconfig)
 	upsertCondition(ecStatus, ephemeralclusterv1.ClusterReady, ephemeralclusterv1.ConditionTrue, r.now(), "", "")
 	if !hasCondition(oldStatus, ephemeralclusterv1.ClusterReady, ephemeralclusterv1.ConditionTrue, r.now(), "", "") {
 		log.Info("Kubeconfig fetched, the cluster is ready")
@@ -605,7 +618,7 @@ func (r *reconciler) updateEphemeralClusterStatus(ctx context.Context, ec *ephem
 		cmpopts.SortSlices(func(a, b ephemeralclusterv1.EphemeralClusterCondition) int {
 			return strings.Compare(string(a.Type), string(b.Type))
 		}),
-		cmpopts.IgnoreTypes(metav1.Time{}),
+		cmpopts.IgnoreFields(ephemeralclusterv1.EphemeralClusterCondition{}, "LastTransitionTime"),
 	}
 
 	if !cmp.Equal(&ec.Status, observedStatus, cmpECStatusOpts...) {
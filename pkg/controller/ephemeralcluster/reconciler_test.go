@@ -1072,6 +1072,243 @@ func TestReconcile(t *testing.T) {
 			},
 			wantRes: reconcile.Result{RequeueAfter: pollingTime},
 		},
+		{
+			name: "TTL expired, tear down the cluster",
+			ec: &ephemeralclusterv1.EphemeralCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "foo",
+					Namespace:         "bar",
+					UID:               types.UID("test-ec-uid"),
+					CreationTimestamp: metav1.NewTime(fakeNow.Add(-5 * time.Hour)),
+				},
+				Spec: ephemeralclusterv1.EphemeralClusterSpec{
+					TTL:            &metav1.Duration{Duration: 2 * time.Hour},
+					ExtensionHours: 2,
+				},
+				Status: ephemeralclusterv1.EphemeralClusterStatus{
+					ProwJobID: "pj-123",
+				},
+			},
+			objs: []ctrlclient.Object{
+				&prowv1.ProwJob{
+					ObjectMeta: metav1.ObjectMeta{Name: "pj-123", Namespace: prowJobNamespace},
+					Spec:       prowv1.ProwJobSpec{Cluster: "build01"},
+				},
+			},
+			buildClients: func() map[string]*ctrlruntimetest.FakeClient {
+				objs := []ctrlclient.Object{
+					&corev1.Namespace{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{steps.LabelJobID: "pj-123"},
+							Name:   "ci-op-1234",
+						},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: EphemeralClusterTestName, Namespace: "ci-op-1234"},
+						Data:       map[string][]byte{"kubeconfig": []byte("kubeconfig")},
+					},
+				}
+				c := fake.NewClientBuilder().WithObjects(objs...).WithScheme(scheme).Build()
+				return map[string]*ctrlruntimetest.FakeClient{
+					"build01": ctrlruntimetest.NewFakeClient(c, scheme, ctrlruntimetest.WithInitObjects(objs...)),
+				}
+			},
+			wantEC: &ephemeralclusterv1.EphemeralCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "foo",
+					Namespace:         "bar",
+					ResourceVersion:   "1000",
+					CreationTimestamp: metav1.NewTime(fakeNow.Add(-5 * time.Hour)),
+				},
+				Spec: ephemeralclusterv1.EphemeralClusterSpec{
+					TTL:            &metav1.Duration{Duration: 2 * time.Hour},
+					ExtensionHours: 2,
+				},
+				Status: ephemeralclusterv1.EphemeralClusterStatus{
+					Phase:                    ephemeralclusterv1.EphemeralClusterDeprovisioning,
+					ProwJobID:                "pj-123",
+					SecretRef:                "foo-credentials",
+					GrantedExtensionHours:    2,
+					ExpirationTime:           ptr.To(metav1.NewTime(fakeNow.Add(-time.Hour))),
+					KubeconfigExpirationTime: ptr.To(metav1.NewTime(fakeNow.Add(-time.Hour))),
+					Conditions: []ephemeralclusterv1.EphemeralClusterCondition{{
+						Type:               ephemeralclusterv1.ProwJobCreating,
+						Status:             ephemeralclusterv1.ConditionFalse,
+						Reason:             ProwJobCreatingDoneReason,
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}, {
+						Type:               ephemeralclusterv1.ClusterReady,
+						Status:             ephemeralclusterv1.ConditionTrue,
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}, {
+						Type:               ephemeralclusterv1.Expired,
+						Status:             ephemeralclusterv1.ConditionTrue,
+						Reason:             ephemeralclusterv1.TTLExpiredReason,
+						Message:            "the cluster expired at 2025-04-02T11:12:12Z",
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}, {
+						Type:               ephemeralclusterv1.TestCompleted,
+						Status:             ephemeralclusterv1.ConditionTrue,
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}},
+				},
+			},
+			wantRes: reconcile.Result{RequeueAfter: pollingTime},
+		},
+		{
+			name: "Idle cluster hibernates",
+			ec: &ephemeralclusterv1.EphemeralCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "foo",
+					Namespace:         "bar",
+					UID:               types.UID("test-ec-uid"),
+					CreationTimestamp: metav1.NewTime(fakeNow.Add(-5 * time.Hour)),
+					Annotations:       map[string]string{ephemeralclusterv1.LastActivityAnnotation: "2025-04-02T10:12:12Z"},
+				},
+				Spec: ephemeralclusterv1.EphemeralClusterSpec{
+					Hibernation: &ephemeralclusterv1.HibernationSpec{IdleTimeout: &metav1.Duration{Duration: time.Hour}},
+				},
+				Status: ephemeralclusterv1.EphemeralClusterStatus{
+					ProwJobID: "pj-123",
+				},
+			},
+			objs: []ctrlclient.Object{
+				&prowv1.ProwJob{
+					ObjectMeta: metav1.ObjectMeta{Name: "pj-123", Namespace: prowJobNamespace},
+					Spec:       prowv1.ProwJobSpec{Cluster: "build01"},
+				},
+			},
+			buildClients: func() map[string]*ctrlruntimetest.FakeClient {
+				objs := []ctrlclient.Object{
+					&corev1.Namespace{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{steps.LabelJobID: "pj-123"},
+							Name:   "ci-op-1234",
+						},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: EphemeralClusterTestName, Namespace: "ci-op-1234"},
+						Data:       map[string][]byte{"kubeconfig": []byte("kubeconfig")},
+					},
+				}
+				c := fake.NewClientBuilder().WithObjects(objs...).WithScheme(scheme).Build()
+				return map[string]*ctrlruntimetest.FakeClient{
+					"build01": ctrlruntimetest.NewFakeClient(c, scheme, ctrlruntimetest.WithInitObjects(objs...)),
+				}
+			},
+			wantEC: &ephemeralclusterv1.EphemeralCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "foo",
+					Namespace:         "bar",
+					ResourceVersion:   "1000",
+					CreationTimestamp: metav1.NewTime(fakeNow.Add(-5 * time.Hour)),
+					Annotations:       map[string]string{ephemeralclusterv1.LastActivityAnnotation: "2025-04-02T10:12:12Z"},
+				},
+				Spec: ephemeralclusterv1.EphemeralClusterSpec{
+					Hibernation: &ephemeralclusterv1.HibernationSpec{IdleTimeout: &metav1.Duration{Duration: time.Hour}},
+				},
+				Status: ephemeralclusterv1.EphemeralClusterStatus{
+					Phase:            ephemeralclusterv1.EphemeralClusterHibernated,
+					ProwJobID:        "pj-123",
+					SecretRef:        "foo-credentials",
+					LastActivityTime: ptr.To(metav1.NewTime(fakeNow.Add(-2 * time.Hour))),
+					Conditions: []ephemeralclusterv1.EphemeralClusterCondition{{
+						Type:               ephemeralclusterv1.ProwJobCreating,
+						Status:             ephemeralclusterv1.ConditionFalse,
+						Reason:             ProwJobCreatingDoneReason,
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}, {
+						Type:               ephemeralclusterv1.ClusterReady,
+						Status:             ephemeralclusterv1.ConditionTrue,
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}, {
+						Type:               ephemeralclusterv1.Hibernated,
+						Status:             ephemeralclusterv1.ConditionTrue,
+						Reason:             ephemeralclusterv1.IdleReason,
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}},
+				},
+			},
+			wantRes: reconcile.Result{RequeueAfter: pollingTime},
+		},
+		{
+			name: "Resume a hibernated cluster",
+			ec: &ephemeralclusterv1.EphemeralCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "bar",
+					UID:       types.UID("test-ec-uid"),
+				},
+				Spec: ephemeralclusterv1.EphemeralClusterSpec{
+					Hibernation: &ephemeralclusterv1.HibernationSpec{},
+				},
+				Status: ephemeralclusterv1.EphemeralClusterStatus{
+					ProwJobID: "pj-123",
+					Conditions: []ephemeralclusterv1.EphemeralClusterCondition{{
+						Type:   ephemeralclusterv1.Hibernated,
+						Status: ephemeralclusterv1.ConditionTrue,
+						Reason: ephemeralclusterv1.HibernationRequestedReason,
+					}},
+				},
+			},
+			objs: []ctrlclient.Object{
+				&prowv1.ProwJob{
+					ObjectMeta: metav1.ObjectMeta{Name: "pj-123", Namespace: prowJobNamespace},
+					Spec:       prowv1.ProwJobSpec{Cluster: "build01"},
+				},
+			},
+			buildClients: func() map[string]*ctrlruntimetest.FakeClient {
+				objs := []ctrlclient.Object{
+					&corev1.Namespace{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{steps.LabelJobID: "pj-123"},
+							Name:   "ci-op-1234",
+						},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: EphemeralClusterTestName, Namespace: "ci-op-1234"},
+						Data:       map[string][]byte{"kubeconfig": []byte("kubeconfig")},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: api.EphemeralClusterHibernateSignalSecretName, Namespace: "ci-op-1234"},
+					},
+				}
+				c := fake.NewClientBuilder().WithObjects(objs...).WithScheme(scheme).Build()
+				return map[string]*ctrlruntimetest.FakeClient{
+					"build01": ctrlruntimetest.NewFakeClient(c, scheme, ctrlruntimetest.WithInitObjects(objs...)),
+				}
+			},
+			wantEC: &ephemeralclusterv1.EphemeralCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "foo",
+					Namespace:       "bar",
+					ResourceVersion: "1000",
+				},
+				Spec: ephemeralclusterv1.EphemeralClusterSpec{
+					Hibernation: &ephemeralclusterv1.HibernationSpec{},
+				},
+				Status: ephemeralclusterv1.EphemeralClusterStatus{
+					Phase:     ephemeralclusterv1.EphemeralClusterReady,
+					ProwJobID: "pj-123",
+					SecretRef: "foo-credentials",
+					Conditions: []ephemeralclusterv1.EphemeralClusterCondition{{
+						Type:               ephemeralclusterv1.ProwJobCreating,
+						Status:             ephemeralclusterv1.ConditionFalse,
+						Reason:             ProwJobCreatingDoneReason,
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}, {
+						Type:               ephemeralclusterv1.ClusterReady,
+						Status:             ephemeralclusterv1.ConditionTrue,
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}, {
+						Type:               ephemeralclusterv1.Hibernated,
+						Status:             ephemeralclusterv1.ConditionFalse,
+						LastTransitionTime: metav1.NewTime(fakeNow),
+					}},
+				},
+			},
+			wantRes: reconcile.Result{RequeueAfter: pollingTime},
+		},
 		{
 			name: "Hive cluster provisioned, report secrets",
 			ec: &ephemeralclusterv1.EphemeralCluster{
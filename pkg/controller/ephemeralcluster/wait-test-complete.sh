@@ -4,14 +4,61 @@ set +e
 # a konflux test to complete. Once the test is done, the EphemeralCluster 
 # controller creates a synthetic secret 'test-done-signal' into this ci-operator NS,
 # unbloking the workflow and starting the deprovisioning procedures.
+#
+# While the cluster is not in use, the controller creates the secret 'hibernate-signal'
+# instead. The worker machines are then scaled down to zero, remembering their replicas
+# in an annotation, and scaled back up as soon as the secret is gone.
 
 # This kubeconfig points to the ephemeral cluster. Unsetting it as we want to reach out to
 # the build farm cluster.
+cluster_kubeconfig="$KUBECONFIG"
 unset KUBECONFIG
 
 i=0
 unexpected_err=0
 secret='test-done-signal'
+hibernate_secret='hibernate-signal'
+replicas_annotation='ci.openshift.io/hibernated-replicas'
+# 0: running, 1: hibernated, 2: hibernation failed part way and has to be retried
+# or undone.
+hibernated=0
+
+cluster_oc() {
+    oc --kubeconfig="$cluster_kubeconfig" -n openshift-machine-api "$@"
+}
+
+# Both functions fail when any machineset could not be handled. They can be retried:
+# machinesets that were annotated but not scaled down yet are scaled down again.
+hibernate() {
+    local machinesets failed=0
+    machinesets="$(cluster_oc get machinesets -o jsonpath='{range .items[*]}{.metadata.name} {.spec.replicas} {.metadata.annotations.ci\.openshift\.io/hibernated-replicas}{"\n"}{end}')" || return 1
+    while read -r name replicas hibernated_replicas; do
+        if [ -z "$name" ]; then
+            continue
+        fi
+        # Already hibernated, do not overwrite the replicas to restore.
+        if [ -z "$hibernated_replicas" ]; then
+            cluster_oc annotate "machineset/$name" --overwrite "$replicas_annotation=$replicas" || { failed=1; continue; }
+        fi
+        if [ "$replicas" != "0" ]; then
+            cluster_oc scale "machineset/$name" --replicas=0 || failed=1
+        fi
+    done <<<"$machinesets"
+    return $failed
+}
+
+resume() {
+    local machinesets failed=0
+    machinesets="$(cluster_oc get machinesets -o jsonpath='{range .items[*]}{.metadata.name} {.metadata.annotations.ci\.openshift\.io/hibernated-replicas}{"\n"}{end}')" || return 1
+    while read -r name replicas; do
+        if [ -z "$name" ] || [ -z "$replicas" ]; then
+            continue
+        fi
+        { cluster_oc scale "machineset/$name" --replicas="$replicas" &&
+            cluster_oc annotate "machineset/$name" "$replicas_annotation-"; } || failed=1
+    done <<<"$machinesets"
+    return $failed
+}
 
 while true; do
     printf 'attempt %d\n' $i
@@ -37,6 +84,25 @@ while true; do
         unexpected_err=0
     fi
 
+    if oc get secret/$hibernate_secret >/dev/null 2>&1; then
+        if [ $hibernated -ne 1 ]; then
+            printf 'hibernating the cluster\n'
+            if hibernate; then
+                hibernated=1
+            else
+                printf 'failed to hibernate the cluster, retrying\n'
+                hibernated=2
+            fi
+        fi
+    elif [ $hibernated -ne 0 ]; then
+        printf 'resuming the cluster\n'
+        if resume; then
+            hibernated=0
+        else
+            printf 'failed to resume the cluster, retrying\n'
+        fi
+    fi
+
     i=$((i+1))
     sleep 5s
 done
@@ -46,13 +46,18 @@ type Config struct {
 	InjectedTest                bool
 	EnableSecretsStoreCSIDriver bool
 	GSMConfig                   *multi_stage.GSMConfiguration
-	MetricsAgent                *metrics.MetricsAgent
-	SkippedImages               sets.Set[string]
-	params                      *api.DeferredParameters
-	ClusterProfileGetter        func(profileName string) (*api.ClusterProfileDetails, error)
+	// QuarantineAddress is the address of the flaky test quarantine service test steps use
+	QuarantineAddress    string
+	MetricsAgent         *metrics.MetricsAgent
+	SkippedImages        sets.Set[string]
+	params               *api.DeferredParameters
+	ClusterProfileGetter func(profileName string) (*api.ClusterProfileDetails, error)
 
 	HTTPServerAddr string
 	HTTPServerMux  *http.ServeMux
+
+	// DryRun generates steps that are only described and never run
+	DryRun bool
 }
 
 type Clients struct {
@@ -19,6 +19,7 @@ import (
 	"k8s.io/client-go/util/retry"
 	utilpointer "k8s.io/utils/pointer"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/pod-utils/decorate"
 	"sigs.k8s.io/yaml"
@@ -49,7 +50,14 @@ type inputImageSet map[api.InputImage]struct{}
 // the full set of steps requires for the build, including defaulted steps,
 // generated steps and all raw steps that the user provided.
 func FromConfig(ctx context.Context, cfg *Config) ([]api.Step, []api.Step, error) {
-	crclient, err := ctrlruntimeclient.NewWithWatch(cfg.ClusterConfig, ctrlruntimeclient.Options{})
+	var crclient ctrlruntimeclient.WithWatch
+	var err error
+	if cfg.DryRun {
+		// steps are only described and never run, so nothing is read from a cluster
+		crclient = fakectrlruntimeclient.NewClientBuilder().Build()
+	} else {
+		crclient, err = ctrlruntimeclient.NewWithWatch(cfg.ClusterConfig, ctrlruntimeclient.Options{})
+	}
 	crclient = secretrecordingclient.Wrap(crclient, cfg.Censor)
 	crclient = labeledclient.WrapWithWatch(crclient, cfg.JobSpec)
 	if err != nil {
@@ -312,6 +320,9 @@ func fromConfig(ctx context.Context, cfg *Config) ([]api.Step, []api.Step, error
 		} else {
 			promotionSteps = append(promotionSteps, releasesteps.PromotionStep(api.PromotionQuayStepName, cfg.CIConfig, requiredNames, cfg.JobSpec, cfg.podClient, cfg.PushSecret, api.QuayOpenShiftCIRepo, api.QuayCombinedMirrorFunc, api.QuayTargetNameFunc, cfg.NodeArchitectures))
 		}
+		if len(cfg.CIConfig.PromotionConfiguration.RegistryTargets) > 0 {
+			promotionSteps = append(promotionSteps, releasesteps.RegistryPromotionStep(cfg.CIConfig, cfg.JobSpec, cfg.podClient, cfg.ManifestToolDockerCfg))
+		}
 	}
 
 	return append(overridableSteps, buildSteps...), promotionSteps, nil
@@ -365,7 +376,7 @@ func stepForTest(cfg *Config, inputImages inputImageSet, c *api.TestStepConfigur
 		}
 
 		var ret []api.Step
-		step := multi_stage.MultiStageTestStep(*c, cfg.CIConfig, params, cfg.podClient, cfg.JobSpec, leases, cfg.NodeName, cfg.TargetAdditionalSuffix, nil, cfg.GSMConfig != nil, cfg.GSMConfig, isLeaseProxyServerAvailable(cfg), retry.DefaultRetry)
+		step := multi_stage.MultiStageTestStep(*c, cfg.CIConfig, params, cfg.podClient, cfg.JobSpec, leases, cfg.NodeName, cfg.TargetAdditionalSuffix, nil, cfg.GSMConfig != nil, cfg.GSMConfig, isLeaseProxyServerAvailable(cfg), retry.DefaultRetry, cfg.QuarantineAddress)
 
 		if len(leases) != 0 {
 			step = steps.IPPoolStep(cfg.LeaseClient, cfg.podClient, step, params, cfg.JobSpec.Namespace, cfg.MetricsAgent, test.ClusterProfileLiteral, cfg.CIConfig.Metadata.Branch)
@@ -1730,6 +1730,24 @@ func TestFromConfig(t *testing.T) {
 		promote:       true,
 		expectedSteps: []string{"[output-images]", "[images]"},
 		expectedPost:  []string{"[promotion-quay]"},
+	}, {
+		name: "promote to registry targets",
+		config: api.ReleaseBuildConfiguration{
+			PromotionConfiguration: &api.PromotionConfiguration{
+				Targets: []api.PromotionTarget{{
+					Namespace: ns,
+					Name:      "name",
+				}},
+				RegistryTargets: []api.RegistryPromotionTarget{{
+					Registry:   "quay.io",
+					Repository: "org/${component}",
+					Tags:       []string{"latest"},
+				}},
+			},
+		},
+		promote:       true,
+		expectedSteps: []string{"[output-images]", "[images]"},
+		expectedPost:  []string{"[promotion]", "[promotion-quay]", "[promotion-registries]"},
 	}, {
 		name: "promote 4.23 legacy quay",
 		config: api.ReleaseBuildConfiguration{
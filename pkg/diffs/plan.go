@@ -0,0 +1,518 @@
+package diffs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/prowgen"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/steps/release"
+)
+
+const (
+	// ChangeAdded marks elements that only exist in the head revision
+	ChangeAdded = "added"
+	// ChangeRemoved marks elements that only exist in the base revision
+	ChangeRemoved = "removed"
+	// ChangeChanged marks elements that exist in both revisions but differ
+	ChangeChanged = "changed"
+)
+
+// Registry is the content of the step registry at one revision.
+type Registry struct {
+	References registry.ReferenceByName
+	Chains     registry.ChainByName
+	Workflows  registry.WorkflowByName
+	Observers  registry.ObserverByName
+	// ClusterProfiles is used when resolving tests
+	ClusterProfiles api.ClusterProfilesMap
+}
+
+// Revision is the content of the release repository at one revision
+// that determines what ci-operator executes.
+type Revision struct {
+	Configs  config.DataByFilename
+	Registry Registry
+}
+
+// ChangeSet holds the names of elements that differ between two revisions.
+type ChangeSet struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Empty determines if there are no changes in the set.
+func (c ChangeSet) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// TestPlanChange describes how the execution of a single test changes.
+type TestPlanChange struct {
+	Name   string `json:"name"`
+	Change string `json:"change"`
+	// Fields are the test fields that changed, other than steps
+	Fields []string `json:"fields,omitempty"`
+	// Steps are the resolved multi-stage steps, as `phase/name`
+	Steps ChangeSet `json:"steps,omitzero"`
+	// Registry are the changed registry components the test uses,
+	// as `type/name`, that explain changes not made in the config
+	Registry []string `json:"registry,omitempty"`
+}
+
+// ConfigPlanChange describes how the execution of all tests and
+// jobs generated from a single ci-operator configuration changes.
+type ConfigPlanChange struct {
+	Filename string `json:"filename"`
+	Change   string `json:"change"`
+	// Fields are the top-level configuration fields that changed,
+	// other than images and tests
+	Fields    []string         `json:"fields,omitempty"`
+	Images    ChangeSet        `json:"images,omitzero"`
+	Tests     []TestPlanChange `json:"tests,omitempty"`
+	Promotion ChangeSet        `json:"promotion,omitzero"`
+	Jobs      ChangeSet        `json:"jobs,omitzero"`
+}
+
+// ConfigPlanError describes why the execution plan of a single ci-operator
+// configuration could not be determined.
+type ConfigPlanError struct {
+	Filename string `json:"filename"`
+	Error    string `json:"error"`
+}
+
+// PlanDiff describes how the execution plans of ci-operator
+// configurations differ between two revisions.
+type PlanDiff struct {
+	// Registry are the step registry components that changed, as `type/name`
+	Registry ChangeSet          `json:"registry,omitzero"`
+	Configs  []ConfigPlanChange `json:"configs,omitempty"`
+	// Errors are the configurations that could not be compared
+	Errors []ConfigPlanError `json:"errors,omitempty"`
+}
+
+// DiffPlans determines how execution plans change between the base and head
+// revisions. Configurations are compared after resolving them against the
+// registry of their revision, so changes to registry steps, chains and
+// workflows are reported for every test that transitively uses them.
+// Configurations that can not be resolved are reported in the diff's errors
+// and do not prevent the comparison of the others.
+func DiffPlans(base, head Revision) (*PlanDiff, error) {
+	diff := &PlanDiff{Registry: diffRegistry(base.Registry, head.Registry)}
+	changedComponents := sets.New[string](diff.Registry.Changed...).Insert(diff.Registry.Added...)
+	graph, err := registry.NewGraph(head.Registry.References, head.Registry.Chains, head.Registry.Workflows, head.Registry.Observers)
+	if err != nil {
+		return nil, fmt.Errorf("could not build registry graph: %w", err)
+	}
+	baseResolver := resolverFor(base.Registry)
+	headResolver := resolverFor(head.Registry)
+
+	filenames := sets.KeySet(base.Configs).Union(sets.KeySet(head.Configs))
+	for _, filename := range sets.List(filenames) {
+		var before, after *resolvedConfig
+		if data, ok := base.Configs[filename]; ok {
+			if before, err = resolve(baseResolver, data); err != nil {
+				diff.Errors = append(diff.Errors, ConfigPlanError{Filename: filename, Error: fmt.Sprintf("base revision: %v", err)})
+				continue
+			}
+		}
+		if data, ok := head.Configs[filename]; ok {
+			if after, err = resolve(headResolver, data); err != nil {
+				diff.Errors = append(diff.Errors, ConfigPlanError{Filename: filename, Error: fmt.Sprintf("head revision: %v", err)})
+				continue
+			}
+		}
+		if change := diffConfig(filename, before, after, graph, changedComponents); change != nil {
+			diff.Configs = append(diff.Configs, *change)
+		}
+	}
+	return diff, nil
+}
+
+func resolverFor(r Registry) registry.Resolver {
+	return registry.NewResolver(r.References, r.Chains, r.Workflows, r.Observers, r.ClusterProfiles)
+}
+
+type resolvedConfig struct {
+	// raw is the configuration as written, used to determine registry usage
+	raw      api.ReleaseBuildConfiguration
+	resolved api.ReleaseBuildConfiguration
+	jobs     map[string]any
+}
+
+func resolve(resolver registry.Resolver, data config.DataWithInfo) (*resolvedConfig, error) {
+	resolved, err := registry.ResolveConfig(resolver, data.Configuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve configuration: %w", err)
+	}
+	jobConfig, err := prowgen.GenerateJobs(&resolved, &data.Info.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate jobs: %w", err)
+	}
+	return &resolvedConfig{raw: data.Configuration, resolved: resolved, jobs: jobsByName(jobConfig)}, nil
+}
+
+func jobsByName(jobConfig *prowconfig.JobConfig) map[string]any {
+	jobs := map[string]any{}
+	for _, presubmits := range jobConfig.PresubmitsStatic {
+		for _, job := range presubmits {
+			jobs[job.Name] = job
+		}
+	}
+	for _, postsubmits := range jobConfig.PostsubmitsStatic {
+		for _, job := range postsubmits {
+			jobs[job.Name] = job
+		}
+	}
+	for _, job := range jobConfig.Periodics {
+		jobs[job.Name] = job
+	}
+	return jobs
+}
+
+func diffRegistry(base, head Registry) ChangeSet {
+	var changes ChangeSet
+	merge := func(kind string, other ChangeSet) {
+		for _, into := range []struct {
+			from []string
+			to   *[]string
+		}{{other.Added, &changes.Added}, {other.Removed, &changes.Removed}, {other.Changed, &changes.Changed}} {
+			for _, name := range into.from {
+				*into.to = append(*into.to, kind+"/"+name)
+			}
+		}
+	}
+	merge("reference", diffMaps(base.References, head.References))
+	merge("chain", diffMaps(base.Chains, head.Chains))
+	merge("workflow", diffMaps(base.Workflows, head.Workflows))
+	merge("observer", diffMaps(base.Observers, head.Observers))
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Changed)
+	return changes
+}
+
+// diffMaps compares two maps by key; values are compared in their serialized
+// form as some of them hold unexported fields
+func diffMaps[V any](base, head map[string]V) ChangeSet {
+	var changes ChangeSet
+	for _, key := range sets.List(sets.KeySet(base).Union(sets.KeySet(head))) {
+		before, inBase := base[key]
+		after, inHead := head[key]
+		switch {
+		case !inBase:
+			changes.Added = append(changes.Added, key)
+		case !inHead:
+			changes.Removed = append(changes.Removed, key)
+		case !equalSerialized(before, after):
+			changes.Changed = append(changes.Changed, key)
+		}
+	}
+	return changes
+}
+
+func diffConfig(filename string, before, after *resolvedConfig, graph registry.NodeByName, changedComponents sets.Set[string]) *ConfigPlanChange {
+	change := &ConfigPlanChange{Filename: filename, Change: ChangeChanged}
+	var base, head api.ReleaseBuildConfiguration
+	baseJobs, headJobs := map[string]any{}, map[string]any{}
+	var rawHead api.ReleaseBuildConfiguration
+	switch {
+	case before == nil:
+		change.Change = ChangeAdded
+		head, headJobs, rawHead = after.resolved, after.jobs, after.raw
+	case after == nil:
+		change.Change = ChangeRemoved
+		base, baseJobs = before.resolved, before.jobs
+	default:
+		base, baseJobs = before.resolved, before.jobs
+		head, headJobs, rawHead = after.resolved, after.jobs, after.raw
+	}
+
+	change.Fields = diffFields(base, head, "tests", "images", "zz_generated_metadata")
+	change.Images = diffMaps(imagesByName(base), imagesByName(head))
+	change.Promotion = diffPromotion(base, head, sets.New[string](change.Images.Changed...))
+	change.Jobs = diffMaps(baseJobs, headJobs)
+
+	baseTests, headTests := getTestsByName(base.Tests), getTestsByName(head.Tests)
+	rawTests := getTestsByName(rawHead.Tests)
+	for _, name := range sets.List(sets.KeySet(baseTests).Union(sets.KeySet(headTests))) {
+		if test := diffTest(name, baseTests, headTests, rawTests[name], graph, changedComponents); test != nil {
+			change.Tests = append(change.Tests, *test)
+		}
+	}
+
+	if change.Change == ChangeChanged && len(change.Fields) == 0 && change.Images.Empty() && len(change.Tests) == 0 && change.Promotion.Empty() && change.Jobs.Empty() {
+		return nil
+	}
+	return change
+}
+
+func imagesByName(c api.ReleaseBuildConfiguration) map[string]api.ProjectDirectoryImageBuildStepConfiguration {
+	images := map[string]api.ProjectDirectoryImageBuildStepConfiguration{}
+	for _, image := range c.Images.Items {
+		images[string(image.To)] = image
+	}
+	return images
+}
+
+// diffPromotion compares promoted tags; tags promoted from images with changed
+// builds are reported as changed.
+func diffPromotion(base, head api.ReleaseBuildConfiguration, changedImages sets.Set[string]) ChangeSet {
+	promoted := func(c api.ReleaseBuildConfiguration) map[string]string {
+		tags := map[string]string{}
+		if c.PromotionConfiguration == nil {
+			return tags
+		}
+		mapping, _ := release.PromotedTagsWithRequiredImages(&c)
+		for source, destinations := range mapping {
+			for _, destination := range destinations {
+				tags[destination.ISTagName()] = source
+			}
+		}
+		return tags
+	}
+	before, after := promoted(base), promoted(head)
+	changes := diffMaps(before, after)
+	for _, tag := range sets.List(sets.KeySet(before).Intersection(sets.KeySet(after))) {
+		if before[tag] == after[tag] && changedImages.Has(after[tag]) {
+			changes.Changed = append(changes.Changed, tag)
+		}
+	}
+	sort.Strings(changes.Changed)
+	return changes
+}
+
+func diffTest(name string, baseTests, headTests map[string]api.TestStepConfiguration, raw api.TestStepConfiguration, graph registry.NodeByName, changedComponents sets.Set[string]) *TestPlanChange {
+	before, inBase := baseTests[name]
+	after, inHead := headTests[name]
+	change := &TestPlanChange{Name: name, Change: ChangeChanged}
+	switch {
+	case !inBase:
+		change.Change = ChangeAdded
+	case !inHead:
+		change.Change = ChangeRemoved
+	}
+	change.Steps = diffMaps(stepsByName(before.MultiStageTestConfigurationLiteral), stepsByName(after.MultiStageTestConfigurationLiteral))
+	if change.Change != ChangeChanged {
+		return change
+	}
+
+	withoutSteps := func(in api.TestStepConfiguration) map[string]any {
+		fields := toFields(in)
+		delete(fields, "steps")
+		delete(fields, "literal_steps")
+		if in.MultiStageTestConfigurationLiteral != nil {
+			literal := *in.MultiStageTestConfigurationLiteral
+			literal.Pre, literal.Test, literal.Post, literal.Observers = nil, nil, nil, nil
+			for key, value := range toFields(literal) {
+				fields[key] = value
+			}
+		}
+		return fields
+	}
+	change.Fields = diffFieldMaps(withoutSteps(before), withoutSteps(after))
+	if len(change.Fields) == 0 && change.Steps.Empty() {
+		return nil
+	}
+	if raw.MultiStageTestConfiguration != nil {
+		used := registryComponentsUsedBy(*raw.MultiStageTestConfiguration, graph)
+		change.Registry = sets.List(used.Intersection(changedComponents))
+	}
+	return change
+}
+
+func stepsByName(literal *api.MultiStageTestConfigurationLiteral) map[string]api.LiteralTestStep {
+	steps := map[string]api.LiteralTestStep{}
+	if literal == nil {
+		return steps
+	}
+	for _, phase := range []struct {
+		name  string
+		steps []api.LiteralTestStep
+	}{{"pre", literal.Pre}, {"test", literal.Test}, {"post", literal.Post}} {
+		for _, step := range phase.steps {
+			steps[phase.name+"/"+step.As] = step
+		}
+	}
+	return steps
+}
+
+// registryComponentsUsedBy determines all registry components, as `type/name`,
+// that a test uses directly or through chains and workflows.
+func registryComponentsUsedBy(test api.MultiStageTestConfiguration, graph registry.NodeByName) sets.Set[string] {
+	used := sets.New[string]()
+	var nodes []registry.Node
+	if test.Workflow != nil {
+		if node, ok := graph.Workflows[*test.Workflow]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+	for _, phase := range [][]api.TestStep{test.Pre, test.Test, test.Post} {
+		for _, step := range phase {
+			switch {
+			case step.Reference != nil:
+				if node, ok := graph.References[*step.Reference]; ok {
+					nodes = append(nodes, node)
+				}
+			case step.Chain != nil:
+				if node, ok := graph.Chains[*step.Chain]; ok {
+					nodes = append(nodes, node)
+				}
+			}
+		}
+	}
+	if test.Observers != nil {
+		for _, observer := range test.Observers.Enable {
+			if node, ok := graph.Observers[observer]; ok {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	for _, node := range nodes {
+		used.Insert(nodeKey(node))
+		for _, descendant := range node.Descendants() {
+			used.Insert(nodeKey(descendant))
+		}
+	}
+	return used
+}
+
+func nodeKey(node registry.Node) string {
+	switch node.Type() {
+	case registry.Workflow:
+		return "workflow/" + node.Name()
+	case registry.Chain:
+		return "chain/" + node.Name()
+	case registry.Observer:
+		return "observer/" + node.Name()
+	default:
+		return "reference/" + node.Name()
+	}
+}
+
+func equalSerialized(a, b any) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
+
+// toFields serializes the value to its top-level JSON fields
+func toFields(in any) map[string]any {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return nil
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// diffFields returns the top-level JSON fields that differ, except ignored ones
+func diffFields(base, head any, ignored ...string) []string {
+	before, after := toFields(base), toFields(head)
+	for _, field := range ignored {
+		delete(before, field)
+		delete(after, field)
+	}
+	return diffFieldMaps(before, after)
+}
+
+func diffFieldMaps(before, after map[string]any) []string {
+	changes := diffMaps(before, after)
+	if changes.Empty() {
+		return nil
+	}
+	return sets.List(sets.New[string](changes.Added...).Insert(changes.Removed...).Insert(changes.Changed...))
+}
+
+// WriteText writes a human-readable description of the changes, suitable for
+// review of pull requests to the release repository.
+func (d *PlanDiff) WriteText(w io.Writer) error {
+	var b strings.Builder
+	if !d.Registry.Empty() {
+		b.WriteString("Step registry:\n")
+		writeChangeSet(&b, "  ", d.Registry)
+	}
+	for _, c := range d.Configs {
+		fmt.Fprintf(&b, "%s (%s):\n", c.Filename, c.Change)
+		if len(c.Fields) > 0 {
+			fmt.Fprintf(&b, "  configuration: %s\n", strings.Join(c.Fields, ", "))
+		}
+		for _, section := range []struct {
+			name    string
+			changes ChangeSet
+		}{{"images", c.Images}, {"promotion", c.Promotion}} {
+			if !section.changes.Empty() {
+				fmt.Fprintf(&b, "  %s:\n", section.name)
+				writeChangeSet(&b, "    ", section.changes)
+			}
+		}
+		if len(c.Tests) > 0 {
+			b.WriteString("  tests:\n")
+		}
+		for _, test := range c.Tests {
+			fmt.Fprintf(&b, "    %s %s\n", changeMarker(test.Change), test.Name)
+			if test.Change != ChangeChanged {
+				continue
+			}
+			if len(test.Fields) > 0 {
+				fmt.Fprintf(&b, "        fields: %s\n", strings.Join(test.Fields, ", "))
+			}
+			if !test.Steps.Empty() {
+				b.WriteString("        steps:\n")
+				writeChangeSet(&b, "          ", test.Steps)
+			}
+			if len(test.Registry) > 0 {
+				fmt.Fprintf(&b, "        via registry: %s\n", strings.Join(test.Registry, ", "))
+			}
+		}
+		if !c.Jobs.Empty() {
+			b.WriteString("  jobs:\n")
+			writeChangeSet(&b, "    ", c.Jobs)
+		}
+	}
+	if len(d.Errors) > 0 {
+		b.WriteString("Could not compare:\n")
+	}
+	for _, e := range d.Errors {
+		fmt.Fprintf(&b, "  %s: %s\n", e.Filename, e.Error)
+	}
+	if d.Registry.Empty() && len(d.Configs) == 0 && len(d.Errors) == 0 {
+		b.WriteString("No changes to execution plans.\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeChangeSet(b *strings.Builder, indent string, changes ChangeSet) {
+	for _, group := range []struct {
+		change string
+		names  []string
+	}{{ChangeAdded, changes.Added}, {ChangeRemoved, changes.Removed}, {ChangeChanged, changes.Changed}} {
+		for _, name := range group.names {
+			fmt.Fprintf(b, "%s%s %s\n", indent, changeMarker(group.change), name)
+		}
+	}
+}
+
+func changeMarker(change string) string {
+	switch change {
+	case ChangeAdded:
+		return "+"
+	case ChangeRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
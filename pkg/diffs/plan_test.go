@@ -0,0 +1,190 @@
+package diffs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	cioperatorapi "github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/registry"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func planRevision(commands string, images ...string) Revision {
+	install, chain, workflow := "install", "ipi", "wf"
+	var items []cioperatorapi.ProjectDirectoryImageBuildStepConfiguration
+	for _, image := range images {
+		items = append(items, cioperatorapi.ProjectDirectoryImageBuildStepConfiguration{
+			To:                               cioperatorapi.PipelineImageStreamTagReference(image),
+			ProjectDirectoryImageBuildInputs: cioperatorapi.ProjectDirectoryImageBuildInputs{DockerfilePath: "Dockerfile." + image},
+		})
+	}
+	info := config.Info{Metadata: cioperatorapi.Metadata{Org: "org", Repo: "repo", Branch: "master"}}
+	return Revision{
+		Configs: config.DataByFilename{
+			"org-repo-master.yaml": {
+				Configuration: cioperatorapi.ReleaseBuildConfiguration{
+					InputConfiguration: cioperatorapi.InputConfiguration{
+						BuildRootImage: &cioperatorapi.BuildRootImageConfiguration{ImageStreamTagReference: &cioperatorapi.ImageStreamTagReference{Namespace: "ci", Name: "root", Tag: "latest"}},
+					},
+					Images: cioperatorapi.ImageConfiguration{Items: items},
+					PromotionConfiguration: &cioperatorapi.PromotionConfiguration{
+						Targets: []cioperatorapi.PromotionTarget{{Namespace: "ocp", Name: "4.19"}},
+					},
+					Resources: cioperatorapi.ResourceConfiguration{"*": {Requests: cioperatorapi.ResourceList{"cpu": "1"}}},
+					Tests: []cioperatorapi.TestStepConfiguration{
+						{As: "unit", ContainerTestConfiguration: &cioperatorapi.ContainerTestConfiguration{From: "src"}, Commands: "make test"},
+						{As: "e2e", MultiStageTestConfiguration: &cioperatorapi.MultiStageTestConfiguration{Workflow: &workflow}},
+					},
+				},
+				Info: info,
+			},
+		},
+		Registry: Registry{
+			References: registry.ReferenceByName{
+				install: {As: install, From: "src", Commands: commands, Resources: cioperatorapi.ResourceRequirements{Requests: cioperatorapi.ResourceList{"cpu": "1"}}},
+			},
+			Chains: registry.ChainByName{
+				chain: {As: chain, Steps: []cioperatorapi.TestStep{{Reference: &install}}},
+			},
+			Workflows: registry.WorkflowByName{
+				workflow: {Pre: []cioperatorapi.TestStep{{Chain: &chain}}},
+			},
+		},
+	}
+}
+
+func TestDiffPlans(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		base, head Revision
+		expected   *PlanDiff
+	}{
+		{
+			name:     "no changes",
+			base:     planRevision("install", "img"),
+			head:     planRevision("install", "img"),
+			expected: &PlanDiff{},
+		},
+		{
+			name: "registry reference changed, test changes transitively",
+			base: planRevision("install", "img"),
+			head: planRevision("install --verbose", "img"),
+			expected: &PlanDiff{
+				Registry: ChangeSet{Changed: []string{"reference/install"}},
+				Configs: []ConfigPlanChange{{
+					Filename: "org-repo-master.yaml",
+					Change:   ChangeChanged,
+					Tests: []TestPlanChange{{
+						Name:     "e2e",
+						Change:   ChangeChanged,
+						Steps:    ChangeSet{Changed: []string{"pre/install"}},
+						Registry: []string{"reference/install"},
+					}},
+				}},
+			},
+		},
+		{
+			name: "image added, promoted and checked out by all jobs",
+			base: planRevision("install", "img"),
+			head: planRevision("install", "img", "other"),
+			expected: &PlanDiff{
+				Configs: []ConfigPlanChange{{
+					Filename:  "org-repo-master.yaml",
+					Change:    ChangeChanged,
+					Images:    ChangeSet{Added: []string{"other"}},
+					Promotion: ChangeSet{Added: []string{"ocp/4.19:other"}},
+					Jobs:      ChangeSet{Changed: []string{"branch-ci-org-repo-master-images", "pull-ci-org-repo-master-e2e", "pull-ci-org-repo-master-images", "pull-ci-org-repo-master-unit"}},
+				}},
+			},
+		},
+		{
+			name: "configuration added",
+			base: Revision{},
+			head: planRevision("install", "img"),
+			expected: &PlanDiff{
+				Registry: ChangeSet{Added: []string{"chain/ipi", "reference/install", "workflow/wf"}},
+				Configs: []ConfigPlanChange{{
+					Filename:  "org-repo-master.yaml",
+					Change:    ChangeAdded,
+					Fields:    []string{"build_root", "promotion", "resources"},
+					Images:    ChangeSet{Added: []string{"img"}},
+					Promotion: ChangeSet{Added: []string{"ocp/4.19:img"}},
+					Jobs:      ChangeSet{Added: []string{"branch-ci-org-repo-master-images", "pull-ci-org-repo-master-e2e", "pull-ci-org-repo-master-images", "pull-ci-org-repo-master-unit"}},
+					Tests: []TestPlanChange{
+						{Name: "e2e", Change: ChangeAdded, Steps: ChangeSet{Added: []string{"pre/install"}}},
+						{Name: "unit", Change: ChangeAdded},
+					},
+				}},
+			},
+		},
+		{
+			name: "unresolvable configuration is reported, others are compared",
+			base: planRevision("install", "img"),
+			head: func() Revision {
+				r := planRevision("install --verbose", "img")
+				missing := "missing"
+				broken := r.Configs["org-repo-master.yaml"]
+				broken.Configuration.Tests = []cioperatorapi.TestStepConfiguration{
+					{As: "e2e", MultiStageTestConfiguration: &cioperatorapi.MultiStageTestConfiguration{Workflow: &missing}},
+				}
+				r.Configs["org-other-master.yaml"] = broken
+				return r
+			}(),
+			expected: &PlanDiff{
+				Registry: ChangeSet{Changed: []string{"reference/install"}},
+				Configs: []ConfigPlanChange{{
+					Filename: "org-repo-master.yaml",
+					Change:   ChangeChanged,
+					Tests: []TestPlanChange{{
+						Name:     "e2e",
+						Change:   ChangeChanged,
+						Steps:    ChangeSet{Changed: []string{"pre/install"}},
+						Registry: []string{"reference/install"},
+					}},
+				}},
+				Errors: []ConfigPlanError{{
+					Filename: "org-other-master.yaml",
+					Error:    "head revision: failed to resolve configuration: Failed resolve MultiStageTestConfiguration: no workflow named missing",
+				}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := DiffPlans(tc.base, tc.head)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected plan diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPlanDiffWriteText(t *testing.T) {
+	diff := &PlanDiff{
+		Registry: ChangeSet{Changed: []string{"reference/install"}},
+		Configs: []ConfigPlanChange{{
+			Filename:  "org-repo-master.yaml",
+			Change:    ChangeChanged,
+			Fields:    []string{"releases"},
+			Images:    ChangeSet{Added: []string{"other"}, Removed: []string{"img"}},
+			Promotion: ChangeSet{Added: []string{"ocp/4.19:other"}, Removed: []string{"ocp/4.19:img"}},
+			Tests: []TestPlanChange{
+				{Name: "e2e", Change: ChangeChanged, Fields: []string{"cluster_profile"}, Steps: ChangeSet{Changed: []string{"pre/install"}}, Registry: []string{"reference/install"}},
+				{Name: "unit", Change: ChangeRemoved},
+			},
+			Jobs: ChangeSet{Changed: []string{"pull-ci-org-repo-master-e2e"}, Removed: []string{"pull-ci-org-repo-master-unit"}},
+		}},
+		Errors: []ConfigPlanError{{Filename: "org-other-master.yaml", Error: "head revision: failed to resolve configuration"}},
+	}
+	var out bytes.Buffer
+	if err := diff.WriteText(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testhelper.CompareWithFixture(t, out.String())
+}
@@ -0,0 +1,143 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// freeCapacityQuery is the fraction of the peak number of jobs running concurrently on a cluster in the last week
+	// which is not used by jobs running now. Series scraped from the build clusters themselves do not identify the
+	// cluster they come from, so the signal is derived from Prow's metrics, which do.
+	freeCapacityQuery = `1 - sum by (cluster) (prowjobs{state="pending"}) / max_over_time(sum by (cluster) (prowjobs{state="pending"})[7d:10m])`
+	// failureRateQuery is the fraction of Prow jobs completed on a cluster in the last hours which errored instead of producing a result
+	failureRateQuery = `sum by (cluster) (increase(prowjob_state_transitions{state="error"}[3h])) / sum by (cluster) (increase(prowjob_state_transitions{state=~"success|failure|error"}[3h]))`
+)
+
+// ClusterHealth holds live signals about the state of a cluster
+type ClusterHealth struct {
+	// FreeCapacity is the fraction of the recent peak of concurrently running jobs not used now
+	FreeCapacity *float64
+	// FailureRate is the fraction of recently completed jobs which errored
+	FailureRate *float64
+}
+
+// ClusterHealthMap maps a cluster name to its health
+type ClusterHealthMap map[string]ClusterHealth
+
+// GetClusterHealthFromPrometheus gets the free capacity and failure rate of clusters from a Prometheus server for the given time
+func GetClusterHealthFromPrometheus(ctx context.Context, prometheusAPI PrometheusAPI, ts time.Time) (ClusterHealthMap, error) {
+	health := ClusterHealthMap{}
+	for query, set := range map[string]func(*ClusterHealth, float64){
+		freeCapacityQuery: func(h *ClusterHealth, v float64) { h.FreeCapacity = &v },
+		failureRateQuery:  func(h *ClusterHealth, v float64) { h.FailureRate = &v },
+	} {
+		result, warnings, err := prometheusAPI.Query(ctx, query, ts)
+		if err != nil {
+			return nil, err
+		}
+		if len(warnings) > 0 {
+			logrus.WithField("Warnings", warnings).Warn("Got warnings from Prometheus")
+		}
+		vector, ok := result.(model.Vector)
+		if !ok {
+			return nil, fmt.Errorf("returned result of type %T from Prometheus cannot be cast to vector", result)
+		}
+		for _, v := range vector {
+			cluster := string(v.Metric[model.LabelName("cluster")])
+			if cluster == "" {
+				continue
+			}
+			h := health[cluster]
+			set(&h, float64(v.Value))
+			health[cluster] = h
+		}
+	}
+	return health, nil
+}
+
+// HealthThresholds determine when a cluster should not receive more jobs
+type HealthThresholds struct {
+	// MinFreeCapacity is the free capacity below which a cluster is saturated
+	MinFreeCapacity float64
+	// MaxFailureRate is the failure rate above which a cluster is unhealthy
+	MaxFailureRate float64
+}
+
+// Problems returns the reason each cluster should not receive more jobs, if any. Clusters without
+// a signal are assumed to be fine.
+func (t HealthThresholds) Problems(health ClusterHealthMap) map[string]string {
+	problems := map[string]string{}
+	for cluster, h := range health {
+		if h.FreeCapacity != nil && *h.FreeCapacity < t.MinFreeCapacity {
+			problems[cluster] = fmt.Sprintf("saturated: %.0f%% of its peak job capacity is free, below %.0f%%", *h.FreeCapacity*100, t.MinFreeCapacity*100)
+		} else if h.FailureRate != nil && *h.FailureRate > t.MaxFailureRate {
+			problems[cluster] = fmt.Sprintf("unhealthy: %.0f%% of recent jobs errored, above %.0f%%", *h.FailureRate*100, t.MaxFailureRate*100)
+		}
+	}
+	return problems
+}
+
+// Move records a job which was assigned to a different cluster
+type Move struct {
+	Job    string `json:"job"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// DiffAssignments reports the jobs that moved between two assignments and why. Jobs that are
+// new or were removed are not reported.
+func DiffAssignments(previous, current map[string]ProwJobData, problems map[string]string) []Move {
+	var moves []Move
+	for job, data := range current {
+		before, ok := previous[job]
+		if !ok || before.Cluster == data.Cluster {
+			continue
+		}
+		reason := "load balancing"
+		if problem, ok := problems[before.Cluster]; ok {
+			reason = fmt.Sprintf("%s is %s", before.Cluster, problem)
+		}
+		moves = append(moves, Move{Job: job, From: before.Cluster, To: data.Cluster, Reason: reason})
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		return moves[i].Job < moves[j].Job
+	})
+	return moves
+}
+
+// SummarizeMoves describes the moves by source and target cluster in Markdown
+func SummarizeMoves(moves []Move) string {
+	if len(moves) == 0 {
+		return ""
+	}
+	type key struct{ from, to, reason string }
+	counts := map[key]int{}
+	for _, move := range moves {
+		counts[key{from: move.From, to: move.To, reason: move.Reason}]++
+	}
+	var keys []key
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].from != keys[j].from {
+			return keys[i].from < keys[j].from
+		}
+		if keys[i].to != keys[j].to {
+			return keys[i].to < keys[j].to
+		}
+		return keys[i].reason < keys[j].reason
+	})
+	summary := fmt.Sprintf("%d jobs were moved between clusters:\n\n| From | To | Jobs | Reason |\n| --- | --- | --- | --- |\n", len(moves))
+	for _, k := range keys {
+		summary += fmt.Sprintf("| %s | %s | %d | %s |\n", k.from, k.to, counts[k], k.reason)
+	}
+	return summary
+}
@@ -0,0 +1,116 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	prometheusapi "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestGetClusterHealthFromPrometheus(t *testing.T) {
+	sample := func(cluster string, value float64) *model.Sample {
+		return &model.Sample{Metric: model.Metric{"cluster": model.LabelValue(cluster)}, Value: model.SampleValue(value)}
+	}
+	testCases := []struct {
+		name          string
+		queryFunc     func(ctx context.Context, query string, ts time.Time) (model.Value, prometheusapi.Warnings, error)
+		expected      ClusterHealthMap
+		expectedError error
+	}{
+		{
+			name: "signals are merged by cluster",
+			queryFunc: func(ctx context.Context, query string, ts time.Time) (model.Value, prometheusapi.Warnings, error) {
+				if query == freeCapacityQuery {
+					return model.Vector{sample("build01", 0.05), sample("build02", 0.5), sample("", 1)}, nil, nil
+				}
+				return model.Vector{sample("build01", 0.01), sample("build03", 0.3)}, nil, nil
+			},
+			expected: ClusterHealthMap{
+				"build01": {FreeCapacity: ptr.To(0.05), FailureRate: ptr.To(0.01)},
+				"build02": {FreeCapacity: ptr.To(0.5)},
+				"build03": {FailureRate: ptr.To(0.3)},
+			},
+		},
+		{
+			name: "query fails",
+			queryFunc: func(ctx context.Context, query string, ts time.Time) (model.Value, prometheusapi.Warnings, error) {
+				return nil, nil, fmt.Errorf("oops")
+			},
+			expectedError: fmt.Errorf("oops"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, actualError := GetClusterHealthFromPrometheus(context.Background(), &prometheusAPIForTest{tc.queryFunc}, time.Now())
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.expectedError, actualError, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestHealthThresholdsProblems(t *testing.T) {
+	thresholds := HealthThresholds{MinFreeCapacity: 0.1, MaxFailureRate: 0.2}
+	health := ClusterHealthMap{
+		"saturated": {FreeCapacity: ptr.To(0.05), FailureRate: ptr.To(0.5)},
+		"unhealthy": {FreeCapacity: ptr.To(0.5), FailureRate: ptr.To(0.25)},
+		"fine":      {FreeCapacity: ptr.To(0.5), FailureRate: ptr.To(0.01)},
+		"unknown":   {},
+	}
+	expected := map[string]string{
+		"saturated": "saturated: 5% of its peak job capacity is free, below 10%",
+		"unhealthy": "unhealthy: 25% of recent jobs errored, above 20%",
+	}
+	if diff := cmp.Diff(expected, thresholds.Problems(health)); diff != "" {
+		t.Errorf("actual does not match expected, diff: %s", diff)
+	}
+}
+
+func TestDiffAssignments(t *testing.T) {
+	previous := map[string]ProwJobData{
+		"a":       {Cluster: "build01"},
+		"b":       {Cluster: "build02"},
+		"c":       {Cluster: "build03"},
+		"removed": {Cluster: "build01"},
+	}
+	current := map[string]ProwJobData{
+		"a":   {Cluster: "build03"},
+		"b":   {Cluster: "build03"},
+		"c":   {Cluster: "build03"},
+		"new": {Cluster: "build01"},
+	}
+	problems := map[string]string{"build01": "saturated: 5% of its peak job capacity is free, below 10%"}
+	expected := []Move{
+		{Job: "a", From: "build01", To: "build03", Reason: "build01 is saturated: 5% of its peak job capacity is free, below 10%"},
+		{Job: "b", From: "build02", To: "build03", Reason: "load balancing"},
+	}
+	moves := DiffAssignments(previous, current, problems)
+	if diff := cmp.Diff(expected, moves); diff != "" {
+		t.Errorf("actual does not match expected, diff: %s", diff)
+	}
+
+	expectedSummary := `2 jobs were moved between clusters:
+
+| From | To | Jobs | Reason |
+| --- | --- | --- | --- |
+| build01 | build03 | 1 | build01 is saturated: 5% of its peak job capacity is free, below 10% |
+| build02 | build03 | 1 | load balancing |
+`
+	if diff := cmp.Diff(expectedSummary, SummarizeMoves(moves)); diff != "" {
+		t.Errorf("actual summary does not match expected, diff: %s", diff)
+	}
+	if summary := SummarizeMoves(nil); summary != "" {
+		t.Errorf("expected no summary without moves, got %q", summary)
+	}
+}
@@ -52,6 +52,14 @@ func (pv *prometheusVolumes) GetJobVolumes() (map[string]float64, error) {
 	return pv.jobVolumes, nil
 }
 
+// GetClusterHealth gets the current health of the clusters
+func (pv *prometheusVolumes) GetClusterHealth() (ClusterHealthMap, error) {
+	v1api := prometheusapi.NewAPI(pv.promClient)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return GetClusterHealthFromPrometheus(ctx, v1api, time.Now())
+}
+
 func (pv *prometheusVolumes) getTotalVolume() float64 {
 	var totalVolume float64
 	for _, volume := range pv.jobVolumes {
@@ -0,0 +1,442 @@
+package gsmsecrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// RotationPhase is a step of a secret rotation
+type RotationPhase string
+
+const (
+	// RotationPhaseCreateVersion stages the new version in the candidate secret
+	RotationPhaseCreateVersion RotationPhase = "CreateVersion"
+	// RotationPhaseCanary syncs the candidate to the canary cluster and validates it there
+	RotationPhaseCanary RotationPhase = "Canary"
+	// RotationPhasePromote adds the validated candidate as the latest version of the secret
+	RotationPhasePromote RotationPhase = "Promote"
+	// RotationPhaseRollout syncs the new version to all clusters
+	RotationPhaseRollout RotationPhase = "Rollout"
+	// RotationPhaseGracePeriod waits for the grace period before disabling the previous version
+	RotationPhaseGracePeriod RotationPhase = "GracePeriod"
+	// RotationPhaseDone means the previous version was disabled
+	RotationPhaseDone RotationPhase = "Done"
+	// RotationPhaseRolledBack means the previous version was restored
+	RotationPhaseRolledBack RotationPhase = "RolledBack"
+)
+
+// RotationCandidateSuffix is appended to the name of a rotated secret to name the
+// secret the new version is staged in until it passes validation on the canary
+// cluster, so that it does not become the latest version before that
+const RotationCandidateSuffix = "____rotation-candidate"
+
+// RotationClient is the part of the Google Secret Manager client needed to rotate secrets
+type RotationClient interface {
+	GetSecret(ctx context.Context, req *secretmanagerpb.GetSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error)
+	CreateSecret(ctx context.Context, req *secretmanagerpb.CreateSecretRequest, opts ...gax.CallOption) (*secretmanagerpb.Secret, error)
+	AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+	AddSecretVersion(ctx context.Context, req *secretmanagerpb.AddSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	DisableSecretVersion(ctx context.Context, req *secretmanagerpb.DisableSecretVersionRequest, opts ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+}
+
+// RotationHooks are the operations that differ between secrets
+type RotationHooks struct {
+	// Generate creates the payload of the new version
+	Generate func(ctx context.Context) ([]byte, error)
+	// Sync syncs the given version of the secret, by its resource name, to a cluster
+	Sync func(ctx context.Context, cluster, version string) error
+	// Validate verifies that the secret synced to a cluster works
+	Validate func(ctx context.Context, cluster string) error
+}
+
+// Rotation configures the rotation of a secret
+type Rotation struct {
+	Collection string
+	Group      string
+	Field      string
+	// CanaryCluster receives and validates the new version before other clusters
+	CanaryCluster string
+	// Clusters are the remaining clusters the secret is synced to
+	Clusters []string
+	// GracePeriod is how long the previous version stays enabled after the rollout
+	GracePeriod time.Duration
+}
+
+// RotationState records the progress of a rotation, so an interrupted one can be resumed or rolled back
+type RotationState struct {
+	Phase RotationPhase `json:"phase"`
+	// PreviousVersion is the resource name of the version being rotated
+	PreviousVersion string `json:"previous_version,omitempty"`
+	// CandidateVersion is the resource name of the new version staged in the candidate secret
+	CandidateVersion string `json:"candidate_version,omitempty"`
+	// NewVersion is the resource name of the version the candidate was promoted to
+	NewVersion string `json:"new_version,omitempty"`
+	// SyncedClusters received the new version
+	SyncedClusters []string `json:"synced_clusters,omitempty"`
+	// RolledOutAt is when the new version was synced to all clusters
+	RolledOutAt *time.Time `json:"rolled_out_at,omitempty"`
+	// Error is the reason of the last failure
+	Error string `json:"error,omitempty"`
+}
+
+// LoadRotationState loads the state of a rotation from a file. A missing file means the rotation did not start.
+func LoadRotationState(path string) (*RotationState, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &RotationState{Phase: RotationPhaseCreateVersion}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read rotation state: %w", err)
+	}
+	var state RotationState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rotation state: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveRotationState atomically writes the state of a rotation to a file
+func SaveRotationState(path string, state *RotationState) error {
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rotation state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	_, err = tmp.Write(raw)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write rotation state: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Rotator drives a rotation from its recorded state
+type Rotator struct {
+	client    RotationClient
+	config    Config
+	rotation  Rotation
+	hooks     RotationHooks
+	saveState func(*RotationState) error
+	now       func() time.Time
+}
+
+// NewRotator creates a rotator which persists its state with saveState after every change
+func NewRotator(client RotationClient, config Config, rotation Rotation, hooks RotationHooks, saveState func(*RotationState) error) *Rotator {
+	return &Rotator{client: client, config: config, rotation: rotation, hooks: hooks, saveState: saveState, now: time.Now}
+}
+
+func (r *Rotator) secretPath() string {
+	return GetGSMSecretResourceName(r.config.ProjectIdNumber, r.rotation.Collection, r.rotation.Group, r.rotation.Field)
+}
+
+func (r *Rotator) candidatePath() string {
+	return r.secretPath() + RotationCandidateSuffix
+}
+
+func (r *Rotator) logger() *logrus.Entry {
+	return logrus.WithFields(logrus.Fields{"collection": r.rotation.Collection, "group": r.rotation.Group, "field": r.rotation.Field})
+}
+
+func (r *Rotator) transition(state *RotationState, phase RotationPhase) error {
+	r.logger().WithField("phase", phase).Info("Rotation entered a new phase.")
+	state.Phase = phase
+	state.Error = ""
+	return r.saveState(state)
+}
+
+// sync syncs the version to the clusters which did not receive it yet
+func (r *Rotator) sync(ctx context.Context, state *RotationState, version string, clusters ...string) error {
+	synced := sets.New[string](state.SyncedClusters...)
+	for _, cluster := range clusters {
+		if synced.Has(cluster) {
+			continue
+		}
+		if err := r.hooks.Sync(ctx, cluster, version); err != nil {
+			return fmt.Errorf("failed to sync to cluster %s: %w", cluster, err)
+		}
+		synced.Insert(cluster)
+		state.SyncedClusters = sets.List(synced)
+		if err := r.saveState(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run advances the rotation as far as possible. It returns without an error while
+// the grace period has not passed; running it again later finishes the rotation.
+// A failed validation on the canary cluster rolls the rotation back.
+// A rotation which was rolled back is started over.
+func (r *Rotator) Run(ctx context.Context, state *RotationState) error {
+	if state.Phase == RotationPhaseRolledBack {
+		r.logger().Info("The previous rotation was rolled back, starting a new one.")
+		*state = RotationState{Phase: RotationPhaseCreateVersion}
+	}
+	for {
+		var err error
+		switch state.Phase {
+		case RotationPhaseCreateVersion, "":
+			err = r.createVersion(ctx, state)
+		case RotationPhaseCanary:
+			if err = r.sync(ctx, state, state.CandidateVersion, r.rotation.CanaryCluster); err != nil {
+				break
+			}
+			if validationErr := r.hooks.Validate(ctx, r.rotation.CanaryCluster); validationErr != nil {
+				r.logger().WithError(validationErr).Warn("The new version failed validation on the canary cluster, rolling back.")
+				if err := r.Rollback(ctx, state); err != nil {
+					return fmt.Errorf("failed to roll back after failed validation: %w", err)
+				}
+				state.Error = fmt.Sprintf("validation on canary cluster %s failed: %v", r.rotation.CanaryCluster, validationErr)
+				if err := r.saveState(state); err != nil {
+					return err
+				}
+				return fmt.Errorf("rotation was rolled back: %s", state.Error)
+			}
+			err = r.transition(state, RotationPhasePromote)
+		case RotationPhasePromote:
+			err = r.promote(ctx, state)
+		case RotationPhaseRollout:
+			if err = r.sync(ctx, state, state.NewVersion, r.rotation.Clusters...); err != nil {
+				break
+			}
+			now := r.now()
+			state.RolledOutAt = &now
+			err = r.transition(state, RotationPhaseGracePeriod)
+		case RotationPhaseGracePeriod:
+			if remaining := state.RolledOutAt.Add(r.rotation.GracePeriod).Sub(r.now()); remaining > 0 {
+				r.logger().Infof("The previous version will be disabled in %s.", remaining.Round(time.Second))
+				return nil
+			}
+			if err = r.disable(ctx, state.PreviousVersion); err != nil {
+				break
+			}
+			err = r.transition(state, RotationPhaseDone)
+		case RotationPhaseDone, RotationPhaseRolledBack:
+			return nil
+		default:
+			return fmt.Errorf("unknown rotation phase %q", state.Phase)
+		}
+		if err != nil {
+			state.Error = err.Error()
+			if saveErr := r.saveState(state); saveErr != nil {
+				r.logger().WithError(saveErr).Error("Failed to save rotation state.")
+			}
+			return err
+		}
+	}
+}
+
+// createVersion records the version being rotated before staging the new one
+// in the candidate secret, so that a run interrupted after staging it adopts
+// the staged version on resume instead of generating another one. The latest
+// version of the secret is left untouched until the candidate is validated.
+func (r *Rotator) createVersion(ctx context.Context, state *RotationState) error {
+	if state.PreviousVersion == "" {
+		previous, err := r.latestVersion(ctx)
+		if err != nil {
+			return err
+		}
+		state.PreviousVersion = previous
+		state.SyncedClusters = nil
+		if err := r.saveState(state); err != nil {
+			return err
+		}
+	} else {
+		staged, err := r.stagedVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if staged != "" {
+			r.logger().WithField("version", staged).Info("Found the candidate staged by an interrupted run.")
+			state.CandidateVersion = staged
+			return r.transition(state, RotationPhaseCanary)
+		}
+	}
+	if err := r.ensureCandidateSecret(ctx); err != nil {
+		return err
+	}
+	payload, err := r.hooks.Generate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to generate the new version: %w", err)
+	}
+	version, err := r.client.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+		Parent:  r.candidatePath(),
+		Payload: &secretmanagerpb.SecretPayload{Data: payload},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stage the new version: %w", err)
+	}
+	state.CandidateVersion = version.Name
+	return r.transition(state, RotationPhaseCanary)
+}
+
+// promote adds the payload of the validated candidate as the latest version of
+// the secret and disables the candidate. A version added after the recorded
+// previous one was added by an interrupted run and is adopted.
+func (r *Rotator) promote(ctx context.Context, state *RotationState) error {
+	if state.NewVersion == "" {
+		latest, err := r.latestVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if latest != state.PreviousVersion {
+			r.logger().WithField("version", latest).Info("Found the new version added by an interrupted run.")
+			state.NewVersion = latest
+		} else {
+			candidate, err := r.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: state.CandidateVersion})
+			if err != nil {
+				return fmt.Errorf("failed to access the candidate version: %w", err)
+			}
+			version, err := r.client.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+				Parent:  r.secretPath(),
+				Payload: &secretmanagerpb.SecretPayload{Data: candidate.Payload.Data},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to add the new version: %w", err)
+			}
+			state.NewVersion = version.Name
+		}
+		if err := r.saveState(state); err != nil {
+			return err
+		}
+	}
+	if err := r.disable(ctx, state.CandidateVersion); err != nil {
+		return err
+	}
+	return r.transition(state, RotationPhaseRollout)
+}
+
+// latestVersion determines the resource name of the latest version
+func (r *Rotator) latestVersion(ctx context.Context) (string, error) {
+	latest, err := r.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: r.secretPath() + "/versions/latest"})
+	if err != nil {
+		return "", fmt.Errorf("failed to access the current version: %w", err)
+	}
+	return latest.Name, nil
+}
+
+// stagedVersion determines the candidate staged by a run which was interrupted
+// before recording it, if any. Candidates are disabled once they are promoted
+// or rolled back, so only the one of an unfinished rotation can be accessed.
+func (r *Rotator) stagedVersion(ctx context.Context) (string, error) {
+	staged, err := r.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: r.candidatePath() + "/versions/latest"})
+	if err != nil {
+		if s, ok := status.FromError(err); ok && (s.Code() == codes.NotFound || s.Code() == codes.FailedPrecondition) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to access the candidate version: %w", err)
+	}
+	return staged.Name, nil
+}
+
+func (r *Rotator) ensureCandidateSecret(ctx context.Context) error {
+	_, err := r.client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: r.candidatePath()})
+	if err == nil {
+		return nil
+	}
+	if s, ok := status.FromError(err); !ok || s.Code() != codes.NotFound {
+		return fmt.Errorf("failed to get the candidate secret: %w", err)
+	}
+	_, err = r.client.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
+		Parent:   GetProjectResourceIdNumber(r.config.ProjectIdNumber),
+		SecretId: GetSecretID(r.candidatePath()),
+		Secret: &secretmanagerpb.Secret{
+			Replication: &secretmanagerpb.Replication{
+				Replication: &secretmanagerpb.Replication_Automatic_{
+					Automatic: &secretmanagerpb.Replication_Automatic{},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create the candidate secret: %w", err)
+	}
+	return nil
+}
+
+func (r *Rotator) disable(ctx context.Context, version string) error {
+	if _, err := r.client.DisableSecretVersion(ctx, &secretmanagerpb.DisableSecretVersionRequest{Name: version}); err != nil {
+		return fmt.Errorf("failed to disable version %s: %w", version, err)
+	}
+	return nil
+}
+
+// Rollback disables the candidate and, once it was promoted, restores the
+// previous version by adding its payload as the latest version and disables
+// the new version. The clusters that received the candidate or the new version
+// are synced again. Rolling back is idempotent: the previous payload is only
+// added when the latest version does not hold it already. A rotation cannot be
+// rolled back once the previous version was disabled.
+func (r *Rotator) Rollback(ctx context.Context, state *RotationState) error {
+	switch state.Phase {
+	case RotationPhaseDone:
+		return errors.New("the previous version was already disabled")
+	case RotationPhaseRolledBack:
+		return nil
+	}
+	if state.PreviousVersion == "" {
+		return r.transition(state, RotationPhaseRolledBack)
+	}
+	if state.CandidateVersion == "" {
+		staged, err := r.stagedVersion(ctx)
+		if err != nil {
+			return err
+		}
+		state.CandidateVersion = staged
+	}
+	previous, err := r.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: state.PreviousVersion})
+	if err != nil {
+		return fmt.Errorf("failed to access the previous version: %w", err)
+	}
+	latest, err := r.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: r.secretPath() + "/versions/latest"})
+	if err != nil {
+		return fmt.Errorf("failed to access the current version: %w", err)
+	}
+	restored := latest.Name
+	if latest.Name != state.PreviousVersion && !bytes.Equal(latest.Payload.Data, previous.Payload.Data) {
+		if state.NewVersion == "" {
+			// the candidate was promoted by an interrupted run
+			state.NewVersion = latest.Name
+		}
+		version, err := r.client.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+			Parent:  r.secretPath(),
+			Payload: &secretmanagerpb.SecretPayload{Data: previous.Payload.Data},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore the previous version: %w", err)
+		}
+		restored = version.Name
+	}
+	for _, version := range []string{state.CandidateVersion, state.NewVersion} {
+		if version == "" {
+			continue
+		}
+		if err := r.disable(ctx, version); err != nil {
+			return err
+		}
+	}
+	for _, cluster := range state.SyncedClusters {
+		if err := r.hooks.Sync(ctx, cluster, restored); err != nil {
+			return fmt.Errorf("failed to sync the restored version to cluster %s: %w", cluster, err)
+		}
+	}
+	return r.transition(state, RotationPhaseRolledBack)
+}
@@ -0,0 +1,325 @@
+package gsmsecrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/google/go-cmp/cmp"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeRotationClient keeps the versions of secrets in memory
+type fakeRotationClient struct {
+	payloads map[string][]string
+	disabled []string
+}
+
+func (f *fakeRotationClient) isDisabled(version string) bool {
+	for _, disabled := range f.disabled {
+		if disabled == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeRotationClient) GetSecret(_ context.Context, req *secretmanagerpb.GetSecretRequest, _ ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+	if _, ok := f.payloads[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "secret %s not found", req.Name)
+	}
+	return &secretmanagerpb.Secret{Name: req.Name}, nil
+}
+
+func (f *fakeRotationClient) CreateSecret(_ context.Context, req *secretmanagerpb.CreateSecretRequest, _ ...gax.CallOption) (*secretmanagerpb.Secret, error) {
+	name := fmt.Sprintf("%s/secrets/%s", req.Parent, req.SecretId)
+	f.payloads[name] = nil
+	return &secretmanagerpb.Secret{Name: name}, nil
+}
+
+func (f *fakeRotationClient) AccessSecretVersion(_ context.Context, req *secretmanagerpb.AccessSecretVersionRequest, _ ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	secret, version, _ := strings.Cut(req.Name, "/versions/")
+	payloads := f.payloads[secret]
+	n := len(payloads)
+	if version != "latest" {
+		n, _ = strconv.Atoi(version)
+	}
+	if n < 1 || n > len(payloads) {
+		return nil, status.Errorf(codes.NotFound, "version %s not found", req.Name)
+	}
+	name := fmt.Sprintf("%s/versions/%d", secret, n)
+	if f.isDisabled(name) {
+		return nil, status.Errorf(codes.FailedPrecondition, "version %s is disabled", name)
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{Name: name, Payload: &secretmanagerpb.SecretPayload{Data: []byte(payloads[n-1])}}, nil
+}
+
+func (f *fakeRotationClient) AddSecretVersion(_ context.Context, req *secretmanagerpb.AddSecretVersionRequest, _ ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	if _, ok := f.payloads[req.Parent]; !ok {
+		return nil, status.Errorf(codes.NotFound, "secret %s not found", req.Parent)
+	}
+	f.payloads[req.Parent] = append(f.payloads[req.Parent], string(req.Payload.Data))
+	return &secretmanagerpb.SecretVersion{Name: fmt.Sprintf("%s/versions/%d", req.Parent, len(f.payloads[req.Parent]))}, nil
+}
+
+func (f *fakeRotationClient) DisableSecretVersion(_ context.Context, req *secretmanagerpb.DisableSecretVersionRequest, _ ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	f.disabled = append(f.disabled, req.Name)
+	return &secretmanagerpb.SecretVersion{Name: req.Name}, nil
+}
+
+func TestRotator(t *testing.T) {
+	config := Config{ProjectIdString: "project", ProjectIdNumber: "123"}
+	rotation := Rotation{Collection: "collection", Group: "group", Field: "token", CanaryCluster: "build01", Clusters: []string{"build02", "build03"}, GracePeriod: time.Hour}
+	secret := GetGSMSecretResourceName(config.ProjectIdNumber, rotation.Collection, rotation.Group, rotation.Field)
+	candidate := secret + RotationCandidateSuffix
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name               string
+		state              RotationState
+		versions           []string
+		candidates         []string
+		disabled           []string
+		failSync           map[string]int
+		failValidate       bool
+		elapsed            time.Duration
+		rollback           bool
+		expected           RotationState
+		expectedErr        bool
+		expectedSyncs      []string
+		expectedData       []string
+		expectedCandidates []string
+		expectedOff        []string
+	}{
+		{
+			name:               "rotation waits for the grace period",
+			state:              RotationState{Phase: RotationPhaseCreateVersion},
+			expected:           RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			expectedSyncs:      []string{"build01=" + candidate + "/versions/1", "build02=" + secret + "/versions/2", "build03=" + secret + "/versions/2"},
+			expectedData:       []string{"old", "new"},
+			expectedCandidates: []string{"new"},
+			expectedOff:        []string{candidate + "/versions/1"},
+		},
+		{
+			name:               "rotation disables the previous version after the grace period",
+			state:              RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			versions:           []string{"old", "new"},
+			candidates:         []string{"new"},
+			disabled:           []string{candidate + "/versions/1"},
+			elapsed:            2 * time.Hour,
+			expected:           RotationState{Phase: RotationPhaseDone, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			expectedData:       []string{"old", "new"},
+			expectedCandidates: []string{"new"},
+			expectedOff:        []string{candidate + "/versions/1", secret + "/versions/1"},
+		},
+		{
+			name:               "interrupted creation adopts the candidate it staged",
+			state:              RotationState{Phase: RotationPhaseCreateVersion, PreviousVersion: secret + "/versions/1", Error: "interrupted"},
+			candidates:         []string{"staged"},
+			expected:           RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			expectedSyncs:      []string{"build01=" + candidate + "/versions/1", "build02=" + secret + "/versions/2", "build03=" + secret + "/versions/2"},
+			expectedData:       []string{"old", "staged"},
+			expectedCandidates: []string{"staged"},
+			expectedOff:        []string{candidate + "/versions/1"},
+		},
+		{
+			name:               "interrupted creation stages the candidate it did not stage",
+			state:              RotationState{Phase: RotationPhaseCreateVersion, PreviousVersion: secret + "/versions/1", Error: "interrupted"},
+			candidates:         []string{"promoted"},
+			disabled:           []string{candidate + "/versions/1"},
+			expected:           RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/2", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			expectedSyncs:      []string{"build01=" + candidate + "/versions/2", "build02=" + secret + "/versions/2", "build03=" + secret + "/versions/2"},
+			expectedData:       []string{"old", "new"},
+			expectedCandidates: []string{"promoted", "new"},
+			expectedOff:        []string{candidate + "/versions/1", candidate + "/versions/2"},
+		},
+		{
+			name:               "interrupted promotion adopts the version it added",
+			state:              RotationState{Phase: RotationPhasePromote, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", SyncedClusters: []string{"build01"}},
+			versions:           []string{"old", "new"},
+			candidates:         []string{"new"},
+			expected:           RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			expectedSyncs:      []string{"build02=" + secret + "/versions/2", "build03=" + secret + "/versions/2"},
+			expectedData:       []string{"old", "new"},
+			expectedCandidates: []string{"new"},
+			expectedOff:        []string{candidate + "/versions/1"},
+		},
+		{
+			name:               "rolled back rotation starts over",
+			state:              RotationState{Phase: RotationPhaseRolledBack, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", SyncedClusters: []string{"build01"}, Error: "validation on canary cluster build01 failed: injected"},
+			candidates:         []string{"bad"},
+			disabled:           []string{candidate + "/versions/1"},
+			expected:           RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/2", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			expectedSyncs:      []string{"build01=" + candidate + "/versions/2", "build02=" + secret + "/versions/2", "build03=" + secret + "/versions/2"},
+			expectedData:       []string{"old", "new"},
+			expectedCandidates: []string{"bad", "new"},
+			expectedOff:        []string{candidate + "/versions/1", candidate + "/versions/2"},
+		},
+		{
+			name:               "rollback of interrupted creation disables the candidate it staged",
+			state:              RotationState{Phase: RotationPhaseCreateVersion, PreviousVersion: secret + "/versions/1", Error: "interrupted"},
+			candidates:         []string{"new"},
+			rollback:           true,
+			expected:           RotationState{Phase: RotationPhaseRolledBack, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1"},
+			expectedData:       []string{"old"},
+			expectedCandidates: []string{"new"},
+			expectedOff:        []string{candidate + "/versions/1"},
+		},
+		{
+			name:               "failed validation on the canary cluster rolls back without touching the latest version",
+			state:              RotationState{Phase: RotationPhaseCreateVersion},
+			failValidate:       true,
+			expected:           RotationState{Phase: RotationPhaseRolledBack, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", SyncedClusters: []string{"build01"}, Error: "validation on canary cluster build01 failed: injected"},
+			expectedErr:        true,
+			expectedSyncs:      []string{"build01=" + candidate + "/versions/1", "build01=" + secret + "/versions/1"},
+			expectedData:       []string{"old"},
+			expectedCandidates: []string{"new"},
+			expectedOff:        []string{candidate + "/versions/1"},
+		},
+		{
+			name:               "failed sync during the rollout is recorded",
+			state:              RotationState{Phase: RotationPhaseCreateVersion},
+			failSync:           map[string]int{"build03": 1},
+			expected:           RotationState{Phase: RotationPhaseRollout, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02"}, Error: "failed to sync to cluster build03: injected"},
+			expectedErr:        true,
+			expectedSyncs:      []string{"build01=" + candidate + "/versions/1", "build02=" + secret + "/versions/2"},
+			expectedData:       []string{"old", "new"},
+			expectedCandidates: []string{"new"},
+			expectedOff:        []string{candidate + "/versions/1"},
+		},
+		{
+			name:          "interrupted rollout resumes with the remaining clusters",
+			state:         RotationState{Phase: RotationPhaseRollout, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02"}, Error: "failed to sync to cluster build03: injected"},
+			versions:      []string{"old", "new"},
+			expected:      RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			expectedSyncs: []string{"build03=" + secret + "/versions/2"},
+			expectedData:  []string{"old", "new"},
+		},
+		{
+			name:          "rollback during the grace period",
+			state:         RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			versions:      []string{"old", "new"},
+			rollback:      true,
+			expected:      RotationState{Phase: RotationPhaseRolledBack, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			expectedSyncs: []string{"build01=" + secret + "/versions/3", "build02=" + secret + "/versions/3", "build03=" + secret + "/versions/3"},
+			expectedData:  []string{"old", "new", "old"},
+			expectedOff:   []string{candidate + "/versions/1", secret + "/versions/2"},
+		},
+		{
+			name:          "interrupted rollback does not restore the previous version again",
+			state:         RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			versions:      []string{"old", "new", "old"},
+			rollback:      true,
+			expected:      RotationState{Phase: RotationPhaseRolledBack, PreviousVersion: secret + "/versions/1", CandidateVersion: candidate + "/versions/1", NewVersion: secret + "/versions/2", SyncedClusters: []string{"build01", "build02", "build03"}, RolledOutAt: &start},
+			expectedSyncs: []string{"build01=" + secret + "/versions/3", "build02=" + secret + "/versions/3", "build03=" + secret + "/versions/3"},
+			expectedData:  []string{"old", "new", "old"},
+			expectedOff:   []string{candidate + "/versions/1", secret + "/versions/2"},
+		},
+		{
+			name:         "rotation cannot be rolled back after the previous version was disabled",
+			state:        RotationState{Phase: RotationPhaseDone, PreviousVersion: secret + "/versions/1", NewVersion: secret + "/versions/2"},
+			rollback:     true,
+			expected:     RotationState{Phase: RotationPhaseDone, PreviousVersion: secret + "/versions/1", NewVersion: secret + "/versions/2"},
+			expectedErr:  true,
+			expectedData: []string{"old"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			versions := tc.versions
+			if versions == nil {
+				versions = []string{"old"}
+			}
+			client := &fakeRotationClient{payloads: map[string][]string{secret: versions}, disabled: tc.disabled}
+			if tc.candidates != nil {
+				client.payloads[candidate] = tc.candidates
+			}
+			var syncs []string
+			hooks := RotationHooks{
+				Generate: func(context.Context) ([]byte, error) { return []byte("new"), nil },
+				Sync: func(_ context.Context, cluster, version string) error {
+					if tc.failSync[cluster] > 0 {
+						tc.failSync[cluster]--
+						return errors.New("injected")
+					}
+					syncs = append(syncs, fmt.Sprintf("%s=%s", cluster, version))
+					return nil
+				},
+				Validate: func(context.Context, string) error {
+					if tc.failValidate {
+						return errors.New("injected")
+					}
+					return nil
+				},
+			}
+			var saved RotationState
+			rotator := NewRotator(client, config, rotation, hooks, func(state *RotationState) error {
+				saved = *state
+				return nil
+			})
+			rotator.now = func() time.Time { return start.Add(tc.elapsed) }
+
+			state := tc.state
+			var err error
+			if tc.rollback {
+				err = rotator.Rollback(context.Background(), &state)
+			} else {
+				err = rotator.Run(context.Background(), &state)
+			}
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, state); diff != "" {
+				t.Errorf("unexpected state: %s", diff)
+			}
+			if saved.Phase != "" {
+				if diff := cmp.Diff(state, saved); diff != "" {
+					t.Errorf("saved state differs from the final state: %s", diff)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedSyncs, syncs); diff != "" {
+				t.Errorf("unexpected syncs: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedData, client.payloads[secret]); diff != "" {
+				t.Errorf("unexpected versions: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedCandidates, client.payloads[candidate]); diff != "" {
+				t.Errorf("unexpected candidate versions: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedOff, client.disabled); diff != "" {
+				t.Errorf("unexpected disabled versions: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRotationState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadRotationState(path)
+	if err != nil {
+		t.Fatalf("failed to load missing state: %v", err)
+	}
+	if diff := cmp.Diff(&RotationState{Phase: RotationPhaseCreateVersion}, state); diff != "" {
+		t.Errorf("unexpected initial state: %s", diff)
+	}
+	rolledOut := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	state = &RotationState{Phase: RotationPhaseGracePeriod, PreviousVersion: "v1", NewVersion: "v2", SyncedClusters: []string{"build01"}, RolledOutAt: &rolledOut}
+	if err := SaveRotationState(path, state); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+	loaded, err := LoadRotationState(path)
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if diff := cmp.Diff(state, loaded); diff != "" {
+		t.Errorf("unexpected loaded state: %s", diff)
+	}
+}
@@ -29,6 +29,8 @@ func NewJobAggregatorCommand() *cobra.Command {
 	cmd.AddCommand(jobrunbigqueryloader.NewBigQueryDisruptionUploadFlagsCommand())
 	cmd.AddCommand(jobrunbigqueryloader.NewBigQueryAlertUploadFlagsCommand())
 	cmd.AddCommand(jobrunaggregatoranalyzer.NewJobRunsAnalyzerCommand())
+	cmd.AddCommand(jobrunaggregatoranalyzer.NewSnapshotBaselinesCommand())
+	cmd.AddCommand(jobrunaggregatoranalyzer.NewBisectTestRegressionCommand())
 	cmd.AddCommand(jobtableprimer.NewPrimeJobTableCommand())
 
 	cmd.AddCommand(releasebigqueryloader.NewBigQueryReleaseTableCreateFlagsCommand())
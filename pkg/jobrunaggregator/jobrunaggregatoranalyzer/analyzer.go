@@ -29,6 +29,8 @@ import (
 type JobRunAggregatorAnalyzerOptions struct {
 	jobRunLocator      jobrunaggregatorlib.JobRunLocator
 	passFailCalculator baseline
+	// significanceConfig selects the failure criterion of each test, the heuristic is used for all tests when it is nil
+	significanceConfig *SignificanceConfig
 
 	// explicitGCSPrefix is set to control the base path we search in GCSBuckets. If not set, the jobName will be used
 	// to set a default value that usually works.
@@ -46,7 +48,9 @@ type JobRunAggregatorAnalyzerOptions struct {
 	prowJobClient       *prowjobclientset.Clientset
 	jobStateQuerySource string
 	prowJobMatcherFunc  jobrunaggregatorlib.ProwJobMatcherFunc
-	ciDataClient        jobrunaggregatorlib.CIDataClient
+	// ciDataClient is nil when neither the job runs nor the baselines are read from BigQuery
+	ciDataClient       jobrunaggregatorlib.CIDataClient
+	baselineDataSource jobrunaggregatorlib.BaselineDataSource
 
 	staticJobRunIdentifiers []jobrunaggregatorlib.JobRunIdentifier
 	gcsBucket               string
@@ -104,6 +108,24 @@ func (o *JobRunAggregatorAnalyzerOptions) GetRelatedJobRuns(ctx context.Context)
 	}
 }
 
+// getJobVariants gets the variants of the job from BigQuery or, when it is not used, from the baselines.
+// A job the baselines do not know has no variants.
+func (o *JobRunAggregatorAnalyzerOptions) getJobVariants(ctx context.Context) (*jobrunaggregatorapi.JobRowWithVariants, error) {
+	if o.ciDataClient != nil {
+		return o.ciDataClient.GetJobVariants(ctx, o.jobName)
+	}
+	jobs, err := o.baselineDataSource.ListAllJobsWithVariants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the jobs of the baselines: %w", err)
+	}
+	for i := range jobs {
+		if jobs[i].JobName == o.jobName {
+			return &jobs[i], nil
+		}
+	}
+	return &jobrunaggregatorapi.JobRowWithVariants{JobName: o.jobName}, nil
+}
+
 func (o *JobRunAggregatorAnalyzerOptions) Run(ctx context.Context) error {
 	// if it hasn't been more than two hours since the jobRuns started, the list isn't complete.
 	readyAt := o.jobRunStartEstimate.Add(10 * time.Minute)
@@ -252,11 +274,11 @@ func (o *JobRunAggregatorAnalyzerOptions) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := assignPassFail(ctx, o.jobName, currentAggregationJunitSuites, o.passFailCalculator); err != nil {
+	if err := assignPassFail(ctx, o.jobName, currentAggregationJunitSuites, o.passFailCalculator, o.significanceConfig); err != nil {
 		return err
 	}
 
-	jobWithVariants, err := o.ciDataClient.GetJobVariants(ctx, o.jobName)
+	jobWithVariants, err := o.getJobVariants(ctx)
 	if err != nil {
 		return err
 	}
@@ -103,10 +103,15 @@ func (o *JobRunAggregatorAnalyzerOptions) CalculateDisruptionTestSuite(ctx conte
 			if err != nil {
 				return nil, err
 			}
+			significance, err := o.passFailCalculator.DisruptionSignificance(ctx, jobRunIDToAvailabilityResultForBackend, backendName, masterNodesUpdated)
+			if err != nil {
+				return nil, err
+			}
 
 			testCaseName := fmt.Sprintf(testCaseNamePattern, backendName)
 			testSuiteName := "aggregated-disruption"
-			junitTestCase, err := disruptionToJUnitTestCase(testCaseName, testSuiteName, jobGCSBucketRoot, failedJobRunIDs, successfulJobRunIDs, status, message)
+			status, message = o.significanceConfig.apply(testCaseName, status, message, significance)
+			junitTestCase, err := disruptionToJUnitTestCase(testCaseName, testSuiteName, jobGCSBucketRoot, failedJobRunIDs, successfulJobRunIDs, status, message, significance)
 			if err != nil {
 				return nil, err
 			}
@@ -129,7 +134,7 @@ func checkPercentileDisruption(passFailCalculator baseline, percentile, graceSec
 
 type disruptionJunitCheckFunc func(ctx context.Context, jobRunIDToAvailabilityResultForBackend map[string]jobrunaggregatorlib.AvailabilityResult, backend, masterNodesUpdated string) (failedJobRunsIDs []string, successfulJobRunIDs []string, status testCaseStatus, message string, err error)
 
-func disruptionToJUnitTestCase(testCaseName, testSuiteName, jobGCSBucketRoot string, failedJobRunIDs, successfulJobRunIDs []string, status testCaseStatus, message string) (*junit.TestCase, error) {
+func disruptionToJUnitTestCase(testCaseName, testSuiteName, jobGCSBucketRoot string, failedJobRunIDs, successfulJobRunIDs []string, status testCaseStatus, message string, significance *jobrunaggregatorlib.Significance) (*junit.TestCase, error) {
 	junitTestCase := &junit.TestCase{
 		Name: testCaseName,
 	}
@@ -138,6 +143,7 @@ func disruptionToJUnitTestCase(testCaseName, testSuiteName, jobGCSBucketRoot str
 		Name:          junitTestCase.Name,
 		TestSuiteName: testSuiteName,
 		Summary:       message,
+		Significance:  significance,
 	}
 	for _, jobRunID := range failedJobRunIDs {
 		humanURL := jobrunaggregatorapi.GetHumanURLForLocation(path.Join(jobGCSBucketRoot, jobRunID), "test-platform-results")
@@ -323,6 +323,13 @@ func (m mockPassFailCalculator) CheckPercentileDisruption(ctx context.Context, j
 	return failureJobRunIDs, successJobRunIDs, status, message, nil
 }
 
+func (m mockPassFailCalculator) PassRateSignificance(ctx context.Context, jobName string, testCaseDetails *jobrunaggregatorlib.TestCaseDetails) (*jobrunaggregatorlib.Significance, error) {
+	return nil, nil
+}
+func (m mockPassFailCalculator) DisruptionSignificance(ctx context.Context, jobRunIDToAvailabilityResultForBackend map[string]jobrunaggregatorlib.AvailabilityResult, backend, masterNodesUpdated string) (*jobrunaggregatorlib.Significance, error) {
+	return nil, nil
+}
+
 func NewMockPassFailCalculator(jobRunIDs []string, historicalBackendDisruptions jobrunaggregatorlib.BackendDisruptionList) mockPassFailCalculator {
 	return mockPassFailCalculator{
 		jobRunIDs:                    jobRunIDs,
@@ -330,6 +337,32 @@ func NewMockPassFailCalculator(jobRunIDs []string, historicalBackendDisruptions
 	}
 }
 
+func TestGetJobVariants(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// without BigQuery, the variants of the job are looked up in the baselines
+	baselineDataSource := jobrunaggregatorlib.NewMockCIDataClient(mockCtrl)
+	baselineDataSource.EXPECT().ListAllJobsWithVariants(gomock.Any()).Return([]jobrunaggregatorapi.JobRowWithVariants{
+		{JobName: "other", Topology: "ha"},
+		{JobName: testJobName, Topology: "single"},
+	}, nil).Times(2)
+	o := &JobRunAggregatorAnalyzerOptions{jobName: testJobName, baselineDataSource: baselineDataSource}
+	variants, err := o.getJobVariants(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "single", variants.Topology)
+
+	o.jobName = "unknown"
+	variants, err = o.getJobVariants(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, &jobrunaggregatorapi.JobRowWithVariants{JobName: "unknown"}, variants)
+
+	// without BigQuery, related job runs cannot be found
+	locator := jobrunaggregatorlib.NewPayloadAnalysisJobLocatorForReleaseController(testJobName, testPayloadtag, time.Now(), nil, nil, "bucketname")
+	_, err = locator.FindRelatedJobs(context.TODO())
+	assert.Error(t, err)
+}
+
 func TestIsInformingTest(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -530,7 +563,7 @@ func TestInformingTestFailureMessage(t *testing.T) {
 	}
 
 	// Use a mock that always fails tests
-	err := assignPassFail(context.TODO(), "test-job", suite, &alwaysFailBaseline{})
+	err := assignPassFail(context.TODO(), "test-job", suite, &alwaysFailBaseline{}, nil)
 	assert.NoError(t, err)
 
 	tc := suite.Suites[0].TestCases[0]
@@ -628,3 +661,11 @@ func (b *alwaysFailBaseline) CheckDisruptionMeanWithinOneStandardDeviation(_ con
 func (b *alwaysFailBaseline) CheckPercentileDisruption(_ context.Context, _ map[string]jobrunaggregatorlib.AvailabilityResult, _ string, _ int, _ int, _ string) ([]string, []string, testCaseStatus, string, error) {
 	return []string{}, b.jobRunIDs, testCasePassed, "", nil
 }
+
+func (b *alwaysFailBaseline) PassRateSignificance(_ context.Context, _ string, _ *jobrunaggregatorlib.TestCaseDetails) (*jobrunaggregatorlib.Significance, error) {
+	return nil, nil
+}
+
+func (b *alwaysFailBaseline) DisruptionSignificance(_ context.Context, _ map[string]jobrunaggregatorlib.AvailabilityResult, _, _ string) (*jobrunaggregatorlib.Significance, error) {
+	return nil, nil
+}
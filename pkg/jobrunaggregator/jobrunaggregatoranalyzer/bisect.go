@@ -0,0 +1,181 @@
+package jobrunaggregatoranalyzer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+)
+
+// payloadRegression is the payload from which on a test passes significantly less often than before
+type payloadRegression struct {
+	lastGood jobrunaggregatorapi.ReleaseTagTestRunCountsRow
+	firstBad jobrunaggregatorapi.ReleaseTagTestRunCountsRow
+
+	passesBefore, attemptsBefore int
+	passesAfter, attemptsAfter   int
+	// pValue is corrected for trying every payload as the first bad one
+	pValue float64
+}
+
+func passRate(passes, attempts int) float64 {
+	if attempts == 0 {
+		return 0
+	}
+	return 100 * float64(passes) / float64(attempts)
+}
+
+// findRegression tries every payload as the first bad one, comparing the runs against the payloads before it
+// with the runs against it and the payloads after it with Fisher's exact test. The payload with the lowest
+// p-value is the first bad one if the p-value, after the Bonferroni correction for the number of payloads
+// tried, is below alpha. Flakes passed on retry, so they count as passes.
+func findRegression(payloads []jobrunaggregatorapi.ReleaseTagTestRunCountsRow, alpha float64) *payloadRegression {
+	var ran []jobrunaggregatorapi.ReleaseTagTestRunCountsRow
+	totalPasses, totalAttempts := 0, 0
+	for _, payload := range payloads {
+		attempts := payload.PassCount + payload.FlakeCount + payload.FailCount
+		if attempts == 0 {
+			continue
+		}
+		ran = append(ran, payload)
+		totalPasses += payload.PassCount + payload.FlakeCount
+		totalAttempts += attempts
+	}
+	if len(ran) < 2 {
+		return nil
+	}
+
+	var best *payloadRegression
+	passesBefore, attemptsBefore := 0, 0
+	for i := 1; i < len(ran); i++ {
+		passesBefore += ran[i-1].PassCount + ran[i-1].FlakeCount
+		attemptsBefore += ran[i-1].PassCount + ran[i-1].FlakeCount + ran[i-1].FailCount
+		passesAfter, attemptsAfter := totalPasses-passesBefore, totalAttempts-attemptsBefore
+		pValue := fisherExactLess(passesAfter, attemptsAfter-passesAfter, passesBefore, attemptsBefore-passesBefore)
+		if best == nil || pValue < best.pValue {
+			best = &payloadRegression{
+				lastGood:       ran[i-1],
+				firstBad:       ran[i],
+				passesBefore:   passesBefore,
+				attemptsBefore: attemptsBefore,
+				passesAfter:    passesAfter,
+				attemptsAfter:  attemptsAfter,
+				pValue:         pValue,
+			}
+		}
+	}
+	best.pValue = math.Min(1, best.pValue*float64(len(ran)-1))
+	if best.pValue >= alpha {
+		return nil
+	}
+	return best
+}
+
+type bisectTestRegressionOptions struct {
+	ciDataClient jobrunaggregatorlib.CIDataClient
+
+	jobName  string
+	testName string
+	since    time.Time
+	alpha    float64
+
+	out io.Writer
+}
+
+// payloadsOfLatestStream keeps the payloads of the release, stream and architecture of the latest payload.
+// A job may have run against payloads of several streams, like when it moved from ci to nightly payloads,
+// but only payloads of one stream are ordered and can be listed between the last good and the first bad one.
+func payloadsOfLatestStream(payloads []jobrunaggregatorapi.ReleaseTagTestRunCountsRow) []jobrunaggregatorapi.ReleaseTagTestRunCountsRow {
+	if len(payloads) == 0 {
+		return nil
+	}
+	latest := payloads[len(payloads)-1]
+	var ret []jobrunaggregatorapi.ReleaseTagTestRunCountsRow
+	for _, payload := range payloads {
+		if payload.Release == latest.Release && payload.Stream == latest.Stream && payload.Architecture == latest.Architecture {
+			ret = append(ret, payload)
+		}
+	}
+	return ret
+}
+
+func (o *bisectTestRegressionOptions) Run(ctx context.Context) error {
+	allPayloads, err := o.ciDataClient.ListTestRunCountsByReleaseTag(ctx, o.jobName, o.testName, o.since)
+	if err != nil {
+		return fmt.Errorf("failed to list the runs of %q in %s by payload: %w", o.testName, o.jobName, err)
+	}
+	payloads := payloadsOfLatestStream(allPayloads)
+	regression := findRegression(payloads, o.alpha)
+	if regression == nil {
+		_, err := fmt.Fprintf(o.out, "The pass rate of %q in %s did not drop significantly at alpha=%g in %d payloads since %s.\n",
+			o.testName, o.jobName, o.alpha, len(payloads), o.since.Format(time.RFC3339))
+		return err
+	}
+
+	tags, err := o.ciDataClient.ListReleaseTagsBetween(ctx, regression.lastGood.ReleaseTag, regression.firstBad.ReleaseTag)
+	if err != nil {
+		return fmt.Errorf("failed to list the payloads between %s and %s: %w", regression.lastGood.ReleaseTag, regression.firstBad.ReleaseTag, err)
+	}
+	var tagNames []string
+	for _, tag := range tags {
+		tagNames = append(tagNames, tag.ReleaseTag)
+	}
+	var pullRequests []jobrunaggregatorapi.ReleasePullRequestRow
+	if len(tagNames) > 0 {
+		pullRequests, err = o.ciDataClient.ListReleasePullRequests(ctx, tagNames)
+		if err != nil {
+			return fmt.Errorf("failed to list the pull requests between %s and %s: %w", regression.lastGood.ReleaseTag, regression.firstBad.ReleaseTag, err)
+		}
+	}
+
+	return writeRegressionReport(o.out, o.jobName, o.testName, regression, tags, pullRequests)
+}
+
+// writeRegressionReport describes the regression and the changes in the payloads after the last good one,
+// up to and including the first bad one
+func writeRegressionReport(out io.Writer, jobName, testName string, regression *payloadRegression, tags []jobrunaggregatorapi.ReleaseTagRow, pullRequests []jobrunaggregatorapi.ReleasePullRequestRow) error {
+	w := &reportWriter{out: out}
+	w.printf("The pass rate of %q in %s dropped from %.1f%% (%d/%d) to %.1f%% (%d/%d), p=%.4g.\n",
+		testName, jobName,
+		passRate(regression.passesBefore, regression.attemptsBefore), regression.passesBefore, regression.attemptsBefore,
+		passRate(regression.passesAfter, regression.attemptsAfter), regression.passesAfter, regression.attemptsAfter,
+		regression.pValue)
+	w.printf("Last good payload: %s\n", regression.lastGood.ReleaseTag)
+	w.printf("First bad payload: %s\n", regression.firstBad.ReleaseTag)
+
+	w.printf("\nChanges in %d payloads:\n", len(tags))
+	for _, tag := range tags {
+		if len(tag.PreviousOSVersion) > 0 {
+			w.printf("  %s: machine OS %s -> %s %s\n", tag.ReleaseTag, tag.PreviousOSVersion, tag.CurrentOSVersion, tag.OSDiffURL)
+		}
+	}
+	seen := map[string]bool{}
+	for _, pullRequest := range pullRequests {
+		if seen[pullRequest.URL] {
+			continue
+		}
+		seen[pullRequest.URL] = true
+		w.printf("  %s: %s %s\n", pullRequest.Name, pullRequest.Description, pullRequest.URL)
+	}
+	if len(seen) == 0 {
+		w.printf("  no pull requests are recorded for these payloads\n")
+	}
+	return w.err
+}
+
+// reportWriter remembers the first error writing the report, so it only has to be checked once
+type reportWriter struct {
+	out io.Writer
+	err error
+}
+
+func (w *reportWriter) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.out, format, args...)
+}
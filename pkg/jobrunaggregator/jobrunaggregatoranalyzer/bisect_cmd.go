@@ -0,0 +1,120 @@
+package jobrunaggregatoranalyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+)
+
+type bisectTestRegressionFlags struct {
+	DataCoordinates *jobrunaggregatorlib.BigQueryDataCoordinates
+	Authentication  *jobrunaggregatorlib.GoogleAuthenticationFlags
+
+	JobName  string
+	TestName string
+	DaysBack int
+	Alpha    float64
+}
+
+func newBisectTestRegressionFlags() *bisectTestRegressionFlags {
+	return &bisectTestRegressionFlags{
+		DataCoordinates: jobrunaggregatorlib.NewBigQueryDataCoordinates(),
+		Authentication:  jobrunaggregatorlib.NewGoogleAuthenticationFlags(),
+
+		DaysBack: 14,
+		Alpha:    defaultAlpha,
+	}
+}
+
+func (f *bisectTestRegressionFlags) BindFlags(fs *pflag.FlagSet) {
+	f.DataCoordinates.BindFlags(fs)
+	f.Authentication.BindFlags(fs)
+
+	fs.StringVar(&f.JobName, "job", f.JobName, "The name of the job the test regressed in, like periodic-ci-openshift-release-master-ci-4.9-e2e-gcp-upgrade")
+	fs.StringVar(&f.TestName, "test", f.TestName, "The name of the test that regressed")
+	fs.IntVar(&f.DaysBack, "days-back", f.DaysBack, "How many days of payloads to search")
+	fs.Float64Var(&f.Alpha, "alpha", f.Alpha, "The significance level below which a drop in the pass rate is reported")
+}
+
+func NewBisectTestRegressionCommand() *cobra.Command {
+	f := newBisectTestRegressionFlags()
+
+	cmd := &cobra.Command{
+		Use: "bisect-test-regression",
+		Long: `Find the first payload from which on a test passes significantly less often in a job, and list the
+changes between the last good and the first bad payload.`,
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			if err := f.Validate(); err != nil {
+				logrus.WithError(err).Fatal("Flags are invalid")
+			}
+			o, err := f.ToOptions(ctx)
+			if err != nil {
+				logrus.WithError(err).Fatal("Failed to build runtime options")
+			}
+
+			if err := o.Run(ctx); err != nil {
+				logrus.WithError(err).Fatal("Command failed")
+			}
+
+			return nil
+		},
+
+		Args: jobrunaggregatorlib.NoArgs,
+	}
+
+	f.BindFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Validate checks to see if the user-input is likely to produce functional runtime options
+func (f *bisectTestRegressionFlags) Validate() error {
+	if len(f.JobName) == 0 {
+		return fmt.Errorf("missing --job: like periodic-ci-openshift-release-master-ci-4.9-e2e-gcp-upgrade")
+	}
+	if len(f.TestName) == 0 {
+		return fmt.Errorf("missing --test")
+	}
+	if f.DaysBack <= 0 {
+		return fmt.Errorf("--days-back must be positive")
+	}
+	if f.Alpha <= 0 || f.Alpha >= 1 {
+		return fmt.Errorf("--alpha must be between 0 and 1")
+	}
+	if err := f.DataCoordinates.Validate(); err != nil {
+		return err
+	}
+	return f.Authentication.Validate()
+}
+
+// ToOptions goes from the user input to the runtime values need to run the command.
+// Expect to see unit tests on the options, but not on the flags which are simply value mappings.
+func (f *bisectTestRegressionFlags) ToOptions(ctx context.Context) (*bisectTestRegressionOptions, error) {
+	bigQueryClient, err := f.Authentication.NewBigQueryClient(ctx, f.DataCoordinates.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	ciDataClient := jobrunaggregatorlib.NewRetryingCIDataClient(
+		jobrunaggregatorlib.NewCIDataClient(*f.DataCoordinates, bigQueryClient),
+	)
+
+	return &bisectTestRegressionOptions{
+		ciDataClient: ciDataClient,
+		jobName:      f.JobName,
+		testName:     f.TestName,
+		since:        time.Now().Add(-time.Duration(f.DaysBack) * 24 * time.Hour),
+		alpha:        f.Alpha,
+		out:          os.Stdout,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package jobrunaggregatoranalyzer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+)
+
+func payloadCounts(releaseTag string, passes, flakes, failures int) jobrunaggregatorapi.ReleaseTagTestRunCountsRow {
+	return jobrunaggregatorapi.ReleaseTagTestRunCountsRow{ReleaseTag: releaseTag, PassCount: passes, FlakeCount: flakes, FailCount: failures}
+}
+
+func TestFindRegression(t *testing.T) {
+	tests := []struct {
+		name             string
+		payloads         []jobrunaggregatorapi.ReleaseTagTestRunCountsRow
+		expectedLastGood string
+		expectedFirstBad string
+	}{
+		{
+			name: "pass rate drops",
+			payloads: []jobrunaggregatorapi.ReleaseTagTestRunCountsRow{
+				payloadCounts("a", 10, 0, 0),
+				payloadCounts("b", 9, 1, 0),
+				payloadCounts("c", 4, 0, 6),
+				payloadCounts("d", 3, 0, 7),
+			},
+			expectedLastGood: "b",
+			expectedFirstBad: "c",
+		},
+		{
+			name: "payloads without runs are skipped",
+			payloads: []jobrunaggregatorapi.ReleaseTagTestRunCountsRow{
+				payloadCounts("a", 10, 0, 0),
+				payloadCounts("b", 10, 0, 0),
+				payloadCounts("c", 0, 0, 0),
+				payloadCounts("d", 2, 0, 8),
+				payloadCounts("e", 3, 0, 7),
+			},
+			expectedLastGood: "b",
+			expectedFirstBad: "d",
+		},
+		{
+			name: "pass rate is stable",
+			payloads: []jobrunaggregatorapi.ReleaseTagTestRunCountsRow{
+				payloadCounts("a", 9, 0, 1),
+				payloadCounts("b", 8, 1, 1),
+				payloadCounts("c", 9, 0, 1),
+				payloadCounts("d", 8, 0, 2),
+			},
+		},
+		{
+			name: "pass rate improves",
+			payloads: []jobrunaggregatorapi.ReleaseTagTestRunCountsRow{
+				payloadCounts("a", 2, 0, 8),
+				payloadCounts("b", 3, 0, 7),
+				payloadCounts("c", 10, 0, 0),
+				payloadCounts("d", 10, 0, 0),
+			},
+		},
+		{
+			name:     "a single payload",
+			payloads: []jobrunaggregatorapi.ReleaseTagTestRunCountsRow{payloadCounts("a", 0, 0, 10)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regression := findRegression(tt.payloads, defaultAlpha)
+			if len(tt.expectedFirstBad) == 0 {
+				assert.Nil(t, regression)
+				return
+			}
+			if assert.NotNil(t, regression) {
+				assert.Equal(t, tt.expectedLastGood, regression.lastGood.ReleaseTag)
+				assert.Equal(t, tt.expectedFirstBad, regression.firstBad.ReleaseTag)
+				assert.Less(t, regression.pValue, defaultAlpha)
+			}
+		})
+	}
+}
+
+func TestBisectTestRegression(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockCtrl := gomock.NewController(t)
+	mockDataClient := jobrunaggregatorlib.NewMockCIDataClient(mockCtrl)
+	inStream := func(row jobrunaggregatorapi.ReleaseTagTestRunCountsRow, stream string) jobrunaggregatorapi.ReleaseTagTestRunCountsRow {
+		row.Release, row.Stream, row.Architecture = "4.16", stream, "amd64"
+		return row
+	}
+	// the job moved from ci to nightly payloads, the failures on ci payloads are not part of the regression
+	mockDataClient.EXPECT().ListTestRunCountsByReleaseTag(gomock.Any(), "test-job", "test-case", since).Return([]jobrunaggregatorapi.ReleaseTagTestRunCountsRow{
+		inStream(payloadCounts("4.16.0-0.ci-2024-02-28-000000", 10, 0, 0), "ci"),
+		inStream(payloadCounts("4.16.0-0.ci-2024-02-29-000000", 0, 0, 10), "ci"),
+		inStream(payloadCounts("4.16.0-0.nightly-2024-03-01-000000", 10, 0, 0), "nightly"),
+		inStream(payloadCounts("4.16.0-0.nightly-2024-03-02-000000", 10, 0, 0), "nightly"),
+		inStream(payloadCounts("4.16.0-0.nightly-2024-03-04-000000", 2, 0, 8), "nightly"),
+	}, nil)
+	mockDataClient.EXPECT().ListReleaseTagsBetween(gomock.Any(), "4.16.0-0.nightly-2024-03-02-000000", "4.16.0-0.nightly-2024-03-04-000000").Return([]jobrunaggregatorapi.ReleaseTagRow{
+		{ReleaseTag: "4.16.0-0.nightly-2024-03-03-000000"},
+		{ReleaseTag: "4.16.0-0.nightly-2024-03-04-000000", PreviousOSVersion: "416.94.1", CurrentOSVersion: "416.94.2", OSDiffURL: "https://example.com/diff"},
+	}, nil)
+	mockDataClient.EXPECT().ListReleasePullRequests(gomock.Any(), []string{"4.16.0-0.nightly-2024-03-03-000000", "4.16.0-0.nightly-2024-03-04-000000"}).Return([]jobrunaggregatorapi.ReleasePullRequestRow{
+		{Name: "cluster-network-operator", Description: "Bump OVN", URL: "https://github.com/openshift/cluster-network-operator/pull/1"},
+		{Name: "cluster-network-operator", Description: "Bump OVN", URL: "https://github.com/openshift/cluster-network-operator/pull/1"},
+		{Name: "machine-config-operator", Description: "Fix kubelet config", URL: "https://github.com/openshift/machine-config-operator/pull/2"},
+	}, nil)
+
+	out := &bytes.Buffer{}
+	o := &bisectTestRegressionOptions{
+		ciDataClient: mockDataClient,
+		jobName:      "test-job",
+		testName:     "test-case",
+		since:        since,
+		alpha:        defaultAlpha,
+		out:          out,
+	}
+	assert.NoError(t, o.Run(context.TODO()))
+	assert.Equal(t, `The pass rate of "test-case" in test-job dropped from 100.0% (20/20) to 20.0% (2/10), p=1.538e-05.
+Last good payload: 4.16.0-0.nightly-2024-03-02-000000
+First bad payload: 4.16.0-0.nightly-2024-03-04-000000
+
+Changes in 2 payloads:
+  4.16.0-0.nightly-2024-03-04-000000: machine OS 416.94.1 -> 416.94.2 https://example.com/diff
+  cluster-network-operator: Bump OVN https://github.com/openshift/cluster-network-operator/pull/1
+  machine-config-operator: Fix kubelet config https://github.com/openshift/machine-config-operator/pull/2
+`, out.String())
+}
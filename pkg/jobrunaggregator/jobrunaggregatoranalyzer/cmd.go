@@ -28,6 +28,9 @@ type JobRunsAnalyzerFlags struct {
 	Timeout                     time.Duration
 	EstimatedJobStartTimeString string
 	JobStateQuerySource         string
+	BaselineSource              string
+	BaselineLocation            string
+	SignificanceConfigPath      string
 
 	StaticJobRunIdentifierPath string
 	StaticJobRunIdentifierJSON string
@@ -59,6 +62,9 @@ func (f *JobRunsAnalyzerFlags) BindFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&f.Timeout, "timeout", f.Timeout, "Time to wait for aggregation to complete.")
 	fs.StringVar(&f.EstimatedJobStartTimeString, "job-start-time", f.EstimatedJobStartTimeString, fmt.Sprintf("Start time in RFC822Z: %s", kubeTimeSerializationLayout))
 	fs.StringVar(&f.JobStateQuerySource, "query-source", jobrunaggregatorlib.JobStateQuerySourceBigQuery, "The source from which job states are found. It is either bigquery or cluster")
+	fs.StringVar(&f.BaselineSource, "baseline-source", jobrunaggregatorlib.BaselineSourceBigQuery, "The source of the historical pass rates and disruption the job runs are judged against. It is one of bigquery, snapshot, http or static")
+	fs.StringVar(&f.BaselineLocation, "baseline-location", f.BaselineLocation, "The snapshot directory, the URL of the API or the path to the static baseline YAML, depending on --baseline-source")
+	fs.StringVar(&f.SignificanceConfigPath, "significance-config", f.SignificanceConfigPath, "The optional path to a YAML file selecting, per test, whether tests fail with the heuristic or when their results are significantly worse than the baseline")
 
 	// optional for local use or potentially gangway results
 	fs.StringVar(&f.StaticJobRunIdentifierPath, "static-run-info-path", f.StaticJobRunIdentifierPath, "The optional path to a file containing JSON formatted JobRunIdentifier array used for aggregated analysis")
@@ -132,6 +138,9 @@ func (f *JobRunsAnalyzerFlags) Validate() error {
 			return fmt.Errorf("unknown query-source %s, valid values are: %+q", f.JobStateQuerySource, sets.List(jobrunaggregatorlib.KnownQuerySources))
 		}
 	}
+	if err := jobrunaggregatorlib.ValidateBaselineSource(f.BaselineSource, f.BaselineLocation); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -144,25 +153,39 @@ func (f *JobRunsAnalyzerFlags) ToOptions(ctx context.Context) (*JobRunAggregator
 		return nil, err
 	}
 
-	bigQueryClient, err := f.Authentication.NewBigQueryClient(ctx, f.DataCoordinates.ProjectID)
+	var staticJobRunIdentifiers []jobrunaggregatorlib.JobRunIdentifier
+	if len(f.StaticJobRunIdentifierJSON) > 0 || len(f.StaticJobRunIdentifierPath) > 0 {
+		staticJobRunIdentifiers, err = jobrunaggregatorlib.GetStaticJobRunInfo(f.StaticJobRunIdentifierJSON, f.StaticJobRunIdentifierPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// BigQuery is only needed to locate the job runs when they are not passed in, or to read the baselines from it
+	var ciDataClient jobrunaggregatorlib.CIDataClient
+	if len(staticJobRunIdentifiers) == 0 || f.BaselineSource == jobrunaggregatorlib.BaselineSourceBigQuery {
+		bigQueryClient, err := f.Authentication.NewBigQueryClient(ctx, f.DataCoordinates.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		ciDataClient = jobrunaggregatorlib.NewRetryingCIDataClient(
+			jobrunaggregatorlib.NewCIDataClient(*f.DataCoordinates, bigQueryClient),
+		)
+	}
+
+	baselineDataSource, err := jobrunaggregatorlib.NewBaselineDataSource(f.BaselineSource, f.BaselineLocation, ciDataClient)
 	if err != nil {
 		return nil, err
 	}
-	ciDataClient := jobrunaggregatorlib.NewRetryingCIDataClient(
-		jobrunaggregatorlib.NewCIDataClient(*f.DataCoordinates, bigQueryClient),
-	)
 
-	ciGCSClient, err := f.Authentication.NewCIGCSClient(ctx, f.GCSBucket)
+	significanceConfig, err := loadSignificanceConfig(f.SignificanceConfigPath)
 	if err != nil {
 		return nil, err
 	}
 
-	var staticJobRunIdentifiers []jobrunaggregatorlib.JobRunIdentifier
-	if len(f.StaticJobRunIdentifierJSON) > 0 || len(f.StaticJobRunIdentifierPath) > 0 {
-		staticJobRunIdentifiers, err = jobrunaggregatorlib.GetStaticJobRunInfo(f.StaticJobRunIdentifierJSON, f.StaticJobRunIdentifierPath)
-		if err != nil {
-			return nil, err
-		}
+	ciGCSClient, err := f.Authentication.NewCIGCSClient(ctx, f.GCSBucket)
+	if err != nil {
+		return nil, err
 	}
 
 	var jobRunLocator jobrunaggregatorlib.JobRunLocator
@@ -203,11 +226,13 @@ func (f *JobRunsAnalyzerFlags) ToOptions(ctx context.Context) (*JobRunAggregator
 	return &JobRunAggregatorAnalyzerOptions{
 		explicitGCSPrefix:       f.ExplicitGCSPrefix,
 		jobRunLocator:           jobRunLocator,
-		passFailCalculator:      newWeeklyAverageFromTenDaysAgo(f.JobName, estimatedStartTime, 6, ciDataClient),
+		passFailCalculator:      newWeeklyAverageFromTenDaysAgo(f.JobName, estimatedStartTime, 6, baselineDataSource),
+		significanceConfig:      significanceConfig,
 		jobName:                 f.JobName,
 		payloadTag:              f.PayloadTag,
 		workingDir:              f.WorkingDir,
 		ciDataClient:            ciDataClient,
+		baselineDataSource:      baselineDataSource,
 		jobRunStartEstimate:     estimatedStartTime,
 		clock:                   clock.RealClock{},
 		timeout:                 f.Timeout,
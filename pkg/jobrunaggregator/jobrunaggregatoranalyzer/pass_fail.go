@@ -34,11 +34,15 @@ type baseline interface {
 	CheckDisruptionMeanWithinOneStandardDeviation(ctx context.Context, jobRunIDToAvailabilityResultForBackend map[string]jobrunaggregatorlib.AvailabilityResult, backend, masterNodesUpdated string) (failedJobRunsIDs []string, successfulJobRunIDs []string, status testCaseStatus, message string, err error)
 	CheckPercentileDisruption(ctx context.Context, jobRunIDToAvailabilityResultForBackend map[string]jobrunaggregatorlib.AvailabilityResult,
 		backend string, percentile int, fixedGraceSeconds int, masterNodesUpdated string) (failureJobRunIDs []string, successJobRunIDs []string, status testCaseStatus, message string, err error)
+	// PassRateSignificance tests the pass rate of the test against the baseline, it returns nil when there is nothing to compare
+	PassRateSignificance(ctx context.Context, jobName string, testCaseDetails *jobrunaggregatorlib.TestCaseDetails) (*jobrunaggregatorlib.Significance, error)
+	// DisruptionSignificance tests the disruption of the backend against the baseline, it returns nil when there is nothing to compare
+	DisruptionSignificance(ctx context.Context, jobRunIDToAvailabilityResultForBackend map[string]jobrunaggregatorlib.AvailabilityResult, backend, masterNodesUpdated string) (*jobrunaggregatorlib.Significance, error)
 }
 
-func assignPassFail(ctx context.Context, jobName string, combined *junit.TestSuites, baselinePassFail baseline) error {
+func assignPassFail(ctx context.Context, jobName string, combined *junit.TestSuites, baselinePassFail baseline, significanceConfig *SignificanceConfig) error {
 	for _, currTestSuite := range combined.Suites {
-		if err := assignPassFailForTestSuite(ctx, jobName, []string{}, currTestSuite, baselinePassFail); err != nil {
+		if err := assignPassFailForTestSuite(ctx, jobName, []string{}, currTestSuite, baselinePassFail, significanceConfig); err != nil {
 			return err
 		}
 
@@ -47,12 +51,12 @@ func assignPassFail(ctx context.Context, jobName string, combined *junit.TestSui
 	return nil
 }
 
-func assignPassFailForTestSuite(ctx context.Context, jobName string, parentTestSuites []string, combined *junit.TestSuite, baselinePassFail baseline) error {
+func assignPassFailForTestSuite(ctx context.Context, jobName string, parentTestSuites []string, combined *junit.TestSuite, baselinePassFail baseline, significanceConfig *SignificanceConfig) error {
 	failureCount := uint(0)
 
 	currSuiteNames := append(parentTestSuites, combined.Name)
 	for _, currTestSuite := range combined.Children {
-		if err := assignPassFailForTestSuite(ctx, jobName, currSuiteNames, currTestSuite, baselinePassFail); err != nil {
+		if err := assignPassFailForTestSuite(ctx, jobName, currSuiteNames, currTestSuite, baselinePassFail, significanceConfig); err != nil {
 			return err
 		}
 		failureCount += currTestSuite.NumFailed
@@ -78,8 +82,14 @@ func assignPassFailForTestSuite(ctx context.Context, jobName string, parentTestS
 		if err != nil {
 			return err
 		}
+		significance, err := baselinePassFail.PassRateSignificance(ctx, jobName, currDetails)
+		if err != nil {
+			return err
+		}
+		status, message = significanceConfig.apply(currTestCase.Name, status, message, significance)
 
 		currDetails.Summary = message
+		currDetails.Significance = significance
 		detailsBytes, err := yaml.Marshal(currDetails)
 		if err != nil {
 			return err
@@ -155,7 +165,7 @@ type weeklyAverageFromTenDays struct {
 	jobName                 string
 	startDay                time.Time
 	minimumNumberOfAttempts int
-	bigQueryClient          jobrunaggregatorlib.CIDataClient
+	dataSource              jobrunaggregatorlib.BaselineDataSource
 
 	queryTestRunsOnce        sync.Once
 	queryTestRunsErr         error
@@ -172,14 +182,17 @@ type TestKey struct {
 	CombinedTestSuiteName string
 }
 
-func newWeeklyAverageFromTenDaysAgo(jobName string, startDay time.Time, minimumNumberOfAttempts int, bigQueryClient jobrunaggregatorlib.CIDataClient) baseline {
-	tenDayAgo := jobrunaggregatorlib.GetUTCDay(startDay).Add(-10 * 24 * time.Hour)
+// tenDaysBefore is the start of the week whose average pass rate is used for a job started at startDay
+func tenDaysBefore(startDay time.Time) time.Time {
+	return jobrunaggregatorlib.GetUTCDay(startDay).Add(-10 * 24 * time.Hour)
+}
 
+func newWeeklyAverageFromTenDaysAgo(jobName string, startDay time.Time, minimumNumberOfAttempts int, dataSource jobrunaggregatorlib.BaselineDataSource) baseline {
 	return &weeklyAverageFromTenDays{
 		jobName:                  jobName,
-		startDay:                 tenDayAgo,
+		startDay:                 tenDaysBefore(startDay),
 		minimumNumberOfAttempts:  minimumNumberOfAttempts,
-		bigQueryClient:           bigQueryClient,
+		dataSource:               dataSource,
 		queryTestRunsOnce:        sync.Once{},
 		queryTestRunsErr:         nil,
 		aggregatedTestRunsByName: nil,
@@ -189,7 +202,7 @@ func newWeeklyAverageFromTenDaysAgo(jobName string, startDay time.Time, minimumN
 
 func (a *weeklyAverageFromTenDays) getAggregatedTestRuns(ctx context.Context) (map[TestKey]jobrunaggregatorapi.AggregatedTestRunRow, error) {
 	a.queryTestRunsOnce.Do(func() {
-		rows, err := a.bigQueryClient.ListAggregatedTestRunsForJob(ctx, "ByOneWeek", a.jobName, a.startDay)
+		rows, err := a.dataSource.ListAggregatedTestRunsForJob(ctx, "ByOneWeek", a.jobName, a.startDay)
 		a.aggregatedTestRunsByName = map[TestKey]jobrunaggregatorapi.AggregatedTestRunRow{}
 		if err != nil {
 			a.queryTestRunsErr = err
@@ -218,7 +231,7 @@ func normalizeJobName(jobName, fromRelease, toRelease string) string {
 }
 
 func (a *weeklyAverageFromTenDays) getNormalizedFallBackJobName(ctx context.Context, jobName string) (string, error) {
-	allJobs, err := a.bigQueryClient.ListAllJobsWithVariants(ctx)
+	allJobs, err := a.dataSource.ListAllJobsWithVariants(ctx)
 	if err != nil {
 		return jobName, err
 	}
@@ -276,7 +289,7 @@ func (a *weeklyAverageFromTenDays) getNormalizedFallBackJobName(ctx context.Cont
 func (a *weeklyAverageFromTenDays) getDisruptionByBackend(ctx context.Context, masterNodesUpdated string) (map[string]backendDisruptionStats, string, error) {
 	a.queryDisruptionOnce.Do(func() {
 		jobName := a.jobName
-		count, err := a.bigQueryClient.GetBackendDisruptionRowCountByJob(ctx, jobName, masterNodesUpdated)
+		count, err := a.dataSource.GetBackendDisruptionRowCountByJob(ctx, jobName, masterNodesUpdated)
 		if err != nil {
 			a.queryDisruptionErr = err
 			return
@@ -289,7 +302,7 @@ func (a *weeklyAverageFromTenDays) getDisruptionByBackend(ctx context.Context, m
 			}
 			a.fallBackJobName = jobName
 		}
-		rows, err := a.bigQueryClient.GetBackendDisruptionStatisticsByJob(ctx, jobName, masterNodesUpdated)
+		rows, err := a.dataSource.GetBackendDisruptionStatisticsByJob(ctx, jobName, masterNodesUpdated)
 		if err != nil {
 			a.queryDisruptionErr = err
 			return
@@ -1,8 +1,10 @@
 package jobrunaggregatoranalyzer
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -452,7 +454,7 @@ func TestCheckFailedWithPityFactor(t *testing.T) {
 			baseline := &weeklyAverageFromTenDays{
 				aggregatedTestRunsByName: aggregatedTestRuns,
 			}
-			// Mark query as already done to avoid nil bigQueryClient access
+			// Mark query as already done to avoid nil dataSource access
 			baseline.queryTestRunsOnce.Do(func() {})
 
 			testCaseDetails := &jobrunaggregatorlib.TestCaseDetails{
@@ -570,3 +572,56 @@ func TestInnerCheckPercentileDisruptionWithPityFactor(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckFailedWithStaticBaseline(t *testing.T) {
+	dataSource, err := jobrunaggregatorlib.NewStaticBaselineDataSource(jobrunaggregatorlib.StaticBaseline{
+		Jobs: []jobrunaggregatorlib.StaticJobBaseline{{
+			JobName: "test-job",
+			Tests:   []jobrunaggregatorlib.StaticTestPassRate{{TestSuiteName: "test-suite", TestName: "test-case", PassPercentage: 100}},
+		}},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		testName        string
+		passes          int
+		expectedStatus  testCaseStatus
+		expectedMessage string
+	}{
+		{
+			name:            "static pass rate is used",
+			testName:        "test-case",
+			passes:          7,
+			expectedStatus:  testCaseFailed,
+			expectedMessage: "Failed: Passed 7 times, failed 3 times.  The historical pass rate is 100%.  The required number of passes is 8.",
+		},
+		{
+			name:            "tests without a static pass rate are assigned the default",
+			testName:        "other-test-case",
+			passes:          7,
+			expectedStatus:  testCasePassed,
+			expectedMessage: "Passed: Passed 7 times, failed 3 times.  The historical pass rate is 70%.  The required number of passes is 3.",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			baseline := newWeeklyAverageFromTenDaysAgo("test-job", time.Now(), 6, dataSource)
+			testCaseDetails := &jobrunaggregatorlib.TestCaseDetails{
+				Name:          test.testName,
+				TestSuiteName: "test-suite",
+			}
+			for i := 0; i < test.passes; i++ {
+				testCaseDetails.Passes = append(testCaseDetails.Passes, jobrunaggregatorlib.TestCasePass{JobRunID: fmt.Sprintf("pass-%d", i)})
+			}
+			for i := test.passes; i < 10; i++ {
+				testCaseDetails.Failures = append(testCaseDetails.Failures, jobrunaggregatorlib.TestCaseFailure{JobRunID: fmt.Sprintf("fail-%d", i)})
+			}
+
+			status, message, err := baseline.CheckFailed(context.Background(), "test-job", []string{"suite"}, testCaseDetails)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedStatus, status)
+			assert.Equal(t, test.expectedMessage, message)
+		})
+	}
+}
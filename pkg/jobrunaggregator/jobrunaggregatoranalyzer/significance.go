@@ -0,0 +1,183 @@
+package jobrunaggregatoranalyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+)
+
+const (
+	// criterionHeuristic decides with the required pass tables and disruption thresholds, the significance
+	// of the results is only reported
+	criterionHeuristic = "heuristic"
+	// criterionSignificance fails tests whose results are significantly worse than the baseline
+	criterionSignificance = "significance"
+
+	defaultAlpha = 0.05
+)
+
+// SignificanceConfig selects the criterion deciding whether aggregated tests fail
+type SignificanceConfig struct {
+	// Tests are matched in order and the first match applies. Tests that match none use the heuristic.
+	Tests []TestCriterion `json:"tests"`
+}
+
+// TestCriterion is the failure criterion of the tests matching a pattern
+type TestCriterion struct {
+	// Pattern is a regular expression matched against the name of the test
+	Pattern string `json:"pattern"`
+	// Criterion is either heuristic or significance. Pass rates are compared with Fisher's exact test, or
+	// with the binomial test when the baseline only knows the pass rate, and disruption with the Mann-Whitney
+	// U test.
+	Criterion string `json:"criterion"`
+	// Alpha is the significance level below which the significance criterion fails a test, 0.05 by default
+	Alpha float64 `json:"alpha,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// loadSignificanceConfig loads the configuration at path, no path means the heuristic is used for all tests
+func loadSignificanceConfig(path string) (*SignificanceConfig, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read significance configuration: %w", err)
+	}
+	config := &SignificanceConfig{}
+	if err := yaml.UnmarshalStrict(raw, config); err != nil {
+		return nil, fmt.Errorf("failed to load significance configuration %s: %w", path, err)
+	}
+	if err := config.complete(); err != nil {
+		return nil, fmt.Errorf("invalid significance configuration %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// complete validates the configuration and compiles its patterns
+func (c *SignificanceConfig) complete() error {
+	for i := range c.Tests {
+		test := &c.Tests[i]
+		re, err := regexp.Compile(test.Pattern)
+		if err != nil {
+			return fmt.Errorf("tests[%d]: invalid pattern: %w", i, err)
+		}
+		test.re = re
+		switch test.Criterion {
+		case criterionHeuristic, criterionSignificance:
+		default:
+			return fmt.Errorf("tests[%d]: criterion must be %s or %s, not %q", i, criterionHeuristic, criterionSignificance, test.Criterion)
+		}
+		if test.Alpha == 0 {
+			test.Alpha = defaultAlpha
+		}
+		if test.Alpha < 0 || test.Alpha >= 1 {
+			return fmt.Errorf("tests[%d]: alpha must be between 0 and 1, not %v", i, test.Alpha)
+		}
+	}
+	return nil
+}
+
+func (c *SignificanceConfig) criterionFor(testName string) (string, float64) {
+	if c != nil {
+		for _, test := range c.Tests {
+			if test.re.MatchString(testName) {
+				return test.Criterion, test.Alpha
+			}
+		}
+	}
+	return criterionHeuristic, defaultAlpha
+}
+
+// apply decides the status of the test with its criterion. The significance is reported in the message
+// either way, but only decides the status of tests using the significance criterion. The message of the
+// heuristic is kept for those, as the counts in it are still informative.
+func (c *SignificanceConfig) apply(testName string, status testCaseStatus, message string, significance *jobrunaggregatorlib.Significance) (testCaseStatus, string) {
+	if significance == nil || status == testCaseSkipped {
+		return status, message
+	}
+	criterion, alpha := c.criterionFor(testName)
+	if criterion == criterionHeuristic {
+		return status, fmt.Sprintf("%s\n%s", message, significance)
+	}
+	if significance.PValue < alpha {
+		return testCaseFailed, fmt.Sprintf("Failed: %s, which is significant at alpha=%g\nHeuristic: %s", significance, alpha, message)
+	}
+	return testCasePassed, fmt.Sprintf("Passed: %s, which is not significant at alpha=%g\nHeuristic: %s", significance, alpha, message)
+}
+
+// PassRateSignificance tests whether the pass rate of the test is significantly lower than its historical pass rate
+func (a *weeklyAverageFromTenDays) PassRateSignificance(ctx context.Context, jobName string, testCaseDetails *jobrunaggregatorlib.TestCaseDetails) (*jobrunaggregatorlib.Significance, error) {
+	if len(testShouldAlwaysPass(jobName, testCaseDetails.Name, testCaseDetails.TestSuiteName)) > 0 ||
+		len(testShouldNeverFail(testCaseDetails.Name)) > 0 ||
+		!didTestRun(testCaseDetails) {
+		return nil, nil
+	}
+	aggregatedTestRunsByName, err := a.getAggregatedTestRuns(ctx)
+	if err != nil {
+		// CheckFailed reports the missing historical data
+		return nil, nil
+	}
+	historical, ok := aggregatedTestRunsByName[TestKey{TestCaseName: testCaseDetails.Name, CombinedTestSuiteName: testCaseDetails.TestSuiteName}]
+	if !ok {
+		return nil, nil
+	}
+
+	attempts := getAttempts(testCaseDetails)
+	passes := getNumberOfPasses(testCaseDetails)
+	lower, upper := wilsonInterval(passes, attempts)
+	significance := &jobrunaggregatorlib.Significance{
+		Quantity:        "pass rate",
+		Unit:            "%",
+		Estimate:        100 * float64(passes) / float64(attempts),
+		Lower:           100 * lower,
+		Upper:           100 * upper,
+		ConfidenceLevel: confidenceLevel,
+	}
+	// flakes passed on retry, so they count as passes like they do for the aggregated job runs
+	historicalPasses := historical.PassCount + historical.FlakeCount
+	if historicalPasses+historical.FailCount > 0 {
+		significance.Test = fisherExactTest
+		significance.PValue = fisherExactLess(passes, attempts-passes, historicalPasses, historical.FailCount)
+	} else {
+		significance.Test = binomialTest
+		significance.PValue = binomialLess(passes, attempts, historical.WorkingPercentage/100)
+	}
+	return significance, nil
+}
+
+// DisruptionSignificance tests whether the disruption of the backend is significantly greater than its historical
+// disruption. Only the percentiles of the historical disruption are known, so they stand in for its sample.
+func (a *weeklyAverageFromTenDays) DisruptionSignificance(ctx context.Context, jobRunIDToAvailabilityResultForBackend map[string]jobrunaggregatorlib.AvailabilityResult, backend, masterNodesUpdated string) (*jobrunaggregatorlib.Significance, error) {
+	historicalDisruption, _, err := a.getDisruptionByBackend(ctx, masterNodesUpdated)
+	if err != nil {
+		// the disruption checks report the missing historical data
+		return nil, nil
+	}
+	historicalDisruptionStatistic, ok := historicalDisruption[backend]
+	if !ok || len(jobRunIDToAvailabilityResultForBackend) == 0 {
+		return nil, nil
+	}
+	historical := historicalDisruptionStatistic.percentileByIndex[1:]
+	var current []float64
+	for _, result := range jobRunIDToAvailabilityResultForBackend {
+		current = append(current, float64(result.SecondsUnavailable))
+	}
+	shift, lower, upper := hodgesLehmannShift(current, historical)
+	return &jobrunaggregatorlib.Significance{
+		Test:            mannWhitneyTest,
+		PValue:          mannWhitneyGreater(current, historical),
+		Quantity:        "disruption shift",
+		Unit:            "s",
+		Estimate:        shift,
+		Lower:           lower,
+		Upper:           upper,
+		ConfidenceLevel: confidenceLevel,
+	}, nil
+}
@@ -0,0 +1,228 @@
+package jobrunaggregatoranalyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+)
+
+func TestLoadSignificanceConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "valid",
+			content: `tests:
+- pattern: "\\[sig-network\\]"
+  criterion: significance
+  alpha: 0.01
+- pattern: ".*"
+  criterion: heuristic
+`,
+		},
+		{
+			name: "invalid criterion",
+			content: `tests:
+- pattern: ".*"
+  criterion: vibes
+`,
+			expectedError: `criterion must be heuristic or significance, not "vibes"`,
+		},
+		{
+			name: "invalid pattern",
+			content: `tests:
+- pattern: "("
+  criterion: significance
+`,
+			expectedError: "invalid pattern",
+		},
+		{
+			name: "invalid alpha",
+			content: `tests:
+- pattern: ".*"
+  criterion: significance
+  alpha: 1.5
+`,
+			expectedError: "alpha must be between 0 and 1, not 1.5",
+		},
+		{
+			name: "unknown field",
+			content: `tests:
+- pattern: ".*"
+  criteria: significance
+`,
+			expectedError: "unknown field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "significance.yaml")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			config, err := loadSignificanceConfig(path)
+			if len(tt.expectedError) > 0 {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			criterion, alpha := config.criterionFor("[sig-network] pods should connect")
+			assert.Equal(t, criterionSignificance, criterion)
+			assert.Equal(t, 0.01, alpha)
+			criterion, alpha = config.criterionFor("[sig-storage] volumes should mount")
+			assert.Equal(t, criterionHeuristic, criterion)
+			assert.Equal(t, defaultAlpha, alpha)
+		})
+	}
+
+	config, err := loadSignificanceConfig("")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+}
+
+func TestSignificanceConfigApply(t *testing.T) {
+	config := &SignificanceConfig{Tests: []TestCriterion{{Pattern: "significant", Criterion: criterionSignificance}}}
+	assert.NoError(t, config.complete())
+	significance := &jobrunaggregatorlib.Significance{
+		Test:            binomialTest,
+		PValue:          0.0115,
+		Quantity:        "pass rate",
+		Unit:            "%",
+		Estimate:        70,
+		Lower:           39.7,
+		Upper:           89.2,
+		ConfidenceLevel: confidenceLevel,
+	}
+
+	tests := []struct {
+		name            string
+		config          *SignificanceConfig
+		testName        string
+		status          testCaseStatus
+		significance    *jobrunaggregatorlib.Significance
+		expectedStatus  testCaseStatus
+		expectedMessage string
+	}{
+		{
+			name:            "no significance",
+			config:          config,
+			testName:        "significant",
+			status:          testCasePassed,
+			expectedStatus:  testCasePassed,
+			expectedMessage: "heuristic message",
+		},
+		{
+			name:            "no configuration reports the significance",
+			testName:        "significant",
+			status:          testCasePassed,
+			significance:    significance,
+			expectedStatus:  testCasePassed,
+			expectedMessage: "heuristic message\nbinomial test p=0.0115, pass rate 70.0% with 95% confidence interval [39.7%, 89.2%]",
+		},
+		{
+			name:            "significance fails the test",
+			config:          config,
+			testName:        "significant",
+			status:          testCasePassed,
+			significance:    significance,
+			expectedStatus:  testCaseFailed,
+			expectedMessage: "Failed: binomial test p=0.0115, pass rate 70.0% with 95% confidence interval [39.7%, 89.2%], which is significant at alpha=0.05\nHeuristic: heuristic message",
+		},
+		{
+			name:   "no significance passes the test",
+			config: config,
+			// the pattern is not anchored
+			testName:        "not significant",
+			status:          testCaseFailed,
+			significance:    &jobrunaggregatorlib.Significance{Test: fisherExactTest, PValue: 0.2, Quantity: "pass rate", Unit: "%", Estimate: 90, Lower: 59.6, Upper: 98.2, ConfidenceLevel: confidenceLevel},
+			expectedStatus:  testCasePassed,
+			expectedMessage: "Passed: fisher-exact test p=0.2, pass rate 90.0% with 95% confidence interval [59.6%, 98.2%], which is not significant at alpha=0.05\nHeuristic: heuristic message",
+		},
+		{
+			name:            "skipped tests stay skipped",
+			config:          config,
+			testName:        "significant",
+			status:          testCaseSkipped,
+			significance:    significance,
+			expectedStatus:  testCaseSkipped,
+			expectedMessage: "heuristic message",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := tt.config.apply(tt.testName, tt.status, "heuristic message", tt.significance)
+			assert.Equal(t, tt.expectedStatus, status)
+			assert.Equal(t, tt.expectedMessage, message)
+		})
+	}
+}
+
+func TestPassRateSignificance(t *testing.T) {
+	dataSource, err := jobrunaggregatorlib.NewStaticBaselineDataSource(jobrunaggregatorlib.StaticBaseline{
+		Jobs: []jobrunaggregatorlib.StaticJobBaseline{{
+			JobName: "test-job",
+			Tests:   []jobrunaggregatorlib.StaticTestPassRate{{TestSuiteName: "test-suite", TestName: "test-case", PassPercentage: 95}},
+		}},
+	})
+	assert.NoError(t, err)
+	baseline := newWeeklyAverageFromTenDaysAgo("test-job", time.Now(), 6, dataSource)
+
+	testCaseDetails := func(name string, passes int) *jobrunaggregatorlib.TestCaseDetails {
+		details := &jobrunaggregatorlib.TestCaseDetails{Name: name, TestSuiteName: "test-suite"}
+		for i := 0; i < passes; i++ {
+			details.Passes = append(details.Passes, jobrunaggregatorlib.TestCasePass{JobRunID: fmt.Sprintf("pass-%d", i)})
+		}
+		for i := passes; i < 10; i++ {
+			details.Failures = append(details.Failures, jobrunaggregatorlib.TestCaseFailure{JobRunID: fmt.Sprintf("fail-%d", i)})
+		}
+		return details
+	}
+
+	// the static baseline only knows the pass rate, so the binomial test is used
+	significance, err := baseline.PassRateSignificance(context.Background(), "test-job", testCaseDetails("test-case", 7))
+	assert.NoError(t, err)
+	if assert.NotNil(t, significance) {
+		assert.Equal(t, binomialTest, significance.Test)
+		assert.InDelta(t, 0.011503, significance.PValue, 1e-6)
+		assert.Equal(t, 70.0, significance.Estimate)
+		assert.InDelta(t, 39.68, significance.Lower, 0.01)
+		assert.InDelta(t, 89.22, significance.Upper, 0.01)
+	}
+
+	significance, err = baseline.PassRateSignificance(context.Background(), "test-job", testCaseDetails("other-test-case", 7))
+	assert.NoError(t, err)
+	assert.Nil(t, significance, "tests without a baseline have no significance")
+}
+
+func TestDisruptionSignificance(t *testing.T) {
+	dataSource, err := jobrunaggregatorlib.NewStaticBaselineDataSource(jobrunaggregatorlib.StaticBaseline{
+		Jobs: []jobrunaggregatorlib.StaticJobBaseline{{
+			JobName: "test-job",
+			Disruption: []jobrunaggregatorlib.StaticDisruptionThreshold{{
+				BackendName: "kube-api-new-connections",
+				Percentiles: map[int]float64{50: 1, 75: 2, 95: 3, 99: 4},
+			}},
+		}},
+	})
+	assert.NoError(t, err)
+	baseline := newWeeklyAverageFromTenDaysAgo("test-job", time.Now(), 6, dataSource)
+
+	significance, err := baseline.DisruptionSignificance(context.Background(), createJobRunIDToAvailabilityResultForBackend([]int{10, 12, 15, 20, 30}), "kube-api-new-connections", "")
+	assert.NoError(t, err)
+	if assert.NotNil(t, significance) {
+		assert.Equal(t, mannWhitneyTest, significance.Test)
+		assert.Less(t, significance.PValue, 0.001)
+		assert.Greater(t, significance.Estimate, 0.0)
+	}
+
+	significance, err = baseline.DisruptionSignificance(context.Background(), createJobRunIDToAvailabilityResultForBackend([]int{10}), "unknown-backend", "")
+	assert.NoError(t, err)
+	assert.Nil(t, significance)
+}
@@ -0,0 +1,104 @@
+package jobrunaggregatoranalyzer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+)
+
+type snapshotBaselinesFlags struct {
+	DataCoordinates *jobrunaggregatorlib.BigQueryDataCoordinates
+	Authentication  *jobrunaggregatorlib.GoogleAuthenticationFlags
+
+	JobNames                    []string
+	OutputDir                   string
+	EstimatedJobStartTimeString string
+	HistoricalData              bool
+}
+
+func newSnapshotBaselinesFlags() *snapshotBaselinesFlags {
+	return &snapshotBaselinesFlags{
+		DataCoordinates: jobrunaggregatorlib.NewBigQueryDataCoordinates(),
+		Authentication:  jobrunaggregatorlib.NewGoogleAuthenticationFlags(),
+
+		EstimatedJobStartTimeString: time.Now().Format(kubeTimeSerializationLayout),
+	}
+}
+
+func (f *snapshotBaselinesFlags) BindFlags(fs *pflag.FlagSet) {
+	f.DataCoordinates.BindFlags(fs)
+	f.Authentication.BindFlags(fs)
+
+	fs.StringSliceVar(&f.JobNames, "job", f.JobNames, "The name of a job to snapshot the baselines of, like periodic-ci-openshift-release-master-ci-4.9-e2e-gcp-upgrade. May be repeated.")
+	fs.StringVar(&f.OutputDir, "output-dir", f.OutputDir, "The directory to write the snapshot to, for analyze-job-runs --baseline-source=snapshot --baseline-location")
+	fs.BoolVar(&f.HistoricalData, "historical-data", f.HistoricalData, "Also snapshot the disruption and alert historical data, for analyze-historical-data --baseline-source=snapshot --baseline-location")
+	fs.StringVar(&f.EstimatedJobStartTimeString, "job-start-time", f.EstimatedJobStartTimeString, fmt.Sprintf("Start time of the job runs the snapshot will be used to analyze in RFC822Z: %s", kubeTimeSerializationLayout))
+}
+
+func NewSnapshotBaselinesCommand() *cobra.Command {
+	f := newSnapshotBaselinesFlags()
+
+	cmd := &cobra.Command{
+		Use:          "snapshot-baselines",
+		Long:         `Write the pass rates and disruption statistics that job runs are judged against, and optionally the historical data, to Parquet files, to analyze without BigQuery.`,
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			if err := f.Validate(); err != nil {
+				logrus.WithError(err).Fatal("Flags are invalid")
+			}
+			estimatedStartTime, err := time.Parse(kubeTimeSerializationLayout, f.EstimatedJobStartTimeString)
+			if err != nil {
+				logrus.WithError(err).Fatal("Flags are invalid")
+			}
+			bigQueryClient, err := f.Authentication.NewBigQueryClient(ctx, f.DataCoordinates.ProjectID)
+			if err != nil {
+				logrus.WithError(err).Fatal("Failed to build runtime options")
+			}
+			ciDataClient := jobrunaggregatorlib.NewRetryingCIDataClient(
+				jobrunaggregatorlib.NewCIDataClient(*f.DataCoordinates, bigQueryClient),
+			)
+
+			if len(f.JobNames) > 0 {
+				if err := jobrunaggregatorlib.WriteBaselineSnapshot(ctx, ciDataClient, f.OutputDir, f.JobNames, tenDaysBefore(estimatedStartTime)); err != nil {
+					logrus.WithError(err).Fatal("Command failed")
+				}
+			}
+			if f.HistoricalData {
+				if err := jobrunaggregatorlib.WriteHistoricalDataSnapshot(ctx, ciDataClient, f.OutputDir); err != nil {
+					logrus.WithError(err).Fatal("Command failed")
+				}
+			}
+
+			return nil
+		},
+
+		Args: jobrunaggregatorlib.NoArgs,
+	}
+
+	f.BindFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Validate checks to see if the user-input is likely to produce functional runtime options
+func (f *snapshotBaselinesFlags) Validate() error {
+	if len(f.JobNames) == 0 && !f.HistoricalData {
+		return fmt.Errorf("missing --job: like periodic-ci-openshift-release-master-ci-4.9-e2e-gcp-upgrade, or --historical-data")
+	}
+	if len(f.OutputDir) == 0 {
+		return fmt.Errorf("missing --output-dir")
+	}
+	if err := f.DataCoordinates.Validate(); err != nil {
+		return err
+	}
+	return f.Authentication.Validate()
+}
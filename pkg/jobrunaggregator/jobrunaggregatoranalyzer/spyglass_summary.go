@@ -226,6 +226,7 @@ func infoForTestCase(jobName string, parents []string, testCase *junit.TestCase,
 	}
 
 	testInfo.Summary = currDetails.Summary
+	testInfo.Significance = currDetails.Significance
 
 	// a job can have failed runs and still not be failed because it has flaked.
 	failedJobRuns := getFailedJobNames(currDetails)
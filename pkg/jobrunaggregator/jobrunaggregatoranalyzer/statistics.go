@@ -0,0 +1,172 @@
+package jobrunaggregatoranalyzer
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	fisherExactTest = "fisher-exact"
+	binomialTest    = "binomial"
+	mannWhitneyTest = "mann-whitney"
+
+	// confidenceLevel is the level of the confidence intervals that are reported
+	confidenceLevel = 0.95
+)
+
+// zForConfidence is the quantile of the standard normal distribution for two-sided intervals at confidenceLevel
+var zForConfidence = normalQuantile(1 - (1-confidenceLevel)/2)
+
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// fisherExactLess is the one-sided p-value of Fisher's exact test that the pass rate of the current runs is
+// lower than the historical one, i.e. the probability of the current runs passing this few times or fewer if
+// both samples came from the same distribution.
+func fisherExactLess(passes, failures, historicalPasses, historicalFailures int) float64 {
+	attempts := passes + failures
+	totalPasses := passes + historicalPasses
+	total := attempts + historicalPasses + historicalFailures
+	logDenominator := logChoose(total, attempts)
+	pValue := 0.0
+	for x := max(0, attempts-(total-totalPasses)); x <= passes; x++ {
+		pValue += math.Exp(logChoose(totalPasses, x) + logChoose(total-totalPasses, attempts-x) - logDenominator)
+	}
+	return math.Min(pValue, 1)
+}
+
+// binomialLess is the one-sided p-value of the binomial test that the pass rate of the current runs is lower
+// than passRate, i.e. the probability of passing this few times or fewer with passRate
+func binomialLess(passes, attempts int, passRate float64) float64 {
+	switch {
+	case passRate <= 0:
+		return 1
+	case passRate >= 1:
+		if passes < attempts {
+			return 0
+		}
+		return 1
+	}
+	pValue := 0.0
+	for k := 0; k <= passes; k++ {
+		pValue += math.Exp(logChoose(attempts, k) + float64(k)*math.Log(passRate) + float64(attempts-k)*math.Log(1-passRate))
+	}
+	return math.Min(pValue, 1)
+}
+
+// wilsonInterval is the Wilson score interval of the pass rate at confidenceLevel
+func wilsonInterval(passes, attempts int) (float64, float64) {
+	if attempts == 0 {
+		return 0, 1
+	}
+	n := float64(attempts)
+	p := float64(passes) / n
+	z2 := zForConfidence * zForConfidence
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	halfWidth := zForConfidence * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+	return math.Max(0, center-halfWidth), math.Min(1, center+halfWidth)
+}
+
+// mannWhitneyGreater is the one-sided p-value of the Mann-Whitney U test that the current values are
+// stochastically greater than the historical ones. It uses the normal approximation with a correction
+// for ties and for continuity, which is accurate enough from about eight values per sample.
+func mannWhitneyGreater(current, historical []float64) float64 {
+	n1, n2 := float64(len(current)), float64(len(historical))
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	type value struct {
+		value   float64
+		current bool
+	}
+	var values []value
+	for _, v := range current {
+		values = append(values, value{value: v, current: true})
+	}
+	for _, v := range historical {
+		values = append(values, value{value: v})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].value < values[j].value })
+
+	// average the ranks of ties and collect the tie sizes for the variance correction
+	rankSum := 0.0
+	tieCorrection := 0.0
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j].value == values[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if values[k].current {
+				rankSum += rank
+			}
+		}
+		ties := float64(j - i)
+		tieCorrection += ties*ties*ties - ties
+		i = j
+	}
+	u := rankSum - n1*(n1+1)/2
+	n := n1 + n2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieCorrection/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (u - mean - 0.5) / math.Sqrt(variance)
+	return 1 - normalCDF(z)
+}
+
+// hodgesLehmannShift estimates how much greater the current values are than the historical ones, as the
+// median of their pairwise differences, with the distribution-free confidence interval at confidenceLevel
+func hodgesLehmannShift(current, historical []float64) (float64, float64, float64) {
+	var differences []float64
+	for _, c := range current {
+		for _, h := range historical {
+			differences = append(differences, c-h)
+		}
+	}
+	if len(differences) == 0 {
+		return 0, 0, 0
+	}
+	sort.Float64s(differences)
+	n1, n2 := float64(len(current)), float64(len(historical))
+	count := len(differences)
+	var estimate float64
+	if count%2 == 1 {
+		estimate = differences[count/2]
+	} else {
+		estimate = (differences[count/2-1] + differences[count/2]) / 2
+	}
+	// the number of differences to cut off each end of the sorted differences
+	cut := int(math.Floor(n1*n2/2 - zForConfidence*math.Sqrt(n1*n2*(n1+n2+1)/12)))
+	if cut < 0 {
+		cut = 0
+	}
+	if cut >= count/2 {
+		cut = (count - 1) / 2
+	}
+	return estimate, differences[cut], differences[count-1-cut]
+}
+
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+// normalQuantile inverts normalCDF by bisection, which is plenty for the few quantiles that are needed
+func normalQuantile(p float64) float64 {
+	low, high := -10.0, 10.0
+	for i := 0; i < 100; i++ {
+		mid := (low + high) / 2
+		if normalCDF(mid) < p {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
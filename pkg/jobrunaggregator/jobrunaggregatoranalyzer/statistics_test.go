@@ -0,0 +1,145 @@
+package jobrunaggregatoranalyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFisherExactLess(t *testing.T) {
+	tests := []struct {
+		name                                                   string
+		passes, failures, historicalPasses, historicalFailures int
+		expected                                               float64
+	}{
+		{
+			// [[3,1],[1,3]] gives (C(4,3)C(4,1)+C(4,4)C(4,0))/C(8,4) = 17/70
+			name:     "fewer passes than historically",
+			passes:   1,
+			failures: 3, historicalPasses: 3, historicalFailures: 1,
+			expected: 17.0 / 70,
+		},
+		{
+			name:     "all passes",
+			passes:   10,
+			failures: 0, historicalPasses: 90, historicalFailures: 10,
+			expected: 1,
+		},
+		{
+			name:     "no passes against a perfect history",
+			passes:   0,
+			failures: 2, historicalPasses: 2, historicalFailures: 0,
+			expected: 1.0 / 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, fisherExactLess(tt.passes, tt.failures, tt.historicalPasses, tt.historicalFailures), 1e-9)
+		})
+	}
+}
+
+func TestBinomialLess(t *testing.T) {
+	tests := []struct {
+		name             string
+		passes, attempts int
+		passRate         float64
+		expected         float64
+	}{
+		{
+			name:     "seven of ten against 95%",
+			passes:   7,
+			attempts: 10,
+			passRate: 0.95,
+			expected: 0.011503,
+		},
+		{
+			name:     "all passes",
+			passes:   10,
+			attempts: 10,
+			passRate: 0.95,
+			expected: 1,
+		},
+		{
+			name:     "failure against a perfect pass rate",
+			passes:   9,
+			attempts: 10,
+			passRate: 1,
+			expected: 0,
+		},
+		{
+			name:     "no pass rate",
+			passes:   0,
+			attempts: 10,
+			passRate: 0,
+			expected: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, binomialLess(tt.passes, tt.attempts, tt.passRate), 1e-6)
+		})
+	}
+}
+
+func TestWilsonInterval(t *testing.T) {
+	lower, upper := wilsonInterval(7, 10)
+	assert.InDelta(t, 0.3968, lower, 1e-4)
+	assert.InDelta(t, 0.8922, upper, 1e-4)
+
+	lower, upper = wilsonInterval(0, 0)
+	assert.Equal(t, 0.0, lower)
+	assert.Equal(t, 1.0, upper)
+}
+
+func TestMannWhitneyGreater(t *testing.T) {
+	tests := []struct {
+		name                string
+		current, historical []float64
+		expected            float64
+	}{
+		{
+			name:       "current all greater",
+			current:    []float64{4, 5, 6},
+			historical: []float64{1, 2, 3},
+			expected:   0.0404,
+		},
+		{
+			name:       "current all lower",
+			current:    []float64{1, 2, 3},
+			historical: []float64{4, 5, 6},
+			expected:   0.9855,
+		},
+		{
+			name:       "all equal",
+			current:    []float64{0, 0, 0},
+			historical: []float64{0, 0, 0},
+			expected:   1,
+		},
+		{
+			name:       "no current values",
+			historical: []float64{1, 2, 3},
+			expected:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, mannWhitneyGreater(tt.current, tt.historical), 1e-4)
+		})
+	}
+}
+
+func TestHodgesLehmannShift(t *testing.T) {
+	estimate, lower, upper := hodgesLehmannShift([]float64{4, 5, 6}, []float64{1, 2, 3})
+	assert.Equal(t, 3.0, estimate)
+	assert.LessOrEqual(t, lower, estimate)
+	assert.GreaterOrEqual(t, upper, estimate)
+
+	estimate, lower, upper = hodgesLehmannShift(nil, []float64{1, 2, 3})
+	assert.Equal(t, []float64{0, 0, 0}, []float64{estimate, lower, upper})
+}
+
+func TestNormalQuantile(t *testing.T) {
+	assert.InDelta(t, 1.959964, zForConfidence, 1e-6)
+	assert.InDelta(t, 0, normalQuantile(0.5), 1e-9)
+}
@@ -1,5 +1,7 @@
 package jobrunaggregatoranalyzer
 
+import "github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+
 type AggregationConfiguration struct {
 	UnfinishedJobs []JobRunInfo
 	FinishedJobs   []JobRunInfo
@@ -20,4 +22,6 @@ type TestInfo struct {
 	Status  string
 	Parents []string
 	Summary string
+
+	Significance *jobrunaggregatorlib.Significance
 }
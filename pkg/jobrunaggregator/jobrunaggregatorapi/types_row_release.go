@@ -155,3 +155,21 @@ type ReleaseJobRunRow struct {
 	// Upgrade is a flag that indicates whether this job run was an upgrade or not.
 	Upgrade bool `bigquery:"upgrade"`
 }
+
+// ReleaseTagTestRunCountsRow counts the results of a test in the runs of a job against one payload.
+type ReleaseTagTestRunCountsRow struct {
+	// ReleaseTag is the OpenShift version, e.g. 4.8.0-0.nightly-2021-10-28-013428.
+	ReleaseTag string `bigquery:"releaseTag"`
+
+	// ReleaseTime contains the time the release was created.
+	ReleaseTime time.Time `bigquery:"releaseTime"`
+
+	// Release, Stream and Architecture identify the stream of payloads the release belongs to, e.g. 4.8 nightly amd64
+	Release      string `bigquery:"release"`
+	Stream       string `bigquery:"stream"`
+	Architecture string `bigquery:"architecture"`
+
+	PassCount  int `bigquery:"passCount"`
+	FailCount  int `bigquery:"failCount"`
+	FlakeCount int `bigquery:"flakeCount"`
+}
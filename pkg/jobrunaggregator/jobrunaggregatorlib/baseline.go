@@ -0,0 +1,89 @@
+package jobrunaggregatorlib
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+)
+
+const (
+	// BaselineSourceBigQuery reads baselines from the BigQuery tables the job runs are uploaded to
+	BaselineSourceBigQuery = "bigquery"
+	// BaselineSourceSnapshot reads baselines from a directory of Parquet files written by snapshot-baselines
+	BaselineSourceSnapshot = "snapshot"
+	// BaselineSourceHTTP reads baselines from a Sippy-style HTTP API
+	BaselineSourceHTTP = "http"
+	// BaselineSourceStatic reads fixed pass rates and disruption thresholds from a YAML file
+	BaselineSourceStatic = "static"
+)
+
+var (
+	KnownBaselineSources = sets.New[string](BaselineSourceBigQuery, BaselineSourceSnapshot, BaselineSourceHTTP, BaselineSourceStatic)
+	// KnownHistoricalDataSources are the baseline sources that provide historical data
+	KnownHistoricalDataSources = sets.New[string](BaselineSourceBigQuery, BaselineSourceSnapshot)
+)
+
+// BaselineDataSource provides the historical data that aggregated job runs are judged against.
+// The BigQuery CIDataClient is one, the others allow aggregating without access to BigQuery.
+type BaselineDataSource interface {
+	// ListAggregatedTestRunsForJob lists the pass rates of the tests of the job in the period starting at startDay
+	ListAggregatedTestRunsForJob(ctx context.Context, frequency, jobName string, startDay time.Time) ([]jobrunaggregatorapi.AggregatedTestRunRow, error)
+	// ListAllJobsWithVariants lists the known jobs, used to find the job of the previous release to fall back to
+	ListAllJobsWithVariants(ctx context.Context) ([]jobrunaggregatorapi.JobRowWithVariants, error)
+	// GetBackendDisruptionRowCountByJob gets the number of job runs the disruption statistics of the job are based on
+	GetBackendDisruptionRowCountByJob(ctx context.Context, jobName, masterNodesUpdated string) (uint64, error)
+	// GetBackendDisruptionStatisticsByJob gets the disruption statistics per backend of the job
+	GetBackendDisruptionStatisticsByJob(ctx context.Context, jobName, masterNodesUpdated string) ([]jobrunaggregatorapi.BackendDisruptionStatisticsRow, error)
+}
+
+// HistoricalDataSource provides the data the historical data analyzer compares the checked in data against
+type HistoricalDataSource interface {
+	HistoricalDataClient
+	ListAllKnownAlerts(ctx context.Context) ([]*jobrunaggregatorapi.KnownAlertRow, error)
+	ListTestSummaryByPeriod(ctx context.Context, suiteName, releaseName string, daysBack, minTestCount int) ([]jobrunaggregatorapi.TestSummaryByPeriodRow, error)
+}
+
+// ValidateBaselineSource checks that the source is known and that its location is set when it needs one
+func ValidateBaselineSource(source, location string) error {
+	if !KnownBaselineSources.Has(source) {
+		return fmt.Errorf("unknown baseline source %s, valid values are: %+q", source, sets.List(KnownBaselineSources))
+	}
+	if source != BaselineSourceBigQuery && len(location) == 0 {
+		return fmt.Errorf("baseline source %s requires --baseline-location", source)
+	}
+	return nil
+}
+
+// NewBaselineDataSource creates the data source for the baseline source. The location is the snapshot
+// directory, the URL of the API or the path to the static baseline depending on the source.
+func NewBaselineDataSource(source, location string, ciDataClient CIDataClient) (BaselineDataSource, error) {
+	switch source {
+	case BaselineSourceBigQuery:
+		return ciDataClient, nil
+	case BaselineSourceSnapshot:
+		return NewSnapshotBaselineDataSource(location), nil
+	case BaselineSourceHTTP:
+		return NewHTTPBaselineDataSource(location, nil), nil
+	case BaselineSourceStatic:
+		return NewStaticBaselineDataSourceFromFile(location)
+	default:
+		return nil, fmt.Errorf("unknown baseline source %s", source)
+	}
+}
+
+// NewHistoricalDataSource creates the historical data source for the baseline source, one of KnownHistoricalDataSources.
+// The location is the snapshot directory for snapshots.
+func NewHistoricalDataSource(source, location string, ciDataClient CIDataClient) (HistoricalDataSource, error) {
+	switch source {
+	case BaselineSourceBigQuery:
+		return ciDataClient, nil
+	case BaselineSourceSnapshot:
+		return NewSnapshotHistoricalDataSource(location), nil
+	default:
+		return nil, fmt.Errorf("baseline source %s does not provide historical data", source)
+	}
+}
@@ -0,0 +1,121 @@
+package jobrunaggregatorlib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+)
+
+const (
+	// BaselineAPIJobsPath lists the jobs with their variants
+	BaselineAPIJobsPath = "/api/aggregation/jobs"
+	// BaselineAPITestRunsPath lists the aggregated test runs of the job, frequency and start query parameters
+	BaselineAPITestRunsPath = "/api/aggregation/test_runs"
+	// BaselineAPIDisruptionPath serves the BackendDisruptionResponse of the job and master_nodes_updated query parameters
+	BaselineAPIDisruptionPath = "/api/aggregation/disruption"
+)
+
+// BackendDisruptionResponse is served by the disruption endpoint of the baseline API
+type BackendDisruptionResponse struct {
+	// JobRuns is the number of job runs the statistics are based on
+	JobRuns  uint64                                               `json:"jobRuns"`
+	Backends []jobrunaggregatorapi.BackendDisruptionStatisticsRow `json:"backends"`
+}
+
+type httpBaselineDataSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPBaselineDataSource reads baselines from a Sippy-style HTTP API at baseURL. Rows are
+// exchanged as JSON with the field names of the BigQuery rows, and the start day is formatted
+// as 2006-01-02. The default client is used when client is nil.
+func NewHTTPBaselineDataSource(baseURL string, client *http.Client) BaselineDataSource {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &httpBaselineDataSource{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+func (s *httpBaselineDataSource) get(ctx context.Context, path string, query url.Values, into interface{}) error {
+	target := s.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", target, err)
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", target, err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response from %s: %w", target, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s: status %d: %s", target, response.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, into); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", target, err)
+	}
+	return nil
+}
+
+func (s *httpBaselineDataSource) ListAggregatedTestRunsForJob(ctx context.Context, frequency, jobName string, startDay time.Time) ([]jobrunaggregatorapi.AggregatedTestRunRow, error) {
+	var rows []jobrunaggregatorapi.AggregatedTestRunRow
+	query := url.Values{
+		"job":       []string{jobName},
+		"frequency": []string{frequency},
+		"start":     []string{startDay.Format("2006-01-02")},
+	}
+	if err := s.get(ctx, BaselineAPITestRunsPath, query, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (s *httpBaselineDataSource) ListAllJobsWithVariants(ctx context.Context) ([]jobrunaggregatorapi.JobRowWithVariants, error) {
+	var rows []jobrunaggregatorapi.JobRowWithVariants
+	if err := s.get(ctx, BaselineAPIJobsPath, nil, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (s *httpBaselineDataSource) getDisruption(ctx context.Context, jobName, masterNodesUpdated string) (*BackendDisruptionResponse, error) {
+	response := &BackendDisruptionResponse{}
+	query := url.Values{"job": []string{jobName}}
+	if len(masterNodesUpdated) > 0 {
+		query.Set("master_nodes_updated", masterNodesUpdated)
+	}
+	if err := s.get(ctx, BaselineAPIDisruptionPath, query, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (s *httpBaselineDataSource) GetBackendDisruptionRowCountByJob(ctx context.Context, jobName, masterNodesUpdated string) (uint64, error) {
+	response, err := s.getDisruption(ctx, jobName, masterNodesUpdated)
+	if err != nil {
+		return 0, err
+	}
+	return response.JobRuns, nil
+}
+
+func (s *httpBaselineDataSource) GetBackendDisruptionStatisticsByJob(ctx context.Context, jobName, masterNodesUpdated string) ([]jobrunaggregatorapi.BackendDisruptionStatisticsRow, error) {
+	response, err := s.getDisruption(ctx, jobName, masterNodesUpdated)
+	if err != nil {
+		return nil, err
+	}
+	return response.Backends, nil
+}
@@ -0,0 +1,264 @@
+package jobrunaggregatorlib
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+)
+
+const (
+	snapshotAggregatedTestRunsFile = "aggregated-test-runs.parquet"
+	snapshotBackendDisruptionFile  = "backend-disruption.parquet"
+	snapshotJobsFile               = "jobs.parquet"
+
+	snapshotDisruptionHistoricalDataFile = "disruption-historical-data.parquet"
+	snapshotAlertHistoricalDataFile      = "alert-historical-data.parquet"
+	snapshotKnownAlertsFile              = "known-alerts.parquet"
+)
+
+// SnapshotMasterNodesUpdatedValues are the values of MasterNodesUpdated that disruption statistics
+// are snapshotted for. The empty value does not filter on whether master nodes were updated.
+var SnapshotMasterNodesUpdatedValues = []string{"", "Y", "N"}
+
+// snapshotBackendDisruptionRow records the disruption statistics of a backend along with the job
+// and the number of job runs they are based on, which the statistics rows do not carry
+type snapshotBackendDisruptionRow struct {
+	JobName            string
+	MasterNodesUpdated string
+	JobRuns            uint64
+	jobrunaggregatorapi.BackendDisruptionStatisticsRow
+}
+
+type snapshotBaselineDataSource struct {
+	dir string
+
+	loadOnce           sync.Once
+	loadErr            error
+	aggregatedTestRuns []jobrunaggregatorapi.AggregatedTestRunRow
+	backendDisruption  []snapshotBackendDisruptionRow
+	jobs               []jobrunaggregatorapi.JobRowWithVariants
+}
+
+// NewSnapshotBaselineDataSource reads baselines from the Parquet files that WriteBaselineSnapshot
+// wrote to dir. A snapshot holds the pass rates of a single period, so the frequency and start day
+// of the requested pass rates are not taken into account.
+func NewSnapshotBaselineDataSource(dir string) BaselineDataSource {
+	return &snapshotBaselineDataSource{dir: dir}
+}
+
+func (s *snapshotBaselineDataSource) load() error {
+	s.loadOnce.Do(func() {
+		for name, rows := range map[string]interface{}{
+			snapshotAggregatedTestRunsFile: &s.aggregatedTestRuns,
+			snapshotBackendDisruptionFile:  &s.backendDisruption,
+			snapshotJobsFile:               &s.jobs,
+		} {
+			if err := readParquetRows(filepath.Join(s.dir, name), rows); err != nil {
+				s.loadErr = fmt.Errorf("failed to load baseline snapshot: %w", err)
+				return
+			}
+		}
+	})
+	return s.loadErr
+}
+
+func (s *snapshotBaselineDataSource) ListAggregatedTestRunsForJob(_ context.Context, _, jobName string, _ time.Time) ([]jobrunaggregatorapi.AggregatedTestRunRow, error) {
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	var rows []jobrunaggregatorapi.AggregatedTestRunRow
+	for _, row := range s.aggregatedTestRuns {
+		if row.JobName == jobName {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (s *snapshotBaselineDataSource) ListAllJobsWithVariants(_ context.Context) ([]jobrunaggregatorapi.JobRowWithVariants, error) {
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s.jobs, nil
+}
+
+func (s *snapshotBaselineDataSource) GetBackendDisruptionRowCountByJob(_ context.Context, jobName, masterNodesUpdated string) (uint64, error) {
+	if err := s.load(); err != nil {
+		return 0, err
+	}
+	for _, row := range s.backendDisruption {
+		if row.JobName == jobName && row.MasterNodesUpdated == masterNodesUpdated {
+			return row.JobRuns, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *snapshotBaselineDataSource) GetBackendDisruptionStatisticsByJob(_ context.Context, jobName, masterNodesUpdated string) ([]jobrunaggregatorapi.BackendDisruptionStatisticsRow, error) {
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	var rows []jobrunaggregatorapi.BackendDisruptionStatisticsRow
+	for _, row := range s.backendDisruption {
+		if row.JobName == jobName && row.MasterNodesUpdated == masterNodesUpdated {
+			rows = append(rows, row.BackendDisruptionStatisticsRow)
+		}
+	}
+	return rows, nil
+}
+
+// WriteBaselineSnapshot writes the baselines of the jobs from the source to Parquet files in dir,
+// for NewSnapshotBaselineDataSource to read them.
+func WriteBaselineSnapshot(ctx context.Context, source BaselineDataSource, dir string, jobNames []string, startDay time.Time) error {
+	var aggregatedTestRuns []jobrunaggregatorapi.AggregatedTestRunRow
+	var backendDisruption []snapshotBackendDisruptionRow
+	for _, jobName := range jobNames {
+		rows, err := source.ListAggregatedTestRunsForJob(ctx, "ByOneWeek", jobName, startDay)
+		if err != nil {
+			return fmt.Errorf("failed to list aggregated test runs of %s: %w", jobName, err)
+		}
+		aggregatedTestRuns = append(aggregatedTestRuns, rows...)
+
+		for _, masterNodesUpdated := range SnapshotMasterNodesUpdatedValues {
+			jobRuns, err := source.GetBackendDisruptionRowCountByJob(ctx, jobName, masterNodesUpdated)
+			if err != nil {
+				return fmt.Errorf("failed to count disruption rows of %s: %w", jobName, err)
+			}
+			statistics, err := source.GetBackendDisruptionStatisticsByJob(ctx, jobName, masterNodesUpdated)
+			if err != nil {
+				return fmt.Errorf("failed to get disruption statistics of %s: %w", jobName, err)
+			}
+			for _, row := range statistics {
+				backendDisruption = append(backendDisruption, snapshotBackendDisruptionRow{
+					JobName:                        jobName,
+					MasterNodesUpdated:             masterNodesUpdated,
+					JobRuns:                        jobRuns,
+					BackendDisruptionStatisticsRow: row,
+				})
+			}
+		}
+	}
+	jobs, err := source.ListAllJobsWithVariants(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for name, rows := range map[string]interface{}{
+		snapshotAggregatedTestRunsFile: aggregatedTestRuns,
+		snapshotBackendDisruptionFile:  backendDisruption,
+		snapshotJobsFile:               jobs,
+	} {
+		if err := writeParquetRows(filepath.Join(dir, name), rows); err != nil {
+			return fmt.Errorf("failed to write baseline snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+type snapshotHistoricalDataSource struct {
+	dir string
+}
+
+// NewSnapshotHistoricalDataSource reads historical data from the Parquet files that WriteHistoricalDataSnapshot
+// wrote to dir. Test summaries are not part of snapshots.
+func NewSnapshotHistoricalDataSource(dir string) HistoricalDataSource {
+	return &snapshotHistoricalDataSource{dir: dir}
+}
+
+func (s *snapshotHistoricalDataSource) read(name string, rows interface{}) error {
+	if err := readParquetRows(filepath.Join(s.dir, name), rows); err != nil {
+		return fmt.Errorf("failed to load historical data snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *snapshotHistoricalDataSource) ListDisruptionHistoricalData(_ context.Context) ([]jobrunaggregatorapi.HistoricalData, error) {
+	var rows []jobrunaggregatorapi.DisruptionHistoricalDataRow
+	if err := s.read(snapshotDisruptionHistoricalDataFile, &rows); err != nil {
+		return nil, err
+	}
+	return jobrunaggregatorapi.ConvertToHistoricalData(pointers(rows)), nil
+}
+
+func (s *snapshotHistoricalDataSource) ListAlertHistoricalData(_ context.Context) ([]*jobrunaggregatorapi.AlertHistoricalDataRow, error) {
+	var rows []jobrunaggregatorapi.AlertHistoricalDataRow
+	if err := s.read(snapshotAlertHistoricalDataFile, &rows); err != nil {
+		return nil, err
+	}
+	return pointers(rows), nil
+}
+
+func (s *snapshotHistoricalDataSource) ListAllKnownAlerts(_ context.Context) ([]*jobrunaggregatorapi.KnownAlertRow, error) {
+	var rows []jobrunaggregatorapi.KnownAlertRow
+	if err := s.read(snapshotKnownAlertsFile, &rows); err != nil {
+		return nil, err
+	}
+	return pointers(rows), nil
+}
+
+func (s *snapshotHistoricalDataSource) ListTestSummaryByPeriod(_ context.Context, _, _ string, _, _ int) ([]jobrunaggregatorapi.TestSummaryByPeriodRow, error) {
+	return nil, fmt.Errorf("test summaries are not part of historical data snapshots")
+}
+
+// WriteHistoricalDataSnapshot writes the disruption and alert historical data from the source to Parquet
+// files in dir, for NewSnapshotHistoricalDataSource to read them.
+func WriteHistoricalDataSnapshot(ctx context.Context, source HistoricalDataSource, dir string) error {
+	disruptionData, err := source.ListDisruptionHistoricalData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list disruption historical data: %w", err)
+	}
+	var disruption []jobrunaggregatorapi.DisruptionHistoricalDataRow
+	for _, data := range disruptionData {
+		row, ok := data.(*jobrunaggregatorapi.DisruptionHistoricalDataRow)
+		if !ok {
+			return fmt.Errorf("unexpected disruption historical data of type %T", data)
+		}
+		disruption = append(disruption, *row)
+	}
+	alerts, err := source.ListAlertHistoricalData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list alert historical data: %w", err)
+	}
+	knownAlerts, err := source.ListAllKnownAlerts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list known alerts: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for name, rows := range map[string]interface{}{
+		snapshotDisruptionHistoricalDataFile: disruption,
+		snapshotAlertHistoricalDataFile:      values(alerts),
+		snapshotKnownAlertsFile:              values(knownAlerts),
+	} {
+		if err := writeParquetRows(filepath.Join(dir, name), rows); err != nil {
+			return fmt.Errorf("failed to write historical data snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+func pointers[T any](rows []T) []*T {
+	ret := make([]*T, len(rows))
+	for i := range rows {
+		ret[i] = &rows[i]
+	}
+	return ret
+}
+
+func values[T any](rows []*T) []T {
+	ret := make([]T, len(rows))
+	for i := range rows {
+		ret[i] = *rows[i]
+	}
+	return ret
+}
@@ -0,0 +1,178 @@
+package jobrunaggregatorlib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+)
+
+// StaticBaseline holds fixed pass rates and disruption thresholds for jobs, instead of ones
+// derived from the history of the jobs
+type StaticBaseline struct {
+	Jobs []StaticJobBaseline `json:"jobs"`
+}
+
+// StaticJobBaseline holds the pass rates and disruption thresholds of a job
+type StaticJobBaseline struct {
+	JobName    string                      `json:"jobName"`
+	Tests      []StaticTestPassRate        `json:"tests,omitempty"`
+	Disruption []StaticDisruptionThreshold `json:"disruption,omitempty"`
+}
+
+// StaticTestPassRate is the pass rate a test is expected to have. The number of passes required
+// of the aggregated job runs is derived from it like it is from a historical pass rate.
+type StaticTestPassRate struct {
+	TestSuiteName  string  `json:"testSuiteName,omitempty"`
+	TestName       string  `json:"testName"`
+	PassPercentage float64 `json:"passPercentage"`
+}
+
+// StaticDisruptionThreshold holds the disruption statistics of a backend
+type StaticDisruptionThreshold struct {
+	BackendName string `json:"backendName"`
+	// MasterNodesUpdated restricts the threshold to job runs that did (Y) or did not (N) update
+	// master nodes. Thresholds for a specific value take precedence over those without one.
+	// The value must be quoted, as YAML reads unquoted Y and N as booleans.
+	MasterNodesUpdated string  `json:"masterNodesUpdated,omitempty"`
+	Mean               float64 `json:"mean"`
+	StandardDeviation  float64 `json:"standardDeviation"`
+	// Percentiles maps percentiles from 1 to 99 to the disruption in seconds
+	Percentiles map[int]float64 `json:"percentiles,omitempty"`
+}
+
+// Validate checks that the thresholds are well-formed
+func (b StaticBaseline) Validate() error {
+	var errs []error
+	seen := map[string]bool{}
+	for i, job := range b.Jobs {
+		if len(job.JobName) == 0 {
+			errs = append(errs, fmt.Errorf("jobs[%d]: jobName must be set", i))
+		} else if seen[job.JobName] {
+			errs = append(errs, fmt.Errorf("jobs[%d]: duplicate job %s", i, job.JobName))
+		}
+		seen[job.JobName] = true
+		for j, test := range job.Tests {
+			if len(test.TestName) == 0 {
+				errs = append(errs, fmt.Errorf("jobs[%d].tests[%d]: testName must be set", i, j))
+			}
+			if test.PassPercentage < 0 || test.PassPercentage > 100 {
+				errs = append(errs, fmt.Errorf("jobs[%d].tests[%d]: passPercentage must be between 0 and 100, not %v", i, j, test.PassPercentage))
+			}
+		}
+		for j, disruption := range job.Disruption {
+			if len(disruption.BackendName) == 0 {
+				errs = append(errs, fmt.Errorf("jobs[%d].disruption[%d]: backendName must be set", i, j))
+			}
+			switch disruption.MasterNodesUpdated {
+			case "", "Y", "N":
+			default:
+				errs = append(errs, fmt.Errorf("jobs[%d].disruption[%d]: masterNodesUpdated must be Y or N, not %s", i, j, disruption.MasterNodesUpdated))
+			}
+			for percentile := range disruption.Percentiles {
+				if percentile < 1 || percentile > 99 {
+					errs = append(errs, fmt.Errorf("jobs[%d].disruption[%d]: percentile %d is not between 1 and 99", i, j, percentile))
+				}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+type staticBaselineDataSource struct {
+	jobs map[string]StaticJobBaseline
+}
+
+// NewStaticBaselineDataSource serves the pass rates and disruption thresholds of the baseline.
+// The thresholds of a job are authoritative: they are never replaced by those of the job of a
+// previous release, which the aggregator otherwise does for jobs with few runs.
+func NewStaticBaselineDataSource(baseline StaticBaseline) (BaselineDataSource, error) {
+	if err := baseline.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid static baseline: %w", err)
+	}
+	source := &staticBaselineDataSource{jobs: map[string]StaticJobBaseline{}}
+	for _, job := range baseline.Jobs {
+		source.jobs[job.JobName] = job
+	}
+	return source, nil
+}
+
+// NewStaticBaselineDataSourceFromFile loads the StaticBaseline at path
+func NewStaticBaselineDataSourceFromFile(path string) (BaselineDataSource, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read static baseline: %w", err)
+	}
+	var baseline StaticBaseline
+	if err := yaml.UnmarshalStrict(raw, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to load static baseline %s: %w", path, err)
+	}
+	return NewStaticBaselineDataSource(baseline)
+}
+
+func (s *staticBaselineDataSource) ListAggregatedTestRunsForJob(_ context.Context, _, jobName string, startDay time.Time) ([]jobrunaggregatorapi.AggregatedTestRunRow, error) {
+	var rows []jobrunaggregatorapi.AggregatedTestRunRow
+	for _, test := range s.jobs[jobName].Tests {
+		rows = append(rows, jobrunaggregatorapi.AggregatedTestRunRow{
+			AggregationStartDate: startDay,
+			TestName:             test.TestName,
+			TestSuiteName:        bigquery.NullString{StringVal: test.TestSuiteName, Valid: len(test.TestSuiteName) > 0},
+			JobName:              jobName,
+			PassPercentage:       test.PassPercentage,
+			WorkingPercentage:    test.PassPercentage,
+		})
+	}
+	return rows, nil
+}
+
+func (s *staticBaselineDataSource) ListAllJobsWithVariants(_ context.Context) ([]jobrunaggregatorapi.JobRowWithVariants, error) {
+	return nil, nil
+}
+
+func (s *staticBaselineDataSource) GetBackendDisruptionRowCountByJob(_ context.Context, jobName, _ string) (uint64, error) {
+	if _, ok := s.jobs[jobName]; !ok {
+		return 0, nil
+	}
+	return math.MaxUint64, nil
+}
+
+func (s *staticBaselineDataSource) GetBackendDisruptionStatisticsByJob(_ context.Context, jobName, masterNodesUpdated string) ([]jobrunaggregatorapi.BackendDisruptionStatisticsRow, error) {
+	thresholds := map[string]StaticDisruptionThreshold{}
+	var backends []string
+	for _, disruption := range s.jobs[jobName].Disruption {
+		if disruption.MasterNodesUpdated != "" && disruption.MasterNodesUpdated != masterNodesUpdated {
+			continue
+		}
+		existing, ok := thresholds[disruption.BackendName]
+		if !ok {
+			backends = append(backends, disruption.BackendName)
+		}
+		if !ok || existing.MasterNodesUpdated == "" {
+			thresholds[disruption.BackendName] = disruption
+		}
+	}
+	var rows []jobrunaggregatorapi.BackendDisruptionStatisticsRow
+	for _, backend := range backends {
+		threshold := thresholds[backend]
+		row := jobrunaggregatorapi.BackendDisruptionStatisticsRow{
+			BackendName:       backend,
+			Mean:              threshold.Mean,
+			StandardDeviation: threshold.StandardDeviation,
+		}
+		value := reflect.ValueOf(&row).Elem()
+		for percentile, seconds := range threshold.Percentiles {
+			value.FieldByName(fmt.Sprintf("P%d", percentile)).SetFloat(seconds)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
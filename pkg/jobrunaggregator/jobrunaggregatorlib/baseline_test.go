@@ -0,0 +1,308 @@
+package jobrunaggregatorlib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+const (
+	baselineTestJob     = "periodic-ci-openshift-release-master-ci-4.19-e2e-aws-upgrade"
+	baselineTestOldJob  = "periodic-ci-openshift-release-master-ci-4.18-e2e-aws-upgrade"
+	baselineTestBackend = "kube-api-new-connections"
+)
+
+type fakeBaselineDataSource struct {
+	testRuns   []jobrunaggregatorapi.AggregatedTestRunRow
+	jobs       []jobrunaggregatorapi.JobRowWithVariants
+	jobRuns    map[string]uint64
+	disruption map[string][]jobrunaggregatorapi.BackendDisruptionStatisticsRow
+}
+
+func (f *fakeBaselineDataSource) ListAggregatedTestRunsForJob(_ context.Context, _, jobName string, _ time.Time) ([]jobrunaggregatorapi.AggregatedTestRunRow, error) {
+	var rows []jobrunaggregatorapi.AggregatedTestRunRow
+	for _, row := range f.testRuns {
+		if row.JobName == jobName {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeBaselineDataSource) ListAllJobsWithVariants(_ context.Context) ([]jobrunaggregatorapi.JobRowWithVariants, error) {
+	return f.jobs, nil
+}
+
+func (f *fakeBaselineDataSource) GetBackendDisruptionRowCountByJob(_ context.Context, jobName, masterNodesUpdated string) (uint64, error) {
+	return f.jobRuns[jobName+masterNodesUpdated], nil
+}
+
+func (f *fakeBaselineDataSource) GetBackendDisruptionStatisticsByJob(_ context.Context, jobName, masterNodesUpdated string) ([]jobrunaggregatorapi.BackendDisruptionStatisticsRow, error) {
+	return f.disruption[jobName+masterNodesUpdated], nil
+}
+
+func newFakeBaselineDataSource() *fakeBaselineDataSource {
+	startDay := time.Date(2025, time.March, 4, 0, 0, 0, 0, time.UTC)
+	return &fakeBaselineDataSource{
+		testRuns: []jobrunaggregatorapi.AggregatedTestRunRow{
+			{AggregationStartDate: startDay, TestName: "install should succeed", TestSuiteName: bigquery.NullString{StringVal: "cluster install", Valid: true}, JobName: baselineTestJob, PassCount: 95, FailCount: 5, PassPercentage: 95, WorkingPercentage: 95},
+			{AggregationStartDate: startDay, TestName: "upgrade should succeed", JobName: baselineTestJob, PassCount: 9, FailCount: 1, FlakeCount: 2, PassPercentage: 90, WorkingPercentage: 92},
+			{AggregationStartDate: startDay, TestName: "install should succeed", JobName: baselineTestOldJob, PassCount: 1, PassPercentage: 100, WorkingPercentage: 100},
+		},
+		jobs: []jobrunaggregatorapi.JobRowWithVariants{
+			{JobName: baselineTestJob, Platform: "aws", Release: "4.19", FromRelease: bigquery.NullString{StringVal: "4.18", Valid: true}, CollectDisruption: true},
+			{JobName: baselineTestOldJob, Platform: "aws", Release: "4.18"},
+		},
+		jobRuns: map[string]uint64{baselineTestJob + "Y": 120, baselineTestJob + "N": 80},
+		disruption: map[string][]jobrunaggregatorapi.BackendDisruptionStatisticsRow{
+			baselineTestJob + "Y": {{BackendName: baselineTestBackend, Mean: 1.5, StandardDeviation: 0.5, P50: 1, P95: 3, P99: 4}},
+			baselineTestJob + "N": {{BackendName: baselineTestBackend, Mean: 0.5, StandardDeviation: 0.1, P95: 1}},
+		},
+	}
+}
+
+// checkBaselineDataSource compares what the data source serves for the test job to what the fake serves
+func checkBaselineDataSource(t *testing.T, expected *fakeBaselineDataSource, actual BaselineDataSource) {
+	ctx := context.Background()
+	startDay := time.Date(2025, time.March, 4, 0, 0, 0, 0, time.UTC)
+	for _, jobName := range []string{baselineTestJob, "unknown"} {
+		expectedRuns, _ := expected.ListAggregatedTestRunsForJob(ctx, "ByOneWeek", jobName, startDay)
+		actualRuns, err := actual.ListAggregatedTestRunsForJob(ctx, "ByOneWeek", jobName, startDay)
+		if err != nil {
+			t.Fatalf("failed to list aggregated test runs: %v", err)
+		}
+		if diff := cmp.Diff(expectedRuns, actualRuns); diff != "" {
+			t.Errorf("unexpected aggregated test runs of %s: %s", jobName, diff)
+		}
+		for _, masterNodesUpdated := range SnapshotMasterNodesUpdatedValues {
+			expectedCount, _ := expected.GetBackendDisruptionRowCountByJob(ctx, jobName, masterNodesUpdated)
+			actualCount, err := actual.GetBackendDisruptionRowCountByJob(ctx, jobName, masterNodesUpdated)
+			if err != nil {
+				t.Fatalf("failed to count disruption rows: %v", err)
+			}
+			if expectedCount != actualCount {
+				t.Errorf("expected %d disruption rows of %s/%q, got %d", expectedCount, jobName, masterNodesUpdated, actualCount)
+			}
+			expectedStatistics, _ := expected.GetBackendDisruptionStatisticsByJob(ctx, jobName, masterNodesUpdated)
+			actualStatistics, err := actual.GetBackendDisruptionStatisticsByJob(ctx, jobName, masterNodesUpdated)
+			if err != nil {
+				t.Fatalf("failed to get disruption statistics: %v", err)
+			}
+			if diff := cmp.Diff(expectedStatistics, actualStatistics); diff != "" {
+				t.Errorf("unexpected disruption statistics of %s/%q: %s", jobName, masterNodesUpdated, diff)
+			}
+		}
+	}
+	actualJobs, err := actual.ListAllJobsWithVariants(ctx)
+	if err != nil {
+		t.Fatalf("failed to list jobs: %v", err)
+	}
+	if diff := cmp.Diff(expected.jobs, actualJobs); diff != "" {
+		t.Errorf("unexpected jobs: %s", diff)
+	}
+}
+
+func TestSnapshotBaselineDataSource(t *testing.T) {
+	fake := newFakeBaselineDataSource()
+	dir := filepath.Join(t.TempDir(), "snapshot")
+	if err := WriteBaselineSnapshot(context.Background(), fake, dir, []string{baselineTestJob}, time.Time{}); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	// only the rows of the snapshotted jobs are kept
+	fake.testRuns = fake.testRuns[:2]
+	checkBaselineDataSource(t, fake, NewSnapshotBaselineDataSource(dir))
+
+	_, err := NewSnapshotBaselineDataSource(t.TempDir()).ListAllJobsWithVariants(context.Background())
+	if err == nil {
+		t.Error("expected an error loading a missing snapshot")
+	}
+}
+
+func TestHTTPBaselineDataSource(t *testing.T) {
+	fake := newFakeBaselineDataSource()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		query := r.URL.Query()
+		var response interface{}
+		switch r.URL.Path {
+		case BaselineAPIJobsPath:
+			response, _ = fake.ListAllJobsWithVariants(ctx)
+		case BaselineAPITestRunsPath:
+			startDay, err := time.Parse("2006-01-02", query.Get("start"))
+			if err != nil || query.Get("frequency") != "ByOneWeek" {
+				http.Error(w, "invalid query", http.StatusBadRequest)
+				return
+			}
+			response, _ = fake.ListAggregatedTestRunsForJob(ctx, query.Get("frequency"), query.Get("job"), startDay)
+		case BaselineAPIDisruptionPath:
+			jobRuns, _ := fake.GetBackendDisruptionRowCountByJob(ctx, query.Get("job"), query.Get("master_nodes_updated"))
+			backends, _ := fake.GetBackendDisruptionStatisticsByJob(ctx, query.Get("job"), query.Get("master_nodes_updated"))
+			response = BackendDisruptionResponse{JobRuns: jobRuns, Backends: backends}
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	checkBaselineDataSource(t, fake, NewHTTPBaselineDataSource(server.URL+"/", server.Client()))
+
+	_, err := NewHTTPBaselineDataSource(server.URL+"/missing", server.Client()).ListAllJobsWithVariants(context.Background())
+	expectedErr := errors.New("failed to get " + server.URL + "/missing/api/aggregation/jobs: status 404: not found")
+	if diff := cmp.Diff(expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+}
+
+func TestStaticBaselineDataSource(t *testing.T) {
+	startDay := time.Date(2025, time.March, 4, 0, 0, 0, 0, time.UTC)
+	baseline := []byte(`jobs:
+- jobName: ` + baselineTestJob + `
+  tests:
+  - testSuiteName: cluster install
+    testName: install should succeed
+    passPercentage: 95
+  - testName: upgrade should succeed
+    passPercentage: 92
+  disruption:
+  - backendName: ` + baselineTestBackend + `
+    mean: 1.5
+    standardDeviation: 0.5
+    percentiles:
+      50: 1
+      95: 3
+      99: 4
+  - backendName: ` + baselineTestBackend + `
+    masterNodesUpdated: "N"
+    mean: 0.5
+    standardDeviation: 0.1
+    percentiles:
+      95: 1
+`)
+	path := filepath.Join(t.TempDir(), "baseline.yaml")
+	if err := os.WriteFile(path, baseline, 0644); err != nil {
+		t.Fatal(err)
+	}
+	source, err := NewStaticBaselineDataSourceFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to load static baseline: %v", err)
+	}
+	ctx := context.Background()
+
+	runs, err := source.ListAggregatedTestRunsForJob(ctx, "ByOneWeek", baselineTestJob, startDay)
+	if err != nil {
+		t.Fatalf("failed to list aggregated test runs: %v", err)
+	}
+	expectedRuns := []jobrunaggregatorapi.AggregatedTestRunRow{
+		{AggregationStartDate: startDay, TestName: "install should succeed", TestSuiteName: bigquery.NullString{StringVal: "cluster install", Valid: true}, JobName: baselineTestJob, PassPercentage: 95, WorkingPercentage: 95},
+		{AggregationStartDate: startDay, TestName: "upgrade should succeed", JobName: baselineTestJob, PassPercentage: 92, WorkingPercentage: 92},
+	}
+	if diff := cmp.Diff(expectedRuns, runs); diff != "" {
+		t.Errorf("unexpected aggregated test runs: %s", diff)
+	}
+
+	for masterNodesUpdated, expected := range map[string][]jobrunaggregatorapi.BackendDisruptionStatisticsRow{
+		"":  {{BackendName: baselineTestBackend, Mean: 1.5, StandardDeviation: 0.5, P50: 1, P95: 3, P99: 4}},
+		"Y": {{BackendName: baselineTestBackend, Mean: 1.5, StandardDeviation: 0.5, P50: 1, P95: 3, P99: 4}},
+		"N": {{BackendName: baselineTestBackend, Mean: 0.5, StandardDeviation: 0.1, P95: 1}},
+	} {
+		statistics, err := source.GetBackendDisruptionStatisticsByJob(ctx, baselineTestJob, masterNodesUpdated)
+		if err != nil {
+			t.Fatalf("failed to get disruption statistics: %v", err)
+		}
+		if diff := cmp.Diff(expected, statistics); diff != "" {
+			t.Errorf("unexpected disruption statistics for %q: %s", masterNodesUpdated, diff)
+		}
+	}
+
+	if count, _ := source.GetBackendDisruptionRowCountByJob(ctx, "unknown", ""); count != 0 {
+		t.Errorf("expected no disruption rows for an unknown job, got %d", count)
+	}
+
+	_, err = NewStaticBaselineDataSource(StaticBaseline{Jobs: []StaticJobBaseline{
+		{JobName: baselineTestJob, Tests: []StaticTestPassRate{{TestName: "install", PassPercentage: 101}}},
+		{JobName: baselineTestJob, Disruption: []StaticDisruptionThreshold{{BackendName: baselineTestBackend, MasterNodesUpdated: "yes", Percentiles: map[int]float64{100: 1}}}},
+	}})
+	expectedErr := errors.New("invalid static baseline: [jobs[0].tests[0]: passPercentage must be between 0 and 100, not 101, jobs[1]: duplicate job " + baselineTestJob + ", jobs[1].disruption[0]: masterNodesUpdated must be Y or N, not yes, jobs[1].disruption[0]: percentile 100 is not between 1 and 99]")
+	if diff := cmp.Diff(expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+}
+
+type fakeHistoricalDataSource struct {
+	disruption  []*jobrunaggregatorapi.DisruptionHistoricalDataRow
+	alerts      []*jobrunaggregatorapi.AlertHistoricalDataRow
+	knownAlerts []*jobrunaggregatorapi.KnownAlertRow
+}
+
+func (f *fakeHistoricalDataSource) ListDisruptionHistoricalData(_ context.Context) ([]jobrunaggregatorapi.HistoricalData, error) {
+	return jobrunaggregatorapi.ConvertToHistoricalData(f.disruption), nil
+}
+
+func (f *fakeHistoricalDataSource) ListAlertHistoricalData(_ context.Context) ([]*jobrunaggregatorapi.AlertHistoricalDataRow, error) {
+	return f.alerts, nil
+}
+
+func (f *fakeHistoricalDataSource) ListAllKnownAlerts(_ context.Context) ([]*jobrunaggregatorapi.KnownAlertRow, error) {
+	return f.knownAlerts, nil
+}
+
+func (f *fakeHistoricalDataSource) ListTestSummaryByPeriod(_ context.Context, _, _ string, _, _ int) ([]jobrunaggregatorapi.TestSummaryByPeriodRow, error) {
+	return nil, nil
+}
+
+func TestSnapshotHistoricalDataSource(t *testing.T) {
+	ctx := context.Background()
+	observed := time.Date(2025, time.March, 4, 0, 0, 0, 0, time.UTC)
+	jobData := jobrunaggregatorapi.HistoricalJobData{Release: "4.19", FromRelease: "4.18", Platform: "aws", Architecture: "amd64", Network: "ovn", Topology: "ha", MasterNodesUpdated: bigquery.NullString{StringVal: "Y", Valid: true}, JobRuns: 120}
+	fake := &fakeHistoricalDataSource{
+		disruption:  []*jobrunaggregatorapi.DisruptionHistoricalDataRow{{BackendName: baselineTestBackend, HistoricalJobData: jobData, P50: "1.0", P75: "2.0", P95: "3.0", P99: "4.0"}},
+		alerts:      []*jobrunaggregatorapi.AlertHistoricalDataRow{{AlertName: "KubeAPIDown", AlertNamespace: "openshift-kube-apiserver", AlertLevel: "critical", HistoricalJobData: jobData, P50: "0.0", P75: "0.0", P95: "1.0", P99: "2.0"}},
+		knownAlerts: []*jobrunaggregatorapi.KnownAlertRow{{AlertName: "KubeAPIDown", AlertNamespace: "openshift-kube-apiserver", AlertLevel: "critical", Release: "4.19", FirstObserved: observed, LastObserved: observed.Add(24 * time.Hour), Results: 3}},
+	}
+	dir := filepath.Join(t.TempDir(), "snapshot")
+	if err := WriteHistoricalDataSnapshot(ctx, fake, dir); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	snapshot := NewSnapshotHistoricalDataSource(dir)
+
+	disruption, err := snapshot.ListDisruptionHistoricalData(ctx)
+	if err != nil {
+		t.Fatalf("failed to list disruption historical data: %v", err)
+	}
+	if diff := cmp.Diff(jobrunaggregatorapi.ConvertToHistoricalData(fake.disruption), disruption); diff != "" {
+		t.Errorf("unexpected disruption historical data: %s", diff)
+	}
+	alerts, err := snapshot.ListAlertHistoricalData(ctx)
+	if err != nil {
+		t.Fatalf("failed to list alert historical data: %v", err)
+	}
+	if diff := cmp.Diff(fake.alerts, alerts); diff != "" {
+		t.Errorf("unexpected alert historical data: %s", diff)
+	}
+	knownAlerts, err := snapshot.ListAllKnownAlerts(ctx)
+	if err != nil {
+		t.Fatalf("failed to list known alerts: %v", err)
+	}
+	if diff := cmp.Diff(fake.knownAlerts, knownAlerts); diff != "" {
+		t.Errorf("unexpected known alerts: %s", diff)
+	}
+	if _, err := snapshot.ListTestSummaryByPeriod(ctx, "openshift-tests", "4.19", 30, 100); err == nil {
+		t.Error("expected an error listing test summaries of a snapshot")
+	}
+}
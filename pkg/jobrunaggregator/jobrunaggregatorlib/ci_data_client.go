@@ -56,6 +56,15 @@ type CIDataClient interface {
 
 	// these deal with release tags
 	ListReleaseTags(ctx context.Context) (map[string]bool, error)
+	// ListTestRunCountsByReleaseTag counts the results of a test in the runs of a job since the given time
+	// by the payload the runs tested, ordered by the time the payloads were created. The payloads of every stream
+	// the job ran against are listed, with their stream.
+	ListTestRunCountsByReleaseTag(ctx context.Context, jobName, testName string, since time.Time) ([]jobrunaggregatorapi.ReleaseTagTestRunCountsRow, error)
+	// ListReleaseTagsBetween lists the payloads of the stream of toReleaseTag created after fromReleaseTag,
+	// up to and including toReleaseTag, ordered by the time they were created.
+	ListReleaseTagsBetween(ctx context.Context, fromReleaseTag, toReleaseTag string) ([]jobrunaggregatorapi.ReleaseTagRow, error)
+	// ListReleasePullRequests lists the pull requests that were first included in the given payloads.
+	ListReleasePullRequests(ctx context.Context, releaseTags []string) ([]jobrunaggregatorapi.ReleasePullRequestRow, error)
 
 	// GetLastJobRunEndTimeFromTable returns the last uploaded job runs EndTime in the given table.
 	GetLastJobRunEndTimeFromTable(ctx context.Context, table string) (*time.Time, error)
@@ -941,6 +950,153 @@ func (c *ciDataClient) ListReleases(ctx context.Context) ([]jobrunaggregatorapi.
 	return releases, nil
 }
 
+func (c *ciDataClient) ListTestRunCountsByReleaseTag(ctx context.Context, jobName, testName string, since time.Time) ([]jobrunaggregatorapi.ReleaseTagTestRunCountsRow, error) {
+	// JobRunStartTime is the partition column of the test runs, filtering on it keeps the query small.
+	queryString := c.dataCoordinates.SubstituteDataSetLocation(`
+SELECT
+  testRuns.ReleaseTag AS releaseTag,
+  MIN(tags.releaseTime) AS releaseTime,
+  tags.release AS release,
+  tags.stream AS stream,
+  tags.architecture AS architecture,
+  COUNTIF(testRuns.TestStatus = 'Passed') AS passCount,
+  COUNTIF(testRuns.TestStatus = 'Failed') AS failCount,
+  COUNTIF(testRuns.TestStatus = 'Flaked') AS flakeCount
+FROM
+  DATA_SET_LOCATION.UnifiedTestRuns AS testRuns
+JOIN
+  DATA_SET_LOCATION.ReleaseTags AS tags
+ON
+  tags.releaseTag = testRuns.ReleaseTag
+WHERE
+  testRuns.JobName = @JobName
+  AND testRuns.TestName = @TestName
+  AND testRuns.JobRunStartTime >= @Since
+GROUP BY
+  testRuns.ReleaseTag,
+  tags.release,
+  tags.stream,
+  tags.architecture
+ORDER BY
+  releaseTime ASC
+`)
+	query := c.client.Query(queryString)
+	query.Labels = map[string]string{
+		bigQueryLabelKeyApp:   bigQueryLabelValueApp,
+		bigQueryLabelKeyQuery: bigQueryLabelValueTestRunsByReleaseTag,
+	}
+	query.QueryConfig.Parameters = []bigquery.QueryParameter{
+		{Name: "JobName", Value: jobName},
+		{Name: "TestName", Value: testName},
+		{Name: "Since", Value: since},
+	}
+	it, err := query.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query test runs with %q: %w", queryString, err)
+	}
+	ret := []jobrunaggregatorapi.ReleaseTagTestRunCountsRow{}
+	for {
+		row := jobrunaggregatorapi.ReleaseTagTestRunCountsRow{}
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, row)
+	}
+
+	return ret, nil
+}
+
+func (c *ciDataClient) ListReleaseTagsBetween(ctx context.Context, fromReleaseTag, toReleaseTag string) ([]jobrunaggregatorapi.ReleaseTagRow, error) {
+	queryString := c.dataCoordinates.SubstituteDataSetLocation(`
+SELECT
+  tags.*
+FROM
+  DATA_SET_LOCATION.ReleaseTags AS tags,
+  DATA_SET_LOCATION.ReleaseTags AS fromTag,
+  DATA_SET_LOCATION.ReleaseTags AS toTag
+WHERE
+  fromTag.releaseTag = @FromReleaseTag
+  AND toTag.releaseTag = @ToReleaseTag
+  AND tags.release = toTag.release
+  AND tags.stream = toTag.stream
+  AND tags.architecture = toTag.architecture
+  AND tags.releaseTime > fromTag.releaseTime
+  AND tags.releaseTime <= toTag.releaseTime
+ORDER BY
+  tags.releaseTime ASC
+`)
+	query := c.client.Query(queryString)
+	query.Labels = map[string]string{
+		bigQueryLabelKeyApp:   bigQueryLabelValueApp,
+		bigQueryLabelKeyQuery: bigQueryLabelValueReleaseTagsBetween,
+	}
+	query.QueryConfig.Parameters = []bigquery.QueryParameter{
+		{Name: "FromReleaseTag", Value: fromReleaseTag},
+		{Name: "ToReleaseTag", Value: toReleaseTag},
+	}
+	it, err := query.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query release tags with %q: %w", queryString, err)
+	}
+	ret := []jobrunaggregatorapi.ReleaseTagRow{}
+	for {
+		row := jobrunaggregatorapi.ReleaseTagRow{}
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, row)
+	}
+
+	return ret, nil
+}
+
+func (c *ciDataClient) ListReleasePullRequests(ctx context.Context, releaseTags []string) ([]jobrunaggregatorapi.ReleasePullRequestRow, error) {
+	queryString := c.dataCoordinates.SubstituteDataSetLocation(`
+SELECT
+  *
+FROM
+  DATA_SET_LOCATION.ReleasePullRequests
+WHERE
+  releaseTag IN UNNEST(@ReleaseTags)
+ORDER BY
+  name, pullRequestID
+`)
+	query := c.client.Query(queryString)
+	query.Labels = map[string]string{
+		bigQueryLabelKeyApp:   bigQueryLabelValueApp,
+		bigQueryLabelKeyQuery: bigQueryLabelValueReleasePullRequests,
+	}
+	query.QueryConfig.Parameters = []bigquery.QueryParameter{
+		{Name: "ReleaseTags", Value: releaseTags},
+	}
+	it, err := query.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query release pull requests with %q: %w", queryString, err)
+	}
+	ret := []jobrunaggregatorapi.ReleasePullRequestRow{}
+	for {
+		row := jobrunaggregatorapi.ReleasePullRequestRow{}
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, row)
+	}
+
+	return ret, nil
+}
+
 type UnifiedTestRunRowIterator struct {
 	delegatedIterator *bigquery.RowIterator
 }
@@ -238,6 +238,21 @@ func (mr *MockCIDataClientMockRecorder) ListProwJobRunsSince(ctx, since any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProwJobRunsSince", reflect.TypeOf((*MockCIDataClient)(nil).ListProwJobRunsSince), ctx, since)
 }
 
+// ListReleasePullRequests mocks base method.
+func (m *MockCIDataClient) ListReleasePullRequests(ctx context.Context, releaseTags []string) ([]jobrunaggregatorapi.ReleasePullRequestRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReleasePullRequests", ctx, releaseTags)
+	ret0, _ := ret[0].([]jobrunaggregatorapi.ReleasePullRequestRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReleasePullRequests indicates an expected call of ListReleasePullRequests.
+func (mr *MockCIDataClientMockRecorder) ListReleasePullRequests(ctx, releaseTags any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReleasePullRequests", reflect.TypeOf((*MockCIDataClient)(nil).ListReleasePullRequests), ctx, releaseTags)
+}
+
 // ListReleaseTags mocks base method.
 func (m *MockCIDataClient) ListReleaseTags(ctx context.Context) (map[string]bool, error) {
 	m.ctrl.T.Helper()
@@ -253,6 +268,21 @@ func (mr *MockCIDataClientMockRecorder) ListReleaseTags(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReleaseTags", reflect.TypeOf((*MockCIDataClient)(nil).ListReleaseTags), ctx)
 }
 
+// ListReleaseTagsBetween mocks base method.
+func (m *MockCIDataClient) ListReleaseTagsBetween(ctx context.Context, fromReleaseTag, toReleaseTag string) ([]jobrunaggregatorapi.ReleaseTagRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReleaseTagsBetween", ctx, fromReleaseTag, toReleaseTag)
+	ret0, _ := ret[0].([]jobrunaggregatorapi.ReleaseTagRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReleaseTagsBetween indicates an expected call of ListReleaseTagsBetween.
+func (mr *MockCIDataClientMockRecorder) ListReleaseTagsBetween(ctx, fromReleaseTag, toReleaseTag any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReleaseTagsBetween", reflect.TypeOf((*MockCIDataClient)(nil).ListReleaseTagsBetween), ctx, fromReleaseTag, toReleaseTag)
+}
+
 // ListReleases mocks base method.
 func (m *MockCIDataClient) ListReleases(ctx context.Context) ([]jobrunaggregatorapi.ReleaseRow, error) {
 	m.ctrl.T.Helper()
@@ -268,6 +298,21 @@ func (mr *MockCIDataClientMockRecorder) ListReleases(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReleases", reflect.TypeOf((*MockCIDataClient)(nil).ListReleases), ctx)
 }
 
+// ListTestRunCountsByReleaseTag mocks base method.
+func (m *MockCIDataClient) ListTestRunCountsByReleaseTag(ctx context.Context, jobName, testName string, since time.Time) ([]jobrunaggregatorapi.ReleaseTagTestRunCountsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTestRunCountsByReleaseTag", ctx, jobName, testName, since)
+	ret0, _ := ret[0].([]jobrunaggregatorapi.ReleaseTagTestRunCountsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTestRunCountsByReleaseTag indicates an expected call of ListTestRunCountsByReleaseTag.
+func (mr *MockCIDataClientMockRecorder) ListTestRunCountsByReleaseTag(ctx, jobName, testName, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTestRunCountsByReleaseTag", reflect.TypeOf((*MockCIDataClient)(nil).ListTestRunCountsByReleaseTag), ctx, jobName, testName, since)
+}
+
 // ListTestSummaryByPeriod mocks base method.
 func (m *MockCIDataClient) ListTestSummaryByPeriod(ctx context.Context, suiteName, releaseName string, daysBack, minTestCount int) ([]jobrunaggregatorapi.TestSummaryByPeriodRow, error) {
 	m.ctrl.T.Helper()
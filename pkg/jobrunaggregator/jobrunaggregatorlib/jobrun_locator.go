@@ -2,6 +2,7 @@ package jobrunaggregatorlib
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -42,6 +43,7 @@ type analysisJobAggregator struct {
 	// bigquery dataset.
 	startTime time.Time
 
+	// ciDataClient is only used to find related jobs, it is nil when the job runs are passed in
 	ciDataClient  AggregationJobClient
 	ciGCSClient   CIGCSClient
 	gcsBucketName string
@@ -71,6 +73,9 @@ func NewPayloadAnalysisJobLocator(
 // FindRelatedJobs returns a slice of JobRunInfo which has info contained in GCS buckets
 // used to determine pass/fail.
 func (a *analysisJobAggregator) FindRelatedJobs(ctx context.Context) ([]jobrunaggregatorapi.JobRunInfo, error) {
+	if a.ciDataClient == nil {
+		return nil, fmt.Errorf("cannot find the job runs of %s without BigQuery, pass them as static job run identifiers", a.jobName)
+	}
 	startOfJobRunWindow := a.startTime.Add(-1 * JobSearchWindowStartOffset)
 	endOfJobRunWindow := a.startTime.Add(JobSearchWindowEndOffset)
 	startingJobRunID, err := a.ciDataClient.GetJobRunForJobNameBeforeTime(ctx, a.jobName, startOfJobRunWindow)
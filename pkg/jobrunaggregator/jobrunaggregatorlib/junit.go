@@ -1,5 +1,7 @@
 package jobrunaggregatorlib
 
+import "fmt"
+
 // TestSuitesSeparator defines the separator to use when combine multiple level of suite names
 var TestSuitesSeparator = "|||"
 
@@ -13,6 +15,30 @@ type TestCaseDetails struct {
 	Failures []TestCaseFailure
 	Skips    []TestCaseSkip
 	//NeverExecuted []TestCaseNeverExecuted
+
+	// Significance is filled in during the pass/fail calculation when there is a baseline to compare to
+	Significance *Significance `yaml:",omitempty"`
+}
+
+// Significance is the outcome of a statistical test comparing the aggregated job runs to the baseline
+type Significance struct {
+	// Test is the statistical test, like fisher-exact, binomial or mann-whitney
+	Test string
+	// PValue is the probability of results at least as bad as these if the job runs had not regressed
+	PValue float64
+	// Quantity is what the estimate and its confidence interval are about, like "pass rate"
+	Quantity string
+	Unit     string
+	Estimate float64
+	// Lower and Upper bound the confidence interval of the estimate at ConfidenceLevel
+	Lower           float64
+	Upper           float64
+	ConfidenceLevel float64
+}
+
+func (s Significance) String() string {
+	return fmt.Sprintf("%s test p=%.4g, %s %.1f%s with %.0f%% confidence interval [%.1f%s, %.1f%s]",
+		s.Test, s.PValue, s.Quantity, s.Estimate, s.Unit, 100*s.ConfidenceLevel, s.Lower, s.Unit, s.Upper, s.Unit)
 }
 
 type TestCasePass struct {
@@ -0,0 +1,332 @@
+package jobrunaggregatorlib
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/apache/arrow/go/v15/parquet"
+	"github.com/apache/arrow/go/v15/parquet/file"
+	"github.com/apache/arrow/go/v15/parquet/schema"
+)
+
+type parquetColumnKind int
+
+const (
+	parquetString parquetColumnKind = iota
+	parquetNullString
+	parquetInt
+	parquetUint
+	parquetFloat
+	parquetBool
+	parquetTime
+)
+
+// parquetColumn maps a field of a row struct to a column of a Parquet file
+type parquetColumn struct {
+	name  string
+	index []int
+	kind  parquetColumnKind
+	node  schema.Node
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	nullStringType = reflect.TypeOf(bigquery.NullString{})
+)
+
+// parquetColumns flattens the fields of the row struct, including those of embedded structs, into columns
+func parquetColumns(rowType reflect.Type, parent []int) ([]parquetColumn, error) {
+	var columns []parquetColumn
+	for i := 0; i < rowType.NumField(); i++ {
+		field := rowType.Field(i)
+		index := append(append([]int{}, parent...), i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			embedded, err := parquetColumns(field.Type, index)
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, embedded...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		column := parquetColumn{name: field.Name, index: index}
+		var err error
+		switch {
+		case field.Type == timeType:
+			column.kind = parquetTime
+			column.node, err = schema.NewPrimitiveNodeLogical(field.Name, parquet.Repetitions.Required, schema.NewTimestampLogicalType(true, schema.TimeUnitMicros), parquet.Types.Int64, -1, -1)
+		case field.Type == nullStringType:
+			column.kind = parquetNullString
+			column.node, err = schema.NewPrimitiveNodeLogical(field.Name, parquet.Repetitions.Optional, schema.StringLogicalType{}, parquet.Types.ByteArray, -1, -1)
+		case field.Type.Kind() == reflect.String:
+			column.kind = parquetString
+			column.node, err = schema.NewPrimitiveNodeLogical(field.Name, parquet.Repetitions.Required, schema.StringLogicalType{}, parquet.Types.ByteArray, -1, -1)
+		case field.Type.Kind() == reflect.Int || field.Type.Kind() == reflect.Int32 || field.Type.Kind() == reflect.Int64:
+			column.kind = parquetInt
+			column.node = schema.NewInt64Node(field.Name, parquet.Repetitions.Required, -1)
+		case field.Type.Kind() == reflect.Uint || field.Type.Kind() == reflect.Uint32 || field.Type.Kind() == reflect.Uint64:
+			column.kind = parquetUint
+			column.node = schema.NewInt64Node(field.Name, parquet.Repetitions.Required, -1)
+		case field.Type.Kind() == reflect.Float64:
+			column.kind = parquetFloat
+			column.node = schema.NewFloat64Node(field.Name, parquet.Repetitions.Required, -1)
+		case field.Type.Kind() == reflect.Bool:
+			column.kind = parquetBool
+			column.node = schema.NewBooleanNode(field.Name, parquet.Repetitions.Required, -1)
+		default:
+			return nil, fmt.Errorf("field %s of %s has unsupported type %s", field.Name, rowType.Name(), field.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create column for field %s of %s: %w", field.Name, rowType.Name(), err)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// writeParquetRows writes the rows, a slice of structs, to a Parquet file at path
+func writeParquetRows(path string, rows interface{}) (err error) {
+	slice := reflect.ValueOf(rows)
+	if slice.Kind() != reflect.Slice || slice.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("rows must be a slice of structs, not %T", rows)
+	}
+	columns, err := parquetColumns(slice.Type().Elem(), nil)
+	if err != nil {
+		return err
+	}
+	fields := make(schema.FieldList, 0, len(columns))
+	for _, column := range columns {
+		fields = append(fields, column.node)
+	}
+	root, err := schema.NewGroupNode("schema", parquet.Repetitions.Required, fields, -1)
+	if err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	writer := file.NewParquetWriter(out, root)
+	// closing the writer closes the file
+	defer func() {
+		if closeErr := writer.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	rowGroup := writer.AppendRowGroup()
+	for _, column := range columns {
+		columnWriter, err := rowGroup.NextColumn()
+		if err != nil {
+			return fmt.Errorf("failed to write column %s: %w", column.name, err)
+		}
+		if err := writeParquetColumn(columnWriter, column, slice); err != nil {
+			return fmt.Errorf("failed to write column %s: %w", column.name, err)
+		}
+		if err := columnWriter.Close(); err != nil {
+			return fmt.Errorf("failed to write column %s: %w", column.name, err)
+		}
+	}
+	if err := rowGroup.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeParquetColumn(columnWriter file.ColumnChunkWriter, column parquetColumn, rows reflect.Value) error {
+	n := rows.Len()
+	field := func(i int) reflect.Value {
+		return rows.Index(i).FieldByIndex(column.index)
+	}
+	var err error
+	switch column.kind {
+	case parquetString:
+		values := make([]parquet.ByteArray, n)
+		for i := range values {
+			values[i] = parquet.ByteArray(field(i).String())
+		}
+		_, err = columnWriter.(*file.ByteArrayColumnChunkWriter).WriteBatch(values, nil, nil)
+	case parquetNullString:
+		var values []parquet.ByteArray
+		defLevels := make([]int16, n)
+		for i := range defLevels {
+			if value := field(i).Interface().(bigquery.NullString); value.Valid {
+				values = append(values, parquet.ByteArray(value.StringVal))
+				defLevels[i] = 1
+			}
+		}
+		_, err = columnWriter.(*file.ByteArrayColumnChunkWriter).WriteBatch(values, defLevels, nil)
+	case parquetInt, parquetUint, parquetTime:
+		values := make([]int64, n)
+		for i := range values {
+			switch column.kind {
+			case parquetInt:
+				values[i] = field(i).Int()
+			case parquetUint:
+				values[i] = int64(field(i).Uint())
+			case parquetTime:
+				values[i] = field(i).Interface().(time.Time).UnixMicro()
+			}
+		}
+		_, err = columnWriter.(*file.Int64ColumnChunkWriter).WriteBatch(values, nil, nil)
+	case parquetFloat:
+		values := make([]float64, n)
+		for i := range values {
+			values[i] = field(i).Float()
+		}
+		_, err = columnWriter.(*file.Float64ColumnChunkWriter).WriteBatch(values, nil, nil)
+	case parquetBool:
+		values := make([]bool, n)
+		for i := range values {
+			values[i] = field(i).Bool()
+		}
+		_, err = columnWriter.(*file.BooleanColumnChunkWriter).WriteBatch(values, nil, nil)
+	}
+	return err
+}
+
+// readParquetRows reads the Parquet file at path into rows, a pointer to a slice of structs.
+// Columns are matched to fields by name, fields without a column are left empty.
+func readParquetRows(path string, rows interface{}) error {
+	target := reflect.ValueOf(rows)
+	if target.Kind() != reflect.Pointer || target.Elem().Kind() != reflect.Slice || target.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("rows must be a pointer to a slice of structs, not %T", rows)
+	}
+	slice := target.Elem()
+	columns, err := parquetColumns(slice.Type().Elem(), nil)
+	if err != nil {
+		return err
+	}
+
+	reader, err := file.OpenParquetFile(path, false)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer reader.Close()
+	fileSchema := reader.MetaData().Schema
+	for i := 0; i < reader.NumRowGroups(); i++ {
+		rowGroup := reader.RowGroup(i)
+		numRows := rowGroup.NumRows()
+		offset := slice.Len()
+		slice.Set(reflect.AppendSlice(slice, reflect.MakeSlice(slice.Type(), int(numRows), int(numRows))))
+		for _, column := range columns {
+			index := fileSchema.ColumnIndexByName(column.name)
+			if index < 0 {
+				continue
+			}
+			columnReader, err := rowGroup.Column(index)
+			if err != nil {
+				return fmt.Errorf("failed to read column %s of %s: %w", column.name, path, err)
+			}
+			field := func(i int) reflect.Value {
+				return slice.Index(offset + i).FieldByIndex(column.index)
+			}
+			if err := readParquetColumn(columnReader, column, numRows, field); err != nil {
+				return fmt.Errorf("failed to read column %s of %s: %w", column.name, path, err)
+			}
+		}
+	}
+	return nil
+}
+
+type parquetBatchReader[T any] interface {
+	ReadBatch(batchSize int64, values []T, defLvls, repLvls []int16) (total int64, valuesRead int, err error)
+}
+
+// readParquetBatches reads the values of the column, which only holds the values that are not null,
+// and the definition levels telling which rows are null
+func readParquetBatches[T any](reader parquetBatchReader[T], numRows int64) ([]T, []int16, error) {
+	values := make([]T, numRows)
+	defLevels := make([]int16, numRows)
+	var read int64
+	var valuesRead int
+	for read < numRows {
+		total, n, err := reader.ReadBatch(numRows-read, values[valuesRead:], defLevels[read:], nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if total == 0 {
+			return nil, nil, fmt.Errorf("expected %d rows, got %d", numRows, read)
+		}
+		read += total
+		valuesRead += n
+	}
+	return values[:valuesRead], defLevels, nil
+}
+
+func readParquetColumn(columnReader file.ColumnChunkReader, column parquetColumn, numRows int64, field func(int) reflect.Value) error {
+	switch column.kind {
+	case parquetString, parquetNullString:
+		byteArrayReader, ok := columnReader.(*file.ByteArrayColumnChunkReader)
+		if !ok {
+			return fmt.Errorf("column has type %s, expected a byte array", columnReader.Type())
+		}
+		values, defLevels, err := readParquetBatches[parquet.ByteArray](byteArrayReader, numRows)
+		if err != nil {
+			return err
+		}
+		if column.kind == parquetString {
+			for i, value := range values {
+				field(i).SetString(string(value))
+			}
+			return nil
+		}
+		next := 0
+		for i, level := range defLevels {
+			if level == 0 {
+				continue
+			}
+			field(i).Set(reflect.ValueOf(bigquery.NullString{StringVal: string(values[next]), Valid: true}))
+			next++
+		}
+	case parquetInt, parquetUint, parquetTime:
+		int64Reader, ok := columnReader.(*file.Int64ColumnChunkReader)
+		if !ok {
+			return fmt.Errorf("column has type %s, expected int64", columnReader.Type())
+		}
+		values, _, err := readParquetBatches[int64](int64Reader, numRows)
+		if err != nil {
+			return err
+		}
+		for i, value := range values {
+			switch column.kind {
+			case parquetInt:
+				field(i).SetInt(value)
+			case parquetUint:
+				field(i).SetUint(uint64(value))
+			case parquetTime:
+				field(i).Set(reflect.ValueOf(time.UnixMicro(value).UTC()))
+			}
+		}
+	case parquetFloat:
+		float64Reader, ok := columnReader.(*file.Float64ColumnChunkReader)
+		if !ok {
+			return fmt.Errorf("column has type %s, expected double", columnReader.Type())
+		}
+		values, _, err := readParquetBatches[float64](float64Reader, numRows)
+		if err != nil {
+			return err
+		}
+		for i, value := range values {
+			field(i).SetFloat(value)
+		}
+	case parquetBool:
+		boolReader, ok := columnReader.(*file.BooleanColumnChunkReader)
+		if !ok {
+			return fmt.Errorf("column has type %s, expected boolean", columnReader.Type())
+		}
+		values, _, err := readParquetBatches[bool](boolReader, numRows)
+		if err != nil {
+			return err
+		}
+		for i, value := range values {
+			field(i).SetBool(value)
+		}
+	}
+	return nil
+}
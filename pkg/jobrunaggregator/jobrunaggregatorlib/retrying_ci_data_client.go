@@ -115,6 +115,36 @@ func (c *retryingCIDataClient) ListReleaseTags(ctx context.Context) (map[string]
 	return ret, err
 }
 
+func (c *retryingCIDataClient) ListTestRunCountsByReleaseTag(ctx context.Context, jobName, testName string, since time.Time) ([]jobrunaggregatorapi.ReleaseTagTestRunCountsRow, error) {
+	var ret []jobrunaggregatorapi.ReleaseTagTestRunCountsRow
+	err := retry.OnError(slowBackoff, isReadQuotaError, func() error {
+		var innerErr error
+		ret, innerErr = c.delegate.ListTestRunCountsByReleaseTag(ctx, jobName, testName, since)
+		return innerErr
+	})
+	return ret, err
+}
+
+func (c *retryingCIDataClient) ListReleaseTagsBetween(ctx context.Context, fromReleaseTag, toReleaseTag string) ([]jobrunaggregatorapi.ReleaseTagRow, error) {
+	var ret []jobrunaggregatorapi.ReleaseTagRow
+	err := retry.OnError(slowBackoff, isReadQuotaError, func() error {
+		var innerErr error
+		ret, innerErr = c.delegate.ListReleaseTagsBetween(ctx, fromReleaseTag, toReleaseTag)
+		return innerErr
+	})
+	return ret, err
+}
+
+func (c *retryingCIDataClient) ListReleasePullRequests(ctx context.Context, releaseTags []string) ([]jobrunaggregatorapi.ReleasePullRequestRow, error) {
+	var ret []jobrunaggregatorapi.ReleasePullRequestRow
+	err := retry.OnError(slowBackoff, isReadQuotaError, func() error {
+		var innerErr error
+		ret, innerErr = c.delegate.ListReleasePullRequests(ctx, releaseTags)
+		return innerErr
+	})
+	return ret, err
+}
+
 func (c *retryingCIDataClient) ListReleases(ctx context.Context) ([]jobrunaggregatorapi.ReleaseRow, error) {
 	var ret []jobrunaggregatorapi.ReleaseRow
 	err := retry.OnError(slowBackoff, isReadQuotaError, func() error {
@@ -52,6 +52,9 @@ const (
 	bigQueryLabelValueReleaseTags              = "aggregator-release-tags"
 	bigQueryLabelValueJobRunIDsSinceTime       = "aggregator-job-run-ids-since-time"
 	bigQueryLabelValueTestSummaryByPeriod      = "aggregator-test-summary-by-period"
+	bigQueryLabelValueTestRunsByReleaseTag     = "aggregator-test-runs-by-release-tag"
+	bigQueryLabelValueReleaseTagsBetween       = "aggregator-release-tags-between"
+	bigQueryLabelValueReleasePullRequests      = "aggregator-release-pull-requests"
 )
 
 var (
@@ -12,7 +12,7 @@ import (
 )
 
 type JobRunHistoricalDataAnalyzerOptions struct {
-	ciDataClient    jobrunaggregatorlib.CIDataClient
+	dataSource      jobrunaggregatorlib.HistoricalDataSource
 	outputFile      string
 	newFile         string
 	currentFile     string
@@ -65,7 +65,7 @@ func (o *JobRunHistoricalDataAnalyzerOptions) Run(ctx context.Context) error {
 			return fmt.Errorf("failed while attempting to read Alert Historical Data: %w", err)
 		}
 	case o.newFile == "" && o.dataType == "disruptions":
-		newHistoricalData, err = o.ciDataClient.ListDisruptionHistoricalData(ctx)
+		newHistoricalData, err = o.dataSource.ListDisruptionHistoricalData(ctx)
 		if err != nil {
 			return err
 		}
@@ -122,7 +122,7 @@ func (o *JobRunHistoricalDataAnalyzerOptions) runTestsDataType(ctx context.Conte
 	}
 
 	// Fetch new test data from BigQuery
-	testSummaries, err := o.ciDataClient.ListTestSummaryByPeriod(ctx, suiteName, release, daysBack, minTestCount)
+	testSummaries, err := o.dataSource.ListTestSummaryByPeriod(ctx, suiteName, release, daysBack, minTestCount)
 	if err != nil {
 		return fmt.Errorf("failed to list test summary by period: %w", err)
 	}
@@ -175,11 +175,11 @@ func (o *JobRunHistoricalDataAnalyzerOptions) getAlertData(ctx context.Context)
 	var allKnownAlerts []*jobrunaggregatorapi.KnownAlertRow
 	var newHistoricalData []*jobrunaggregatorapi.AlertHistoricalDataRow
 
-	newHistoricalData, err := o.ciDataClient.ListAlertHistoricalData(ctx)
+	newHistoricalData, err := o.dataSource.ListAlertHistoricalData(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list alert historical data: %w", err)
 	}
-	allKnownAlerts, err = o.ciDataClient.ListAllKnownAlerts(ctx)
+	allKnownAlerts, err = o.dataSource.ListAllKnownAlerts(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list all known alerts: %w", err)
 	}
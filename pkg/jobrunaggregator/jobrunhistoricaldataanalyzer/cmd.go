@@ -17,13 +17,15 @@ type JobRunHistoricalDataAnalyzerFlags struct {
 	DataCoordinates *jobrunaggregatorlib.BigQueryDataCoordinates
 	Authentication  *jobrunaggregatorlib.GoogleAuthenticationFlags
 
-	NewFile         string
-	CurrentFile     string
-	DataType        string
-	Leeway          float64
-	OutputFile      string
-	TargetRelease   string
-	PreviousRelease string
+	NewFile          string
+	CurrentFile      string
+	DataType         string
+	Leeway           float64
+	OutputFile       string
+	TargetRelease    string
+	PreviousRelease  string
+	BaselineSource   string
+	BaselineLocation string
 }
 
 var supportedDataTypes = sets.New[string]("alerts", "disruptions", "tests")
@@ -46,20 +48,40 @@ func (f *JobRunHistoricalDataAnalyzerFlags) BindFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&f.TargetRelease, "target-release", f.TargetRelease, "override for release to generate data for, omit to use the most recent release. Be sure to checkout the correct branch for --current.")
 	fs.StringVar(&f.PreviousRelease, "previous-release", f.PreviousRelease, "override for previous release to generate data when we do not have enough for target release. Must be specified if using --target-release.")
 	fs.Float64Var(&f.Leeway, "leeway", f.Leeway, "percent leeway threshold for increased time diff")
+	fs.StringVar(&f.BaselineSource, "baseline-source", jobrunaggregatorlib.BaselineSourceBigQuery, fmt.Sprintf("source of the new historical data when --new is not set %s", sets.List(jobrunaggregatorlib.KnownHistoricalDataSources)))
+	fs.StringVar(&f.BaselineLocation, "baseline-location", f.BaselineLocation, "the directory written by snapshot-baselines --historical-data, for --baseline-source=snapshot")
+}
+
+// needsBigQuery determines whether the new historical data is read from BigQuery
+func (f *JobRunHistoricalDataAnalyzerFlags) needsBigQuery() bool {
+	// test summaries are always queried, --new only replaces the alert and disruption data
+	return f.BaselineSource == jobrunaggregatorlib.BaselineSourceBigQuery && (f.NewFile == "" || f.DataType == "tests")
 }
 
 func (f *JobRunHistoricalDataAnalyzerFlags) Validate() error {
-	if err := f.DataCoordinates.Validate(); err != nil && f.NewFile == "" {
-		return err
-	}
-	if err := f.Authentication.Validate(); err != nil && f.NewFile == "" {
-		return err
+	if f.needsBigQuery() {
+		if err := f.DataCoordinates.Validate(); err != nil {
+			return err
+		}
+		if err := f.Authentication.Validate(); err != nil {
+			return err
+		}
 	}
 
 	if !supportedDataTypes.Has(f.DataType) {
 		return fmt.Errorf("must provide supported datatype %v", sets.List(supportedDataTypes))
 	}
 
+	if !jobrunaggregatorlib.KnownHistoricalDataSources.Has(f.BaselineSource) {
+		return fmt.Errorf("unknown baseline source %s, valid values are: %+q", f.BaselineSource, sets.List(jobrunaggregatorlib.KnownHistoricalDataSources))
+	}
+	if err := jobrunaggregatorlib.ValidateBaselineSource(f.BaselineSource, f.BaselineLocation); err != nil {
+		return err
+	}
+	if f.DataType == "tests" && f.BaselineSource != jobrunaggregatorlib.BaselineSourceBigQuery {
+		return fmt.Errorf("test summaries can only be read from --baseline-source=%s", jobrunaggregatorlib.BaselineSourceBigQuery)
+	}
+
 	// For tests data type, we don't need --current since we don't do comparison
 	if f.DataType != "tests" && f.CurrentFile == "" {
 		return fmt.Errorf("must provide --current [file_path] flag to compare against")
@@ -77,21 +99,27 @@ func (f *JobRunHistoricalDataAnalyzerFlags) Validate() error {
 }
 
 func (f *JobRunHistoricalDataAnalyzerFlags) ToOptions(ctx context.Context) (*JobRunHistoricalDataAnalyzerOptions, error) {
-	bigQueryClient, err := f.Authentication.NewBigQueryClient(ctx, f.DataCoordinates.ProjectID)
-	if err != nil && f.NewFile == "" {
+	var ciDataClient jobrunaggregatorlib.CIDataClient
+	if f.needsBigQuery() {
+		bigQueryClient, err := f.Authentication.NewBigQueryClient(ctx, f.DataCoordinates.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		ciDataClient = jobrunaggregatorlib.NewRetryingCIDataClient(
+			jobrunaggregatorlib.NewCIDataClient(*f.DataCoordinates, bigQueryClient),
+		)
+	}
+	dataSource, err := jobrunaggregatorlib.NewHistoricalDataSource(f.BaselineSource, f.BaselineLocation, ciDataClient)
+	if err != nil {
 		return nil, err
 	}
 
-	ciDataClient := jobrunaggregatorlib.NewRetryingCIDataClient(
-		jobrunaggregatorlib.NewCIDataClient(*f.DataCoordinates, bigQueryClient),
-	)
-
 	if f.OutputFile == "" {
 		f.OutputFile = fmt.Sprintf("results_%s.json", f.DataType)
 	}
 
 	return &JobRunHistoricalDataAnalyzerOptions{
-		ciDataClient:    ciDataClient,
+		dataSource:      dataSource,
 		newFile:         f.NewFile,
 		currentFile:     f.CurrentFile,
 		leeway:          f.Leeway,
@@ -22,12 +22,26 @@ const (
 	leasedState = "leased"
 )
 
-type boskosClient interface {
+// Backend holds the state of all resources and hands them out to a single
+// owner. Resources move between states: leasing one moves it from `free` to
+// `leased`, releasing it moves it back. The Boskos client is the canonical
+// implementation, NewFileBackend and NewConfigMapBackend are in-process
+// implementations for installations without a Boskos server.
+type Backend interface {
+	// AcquireWaitWithPriority moves a resource of the type from `state` to
+	// `dest`, blocking until one is available. Requests are served in the
+	// order they were made in.
 	AcquireWaitWithPriority(ctx context.Context, rtype, state, dest, requestID string) (*common.Resource, error)
+	// Acquire moves a resource of the type from `state` to `dest`, returning
+	// ErrNotFound if none is available right away.
 	Acquire(rtype, state, dest string) (*common.Resource, error)
+	// UpdateOne refreshes a resource held by the owner, keeping the lease alive.
 	UpdateOne(name, dest string, _ *common.UserData) error
+	// ReleaseOne returns a resource held by the owner in the `dest` state.
 	ReleaseOne(name, dest string) error
+	// ReleaseAll returns all resources held by the owner in the `dest` state.
 	ReleaseAll(dest string) error
+	// Metric counts resources of the type by state.
 	Metric(rtype string) (common.Metric, error)
 }
 
@@ -43,7 +57,8 @@ type Metrics struct {
 }
 
 type clientOptions struct {
-	randID func() string
+	randID     func() string
+	prioritize func(Backend) Backend
 }
 
 type ClientOptions func(*clientOptions)
@@ -52,6 +67,14 @@ func WithRandID(randID func() string) ClientOptions {
 	return func(o *clientOptions) { o.randID = randID }
 }
 
+// WithPriority orders the requests of the client against those of other
+// clients by the class of job and the organization, see NewPrioritizedBackend.
+func WithPriority(class, org string, policy PriorityPolicy) ClientOptions {
+	return func(o *clientOptions) {
+		o.prioritize = func(backend Backend) Backend { return NewPrioritizedBackend(backend, class, org, policy) }
+	}
+}
+
 // Client manages resource leases, acquiring, releasing, and keeping them
 // updated.
 type Client interface {
@@ -85,10 +108,11 @@ func NewClient(owner, url, username string, passwordGetter func() []byte, retrie
 		return nil, err
 	}
 	c.DistinguishNotFoundVsTypeNotFound = true
-	return newClient(c, retries, acquireTimeout, opts...), nil
+	return NewClientWithBackend(c, retries, acquireTimeout, opts...), nil
 }
 
-func newClient(boskos boskosClient, retries int, acquireTimeout time.Duration, opts ...ClientOptions) Client {
+// NewClientWithBackend creates a client that leases resources from the backend.
+func NewClientWithBackend(backend Backend, retries int, acquireTimeout time.Duration, opts ...ClientOptions) Client {
 	defOpts := &clientOptions{
 		randID: func() string {
 			return strconv.Itoa(rand.Int())
@@ -98,10 +122,13 @@ func newClient(boskos boskosClient, retries int, acquireTimeout time.Duration, o
 	for _, f := range opts {
 		f(defOpts)
 	}
+	if defOpts.prioritize != nil {
+		backend = defOpts.prioritize(backend)
+	}
 
 	return &client{
 		opts:           defOpts,
-		boskos:         boskos,
+		backend:        backend,
 		retries:        retries,
 		acquireTimeout: acquireTimeout,
 		leases:         make(map[string]*lease),
@@ -111,7 +138,7 @@ func newClient(boskos boskosClient, retries int, acquireTimeout time.Duration, o
 type client struct {
 	sync.RWMutex
 	opts           *clientOptions
-	boskos         boskosClient
+	backend        Backend
 	retries        int
 	acquireTimeout time.Duration
 	leases         map[string]*lease
@@ -133,7 +160,7 @@ func (c *client) Acquire(rtype string, n uint, ctx context.Context, cancel conte
 	var ret []string
 	// TODO `m` processes may fight for the last `m * n` remaining leases
 	for i := uint(0); i < n; i++ {
-		r, err := c.boskos.AcquireWaitWithPriority(ctx, rtype, freeState, leasedState, c.opts.randID())
+		r, err := c.backend.AcquireWaitWithPriority(ctx, rtype, freeState, leasedState, c.opts.randID())
 		if err != nil {
 			return nil, err
 		}
@@ -148,7 +175,7 @@ func (c *client) Acquire(rtype string, n uint, ctx context.Context, cancel conte
 func (c *client) AcquireIfAvailableImmediately(rtype string, n uint, cancel context.CancelFunc) ([]string, error) {
 	var ret []string
 	for i := uint(0); i < n; i++ {
-		r, err := c.boskos.Acquire(rtype, freeState, leasedState)
+		r, err := c.backend.Acquire(rtype, freeState, leasedState)
 		if err != nil {
 			return nil, err
 		}
@@ -165,7 +192,7 @@ func (c *client) Heartbeat() error {
 	defer c.Unlock()
 	var errs []error
 	for name, lease := range c.leases {
-		err := c.boskos.UpdateOne(name, leasedState, nil)
+		err := c.backend.UpdateOne(name, leasedState, nil)
 		if err == nil {
 			c.leases[name].updateFailures = 0
 			continue
@@ -185,7 +212,7 @@ func (c *client) Heartbeat() error {
 func (c *client) Release(name string) error {
 	c.Lock()
 	defer c.Unlock()
-	if err := c.boskos.ReleaseOne(name, freeState); err != nil {
+	if err := c.backend.ReleaseOne(name, freeState); err != nil {
 		return err
 	}
 	delete(c.leases, name)
@@ -199,7 +226,7 @@ func (c *client) ReleaseAll() ([]string, error) {
 	var errs []error
 	for l := range c.leases {
 		ret = append(ret, l)
-		if err := c.boskos.ReleaseOne(l, freeState); err != nil {
+		if err := c.backend.ReleaseOne(l, freeState); err != nil {
 			errs = append(errs, err)
 			continue
 		}
@@ -209,7 +236,7 @@ func (c *client) ReleaseAll() ([]string, error) {
 }
 
 func (c *client) Metrics(rtype string) (Metrics, error) {
-	metrics, err := c.boskos.Metric(rtype)
+	metrics, err := c.backend.Metric(rtype)
 	if err != nil {
 		return Metrics{}, err
 	}
@@ -24,7 +24,7 @@ func NewFakeClient(owner, url string, retries int, failures map[string]error, ca
 	if resources == nil {
 		resources = make(map[string]*common.Resource)
 	}
-	return newClient(&fakeClient{
+	return NewClientWithBackend(&fakeClient{
 		owner:     owner,
 		failures:  failures,
 		calls:     calls,
@@ -0,0 +1,525 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/boskos/common"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LocalStateKey is the key in the ConfigMap that holds the state of an
+	// in-process backend
+	LocalStateKey = "state.json"
+
+	defaultLeaseExpiry  = 30 * time.Minute
+	defaultPollInterval = 10 * time.Second
+)
+
+// LocalState is the persisted state of an in-process backend. Administrators
+// seed it with the resources to hand out, in the `free` state.
+type LocalState struct {
+	Resources []common.Resource `json:"resources"`
+	// Requests holds the clients waiting for a resource, which are served by
+	// priority, fair share between organizations and the time they started
+	// waiting, in that order
+	Requests []LocalRequest `json:"requests,omitempty"`
+	// PriorityClasses ranks the classes of waiting requests, requests in a
+	// class with a higher priority are served first. Defaults to the classes
+	// the backend is configured with.
+	PriorityClasses map[string]int `json:"priorityClasses,omitempty"`
+	// Orgs records the organization of owners holding resources, used to share
+	// resources of a type fairly between organizations
+	Orgs map[string]string `json:"orgs,omitempty"`
+}
+
+// LocalRequest is a client waiting for a resource of a type.
+type LocalRequest struct {
+	ID    string    `json:"id"`
+	Type  string    `json:"type"`
+	Class string    `json:"class,omitempty"`
+	Org   string    `json:"org,omitempty"`
+	Since time.Time `json:"since"`
+	// LastSeen is refreshed while the client is waiting, requests from clients
+	// that stopped waiting are dropped
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// localStore persists the state of an in-process backend
+type localStore interface {
+	// read loads the current state
+	read(ctx context.Context) (*LocalState, error)
+	// update loads the state, mutates it and persists it atomically; nothing is
+	// persisted when the mutation fails
+	update(ctx context.Context, mutate func(*LocalState) error) error
+}
+
+type localBackend struct {
+	owner        string
+	class        string
+	org          string
+	priorities   map[string]int
+	store        localStore
+	leaseExpiry  time.Duration
+	pollInterval time.Duration
+	now          func() time.Time
+	report       func(rtype string, position, waiting int)
+}
+
+// LocalBackendOption configures an in-process backend.
+type LocalBackendOption func(*localBackend)
+
+// WithLeaseExpiry sets the time after which leases that were not updated are
+// considered abandoned and their resources handed out again.
+func WithLeaseExpiry(expiry time.Duration) LocalBackendOption {
+	return func(b *localBackend) { b.leaseExpiry = expiry }
+}
+
+// WithPollInterval sets how often waiting clients check for free resources.
+func WithPollInterval(interval time.Duration) LocalBackendOption {
+	return func(b *localBackend) { b.pollInterval = interval }
+}
+
+// WithRequester sets the class of job, e.g. `presubmit`, and the organization
+// resources are requested for. Waiting requests are ordered by the priority of
+// their class and then by the number of resources of the type already held by
+// their organization, so no organization starves the others.
+func WithRequester(class, org string) LocalBackendOption {
+	return func(b *localBackend) { b.class, b.org = class, org }
+}
+
+// WithPriorityClasses ranks the classes of requests when the state does not
+// configure priority classes. Defaults to DefaultPriorityClasses.
+func WithPriorityClasses(classes map[string]int) LocalBackendOption {
+	return func(b *localBackend) { b.priorities = classes }
+}
+
+// WithQueuePositionReporter sets a function called whenever the position of a
+// waiting request in the queue for a type changes, along with the number of
+// requests waiting for the type. The request at position 0 is served next.
+func WithQueuePositionReporter(report func(rtype string, position, waiting int)) LocalBackendOption {
+	return func(b *localBackend) { b.report = report }
+}
+
+func newLocalBackend(owner string, store localStore, opts ...LocalBackendOption) Backend {
+	b := &localBackend{
+		owner:        owner,
+		store:        store,
+		priorities:   DefaultPriorityClasses,
+		leaseExpiry:  defaultLeaseExpiry,
+		pollInterval: defaultPollInterval,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// NewFileBackend creates a backend that keeps the state of resources in a
+// local file, shared between processes on the same machine.
+func NewFileBackend(owner, path string, opts ...LocalBackendOption) Backend {
+	return newLocalBackend(owner, &fileStore{path: path}, opts...)
+}
+
+// NewConfigMapBackend creates a backend that keeps the state of resources in
+// a ConfigMap, shared between all clients with access to it.
+func NewConfigMapBackend(owner string, client ctrlruntimeclient.Client, namespace, name string, opts ...LocalBackendOption) Backend {
+	return newLocalBackend(owner, &configMapStore{client: client, key: ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}}, opts...)
+}
+
+// candidates reclaims expired leases on resources of the type and returns the
+// indices of those in the state, least recently used first
+func (b *localBackend) candidates(state *LocalState, rtype, from string) ([]int, error) {
+	now := b.now()
+	var candidates []int
+	typeExists := false
+	for i := range state.Resources {
+		r := &state.Resources[i]
+		if r.Type != rtype {
+			continue
+		}
+		typeExists = true
+		if r.Owner != "" && now.Sub(r.LastUpdate) > b.leaseExpiry {
+			logrus.Warnf("Lease on resource %s by %s expired, reclaiming it.", r.Name, r.Owner)
+			r.State, r.Owner, r.LastUpdate = freeState, "", now
+		}
+		if r.State == from {
+			candidates = append(candidates, i)
+		}
+	}
+	if !typeExists {
+		return nil, ErrTypeNotFound
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return state.Resources[candidates[i]].LastUpdate.Before(state.Resources[candidates[j]].LastUpdate)
+	})
+	return candidates, nil
+}
+
+// acquire hands out the least recently used resource of the type in the state
+func (b *localBackend) acquire(state *LocalState, rtype, from, dest string) (*common.Resource, error) {
+	candidates, err := b.candidates(state, rtype, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNotFound
+	}
+	r := &state.Resources[candidates[0]]
+	r.State, r.Owner, r.LastUpdate = dest, b.owner, b.now()
+	if b.org != "" {
+		if state.Orgs == nil {
+			state.Orgs = map[string]string{}
+		}
+		state.Orgs[b.owner] = b.org
+	}
+	pruneOrgs(state)
+	acquired := *r
+	return &acquired, nil
+}
+
+// pruneOrgs forgets the organizations of owners no longer holding resources
+func pruneOrgs(state *LocalState) {
+	holding := sets.New[string]()
+	for _, r := range state.Resources {
+		holding.Insert(r.Owner)
+	}
+	for owner := range state.Orgs {
+		if !holding.Has(owner) {
+			delete(state.Orgs, owner)
+		}
+	}
+}
+
+// queue orders the requests waiting for resources of the type: by priority
+// of their class, then by the resources of the type their organization holds,
+// then by the time they started waiting
+func queue(state *LocalState, rtype string, defaultPriorities map[string]int) []LocalRequest {
+	priorities := state.PriorityClasses
+	if priorities == nil {
+		priorities = defaultPriorities
+	}
+	held := map[string]int{}
+	for _, r := range state.Resources {
+		if r.Type == rtype && r.Owner != "" {
+			held[state.Orgs[r.Owner]]++
+		}
+	}
+	var requests []LocalRequest
+	for _, request := range state.Requests {
+		if request.Type == rtype {
+			requests = append(requests, request)
+		}
+	}
+	sort.SliceStable(requests, func(i, j int) bool {
+		if pi, pj := priorities[requests[i].Class], priorities[requests[j].Class]; pi != pj {
+			return pi > pj
+		}
+		if hi, hj := held[requests[i].Org], held[requests[j].Org]; hi != hj {
+			return hi < hj
+		}
+		if !requests[i].Since.Equal(requests[j].Since) {
+			return requests[i].Since.Before(requests[j].Since)
+		}
+		return requests[i].ID < requests[j].ID
+	})
+	return requests
+}
+
+// waiting counts the requests for resources of the type whose clients are
+// still waiting
+func (b *localBackend) waiting(state *LocalState, rtype string) int {
+	now := b.now()
+	waiting := 0
+	for _, request := range state.Requests {
+		if request.Type == rtype && now.Sub(request.LastSeen) <= 3*b.pollInterval {
+			waiting++
+		}
+	}
+	return waiting
+}
+
+// Acquire hands out a resource without waiting. Requests that are already
+// waiting for resources of the type are served first, so only resources
+// beyond those they will take are handed out.
+func (b *localBackend) Acquire(rtype, state, dest string) (*common.Resource, error) {
+	var acquired *common.Resource
+	var notFound bool
+	err := b.store.update(context.Background(), func(s *LocalState) error {
+		candidates, err := b.candidates(s, rtype, state)
+		if err != nil {
+			return err
+		}
+		if len(candidates) <= b.waiting(s, rtype) {
+			// leases reclaimed while looking for a resource are persisted
+			notFound = true
+			return nil
+		}
+		acquired, err = b.acquire(s, rtype, state, dest)
+		return err
+	})
+	if err == nil && notFound {
+		return nil, ErrNotFound
+	}
+	return acquired, err
+}
+
+func (b *localBackend) AcquireWaitWithPriority(ctx context.Context, rtype, state, dest, requestID string) (*common.Resource, error) {
+	lastPosition := -1
+	for {
+		var acquired *common.Resource
+		position, waiting := 0, 0
+		err := b.store.update(ctx, func(s *LocalState) error {
+			now := b.now()
+			var requests []LocalRequest
+			found := false
+			for _, request := range s.Requests {
+				if request.ID == requestID {
+					request.LastSeen, found = now, true
+				} else if now.Sub(request.LastSeen) > 3*b.pollInterval {
+					continue
+				}
+				requests = append(requests, request)
+			}
+			if !found {
+				requests = append(requests, LocalRequest{ID: requestID, Type: rtype, Class: b.class, Org: b.org, Since: now, LastSeen: now})
+			}
+			s.Requests = requests
+
+			candidates, err := b.candidates(s, rtype, state)
+			if err != nil {
+				return err
+			}
+			queued := queue(s, rtype, b.priorities)
+			waiting = len(queued)
+			position = slices.IndexFunc(queued, func(request LocalRequest) bool { return request.ID == requestID })
+			if position >= len(candidates) {
+				// requests ahead of us take all free resources
+				return nil
+			}
+			if acquired, err = b.acquire(s, rtype, state, dest); err != nil {
+				return err
+			}
+			s.Requests = removeRequest(s.Requests, requestID)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if acquired != nil {
+			return acquired, nil
+		}
+		if position != lastPosition && b.report != nil {
+			b.report(rtype, position, waiting)
+		}
+		lastPosition = position
+		select {
+		case <-ctx.Done():
+			if err := b.store.update(context.Background(), func(s *LocalState) error {
+				s.Requests = removeRequest(s.Requests, requestID)
+				return nil
+			}); err != nil {
+				logrus.WithError(err).Warnf("Failed to remove request %s.", requestID)
+			}
+			return nil, ctx.Err()
+		case <-time.After(b.pollInterval):
+		}
+	}
+}
+
+func removeRequest(requests []LocalRequest, id string) []LocalRequest {
+	var ret []LocalRequest
+	for _, request := range requests {
+		if request.ID != id {
+			ret = append(ret, request)
+		}
+	}
+	return ret
+}
+
+// owned finds a resource held by the owner of the backend
+func (b *localBackend) owned(state *LocalState, name string) (*common.Resource, error) {
+	for i := range state.Resources {
+		if r := &state.Resources[i]; r.Name == name {
+			if r.Owner != b.owner {
+				return nil, fmt.Errorf("resource %s is owned by %q, not %q", name, r.Owner, b.owner)
+			}
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("resource %s does not exist", name)
+}
+
+func (b *localBackend) UpdateOne(name, dest string, _ *common.UserData) error {
+	return b.store.update(context.Background(), func(s *LocalState) error {
+		r, err := b.owned(s, name)
+		if err != nil {
+			return err
+		}
+		r.State, r.LastUpdate = dest, b.now()
+		return nil
+	})
+}
+
+func (b *localBackend) ReleaseOne(name, dest string) error {
+	return b.store.update(context.Background(), func(s *LocalState) error {
+		r, err := b.owned(s, name)
+		if err != nil {
+			return err
+		}
+		r.State, r.Owner, r.LastUpdate = dest, "", b.now()
+		pruneOrgs(s)
+		return nil
+	})
+}
+
+func (b *localBackend) ReleaseAll(dest string) error {
+	return b.store.update(context.Background(), func(s *LocalState) error {
+		for i := range s.Resources {
+			if r := &s.Resources[i]; r.Owner == b.owner {
+				r.State, r.Owner, r.LastUpdate = dest, "", b.now()
+			}
+		}
+		pruneOrgs(s)
+		return nil
+	})
+}
+
+func (b *localBackend) Metric(rtype string) (common.Metric, error) {
+	metric := common.NewMetric(rtype)
+	state, err := b.store.read(context.Background())
+	if err != nil {
+		return metric, err
+	}
+	for _, r := range state.Resources {
+		if r.Type != rtype {
+			continue
+		}
+		metric.Current[r.State]++
+		metric.Owners[r.Owner]++
+	}
+	return metric, nil
+}
+
+// fileStore keeps the state in a file, using an advisory lock on a sibling
+// file to serialize updates from multiple processes
+type fileStore struct {
+	path string
+}
+
+func (s *fileStore) read(context.Context) (*LocalState, error) {
+	state := &LocalState{}
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease state: %w", err)
+	}
+	if err := json.Unmarshal(raw, state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lease state: %w", err)
+	}
+	return state, nil
+}
+
+func (s *fileStore) update(ctx context.Context, mutate func(*LocalState) error) error {
+	lock, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer func() {
+		if err := lock.Close(); err != nil {
+			logrus.WithError(err).Warn("Failed to close lock file.")
+		}
+	}()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("failed to lock lease state: %w", err)
+	}
+	defer func() {
+		if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_UN); err != nil {
+			logrus.WithError(err).Warn("Failed to unlock lease state.")
+		}
+	}()
+
+	state, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	if err := mutate(state); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lease state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write lease state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write lease state: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// configMapStore keeps the state in a ConfigMap, using optimistic
+// concurrency to serialize updates from multiple clients
+type configMapStore struct {
+	client ctrlruntimeclient.Client
+	key    ctrlruntimeclient.ObjectKey
+}
+
+func (s *configMapStore) get(ctx context.Context) (*coreapi.ConfigMap, *LocalState, error) {
+	cm := &coreapi.ConfigMap{}
+	if err := s.client.Get(ctx, s.key, cm); err != nil {
+		return nil, nil, fmt.Errorf("failed to get lease state %s: %w", s.key, err)
+	}
+	state := &LocalState{}
+	if raw, ok := cm.Data[LocalStateKey]; ok {
+		if err := json.Unmarshal([]byte(raw), state); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal lease state %s: %w", s.key, err)
+		}
+	}
+	return cm, state, nil
+}
+
+func (s *configMapStore) read(ctx context.Context) (*LocalState, error) {
+	_, state, err := s.get(ctx)
+	return state, err
+}
+
+func (s *configMapStore) update(ctx context.Context, mutate func(*LocalState) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, state, err := s.get(ctx)
+		if err != nil {
+			return err
+		}
+		if err := mutate(state); err != nil {
+			return err
+		}
+		raw, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("failed to marshal lease state: %w", err)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[LocalStateKey] = string(raw)
+		return s.client.Update(ctx, cm)
+	})
+}
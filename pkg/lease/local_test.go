@@ -0,0 +1,383 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/boskos/common"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func seedState(t *testing.T, resources ...common.Resource) []byte {
+	raw, err := json.Marshal(LocalState{Resources: resources})
+	if err != nil {
+		t.Fatalf("failed to marshal state: %v", err)
+	}
+	return raw
+}
+
+func freeResource(name, rtype string) common.Resource {
+	return common.Resource{Name: name, Type: rtype, State: freeState}
+}
+
+// localBackends creates the in-process backends for a set of owners sharing state
+func localBackends(t *testing.T, resources ...common.Resource) map[string]func(owner string, opts ...LocalBackendOption) Backend {
+	return map[string]func(owner string, opts ...LocalBackendOption) Backend{
+		"file": func() func(string, ...LocalBackendOption) Backend {
+			path := filepath.Join(t.TempDir(), "state.json")
+			if err := os.WriteFile(path, seedState(t, resources...), 0644); err != nil {
+				t.Fatalf("failed to write state: %v", err)
+			}
+			return func(owner string, opts ...LocalBackendOption) Backend {
+				return NewFileBackend(owner, path, opts...)
+			}
+		}(),
+		"configmap": func() func(string, ...LocalBackendOption) Backend {
+			client := fakectrlruntimeclient.NewClientBuilder().WithObjects(&coreapi.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "leases"},
+				Data:       map[string]string{LocalStateKey: string(seedState(t, resources...))},
+			}).Build()
+			return func(owner string, opts ...LocalBackendOption) Backend {
+				return NewConfigMapBackend(owner, client, "ci", "leases", opts...)
+			}
+		}(),
+	}
+}
+
+func TestLocalBackendLifecycle(t *testing.T) {
+	for name, newBackend := range localBackends(t, freeResource("a", "aws"), freeResource("b", "aws"), freeResource("g", "gcp")) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			first := NewClientWithBackend(newBackend("first"), 0, time.Minute)
+			second := NewClientWithBackend(newBackend("second"), 0, time.Minute)
+
+			leased, err := first.Acquire("aws", 1, ctx, func() {})
+			if err != nil {
+				t.Fatalf("failed to acquire: %v", err)
+			}
+			if _, err := second.AcquireIfAvailableImmediately("aws", 1, func() {}); err != nil {
+				t.Fatalf("failed to acquire: %v", err)
+			}
+			if _, err := second.AcquireIfAvailableImmediately("aws", 1, func() {}); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected %v when all resources are leased, got %v", ErrNotFound, err)
+			}
+			if _, err := second.AcquireIfAvailableImmediately("azure", 1, func() {}); !errors.Is(err, ErrTypeNotFound) {
+				t.Errorf("expected %v for unknown type, got %v", ErrTypeNotFound, err)
+			}
+			metrics, err := first.Metrics("aws")
+			if err != nil {
+				t.Fatalf("failed to get metrics: %v", err)
+			}
+			if diff := cmp.Diff(Metrics{Leased: 2}, metrics); diff != "" {
+				t.Errorf("unexpected metrics: %s", diff)
+			}
+			if err := first.Heartbeat(); err != nil {
+				t.Errorf("failed to heartbeat: %v", err)
+			}
+			if err := second.Release(leased[0]); err == nil {
+				t.Error("expected releasing a resource leased by another owner to fail")
+			}
+			if err := first.Release(leased[0]); err != nil {
+				t.Errorf("failed to release: %v", err)
+			}
+			if released, err := second.ReleaseAll(); err != nil || len(released) != 1 {
+				t.Errorf("expected to release one lease, got %v: %v", released, err)
+			}
+			metrics, err = first.Metrics("aws")
+			if err != nil {
+				t.Fatalf("failed to get metrics: %v", err)
+			}
+			if diff := cmp.Diff(Metrics{Free: 2}, metrics); diff != "" {
+				t.Errorf("unexpected metrics: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLocalBackendExpiredLeases(t *testing.T) {
+	for name, newBackend := range localBackends(t, freeResource("a", "aws")) {
+		t.Run(name, func(t *testing.T) {
+			abandoned := newBackend("abandoned")
+			if _, err := abandoned.Acquire("aws", freeState, leasedState); err != nil {
+				t.Fatalf("failed to acquire: %v", err)
+			}
+			if _, err := newBackend("other").Acquire("aws", freeState, leasedState); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected %v while the lease is active, got %v", ErrNotFound, err)
+			}
+			reclaiming := newBackend("other", WithLeaseExpiry(-time.Second))
+			r, err := reclaiming.Acquire("aws", freeState, leasedState)
+			if err != nil {
+				t.Fatalf("expected expired lease to be reclaimed, got %v", err)
+			}
+			if r.Owner != "other" {
+				t.Errorf("expected resource to be owned by the new owner, got %q", r.Owner)
+			}
+			if err := abandoned.UpdateOne("a", leasedState, nil); err == nil {
+				t.Error("expected update of a reclaimed lease to fail")
+			}
+		})
+	}
+}
+
+func TestLocalBackendPersistsReclaimedLeasesWhenNoneAvailable(t *testing.T) {
+	for name, newBackend := range localBackends(t, freeResource("a", "aws")) {
+		t.Run(name, func(t *testing.T) {
+			abandoned := newBackend("abandoned")
+			if _, err := abandoned.Acquire("aws", freeState, leasedState); err != nil {
+				t.Fatalf("failed to acquire: %v", err)
+			}
+			// the reclaimed resource is free, but not in the requested state
+			if _, err := newBackend("other", WithLeaseExpiry(-time.Second)).Acquire("aws", "dirty", leasedState); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected %v, got %v", ErrNotFound, err)
+			}
+			metric, err := newBackend("other").Metric("aws")
+			if err != nil {
+				t.Fatalf("failed to get metrics: %v", err)
+			}
+			if metric.Current[freeState] != 1 || metric.Owners["abandoned"] != 0 {
+				t.Errorf("expected the reclaimed lease to be persisted, got %#v", metric)
+			}
+			if err := abandoned.UpdateOne("a", leasedState, nil); err == nil {
+				t.Error("expected update of a reclaimed lease to fail")
+			}
+		})
+	}
+}
+
+func TestLocalBackendWaitsInOrder(t *testing.T) {
+	for name, newBackend := range localBackends(t, freeResource("a", "aws")) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			holder := newBackend("holder")
+			if _, err := holder.Acquire("aws", freeState, leasedState); err != nil {
+				t.Fatalf("failed to acquire: %v", err)
+			}
+
+			first, second := newBackend("first", WithPollInterval(10*time.Millisecond)), newBackend("second", WithPollInterval(10*time.Millisecond))
+			acquired := make(chan string, 2)
+			waitFor := func(backend Backend, id string) {
+				r, err := backend.AcquireWaitWithPriority(ctx, "aws", freeState, leasedState, id)
+				if err != nil {
+					t.Errorf("failed to acquire: %v", err)
+					return
+				}
+				acquired <- r.Owner
+				time.Sleep(50 * time.Millisecond)
+				if err := backend.ReleaseOne(r.Name, freeState); err != nil {
+					t.Errorf("failed to release: %v", err)
+				}
+			}
+			go waitFor(first, "1")
+			time.Sleep(50 * time.Millisecond)
+			go waitFor(second, "2")
+			time.Sleep(50 * time.Millisecond)
+			if err := holder.ReleaseOne("a", freeState); err != nil {
+				t.Fatalf("failed to release: %v", err)
+			}
+			var order []string
+			for range 2 {
+				select {
+				case owner := <-acquired:
+					order = append(order, owner)
+				case <-time.After(10 * time.Second):
+					t.Fatal("timed out waiting for leases")
+				}
+			}
+			if diff := cmp.Diff([]string{"first", "second"}, order); diff != "" {
+				t.Errorf("requests were not served in order: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLocalBackendAcquireServesWaitingRequestsFirst(t *testing.T) {
+	for name, newBackend := range localBackends(t, freeResource("a", "aws")) {
+		t.Run(name, func(t *testing.T) {
+			backend := newBackend("other")
+			queueRequest := func(lastSeen time.Time) {
+				if err := backend.(*localBackend).store.update(context.Background(), func(s *LocalState) error {
+					s.Requests = []LocalRequest{{ID: "1", Type: "aws", Since: lastSeen, LastSeen: lastSeen}}
+					return nil
+				}); err != nil {
+					t.Fatalf("failed to queue request: %v", err)
+				}
+			}
+			queueRequest(time.Now())
+			if _, err := backend.Acquire("aws", freeState, leasedState); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected %v while a request is waiting, got %v", ErrNotFound, err)
+			}
+			queueRequest(time.Now().Add(-time.Hour))
+			if _, err := backend.Acquire("aws", freeState, leasedState); err != nil {
+				t.Errorf("expected a request that stopped waiting to be ignored, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalBackendWaitCancelled(t *testing.T) {
+	for name, newBackend := range localBackends(t, common.Resource{Name: "a", Type: "aws", State: leasedState, Owner: "holder", LastUpdate: time.Now()}) {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			backend := newBackend("waiting", WithPollInterval(10*time.Millisecond))
+			if _, err := backend.AcquireWaitWithPriority(ctx, "aws", freeState, leasedState, "1"); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected the wait to time out, got %v", err)
+			}
+			state, err := backend.(*localBackend).store.read(context.Background())
+			if err != nil {
+				t.Fatalf("failed to read state: %v", err)
+			}
+			if len(state.Requests) != 0 {
+				t.Errorf("expected cancelled request to be removed, got %v", state.Requests)
+			}
+		})
+	}
+}
+
+func TestQueue(t *testing.T) {
+	now := time.Now()
+	held := func(name, owner string) common.Resource {
+		return common.Resource{Name: name, Type: "aws", State: leasedState, Owner: owner}
+	}
+	testCases := []struct {
+		name       string
+		state      LocalState
+		priorities map[string]int
+		expected   []string
+	}{
+		{
+			name: "requests in the same class are served in order",
+			state: LocalState{Requests: []LocalRequest{
+				{ID: "second", Type: "aws", Since: now.Add(time.Second)},
+				{ID: "first", Type: "aws", Since: now},
+				{ID: "other", Type: "gcp", Since: now},
+			}},
+			expected: []string{"first", "second"},
+		},
+		{
+			name: "presubmits are served before postsubmits and periodics",
+			state: LocalState{Requests: []LocalRequest{
+				{ID: "periodic", Type: "aws", Class: "periodic", Since: now},
+				{ID: "postsubmit", Type: "aws", Class: "postsubmit", Since: now.Add(time.Second)},
+				{ID: "presubmit", Type: "aws", Class: "presubmit", Since: now.Add(2 * time.Second)},
+			}},
+			expected: []string{"presubmit", "postsubmit", "periodic"},
+		},
+		{
+			name: "priority classes are configurable",
+			state: LocalState{
+				PriorityClasses: map[string]int{"periodic": 10},
+				Requests: []LocalRequest{
+					{ID: "presubmit", Type: "aws", Class: "presubmit", Since: now},
+					{ID: "periodic", Type: "aws", Class: "periodic", Since: now.Add(time.Second)},
+				},
+			},
+			expected: []string{"periodic", "presubmit"},
+		},
+		{
+			name:       "priority classes of the backend apply when the state does not configure them",
+			priorities: map[string]int{"postsubmit": 10},
+			state: LocalState{Requests: []LocalRequest{
+				{ID: "presubmit", Type: "aws", Class: "presubmit", Since: now},
+				{ID: "postsubmit", Type: "aws", Class: "postsubmit", Since: now.Add(time.Second)},
+			}},
+			expected: []string{"postsubmit", "presubmit"},
+		},
+		{
+			name: "organizations holding fewer resources are served first",
+			state: LocalState{
+				Resources: []common.Resource{held("a", "busy-1"), held("b", "busy-2"), held("c", "quiet-1")},
+				Orgs:      map[string]string{"busy-1": "busy", "busy-2": "busy", "quiet-1": "quiet"},
+				Requests: []LocalRequest{
+					{ID: "busy", Type: "aws", Class: "presubmit", Org: "busy", Since: now},
+					{ID: "quiet", Type: "aws", Class: "presubmit", Org: "quiet", Since: now.Add(time.Second)},
+					{ID: "idle", Type: "aws", Class: "presubmit", Org: "idle", Since: now.Add(2 * time.Second)},
+					{ID: "periodic", Type: "aws", Class: "periodic", Org: "idle", Since: now},
+				},
+			},
+			expected: []string{"idle", "quiet", "busy", "periodic"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			priorities := DefaultPriorityClasses
+			if tc.priorities != nil {
+				priorities = tc.priorities
+			}
+			var ids []string
+			for _, request := range queue(&tc.state, "aws", priorities) {
+				ids = append(ids, request.ID)
+			}
+			if diff := cmp.Diff(tc.expected, ids); diff != "" {
+				t.Errorf("unexpected queue: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLocalBackendWaitsByPriority(t *testing.T) {
+	for name, newBackend := range localBackends(t, freeResource("a", "aws")) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			holder := newBackend("holder")
+			if _, err := holder.Acquire("aws", freeState, leasedState); err != nil {
+				t.Fatalf("failed to acquire: %v", err)
+			}
+
+			positions := make(chan int, 10)
+			periodic := newBackend("periodic", WithPollInterval(10*time.Millisecond), WithRequester("periodic", "org"), WithQueuePositionReporter(func(rtype string, position, waiting int) {
+				positions <- position
+			}))
+			presubmit := newBackend("presubmit", WithPollInterval(10*time.Millisecond), WithRequester("presubmit", "org"))
+			acquired := make(chan string, 2)
+			waitFor := func(backend Backend, id string) {
+				r, err := backend.AcquireWaitWithPriority(ctx, "aws", freeState, leasedState, id)
+				if err != nil {
+					t.Errorf("failed to acquire: %v", err)
+					return
+				}
+				acquired <- r.Owner
+				time.Sleep(50 * time.Millisecond)
+				if err := backend.ReleaseOne(r.Name, freeState); err != nil {
+					t.Errorf("failed to release: %v", err)
+				}
+			}
+			go waitFor(periodic, "1")
+			time.Sleep(50 * time.Millisecond)
+			go waitFor(presubmit, "2")
+			time.Sleep(50 * time.Millisecond)
+			if err := holder.ReleaseOne("a", freeState); err != nil {
+				t.Fatalf("failed to release: %v", err)
+			}
+			var order []string
+			for range 2 {
+				select {
+				case owner := <-acquired:
+					order = append(order, owner)
+				case <-time.After(10 * time.Second):
+					t.Fatal("timed out waiting for leases")
+				}
+			}
+			if diff := cmp.Diff([]string{"presubmit", "periodic"}, order); diff != "" {
+				t.Errorf("requests were not served by priority: %s", diff)
+			}
+			close(positions)
+			var reported []int
+			for position := range positions {
+				reported = append(reported, position)
+			}
+			if diff := cmp.Diff([]int{0, 1, 0}, reported); diff != "" {
+				t.Errorf("unexpected queue positions reported: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package lease
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/boskos/common"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+const (
+	// ownerOrgSeparator separates the organization a lease is held for from
+	// the rest of the owner name; GitHub organizations never contain it
+	ownerOrgSeparator = "@"
+
+	defaultPriorityDeferral = 5 * time.Minute
+)
+
+// DefaultPriorityClasses ranks requests by the type of job making them when
+// priority classes are not configured: presubmits block humans and are served
+// first, periodics last. Unknown classes have the lowest priority.
+var DefaultPriorityClasses = map[string]int{
+	string(prowapi.PresubmitJob):  3,
+	string(prowapi.BatchJob):      3,
+	string(prowapi.PostsubmitJob): 2,
+	string(prowapi.PeriodicJob):   1,
+}
+
+// ParsePriorityClasses parses priority classes in the `class=priority,...`
+// form, e.g. `presubmit=3,periodic=1`.
+func ParsePriorityClasses(raw string) (map[string]int, error) {
+	classes := map[string]int{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		class, value, ok := strings.Cut(item, "=")
+		if !ok || class == "" {
+			return nil, fmt.Errorf("invalid priority class %q, expected <class>=<priority>", item)
+		}
+		priority, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority for class %s: %w", class, err)
+		}
+		classes[class] = priority
+	}
+	return classes, nil
+}
+
+// OwnerForOrg records the organization resources are leased for in the name
+// of the owner, so that clients of a Boskos server can determine how many
+// resources each organization holds.
+func OwnerForOrg(owner, org string) string {
+	if org == "" {
+		return owner
+	}
+	return owner + ownerOrgSeparator + org
+}
+
+// orgFromOwner determines the organization recorded by OwnerForOrg
+func orgFromOwner(owner string) string {
+	if i := strings.LastIndex(owner, ownerOrgSeparator); i != -1 {
+		return owner[i+len(ownerOrgSeparator):]
+	}
+	return ""
+}
+
+// PriorityPolicy configures how requests for resources from a Boskos server
+// are ordered. Boskos serves waiting requests in the order they were made in,
+// so requests with a lower priority defer joining its queue while resources
+// are scarce, which lets requests with a higher priority made in the meantime
+// be served first. This is a best effort on the side of the client: Boskos
+// neither orders its queue by priority nor exposes the position of requests
+// in it, and once deferred requests joined the queue they are served in order
+// with everyone else.
+type PriorityPolicy struct {
+	// Classes ranks the classes of requests, requests in a class with a
+	// higher priority are served first. Defaults to DefaultPriorityClasses.
+	Classes map[string]int
+	// Deferral is how long a request waits for every priority level it is
+	// below the highest one, and once more when its organization holds its
+	// fair share of the resources, before joining the queue regardless.
+	Deferral time.Duration
+	// ReportDeferral is called with how long a request was deferred when it
+	// joins the queue, if it was deferred at all.
+	ReportDeferral func(rtype string, deferred time.Duration)
+}
+
+type prioritizedBackend struct {
+	Backend
+	class        string
+	org          string
+	policy       PriorityPolicy
+	pollInterval time.Duration
+	now          func() time.Time
+}
+
+// NewPrioritizedBackend orders the requests of a class of job, e.g.
+// `presubmit`, for an organization against those of other clients of the
+// backend. The owner of the backend must be created with OwnerForOrg.
+func NewPrioritizedBackend(backend Backend, class, org string, policy PriorityPolicy) Backend {
+	if policy.Classes == nil {
+		policy.Classes = DefaultPriorityClasses
+	}
+	if policy.Deferral == 0 {
+		policy.Deferral = defaultPriorityDeferral
+	}
+	return &prioritizedBackend{
+		Backend:      backend,
+		class:        class,
+		org:          org,
+		policy:       policy,
+		pollInterval: defaultPollInterval,
+		now:          time.Now,
+	}
+}
+
+// deferrals determines for how many levels the request is deferred: one for
+// every priority level below the highest one, and one when the organization
+// holds at least its fair share of the leased resources of the type
+func (b *prioritizedBackend) deferrals(metric common.Metric) int {
+	highest := 0
+	for _, priority := range b.policy.Classes {
+		highest = max(highest, priority)
+	}
+	levels := max(highest-b.policy.Classes[b.class], 0)
+
+	held := map[string]int{}
+	leased := 0
+	for owner, count := range metric.Owners {
+		if owner == "" || count == 0 {
+			continue
+		}
+		held[orgFromOwner(owner)] += count
+		leased += count
+	}
+	if _, holding := held[b.org]; !holding {
+		held[b.org] = 0
+	}
+	if b.org != "" && len(held) > 1 && held[b.org] > 0 && held[b.org]*len(held) >= leased {
+		levels++
+	}
+	return levels
+}
+
+func (b *prioritizedBackend) AcquireWaitWithPriority(ctx context.Context, rtype, state, dest, requestID string) (*common.Resource, error) {
+	start := b.now()
+	deferred := false
+	for {
+		metric, err := b.Backend.Metric(rtype)
+		if err != nil {
+			logrus.WithError(err).Debugf("Could not determine the state of %s resources, requesting one regardless.", rtype)
+			break
+		}
+		levels := b.deferrals(metric)
+		if levels == 0 || metric.Current[state] > 0 || b.now().Sub(start) >= time.Duration(levels)*b.policy.Deferral {
+			break
+		}
+		deferred = true
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(b.pollInterval):
+		}
+	}
+	if deferred && b.policy.ReportDeferral != nil {
+		b.policy.ReportDeferral(rtype, b.now().Sub(start))
+	}
+	return b.Backend.AcquireWaitWithPriority(ctx, rtype, state, dest, requestID)
+}
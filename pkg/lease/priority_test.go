@@ -0,0 +1,150 @@
+package lease
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"sigs.k8s.io/boskos/common"
+)
+
+// metricBackend serves metrics in sequence and records when requests join
+// the queue of the server
+type metricBackend struct {
+	Backend
+	lock    sync.Mutex
+	metrics []common.Metric
+	polls   int
+	joined  int
+}
+
+func (b *metricBackend) Metric(string) (common.Metric, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	metric := b.metrics[min(b.polls, len(b.metrics)-1)]
+	b.polls++
+	return metric, nil
+}
+
+func (b *metricBackend) AcquireWaitWithPriority(context.Context, string, string, string, string) (*common.Resource, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.joined = b.polls
+	return &common.Resource{Name: "a"}, nil
+}
+
+func TestParsePriorityClasses(t *testing.T) {
+	classes, err := ParsePriorityClasses("presubmit=3, periodic=1,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]int{"presubmit": 3, "periodic": 1}, classes); diff != "" {
+		t.Errorf("unexpected classes: %s", diff)
+	}
+	for _, invalid := range []string{"presubmit", "=3", "presubmit=high"} {
+		if _, err := ParsePriorityClasses(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestOwnerForOrg(t *testing.T) {
+	if owner := OwnerForOrg("ci-op-abc-123", "openshift"); orgFromOwner(owner) != "openshift" {
+		t.Errorf("expected organization to be recorded in %q", owner)
+	}
+	if owner := OwnerForOrg("ci-op-abc-123", ""); owner != "ci-op-abc-123" || orgFromOwner(owner) != "" {
+		t.Errorf("expected owner without organization to be unchanged, got %q", owner)
+	}
+}
+
+func TestPrioritizedBackend(t *testing.T) {
+	scarce := func(owners map[string]int) common.Metric {
+		return common.Metric{Current: map[string]int{leasedState: 2}, Owners: owners}
+	}
+	available := common.Metric{Current: map[string]int{freeState: 1, leasedState: 1}, Owners: map[string]int{"ci-op-a@other": 1}}
+	testCases := []struct {
+		name     string
+		class    string
+		org      string
+		deferral time.Duration
+		metrics  []common.Metric
+		expected int
+	}{
+		{
+			name:     "highest priority joins the queue right away",
+			class:    "presubmit",
+			org:      "openshift",
+			deferral: time.Hour,
+			metrics:  []common.Metric{scarce(map[string]int{"ci-op-a@other": 2})},
+			expected: 1,
+		},
+		{
+			name:     "lower priority defers while resources are scarce",
+			class:    "periodic",
+			org:      "openshift",
+			deferral: time.Hour,
+			metrics:  []common.Metric{scarce(map[string]int{"ci-op-a@other": 2}), scarce(map[string]int{"ci-op-a@other": 2}), available},
+			expected: 3,
+		},
+		{
+			name:     "lower priority joins the queue once deferred for long enough",
+			class:    "periodic",
+			org:      "openshift",
+			deferral: time.Millisecond,
+			metrics:  []common.Metric{scarce(map[string]int{"ci-op-a@other": 2})},
+		},
+		{
+			name:     "organization holding its fair share defers",
+			class:    "presubmit",
+			org:      "openshift",
+			deferral: time.Hour,
+			metrics:  []common.Metric{scarce(map[string]int{"ci-op-a@openshift": 1, "ci-op-b@other": 1}), available},
+			expected: 2,
+		},
+		{
+			name:     "organization holding less than its fair share does not defer",
+			class:    "presubmit",
+			org:      "openshift",
+			deferral: time.Hour,
+			metrics:  []common.Metric{scarce(map[string]int{"ci-op-a@openshift": 1, "ci-op-b@other": 2})},
+			expected: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := &metricBackend{metrics: tc.metrics}
+			var reported []string
+			policy := PriorityPolicy{Deferral: tc.deferral, ReportDeferral: func(rtype string, _ time.Duration) { reported = append(reported, rtype) }}
+			prioritized := NewPrioritizedBackend(backend, tc.class, tc.org, policy).(*prioritizedBackend)
+			prioritized.pollInterval = time.Millisecond
+			if _, err := prioritized.AcquireWaitWithPriority(context.Background(), "aws", freeState, leasedState, "1"); err != nil {
+				t.Fatalf("failed to acquire: %v", err)
+			}
+			if tc.expected != 0 && backend.joined != tc.expected {
+				t.Errorf("expected to join the queue after %d polls, joined after %d", tc.expected, backend.joined)
+			}
+			if tc.expected == 0 && backend.joined == 0 {
+				t.Error("expected to join the queue")
+			}
+			if deferred := backend.joined > 1 || tc.expected == 0; deferred != (len(reported) == 1) {
+				t.Errorf("expected the deferral to be reported: %v, got %v", deferred, reported)
+			}
+		})
+	}
+}
+
+func TestPrioritizedBackendCancelled(t *testing.T) {
+	backend := &metricBackend{metrics: []common.Metric{{Current: map[string]int{leasedState: 1}, Owners: map[string]int{"ci-op-a": 1}}}}
+	prioritized := NewPrioritizedBackend(backend, "periodic", "", PriorityPolicy{Deferral: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := prioritized.AcquireWaitWithPriority(ctx, "aws", freeState, leasedState, "1"); err == nil {
+		t.Error("expected cancelled request to fail")
+	}
+	if backend.joined != 0 {
+		t.Error("expected cancelled request not to join the queue")
+	}
+}
@@ -39,6 +39,7 @@ type registryAgent struct {
 	clusterProfiles api.ClusterProfilesMap
 	documentation   map[string]string
 	metadata        api.RegistryMetadata
+	versions        registry.Versions
 }
 
 var registryReloadTimeMetric = prometheus.NewHistogram(
@@ -61,6 +62,9 @@ type RegistryAgentOptions struct {
 	// from the filepath. Defaults to true.
 	FlatRegistry            *bool
 	UniversalSymlinkWatcher *UniversalSymlinkWatcher
+	// Versions loads the registry versions tests pin components to. Pinned
+	// components cannot be resolved without it.
+	Versions registry.Versions
 }
 
 type RegistryAgentOption func(*RegistryAgentOptions)
@@ -71,6 +75,12 @@ func WithRegistryMetrics(m *prometheus.CounterVec) RegistryAgentOption {
 	}
 }
 
+func WithRegistryVersions(v registry.Versions) RegistryAgentOption {
+	return func(o *RegistryAgentOptions) {
+		o.Versions = v
+	}
+}
+
 func WithRegistryFlat(v bool) RegistryAgentOption {
 	return func(o *RegistryAgentOptions) {
 		o.FlatRegistry = &v
@@ -99,6 +109,7 @@ func NewRegistryAgent(registryPath string, errCh chan error, opts ...RegistryAge
 		lock:         &sync.RWMutex{},
 		errorMetrics: opt.ErrorMetric,
 		flags:        flags,
+		versions:     opt.Versions,
 	}
 	// Load config once so we fail early if that doesn't work and are ready as soon as we return
 	if err := a.loadRegistry(); err != nil {
@@ -172,7 +183,7 @@ func (a *registryAgent) loadRegistry() error {
 		a.documentation = documentation
 		a.metadata = metadata
 		a.clusterProfiles = clusterProfiles
-		a.resolver = registry.NewResolver(references, chains, workflows, observers, clusterProfiles)
+		a.resolver = registry.NewVersionedResolver(references, chains, workflows, observers, clusterProfiles, a.versions)
 		a.generation++
 		return time.Since(startTime), nil
 	}()
@@ -80,6 +80,10 @@ func Registry(root string, flags RegistryFlag) (registry.ReferenceByName, regist
 		if filepath.Ext(info.Name()) == ".md" || info.Name() == "OWNERS" {
 			return nil
 		}
+		// the named versions of the registry are loaded by RegistryVersions
+		if info.Name() == RegistryVersionsFile && filepath.Dir(path) == filepath.Clean(root) {
+			return nil
+		}
 		raw, err := gzip.ReadFileMaybeGZIP(path)
 		if err != nil {
 			return err
@@ -0,0 +1,215 @@
+package load
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// RegistryVersionsFile lists the named versions of the registry, at its root
+const RegistryVersionsFile = "versions.yaml"
+
+// RegistryVersions loads the named versions of the registry. A registry without
+// named versions can still be pinned to by commit.
+func RegistryVersions(root string) (api.RegistryVersionsConfig, error) {
+	var config api.RegistryVersionsConfig
+	raw, err := os.ReadFile(filepath.Join(root, RegistryVersionsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read registry versions: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(raw, &config); err != nil {
+		return config, fmt.Errorf("failed to load registry versions: %w", err)
+	}
+	return config, nil
+}
+
+var commitRegex = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+type registrySnapshot struct {
+	references registry.ReferenceByName
+	chains     registry.ChainByName
+	workflows  registry.WorkflowByName
+}
+
+type gitRegistryVersions struct {
+	root  string
+	flags RegistryFlag
+
+	lock      sync.Mutex
+	snapshots map[string]registrySnapshot
+	// commits maps the commits versions refer to onto the full commits
+	commits map[string]string
+	// config holds the named versions, read again when versions.yaml changes
+	config     api.RegistryVersionsConfig
+	configStat os.FileInfo
+}
+
+// NewGitRegistryVersions loads versions of the registry at root from the history
+// of the git repository holding it. Loaded versions and resolved commits are kept
+// in memory, as the content of a commit does not change.
+func NewGitRegistryVersions(root string, flags RegistryFlag) registry.Versions {
+	return &gitRegistryVersions{
+		root:      root,
+		flags:     flags &^ (RegistryMetadata | RegistryDocumentation),
+		snapshots: map[string]registrySnapshot{},
+		commits:   map[string]string{},
+	}
+}
+
+func (v *gitRegistryVersions) Registry(version string) (registry.ReferenceByName, registry.ChainByName, registry.WorkflowByName, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	commit, err := v.commit(version)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if snapshot, ok := v.snapshots[commit]; ok {
+		return snapshot.references, snapshot.chains, snapshot.workflows, nil
+	}
+	snapshot, err := v.load(commit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	v.snapshots[commit] = snapshot
+	return snapshot.references, snapshot.chains, snapshot.workflows, nil
+}
+
+func (v *gitRegistryVersions) Deprecation(version string) string {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	config, err := v.versionsConfig()
+	if err != nil {
+		return ""
+	}
+	named, _ := config.Version(version)
+	return named.Deprecated
+}
+
+// versionsConfig returns the named versions, reading versions.yaml only when it changed
+func (v *gitRegistryVersions) versionsConfig() (api.RegistryVersionsConfig, error) {
+	stat, err := os.Stat(filepath.Join(v.root, RegistryVersionsFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return api.RegistryVersionsConfig{}, fmt.Errorf("failed to read registry versions: %w", err)
+	}
+	if stat != nil && v.configStat != nil && stat.ModTime().Equal(v.configStat.ModTime()) && stat.Size() == v.configStat.Size() {
+		return v.config, nil
+	}
+	config, err := RegistryVersions(v.root)
+	if err != nil {
+		return config, err
+	}
+	v.config, v.configStat = config, stat
+	return config, nil
+}
+
+// commit determines the full commit of the version, which is either named or a commit itself
+func (v *gitRegistryVersions) commit(version string) (string, error) {
+	config, err := v.versionsConfig()
+	if err != nil {
+		return "", err
+	}
+	commit := version
+	if named, ok := config.Version(version); ok {
+		commit = named.Commit
+	} else if !commitRegex.MatchString(version) {
+		return "", fmt.Errorf("unknown registry version %s", version)
+	}
+	if full, ok := v.commits[commit]; ok {
+		return full, nil
+	}
+	out, err := git(v.root, "rev-parse", "--verify", "--quiet", commit+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("commit %s of registry version %s does not exist", commit, version)
+	}
+	full := strings.TrimSpace(string(out))
+	v.commits[commit] = full
+	return full, nil
+}
+
+// load extracts the registry at the commit into a temporary directory to load it
+func (v *gitRegistryVersions) load(commit string) (registrySnapshot, error) {
+	toplevel, err := git(v.root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return registrySnapshot{}, err
+	}
+	prefix, err := git(v.root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return registrySnapshot{}, err
+	}
+	archive, err := git(strings.TrimSpace(string(toplevel)), "archive", "--format=tar", fmt.Sprintf("%s:%s", commit, strings.TrimSpace(string(prefix))))
+	if err != nil {
+		return registrySnapshot{}, err
+	}
+	dir, err := os.MkdirTemp("", "registry-"+commit)
+	if err != nil {
+		return registrySnapshot{}, fmt.Errorf("failed to create directory for the registry: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := untar(archive, dir); err != nil {
+		return registrySnapshot{}, fmt.Errorf("failed to extract the registry at %s: %w", commit, err)
+	}
+	references, chains, workflows, _, _, _, _, err := Registry(dir, v.flags)
+	if err != nil {
+		return registrySnapshot{}, fmt.Errorf("failed to load the registry at %s: %w", commit, err)
+	}
+	return registrySnapshot{references: references, chains: chains, workflows: workflows}, nil
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("'%s' failed with error=%w, output:\n%s", cmd.Args, err, stderr.String())
+	}
+	return out, nil
+}
+
+func untar(archive []byte, dir string) error {
+	reader := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, filepath.Clean("/"+header.Name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			raw, err := io.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, raw, 0644); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package load
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestGitRegistryVersions(t *testing.T) {
+	source, err := filepath.Abs("../../test/multistage-registry/registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := t.TempDir()
+	setup := exec.Command("sh", "-ec", `
+git init --quiet .
+git config user.name test
+git config user.email test
+git config commit.gpgsign false
+mkdir ci-operator
+cp -r "$1" ci-operator/step-registry
+git add .
+git commit --quiet -m initial
+echo 'openshift-cluster install --fips' > ci-operator/step-registry/ipi/install/install/ipi-install-install-commands.sh
+printf 'versions:\n- name: v1\n  commit: %s\n  deprecated: use v2, v1 does not install FIPS clusters\n- name: v2\n  commit: "0000000"\n' "$(git rev-parse HEAD)" > ci-operator/step-registry/versions.yaml
+git add .
+git commit --quiet -m fips
+`, "sh", source)
+	setup.Dir = repo
+	if out, err := setup.CombinedOutput(); err != nil {
+		t.Fatalf("%q failed, output:\n%s", setup.Args, out)
+	}
+	rev := exec.Command("git", "rev-parse", "--short", "HEAD")
+	rev.Dir = repo
+	head, err := rev.Output()
+	if err != nil {
+		t.Fatalf("git rev-parse HEAD: %v", err)
+	}
+
+	root := filepath.Join(repo, "ci-operator", "step-registry")
+	if _, _, _, _, _, _, _, err := Registry(root, 0); err != nil {
+		t.Fatalf("failed to load the registry with versions: %v", err)
+	}
+	versions := NewGitRegistryVersions(root, 0)
+
+	testCases := []struct {
+		name        string
+		version     string
+		expected    string
+		expectedErr error
+	}{
+		{name: "named version", version: "v1", expected: "openshift-cluster install\n"},
+		{name: "commit", version: strings.TrimSpace(string(head)), expected: "openshift-cluster install --fips\n"},
+		{name: "unknown version", version: "v3", expectedErr: errors.New("unknown registry version v3")},
+		{name: "missing commit", version: "v2", expectedErr: errors.New("commit 0000000 of registry version v2 does not exist")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			refs, _, _, err := versions.Registry(tc.version)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, refs["ipi-install-install"].Commands); diff != "" {
+				t.Errorf("unexpected commands: %s", diff)
+			}
+		})
+	}
+
+	if diff := cmp.Diff("use v2, v1 does not install FIPS clusters", versions.Deprecation("v1")); diff != "" {
+		t.Errorf("unexpected deprecation: %s", diff)
+	}
+	if deprecation := versions.Deprecation("v2"); deprecation != "" {
+		t.Errorf("expected v2 not to be deprecated, got %q", deprecation)
+	}
+
+	// resolved commits are cached, the repository is not consulted again
+	if err := os.Rename(filepath.Join(repo, ".git"), filepath.Join(repo, "git")); err != nil {
+		t.Fatalf("failed to hide the repository: %v", err)
+	}
+	if _, _, _, err := versions.Registry("v1"); err != nil {
+		t.Errorf("expected the cached version to resolve, got: %v", err)
+	}
+	// a changed versions.yaml is read again
+	v1 := versions.(*gitRegistryVersions).config.Versions[0]
+	if err := os.WriteFile(filepath.Join(root, RegistryVersionsFile), []byte(fmt.Sprintf("versions:\n- name: v3\n  commit: %s\n", v1.Commit)), 0644); err != nil {
+		t.Fatalf("failed to update versions: %v", err)
+	}
+	if _, _, _, err := versions.Registry("v3"); err != nil {
+		t.Errorf("expected the added version to resolve, got: %v", err)
+	}
+	if _, _, _, err := versions.Registry("v1"); err == nil {
+		t.Error("expected the removed version not to resolve")
+	}
+}
@@ -0,0 +1,222 @@
+package manifestpusher
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/content/local"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/reference"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/containerd/errdefs"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/kubernetes/pkg/credentialprovider"
+)
+
+const (
+	signatureTagSuffix   = ".sig"
+	attestationTagSuffix = ".att"
+)
+
+// CopyOptions configure how an image is copied
+type CopyOptions struct {
+	// PinDigest refuses to move target tags which point to a different digest
+	PinDigest bool
+	// Signatures copies the sigstore signatures of the image, when they exist
+	Signatures bool
+	// Attestations copies the sigstore attestations of the image, when they exist
+	Attestations bool
+}
+
+// ImageCopier copies images, including manifest lists, between registries
+type ImageCopier interface {
+	// CopyImage copies the source image with all the content it references to each of
+	// the targets and returns its digest. Manifests are copied unchanged, so the digest
+	// in the targets is the one of the source.
+	CopyImage(ctx context.Context, source string, targets []string, options CopyOptions) (string, error)
+}
+
+// NewImageCopier creates a copier pulling from the registry of the build cluster and pushing to arbitrary
+// registries. The certificate of the source registry is not verified, like when pushing manifests to it.
+func NewImageCopier(logger *logrus.Entry, sourceAuths, targetAuths credentialprovider.DockerConfig) ImageCopier {
+	return &imageCopier{
+		logger: logger,
+		source: newResolver(sourceAuths, true),
+		target: newResolver(targetAuths, false),
+	}
+}
+
+type imageCopier struct {
+	logger *logrus.Entry
+	source remotes.Resolver
+	target remotes.Resolver
+}
+
+func newResolver(auths credentialprovider.DockerConfig, insecure bool) remotes.Resolver {
+	client := &http.Client{}
+	if insecure {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	authorizer := docker.NewDockerAuthorizer(docker.WithAuthClient(client), docker.WithAuthCreds(func(host string) (string, string, error) {
+		auth := registryAuth(auths, host)
+		return auth.Username, auth.Password, nil
+	}))
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(docker.WithClient(client), docker.WithAuthorizer(authorizer)),
+	})
+}
+
+// registryAuth looks the credentials for the host up, accounting for the names Docker Hub is known by
+func registryAuth(auths credentialprovider.DockerConfig, host string) credentialprovider.DockerConfigEntry {
+	candidates := []string{host}
+	if host == "registry-1.docker.io" {
+		candidates = append(candidates, "docker.io", "index.docker.io", "https://index.docker.io/v1/")
+	}
+	for _, candidate := range candidates {
+		if auth, ok := auths[candidate]; ok {
+			return auth
+		}
+	}
+	return credentialprovider.DockerConfigEntry{}
+}
+
+func (c *imageCopier) CopyImage(ctx context.Context, source string, targets []string, options CopyOptions) (string, error) {
+	dir, err := os.MkdirTemp("", "image-copy")
+	if err != nil {
+		return "", fmt.Errorf("failed to create a directory for the image content: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := local.NewStore(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the content store: %w", err)
+	}
+
+	desc, err := c.fetch(ctx, store, source)
+	if err != nil {
+		return "", err
+	}
+	if options.PinDigest {
+		for _, target := range targets {
+			if err := c.checkPinnedDigest(ctx, target, desc); err != nil {
+				return "", err
+			}
+		}
+	}
+	for _, target := range targets {
+		if err := c.push(ctx, store, target, desc); err != nil {
+			return "", err
+		}
+		c.logger.WithFields(logrus.Fields{"source": source, "digest": desc.Digest}).Infof("Image %s pushed", target)
+	}
+
+	var suffixes []string
+	if options.Signatures {
+		suffixes = append(suffixes, signatureTagSuffix)
+	}
+	if options.Attestations {
+		suffixes = append(suffixes, attestationTagSuffix)
+	}
+	for _, suffix := range suffixes {
+		if err := c.copySigstoreTag(ctx, store, source, targets, desc, suffix); err != nil {
+			return "", err
+		}
+	}
+	return desc.Digest.String(), nil
+}
+
+// fetch downloads the image with all the content it references into the store
+func (c *imageCopier) fetch(ctx context.Context, store content.Store, ref string) (ocispec.Descriptor, error) {
+	name, desc, err := c.source.Resolve(ctx, ref)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	fetcher, err := c.source.Fetcher(ctx, name)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to create a fetcher for %s: %w", ref, err)
+	}
+	if err := images.Dispatch(ctx, images.Handlers(remotes.FetchHandler(store, fetcher), images.ChildrenHandler(store)), nil, desc); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	return desc, nil
+}
+
+func (c *imageCopier) push(ctx context.Context, store content.Store, ref string, desc ocispec.Descriptor) error {
+	pusher, err := c.target.Pusher(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to create a pusher for %s: %w", ref, err)
+	}
+	if err := remotes.PushContent(ctx, pusher, desc, store, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to push %s: %w", ref, err)
+	}
+	return nil
+}
+
+// checkPinnedDigest fails when the target tag exists and points to a different digest
+func (c *imageCopier) checkPinnedDigest(ctx context.Context, ref string, desc ocispec.Descriptor) error {
+	_, existing, err := c.target.Resolve(ctx, ref)
+	if errdefs.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	if existing.Digest != desc.Digest {
+		return fmt.Errorf("%s is pinned to %s, refusing to move it to %s", ref, existing.Digest, desc.Digest)
+	}
+	return nil
+}
+
+// copySigstoreTag copies the tag sigstore stores signatures or attestations of the image in, named
+// after its digest, from the repository of the source to the repositories of the targets
+func (c *imageCopier) copySigstoreTag(ctx context.Context, store content.Store, source string, targets []string, desc ocispec.Descriptor, suffix string) error {
+	tag := SigstoreTag(desc.Digest.String(), suffix)
+	sourceRepository, err := repository(source)
+	if err != nil {
+		return err
+	}
+	sourceRef := fmt.Sprintf("%s:%s", sourceRepository, tag)
+	sigDesc, err := c.fetch(ctx, store, sourceRef)
+	if errdefs.IsNotFound(err) {
+		c.logger.WithField("source", source).Debugf("Image has no %s tag, not copying it", tag)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	copied := map[string]bool{}
+	for _, target := range targets {
+		targetRepository, err := repository(target)
+		if err != nil {
+			return err
+		}
+		if copied[targetRepository] {
+			continue
+		}
+		copied[targetRepository] = true
+		if err := c.push(ctx, store, fmt.Sprintf("%s:%s", targetRepository, tag), sigDesc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SigstoreTag returns the tag in which sigstore stores signatures or attestations of the image with the digest
+func SigstoreTag(digest, suffix string) string {
+	return strings.Replace(digest, ":", "-", 1) + suffix
+}
+
+func repository(ref string) (string, error) {
+	spec, err := reference.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", ref, err)
+	}
+	return spec.Locator, nil
+}
@@ -0,0 +1,52 @@
+package manifestpusher
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/kubernetes/pkg/credentialprovider"
+)
+
+func TestRegistryAuth(t *testing.T) {
+	auths := credentialprovider.DockerConfig{
+		"quay.io":                     {Username: "quay"},
+		"https://index.docker.io/v1/": {Username: "hub"},
+	}
+	var testCases = []struct {
+		host string
+		want string
+	}{
+		{host: "quay.io", want: "quay"},
+		{host: "registry-1.docker.io", want: "hub"},
+		{host: "registry.example.com"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.host, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, registryAuth(auths, tc.host).Username); diff != "" {
+				t.Errorf("unexpected credentials: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSigstoreTag(t *testing.T) {
+	if diff := cmp.Diff("sha256-abc.sig", SigstoreTag("sha256:abc", signatureTagSuffix)); diff != "" {
+		t.Errorf("unexpected tag: %s", diff)
+	}
+}
+
+func TestRepository(t *testing.T) {
+	for ref, want := range map[string]string{
+		"quay.io/org/image:latest":               "quay.io/org/image",
+		"registry.svc:5000/ns/pipeline@sha256:1": "registry.svc:5000/ns/pipeline",
+	} {
+		got, err := repository(ref)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", ref, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected repository of %s: %s", ref, diff)
+		}
+	}
+}
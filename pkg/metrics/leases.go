@@ -107,6 +107,55 @@ func (l *LeaseReleaseMetricEvent) Store(lp *leasesPlugin) {
 	lp.releaseEvents = append(lp.releaseEvents, *l)
 }
 
+// LeaseQueueMetricEvent is the event for a change of the position of a lease
+// request in the queue of requests waiting for resources of its type. Boskos
+// does not expose its queue, so requests to it report a position of -1 and
+// how long they were deferred by their priority before joining the queue.
+type LeaseQueueMetricEvent struct {
+	LeaseName     string `json:"name"`
+	Slice         string `json:"slice,omitempty"`
+	Region        string `json:"region,omitempty"`
+	RawLeaseName  string `json:"raw_lease_name,omitempty"`
+	QueuePosition int    `json:"queue_position"`
+	QueueLength   int    `json:"queue_length"`
+	// DeferralSeconds is how long a request to Boskos was deferred
+	DeferralSeconds float64   `json:"deferral_seconds,omitempty"`
+	LeasesFree      int       `json:"leases_free"`
+	LeasesTotal     int       `json:"leases_total"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// Name returns the name of the event.
+func (l *LeaseQueueMetricEvent) Name() string {
+	return l.LeaseName
+}
+
+// SetTimestamp sets the event's timestamp.
+func (l *LeaseQueueMetricEvent) SetTimestamp(t time.Time) {
+	l.Timestamp = t
+}
+
+// GetRawLeaseName returns the raw lease name.
+func (l *LeaseQueueMetricEvent) GetRawLeaseName() string {
+	return l.RawLeaseName
+}
+
+// SetParsedName sets the parsed lease name components.
+func (l *LeaseQueueMetricEvent) SetParsedName(region, leaseName, slice string) {
+	l.Region, l.LeaseName, l.Slice = region, leaseName, slice
+}
+
+// SetPoolMetrics sets the pool metrics.
+func (l *LeaseQueueMetricEvent) SetPoolMetrics(free, total int) {
+	l.LeasesFree = free
+	l.LeasesTotal = total
+}
+
+// Store persists the queue event on the plugin
+func (l *LeaseQueueMetricEvent) Store(lp *leasesPlugin) {
+	lp.queueEvents = append(lp.queueEvents, *l)
+}
+
 // Group 1: region (non-greedy up to the literal "--")
 // Group 2: canonical name (greedy until the last hyphen)
 // Group 3: slice (digit(s)), at the end.
@@ -126,6 +175,7 @@ type leasesPlugin struct {
 	logger        *logrus.Entry
 	events        []LeaseAcquisitionMetricEvent
 	releaseEvents []LeaseReleaseMetricEvent
+	queueEvents   []LeaseQueueMetricEvent
 	client        lease.Client
 }
 
@@ -135,6 +185,7 @@ func newLeasesPlugin(logger *logrus.Entry) *leasesPlugin {
 		logger:        logger.WithField("plugin", "leases"),
 		events:        make([]LeaseAcquisitionMetricEvent, 0),
 		releaseEvents: make([]LeaseReleaseMetricEvent, 0),
+		queueEvents:   make([]LeaseQueueMetricEvent, 0),
 	}
 }
 
@@ -180,13 +231,16 @@ func (lp *leasesPlugin) Events() []MetricsEvent {
 	lp.mu.Lock()
 	defer lp.mu.Unlock()
 
-	events := make([]MetricsEvent, 0, len(lp.events)+len(lp.releaseEvents))
+	events := make([]MetricsEvent, 0, len(lp.events)+len(lp.releaseEvents)+len(lp.queueEvents))
 	for i := range lp.events {
 		events = append(events, &lp.events[i])
 	}
 	for i := range lp.releaseEvents {
 		events = append(events, &lp.releaseEvents[i])
 	}
+	for i := range lp.queueEvents {
+		events = append(events, &lp.queueEvents[i])
+	}
 
 	return events
 }
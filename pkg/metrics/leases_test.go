@@ -1,7 +1,19 @@
 package metrics
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/boskos/common"
+
+	"github.com/openshift/ci-tools/pkg/lease"
 )
 
 func TestParseLeaseEventName(t *testing.T) {
@@ -44,3 +56,33 @@ func TestParseLeaseEventName(t *testing.T) {
 		}
 	}
 }
+
+func TestLeaseQueueEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leases.json")
+	raw, err := json.Marshal(lease.LocalState{Resources: []common.Resource{
+		{Name: "us-east-1--aws-quota-slice-0", Type: "aws-quota-slice", State: "free"},
+		{Name: "us-east-1--aws-quota-slice-1", Type: "aws-quota-slice", State: "leased", Owner: "other", LastUpdate: time.Now()},
+	}})
+	if err != nil {
+		t.Fatalf("failed to marshal state: %v", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+
+	plugin := newLeasesPlugin(logrus.NewEntry(logrus.StandardLogger()))
+	plugin.SetClient(lease.NewClientWithBackend(lease.NewFileBackend("owner", path), 0, time.Minute))
+	plugin.Record(&LeaseQueueMetricEvent{RawLeaseName: "aws-quota-slice", QueuePosition: 2, QueueLength: 3})
+
+	expected := []MetricsEvent{&LeaseQueueMetricEvent{
+		LeaseName:     "aws-quota-slice",
+		RawLeaseName:  "aws-quota-slice",
+		QueuePosition: 2,
+		QueueLength:   3,
+		LeasesFree:    1,
+		LeasesTotal:   2,
+	}}
+	if diff := cmp.Diff(expected, plugin.Events(), cmpopts.IgnoreFields(LeaseQueueMetricEvent{}, "Timestamp")); diff != "" {
+		t.Errorf("unexpected events: %s", diff)
+	}
+}
@@ -0,0 +1,187 @@
+// Package promotionhistory keeps the history of promotions in the ImageStreams they update, so that
+// they can be audited and rolled back. It has no dependency on the promotion configuration.
+package promotionhistory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	imagev1 "github.com/openshift/api/image/v1"
+)
+
+const (
+	// HistoryAnnotation holds the promotion history of an ImageStream, the most recent record first
+	HistoryAnnotation = "ci.openshift.io/promotion-history"
+	// maxHistory is the number of records kept, bounded to keep the annotation small
+	maxHistory = 20
+)
+
+// Source describes what was promoted
+type Source struct {
+	Org          string `json:"org,omitempty"`
+	Repo         string `json:"repo,omitempty"`
+	Branch       string `json:"branch,omitempty"`
+	Commit       string `json:"commit,omitempty"`
+	PullRequests []int  `json:"pullRequests,omitempty"`
+}
+
+// TagChange records the pull spec of a tag before and after a promotion. An empty
+// pull spec means the tag did not exist.
+type TagChange struct {
+	Tag string `json:"tag"`
+	Old string `json:"old,omitempty"`
+	New string `json:"new"`
+}
+
+// Record is an entry of the promotion history of an ImageStream
+type Record struct {
+	Time metav1.Time `json:"time"`
+	// ProwJobID identifies the job which promoted, or the promotion that was rolled back
+	ProwJobID string      `json:"prowJobID,omitempty"`
+	Job       string      `json:"job,omitempty"`
+	Source    *Source     `json:"source,omitempty"`
+	Tags      []TagChange `json:"tags"`
+	// RolledBack is the ProwJob ID of the promotion this record reverted
+	RolledBack string `json:"rolledBack,omitempty"`
+}
+
+// History returns the promotion history of an ImageStream, the most recent record first
+func History(is *imagev1.ImageStream) ([]Record, error) {
+	raw, ok := is.Annotations[HistoryAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var history []Record
+	if err := json.Unmarshal([]byte(raw), &history); err != nil {
+		return nil, fmt.Errorf("could not unmarshal the promotion history of %s/%s: %w", is.Namespace, is.Name, err)
+	}
+	return history, nil
+}
+
+// AddRecord prepends the record to the promotion history of the ImageStream, dropping the oldest records
+func AddRecord(is *imagev1.ImageStream, record Record) error {
+	history, err := History(is)
+	if err != nil {
+		return err
+	}
+	history = append([]Record{record}, history...)
+	if len(history) > maxHistory {
+		history = history[:maxHistory]
+	}
+	raw, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("could not marshal the promotion history: %w", err)
+	}
+	if is.Annotations == nil {
+		is.Annotations = map[string]string{}
+	}
+	is.Annotations[HistoryAnnotation] = string(raw)
+	return nil
+}
+
+// TagPullSpec returns the digest pull spec a tag of the ImageStream currently points to
+func TagPullSpec(is *imagev1.ImageStream, tag string) string {
+	for _, t := range is.Status.Tags {
+		if t.Tag != tag || len(t.Items) == 0 {
+			continue
+		}
+		ref := t.Items[0].DockerImageReference
+		if !strings.Contains(ref, "@sha256:") && strings.HasPrefix(t.Items[0].Image, "sha256:") {
+			if idx := strings.LastIndex(ref, ":"); idx != -1 {
+				return ref[:idx] + "@" + t.Items[0].Image
+			}
+		}
+		return ref
+	}
+	return ""
+}
+
+// TagChanges compares pull specs of tags before and after a promotion, keyed by tag
+func TagChanges(before, after map[string]string) []TagChange {
+	var changes []TagChange
+	for tag, pullSpec := range after {
+		if pullSpec != "" && before[tag] != pullSpec {
+			changes = append(changes, TagChange{Tag: tag, Old: before[tag], New: pullSpec})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Tag < changes[j].Tag })
+	return changes
+}
+
+// Rollback points every tag changed by the promotion of the given ProwJob back to where it
+// was before, and records the rollback in the history. The ImageStream is only modified in
+// memory: updating it in a single request makes the rollback atomic. Unless forced, it refuses
+// to roll back tags that have been promoted to again since, reporting all of them.
+func Rollback(is *imagev1.ImageStream, prowJobID string, force bool, now metav1.Time) (*Record, error) {
+	history, err := History(is)
+	if err != nil {
+		return nil, err
+	}
+	var target *Record
+	for i := range history {
+		if history[i].ProwJobID == prowJobID && history[i].RolledBack == "" {
+			target = &history[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("no promotion by %s in the history of %s/%s", prowJobID, is.Namespace, is.Name)
+	}
+
+	var conflicts []error
+	for _, change := range target.Tags {
+		if current := TagPullSpec(is, change.Tag); current != change.New && !force {
+			conflicts = append(conflicts, fmt.Errorf("tag %s was changed since the promotion by %s: it points to %s instead of %s", change.Tag, prowJobID, current, change.New))
+		}
+	}
+	if len(conflicts) != 0 {
+		return nil, utilerrors.NewAggregate(conflicts)
+	}
+
+	rollback := Record{Time: now, ProwJobID: prowJobID, RolledBack: prowJobID}
+	for _, change := range target.Tags {
+		current := TagPullSpec(is, change.Tag)
+		if change.Old == "" {
+			removeTag(is, change.Tag)
+		} else {
+			setTag(is, change.Tag, change.Old)
+		}
+		rollback.Tags = append(rollback.Tags, TagChange{Tag: change.Tag, Old: current, New: change.Old})
+	}
+	if err := AddRecord(is, rollback); err != nil {
+		return nil, err
+	}
+	return &rollback, nil
+}
+
+func setTag(is *imagev1.ImageStream, tag, pullSpec string) {
+	from := &coreapi.ObjectReference{Kind: "DockerImage", Name: pullSpec}
+	for i := range is.Spec.Tags {
+		if is.Spec.Tags[i].Name == tag {
+			is.Spec.Tags[i].From = from
+			return
+		}
+	}
+	is.Spec.Tags = append(is.Spec.Tags, imagev1.TagReference{
+		Name:            tag,
+		From:            from,
+		ReferencePolicy: imagev1.TagReferencePolicy{Type: imagev1.SourceTagReferencePolicy},
+		ImportPolicy:    imagev1.TagImportPolicy{ImportMode: imagev1.ImportModePreserveOriginal},
+	})
+}
+
+func removeTag(is *imagev1.ImageStream, tag string) {
+	var tags []imagev1.TagReference
+	for _, t := range is.Spec.Tags {
+		if t.Name != tag {
+			tags = append(tags, t)
+		}
+	}
+	is.Spec.Tags = tags
+}
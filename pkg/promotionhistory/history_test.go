@@ -0,0 +1,151 @@
+package promotionhistory
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func imageStream(t *testing.T, tags map[string]string, history ...Record) *imagev1.ImageStream {
+	is := &imagev1.ImageStream{ObjectMeta: metav1.ObjectMeta{Namespace: "ocp", Name: "4.22"}}
+	for tag, pullSpec := range tags {
+		is.Spec.Tags = append(is.Spec.Tags, imagev1.TagReference{Name: tag, From: &coreapi.ObjectReference{Kind: "DockerImage", Name: pullSpec}})
+		is.Status.Tags = append(is.Status.Tags, imagev1.NamedTagEventList{Tag: tag, Items: []imagev1.TagEvent{{DockerImageReference: pullSpec}}})
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if err := AddRecord(is, history[i]); err != nil {
+			t.Fatalf("failed to add record: %v", err)
+		}
+	}
+	return is
+}
+
+func TestAddRecord(t *testing.T) {
+	is := imageStream(t, nil)
+	for i := 0; i < maxHistory+5; i++ {
+		if err := AddRecord(is, Record{ProwJobID: fmt.Sprintf("pj-%d", i)}); err != nil {
+			t.Fatalf("failed to add record: %v", err)
+		}
+	}
+	history, err := History(is)
+	if err != nil {
+		t.Fatalf("failed to read history: %v", err)
+	}
+	if len(history) != maxHistory {
+		t.Errorf("expected %d records, got %d", maxHistory, len(history))
+	}
+	if first, last := history[0].ProwJobID, history[len(history)-1].ProwJobID; first != "pj-24" || last != "pj-5" {
+		t.Errorf("expected records from pj-24 to pj-5, got from %s to %s", first, last)
+	}
+}
+
+func TestTagChanges(t *testing.T) {
+	before := map[string]string{"same": "registry/a@sha256:1", "changed": "registry/b@sha256:1"}
+	after := map[string]string{"same": "registry/a@sha256:1", "changed": "registry/b@sha256:2", "new": "registry/c@sha256:1", "missing": ""}
+	expected := []TagChange{
+		{Tag: "changed", Old: "registry/b@sha256:1", New: "registry/b@sha256:2"},
+		{Tag: "new", New: "registry/c@sha256:1"},
+	}
+	if diff := cmp.Diff(expected, TagChanges(before, after)); diff != "" {
+		t.Errorf("unexpected changes: %s", diff)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	now := metav1.NewTime(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	promotion := Record{
+		ProwJobID: "pj-2",
+		Source:    &Source{Org: "org", Repo: "repo", Branch: "main", Commit: "abc"},
+		Tags: []TagChange{
+			{Tag: "cli", Old: "registry/cli@sha256:1", New: "registry/cli@sha256:2"},
+			{Tag: "tests", New: "registry/tests@sha256:1"},
+		},
+	}
+	testCases := []struct {
+		name         string
+		tags         map[string]string
+		prowJobID    string
+		force        bool
+		expectedTags map[string]string
+		expected     *Record
+		expectedErr  error
+	}{
+		{
+			name:         "promotion is rolled back",
+			tags:         map[string]string{"cli": "registry/cli@sha256:2", "tests": "registry/tests@sha256:1"},
+			prowJobID:    "pj-2",
+			expectedTags: map[string]string{"cli": "registry/cli@sha256:1"},
+			expected: &Record{Time: now, ProwJobID: "pj-2", RolledBack: "pj-2", Tags: []TagChange{
+				{Tag: "cli", Old: "registry/cli@sha256:2", New: "registry/cli@sha256:1"},
+				{Tag: "tests", Old: "registry/tests@sha256:1"},
+			}},
+		},
+		{
+			name:        "unknown promotion",
+			tags:        map[string]string{"cli": "registry/cli@sha256:2"},
+			prowJobID:   "pj-3",
+			expectedErr: fmt.Errorf("no promotion by pj-3 in the history of ocp/4.22"),
+		},
+		{
+			name:        "tag promoted to again since",
+			tags:        map[string]string{"cli": "registry/cli@sha256:3", "tests": "registry/tests@sha256:1"},
+			prowJobID:   "pj-2",
+			expectedErr: fmt.Errorf("tag cli was changed since the promotion by pj-2: it points to registry/cli@sha256:3 instead of registry/cli@sha256:2"),
+		},
+		{
+			name:        "every tag promoted to again since is reported",
+			tags:        map[string]string{"cli": "registry/cli@sha256:3", "tests": "registry/tests@sha256:2"},
+			prowJobID:   "pj-2",
+			expectedErr: fmt.Errorf("[tag cli was changed since the promotion by pj-2: it points to registry/cli@sha256:3 instead of registry/cli@sha256:2, tag tests was changed since the promotion by pj-2: it points to registry/tests@sha256:2 instead of registry/tests@sha256:1]"),
+		},
+		{
+			name:         "forced",
+			tags:         map[string]string{"cli": "registry/cli@sha256:3", "tests": "registry/tests@sha256:1"},
+			prowJobID:    "pj-2",
+			force:        true,
+			expectedTags: map[string]string{"cli": "registry/cli@sha256:1"},
+			expected: &Record{Time: now, ProwJobID: "pj-2", RolledBack: "pj-2", Tags: []TagChange{
+				{Tag: "cli", Old: "registry/cli@sha256:3", New: "registry/cli@sha256:1"},
+				{Tag: "tests", Old: "registry/tests@sha256:1"},
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := imageStream(t, tc.tags, promotion, Record{ProwJobID: "pj-1"})
+			record, err := Rollback(is, tc.prowJobID, tc.force, now)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, record); diff != "" {
+				t.Errorf("unexpected record: %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			tags := map[string]string{}
+			for _, tag := range is.Spec.Tags {
+				tags[tag.Name] = tag.From.Name
+			}
+			if diff := cmp.Diff(tc.expectedTags, tags); diff != "" {
+				t.Errorf("unexpected tags: %s", diff)
+			}
+			history, err := History(is)
+			if err != nil {
+				t.Fatalf("failed to read history: %v", err)
+			}
+			if diff := cmp.Diff(*tc.expected, history[0]); diff != "" {
+				t.Errorf("rollback was not recorded: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package quarantine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client fetches the quarantine list from the service
+type Client interface {
+	List(ctx context.Context) (*List, error)
+}
+
+type client struct {
+	address    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the service at address
+func NewClient(address string, httpClient *http.Client) Client {
+	return &client{address: strings.TrimSuffix(address, "/"), httpClient: httpClient}
+}
+
+func (c *client) List(ctx context.Context) (*List, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.address+Path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantine list: %w", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine list: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get quarantine list: status %d: %s", response.StatusCode, string(body))
+	}
+	list := &List{}
+	if err := json.Unmarshal(body, list); err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine list: %w", err)
+	}
+	return list, nil
+}
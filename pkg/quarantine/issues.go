@@ -0,0 +1,42 @@
+package quarantine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/jira"
+)
+
+const activityType = "Quality / Stability / Reliability"
+
+// FileIssues files a Jira issue for every quarantined test that has none yet, which are the newly
+// quarantined tests and those whose issue could not be filed before, and records the issue keys
+// in the list.
+func FileIssues(filer jira.IssueFiler, reporter string, list *List, logger *logrus.Entry) error {
+	var errs []error
+	for i := range list.Entries {
+		entry := &list.Entries[i]
+		if entry.IssueKey != "" {
+			continue
+		}
+		title := fmt.Sprintf("Flaky test quarantined: %s", entry.TestName)
+		description := fmt.Sprintf(`The test {{%s}} passed %.1f%% of %d runs, so it was quarantined: its failures no longer block merges.
+
+It ran in these jobs:
+* %s
+
+The test is released from quarantine once its pass rate recovers.`, entry.TestName, entry.PassPercentage, entry.Runs, strings.Join(entry.Jobs, "\n* "))
+		issue, err := filer.FileIssue(jira.IssueTypeBug, title, description, reporter, activityType, logger)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to file an issue for %s: %w", entry.TestName, err))
+			continue
+		}
+		entry.IssueKey = issue.Key
+		logger.WithFields(logrus.Fields{"test": entry.TestName, "issue": issue.Key}).Info("Filed an issue for a quarantined test.")
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,61 @@
+package quarantine
+
+import (
+	"errors"
+	"testing"
+
+	jiraapi "github.com/andygrunwald/go-jira"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/jira"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestFileIssues(t *testing.T) {
+	list := &List{Entries: []Entry{
+		{TestName: "filed", IssueKey: "DPTP-1"},
+		{TestName: "new", PassPercentage: 90, Runs: 20, Jobs: []string{"job-a", "job-b"}},
+		{TestName: "failing", PassPercentage: 50, Runs: 10, Jobs: []string{"job-a"}},
+	}}
+	filer := jira.NewFake(map[jira.IssueRequest]jira.IssueResponse{
+		{
+			IssueType: jira.IssueTypeBug,
+			Title:     "Flaky test quarantined: new",
+			Description: `The test {{new}} passed 90.0% of 20 runs, so it was quarantined: its failures no longer block merges.
+
+It ran in these jobs:
+* job-a
+* job-b
+
+The test is released from quarantine once its pass rate recovers.`,
+			Reporter:     "U123",
+			ActivityType: activityType,
+		}: {Issue: &jiraapi.Issue{Key: "DPTP-2"}},
+		{
+			IssueType: jira.IssueTypeBug,
+			Title:     "Flaky test quarantined: failing",
+			Description: `The test {{failing}} passed 50.0% of 10 runs, so it was quarantined: its failures no longer block merges.
+
+It ran in these jobs:
+* job-a
+
+The test is released from quarantine once its pass rate recovers.`,
+			Reporter:     "U123",
+			ActivityType: activityType,
+		}: {Error: errors.New("injected error")},
+	})
+
+	err := FileIssues(filer, "U123", list, logrus.NewEntry(logrus.New()))
+	if diff := cmp.Diff(errors.New("failed to file an issue for failing: injected error"), err, testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error (-want +got):\n%s", diff)
+	}
+	filer.Validate(t)
+	var keys []string
+	for _, entry := range list.Entries {
+		keys = append(keys, entry.IssueKey)
+	}
+	if diff := cmp.Diff([]string{"DPTP-1", "DPTP-2", ""}, keys); diff != "" {
+		t.Errorf("unexpected issue keys (-want +got):\n%s", diff)
+	}
+}
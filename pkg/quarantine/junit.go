@@ -0,0 +1,161 @@
+package quarantine
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// LifecycleInforming marks test cases whose failures do not block, consumers like the job run
+// aggregator already ignore the failures of informing tests
+const LifecycleInforming = "informing"
+
+// Results summarizes the test cases of the JUnit files a step left in its artifacts
+type Results struct {
+	// Marked is the number of quarantined test cases that were marked as informing
+	Marked int
+	// QuarantinedFailures are the quarantined tests that failed
+	QuarantinedFailures sets.Set[string]
+	// Failures are the other tests that failed or errored and did not pass on a retry
+	Failures sets.Set[string]
+}
+
+// OnlyQuarantinedFailed determines whether tests failed, but all of them are quarantined
+func (r *Results) OnlyQuarantinedFailed() bool {
+	return r.QuarantinedFailures.Len() > 0 && r.Failures.Len() == 0
+}
+
+// MarkFiles marks the quarantined test cases as informing in the junit*.xml files under dir and
+// summarizes which tests failed. Only the lifecycle attribute of the quarantined test cases is
+// patched, the files are otherwise kept as they are.
+func MarkFiles(dir string, quarantined sets.Set[string]) (*Results, error) {
+	results := &Results{QuarantinedFailures: sets.New[string](), Failures: sets.New[string]()}
+	failed, passed := sets.New[string](), sets.New[string]()
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "junit") || filepath.Ext(entry.Name()) != ".xml" {
+			return nil
+		}
+		n, err := markFile(path, quarantined, failed, passed)
+		if err != nil {
+			return fmt.Errorf("failed to mark %s: %w", path, err)
+		}
+		results.Marked += n
+		return nil
+	})
+	for name := range failed {
+		switch {
+		case quarantined.Has(name):
+			results.QuarantinedFailures.Insert(name)
+		case !passed.Has(name):
+			results.Failures.Insert(name)
+		}
+	}
+	return results, err
+}
+
+var lifecycleAttribute = regexp.MustCompile(`\slifecycle\s*=\s*("[^"]*"|'[^']*')`)
+
+// testCaseTag is the location of the start tag of a test case in a file
+type testCaseTag struct {
+	start, end int64
+	name       string
+	lifecycle  string
+	failed     bool
+}
+
+func markFile(path string, quarantined, failed, passed sets.Set[string]) (int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var tags []testCaseTag
+	var current *testCaseTag
+	depth := 0
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			depth++
+			if element.Name.Local == "testcase" {
+				tags = append(tags, testCaseTag{start: start, end: decoder.InputOffset()})
+				current = &tags[len(tags)-1]
+				for _, attr := range element.Attr {
+					switch attr.Name.Local {
+					case "name":
+						current.name = attr.Value
+					case "lifecycle":
+						current.lifecycle = attr.Value
+					}
+				}
+				depth = 0
+			} else if current != nil && depth == 1 && (element.Name.Local == "failure" || element.Name.Local == "error") {
+				current.failed = true
+			}
+		case xml.EndElement:
+			depth--
+			if current != nil && element.Name.Local == "testcase" && depth < 0 {
+				current = nil
+			}
+		}
+	}
+
+	var patched bytes.Buffer
+	var last int64
+	marked := 0
+	for _, tag := range tags {
+		if tag.failed {
+			failed.Insert(tag.name)
+		} else {
+			passed.Insert(tag.name)
+		}
+		if !quarantined.Has(tag.name) || tag.lifecycle == LifecycleInforming {
+			continue
+		}
+		patched.Write(raw[last:tag.start])
+		patched.Write(markTag(raw[tag.start:tag.end]))
+		last = tag.end
+		marked++
+	}
+	if marked == 0 {
+		return 0, nil
+	}
+	patched.Write(raw[last:])
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return marked, os.WriteFile(path, patched.Bytes(), info.Mode())
+}
+
+// markTag sets the lifecycle attribute in the start tag of a test case to informing
+func markTag(tag []byte) []byte {
+	attribute := []byte(fmt.Sprintf(` lifecycle="%s"`, LifecycleInforming))
+	if lifecycleAttribute.Match(tag) {
+		return lifecycleAttribute.ReplaceAllLiteral(tag, attribute)
+	}
+	end := len(tag) - 1
+	if bytes.HasSuffix(tag, []byte("/>")) {
+		end--
+	}
+	return append(append(append([]byte{}, tag[:end]...), attribute...), tag[end:]...)
+}
@@ -0,0 +1,120 @@
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestMarkFiles(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		files                   map[string]string
+		expectedFiles           map[string]string
+		expectedMarked          int
+		expectedOnlyQuarantined bool
+		expectedFailures        []string
+	}{
+		{
+			name: "only quarantined tests failed",
+			files: map[string]string{
+				"junit_suites.xml":         "<?xml version=\"1.0\"?>\n<testsuites>\n  <testsuite name=\"suite\" tests=\"2\">\n    <property name=\"kept\" value=\"yes\"/>\n    <testcase name=\"flaky\" time=\"1\"><failure message=\"failed\">output</failure></testcase>\n    <testcase name=\"stable\" time=\"1\"/>\n  </testsuite>\n</testsuites>\n",
+				"nested/junit_e2e.xml":     `<testsuite name="e2e"><testcase name="flaky" lifecycle="blocking"/><testcase name="stable"></testcase></testsuite>`,
+				"junit_stable.xml":         `<testsuite name="stable"><testcase name="stable" time="1"></testcase></testsuite>`,
+				"nested/not-junit-log.xml": `<testsuite name="ignored"><testcase name="flaky"></testcase></testsuite>`,
+			},
+			expectedFiles: map[string]string{
+				"junit_suites.xml":         "<?xml version=\"1.0\"?>\n<testsuites>\n  <testsuite name=\"suite\" tests=\"2\">\n    <property name=\"kept\" value=\"yes\"/>\n    <testcase name=\"flaky\" time=\"1\" lifecycle=\"informing\"><failure message=\"failed\">output</failure></testcase>\n    <testcase name=\"stable\" time=\"1\"/>\n  </testsuite>\n</testsuites>\n",
+				"nested/junit_e2e.xml":     `<testsuite name="e2e"><testcase name="flaky" lifecycle="informing"/><testcase name="stable"></testcase></testsuite>`,
+				"junit_stable.xml":         `<testsuite name="stable"><testcase name="stable" time="1"></testcase></testsuite>`,
+				"nested/not-junit-log.xml": `<testsuite name="ignored"><testcase name="flaky"></testcase></testsuite>`,
+			},
+			expectedMarked:          2,
+			expectedOnlyQuarantined: true,
+		},
+		{
+			name: "other tests failed",
+			files: map[string]string{
+				"junit_e2e.xml": `<testsuite name="e2e"><testcase name="flaky"><failure/></testcase><testcase name="broken"><failure/></testcase></testsuite>`,
+			},
+			expectedFiles: map[string]string{
+				"junit_e2e.xml": `<testsuite name="e2e"><testcase name="flaky" lifecycle="informing"><failure/></testcase><testcase name="broken"><failure/></testcase></testsuite>`,
+			},
+			expectedMarked:   1,
+			expectedFailures: []string{"broken"},
+		},
+		{
+			name: "other tests passed on a retry",
+			files: map[string]string{
+				"junit_e2e.xml": `<testsuite name="e2e"><testcase name="flaky"><failure/></testcase><testcase name="retried"><failure/></testcase><testcase name="retried"/></testsuite>`,
+			},
+			expectedFiles: map[string]string{
+				"junit_e2e.xml": `<testsuite name="e2e"><testcase name="flaky" lifecycle="informing"><failure/></testcase><testcase name="retried"><failure/></testcase><testcase name="retried"/></testsuite>`,
+			},
+			expectedMarked:          1,
+			expectedOnlyQuarantined: true,
+		},
+		{
+			name: "other tests errored",
+			files: map[string]string{
+				"junit_e2e.xml": `<testsuite name="e2e"><testcase name="flaky"><failure/></testcase><testcase name="crashed"><error/></testcase></testsuite>`,
+			},
+			expectedFiles: map[string]string{
+				"junit_e2e.xml": `<testsuite name="e2e"><testcase name="flaky" lifecycle="informing"><failure/></testcase><testcase name="crashed"><error/></testcase></testsuite>`,
+			},
+			expectedMarked:   1,
+			expectedFailures: []string{"crashed"},
+		},
+		{
+			name: "no test failed",
+			files: map[string]string{
+				"junit_e2e.xml": `<testsuite name="e2e"><testcase name="stable"/></testsuite>`,
+			},
+			expectedFiles: map[string]string{
+				"junit_e2e.xml": `<testsuite name="e2e"><testcase name="stable"/></testsuite>`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			results, err := MarkFiles(dir, sets.New("flaky"))
+			if err != nil {
+				t.Fatalf("failed to mark files: %v", err)
+			}
+			if results.Marked != tc.expectedMarked {
+				t.Errorf("expected %d marked test cases, got %d", tc.expectedMarked, results.Marked)
+			}
+			if onlyQuarantined := results.OnlyQuarantinedFailed(); onlyQuarantined != tc.expectedOnlyQuarantined {
+				t.Errorf("expected only quarantined tests to have failed: %v, got %v", tc.expectedOnlyQuarantined, onlyQuarantined)
+			}
+			if diff := cmp.Diff(tc.expectedFailures, sets.List(results.Failures), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected failures (-want +got):\n%s", diff)
+			}
+			for name, expected := range tc.expectedFiles {
+				raw, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(expected, string(raw)); diff != "" {
+					t.Errorf("%s: unexpected content (-want +got):\n%s", name, diff)
+				}
+			}
+		})
+	}
+}
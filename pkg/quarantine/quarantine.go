@@ -0,0 +1,109 @@
+// Package quarantine maintains the list of tests that flake too often to block merges, derived from the
+// pass rates the job run aggregator loads into BigQuery.
+package quarantine
+
+import (
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+)
+
+// Entry is a quarantined test
+type Entry struct {
+	TestName string `json:"testName"`
+	// PassPercentage is the share of the runs in the window the test passed without a retry
+	PassPercentage float64 `json:"passPercentage"`
+	// Runs is the number of runs of the test in the window
+	Runs int `json:"runs"`
+	// Jobs are the jobs the test ran in
+	Jobs []string `json:"jobs,omitempty"`
+	// Since is when the test was quarantined
+	Since time.Time `json:"since"`
+	// IssueKey is the Jira issue filed for the test, if any was filed yet
+	IssueKey string `json:"issueKey,omitempty"`
+}
+
+// List holds the quarantined tests, sorted by name
+type List struct {
+	Entries []Entry   `json:"entries"`
+	Updated time.Time `json:"updated"`
+}
+
+// Tests returns the names of the quarantined tests
+func (l *List) Tests() sets.Set[string] {
+	tests := sets.New[string]()
+	if l == nil {
+		return tests
+	}
+	for _, entry := range l.Entries {
+		tests.Insert(entry.TestName)
+	}
+	return tests
+}
+
+// Criteria decide which tests are quarantined
+type Criteria struct {
+	// MaxPassPercentage is the pass rate under which tests that do pass sometimes are quarantined.
+	// Tests that never pass are broken rather than flaky and are not quarantined.
+	MaxPassPercentage float64
+	// MinRuns is the number of runs a test needs in the window for its pass rate to be trusted
+	MinRuns int
+}
+
+// Update derives the quarantine list from the pass rates of the tests in the window the rows cover,
+// summing the runs of each test across jobs. Tests that were already quarantined keep the time they
+// were quarantined and their issue, tests whose pass rate recovered are released.
+func Update(previous *List, rows []jobrunaggregatorapi.AggregatedTestRunRow, criteria Criteria, now time.Time) *List {
+	type counts struct {
+		passes, runs int
+		jobs         sets.Set[string]
+	}
+	byTest := map[string]*counts{}
+	for _, row := range rows {
+		c, ok := byTest[row.TestName]
+		if !ok {
+			c = &counts{jobs: sets.New[string]()}
+			byTest[row.TestName] = c
+		}
+		c.passes += row.PassCount
+		c.runs += row.PassCount + row.FailCount + row.FlakeCount
+		c.jobs.Insert(row.JobName)
+	}
+
+	existing := map[string]Entry{}
+	if previous != nil {
+		for _, entry := range previous.Entries {
+			existing[entry.TestName] = entry
+		}
+	}
+
+	next := &List{Entries: []Entry{}, Updated: now}
+	for testName, c := range byTest {
+		if c.runs < criteria.MinRuns || c.passes == 0 {
+			continue
+		}
+		passPercentage := 100 * float64(c.passes) / float64(c.runs)
+		if passPercentage >= criteria.MaxPassPercentage {
+			continue
+		}
+		entry := Entry{
+			TestName:       testName,
+			PassPercentage: passPercentage,
+			Runs:           c.runs,
+			Jobs:           sets.List(c.jobs),
+			Since:          now,
+		}
+		if previous, ok := existing[testName]; ok {
+			entry.Since = previous.Since
+			entry.IssueKey = previous.IssueKey
+		}
+		next.Entries = append(next.Entries, entry)
+	}
+	sort.Slice(next.Entries, func(i, j int) bool {
+		return next.Entries[i].TestName < next.Entries[j].TestName
+	})
+	return next
+}
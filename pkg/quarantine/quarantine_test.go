@@ -0,0 +1,82 @@
+package quarantine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+)
+
+func TestUpdate(t *testing.T) {
+	before := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	criteria := Criteria{MaxPassPercentage: 95, MinRuns: 10}
+	row := func(job, test string, passes, failures, flakes int) jobrunaggregatorapi.AggregatedTestRunRow {
+		return jobrunaggregatorapi.AggregatedTestRunRow{JobName: job, TestName: test, PassCount: passes, FailCount: failures, FlakeCount: flakes}
+	}
+
+	testCases := []struct {
+		name     string
+		previous *List
+		rows     []jobrunaggregatorapi.AggregatedTestRunRow
+		expected *List
+	}{
+		{
+			name: "flaky tests are quarantined",
+			rows: []jobrunaggregatorapi.AggregatedTestRunRow{
+				row("job-a", "flaky", 8, 1, 1),
+				row("job-b", "flaky", 10, 0, 0),
+				row("job-a", "stable", 20, 0, 0),
+			},
+			expected: &List{
+				Entries: []Entry{{TestName: "flaky", PassPercentage: 90, Runs: 20, Jobs: []string{"job-a", "job-b"}, Since: now}},
+				Updated: now,
+			},
+		},
+		{
+			name: "tests that never pass are not quarantined",
+			rows: []jobrunaggregatorapi.AggregatedTestRunRow{
+				row("job-a", "broken", 0, 20, 0),
+			},
+			expected: &List{Entries: []Entry{}, Updated: now},
+		},
+		{
+			name: "tests with too few runs are not quarantined",
+			rows: []jobrunaggregatorapi.AggregatedTestRunRow{
+				row("job-a", "flaky", 5, 4, 0),
+			},
+			expected: &List{Entries: []Entry{}, Updated: now},
+		},
+		{
+			name: "quarantined tests keep their issue and are released once they recover",
+			previous: &List{
+				Entries: []Entry{
+					{TestName: "flaky", PassPercentage: 80, Runs: 20, Jobs: []string{"job-a"}, Since: before, IssueKey: "DPTP-1"},
+					{TestName: "recovered", PassPercentage: 80, Runs: 20, Jobs: []string{"job-a"}, Since: before, IssueKey: "DPTP-2"},
+				},
+				Updated: before,
+			},
+			rows: []jobrunaggregatorapi.AggregatedTestRunRow{
+				row("job-a", "flaky", 17, 3, 0),
+				row("job-a", "recovered", 20, 0, 0),
+				row("job-a", "new", 1, 9, 0),
+			},
+			expected: &List{
+				Entries: []Entry{
+					{TestName: "flaky", PassPercentage: 85, Runs: 20, Jobs: []string{"job-a"}, Since: before, IssueKey: "DPTP-1"},
+					{TestName: "new", PassPercentage: 10, Runs: 10, Jobs: []string{"job-a"}, Since: now},
+				},
+				Updated: now,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Update(tc.previous, tc.rows, criteria, now)); diff != "" {
+				t.Errorf("unexpected list (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
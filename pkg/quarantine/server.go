@@ -0,0 +1,108 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Path is where the quarantine list is served
+const Path = "/api/v1/quarantine"
+
+// Store holds the quarantine list and persists it to a file, so that issue keys and the times
+// tests were quarantined survive restarts
+type Store struct {
+	path string
+
+	lock sync.RWMutex
+	list *List
+}
+
+// NewStore loads the list persisted at path, if there is one
+func NewStore(path string) (*Store, error) {
+	store := &Store{path: path, list: &List{Entries: []Entry{}}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine list: %w", err)
+	}
+	if err := json.Unmarshal(raw, store.list); err != nil {
+		return nil, fmt.Errorf("failed to load quarantine list %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Get returns the current list, which must not be modified
+func (s *Store) Get() *List {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.list
+}
+
+// Set persists and replaces the list
+func (s *Store) Set(list *List) error {
+	raw, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantine list: %w", err)
+	}
+	// write to a temporary file first so a crash never leaves a truncated list behind
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		return fmt.Errorf("failed to persist quarantine list: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to persist quarantine list: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to persist quarantine list: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to persist quarantine list: %w", err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.list = list
+	return nil
+}
+
+// Handler serves the list as JSON. With a test query parameter only the entry of that test is
+// served, or 404 if the test is not quarantined.
+func Handler(store *Store) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(Path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		var response interface{} = store.Get()
+		if testName := r.URL.Query().Get("test"); testName != "" {
+			response = nil
+			for _, entry := range store.Get().Entries {
+				if entry.TestName == testName {
+					response = entry
+					break
+				}
+			}
+			if response == nil {
+				http.Error(w, fmt.Sprintf("test %q is not quarantined", testName), http.StatusNotFound)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.WithError(err).Warn("Failed to write quarantine list.")
+		}
+	})
+	return mux
+}
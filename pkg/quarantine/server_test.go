@@ -0,0 +1,64 @@
+package quarantine
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStoreAndClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantine.json")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if diff := cmp.Diff(&List{Entries: []Entry{}}, store.Get()); diff != "" {
+		t.Errorf("unexpected empty list (-want +got):\n%s", diff)
+	}
+
+	updated := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	list := &List{
+		Entries: []Entry{{TestName: "flaky", PassPercentage: 90, Runs: 20, Jobs: []string{"job-a"}, Since: updated, IssueKey: "DPTP-1"}},
+		Updated: updated,
+	}
+	if err := store.Set(list); err != nil {
+		t.Fatalf("failed to set list: %v", err)
+	}
+
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("failed to reload store: %v", err)
+	}
+	if diff := cmp.Diff(list, reloaded.Get()); diff != "" {
+		t.Errorf("unexpected reloaded list (-want +got):\n%s", diff)
+	}
+
+	server := httptest.NewServer(Handler(reloaded))
+	defer server.Close()
+
+	got, err := NewClient(server.URL+"/", server.Client()).List(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get list: %v", err)
+	}
+	if diff := cmp.Diff(list, got); diff != "" {
+		t.Errorf("unexpected served list (-want +got):\n%s", diff)
+	}
+
+	for test, expected := range map[string]int{"flaky": http.StatusOK, "stable": http.StatusNotFound} {
+		response, err := server.Client().Get(server.URL + Path + "?test=" + test)
+		if err != nil {
+			t.Fatalf("failed to get entry: %v", err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		if response.StatusCode != expected {
+			t.Errorf("%s: expected status %d, got %d: %s", test, expected, response.StatusCode, body)
+		}
+	}
+}
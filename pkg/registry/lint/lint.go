@@ -0,0 +1,376 @@
+// Package lint finds quality issues in the step registry and the ci-operator
+// configurations using it, which are structurally valid but likely mistakes:
+// unused components, parameters nobody reads and overrides which never apply.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/load"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// Kind identifies the class of a finding
+type Kind string
+
+const (
+	// UnusedComponent is a step, chain or workflow no job uses
+	UnusedComponent Kind = "unused-component"
+	// UnreadParameter is a parameter a step declares but its commands never read
+	UnreadParameter Kind = "unread-parameter"
+	// UndeclaredParameter is a parameter set for steps none of which declares it
+	UndeclaredParameter Kind = "undeclared-parameter"
+	// ShadowedParameter is a parameter value which never applies, as every job using it
+	// sets the parameter at a level closer to the job
+	ShadowedParameter Kind = "shadowed-parameter"
+	// UnusedCredential is a credential a step mounts but its commands never read
+	UnusedCredential Kind = "unused-credential"
+)
+
+// Finding is an issue in the registry or in a configuration
+type Finding struct {
+	Kind Kind `json:"kind"`
+	// Component is the registry component or the test the finding is about, e.g. ref/ipi-install
+	Component string `json:"component"`
+	// File is the path of the file defining the component, when known
+	File    string `json:"file,omitempty"`
+	Message string `json:"message"`
+}
+
+// Registry is the step registry to lint
+type Registry struct {
+	References registry.ReferenceByName
+	Chains     registry.ChainByName
+	Workflows  registry.WorkflowByName
+	Observers  registry.ObserverByName
+	// Paths maps the names of the files of the registry to their paths
+	Paths map[string]string
+}
+
+// Test is a multi-stage test of a ci-operator configuration
+type Test struct {
+	// Name identifies the test, e.g. org/repo@branch:e2e
+	Name string
+	// File is the path of the configuration
+	File   string
+	Config api.MultiStageTestConfiguration
+}
+
+func (r Registry) file(component string) string {
+	kind, name, _ := strings.Cut(component, "/")
+	suffix := map[string]string{"ref": load.RefSuffix, "chain": load.ChainSuffix, "workflow": load.WorkflowSuffix}[kind]
+	return r.Paths[name+suffix]
+}
+
+// level is a level of the resolution of parameters, the test, its workflow or a chain
+type level struct {
+	component string
+	env       []api.StepParameter
+}
+
+// paramKey identifies a value of a parameter set at a level
+type paramKey struct {
+	component string
+	name      string
+}
+
+type linter struct {
+	registry Registry
+	findings []Finding
+
+	// reached holds parameters declared by a level which a step under it declares
+	reached sets.Set[paramKey]
+	// applied holds parameter values of registry components which applied to a step of a job
+	applied sets.Set[paramKey]
+	// shadowedBy holds the levels which shadowed a parameter value of a registry component
+	shadowedBy map[paramKey]sets.Set[string]
+}
+
+// Lint analyzes the registry and the tests using it
+func Lint(reg Registry, tests []Test) []Finding {
+	l := &linter{
+		registry:   reg,
+		reached:    sets.New[paramKey](),
+		applied:    sets.New[paramKey](),
+		shadowedBy: map[paramKey]sets.Set[string]{},
+	}
+	l.unusedComponents(tests)
+	for name, ref := range reg.References {
+		l.lintStep("ref/"+name, ref)
+	}
+
+	// walk every component alone to find parameters which its own steps do not declare
+	for name, workflow := range reg.Workflows {
+		root := level{component: "workflow/" + name, env: testEnvironment(workflow.Environment)}
+		for _, phase := range [][]api.TestStep{workflow.Pre, workflow.Test, workflow.Post} {
+			l.walk(phase, []level{root}, false)
+		}
+	}
+	for name := range reg.Chains {
+		chain := name
+		l.walk([]api.TestStep{{Chain: &chain}}, nil, false)
+	}
+	for _, component := range l.componentsWithEnv() {
+		for _, param := range l.env(component) {
+			if !l.reached.Has(paramKey{component: component, name: param.Name}) {
+				l.add(UndeclaredParameter, component, l.registry.file(component), fmt.Sprintf("parameter %s is set for steps none of which declares it", param.Name))
+			}
+		}
+	}
+
+	for _, test := range tests {
+		l.lintTest(test)
+	}
+	for key, shadowers := range l.shadowedBy {
+		if l.applied.Has(key) {
+			continue
+		}
+		l.add(ShadowedParameter, key.component, l.registry.file(key.component), fmt.Sprintf("the value of parameter %s never applies, every job using it sets it in %s", key.name, strings.Join(sets.List(shadowers), ", ")))
+	}
+
+	sort.Slice(l.findings, func(i, j int) bool {
+		if l.findings[i].Component != l.findings[j].Component {
+			return l.findings[i].Component < l.findings[j].Component
+		}
+		if l.findings[i].Kind != l.findings[j].Kind {
+			return l.findings[i].Kind < l.findings[j].Kind
+		}
+		return l.findings[i].Message < l.findings[j].Message
+	})
+	return l.findings
+}
+
+func (l *linter) add(kind Kind, component, file, message string) {
+	l.findings = append(l.findings, Finding{Kind: kind, Component: component, File: file, Message: message})
+}
+
+func (l *linter) componentsWithEnv() []string {
+	var components []string
+	for name, workflow := range l.registry.Workflows {
+		if len(workflow.Environment) != 0 {
+			components = append(components, "workflow/"+name)
+		}
+	}
+	for name, chain := range l.registry.Chains {
+		if len(chain.Environment) != 0 {
+			components = append(components, "chain/"+name)
+		}
+	}
+	return components
+}
+
+func (l *linter) env(component string) []api.StepParameter {
+	kind, name, _ := strings.Cut(component, "/")
+	switch kind {
+	case "workflow":
+		return testEnvironment(l.registry.Workflows[name].Environment)
+	case "chain":
+		return l.registry.Chains[name].Environment
+	}
+	return nil
+}
+
+// unusedComponents reports components which are not used by any test, directly or through other components
+func (l *linter) unusedComponents(tests []Test) {
+	graph, err := registry.NewGraph(l.registry.References, l.registry.Chains, l.registry.Workflows, l.registry.Observers)
+	if err != nil {
+		// an inconsistent registry is reported by its validation
+		return
+	}
+	used := sets.New[string]()
+	markUsed := func(node registry.Node) {
+		used.Insert(componentName(node))
+		for _, descendant := range node.Descendants() {
+			used.Insert(componentName(descendant))
+		}
+	}
+	markSteps := func(steps []api.TestStep) {
+		for _, step := range steps {
+			if step.Reference != nil {
+				if node, ok := graph.References[unpinned(*step.Reference)]; ok {
+					markUsed(node)
+				}
+			}
+			if step.Chain != nil {
+				if node, ok := graph.Chains[unpinned(*step.Chain)]; ok {
+					markUsed(node)
+				}
+			}
+		}
+	}
+	for _, test := range tests {
+		if test.Config.Workflow != nil {
+			if node, ok := graph.Workflows[unpinned(*test.Config.Workflow)]; ok {
+				markUsed(node)
+			}
+		}
+		markSteps(test.Config.Pre)
+		markSteps(test.Config.Test)
+		markSteps(test.Config.Post)
+	}
+	for _, nodes := range []map[string]registry.Node{graph.References, graph.Chains, graph.Workflows} {
+		for _, node := range nodes {
+			if name := componentName(node); !used.Has(name) {
+				kind, short, _ := strings.Cut(name, "/")
+				l.add(UnusedComponent, name, l.registry.file(name), fmt.Sprintf("%s %s is not used by any job", map[string]string{"ref": "step", "chain": "chain", "workflow": "workflow"}[kind], short))
+			}
+		}
+	}
+}
+
+// unpinned strips the registry version a component is pinned to, pinned
+// components are linted as the current version of the component
+func unpinned(reference string) string {
+	name, _ := api.SplitRegistryVersion(reference)
+	return name
+}
+
+func componentName(node registry.Node) string {
+	switch node.Type() {
+	case registry.Workflow:
+		return "workflow/" + node.Name()
+	case registry.Chain:
+		return "chain/" + node.Name()
+	case registry.Observer:
+		return "observer/" + node.Name()
+	default:
+		return "ref/" + node.Name()
+	}
+}
+
+// lintStep reports parameters and credentials of a step its commands do not refer to
+func (l *linter) lintStep(component string, step api.LiteralTestStep) {
+	file := l.registry.file(component)
+	for _, param := range step.Environment {
+		if !readsVariable(step.Commands, param.Name) {
+			l.add(UnreadParameter, component, file, fmt.Sprintf("parameter %s is declared but never read by the commands", param.Name))
+		}
+	}
+	for _, credential := range step.Credentials {
+		if credential.MountPath == "" || refersToPath(step, credential.MountPath) {
+			continue
+		}
+		l.add(UnusedCredential, component, file, fmt.Sprintf("credential mounted at %s is never read by the commands", credential.MountPath))
+	}
+}
+
+// readsVariable determines whether the script refers to the variable, conservatively:
+// any occurrence of its name as a word counts, e.g. $NAME, ${NAME:-x} or os.environ["NAME"]
+func readsVariable(commands, name string) bool {
+	return regexp.MustCompile(`(^|[^A-Za-z0-9_])` + regexp.QuoteMeta(name) + `($|[^A-Za-z0-9_])`).MatchString(commands)
+}
+
+// refersToPath determines whether the commands refer to the path, directly or through the
+// default of one of the parameters of the step
+func refersToPath(step api.LiteralTestStep, path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	if strings.Contains(step.Commands, path) {
+		return true
+	}
+	for _, param := range step.Environment {
+		if param.Default != nil && strings.Contains(*param.Default, path) && readsVariable(step.Commands, param.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintTest resolves the parameters of the test like ci-operator does, recording which values apply
+func (l *linter) lintTest(test Test) {
+	config := test.Config
+	root := level{component: "test/" + test.Name, env: testEnvironment(config.Environment)}
+	levels := []level{root}
+	phases := [][]api.TestStep{config.Pre, config.Test, config.Post}
+	var overridden [][]api.TestStep
+	if config.Workflow != nil {
+		name := unpinned(*config.Workflow)
+		workflow, ok := l.registry.Workflows[name]
+		if !ok {
+			return
+		}
+		levels = append(levels, level{component: "workflow/" + name, env: testEnvironment(workflow.Environment)})
+		for i, phase := range [][]api.TestStep{workflow.Pre, workflow.Test, workflow.Post} {
+			if phases[i] == nil {
+				phases[i] = phase
+			} else {
+				overridden = append(overridden, phase)
+			}
+		}
+	}
+	for _, phase := range phases {
+		l.walk(phase, levels, true)
+	}
+	// parameters of steps the test replaces are not reported, as ci-operator does not either
+	for _, phase := range overridden {
+		l.walk(phase, []level{root}, false)
+	}
+	for _, param := range root.env {
+		if !l.reached.Has(paramKey{component: root.component, name: param.Name}) {
+			l.add(UndeclaredParameter, root.component, test.File, fmt.Sprintf("parameter %s is set but no step of the test declares it", param.Name))
+		}
+	}
+}
+
+// walk visits the steps under the levels. When the walk is for a job, it records which values apply.
+func (l *linter) walk(steps []api.TestStep, levels []level, job bool) {
+	for _, step := range steps {
+		switch {
+		case step.Chain != nil:
+			name := unpinned(*step.Chain)
+			chain, ok := l.registry.Chains[name]
+			if !ok {
+				continue
+			}
+			l.walk(chain.Steps, append(levels[:len(levels):len(levels)], level{component: "chain/" + name, env: chain.Environment}), job)
+		case step.Reference != nil:
+			if ref, ok := l.registry.References[unpinned(*step.Reference)]; ok {
+				l.resolve(ref, levels, job)
+			}
+		case step.LiteralTestStep != nil:
+			l.resolve(*step.LiteralTestStep, levels, job)
+		}
+	}
+}
+
+// resolve follows ci-operator: the level furthest from the step which declares a parameter
+// provides its value.
+func (l *linter) resolve(step api.LiteralTestStep, levels []level, job bool) {
+	for _, param := range step.Environment {
+		var applied *paramKey
+		for _, lvl := range levels {
+			for _, e := range lvl.env {
+				if e.Name != param.Name {
+					continue
+				}
+				key := paramKey{component: lvl.component, name: param.Name}
+				l.reached.Insert(key)
+				if applied == nil {
+					applied = &key
+					if job {
+						l.applied.Insert(key)
+					}
+				} else if job && e.Default != nil {
+					if l.shadowedBy[key] == nil {
+						l.shadowedBy[key] = sets.New[string]()
+					}
+					l.shadowedBy[key].Insert(applied.component)
+				}
+			}
+		}
+	}
+}
+
+func testEnvironment(env api.TestEnvironment) []api.StepParameter {
+	params := make([]api.StepParameter, 0, len(env))
+	for _, name := range sets.List(sets.KeySet(env)) {
+		value := env[name]
+		params = append(params, api.StepParameter{Name: name, Default: &value})
+	}
+	return params
+}
@@ -0,0 +1,158 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+func TestLint(t *testing.T) {
+	install, conf, gather, unused := "install", "conf", "gather", "unused"
+	installChain, ipi := "install-chain", "ipi"
+	value := func(s string) *string { return &s }
+
+	reg := Registry{
+		References: registry.ReferenceByName{
+			install: {
+				As:       install,
+				Commands: `openshift-install create cluster --log-level "${LOG_LEVEL}" --dir "${SHARED_DIR}"`,
+				Environment: []api.StepParameter{
+					{Name: "LOG_LEVEL", Default: value("info")},
+					{Name: "INSTALL_TIMEOUT", Default: value("60m")},
+				},
+				Credentials: []api.CredentialReference{
+					{Namespace: "test-credentials", Name: "pull-secret", MountPath: "/var/run/pull-secret"},
+					{Namespace: "test-credentials", Name: "aws", MountPath: "/var/run/aws"},
+				},
+			},
+			conf: {
+				As:       conf,
+				Commands: `cp "${PULL_SECRET_PATH}/.dockerconfigjson" "${SHARED_DIR}"; echo "${REGION}"`,
+				Environment: []api.StepParameter{
+					{Name: "PULL_SECRET_PATH", Default: value("/var/run/secret")},
+					{Name: "REGION", Default: value("us-east-1")},
+				},
+				Credentials: []api.CredentialReference{{Namespace: "test-credentials", Name: "secret", MountPath: "/var/run/secret"}},
+			},
+			gather: {As: gather, Commands: "oc adm must-gather"},
+			unused: {As: unused, Commands: "true"},
+		},
+		Chains: registry.ChainByName{
+			installChain: {
+				As:    installChain,
+				Steps: []api.TestStep{{Reference: &conf}, {Reference: &install}},
+				Environment: []api.StepParameter{
+					{Name: "REGION", Default: value("us-west-1")},
+					{Name: "LOG_LEVEL", Default: value("debug")},
+					{Name: "ARCH", Default: value("amd64")},
+				},
+			},
+		},
+		Workflows: registry.WorkflowByName{
+			ipi: {
+				Pre:         []api.TestStep{{Chain: &installChain}},
+				Post:        []api.TestStep{{Reference: &gather}},
+				Environment: api.TestEnvironment{"REGION": "eu-west-1"},
+			},
+		},
+		Paths: map[string]string{
+			"install-ref.yaml":         "registry/install/install-ref.yaml",
+			"unused-ref.yaml":          "registry/unused/unused-ref.yaml",
+			"install-chain-chain.yaml": "registry/install/chain/install-chain-chain.yaml",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		tests    []Test
+		expected []Finding
+	}{
+		{
+			name: "workflow overrides the chain",
+			tests: []Test{{
+				Name:   "org/repo@main:e2e",
+				File:   "config/org/repo/org-repo-main.yaml",
+				Config: api.MultiStageTestConfiguration{Workflow: &ipi, Environment: api.TestEnvironment{"LOG_LEVEL": "warn", "UNKNOWN": "x"}},
+			}},
+			expected: []Finding{
+				{Kind: ShadowedParameter, Component: "chain/install-chain", File: "registry/install/chain/install-chain-chain.yaml", Message: "the value of parameter LOG_LEVEL never applies, every job using it sets it in test/org/repo@main:e2e"},
+				{Kind: ShadowedParameter, Component: "chain/install-chain", File: "registry/install/chain/install-chain-chain.yaml", Message: "the value of parameter REGION never applies, every job using it sets it in workflow/ipi"},
+				{Kind: UndeclaredParameter, Component: "chain/install-chain", File: "registry/install/chain/install-chain-chain.yaml", Message: "parameter ARCH is set for steps none of which declares it"},
+				{Kind: UnreadParameter, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "parameter INSTALL_TIMEOUT is declared but never read by the commands"},
+				{Kind: UnusedCredential, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "credential mounted at /var/run/aws is never read by the commands"},
+				{Kind: UnusedCredential, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "credential mounted at /var/run/pull-secret is never read by the commands"},
+				{Kind: UnusedComponent, Component: "ref/unused", File: "registry/unused/unused-ref.yaml", Message: "step unused is not used by any job"},
+				{Kind: UndeclaredParameter, Component: "test/org/repo@main:e2e", File: "config/org/repo/org-repo-main.yaml", Message: "parameter UNKNOWN is set but no step of the test declares it"},
+			},
+		},
+		{
+			name: "chain values apply when a test uses the chain directly",
+			tests: []Test{
+				{Name: "org/repo@main:e2e", Config: api.MultiStageTestConfiguration{Workflow: &ipi}},
+				{Name: "org/repo@main:install", Config: api.MultiStageTestConfiguration{Test: []api.TestStep{{Chain: &installChain}, {Reference: &unused}}}},
+			},
+			expected: []Finding{
+				{Kind: UndeclaredParameter, Component: "chain/install-chain", File: "registry/install/chain/install-chain-chain.yaml", Message: "parameter ARCH is set for steps none of which declares it"},
+				{Kind: UnreadParameter, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "parameter INSTALL_TIMEOUT is declared but never read by the commands"},
+				{Kind: UnusedCredential, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "credential mounted at /var/run/aws is never read by the commands"},
+				{Kind: UnusedCredential, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "credential mounted at /var/run/pull-secret is never read by the commands"},
+			},
+		},
+		{
+			name: "pinned components are linted as the current ones",
+			tests: []Test{
+				{Name: "org/repo@main:e2e", Config: api.MultiStageTestConfiguration{Workflow: value(api.PinRegistryVersion(ipi, "v1"))}},
+				{Name: "org/repo@main:install", Config: api.MultiStageTestConfiguration{Test: []api.TestStep{{Chain: value(api.PinRegistryVersion(installChain, "v1"))}, {Reference: value(api.PinRegistryVersion(unused, "v1"))}}}},
+			},
+			expected: []Finding{
+				{Kind: UndeclaredParameter, Component: "chain/install-chain", File: "registry/install/chain/install-chain-chain.yaml", Message: "parameter ARCH is set for steps none of which declares it"},
+				{Kind: UnreadParameter, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "parameter INSTALL_TIMEOUT is declared but never read by the commands"},
+				{Kind: UnusedCredential, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "credential mounted at /var/run/aws is never read by the commands"},
+				{Kind: UnusedCredential, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "credential mounted at /var/run/pull-secret is never read by the commands"},
+			},
+		},
+		{
+			name: "parameters of steps the test replaces are declared",
+			tests: []Test{{
+				Name:   "org/repo@main:e2e",
+				Config: api.MultiStageTestConfiguration{Workflow: &ipi, Pre: []api.TestStep{{Reference: &unused}}, Environment: api.TestEnvironment{"INSTALL_TIMEOUT": "2h"}},
+			}},
+			expected: []Finding{
+				{Kind: UndeclaredParameter, Component: "chain/install-chain", File: "registry/install/chain/install-chain-chain.yaml", Message: "parameter ARCH is set for steps none of which declares it"},
+				{Kind: UnreadParameter, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "parameter INSTALL_TIMEOUT is declared but never read by the commands"},
+				{Kind: UnusedCredential, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "credential mounted at /var/run/aws is never read by the commands"},
+				{Kind: UnusedCredential, Component: "ref/install", File: "registry/install/install-ref.yaml", Message: "credential mounted at /var/run/pull-secret is never read by the commands"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Lint(reg, tc.tests)); diff != "" {
+				t.Errorf("unexpected findings: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReadsVariable(t *testing.T) {
+	testCases := []struct {
+		commands string
+		expected bool
+	}{
+		{commands: "echo $NAME", expected: true},
+		{commands: `echo "${NAME:-default}"`, expected: true},
+		{commands: `os.environ["NAME"]`, expected: true},
+		{commands: "echo $NAMESPACE", expected: false},
+		{commands: "echo $OTHER_NAME", expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.commands, func(t *testing.T) {
+			if actual := readsVariable(tc.commands, "NAME"); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
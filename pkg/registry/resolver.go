@@ -25,7 +25,7 @@ type ObserverByName map[string]api.Observer
 // A superset of this validation is performed later when actual test
 // configurations are resolved.
 func Validate(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName, clusterProfiles api.ClusterProfilesMap) error {
-	reg := registry{stepsByName, chainsByName, workflowsByName, observersByName, clusterProfiles}
+	reg := registry{stepsByName, chainsByName, workflowsByName, observersByName, clusterProfiles, nil}
 	var ret []error
 	for k := range chainsByName {
 		if _, err := reg.process([]api.TestStep{{Chain: &k}}, sets.New[string](), stackForChain()); err != nil {
@@ -56,15 +56,24 @@ type registry struct {
 	workflowsByName WorkflowByName
 	observersByName ObserverByName
 	clusterProfiles api.ClusterProfilesMap
+	// versions loads the registry versions components are pinned to
+	versions Versions
 }
 
 func NewResolver(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName, clusterProfiles api.ClusterProfilesMap) Resolver {
+	return NewVersionedResolver(stepsByName, chainsByName, workflowsByName, observersByName, clusterProfiles, nil)
+}
+
+// NewVersionedResolver returns a resolver which also resolves components pinned to
+// a version of the registry, loading them from versions
+func NewVersionedResolver(stepsByName ReferenceByName, chainsByName ChainByName, workflowsByName WorkflowByName, observersByName ObserverByName, clusterProfiles api.ClusterProfilesMap, versions Versions) Resolver {
 	return &registry{
 		stepsByName:     stepsByName,
 		chainsByName:    chainsByName,
 		workflowsByName: workflowsByName,
 		observersByName: observersByName,
 		clusterProfiles: clusterProfiles,
+		versions:        versions,
 	}
 }
 
@@ -82,7 +91,10 @@ func (r *registry) Resolve(name string, config api.MultiStageTestConfiguration)
 
 func (r *registry) mergeWorkflow(config *api.MultiStageTestConfiguration) ([][]api.TestStep, []error) {
 	var overridden [][]api.TestStep
-	workflow, ok := r.workflowsByName[*config.Workflow]
+	workflow, ok, err := r.workflow(*config.Workflow)
+	if err != nil {
+		return nil, []error{err}
+	}
 	if !ok {
 		return nil, []error{fmt.Errorf("no workflow named %s", *config.Workflow)}
 	}
@@ -185,7 +197,10 @@ func (r *registry) resolveTest(
 }
 
 func (r *registry) ResolveWorkflow(name string) (api.MultiStageTestConfigurationLiteral, error) {
-	workflow, ok := r.workflowsByName[name]
+	workflow, ok, err := r.workflow(name)
+	if err != nil {
+		return api.MultiStageTestConfigurationLiteral{}, err
+	}
 	if !ok {
 		return api.MultiStageTestConfigurationLiteral{}, fmt.Errorf("no workflow named %s", name)
 	}
@@ -292,7 +307,10 @@ func (r *registry) process(steps []api.TestStep, seen sets.Set[string], stack st
 }
 
 func (r *registry) processChain(name string, seen sets.Set[string], stack stack) ([]api.LiteralTestStep, []error) {
-	chain, ok := r.chainsByName[name]
+	chain, ok, loadErr := r.chain(name)
+	if loadErr != nil {
+		return nil, []error{stack.errorf("unknown step chain: %s: %v", name, loadErr)}
+	}
 	if !ok {
 		return nil, []error{stack.errorf("unknown step chain: %s", name)}
 	}
@@ -307,7 +325,11 @@ func (r *registry) processChain(name string, seen sets.Set[string], stack stack)
 func (r *registry) processStep(step *api.TestStep, seen sets.Set[string], stack stack) (ret api.LiteralTestStep, err []error) {
 	if ref := step.Reference; ref != nil {
 		var ok bool
-		ret, ok = r.stepsByName[*ref]
+		var err error
+		ret, ok, err = r.step(*ref)
+		if err != nil {
+			return api.LiteralTestStep{}, []error{stack.errorf("invalid step reference: %s: %v", *ref, err)}
+		}
 		if !ok {
 			return api.LiteralTestStep{}, []error{stack.errorf("invalid step reference: %s", *ref)}
 		}
@@ -382,7 +404,10 @@ func (r *registry) processObservers(observerNames sets.Set[string], stack stack)
 func (r *registry) iterateSteps(s api.TestStep, f func(*api.LiteralTestStep)) error {
 	switch {
 	case s.Chain != nil:
-		c, ok := r.chainsByName[*s.Chain]
+		c, ok, err := r.chain(*s.Chain)
+		if err != nil {
+			return err
+		}
 		if !ok {
 			return fmt.Errorf("invalid reference: %s", *s.Reference)
 		}
@@ -392,7 +417,10 @@ func (r *registry) iterateSteps(s api.TestStep, f func(*api.LiteralTestStep)) er
 			}
 		}
 	case s.Reference != nil:
-		r, ok := r.stepsByName[*s.Reference]
+		r, ok, err := r.step(*s.Reference)
+		if err != nil {
+			return err
+		}
 		if !ok {
 			return fmt.Errorf("invalid reference: %s", *s.Reference)
 		}
@@ -0,0 +1,163 @@
+package registry
+
+import (
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// Versions loads versions of the registry other than the current one, so that
+// tests can pin the components they use to a known good version
+type Versions interface {
+	// Registry returns the components of the registry at the version, which is
+	// either a named version of the registry or a commit
+	Registry(version string) (ReferenceByName, ChainByName, WorkflowByName, error)
+	// Deprecation returns why the version is deprecated, or an empty string if it is not
+	Deprecation(version string) string
+}
+
+// at returns the registry at the version
+func (r *registry) at(version string) (*registry, error) {
+	if r.versions == nil {
+		return nil, fmt.Errorf("registry version %s cannot be used, versions of the registry are not available", version)
+	}
+	refs, chains, workflows, err := r.versions.Registry(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry version %s: %w", version, err)
+	}
+	return &registry{stepsByName: refs, chainsByName: chains, workflowsByName: workflows, clusterProfiles: r.clusterProfiles}, nil
+}
+
+func (r *registry) step(reference string) (api.LiteralTestStep, bool, error) {
+	name, version := api.SplitRegistryVersion(reference)
+	if version == "" {
+		step, ok := r.stepsByName[name]
+		return step, ok, nil
+	}
+	pinned, err := r.at(version)
+	if err != nil {
+		return api.LiteralTestStep{}, false, err
+	}
+	step, ok := pinned.stepsByName[name]
+	return step, ok, nil
+}
+
+// chain returns the chain. The steps of a pinned chain are pinned to the same version.
+func (r *registry) chain(reference string) (api.RegistryChain, bool, error) {
+	name, version := api.SplitRegistryVersion(reference)
+	if version == "" {
+		chain, ok := r.chainsByName[name]
+		return chain, ok, nil
+	}
+	pinned, err := r.at(version)
+	if err != nil {
+		return api.RegistryChain{}, false, err
+	}
+	chain, ok := pinned.chainsByName[name]
+	chain.Steps = pinSteps(chain.Steps, version)
+	return chain, ok, nil
+}
+
+// workflow returns the workflow. The steps of a pinned workflow are pinned to the same version.
+func (r *registry) workflow(reference string) (api.MultiStageTestConfiguration, bool, error) {
+	name, version := api.SplitRegistryVersion(reference)
+	if version == "" {
+		workflow, ok := r.workflowsByName[name]
+		return workflow, ok, nil
+	}
+	pinned, err := r.at(version)
+	if err != nil {
+		return api.MultiStageTestConfiguration{}, false, err
+	}
+	workflow, ok := pinned.workflowsByName[name]
+	workflow.Pre = pinSteps(workflow.Pre, version)
+	workflow.Test = pinSteps(workflow.Test, version)
+	workflow.Post = pinSteps(workflow.Post, version)
+	return workflow, ok, nil
+}
+
+// pinSteps pins the references and chains in the steps to the version, unless
+// they are pinned to a version already
+func pinSteps(steps []api.TestStep, version string) []api.TestStep {
+	if steps == nil {
+		return nil
+	}
+	pin := func(reference string) *string {
+		if _, pinned := api.SplitRegistryVersion(reference); pinned != "" {
+			return &reference
+		}
+		reference = api.PinRegistryVersion(reference, version)
+		return &reference
+	}
+	pinned := make([]api.TestStep, 0, len(steps))
+	for _, step := range steps {
+		if step.Reference != nil {
+			step.Reference = pin(*step.Reference)
+		}
+		if step.Chain != nil {
+			step.Chain = pin(*step.Chain)
+		}
+		pinned = append(pinned, step)
+	}
+	return pinned
+}
+
+// ValidatePinnedVersions verifies that the registry versions the test pins its
+// components to exist and contain those components. It returns the deprecation
+// notices of the versions the test uses.
+func ValidatePinnedVersions(versions Versions, config api.MultiStageTestConfiguration) ([]string, error) {
+	var errs []error
+	deprecated := sets.New[string]()
+	var deprecations []string
+	check := func(kind, reference string, exists func(ReferenceByName, ChainByName, WorkflowByName, string) bool) {
+		name, version := api.SplitRegistryVersion(reference)
+		if version == "" {
+			return
+		}
+		if versions == nil {
+			errs = append(errs, fmt.Errorf("%s %s is pinned to registry version %s, but versions of the registry are not available", kind, name, version))
+			return
+		}
+		refs, chains, workflows, err := versions.Registry(version)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s is pinned to registry version %s, which cannot be loaded: %w", kind, name, version, err))
+			return
+		}
+		if !exists(refs, chains, workflows, name) {
+			errs = append(errs, fmt.Errorf("%s %s does not exist in registry version %s", kind, name, version))
+		}
+		if notice := versions.Deprecation(version); notice != "" && !deprecated.Has(version) {
+			deprecated.Insert(version)
+			deprecations = append(deprecations, fmt.Sprintf("registry version %s is deprecated: %s", version, notice))
+		}
+	}
+	stepExists := func(refs ReferenceByName, _ ChainByName, _ WorkflowByName, name string) bool {
+		_, ok := refs[name]
+		return ok
+	}
+	chainExists := func(_ ReferenceByName, chains ChainByName, _ WorkflowByName, name string) bool {
+		_, ok := chains[name]
+		return ok
+	}
+	workflowExists := func(_ ReferenceByName, _ ChainByName, workflows WorkflowByName, name string) bool {
+		_, ok := workflows[name]
+		return ok
+	}
+	if config.Workflow != nil {
+		check("workflow", *config.Workflow, workflowExists)
+	}
+	for _, steps := range [][]api.TestStep{config.Pre, config.Test, config.Post} {
+		for _, step := range steps {
+			if step.Reference != nil {
+				check("step", *step.Reference, stepExists)
+			}
+			if step.Chain != nil {
+				check("chain", *step.Chain, chainExists)
+			}
+		}
+	}
+	return deprecations, utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,205 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type fakeRegistry struct {
+	refs      ReferenceByName
+	chains    ChainByName
+	workflows WorkflowByName
+}
+
+type fakeVersions struct {
+	versions   map[string]fakeRegistry
+	deprecated map[string]string
+}
+
+func (v fakeVersions) Registry(version string) (ReferenceByName, ChainByName, WorkflowByName, error) {
+	reg, ok := v.versions[version]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unknown registry version %s", version)
+	}
+	return reg.refs, reg.chains, reg.workflows, nil
+}
+
+func (v fakeVersions) Deprecation(version string) string {
+	return v.deprecated[version]
+}
+
+func TestResolvePinned(t *testing.T) {
+	install, gather, installChain, ipi := "install", "gather", "install-chain", "ipi"
+	pin := func(name, version string) *string {
+		pinned := api.PinRegistryVersion(name, version)
+		return &pinned
+	}
+	current := fakeRegistry{
+		refs: ReferenceByName{
+			install: {As: install, Commands: "install --new"},
+			gather:  {As: gather, Commands: "gather --new"},
+		},
+		chains:    ChainByName{installChain: {As: installChain, Steps: []api.TestStep{{Reference: &install}}}},
+		workflows: WorkflowByName{ipi: {Pre: []api.TestStep{{Chain: &installChain}}, Post: []api.TestStep{{Reference: &gather}}}},
+	}
+	versions := fakeVersions{
+		versions: map[string]fakeRegistry{
+			"v2": {
+				refs: ReferenceByName{install: {As: install, Commands: "install --older"}},
+			},
+			"v4": {
+				refs:   ReferenceByName{install: {As: install, Commands: "install --new"}},
+				chains: ChainByName{installChain: {As: installChain, Steps: []api.TestStep{{Reference: pin(install, "v2")}}}},
+			},
+			"v3": {
+				refs: ReferenceByName{
+					install: {As: install, Commands: "install --old"},
+					gather:  {As: gather, Commands: "gather --old"},
+				},
+				chains:    ChainByName{installChain: {As: installChain, Steps: []api.TestStep{{Reference: &install}}}},
+				workflows: WorkflowByName{ipi: {Pre: []api.TestStep{{Chain: &installChain}}, Post: []api.TestStep{{Reference: &gather}}}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		versions    Versions
+		config      api.MultiStageTestConfiguration
+		expected    api.MultiStageTestConfigurationLiteral
+		expectedErr error
+	}{
+		{
+			name:     "pinned step next to a current one",
+			versions: versions,
+			config:   api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: pin(install, "v3")}, {Reference: &gather}}},
+			expected: api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{
+				{As: install, Commands: "install --old"},
+				{As: gather, Commands: "gather --new"},
+			}},
+		},
+		{
+			name:     "steps of a pinned chain come from its version",
+			versions: versions,
+			config:   api.MultiStageTestConfiguration{Test: []api.TestStep{{Chain: pin(installChain, "v3")}}},
+			expected: api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{{As: install, Commands: "install --old"}}},
+		},
+		{
+			name:     "steps a pinned chain pins to another version come from that version",
+			versions: versions,
+			config:   api.MultiStageTestConfiguration{Test: []api.TestStep{{Chain: pin(installChain, "v4")}}},
+			expected: api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{{As: install, Commands: "install --older"}}},
+		},
+		{
+			name:     "steps of a pinned workflow come from its version",
+			versions: versions,
+			config:   api.MultiStageTestConfiguration{Workflow: pin(ipi, "v3")},
+			expected: api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{{As: install, Commands: "install --old"}},
+				Post: []api.LiteralTestStep{{As: gather, Commands: "gather --old"}},
+			},
+		},
+		{
+			name:     "steps replacing those of a pinned workflow are current",
+			versions: versions,
+			config:   api.MultiStageTestConfiguration{Workflow: pin(ipi, "v3"), Post: []api.TestStep{{Reference: &gather}}},
+			expected: api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{{As: install, Commands: "install --old"}},
+				Post: []api.LiteralTestStep{{As: gather, Commands: "gather --new"}},
+			},
+		},
+		{
+			name:        "unknown version",
+			versions:    versions,
+			config:      api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: pin(install, "v1")}}},
+			expectedErr: errors.New("test/test: invalid step reference: install@v1: failed to load registry version v1: unknown registry version v1"),
+		},
+		{
+			name:        "versions are not available",
+			config:      api.MultiStageTestConfiguration{Workflow: pin(ipi, "v3")},
+			expectedErr: errors.New("registry version v3 cannot be used, versions of the registry are not available"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := NewVersionedResolver(current.refs, current.chains, current.workflows, nil, nil, tc.versions)
+			actual, err := resolver.Resolve("test", tc.config)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected resolved configuration: %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidatePinnedVersions(t *testing.T) {
+	install, ipi, missing := "install", "ipi", "missing"
+	pin := func(name, version string) *string {
+		pinned := api.PinRegistryVersion(name, version)
+		return &pinned
+	}
+	versions := fakeVersions{
+		versions: map[string]fakeRegistry{
+			"v2": {refs: ReferenceByName{install: {As: install}}, workflows: WorkflowByName{ipi: {}}},
+			"v3": {refs: ReferenceByName{install: {As: install}}, workflows: WorkflowByName{ipi: {}}},
+		},
+		deprecated: map[string]string{"v2": "use v3, v2 installs clusters without FIPS"},
+	}
+	testCases := []struct {
+		name                 string
+		versions             Versions
+		config               api.MultiStageTestConfiguration
+		expectedDeprecations []string
+		expectedErr          error
+	}{
+		{
+			name:   "nothing is pinned",
+			config: api.MultiStageTestConfiguration{Workflow: &ipi, Test: []api.TestStep{{Reference: &install}}},
+		},
+		{
+			name:     "pinned versions exist",
+			versions: versions,
+			config:   api.MultiStageTestConfiguration{Workflow: pin(ipi, "v3"), Test: []api.TestStep{{Reference: pin(install, "v3")}}},
+		},
+		{
+			name:                 "deprecated version is reported once",
+			versions:             versions,
+			config:               api.MultiStageTestConfiguration{Workflow: pin(ipi, "v2"), Test: []api.TestStep{{Reference: pin(install, "v2")}}},
+			expectedDeprecations: []string{"registry version v2 is deprecated: use v3, v2 installs clusters without FIPS"},
+		},
+		{
+			name:     "missing versions and components",
+			versions: versions,
+			config: api.MultiStageTestConfiguration{
+				Workflow: pin(ipi, "v1"),
+				Pre:      []api.TestStep{{Chain: pin(missing, "v3")}},
+				Post:     []api.TestStep{{Reference: pin(missing, "v3")}},
+			},
+			expectedErr: errors.New("[workflow ipi is pinned to registry version v1, which cannot be loaded: unknown registry version v1, chain missing does not exist in registry version v3, step missing does not exist in registry version v3]"),
+		},
+		{
+			name:        "versions are not available",
+			config:      api.MultiStageTestConfiguration{Workflow: pin(ipi, "v3")},
+			expectedErr: errors.New("workflow ipi is pinned to registry version v3, but versions of the registry are not available"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deprecations, err := ValidatePinnedVersions(tc.versions, tc.config)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedDeprecations, deprecations); diff != "" {
+				t.Errorf("unexpected deprecations: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package rehearse
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+const (
+	causeSeparator          = " → "
+	networkAccessSkipReason = "'restrict_network_access' is set to 'false' and network access rehearsals are not allowed on the PR"
+)
+
+// Explanations record why jobs were found to be affected by a change, keyed by job name
+type Explanations map[string]*Explanation
+
+// Explanation holds the causes that made a job affected and, when the job
+// will not be rehearsed, the reason why
+type Explanation struct {
+	// Causes are chains of causes, each starting with a change in the PR and
+	// ending with the job
+	Causes [][]string
+	// Skipped is the reason an affected job will not be rehearsed
+	Skipped string
+	// RegistryNodes are the changed registry nodes the job exercises
+	RegistryNodes sets.Set[string]
+}
+
+func (e Explanations) get(job string) *Explanation {
+	if _, ok := e[job]; !ok {
+		e[job] = &Explanation{}
+	}
+	return e[job]
+}
+
+// addCause records a chain of causes for a job. It is safe to call on nil Explanations.
+func (e Explanations) addCause(job string, chain ...string) {
+	if e == nil {
+		return
+	}
+	explanation := e.get(job)
+	for _, cause := range explanation.Causes {
+		if slices.Equal(cause, chain) {
+			return
+		}
+	}
+	explanation.Causes = append(explanation.Causes, chain)
+}
+
+// addRegistryNodes records changed registry nodes the job exercises. It is safe to call on nil Explanations.
+func (e Explanations) addRegistryNodes(job string, nodes ...string) {
+	if e == nil {
+		return
+	}
+	explanation := e.get(job)
+	if explanation.RegistryNodes == nil {
+		explanation.RegistryNodes = sets.New[string]()
+	}
+	explanation.RegistryNodes.Insert(nodes...)
+}
+
+// skip records why an affected job will not be rehearsed, keeping the first reason.
+// It is safe to call on nil Explanations.
+func (e Explanations) skip(job, reason string) {
+	if e == nil {
+		return
+	}
+	if explanation := e.get(job); explanation.Skipped == "" {
+		explanation.Skipped = reason
+	}
+}
+
+// Summary returns the causes of a job on a single line
+func (e Explanations) Summary(job string) string {
+	explanation, ok := e[job]
+	if !ok {
+		return ""
+	}
+	var causes []string
+	for _, cause := range explanation.Causes {
+		causes = append(causes, strings.Join(cause, causeSeparator))
+	}
+	return strings.Join(causes, "; ")
+}
+
+// Skipped returns the sorted names of jobs that will not be rehearsed
+func (e Explanations) Skipped() []string {
+	var skipped []string
+	for job, explanation := range e {
+		if explanation.Skipped != "" {
+			skipped = append(skipped, job)
+		}
+	}
+	sort.Strings(skipped)
+	return skipped
+}
+
+// Explain describes in Markdown why a job was found to be affected and
+// whether it will be rehearsed
+func (e Explanations) Explain(job string) string {
+	explanation, ok := e[job]
+	if !ok || len(explanation.Causes) == 0 {
+		return fmt.Sprintf("`%s` is not affected by this change.", job)
+	}
+	lines := []string{fmt.Sprintf("`%s` is affected by this change:", job), ""}
+	for i, cause := range explanation.Causes {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, strings.Join(cause, causeSeparator)))
+	}
+	if explanation.Skipped != "" {
+		lines = append(lines, "", fmt.Sprintf("It will not be rehearsed: %s.", explanation.Skipped))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// explainCiopConfigJobs records the changed ci-operator config file, and the
+// changed test in it if the change was limited to tests, for jobs selected
+// because of a ci-operator config change
+func (e Explanations) explainCiopConfigJobs(presubmits config.Presubmits, periodics config.Periodics, configs config.DataByFilename, affectedJobs map[string]sets.Set[string]) {
+	explain := func(name, prefix string, data config.DataWithInfo) {
+		if !strings.HasPrefix(name, prefix) {
+			return
+		}
+		filename := data.Info.Basename()
+		tests, ok := affectedJobs[filename]
+		if !ok {
+			e.addCause(name, fmt.Sprintf("ci-operator config file %s changed", filename))
+			return
+		}
+		test := strings.TrimPrefix(name, prefix)
+		if tests.Has(test) {
+			e.addCause(name, fmt.Sprintf("test %s changed in ci-operator config file %s", test, filename))
+		}
+	}
+	for _, data := range configs {
+		for _, job := range presubmits[fmt.Sprintf("%s/%s", data.Info.Org, data.Info.Repo)] {
+			explain(job.Name, data.Info.JobName(jobconfig.PresubmitPrefix, ""), data)
+		}
+		for name := range periodics {
+			explain(name, data.Info.JobName(jobconfig.PeriodicPrefix, ""), data)
+		}
+	}
+}
+
+// explainBySourceType records the source type of jobs no other cause was
+// recorded for, naming the cluster profile for jobs selected because of it
+func (e Explanations) explainBySourceType(presubmits config.Presubmits, periodics config.Periodics) {
+	explain := func(job prowconfig.JobBase) {
+		if explanation, ok := e[job.Name]; ok && len(explanation.Causes) > 0 {
+			return
+		}
+		sourceType := config.GetSourceType(job.Labels)
+		if sourceType == config.ChangedClusterProfile {
+			if profile := clusterProfileName(job); profile != "" {
+				e.addCause(job.Name, fmt.Sprintf("cluster profile %s changed", profile))
+				return
+			}
+		}
+		e.addCause(job.Name, sourceType.GetDisplayText())
+	}
+	for _, jobs := range presubmits {
+		for _, job := range jobs {
+			explain(job.JobBase)
+		}
+	}
+	for _, job := range periodics {
+		explain(job.JobBase)
+	}
+}
+
+// ExplainConfigMaps records a cause for every job that mounts a changed ConfigMap
+func (e Explanations) ExplainConfigMaps(presubmits config.Presubmits, periodics config.Periodics, cms ConfigMaps) {
+	for _, cm := range sets.List(cms.ProductionNames) {
+		for _, jobs := range presubmits {
+			for _, job := range jobs {
+				if UsesConfigMap(job.JobBase, cm) {
+					e.addCause(job.Name, fmt.Sprintf("ConfigMap %s changed", cm))
+				}
+			}
+		}
+		for _, job := range periodics {
+			if UsesConfigMap(job.JobBase, cm) {
+				e.addCause(job.Name, fmt.Sprintf("ConfigMap %s changed", cm))
+			}
+		}
+	}
+}
+
+func clusterProfileName(job prowconfig.JobBase) string {
+	if job.Spec == nil {
+		return ""
+	}
+	for _, volume := range job.Spec.Volumes {
+		if volume.Name != "cluster-profile" || volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.ConfigMap != nil {
+				return source.ConfigMap.Name
+			}
+		}
+	}
+	return ""
+}
+
+func describeNode(node registry.Node) string {
+	return fmt.Sprintf("%s %s", registry.FieldsForNode(node)["node-type"], node.Name())
+}
+
+// registryCauseChain describes how a change to one of the changed registry
+// nodes reaches the affected node, e.g. a changed reference that is used by a
+// chain which is used by a workflow
+func registryCauseChain(changed []registry.Node, affected registry.Node) []string {
+	key := func(node registry.Node) string { return describeNode(node) }
+	type path struct {
+		node  registry.Node
+		chain []string
+	}
+	var queue []path
+	seen := sets.New[string]()
+	for _, node := range changed {
+		if !seen.Has(key(node)) {
+			seen.Insert(key(node))
+			queue = append(queue, path{node: node, chain: []string{fmt.Sprintf("registry %s changed", describeNode(node))}})
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if key(current.node) == key(affected) {
+			return current.chain
+		}
+		parents := current.node.Parents()
+		sort.Slice(parents, func(i, j int) bool { return key(parents[i]) < key(parents[j]) })
+		for _, parent := range parents {
+			if seen.Has(key(parent)) {
+				continue
+			}
+			seen.Insert(key(parent))
+			chain := append(slices.Clone(current.chain), fmt.Sprintf("used by %s", describeNode(parent)))
+			queue = append(queue, path{node: parent, chain: chain})
+		}
+	}
+	return []string{fmt.Sprintf("registry %s changed", describeNode(affected))}
+}
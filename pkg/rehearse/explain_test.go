@@ -0,0 +1,124 @@
+package rehearse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+func TestRegistryCauseChain(t *testing.T) {
+	install, setup, ipi, other := "ipi-install", "ipi-setup", "ipi", "other"
+	graph, err := registry.NewGraph(
+		registry.ReferenceByName{install: {As: install}, other: {As: other}},
+		registry.ChainByName{setup: {As: setup, Steps: []api.TestStep{{Reference: &install}}}},
+		registry.WorkflowByName{ipi: {Pre: []api.TestStep{{Chain: &setup}}, Test: []api.TestStep{{Reference: &other}}}},
+		registry.ObserverByName{},
+	)
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	testCases := []struct {
+		name     string
+		changed  []registry.Node
+		affected registry.Node
+		expected []string
+	}{
+		{
+			name:     "changed node itself",
+			changed:  []registry.Node{graph.References[install]},
+			affected: graph.References[install],
+			expected: []string{"registry reference ipi-install changed"},
+		},
+		{
+			name:     "reference used by a chain used by a workflow",
+			changed:  []registry.Node{graph.References[install]},
+			affected: graph.Workflows[ipi],
+			expected: []string{"registry reference ipi-install changed", "used by chain ipi-setup", "used by workflow ipi"},
+		},
+		{
+			name:     "shortest chain from any of the changed nodes",
+			changed:  []registry.Node{graph.References[install], graph.References[other]},
+			affected: graph.Workflows[ipi],
+			expected: []string{"registry reference other changed", "used by workflow ipi"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, registryCauseChain(tc.changed, tc.affected)); diff != "" {
+				t.Errorf("unexpected chain: %s", diff)
+			}
+		})
+	}
+}
+
+func TestExplanations(t *testing.T) {
+	info := config.Info{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"}}
+	configs := config.DataByFilename{
+		"org-repo-master.yaml":  {Info: info},
+		"org-other-master.yaml": {Info: config.Info{Metadata: api.Metadata{Org: "org", Repo: "other", Branch: "master"}}},
+	}
+	presubmit := func(name string, labels map[string]string, volumes ...v1.Volume) prowconfig.Presubmit {
+		return prowconfig.Presubmit{JobBase: prowconfig.JobBase{Name: name, Labels: labels, Spec: &v1.PodSpec{Volumes: volumes}}}
+	}
+	profile := v1.Volume{Name: "cluster-profile", VolumeSource: v1.VolumeSource{Projected: &v1.ProjectedVolumeSource{Sources: []v1.VolumeProjection{{
+		ConfigMap: &v1.ConfigMapProjection{LocalObjectReference: v1.LocalObjectReference{Name: "cluster-profile-aws"}},
+	}}}}}
+	presubmits := config.Presubmits{
+		"org/repo": {
+			presubmit("pull-ci-org-repo-master-unit", nil),
+			presubmit("pull-ci-org-repo-master-e2e", nil),
+		},
+		"org/other": {
+			presubmit("pull-ci-org-other-master-e2e", nil),
+		},
+		"org/profiles": {
+			presubmit("pull-ci-org-profiles-master-e2e-aws", map[string]string{config.SourceTypeLabel: string(config.ChangedClusterProfile)}, profile),
+			presubmit("pull-ci-org-profiles-master-e2e", map[string]string{config.SourceTypeLabel: string(config.ChangedTemplate)}),
+		},
+	}
+	periodics := config.Periodics{
+		"periodic-ci-org-repo-master-nightly": prowconfig.Periodic{JobBase: prowconfig.JobBase{Name: "periodic-ci-org-repo-master-nightly"}},
+	}
+
+	explanations := Explanations{}
+	explanations.explainCiopConfigJobs(presubmits, periodics, configs, map[string]sets.Set[string]{"org-repo-master.yaml": sets.New[string]("unit", "nightly")})
+	explanations.explainBySourceType(presubmits, periodics)
+	explanations.ExplainConfigMaps(presubmits, periodics, ConfigMaps{ProductionNames: sets.New[string]("cluster-profile-aws")})
+	explanations.skip("pull-ci-org-repo-master-unit", networkAccessSkipReason)
+
+	expected := map[string]string{
+		"pull-ci-org-repo-master-unit":          "test unit changed in ci-operator config file org-repo-master.yaml",
+		"pull-ci-org-repo-master-e2e":           "Unknown change occurred",
+		"pull-ci-org-other-master-e2e":          "ci-operator config file org-other-master.yaml changed",
+		"pull-ci-org-profiles-master-e2e-aws":   "cluster profile cluster-profile-aws changed; ConfigMap cluster-profile-aws changed",
+		"pull-ci-org-profiles-master-e2e":       "Template changed",
+		"periodic-ci-org-repo-master-nightly":   "test nightly changed in ci-operator config file org-repo-master.yaml",
+		"periodic-ci-org-repo-master-unrelated": "",
+	}
+	for job, summary := range expected {
+		if diff := cmp.Diff(summary, explanations.Summary(job)); diff != "" {
+			t.Errorf("unexpected summary for %s: %s", job, diff)
+		}
+	}
+	if diff := cmp.Diff([]string{"pull-ci-org-repo-master-unit"}, explanations.Skipped()); diff != "" {
+		t.Errorf("unexpected skipped jobs: %s", diff)
+	}
+
+	expectedExplanation := "`pull-ci-org-repo-master-unit` is affected by this change:\n\n" +
+		"1. test unit changed in ci-operator config file org-repo-master.yaml\n\n" +
+		"It will not be rehearsed: 'restrict_network_access' is set to 'false' and network access rehearsals are not allowed on the PR."
+	if diff := cmp.Diff(expectedExplanation, explanations.Explain("pull-ci-org-repo-master-unit")); diff != "" {
+		t.Errorf("unexpected explanation: %s", diff)
+	}
+	if diff := cmp.Diff("`missing` is not affected by this change.", explanations.Explain("missing")); diff != "" {
+		t.Errorf("unexpected explanation: %s", diff)
+	}
+}
@@ -207,7 +207,7 @@ func contextFor(source *prowconfig.Presubmit) string {
 
 }
 
-func filterPresubmits(changedPresubmits config.Presubmits, disabledJobs []string, logger logrus.FieldLogger) config.Presubmits {
+func filterPresubmits(changedPresubmits config.Presubmits, disabledJobs []string, explanations Explanations, logger logrus.FieldLogger) config.Presubmits {
 	presubmits := config.Presubmits{}
 	for repo, jobs := range changedPresubmits {
 		for _, job := range jobs {
@@ -215,21 +215,25 @@ func filterPresubmits(changedPresubmits config.Presubmits, disabledJobs []string
 
 			if job.Hidden {
 				jobLogger.Debug("hidden jobs are not allowed to be rehearsed")
+				explanations.skip(job.Name, "hidden jobs are not allowed to be rehearsed")
 				continue
 			}
 
 			if !hasRehearsableLabel(job.Labels) {
 				jobLogger.Debugf("job is not allowed to be rehearsed. Label %s is required", jobconfig.CanBeRehearsedLabel)
+				explanations.skip(job.Name, fmt.Sprintf("the %s label is required", jobconfig.CanBeRehearsedLabel))
 				continue
 			}
 
 			if len(job.Branches) == 0 {
 				jobLogger.Debug("cannot rehearse jobs with no branches")
+				explanations.skip(job.Name, "jobs with no branches cannot be rehearsed")
 				continue
 			}
 
 			if slices.Contains(disabledJobs, job.Name) {
 				jobLogger.Debug("cannot rehearse due to 'restrict_network_access' set to 'false' without appropriate label on PR")
+				explanations.skip(job.Name, networkAccessSkipReason)
 				continue
 			}
 
@@ -240,23 +244,26 @@ func filterPresubmits(changedPresubmits config.Presubmits, disabledJobs []string
 	return presubmits
 }
 
-func filterPeriodics(changedPeriodics config.Periodics, disabledJobs []string, logger logrus.FieldLogger) config.Periodics {
+func filterPeriodics(changedPeriodics config.Periodics, disabledJobs []string, explanations Explanations, logger logrus.FieldLogger) config.Periodics {
 	periodics := config.Periodics{}
 	for _, periodic := range changedPeriodics {
 		jobLogger := logger.WithField("job-name", periodic.Name)
 
 		if periodic.Hidden {
 			jobLogger.Warn("hidden jobs are not allowed to be rehearsed")
+			explanations.skip(periodic.Name, "hidden jobs are not allowed to be rehearsed")
 			continue
 		}
 
 		if !hasRehearsableLabel(periodic.Labels) {
 			jobLogger.Debugf("job is not allowed to be rehearsed. Label %s is required", jobconfig.CanBeRehearsedLabel)
+			explanations.skip(periodic.Name, fmt.Sprintf("the %s label is required", jobconfig.CanBeRehearsedLabel))
 			continue
 		}
 
 		if slices.Contains(disabledJobs, periodic.Name) {
 			jobLogger.Debug("cannot rehearse due to 'restrict_network_access' set to 'false' without appropriate label on PR")
+			explanations.skip(periodic.Name, networkAccessSkipReason)
 			continue
 		}
 
@@ -618,7 +625,7 @@ func physicalJobNames(baseName string, shardCount *int) []string {
 }
 
 // selectJobsForRegistryStep returns all jobs affected by the provided registry node.
-func selectJobsForRegistryStep(node registry.Node, configs []*config.DataWithInfo, allPresubmits presubmitsByName, allPeriodics periodicsByName, skipJobs sets.Set[string], logger *logrus.Entry) (presubmitsByRepo, periodicsByRepo) {
+func selectJobsForRegistryStep(node registry.Node, configs []*config.DataWithInfo, allPresubmits presubmitsByName, allPeriodics periodicsByName, logger *logrus.Entry) (presubmitsByRepo, periodicsByRepo) {
 	selectedPresubmits := make(map[string][]prowconfig.Presubmit)
 	selectedPeriodics := make(map[string][]prowconfig.Periodic)
 
@@ -677,11 +684,6 @@ func selectJobsForRegistryStep(node registry.Node, configs []*config.DataWithInf
 				}
 			}
 
-			if skipJobs.Has(jobName) {
-				testLogger.WithField("job-name", jobName).Trace("Already saw this job, skipping")
-				continue
-			}
-
 			// TODO: Handle workflows with overridden logFields.
 			// Workflows can have overridden logFields and thus may have overridden the field that made the workflow an ancestor.
 			// This should be handled to reduce the number of rehearsals being done, but requires much more information than
@@ -750,7 +752,10 @@ func getAffectedNodes(changed []registry.Node) []registry.Node {
 	return worklist
 }
 
-func SelectJobsForChangedRegistry(regSteps []registry.Node, allPresubmits presubmitsByRepo, allPeriodics []prowconfig.Periodic, ciopConfigs config.DataByFilename, logger *logrus.Entry) (config.Presubmits, config.Periodics) {
+// SelectJobsForChangedRegistry returns the jobs affected by changed registry nodes and records
+// the chain from the changed node to the node used by each job, as well as all the changed
+// nodes each job exercises, in the explanations
+func SelectJobsForChangedRegistry(regSteps []registry.Node, allPresubmits presubmitsByRepo, allPeriodics []prowconfig.Periodic, ciopConfigs config.DataByFilename, explanations Explanations, logger *logrus.Entry) (config.Presubmits, config.Periodics) {
 	// We need a sorted index of ci-operator configs for deterministic behavior
 	var sortedConfigs []*config.DataWithInfo
 	for idx := range ciopConfigs {
@@ -764,6 +769,12 @@ func SelectJobsForChangedRegistry(regSteps []registry.Node, allPresubmits presub
 	})
 
 	stepWorklist := getAffectedNodes(regSteps)
+	changedByAffected := map[string][]string{}
+	for _, changed := range regSteps {
+		for _, affected := range append([]registry.Node{changed}, changed.Ancestors()...) {
+			changedByAffected[describeNode(affected)] = append(changedByAffected[describeNode(affected)], describeNode(changed))
+		}
+	}
 
 	presubmitIndex := presubmitsByName{}
 	for _, jobs := range allPresubmits {
@@ -780,9 +791,19 @@ func SelectJobsForChangedRegistry(regSteps []registry.Node, allPresubmits presub
 	selectedPeriodics := config.Periodics{}
 	selectedNames := sets.New[string]()
 	for _, step := range stepWorklist {
-		presubmits, periodics := selectJobsForRegistryStep(step, sortedConfigs, presubmitIndex, periodicsIndex, selectedNames, logger)
+		presubmits, periodics := selectJobsForRegistryStep(step, sortedConfigs, presubmitIndex, periodicsIndex, logger)
+		var cause []string
+		if len(presubmits) > 0 || len(periodics) > 0 {
+			cause = registryCauseChain(regSteps, step)
+		}
+		changed := changedByAffected[describeNode(step)]
 		for repo, jobs := range presubmits {
 			for _, job := range jobs {
+				explanations.addRegistryNodes(job.Name, changed...)
+				explanations.addCause(job.Name, cause...)
+				if selectedNames.Has(job.Name) {
+					continue
+				}
 				selectionFields := logrus.Fields{diffs.LogRepo: repo, diffs.LogJobName: job.Name, diffs.LogReasons: fmt.Sprintf("registry step %s changed", step.Name())}
 				logger.WithFields(selectionFields).Info(diffs.ChosenJob)
 				selectedPresubmits.Add(repo, job, config.ChangedRegistryContent)
@@ -791,6 +812,11 @@ func SelectJobsForChangedRegistry(regSteps []registry.Node, allPresubmits presub
 		}
 		for repo, jobs := range periodics {
 			for _, job := range jobs {
+				explanations.addRegistryNodes(job.Name, changed...)
+				explanations.addCause(job.Name, cause...)
+				if selectedNames.Has(job.Name) {
+					continue
+				}
 				selectionFields := logrus.Fields{diffs.LogRepo: repo, diffs.LogJobName: job.Name, diffs.LogReasons: fmt.Sprintf("registry step %s changed", step.Name())}
 				logger.WithFields(selectionFields).Info(diffs.ChosenJob)
 				selectedPeriodics.Add(job, config.ChangedRegistryContent)
@@ -810,15 +836,7 @@ func getClusterTypes(jobs map[string][]prowconfig.Presubmit) []string {
 	ret := sets.New[string]()
 	for _, jobs := range jobs {
 		for _, j := range jobs {
-			if j.Spec != nil && j.Spec.Containers != nil {
-				for _, c := range j.Spec.Containers {
-					for _, e := range c.Env {
-						if e.Name == clusterTypeEnvName {
-							ret.Insert(e.Value)
-						}
-					}
-				}
-			}
+			ret.Insert(jobClusterTypes(j.JobBase)...)
 		}
 	}
 	if len(ret) == 0 {
@@ -827,6 +845,21 @@ func getClusterTypes(jobs map[string][]prowconfig.Presubmit) []string {
 	return sets.List(ret)
 }
 
+// jobClusterTypes returns the cluster types a job provisions
+func jobClusterTypes(job prowconfig.JobBase) []string {
+	var ret []string
+	if job.Spec != nil && job.Spec.Containers != nil {
+		for _, c := range job.Spec.Containers {
+			for _, e := range c.Env {
+				if e.Name == clusterTypeEnvName {
+					ret = append(ret, e.Value)
+				}
+			}
+		}
+	}
+	return ret
+}
+
 func UsesConfigMap(job prowconfig.JobBase, cm string) bool {
 	if job.Spec != nil {
 		for _, volume := range job.Spec.Volumes {
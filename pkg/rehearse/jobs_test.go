@@ -1026,7 +1026,7 @@ func TestFilterPresubmits(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
-			presubmits := filterPresubmits(tc.presubmits, tc.disabledNames, logrus.New())
+			presubmits := filterPresubmits(tc.presubmits, tc.disabledNames, nil, logrus.New())
 			if diff := cmp.Diff(tc.expected, presubmits, cmp.AllowUnexported(prowconfig.Brancher{}, prowconfig.RegexpChangeMatcher{}, prowconfig.Presubmit{})); diff != "" {
 				t.Fatalf("filtered didn't match expected, diff: %s", diff)
 			}
@@ -1102,7 +1102,7 @@ func TestFilterPeriodics(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
-			periodics := filterPeriodics(tc.periodics, tc.disabledNames, logrus.New())
+			periodics := filterPeriodics(tc.periodics, tc.disabledNames, nil, logrus.New())
 			if diff := cmp.Diff(tc.expected, periodics, cmp.AllowUnexported(prowconfig.Brancher{}, prowconfig.RegexpChangeMatcher{}, prowconfig.Periodic{})); diff != "" {
 				t.Fatalf("filtered didn't match expected, diff: %s", diff)
 			}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -21,6 +20,7 @@ import (
 	"sigs.k8s.io/prow/pkg/flagutil"
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/kube"
+	prowplugins "sigs.k8s.io/prow/pkg/plugins"
 
 	"github.com/openshift/ci-tools/pkg/api"
 	apihelper "github.com/openshift/ci-tools/pkg/api/helper"
@@ -51,6 +51,9 @@ type RehearsalConfig struct {
 
 	StickyLabelAuthors sets.Set[string]
 
+	// JobFlakiness is used to prefer reliable jobs when not all affected jobs can be rehearsed
+	JobFlakiness JobFlakiness
+
 	GCSBucket          string
 	GCSCredentialsFile string
 	GCSBrowserPrefix   string
@@ -112,7 +115,9 @@ type ref struct {
 	ref string
 }
 
-func (r RehearsalConfig) DetermineAffectedJobs(candidate RehearsalCandidate, candidatePath string, networkAccessRehearsalsAllowed bool, logger *logrus.Entry) (*config.ReleaseRepoConfig, config.Presubmits, config.Periodics, []string, error) {
+// DetermineAffectedJobs returns the jobs affected by the candidate, the jobs that cannot be rehearsed without
+// network access being allowed, and explanations of why each job was found to be affected or was skipped
+func (r RehearsalConfig) DetermineAffectedJobs(candidate RehearsalCandidate, candidatePath string, networkAccessRehearsalsAllowed bool, logger *logrus.Entry) (*config.ReleaseRepoConfig, config.Presubmits, config.Periodics, []string, Explanations, error) {
 	start := time.Now()
 	defer func() {
 		logger.Infof("determineAffectedJobs ran in %s", time.Since(start).Truncate(time.Second))
@@ -120,18 +125,27 @@ func (r RehearsalConfig) DetermineAffectedJobs(candidate RehearsalCandidate, can
 
 	prConfig, err := config.GetAllConfigs(candidatePath)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("could not load configuration from candidate revision of release repo: %w", err)
+		return nil, nil, nil, nil, nil, fmt.Errorf("could not load configuration from candidate revision of release repo: %w", err)
 	}
 	baseSHA := candidate.base.sha
 	masterConfig, err := config.GetAllConfigsFromSHA(candidatePath, baseSHA)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("could not load configuration from base revision of release repo: %w", err)
+		return nil, nil, nil, nil, nil, fmt.Errorf("could not load configuration from base revision of release repo: %w", err)
 	}
 
+	explanations := Explanations{}
 	presubmits := config.Presubmits{}
 	presubmits.AddAll(diffs.GetChangedPresubmits(masterConfig.Prow, prConfig.Prow, logger), config.ChangedPresubmit)
 	periodics := config.Periodics{}
 	periodics.AddAll(diffs.GetChangedPeriodics(masterConfig.Prow, prConfig.Prow, logger), config.ChangedPeriodic)
+	for _, jobs := range presubmits {
+		for _, job := range jobs {
+			explanations.addCause(job.Name, "presubmit job configuration changed")
+		}
+	}
+	for name := range periodics {
+		explanations.addCause(name, "periodic job configuration changed")
+	}
 	var restrictNetworkAccessFalseJobs []string
 
 	// We can only detect changes if we managed to load both ci-operator config versions
@@ -144,6 +158,7 @@ func (r RehearsalConfig) DetermineAffectedJobs(candidate RehearsalCandidate, can
 			restrictNetworkAccessFalseJobs = []string{}
 		}
 		presubmitsForCiopConfigs, periodicsForCiopConfigs := diffs.GetJobsForCiopConfigs(prConfig.Prow, changedCiopConfigData, affectedJobs, logger)
+		explanations.explainCiopConfigJobs(presubmitsForCiopConfigs, periodicsForCiopConfigs, changedCiopConfigData, affectedJobs)
 		presubmits.AddAll(presubmitsForCiopConfigs, config.ChangedCiopConfig)
 		periodics.AddAll(periodicsForCiopConfigs, config.ChangedCiopConfig)
 	}
@@ -152,17 +167,40 @@ func (r RehearsalConfig) DetermineAffectedJobs(candidate RehearsalCandidate, can
 	if !r.NoRegistry {
 		changedRegistrySteps, err = determineChangedRegistrySteps(candidatePath, baseSHA, logger)
 		if err != nil {
-			return nil, nil, nil, nil, fmt.Errorf("could not determine changed registry steps: %w", err)
+			return nil, nil, nil, nil, nil, fmt.Errorf("could not determine changed registry steps: %w", err)
 		}
-		presubmitsForRegistry, periodicsForRegistry := SelectJobsForChangedRegistry(changedRegistrySteps, prConfig.Prow.JobConfig.PresubmitsStatic, prConfig.Prow.JobConfig.Periodics, prConfig.CiOperator, logger)
+		presubmitsForRegistry, periodicsForRegistry := SelectJobsForChangedRegistry(changedRegistrySteps, prConfig.Prow.JobConfig.PresubmitsStatic, prConfig.Prow.JobConfig.Periodics, prConfig.CiOperator, explanations, logger)
 		presubmits.AddAll(presubmitsForRegistry, config.ChangedRegistryContent)
 		periodics.AddAll(periodicsForRegistry, config.ChangedRegistryContent)
 	}
 
-	return prConfig, filterPresubmits(presubmits, restrictNetworkAccessFalseJobs, logger), filterPeriodics(periodics, restrictNetworkAccessFalseJobs, logger), restrictNetworkAccessFalseJobs, nil
+	cms, err := changedConfigMaps(candidatePath, candidate)
+	if err != nil {
+		logger.WithError(err).Warn("Could not determine all changed ConfigMaps, jobs mounting them may not be explained by them.")
+	}
+	explanations.ExplainConfigMaps(presubmits, periodics, cms)
+	explanations.explainBySourceType(presubmits, periodics)
+	return prConfig, filterPresubmits(presubmits, restrictNetworkAccessFalseJobs, explanations, logger), filterPeriodics(periodics, restrictNetworkAccessFalseJobs, explanations, logger), restrictNetworkAccessFalseJobs, explanations, nil
 }
 
-func (r RehearsalConfig) SetupJobs(candidate RehearsalCandidate, candidatePath string, prConfig *config.ReleaseRepoConfig, presubmits config.Presubmits, periodics config.Periodics, limit int, logger *logrus.Entry) (*config.ReleaseRepoConfig, *prowapi.Refs, []*prowconfig.Presubmit, error) {
+// changedConfigMaps determines the ConfigMaps config-updater populates from
+// the templates the candidate changed
+func changedConfigMaps(candidatePath string, candidate RehearsalCandidate) (ConfigMaps, error) {
+	changed, err := config.GetChangedTemplates(candidatePath, candidate.base.sha)
+	if err != nil || len(changed) == 0 {
+		return ConfigMaps{}, err
+	}
+	pluginConfigPath := filepath.Join(candidatePath, config.PluginConfigInRepoPath)
+	agent := prowplugins.ConfigAgent{}
+	if err := agent.Load(pluginConfigPath, []string{filepath.Dir(pluginConfigPath)}, config.SupplementalPluginConfigFileName, false, false); err != nil {
+		return ConfigMaps{}, fmt.Errorf("could not load plugin configuration: %w", err)
+	}
+	return NewConfigMaps(changed, "explain", candidate.base.sha, candidate.prNumber, agent.Config().ConfigUpdater)
+}
+
+// SetupJobs configures the rehearsals of up to limit affected jobs, recording the jobs that
+// were left out because of the limit in the explanations
+func (r RehearsalConfig) SetupJobs(candidate RehearsalCandidate, candidatePath string, prConfig *config.ReleaseRepoConfig, presubmits config.Presubmits, periodics config.Periodics, limit int, explanations Explanations, logger *logrus.Entry) (*config.ReleaseRepoConfig, *prowapi.Refs, []*prowconfig.Presubmit, error) {
 	resolver, err := r.createResolver(candidatePath)
 	if err != nil {
 		return nil, nil, nil, err
@@ -199,7 +237,18 @@ func (r RehearsalConfig) SetupJobs(candidate RehearsalCandidate, candidatePath s
 			"rehearsal-jobs":      rehearsals,
 		}
 		logger.WithFields(jobCountFields).Info("Would rehearse too many jobs, selecting a subset")
-		presubmitsToRehearse = determineSubsetToRehearse(presubmitsToRehearse, limit)
+		prefix := fmt.Sprintf("rehearse-%d-", candidate.prNumber)
+		subset := determineSubsetToRehearse(presubmitsToRehearse, limit, rehearsalSampler{prefix: prefix, explanations: explanations, flakiness: r.JobFlakiness})
+		selected := sets.New[string]()
+		for _, presubmit := range subset {
+			selected.Insert(presubmit.Name)
+		}
+		for _, presubmit := range presubmitsToRehearse {
+			if !selected.Has(presubmit.Name) {
+				explanations.skip(strings.TrimPrefix(presubmit.Name, prefix), fmt.Sprintf("truncated by the rehearsal limit, only %d of the %d affected jobs are rehearsed at once", limit, rehearsals))
+			}
+		}
+		presubmitsToRehearse = subset
 	}
 
 	if prConfig.Prow.JobConfig.PresubmitsStatic == nil {
@@ -346,45 +395,6 @@ func determineChangedRegistrySteps(candidate, baseSHA string, logger *logrus.Ent
 	return changedRegistrySteps, nil
 }
 
-// determineSubsetToRehearse determines in a sophisticated way which subset of jobs should be chosen to be rehearsed.
-// First, it will create a list of the jobs mapped by the source type and calculates the maximum allowed jobs for each
-// source type. If there are jobs from a specific source type that are under the max allowed number, it will fill the gap
-// from the other not chosen jobs until it reaches the rehearsal limit.
-func determineSubsetToRehearse(presubmitsToRehearse []*prowconfig.Presubmit, rehearsalLimit int) []*prowconfig.Presubmit {
-	if len(presubmitsToRehearse) <= rehearsalLimit {
-		return presubmitsToRehearse
-	}
-
-	presubmitsBySourceType := make(map[config.SourceType][]*prowconfig.Presubmit)
-	for _, p := range presubmitsToRehearse {
-		sourceType := config.GetSourceType(p.Labels)
-		presubmitsBySourceType[sourceType] = append(presubmitsBySourceType[sourceType], p)
-	}
-
-	maxJobsPerSourceType := rehearsalLimit / len(presubmitsBySourceType)
-	var toRehearse []*prowconfig.Presubmit
-	var dropped []*prowconfig.Presubmit
-
-	for _, jobs := range presubmitsBySourceType {
-		if len(jobs) > maxJobsPerSourceType {
-			dropped = append(dropped, jobs[maxJobsPerSourceType:]...)
-			jobs = jobs[:maxJobsPerSourceType]
-		}
-		toRehearse = append(toRehearse, jobs...)
-	}
-
-	// There are two ways that we will hit this check. First, jobs from a specific resource are less than the
-	// maximum allowed, we will end up having less rehearsals than the limit. Second, summary of the maximum allowed jobs
-	// from each source can be lower than the rehearse limit  due to the rounding inherent in integer division.
-	// In both cases, we fill up the gap from the jobs that we didn't pick earlier.
-	if len(toRehearse) <= rehearsalLimit {
-		sort.Slice(dropped, func(a, b int) bool { return dropped[a].Name < dropped[b].Name })
-		toRehearse = append(toRehearse, dropped[:rehearsalLimit-len(toRehearse)]...)
-	}
-
-	return toRehearse
-}
-
 func pjKubeconfig(path string, defaultKubeconfig *rest.Config) (*rest.Config, error) {
 	if path == "" {
 		return defaultKubeconfig, nil
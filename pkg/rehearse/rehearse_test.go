@@ -1,15 +1,21 @@
 package rehearse
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
 
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	prowconfig "sigs.k8s.io/prow/pkg/config"
 
 	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
 func TestDetermineSubsetToRehearse(t *testing.T) {
@@ -89,14 +95,14 @@ func TestDetermineSubsetToRehearse(t *testing.T) {
 			rehearsalLimit: 5,
 			expected: []*prowconfig.Presubmit{
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-1", Labels: map[string]string{config.SourceTypeLabel: "changedPresubmit"}}},
-				{JobBase: prowconfig.JobBase{Name: "rehearsal-10", Labels: map[string]string{config.SourceTypeLabel: "changedRegistryContent"}}},
+				{JobBase: prowconfig.JobBase{Name: "rehearsal-2", Labels: map[string]string{config.SourceTypeLabel: "changedPresubmit"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-3", Labels: map[string]string{config.SourceTypeLabel: "changedPeriodic"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-5", Labels: map[string]string{config.SourceTypeLabel: "changedTemplate"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-9", Labels: map[string]string{config.SourceTypeLabel: "changedRegistryContent"}}},
 			},
 		},
 		{
-			id: "summary of the maximum allowed jobs per source is lower that the rehearse limit (rounding inherent in integer division)",
+			id: "every source is covered before filling the remaining budget in order",
 			presubmitsToRehearse: []*prowconfig.Presubmit{
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-1", Labels: map[string]string{config.SourceTypeLabel: "changedPresubmit"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-2", Labels: map[string]string{config.SourceTypeLabel: "changedPresubmit"}}},
@@ -115,13 +121,13 @@ func TestDetermineSubsetToRehearse(t *testing.T) {
 			expected: []*prowconfig.Presubmit{
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-1", Labels: map[string]string{config.SourceTypeLabel: "changedPresubmit"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-10", Labels: map[string]string{config.SourceTypeLabel: "changedTemplate"}}},
-				{JobBase: prowconfig.JobBase{Name: "rehearsal-11", Labels: map[string]string{config.SourceTypeLabel: "changedTemplate"}}},
-				{JobBase: prowconfig.JobBase{Name: "rehearsal-12", Labels: map[string]string{config.SourceTypeLabel: "changedTemplate"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-2", Labels: map[string]string{config.SourceTypeLabel: "changedPresubmit"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-3", Labels: map[string]string{config.SourceTypeLabel: "changedPresubmit"}}},
+				{JobBase: prowconfig.JobBase{Name: "rehearsal-4", Labels: map[string]string{config.SourceTypeLabel: "changedPresubmit"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-5", Labels: map[string]string{config.SourceTypeLabel: "changedPeriodic"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-6", Labels: map[string]string{config.SourceTypeLabel: "changedPeriodic"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-7", Labels: map[string]string{config.SourceTypeLabel: "changedPeriodic"}}},
+				{JobBase: prowconfig.JobBase{Name: "rehearsal-8", Labels: map[string]string{config.SourceTypeLabel: "changedPeriodic"}}},
 				{JobBase: prowconfig.JobBase{Name: "rehearsal-9", Labels: map[string]string{config.SourceTypeLabel: "changedTemplate"}}},
 			},
 		},
@@ -143,7 +149,7 @@ func TestDetermineSubsetToRehearse(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.id, func(t *testing.T) {
-			actual := determineSubsetToRehearse(tc.presubmitsToRehearse, tc.rehearsalLimit)
+			actual := determineSubsetToRehearse(tc.presubmitsToRehearse, tc.rehearsalLimit, rehearsalSampler{})
 			sort.Slice(actual, func(a, b int) bool { return actual[a].Name < actual[b].Name })
 
 			if diff := cmp.Diff(actual, tc.expected, allowUnexported); diff != "" {
@@ -154,6 +160,84 @@ func TestDetermineSubsetToRehearse(t *testing.T) {
 	}
 }
 
+func TestDetermineSubsetToRehearseCoverage(t *testing.T) {
+	rehearsal := func(name, clusterType string, labels map[string]string) *prowconfig.Presubmit {
+		labels[config.SourceTypeLabel] = string(config.ChangedRegistryContent)
+		return &prowconfig.Presubmit{JobBase: prowconfig.JobBase{
+			Name:   "rehearse-1-" + name,
+			Labels: labels,
+			Spec:   &v1.PodSpec{Containers: []v1.Container{{Env: []v1.EnvVar{{Name: clusterTypeEnvName, Value: clusterType}}}}},
+		}}
+	}
+	presubmits := []*prowconfig.Presubmit{
+		rehearsal("a", "aws", map[string]string{}),
+		rehearsal("b", "aws", map[string]string{}),
+		rehearsal("c", "gcp", map[string]string{}),
+		rehearsal("d", "aws", map[string]string{"capability/arm64": "arm64"}),
+		rehearsal("e", "aws", map[string]string{}),
+		rehearsal("f", "aws", map[string]string{}),
+	}
+	sampler := rehearsalSampler{
+		prefix: "rehearse-1-",
+		explanations: Explanations{
+			"a": {RegistryNodes: sets.New[string]("reference x")},
+			"b": {RegistryNodes: sets.New[string]("reference x", "reference y")},
+			"c": {RegistryNodes: sets.New[string]("reference y")},
+			"e": {RegistryNodes: sets.New[string]("reference x")},
+		},
+		flakiness: JobFlakiness{"e": 0, "f": 0.9},
+	}
+
+	var actual []string
+	for _, p := range determineSubsetToRehearse(presubmits, 4, sampler) {
+		actual = append(actual, p.Name)
+	}
+	// b covers both changed nodes, c the gcp cluster type and d the arm64 architecture; e is the most reliable of the rest
+	expected := []string{"rehearse-1-b", "rehearse-1-c", "rehearse-1-d", "rehearse-1-e"}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected rehearsals: %s", diff)
+	}
+}
+
+func TestLoadJobFlakiness(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		return path
+	}
+	testCases := []struct {
+		name        string
+		path        string
+		expected    JobFlakiness
+		expectedErr error
+	}{
+		{
+			name:     "valid",
+			path:     write("valid.json", `{"job-a": 0.1, "job-b": 0}`),
+			expected: JobFlakiness{"job-a": 0.1, "job-b": 0},
+		},
+		{
+			name:        "out of range",
+			path:        write("invalid.json", `{"job-a": 1.5}`),
+			expectedErr: errors.New("flakiness of job job-a must be between 0 and 1, got 1.5"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := LoadJobFlakiness(tc.path)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected flakiness: %s", diff)
+			}
+		})
+	}
+}
+
 func TestFilterJobsByRequested(t *testing.T) {
 	testCases := []struct {
 		name                   string
@@ -0,0 +1,153 @@
+package rehearse
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+)
+
+// unknownJobFlakiness is assumed for jobs without history, ranking them below
+// reliable jobs and above jobs that are known to be flaky
+const unknownJobFlakiness = 0.5
+
+// JobFlakiness maps job names to the fraction of their recent runs which failed
+// and then passed without any change. A failed rehearsal of a flaky job says
+// little about the change being rehearsed.
+type JobFlakiness map[string]float64
+
+// LoadJobFlakiness loads job flakiness from a JSON file mapping job names to a fraction
+func LoadJobFlakiness(path string) (JobFlakiness, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read job flakiness: %w", err)
+	}
+	var flakiness JobFlakiness
+	if err := json.Unmarshal(raw, &flakiness); err != nil {
+		return nil, fmt.Errorf("could not unmarshal job flakiness: %w", err)
+	}
+	for job, value := range flakiness {
+		if value < 0 || value > 1 {
+			return nil, fmt.Errorf("flakiness of job %s must be between 0 and 1, got %v", job, value)
+		}
+	}
+	return flakiness, nil
+}
+
+// value is the worth of rehearsing a job, higher for jobs whose failures are more likely to be caused by the change
+func (f JobFlakiness) value(job string) float64 {
+	flakiness, ok := f[job]
+	if !ok {
+		flakiness = unknownJobFlakiness
+	}
+	return 1 - flakiness
+}
+
+// jobArchitecture returns the node architecture a job runs on, as declared by its capability labels
+func jobArchitecture(labels map[string]string) string {
+	for _, arch := range []api.NodeArchitecture{api.NodeArchitectureARM64, api.NodeArchitectureS390x, api.NodeArchitecturePPC64le} {
+		if _, ok := labels[fmt.Sprintf("capability/%s", arch)]; ok {
+			return string(arch)
+		}
+	}
+	return string(api.NodeArchitectureAMD64)
+}
+
+// rehearsalCoverage returns what rehearsing a job exercises: the kind of change that
+// affected it, the changed registry nodes it uses, the cluster types it provisions
+// and the node architecture it runs on
+func rehearsalCoverage(rehearsal *prowconfig.Presubmit, registryNodes sets.Set[string]) sets.Set[string] {
+	coverage := sets.New[string](
+		fmt.Sprintf("source:%s", config.GetSourceType(rehearsal.Labels)),
+		fmt.Sprintf("architecture:%s", jobArchitecture(rehearsal.Labels)),
+	)
+	for node := range registryNodes {
+		coverage.Insert(fmt.Sprintf("registry:%s", node))
+	}
+	for _, clusterType := range jobClusterTypes(rehearsal.JobBase) {
+		coverage.Insert(fmt.Sprintf("cluster-type:%s", clusterType))
+	}
+	return coverage
+}
+
+// rehearsalSampler holds what is known about the jobs to choose rehearsals from
+type rehearsalSampler struct {
+	// prefix is prepended to the names of the source jobs for their rehearsals
+	prefix       string
+	explanations Explanations
+	flakiness    JobFlakiness
+}
+
+func (s rehearsalSampler) coverage(rehearsal *prowconfig.Presubmit) sets.Set[string] {
+	var nodes sets.Set[string]
+	if explanation, ok := s.explanations[strings.TrimPrefix(rehearsal.Name, s.prefix)]; ok {
+		nodes = explanation.RegistryNodes
+	}
+	return rehearsalCoverage(rehearsal, nodes)
+}
+
+func (s rehearsalSampler) value(rehearsal *prowconfig.Presubmit) float64 {
+	return s.flakiness.value(strings.TrimPrefix(rehearsal.Name, s.prefix))
+}
+
+// determineSubsetToRehearse chooses the rehearsals that give the most confidence in a change within the limit.
+// First, it greedily picks the smallest set of jobs that together cover everything the affected jobs exercise:
+// every changed registry node, kind of change, cluster type and node architecture. The remaining budget is
+// filled with the most valuable jobs, preferring jobs that are not flaky. Ties are broken by the order of the
+// input, which puts the jobs for more relevant branches first.
+func determineSubsetToRehearse(presubmitsToRehearse []*prowconfig.Presubmit, rehearsalLimit int, sampler rehearsalSampler) []*prowconfig.Presubmit {
+	if len(presubmitsToRehearse) <= rehearsalLimit {
+		return presubmitsToRehearse
+	}
+
+	coverage := make([]sets.Set[string], len(presubmitsToRehearse))
+	values := make([]float64, len(presubmitsToRehearse))
+	for i, p := range presubmitsToRehearse {
+		coverage[i] = sampler.coverage(p)
+		values[i] = sampler.value(p)
+	}
+
+	chosen := make([]bool, len(presubmitsToRehearse))
+	var toRehearse []*prowconfig.Presubmit
+	covered := sets.New[string]()
+	for len(toRehearse) < rehearsalLimit {
+		best, bestGain := -1, 0
+		for i := range presubmitsToRehearse {
+			if chosen[i] {
+				continue
+			}
+			gain := coverage[i].Difference(covered).Len()
+			if gain > bestGain || (gain > 0 && gain == bestGain && values[i] > values[best]) {
+				best, bestGain = i, gain
+			}
+		}
+		if best == -1 {
+			break
+		}
+		chosen[best] = true
+		covered = covered.Union(coverage[best])
+		toRehearse = append(toRehearse, presubmitsToRehearse[best])
+	}
+
+	var remaining []int
+	for i := range presubmitsToRehearse {
+		if !chosen[i] {
+			remaining = append(remaining, i)
+		}
+	}
+	sort.SliceStable(remaining, func(a, b int) bool {
+		return values[remaining[a]] > values[remaining[b]]
+	})
+	for _, i := range remaining[:rehearsalLimit-len(toRehearse)] {
+		toRehearse = append(toRehearse, presubmitsToRehearse[i])
+	}
+
+	return toRehearse
+}
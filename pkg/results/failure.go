@@ -0,0 +1,268 @@
+package results
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+const (
+	// FailureRecordVersion is the version of the FailureRecord schema, bumped
+	// on incompatible changes
+	FailureRecordVersion = "v1"
+	// FailuresFilename is the name of the job artifact holding the FailureRecord
+	FailuresFilename = "ci-operator-failures.json"
+)
+
+// Classification attributes a failure to the infrastructure running the job,
+// the code under test or the configuration of the job.
+type Classification string
+
+const (
+	ClassificationInfrastructure Classification = "infrastructure"
+	ClassificationTest           Classification = "test"
+	ClassificationConfiguration  Classification = "configuration"
+	ClassificationUnknown        Classification = "unknown"
+)
+
+// reasonClassifications attributes reasons to a classification, the innermost
+// reason in a chain with a classification determines that of the failure
+var reasonClassifications = map[Reason]Classification{
+	"loading_args":               ClassificationConfiguration,
+	"loading_config":             ClassificationConfiguration,
+	"validating_config":          ClassificationConfiguration,
+	"defaulting_config":          ClassificationConfiguration,
+	"config_resolver":            ClassificationConfiguration,
+	"config_resolver_literal":    ClassificationConfiguration,
+	"building_graph":             ClassificationConfiguration,
+	"interrupted":                ClassificationInfrastructure,
+	"initializing_namespace":     ClassificationInfrastructure,
+	"creating_service_account":   ClassificationInfrastructure,
+	"creating_roles":             ClassificationInfrastructure,
+	"binding_roles":              ClassificationInfrastructure,
+	"create_dockercfg_secrets":   ClassificationInfrastructure,
+	"gsm_client":                 ClassificationInfrastructure,
+	"acquiring_lease":            ClassificationInfrastructure,
+	"releasing_lease":            ClassificationInfrastructure,
+	"acquiring_ip_pool_lease":    ClassificationInfrastructure,
+	"releasing_ip_pool_lease":    ClassificationInfrastructure,
+	"acquiring_cluster_claim":    ClassificationInfrastructure,
+	"releasing_cluster_claim":    ClassificationInfrastructure,
+	"executing_lease_proxy":      ClassificationInfrastructure,
+	"importing_release":          ClassificationInfrastructure,
+	"resolving_release":          ClassificationInfrastructure,
+	"reading_release":            ClassificationInfrastructure,
+	"promoting_images":           ClassificationInfrastructure,
+	"tagging_input_image":        ClassificationInfrastructure,
+	"tagging_output_image":       ClassificationInfrastructure,
+	"cloning_source":             ClassificationTest,
+	"building_image":             ClassificationTest,
+	"building_project_image":     ClassificationTest,
+	"building_bundle_source":     ClassificationTest,
+	"building_index_generator":   ClassificationTest,
+	"assembling_release":         ClassificationTest,
+	"creating_release":           ClassificationTest,
+	"running_pod":                ClassificationTest,
+	"executing_test":             ClassificationTest,
+	"executing_template":         ClassificationTest,
+	"executing_multi_stage_test": ClassificationTest,
+}
+
+// Classify determines the classification of a failure from its chain of
+// reasons, outermost first, and the multi-stage phase it happened in, if any.
+// Steps in the pre and post phases set up and tear down the environment under
+// test, so their failures are attributed to the infrastructure.
+func Classify(reasons []Reason, phase string) Classification {
+	for i := len(reasons) - 1; i >= 0; i-- {
+		classification, known := reasonClassifications[reasons[i]]
+		if !known {
+			continue
+		}
+		if classification == ClassificationTest && (phase == "pre" || phase == "post") {
+			return ClassificationInfrastructure
+		}
+		return classification
+	}
+	return ClassificationUnknown
+}
+
+// Details describes the context in which an error happened.
+type Details struct {
+	// Step is the name of the step that failed
+	Step string
+	// Phase is the multi-stage test phase the step ran in: pre, test or post
+	Phase string
+	// LeaseTypes are the types of resources leased for the step
+	LeaseTypes []string
+	// Images holds the pull specs of the images involved in the failure by
+	// name, using digests where they are known
+	Images map[string]string
+}
+
+// merge combines outer details with those of an error it wraps, which are
+// more specific and take precedence
+func (d Details) merge(inner Details) Details {
+	ret := Details{Step: d.Step, Phase: d.Phase}
+	if inner.Step != "" {
+		ret.Step = inner.Step
+	}
+	if inner.Phase != "" {
+		ret.Phase = inner.Phase
+	}
+	for _, rtype := range append(slices.Clone(d.LeaseTypes), inner.LeaseTypes...) {
+		if !slices.Contains(ret.LeaseTypes, rtype) {
+			ret.LeaseTypes = append(ret.LeaseTypes, rtype)
+		}
+	}
+	if len(d.Images)+len(inner.Images) > 0 {
+		ret.Images = maps.Clone(d.Images)
+		if ret.Images == nil {
+			ret.Images = map[string]string{}
+		}
+		maps.Copy(ret.Images, inner.Images)
+	}
+	return ret
+}
+
+type detailedError struct {
+	details Details
+	wrapped error
+}
+
+func (e *detailedError) Error() string {
+	return e.wrapped.Error()
+}
+
+func (e *detailedError) Unwrap() error {
+	return e.wrapped
+}
+
+// WithDetails annotates an error with the context it happened in, which is
+// exported along with its reasons. The message of the error is unchanged.
+func WithDetails(err error, details Details) error {
+	if err == nil {
+		return nil
+	}
+	return &detailedError{details: details, wrapped: err}
+}
+
+// Failure is a single failure of a job, along with its classification.
+type Failure struct {
+	// Reason is a colon-delimited chain of reasons for the failure, as
+	// provided by Reasons
+	Reason         string            `json:"reason"`
+	Classification Classification    `json:"classification"`
+	Step           string            `json:"step,omitempty"`
+	Phase          string            `json:"phase,omitempty"`
+	LeaseTypes     []string          `json:"lease_types,omitempty"`
+	Images         map[string]string `json:"images,omitempty"`
+	// Message is the error message, which is only written to job artifacts
+	Message string `json:"message,omitempty"`
+}
+
+// FailureRecord holds all failures of a job.
+type FailureRecord struct {
+	Version string `json:"version"`
+	// JobName is the name of the job for which failures are being recorded
+	JobName string `json:"job_name"`
+	// BuildID identifies the run of the job
+	BuildID string `json:"build_id,omitempty"`
+	// Type is the type of job ("presubmit", "postsubmit", "periodic" or "batch")
+	Type string `json:"type"`
+	// Cluster is the cluster's console hostname
+	Cluster   string    `json:"cluster"`
+	Timestamp time.Time `json:"timestamp"`
+	Failures  []Failure `json:"failures"`
+}
+
+// NewFailureRecord records the failures of a job.
+func NewFailureRecord(spec *api.JobSpec, cluster string, errs ...error) FailureRecord {
+	record := FailureRecord{
+		Version:   FailureRecordVersion,
+		Cluster:   cluster,
+		Timestamp: time.Now(),
+		Failures:  Failures(errs...),
+	}
+	if spec != nil {
+		record.JobName, record.BuildID, record.Type = spec.Job, spec.BuildID, string(spec.Type)
+	}
+	return record
+}
+
+// Failures provides a Failure for every chain of error reasons, along with the
+// Details the errors in the chain were annotated with. Like Reasons, aggregate
+// errors are recursively expanded, but failures annotated with different
+// details, e.g. of separate steps, are reported separately even if they share
+// a chain of reasons.
+func Failures(errs ...error) []Failure {
+	var ret []Failure
+	for _, err := range errs {
+		collected := collectFailures(err, Details{})
+		if len(collected) == 0 && err != nil {
+			collected = []collectedFailure{{reasons: []Reason{ReasonUnknown}, message: err.Error()}}
+		}
+		for _, f := range collected {
+			phase := f.details.Phase
+			failure := Failure{
+				Classification: Classify(f.reasons, phase),
+				Step:           f.details.Step,
+				Phase:          phase,
+				LeaseTypes:     f.details.LeaseTypes,
+				Images:         f.details.Images,
+				Message:        f.message,
+			}
+			var reasons []string
+			for _, reason := range f.reasons {
+				reasons = append(reasons, string(reason))
+			}
+			failure.Reason = strings.Join(reasons, ":")
+			if failure.Reason == "" {
+				failure.Reason = string(ReasonUnknown)
+			}
+			ret = append(ret, failure)
+		}
+	}
+	return ret
+}
+
+type collectedFailure struct {
+	reasons []Reason
+	details Details
+	message string
+}
+
+func collectFailures(err error, details Details) []collectedFailure {
+	switch err := err.(type) {
+	case nil:
+		return nil
+	case *Error:
+		children := collectFailures(err.Unwrap(), details)
+		if len(children) == 0 {
+			return []collectedFailure{{reasons: []Reason{err.reason}, details: details, message: err.Error()}}
+		}
+		for i := range children {
+			children[i].reasons = append([]Reason{err.reason}, children[i].reasons...)
+		}
+		return children
+	case *detailedError:
+		details = details.merge(err.details)
+		children := collectFailures(err.Unwrap(), details)
+		if len(children) == 0 {
+			return []collectedFailure{{details: details, message: err.Error()}}
+		}
+		return children
+	case interface{ Errors() []error }:
+		var ret []collectedFailure
+		for _, child := range err.Errors() {
+			ret = append(ret, collectFailures(child, details)...)
+		}
+		return ret
+	case interface{ Unwrap() error }:
+		return collectFailures(err.Unwrap(), details)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,143 @@
+package results
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+func TestFailures(t *testing.T) {
+	podFailure := func(step, phase string) error {
+		return WithDetails(fmt.Errorf("pod %s failed", step), Details{
+			Step:   step,
+			Phase:  phase,
+			Images: map[string]string{"test": "registry.ci/ci-op-1234/pipeline@sha256:abc"},
+		})
+	}
+	testCases := []struct {
+		name     string
+		errs     []error
+		expected []Failure
+	}{
+		{
+			name: "no errors",
+		},
+		{
+			name:     "error without reason",
+			errs:     []error{errors.New("oops")},
+			expected: []Failure{{Reason: "unknown", Classification: ClassificationUnknown, Message: "oops"}},
+		},
+		{
+			name: "reason chain is classified by the innermost known reason",
+			errs: []error{ForReason("step_failed").WithError(ForReason("acquiring_lease").ForError(errors.New("no quota"))).Errorf("step failed")},
+			expected: []Failure{{
+				Reason:         "step_failed:acquiring_lease",
+				Classification: ClassificationInfrastructure,
+				Message:        "no quota",
+			}},
+		},
+		{
+			name: "failing steps of a multi-stage test are reported separately",
+			errs: []error{
+				ForReason("step_failed").ForError(
+					WithDetails(
+						ForReason("utilizing_lease").ForError(
+							ForReason("executing_test").ForError(
+								ForReason("executing_multi_stage_test").ForError(utilerrors.NewAggregate([]error{
+									fmt.Errorf("test steps failed: %w", podFailure("e2e", "test")),
+									fmt.Errorf("post steps failed: %w", podFailure("gather", "post")),
+								})),
+							),
+						),
+						Details{Step: "e2e-aws", LeaseTypes: []string{"aws-quota-slice"}},
+					),
+				),
+				ForReason("releasing_cluster_claim").ForError(errors.New("claim gone")),
+			},
+			expected: []Failure{
+				{
+					Reason:         "step_failed:utilizing_lease:executing_test:executing_multi_stage_test",
+					Classification: ClassificationTest,
+					Step:           "e2e",
+					Phase:          "test",
+					LeaseTypes:     []string{"aws-quota-slice"},
+					Images:         map[string]string{"test": "registry.ci/ci-op-1234/pipeline@sha256:abc"},
+					Message:        "pod e2e failed",
+				},
+				{
+					Reason:         "step_failed:utilizing_lease:executing_test:executing_multi_stage_test",
+					Classification: ClassificationInfrastructure,
+					Step:           "gather",
+					Phase:          "post",
+					LeaseTypes:     []string{"aws-quota-slice"},
+					Images:         map[string]string{"test": "registry.ci/ci-op-1234/pipeline@sha256:abc"},
+					Message:        "pod gather failed",
+				},
+				{
+					Reason:         "releasing_cluster_claim",
+					Classification: ClassificationInfrastructure,
+					Message:        "claim gone",
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Failures(tc.errs...)); diff != "" {
+				t.Errorf("unexpected failures: %s", diff)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		name     string
+		reasons  []Reason
+		phase    string
+		expected Classification
+	}{
+		{
+			name:     "no reasons",
+			expected: ClassificationUnknown,
+		},
+		{
+			name:     "unknown reasons",
+			reasons:  []Reason{"step_failed", "something"},
+			expected: ClassificationUnknown,
+		},
+		{
+			name:     "configuration",
+			reasons:  []Reason{"loading_config"},
+			expected: ClassificationConfiguration,
+		},
+		{
+			name:     "innermost reason wins",
+			reasons:  []Reason{"utilizing_lease", "executing_test", "releasing_lease"},
+			expected: ClassificationInfrastructure,
+		},
+		{
+			name:     "test phase of a multi-stage test",
+			reasons:  []Reason{"executing_multi_stage_test"},
+			phase:    "test",
+			expected: ClassificationTest,
+		},
+		{
+			name:     "pre phase of a multi-stage test",
+			reasons:  []Reason{"executing_multi_stage_test"},
+			phase:    "pre",
+			expected: ClassificationInfrastructure,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Classify(tc.reasons, tc.phase); actual != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
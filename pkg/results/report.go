@@ -150,6 +150,28 @@ func (r *reporter) Report(err error) {
 			Reason:  reason,
 		})
 	}
+	if err != nil {
+		r.reportFailures(NewFailureRecord(r.spec, r.consoleHost, err))
+	}
+}
+
+// reportFailures sends the failure record to the aggregation server. Error
+// messages may hold sensitive data and are only written to job artifacts.
+func (r *reporter) reportFailures(record FailureRecord) {
+	for i := range record.Failures {
+		record.Failures[i].Message = ""
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		logrus.Tracef("could not marshal failure record: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/failure", r.address), bytes.NewReader(data))
+	if err != nil {
+		logrus.Tracef("could not create failure record request: %v", err)
+		return
+	}
+	sendRequest(req, r.client, r.username, r.password)
 }
 
 func (r *reporter) report(request Request) {
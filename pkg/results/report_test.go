@@ -2,6 +2,7 @@ package results
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -24,11 +26,12 @@ import (
 
 func TestReporter_Report(t *testing.T) {
 	var testCases = []struct {
-		name        string
-		spec        *api.JobSpec
-		consoleHost string
-		err         error
-		expected    string
+		name             string
+		spec             *api.JobSpec
+		consoleHost      string
+		err              error
+		expected         string
+		expectedFailures []FailureRecord
 	}{
 		{
 			name:        "nil err reports success",
@@ -43,6 +46,13 @@ func TestReporter_Report(t *testing.T) {
 			consoleHost: "foo.com",
 			err:         errors.New("something"),
 			expected:    `{"job_name":"runme","type":"presubmit","cluster":"foo.com","state":"failed","reason":"unknown"}`,
+			expectedFailures: []FailureRecord{{
+				Version:  FailureRecordVersion,
+				JobName:  "runme",
+				Type:     "presubmit",
+				Cluster:  "foo.com",
+				Failures: []Failure{{Reason: "unknown", Classification: ClassificationUnknown}},
+			}},
 		},
 		{
 			name:        "reasoned err reports failure with specific reason",
@@ -50,6 +60,13 @@ func TestReporter_Report(t *testing.T) {
 			consoleHost: "foo.com",
 			err:         ForReason("because").ForError(errors.New("oops")),
 			expected:    `{"job_name":"runme","type":"presubmit","cluster":"foo.com","state":"failed","reason":"because"}`,
+			expectedFailures: []FailureRecord{{
+				Version:  FailureRecordVersion,
+				JobName:  "runme",
+				Type:     "presubmit",
+				Cluster:  "foo.com",
+				Failures: []Failure{{Reason: "because", Classification: ClassificationUnknown}},
+			}},
 		},
 		{
 			name:        "nested reasoned err reports failure with specific reason",
@@ -57,11 +74,33 @@ func TestReporter_Report(t *testing.T) {
 			consoleHost: "foo.com",
 			err:         ForReason("because").WithError(ForReason("something").ForError(errors.New("oops"))).Errorf("argh"),
 			expected:    `{"job_name":"runme","type":"presubmit","cluster":"foo.com","state":"failed","reason":"because:something"}`,
+			expectedFailures: []FailureRecord{{
+				Version:  FailureRecordVersion,
+				JobName:  "runme",
+				Type:     "presubmit",
+				Cluster:  "foo.com",
+				Failures: []Failure{{Reason: "because:something", Classification: ClassificationUnknown}},
+			}},
+		},
+		{
+			name:        "failure details are reported without the message",
+			spec:        &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "runme", Type: v1.PresubmitJob}},
+			consoleHost: "foo.com",
+			err:         ForReason("executing_multi_stage_test").ForError(WithDetails(errors.New("oops"), Details{Step: "e2e", Phase: "test"})),
+			expected:    `{"job_name":"runme","type":"presubmit","cluster":"foo.com","state":"failed","reason":"executing_multi_stage_test"}`,
+			expectedFailures: []FailureRecord{{
+				Version:  FailureRecordVersion,
+				JobName:  "runme",
+				Type:     "presubmit",
+				Cluster:  "foo.com",
+				Failures: []Failure{{Reason: "executing_multi_stage_test", Classification: ClassificationTest, Step: "e2e", Phase: "test"}},
+			}},
 		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
+			var failures []FailureRecord
 			testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				if r.Header.Get("Content-Type") != "application/json" {
 					t.Error("did not correctly set content-type header for JSON")
@@ -73,6 +112,15 @@ func TestReporter_Report(t *testing.T) {
 					http.Error(w, "400 Bad Request", http.StatusBadRequest)
 					return
 				}
+				if r.URL.Path == "/failure" {
+					var record FailureRecord
+					if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+						t.Errorf("failed to decode failure record: %v", err)
+					}
+					record.Timestamp = time.Time{}
+					failures = append(failures, record)
+					return
+				}
 				if !strings.HasPrefix(r.URL.Path, "/result") {
 					t.Errorf("incorrect path to update a bug: %s", r.URL.Path)
 					http.Error(w, "400 Bad Request", http.StatusBadRequest)
@@ -100,6 +148,9 @@ func TestReporter_Report(t *testing.T) {
 				consoleHost: testCase.consoleHost,
 			}
 			reporter.Report(testCase.err)
+			if diff := cmp.Diff(testCase.expectedFailures, failures); diff != "" {
+				t.Errorf("incorrect failure records: %s", diff)
+			}
 		})
 	}
 }
@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// minimumCensorBufferSize is the smallest buffer used to stream files through the censor
+const minimumCensorBufferSize = 64 * 1024
+
+// encodings returns the forms a secret commonly takes when it is written to a
+// file as part of other content. The base64-encoded form is censored by the
+// underlying censorer already.
+func encodings(secret string) []string {
+	forms := sets.New[string](url.QueryEscape(secret), url.PathEscape(secret))
+	if escaped, err := json.Marshal(secret); err == nil {
+		forms.Insert(strings.TrimSuffix(strings.TrimPrefix(string(escaped), `"`), `"`))
+	}
+	forms.Delete(secret)
+	return sets.List(forms)
+}
+
+// CensorArtifacts censors all registered secrets and their encodings in every
+// regular file under the directory, in place, and returns the paths of the
+// files that were redacted, relative to the directory.  Symbolic links are not
+// followed.  Files that cannot be censored do not stop the others from being
+// censored.
+func (c *DynamicCensor) CensorArtifacts(dir string) ([]string, error) {
+	largest := c.LargestSecret()
+	if largest == 0 {
+		return nil, nil
+	}
+	bufferSize := minimumCensorBufferSize
+	if 2*largest > bufferSize {
+		bufferSize = 2 * largest
+	}
+	var redacted []string
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		censored, err := c.censorFile(path, bufferSize)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to censor %s: %w", path, err))
+			return nil
+		}
+		if censored {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			redacted = append(redacted, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return redacted, utilerrors.NewAggregate(errs)
+}
+
+// censorFile censors a file in place. Censored secrets are replaced with as
+// many characters as they had, so the file can be rewritten while it is still
+// being appended to, like the log of the running process.
+func (c *DynamicCensor) censorFile(path string, bufferSize int) (bool, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logrus.WithError(err).Warn("Could not close file after censoring.")
+		}
+	}()
+	return c.censorInPlace(file, bufferSize)
+}
+
+// censorInPlace censors the content using windows of the buffer size which
+// overlap by half, so that every secret no larger than half of the buffer is
+// fully contained in one of them
+func (c *DynamicCensor) censorInPlace(content readerWriterAt, bufferSize int) (bool, error) {
+	censored := false
+	buffer := make([]byte, bufferSize)
+	for offset := int64(0); ; offset += int64(bufferSize / 2) {
+		n, readErr := content.ReadAt(buffer, offset)
+		if readErr != nil && readErr != io.EOF {
+			return false, readErr
+		}
+		data := buffer[:n]
+		original := bytes.Clone(data)
+		c.Censor(&data)
+		if !bytes.Equal(original, data) {
+			censored = true
+			if _, err := content.WriteAt(data, offset); err != nil {
+				return false, err
+			}
+		}
+		if readErr == io.EOF || n < bufferSize {
+			return censored, nil
+		}
+	}
+}
+
+type readerWriterAt interface {
+	io.ReaderAt
+	io.WriterAt
+}
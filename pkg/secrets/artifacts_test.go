@@ -0,0 +1,137 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCensorArtifacts(t *testing.T) {
+	const token = "sha256~to/ken+=\"value\""
+	dir := t.TempDir()
+	files := map[string]string{
+		"clean.log":                   "nothing to see here",
+		"build-log.txt":               "using token " + token,
+		"junit/junit_e2e.xml":         "<failure>token=sha256~to%2Fken%2B%3D%22value%22</failure>",
+		"gather/must-gather/out.json": `{"token": "sha256~to/ken+=\"value\""}`,
+		"path.txt":                    "https://api/sha256~to%2Fken+=%22value%22",
+		"encoded.txt":                 "c2hhMjU2fnRvL2tlbis9InZhbHVlIg==",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "outside")
+	if err := os.WriteFile(outside, []byte(token), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	censor := NewDynamicCensor()
+	redacted, err := censor.CensorArtifacts(dir)
+	if err != nil {
+		t.Fatalf("failed to censor artifacts: %v", err)
+	}
+	if len(redacted) != 0 {
+		t.Errorf("expected nothing to be redacted without secrets, got %v", redacted)
+	}
+
+	censor.AddSecrets(token + "\n")
+	redacted, err = censor.CensorArtifacts(dir)
+	if err != nil {
+		t.Fatalf("failed to censor artifacts: %v", err)
+	}
+	expected := []string{"build-log.txt", "encoded.txt", "gather/must-gather/out.json", "junit/junit_e2e.xml", "path.txt"}
+	if diff := cmp.Diff(expected, redacted); diff != "" {
+		t.Errorf("unexpected redacted files: %s", diff)
+	}
+	expectedContent := map[string]string{
+		"clean.log":                   "nothing to see here",
+		"build-log.txt":               "using token XXXXXXXXXXXXXXXXXXXXXX",
+		"junit/junit_e2e.xml":         "<failure>token=XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX</failure>",
+		"gather/must-gather/out.json": `{"token": "XXXXXXXXXXXXXXXXXXXXXXXX"}`,
+		"path.txt":                    "https://api/XXXXXXXXXXXXXXXXXXXXXXXXXXXX",
+		"encoded.txt":                 "XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX",
+	}
+	for name, content := range expectedContent {
+		raw, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+		if diff := cmp.Diff(content, string(raw)); diff != "" {
+			t.Errorf("unexpected content of %s: %s", name, diff)
+		}
+	}
+	raw, err := os.ReadFile(outside)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if string(raw) != token {
+		t.Errorf("expected symlink target to be left alone, got %q", string(raw))
+	}
+}
+
+func TestCensorInPlace(t *testing.T) {
+	censor := NewDynamicCensor()
+	censor.AddSecrets("secret")
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		censored bool
+	}{
+		{
+			name:     "empty",
+			input:    "",
+			expected: "",
+		},
+		{
+			name:     "shorter than the buffer",
+			input:    "a secret",
+			expected: "a XXXXXX",
+			censored: true,
+		},
+		{
+			name:     "secret across windows",
+			input:    "0123456789secret0123456789secret",
+			expected: "0123456789XXXXXX0123456789XXXXXX",
+			censored: true,
+		},
+		{
+			name:     "nothing to censor",
+			input:    "0123456789abcdef0123456789abcdef0123",
+			expected: "0123456789abcdef0123456789abcdef0123",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(path, []byte(tc.input), 0644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+			censored, err := censor.censorFile(path, 12)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if censored != tc.censored {
+				t.Errorf("expected censored to be %v, got %v", tc.censored, censored)
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, string(raw)); diff != "" {
+				t.Errorf("unexpected content: %s", diff)
+			}
+		})
+	}
+}
@@ -26,11 +26,15 @@ func NewDynamicCensor() DynamicCensor {
 	}
 }
 
-// AddSecrets adds the content of one or more secrets to the censor list.
+// AddSecrets adds the content of one or more secrets to the censor list,
+// along with their URL-encoded and JSON-escaped forms.
 func (c *DynamicCensor) AddSecrets(s ...string) {
 	c.Lock()
 	defer c.Unlock()
-	c.secrets.Insert(s...)
+	for _, secret := range s {
+		c.secrets.Insert(secret)
+		c.secrets.Insert(encodings(strings.TrimSpace(secret))...)
+	}
 	c.ReloadingCensorer.Refresh(sets.List(c.secrets)...)
 }
 
@@ -0,0 +1,368 @@
+// Package secretusage builds the graph of how secrets flow from the Vault and
+// Google Secret Manager items they are stored in, through the secrets synced
+// to the build clusters, to the registry steps that mount them and the Prow
+// jobs that run those steps.
+package secretusage
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/jobconfig"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+// NodeKind is the kind of a node in the secret usage graph
+type NodeKind string
+
+const (
+	// KindVault is a field of an item in Vault
+	KindVault NodeKind = "vault"
+	// KindGSM is a field of a group in a Google Secret Manager collection
+	KindGSM NodeKind = "gsm"
+	// KindBundle is a bundle of Google Secret Manager secrets
+	KindBundle NodeKind = "bundle"
+	// KindSecret is a Kubernetes secret synced to one or more clusters
+	KindSecret NodeKind = "secret"
+	// KindStep is a step which mounts credentials
+	KindStep NodeKind = "step"
+	// KindJob is a Prow job running a step
+	KindJob NodeKind = "job"
+)
+
+// Node is an entity which stores, holds or consumes a secret
+type Node struct {
+	Kind NodeKind
+	Name string
+}
+
+func (n Node) String() string {
+	return fmt.Sprintf("%s:%s", n.Kind, n.Name)
+}
+
+// ParseNode parses a node from its string form, <kind>:<name>
+func ParseNode(raw string) (Node, error) {
+	kind, name, found := strings.Cut(raw, ":")
+	if !found || name == "" {
+		return Node{}, fmt.Errorf("%q is not in the <kind>:<name> format", raw)
+	}
+	switch NodeKind(kind) {
+	case KindVault, KindGSM, KindBundle, KindSecret, KindStep, KindJob:
+		return Node{Kind: NodeKind(kind), Name: name}, nil
+	default:
+		return Node{}, fmt.Errorf("unknown kind %q in %q", kind, raw)
+	}
+}
+
+// StepUsage records a job which runs a step
+type StepUsage struct {
+	Step api.LiteralTestStep
+	// Test identifies the configuration and test the job is generated for,
+	// so that inline steps sharing a name across tests are told apart
+	Test string
+	Job  string
+}
+
+// Graph connects every node to the nodes which consume it
+type Graph struct {
+	consumers map[Node]sets.Set[Node]
+	// clusters are the clusters each secret is synced to
+	clusters map[Node]sets.Set[string]
+}
+
+func (g *Graph) add(from, to Node) {
+	for _, node := range []Node{from, to} {
+		if _, ok := g.consumers[node]; !ok {
+			g.consumers[node] = sets.New[Node]()
+		}
+	}
+	g.consumers[from].Insert(to)
+}
+
+func (g *Graph) addSecret(from Node, cluster, namespace, name string) {
+	secret := Node{Kind: KindSecret, Name: fmt.Sprintf("%s/%s", namespace, name)}
+	g.add(from, secret)
+	if _, ok := g.clusters[secret]; !ok {
+		g.clusters[secret] = sets.New[string]()
+	}
+	if cluster != "" {
+		g.clusters[secret].Insert(cluster)
+	}
+}
+
+func gsmNode(collection, group, field string) Node {
+	return Node{Kind: KindGSM, Name: fmt.Sprintf("%s/%s/%s", collection, group, field)}
+}
+
+// NewGraph builds the graph from the ci-secret-bootstrap and GSM configuration, both
+// of which are optional, the steps in the registry and the steps run by jobs
+func NewGraph(bootstrap *secretbootstrap.Config, gsm *api.GSMConfig, references registry.ReferenceByName, usages []StepUsage) *Graph {
+	g := &Graph{consumers: map[Node]sets.Set[Node]{}, clusters: map[Node]sets.Set[string]{}}
+	if bootstrap != nil {
+		g.addBootstrapConfig(bootstrap)
+	}
+	if gsm != nil {
+		g.addGSMConfig(gsm)
+	}
+	// auto-discovered groups are resolved once every step is added, so that
+	// they link to all known fields of the group regardless of the order
+	var discovered []autoDiscovery
+	for _, reference := range references {
+		discovered = append(discovered, g.addStep(Node{Kind: KindStep, Name: reference.As}, reference)...)
+	}
+	for _, usage := range usages {
+		if len(usage.Step.Credentials) == 0 {
+			continue
+		}
+		node := stepNode(references, usage)
+		discovered = append(discovered, g.addStep(node, usage.Step)...)
+		g.add(node, Node{Kind: KindJob, Name: usage.Job})
+	}
+	g.resolve(discovered)
+	return g
+}
+
+// stepNode returns the node for a step run by a job. Registry steps are shared by
+// all jobs running them, while inline steps are only shared within their test.
+func stepNode(references registry.ReferenceByName, usage StepUsage) Node {
+	if reference, ok := references[usage.Step.As]; ok && reflect.DeepEqual(reference.Credentials, usage.Step.Credentials) {
+		return Node{Kind: KindStep, Name: usage.Step.As}
+	}
+	return Node{Kind: KindStep, Name: fmt.Sprintf("%s/%s", usage.Test, usage.Step.As)}
+}
+
+func (g *Graph) addBootstrapConfig(config *secretbootstrap.Config) {
+	for _, secret := range config.Secrets {
+		var sources []Node
+		for _, from := range secret.From {
+			if from.Item != "" && from.Field != "" {
+				sources = append(sources, Node{Kind: KindVault, Name: fmt.Sprintf("%s/%s", from.Item, from.Field)})
+			}
+			for _, data := range from.DockerConfigJSONData {
+				for _, field := range []string{data.AuthField, data.EmailField} {
+					if field != "" {
+						sources = append(sources, Node{Kind: KindVault, Name: fmt.Sprintf("%s/%s", data.Item, field)})
+					}
+				}
+			}
+		}
+		for _, source := range sources {
+			for _, to := range secret.To {
+				g.addSecret(source, to.Cluster, to.Namespace, to.Name)
+			}
+		}
+	}
+}
+
+func (g *Graph) addGSMConfig(config *api.GSMConfig) {
+	for _, bundle := range config.Bundles {
+		node := Node{Kind: KindBundle, Name: bundle.Name}
+		for _, ref := range bundle.GSMSecrets {
+			for _, field := range ref.Fields {
+				g.add(gsmNode(ref.Collection, ref.Group, field.Name), node)
+			}
+		}
+		if bundle.DockerConfig != nil {
+			for _, registry := range bundle.DockerConfig.Registries {
+				for _, field := range []string{registry.AuthField, registry.EmailField} {
+					if field != "" {
+						g.add(gsmNode(config.DPTPCollection, registry.Group, field), node)
+					}
+				}
+			}
+		}
+		if !bundle.SyncToCluster {
+			continue
+		}
+		for _, target := range bundle.Targets {
+			g.addSecret(node, target.Cluster, target.Namespace, bundle.Name)
+		}
+	}
+}
+
+// autoDiscovery records a step mounting all fields of a GSM group
+type autoDiscovery struct {
+	step              Node
+	collection, group string
+}
+
+// addStep connects the credentials a step mounts to the step and returns the
+// groups whose fields are auto-discovered, to be linked by resolve
+func (g *Graph) addStep(node Node, step api.LiteralTestStep) []autoDiscovery {
+	var discovered []autoDiscovery
+	for _, credential := range step.Credentials {
+		switch {
+		case credential.IsBundleReference():
+			g.add(Node{Kind: KindBundle, Name: credential.Bundle}, node)
+		case credential.IsExplicitField():
+			g.add(gsmNode(credential.Collection, credential.Group, credential.Field), node)
+		case credential.IsAutoDiscovery():
+			discovered = append(discovered, autoDiscovery{step: node, collection: credential.Collection, group: credential.Group})
+		default:
+			g.add(Node{Kind: KindSecret, Name: fmt.Sprintf("%s/%s", credential.Namespace, credential.Name)}, node)
+		}
+	}
+	return discovered
+}
+
+// resolve connects steps with auto-discovered fields to every known field of
+// the group, or to a wildcard field when none is known
+func (g *Graph) resolve(discovered []autoDiscovery) {
+	fields := map[string][]Node{}
+	for node := range g.consumers {
+		if node.Kind != KindGSM {
+			continue
+		}
+		group := node.Name[:strings.LastIndex(node.Name, "/")]
+		fields[group] = append(fields[group], node)
+	}
+	for _, item := range discovered {
+		known := fields[fmt.Sprintf("%s/%s", item.collection, item.group)]
+		if len(known) == 0 {
+			g.add(gsmNode(item.collection, item.group, "*"), item.step)
+			continue
+		}
+		for _, field := range known {
+			g.add(field, item.step)
+		}
+	}
+}
+
+// Match returns the nodes of the kind whose name is the given one or, for
+// Vault items and GSM groups, starts with it, so that all fields of an item
+// can be looked up at once
+func (g *Graph) Match(query Node) []Node {
+	var matched []Node
+	for node := range g.consumers {
+		if node.Kind != query.Kind {
+			continue
+		}
+		if node.Name == query.Name || ((node.Kind == KindVault || node.Kind == KindGSM) && strings.HasPrefix(node.Name, query.Name+"/")) {
+			matched = append(matched, node)
+		}
+	}
+	sortNodes(matched)
+	return matched
+}
+
+// Impact holds everything that consumes a secret, directly or not
+type Impact struct {
+	Bundles []string
+	// Secrets maps the secrets to the clusters they are synced to
+	Secrets map[string][]string
+	Steps   []string
+	Jobs    []string
+}
+
+// Impact returns everything which breaks when the given nodes change
+func (g *Graph) Impact(nodes ...Node) Impact {
+	impact := Impact{Secrets: map[string][]string{}}
+	for _, node := range g.downstream(nodes...) {
+		switch node.Kind {
+		case KindBundle:
+			impact.Bundles = append(impact.Bundles, node.Name)
+		case KindSecret:
+			impact.Secrets[node.Name] = sets.List(g.clusters[node])
+		case KindStep:
+			impact.Steps = append(impact.Steps, node.Name)
+		case KindJob:
+			impact.Jobs = append(impact.Jobs, node.Name)
+		}
+	}
+	return impact
+}
+
+// downstream returns the sorted nodes reachable from the given ones, excluding them
+func (g *Graph) downstream(nodes ...Node) []Node {
+	seen := sets.New[Node](nodes...)
+	queue := append([]Node{}, nodes...)
+	var reached []Node
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for consumer := range g.consumers[current] {
+			if seen.Has(consumer) {
+				continue
+			}
+			seen.Insert(consumer)
+			reached = append(reached, consumer)
+			queue = append(queue, consumer)
+		}
+	}
+	sortNodes(reached)
+	return reached
+}
+
+// Unused returns the Vault and GSM fields which no step mounts, directly or
+// through a secret or bundle. They may still be used outside of jobs, e.g.
+// by services running on the clusters the secrets are synced to.
+func (g *Graph) Unused() []Node {
+	var unused []Node
+	for node := range g.consumers {
+		if node.Kind != KindVault && node.Kind != KindGSM {
+			continue
+		}
+		var used bool
+		for _, consumer := range g.downstream(node) {
+			if consumer.Kind == KindStep {
+				used = true
+				break
+			}
+		}
+		if !used {
+			unused = append(unused, node)
+		}
+	}
+	sortNodes(unused)
+	return unused
+}
+
+func sortNodes(nodes []Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Kind != nodes[j].Kind {
+			return nodes[i].Kind < nodes[j].Kind
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+}
+
+// StepUsagesForTests returns the steps run by the jobs generated for the tests of a
+// ci-operator configuration, which must already be resolved against the registry
+func StepUsagesForTests(metadata api.Metadata, tests []api.TestStepConfiguration) []StepUsage {
+	var usages []StepUsage
+	for _, test := range tests {
+		literal := test.MultiStageTestConfigurationLiteral
+		if literal == nil {
+			continue
+		}
+		identifier := fmt.Sprintf("%s/%s", metadata.RelativePath(), test.As)
+		for _, job := range jobNames(metadata, test) {
+			for _, phase := range [][]api.LiteralTestStep{literal.Pre, literal.Test, literal.Post} {
+				for _, step := range phase {
+					usages = append(usages, StepUsage{Step: step, Test: identifier, Job: job})
+				}
+			}
+		}
+	}
+	return usages
+}
+
+// jobNames returns the names of the jobs prowgen generates for a test
+func jobNames(metadata api.Metadata, test api.TestStepConfiguration) []string {
+	switch {
+	case test.IsPeriodic() && test.Presubmit:
+		return []string{metadata.JobName(jobconfig.PeriodicPrefix, test.As), metadata.JobName(jobconfig.PresubmitPrefix, test.As)}
+	case test.IsPeriodic():
+		return []string{metadata.JobName(jobconfig.PeriodicPrefix, test.As)}
+	case test.Postsubmit:
+		return []string{metadata.JobName(jobconfig.PostsubmitPrefix, test.As)}
+	default:
+		return []string{metadata.JobName(jobconfig.PresubmitPrefix, test.As)}
+	}
+}
@@ -0,0 +1,179 @@
+package secretusage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/api/secretbootstrap"
+	"github.com/openshift/ci-tools/pkg/registry"
+)
+
+func TestGraph(t *testing.T) {
+	bootstrap := &secretbootstrap.Config{Secrets: []secretbootstrap.SecretConfig{
+		{
+			From: map[string]secretbootstrap.ItemContext{
+				"token":  {Item: "aws", Field: "token"},
+				"region": {Item: "aws", Field: "region"},
+			},
+			To: []secretbootstrap.SecretContext{
+				{Cluster: "build01", Namespace: "test-credentials", Name: "aws"},
+				{Cluster: "build02", Namespace: "test-credentials", Name: "aws"},
+			},
+		},
+		{
+			From: map[string]secretbootstrap.ItemContext{
+				".dockerconfigjson": {DockerConfigJSONData: []secretbootstrap.DockerConfigJSONData{{Item: "quay", AuthField: "auth"}}},
+			},
+			To: []secretbootstrap.SecretContext{{Cluster: "app.ci", Namespace: "ci", Name: "pull-secret"}},
+		},
+	}}
+	gsm := &api.GSMConfig{Bundles: []api.GSMBundle{
+		{
+			Name:       "gcp",
+			GSMSecrets: []api.GSMSecretRef{{Collection: "gcp", Group: "ci", Fields: []api.FieldEntry{{Name: "key"}}}},
+		},
+		{
+			Name:          "synced",
+			GSMSecrets:    []api.GSMSecretRef{{Collection: "gcp", Group: "synced", Fields: []api.FieldEntry{{Name: "token"}}}},
+			SyncToCluster: true,
+			Targets:       []api.TargetSpec{{Cluster: "build01", Namespace: "test-credentials"}},
+		},
+	}}
+	references := registry.ReferenceByName{
+		"aws-install":  {As: "aws-install", Credentials: []api.CredentialReference{{Namespace: "test-credentials", Name: "aws", MountPath: "/aws"}}},
+		"gcp-install":  {As: "gcp-install", Credentials: []api.CredentialReference{{Bundle: "gcp", MountPath: "/gcp"}}},
+		"gcp-gather":   {As: "gcp-gather", Credentials: []api.CredentialReference{{Collection: "gcp", Group: "ci", MountPath: "/gcp"}}},
+		"no-creds":     {As: "no-creds"},
+		"gcp-explicit": {As: "gcp-explicit", Credentials: []api.CredentialReference{{Collection: "gcp", Group: "other", Field: "field", MountPath: "/gcp"}}},
+		"gcp-discover": {As: "gcp-discover", Credentials: []api.CredentialReference{{Collection: "gcp", Group: "other", MountPath: "/gcp"}}},
+	}
+	usages := []StepUsage{
+		{Step: references["aws-install"], Job: "pull-ci-org-repo-master-e2e-aws"},
+		{Step: references["aws-install"], Job: "periodic-ci-org-repo-master-e2e-aws"},
+		{Step: references["gcp-install"], Job: "pull-ci-org-repo-master-e2e-gcp"},
+		{Step: references["no-creds"], Job: "pull-ci-org-repo-master-unit"},
+		{
+			Step: api.LiteralTestStep{As: "install", Credentials: []api.CredentialReference{{Namespace: "test-credentials", Name: "aws", MountPath: "/aws"}}},
+			Test: "org/repo/org-repo-master.yaml/e2e-inline",
+			Job:  "pull-ci-org-repo-master-e2e-inline",
+		},
+		{
+			Step: api.LiteralTestStep{As: "install", Credentials: []api.CredentialReference{{Bundle: "gcp", MountPath: "/gcp"}}},
+			Test: "org/other/org-other-master.yaml/e2e-inline",
+			Job:  "pull-ci-org-other-master-e2e-inline",
+		},
+	}
+	graph := NewGraph(bootstrap, gsm, references, usages)
+
+	testCases := []struct {
+		name            string
+		query           string
+		expectedMatches []Node
+		expected        Impact
+	}{
+		{
+			name:            "all fields of a Vault item",
+			query:           "vault:aws",
+			expectedMatches: []Node{{Kind: KindVault, Name: "aws/region"}, {Kind: KindVault, Name: "aws/token"}},
+			expected: Impact{
+				Secrets: map[string][]string{"test-credentials/aws": {"build01", "build02"}},
+				Steps:   []string{"aws-install", "org/repo/org-repo-master.yaml/e2e-inline/install"},
+				Jobs:    []string{"periodic-ci-org-repo-master-e2e-aws", "pull-ci-org-repo-master-e2e-aws", "pull-ci-org-repo-master-e2e-inline"},
+			},
+		},
+		{
+			name:            "GSM field used through a bundle and auto-discovery",
+			query:           "gsm:gcp/ci/key",
+			expectedMatches: []Node{{Kind: KindGSM, Name: "gcp/ci/key"}},
+			expected: Impact{
+				Bundles: []string{"gcp"},
+				Secrets: map[string][]string{},
+				Steps:   []string{"gcp-gather", "gcp-install", "org/other/org-other-master.yaml/e2e-inline/install"},
+				Jobs:    []string{"pull-ci-org-other-master-e2e-inline", "pull-ci-org-repo-master-e2e-gcp"},
+			},
+		},
+		{
+			name:            "GSM field only known from a step is auto-discovered by other steps",
+			query:           "gsm:gcp/other",
+			expectedMatches: []Node{{Kind: KindGSM, Name: "gcp/other/field"}},
+			expected: Impact{
+				Secrets: map[string][]string{},
+				Steps:   []string{"gcp-discover", "gcp-explicit"},
+			},
+		},
+		{
+			name:            "unknown secret",
+			query:           "secret:ci/missing",
+			expectedMatches: nil,
+			expected:        Impact{Secrets: map[string][]string{}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := ParseNode(tc.query)
+			if err != nil {
+				t.Fatalf("failed to parse query: %v", err)
+			}
+			matches := graph.Match(query)
+			if diff := cmp.Diff(tc.expectedMatches, matches); diff != "" {
+				t.Errorf("unexpected matches: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, graph.Impact(matches...)); diff != "" {
+				t.Errorf("unexpected impact: %s", diff)
+			}
+		})
+	}
+
+	expectedUnused := []Node{{Kind: KindGSM, Name: "gcp/synced/token"}, {Kind: KindVault, Name: "quay/auth"}}
+	if diff := cmp.Diff(expectedUnused, graph.Unused()); diff != "" {
+		t.Errorf("unexpected unused fields: %s", diff)
+	}
+}
+
+func TestParseNode(t *testing.T) {
+	testCases := []struct {
+		raw         string
+		expected    Node
+		expectedErr bool
+	}{
+		{raw: "vault:item/field", expected: Node{Kind: KindVault, Name: "item/field"}},
+		{raw: "secret:ns/name", expected: Node{Kind: KindSecret, Name: "ns/name"}},
+		{raw: "vault", expectedErr: true},
+		{raw: "vault:", expectedErr: true},
+		{raw: "cluster:build01", expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.raw, func(t *testing.T) {
+			node, err := ParseNode(tc.raw)
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expected, node); diff != "" {
+				t.Errorf("unexpected node: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStepUsagesForTests(t *testing.T) {
+	step := api.LiteralTestStep{As: "step"}
+	cron := "@daily"
+	tests := []api.TestStepConfiguration{
+		{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+		{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{Test: []api.LiteralTestStep{step}}},
+		{As: "nightly", Cron: &cron, Presubmit: true, MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{Post: []api.LiteralTestStep{step}}},
+		{As: "publish", Postsubmit: true, MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{Pre: []api.LiteralTestStep{step}}},
+	}
+	expected := []StepUsage{
+		{Step: step, Test: "org/repo/org-repo-master.yaml/e2e", Job: "pull-ci-org-repo-master-e2e"},
+		{Step: step, Test: "org/repo/org-repo-master.yaml/nightly", Job: "periodic-ci-org-repo-master-nightly"},
+		{Step: step, Test: "org/repo/org-repo-master.yaml/nightly", Job: "pull-ci-org-repo-master-nightly"},
+		{Step: step, Test: "org/repo/org-repo-master.yaml/publish", Job: "branch-ci-org-repo-master-publish"},
+	}
+	actual := StepUsagesForTests(api.Metadata{Org: "org", Repo: "repo", Branch: "master"}, tests)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected usages: %s", diff)
+	}
+}
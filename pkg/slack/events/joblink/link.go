@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/url"
 	"strconv"
 	"strings"
@@ -17,6 +16,7 @@ import (
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/gcsupload"
@@ -25,6 +25,7 @@ import (
 	gcsutil "sigs.k8s.io/prow/pkg/pod-utils/gcs"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	helpdeskfaq "github.com/openshift/ci-tools/pkg/helpdesk-faq"
 	"github.com/openshift/ci-tools/pkg/jobconfig"
 	"github.com/openshift/ci-tools/pkg/prowgen"
 	"github.com/openshift/ci-tools/pkg/rehearse"
@@ -124,7 +125,9 @@ type messagePoster interface {
 // Handler returns a handler that knows how to respond to
 // messages that mention job details by adding context to
 // them and providing commonly-needed information.
-func Handler(client messagePoster, config JobGetter, gcsClient *storage.Client) events.PartialHandler {
+// For failed runs, a summary of why they failed is added.
+func Handler(client messagePoster, config JobGetter, gcsClient *storage.Client, kubeClient ctrlruntimeclient.Client, namespace string) events.PartialHandler {
+	artifacts := &gcsArtifactReader{client: gcsClient}
 	return events.PartialHandlerFunc("joblink", func(callback *slackevents.EventsAPIEvent, logger *logrus.Entry) (handled bool, err error) {
 		if callback.Type != slackevents.CallbackEvent {
 			return false, nil
@@ -141,7 +144,8 @@ func Handler(client messagePoster, config JobGetter, gcsClient *storage.Client)
 		if len(infos) == 0 {
 			return false, nil
 		}
-		blocks, err := contextFor(logger, infos, config, gcsClient)
+		faqClient := helpdeskfaq.NewCMClient(kubeClient, namespace, logger)
+		blocks, err := contextFor(logger, infos, config, artifacts, &faqClient)
 		if err != nil {
 			logger.WithError(err).Warn("Failed to get context")
 			return false, err
@@ -174,7 +178,7 @@ func rehearsalFromName(name string) (string, string) {
 	return name, rehearsalPR
 }
 
-func contextFor(logger *logrus.Entry, infos []jobInfo, config JobGetter, gcsClient *storage.Client) ([]slack.Block, error) {
+func contextFor(logger *logrus.Entry, infos []jobInfo, config JobGetter, artifacts artifactReader, faq faqGetter) ([]slack.Block, error) {
 	var blocks []slack.Block
 	for _, info := range infos {
 		logger = logger.WithFields(logrus.Fields{
@@ -258,12 +262,7 @@ func contextFor(logger *logrus.Entry, infos []jobInfo, config JobGetter, gcsClie
 					logger.Warn("Alias read found empty object name.")
 					continue
 				}
-				reader, err := gcsClient.Bucket(p.Bucket()).Object(p.Object()).NewReader(context.Background())
-				if err != nil {
-					logger.WithError(err).Warn("Could not open alias for read.")
-					continue
-				}
-				symlink, err := io.ReadAll(reader)
+				symlink, err := artifacts.read(context.Background(), p.Bucket(), p.Object(), 0)
 				if err != nil {
 					logger.WithError(err).Warn("Could not read alias.")
 					continue
@@ -274,6 +273,12 @@ func contextFor(logger *logrus.Entry, infos []jobInfo, config JobGetter, gcsClie
 			}
 			logger.WithField("path", path).Debug("Resolved full GCS path.")
 			text.WriteString("\n - Job result <https://prow.ci.openshift.org/view/gs/" + options.Bucket + "/" + path + "|link>.")
+			triage, err := triageRun(context.Background(), artifacts, faq, options.Bucket, strings.TrimPrefix(path, "/"), job.metadata.TestNameFromJobName(name, prefix), logger)
+			if err != nil {
+				logger.WithError(err).Warn("Could not triage the job run.")
+			} else if triage != nil {
+				text.WriteString(triage.summary(sectionTextLimit - text.Len()))
+			}
 		}
 
 		blocks = append(blocks, &slack.SectionBlock{
@@ -0,0 +1,378 @@
+package joblink
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/testgrid/metadata"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	helpdeskfaq "github.com/openshift/ci-tools/pkg/helpdesk-faq"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+const (
+	// triageLogLines is the number of lines from the end of the log of the failed step that are posted
+	triageLogLines = 10
+	// triageLogBytes bounds how much of the end of the log is read to find those lines
+	triageLogBytes = 16 * 1024
+	// triageLogChars bounds the length of the posted lines, as Slack limits the length of a section
+	triageLogChars = 1500
+	// triageMaxTests is the number of failed tests that are listed
+	triageMaxTests = 5
+	// triageMaxKnownIssues is the number of known issues that are linked
+	triageMaxKnownIssues = 3
+	// sectionTextLimit is the length Slack limits the text of a section to
+	sectionTextLimit = 3000
+	// triageTruncated ends summaries that were cut to fit in the section
+	triageTruncated = "\n - ...the rest of the triage does not fit in this message."
+)
+
+var (
+	// multiStagePhaseTest matches the test cases ci-operator records for the phases of multi-stage tests
+	multiStagePhaseTest = regexp.MustCompile(`^Run multi-stage test (pre|test|post) phase$`)
+	// multiStageContainerTest matches the test cases ci-operator records for the test containers of the steps
+	// of multi-stage tests, whose pods are named after the test and the step
+	multiStageContainerTest = regexp.MustCompile(`^Run multi-stage test (\S+) - (\S+) container test$`)
+)
+
+// artifactReader knows how to read the artifacts of job runs
+type artifactReader interface {
+	// read returns the content of the object, or only its last tail bytes when tail is positive.
+	// Objects that do not exist result in storage.ErrObjectNotExist.
+	read(ctx context.Context, bucket, object string, tail int64) ([]byte, error)
+}
+
+type gcsArtifactReader struct {
+	client *storage.Client
+}
+
+func (r *gcsArtifactReader) read(ctx context.Context, bucket, object string, tail int64) ([]byte, error) {
+	handle := r.client.Bucket(bucket).Object(object)
+	var reader *storage.Reader
+	var err error
+	if tail > 0 {
+		reader, err = handle.NewRangeReader(ctx, -tail, -1)
+	} else {
+		reader, err = handle.NewReader(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// faqGetter knows how to list the items of the helpdesk FAQ
+type faqGetter interface {
+	GetSerializedFAQItems() ([]string, error)
+}
+
+// failedStep is a step of a multi-stage test that failed
+type failedStep struct {
+	name           string
+	phase          string
+	reason         string
+	classification results.Classification
+}
+
+// triage summarizes why a job run failed
+type triage struct {
+	steps       []failedStep
+	failedTests []string
+	// reason is the reason of the failure when it did not happen in a step
+	reason string
+	// logTail holds the last lines of the log of the first failed step
+	logTail     string
+	knownIssues []helpdeskfaq.FaqItem
+}
+
+// triageRun determines why the run of the job with artifacts at runPath failed. Runs that passed or did not
+// finish yet are not triaged. testName is the name of the ci-operator test the job runs, under which the
+// artifacts of its steps are stored.
+func triageRun(ctx context.Context, artifacts artifactReader, faq faqGetter, bucket, runPath, testName string, logger *logrus.Entry) (*triage, error) {
+	raw, err := artifacts.read(ctx, bucket, path.Join(runPath, "finished.json"), 0)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read finished.json: %w", err)
+	}
+	var finished metadata.Finished
+	if err := json.Unmarshal(raw, &finished); err != nil {
+		return nil, fmt.Errorf("failed to parse finished.json: %w", err)
+	}
+	if finished.Passed == nil || *finished.Passed {
+		return nil, nil
+	}
+
+	result := &triage{}
+	raw, err = artifacts.read(ctx, bucket, path.Join(runPath, "artifacts", results.FailuresFilename), 0)
+	switch {
+	case errors.Is(err, storage.ErrObjectNotExist):
+		logger.Debug("Run has no failure record.")
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", results.FailuresFilename, err)
+	default:
+		var record results.FailureRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", results.FailuresFilename, err)
+		}
+		for _, failure := range record.Failures {
+			if failure.Step == "" {
+				if result.reason == "" {
+					result.reason = failure.Reason
+				}
+				continue
+			}
+			result.steps = append(result.steps, failedStep{
+				name:           failure.Step,
+				phase:          failure.Phase,
+				reason:         failure.Reason,
+				classification: failure.Classification,
+			})
+		}
+	}
+
+	raw, err = artifacts.read(ctx, bucket, path.Join(runPath, "artifacts", "junit_operator.xml"), 0)
+	switch {
+	case errors.Is(err, storage.ErrObjectNotExist):
+		logger.Debug("Run has no ci-operator JUnit.")
+	case err != nil:
+		return nil, fmt.Errorf("failed to read junit_operator.xml: %w", err)
+	default:
+		var suites junit.TestSuites
+		if err := xml.Unmarshal(raw, &suites); err != nil {
+			return nil, fmt.Errorf("failed to parse junit_operator.xml: %w", err)
+		}
+		stepsFromJUnit := failedStepsFromJUnit(suites.Suites, &result.failedTests)
+		// older runs have no failure record, so the steps can only be told from the names of the test cases
+		if len(result.steps) == 0 {
+			result.steps = stepsFromJUnit
+		}
+	}
+
+	if len(result.steps) > 0 {
+		step := result.steps[0]
+		raw, err := artifacts.read(ctx, bucket, path.Join(runPath, "artifacts", testName, step.name, "build-log.txt"), triageLogBytes)
+		switch {
+		case errors.Is(err, storage.ErrObjectNotExist):
+			logger.WithField("step", step.name).Debug("Failed step has no log.")
+		case err != nil:
+			return nil, fmt.Errorf("failed to read the log of step %s: %w", step.name, err)
+		default:
+			result.logTail = lastLines(string(raw), triageLogLines)
+		}
+
+		knownIssues, err := knownIssuesFor(faq, result.steps)
+		if err != nil {
+			// the triage is useful without known issues
+			logger.WithError(err).Warn("Failed to look up known issues.")
+		}
+		result.knownIssues = knownIssues
+	}
+	return result, nil
+}
+
+// failedStepsFromJUnit collects the names of the failed test cases and infers the failed steps from them
+func failedStepsFromJUnit(suites []*junit.TestSuite, failedTests *[]string) []failedStep {
+	var steps []failedStep
+	phases := sets.New[string]()
+	for _, suite := range suites {
+		for _, testCase := range suite.TestCases {
+			if testCase.FailureOutput == nil {
+				continue
+			}
+			*failedTests = append(*failedTests, testCase.Name)
+			if match := multiStagePhaseTest.FindStringSubmatch(testCase.Name); match != nil {
+				phases.Insert(match[1])
+			}
+			if match := multiStageContainerTest.FindStringSubmatch(testCase.Name); match != nil {
+				steps = append(steps, failedStep{name: strings.TrimPrefix(match[2], match[1]+"-")})
+			}
+		}
+		steps = append(steps, failedStepsFromJUnit(suite.Children, failedTests)...)
+	}
+	// the test cases do not tell which phase a step ran in, unless only one phase failed
+	if phases.Len() == 1 {
+		for i := range steps {
+			steps[i].phase = sets.List(phases)[0]
+		}
+	}
+	return steps
+}
+
+// knownIssuesFor finds the items of the helpdesk FAQ whose question mentions one of the failed steps by its
+// full name. Items which also mention the reason the step failed with are listed first.
+func knownIssuesFor(faq faqGetter, steps []failedStep) ([]helpdeskfaq.FaqItem, error) {
+	serialized, err := faq.GetSerializedFAQItems()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get FAQ items: %w", err)
+	}
+	var withReason, withStep []helpdeskfaq.FaqItem
+	for _, raw := range serialized {
+		var item helpdeskfaq.FaqItem
+		if err := json.Unmarshal([]byte(raw), &item); err != nil {
+			return append(withReason, withStep...), fmt.Errorf("failed to parse FAQ item: %w", err)
+		}
+		question := item.Question.Subject + "\n" + item.Question.Body
+		matched, reasonMatched := false, false
+		for _, step := range steps {
+			if !mentionsStep(question, step.name) {
+				continue
+			}
+			matched = true
+			if step.reason != "" && mentions(question, step.reason) {
+				reasonMatched = true
+				break
+			}
+		}
+		switch {
+		case reasonMatched:
+			withReason = append(withReason, item)
+		case matched:
+			withStep = append(withStep, item)
+		}
+	}
+	matches := append(withReason, withStep...)
+	if len(matches) > triageMaxKnownIssues {
+		matches = matches[:triageMaxKnownIssues]
+	}
+	return matches, nil
+}
+
+// mentionsStep determines whether the text refers to the step. Names that are plain words, like test or
+// unit, are too common in questions to match anywhere, so they must be formatted as code to refer to the step.
+func mentionsStep(text, name string) bool {
+	if !strings.ContainsAny(name, "-_0123456789") {
+		return strings.Contains(text, "`"+name+"`")
+	}
+	return mentions(text, name)
+}
+
+// mentions determines whether the text contains the name as a whole word, so that short step names
+// like e2e do not match the names of other steps or words they are a part of
+func mentions(text, name string) bool {
+	for offset := 0; ; {
+		index := strings.Index(text[offset:], name)
+		if index < 0 {
+			return false
+		}
+		start, end := offset+index, offset+index+len(name)
+		if (start == 0 || !isNameChar(text[start-1])) && (end == len(text) || !isNameChar(text[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// isNameChar determines whether the character can be a part of the name of a step or a failure reason
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// lastLines returns the last n lines of the text, dropping the first line when it may have been cut, and
+// at most triageLogChars of them
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	} else if len(text) >= triageLogBytes && len(lines) > 1 {
+		lines = lines[1:]
+	}
+	tail := strings.Join(lines, "\n")
+	if len(tail) > triageLogChars {
+		tail = "..." + tail[len(tail)-triageLogChars:]
+	}
+	return tail
+}
+
+// summary formats the triage for Slack in at most limit characters, as the summary is a part of a section.
+// The end of the log is shortened to fit, the parts after it are left out when they do not.
+func (t *triage) summary(limit int) string {
+	var parts []string
+	parts = append(parts, "\n - *Why it failed:*")
+	for _, step := range t.steps {
+		part := fmt.Sprintf("\n    - Step `%s` failed", step.name)
+		if step.phase != "" {
+			part += fmt.Sprintf(" in the `%s` phase", step.phase)
+		}
+		if step.classification != "" && step.classification != results.ClassificationUnknown {
+			part += fmt.Sprintf(", which points at the %s", step.classification)
+		}
+		if step.reason != "" {
+			part += fmt.Sprintf(" (reason `%s`)", step.reason)
+		}
+		parts = append(parts, part+".")
+	}
+	if len(t.steps) == 0 {
+		if t.reason != "" {
+			parts = append(parts, fmt.Sprintf("\n    - `ci-operator` failed with reason `%s`.", t.reason))
+		}
+		tests := t.failedTests
+		if len(tests) > triageMaxTests {
+			tests = tests[:triageMaxTests]
+		}
+		for _, test := range tests {
+			parts = append(parts, fmt.Sprintf("\n    - `%s` failed.", test))
+		}
+		if len(t.failedTests) > len(tests) {
+			parts = append(parts, fmt.Sprintf("\n    - ...and %d more tests failed.", len(t.failedTests)-len(tests)))
+		}
+		if t.reason == "" && len(t.failedTests) == 0 {
+			parts = append(parts, "\n    - No failed step or test was found in the artifacts; the failure may have happened before `ci-operator` ran.")
+		}
+	}
+	logPart := -1
+	if t.logTail != "" {
+		logPart = len(parts)
+		parts = append(parts, fmt.Sprintf("\n - The log of `%s` ends with:\n```%s```", t.steps[0].name, t.logTail))
+	}
+	for _, item := range t.knownIssues {
+		parts = append(parts, fmt.Sprintf("\n - Known issue: <%s|%s>", item.ThreadLink, item.Question.Subject))
+	}
+
+	text := strings.Builder{}
+	for i, part := range parts {
+		room := limit - text.Len()
+		if i < len(parts)-1 {
+			room -= len(triageTruncated)
+		}
+		if len(part) > room && i == logPart {
+			part = shortenLog(t.steps[0].name, t.logTail, room)
+		}
+		if len(part) > room || part == "" {
+			if text.Len()+len(triageTruncated) <= limit {
+				text.WriteString(triageTruncated)
+			}
+			break
+		}
+		text.WriteString(part)
+	}
+	return text.String()
+}
+
+// shortenLog formats the end of the log in at most room characters, or returns nothing when too little of it fits
+func shortenLog(step, tail string, room int) string {
+	format := "\n - The log of `%s` ends with:\n```...%s```"
+	available := room - len(fmt.Sprintf(format, step, ""))
+	if available < triageLogChars/10 {
+		return ""
+	}
+	tail = strings.TrimPrefix(tail, "...")
+	if available < len(tail) {
+		tail = tail[len(tail)-available:]
+	}
+	return fmt.Sprintf(format, step, tail)
+}
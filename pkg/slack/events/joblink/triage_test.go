@@ -0,0 +1,310 @@
+package joblink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	helpdeskfaq "github.com/openshift/ci-tools/pkg/helpdesk-faq"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+type fakeArtifactReader map[string]string
+
+func (f fakeArtifactReader) read(_ context.Context, bucket, object string, tail int64) ([]byte, error) {
+	content, ok := f[bucket+"/"+object]
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	if tail > 0 && int64(len(content)) > tail {
+		content = content[int64(len(content))-tail:]
+	}
+	return []byte(content), nil
+}
+
+type fakeFAQGetter struct {
+	items []string
+	err   error
+}
+
+func (f *fakeFAQGetter) GetSerializedFAQItems() ([]string, error) {
+	return f.items, f.err
+}
+
+const (
+	runPath  = "pr-logs/pull/org_repo/1/pull-ci-org-repo-master-e2e/1234"
+	failed   = `{"timestamp": 1700000000, "passed": false, "result": "FAILURE"}`
+	junitXML = `<testsuites>
+  <testsuite name="operator" tests="4" failures="2">
+    <testcase name="Run multi-stage test e2e - e2e-ipi-install-install container test"><failure>install failed</failure></testcase>
+    <testcase name="Run multi-stage test e2e - e2e-ipi-install-install container artifacts"></testcase>
+    <testcase name="Run multi-stage test pre phase"><failure>step failed</failure></testcase>
+    <testcase name="Run multi-stage test post phase"></testcase>
+  </testsuite>
+</testsuites>`
+)
+
+func artifact(name string) string {
+	return "test-platform-results/" + runPath + "/" + name
+}
+
+func TestTriageRun(t *testing.T) {
+	knownIssue := helpdeskfaq.FaqItem{
+		Question:   helpdeskfaq.Question{Subject: "ipi-install-install times out", Body: "The install step fails with a timeout."},
+		Timestamp:  "1700000000.000000",
+		ThreadLink: "https://slack.com/thread",
+	}
+	faq := &fakeFAQGetter{items: []string{
+		`{"question": {"subject": "Unrelated", "body": "Something about e2e-test."}}`,
+		`{"question": {"subject": "ipi-install-install times out", "body": "The install step fails with a timeout."}, "timestamp": "1700000000.000000", "thread_link": "https://slack.com/thread"}`,
+	}}
+	var longLog []string
+	for i := 1; i <= 20; i++ {
+		longLog = append(longLog, fmt.Sprintf("line %d", i))
+	}
+
+	testCases := []struct {
+		name      string
+		artifacts fakeArtifactReader
+		faq       faqGetter
+		expected  *triage
+	}{
+		{
+			name:      "unfinished runs are not triaged",
+			artifacts: fakeArtifactReader{},
+		},
+		{
+			name:      "passed runs are not triaged",
+			artifacts: fakeArtifactReader{artifact("finished.json"): `{"timestamp": 1700000000, "passed": true, "result": "SUCCESS"}`},
+		},
+		{
+			name: "failed step is taken from the failure record",
+			artifacts: fakeArtifactReader{
+				artifact("finished.json"): failed,
+				artifact("artifacts/" + results.FailuresFilename): `{"version": "v1", "failures": [
+					{"reason": "executing_multi_stage_test:running_pod", "classification": "infrastructure", "step": "ipi-install-install", "phase": "pre"}
+				]}`,
+				artifact("artifacts/junit_operator.xml"):                    junitXML,
+				artifact("artifacts/e2e/ipi-install-install/build-log.txt"): strings.Join(longLog, "\n") + "\n",
+			},
+			faq: faq,
+			expected: &triage{
+				steps: []failedStep{{
+					name:           "ipi-install-install",
+					phase:          "pre",
+					reason:         "executing_multi_stage_test:running_pod",
+					classification: results.ClassificationInfrastructure,
+				}},
+				failedTests: []string{"Run multi-stage test e2e - e2e-ipi-install-install container test", "Run multi-stage test pre phase"},
+				logTail:     strings.Join(longLog[10:], "\n"),
+				knownIssues: []helpdeskfaq.FaqItem{knownIssue},
+			},
+		},
+		{
+			name: "failed step is inferred from the JUnit without a failure record",
+			artifacts: fakeArtifactReader{
+				artifact("finished.json"):                failed,
+				artifact("artifacts/junit_operator.xml"): junitXML,
+			},
+			faq: &fakeFAQGetter{err: errors.New("injected error")},
+			expected: &triage{
+				steps:       []failedStep{{name: "ipi-install-install", phase: "pre"}},
+				failedTests: []string{"Run multi-stage test e2e - e2e-ipi-install-install container test", "Run multi-stage test pre phase"},
+			},
+		},
+		{
+			name: "failures outside of steps are reported with their reason",
+			artifacts: fakeArtifactReader{
+				artifact("finished.json"): failed,
+				artifact("artifacts/" + results.FailuresFilename): `{"version": "v1", "failures": [
+					{"reason": "building_image", "classification": "test"}
+				]}`,
+			},
+			faq:      faq,
+			expected: &triage{reason: "building_image"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := triageRun(context.Background(), tc.artifacts, tc.faq, "test-platform-results", runPath, "e2e", logrus.NewEntry(logrus.New()))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmp.AllowUnexported(triage{}, failedStep{})); diff != "" {
+				t.Errorf("unexpected triage (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTriageSummary(t *testing.T) {
+	longLog := strings.Repeat("x", triageLogChars)
+	shortenedLog := "\n - *Why it failed:*\n    - Step `test` failed.\n - The log of `test` ends with:\n```..."
+	testCases := []struct {
+		name     string
+		triage   triage
+		limit    int
+		expected string
+	}{
+		{
+			name: "failed step",
+			triage: triage{
+				steps: []failedStep{{
+					name:           "ipi-install-install",
+					phase:          "pre",
+					reason:         "executing_multi_stage_test:running_pod",
+					classification: results.ClassificationInfrastructure,
+				}},
+				failedTests: []string{"Run multi-stage test pre phase"},
+				logTail:     "level=error msg=timeout",
+				knownIssues: []helpdeskfaq.FaqItem{{Question: helpdeskfaq.Question{Subject: "Install times out"}, ThreadLink: "https://slack.com/thread"}},
+			},
+			expected: "\n - *Why it failed:*" +
+				"\n    - Step `ipi-install-install` failed in the `pre` phase, which points at the infrastructure (reason `executing_multi_stage_test:running_pod`)." +
+				"\n - The log of `ipi-install-install` ends with:\n```level=error msg=timeout```" +
+				"\n - Known issue: <https://slack.com/thread|Install times out>",
+		},
+		{
+			name: "failed tests without a step",
+			triage: triage{
+				reason:      "building_image",
+				failedTests: []string{"a", "b", "c", "d", "e", "f", "g"},
+			},
+			expected: "\n - *Why it failed:*" +
+				"\n    - `ci-operator` failed with reason `building_image`." +
+				"\n    - `a` failed.\n    - `b` failed.\n    - `c` failed.\n    - `d` failed.\n    - `e` failed." +
+				"\n    - ...and 2 more tests failed.",
+		},
+		{
+			name: "log is shortened to fit",
+			triage: triage{
+				steps:       []failedStep{{name: "test"}},
+				logTail:     "..." + longLog,
+				knownIssues: []helpdeskfaq.FaqItem{{Question: helpdeskfaq.Question{Subject: "Tests fail"}, ThreadLink: "https://slack.com/thread"}},
+			},
+			limit: 1000,
+			expected: shortenedLog + longLog[:1000-len(shortenedLog)-len("```")-len(triageTruncated)] + "```" +
+				"\n - Known issue: <https://slack.com/thread|Tests fail>",
+		},
+		{
+			name: "parts that do not fit are left out",
+			triage: triage{
+				steps:       []failedStep{{name: "test"}},
+				logTail:     longLog,
+				knownIssues: []helpdeskfaq.FaqItem{{Question: helpdeskfaq.Question{Subject: "Tests fail"}, ThreadLink: "https://slack.com/thread"}},
+			},
+			limit: 150,
+			expected: "\n - *Why it failed:*" +
+				"\n    - Step `test` failed." +
+				triageTruncated,
+		},
+		{
+			name:   "nothing found",
+			triage: triage{},
+			expected: "\n - *Why it failed:*" +
+				"\n    - No failed step or test was found in the artifacts; the failure may have happened before `ci-operator` ran.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			limit := tc.limit
+			if limit == 0 {
+				limit = sectionTextLimit
+			}
+			actual := tc.triage.summary(limit)
+			if len(actual) > limit {
+				t.Errorf("summary of %d characters exceeds the limit of %d", len(actual), limit)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected summary (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKnownIssuesFor(t *testing.T) {
+	faq := &fakeFAQGetter{items: []string{
+		`{"question": {"subject": "e2e-aws fails to install", "body": "Unit tests are unrelated."}, "thread_link": "https://slack.com/other-step"}`,
+		`{"question": {"subject": "Step e2e fails", "body": "The e2e step of the test fails."}, "thread_link": "https://slack.com/step"}`,
+		`{"question": {"subject": "Step e2e times out", "body": "e2e fails with executing_multi_stage_test:running_pod."}, "thread_link": "https://slack.com/reason"}`,
+		`{"question": {"subject": "Retesting", "body": "How do I test my e2e-test change?"}, "thread_link": "https://slack.com/unrelated"}`,
+		`{"question": {"subject": "Lint fails", "body": "The \u0060lint\u0060 step fails after a linter update."}, "thread_link": "https://slack.com/lint"}`,
+	}}
+	testCases := []struct {
+		name     string
+		steps    []failedStep
+		expected []string
+	}{
+		{
+			name:     "short step names only match whole words",
+			steps:    []failedStep{{name: "e2e"}},
+			expected: []string{"https://slack.com/step", "https://slack.com/reason"},
+		},
+		{
+			name:     "items mentioning the reason come first",
+			steps:    []failedStep{{name: "e2e", reason: "executing_multi_stage_test:running_pod"}},
+			expected: []string{"https://slack.com/reason", "https://slack.com/step"},
+		},
+		{
+			name:  "plain words only match when formatted as code",
+			steps: []failedStep{{name: "unit"}, {name: "test"}},
+		},
+		{
+			name:     "plain words formatted as code match",
+			steps:    []failedStep{{name: "lint"}},
+			expected: []string{"https://slack.com/lint"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := knownIssuesFor(faq, tc.steps)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var actual []string
+			for _, item := range items {
+				actual = append(actual, item.ThreadLink)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected known issues (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "short log",
+			text:     "a\nb\n",
+			expected: "a\nb",
+		},
+		{
+			name:     "long lines are cut",
+			text:     strings.Repeat("x", 2000),
+			expected: "..." + strings.Repeat("x", triageLogChars),
+		},
+		{
+			name:     "partial first line of a cut log is dropped",
+			text:     strings.Repeat("x", triageLogBytes-2) + "\ny",
+			expected: "y",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, lastLines(tc.text, 3)); diff != "" {
+				t.Errorf("unexpected lines (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -23,6 +23,6 @@ func ForEvents(client *slack.Client, filer jira.IssueFiler, kubeClient ctrlrunti
 		helpdesk.FAQHandler(client, kubeClient, forumChannelId, namespace),
 		supportrequest.HandlerWithLock(client, filer, supportRequestChannelID, supportRequestThreadMessageThreshold, supportrequest.NewConfigMapLockClient(kubeClient, namespace)),
 		mention.Handler(client),
-		joblink.Handler(client, joblink.NewJobGetter(config), gcsClient),
+		joblink.Handler(client, joblink.NewJobGetter(config), gcsClient, kubeClient, namespace),
 	)
 }
@@ -0,0 +1,208 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	crcontrollerutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// CheckpointConfigMapName is the name of the ConfigMap in the test namespace
+// that holds the completion state of the steps in the graph.
+const CheckpointConfigMapName = "ci-operator-checkpoint"
+
+// Checkpointer persists the completion state of steps so that an execution
+// that was interrupted can be resumed without running them again. Only steps
+// that produce images can be skipped, as their outputs can be verified to
+// still exist; tests and steps wrapping them always run again. Skipped steps
+// provide the same parameter values as when they completed.
+type Checkpointer interface {
+	// Completed determines if the step completed in a previous execution
+	// and all of the images it produced and the parameters it provides are
+	// still intact.
+	Completed(ctx context.Context, step api.Step) bool
+	// Record persists the completion state of the step.
+	Record(ctx context.Context, step api.Step) error
+}
+
+// StepCheckpoint is the persisted completion state of a single step.
+type StepCheckpoint struct {
+	Name        string    `json:"name"`
+	CompletedAt time.Time `json:"completedAt"`
+	// Parameters holds the values of the parameters the step provides.
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// ImageStreamTags maps every `stream:tag` in the test namespace the
+	// step created to the image it resolved to.
+	ImageStreamTags map[string]string `json:"imageStreamTags,omitempty"`
+}
+
+type configMapCheckpointer struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+
+	lock  sync.Mutex
+	steps map[string]StepCheckpoint
+}
+
+// NewCheckpointer loads the checkpoint stored in the namespace, if any. The
+// ConfigMap is only created once the first verifiable step is recorded.
+func NewCheckpointer(ctx context.Context, client ctrlruntimeclient.Client, namespace string) (Checkpointer, error) {
+	c := &configMapCheckpointer{client: client, namespace: namespace, steps: map[string]StepCheckpoint{}}
+	cm := &coreapi.ConfigMap{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: CheckpointConfigMapName}, cm); err != nil {
+		if kerrors.IsNotFound(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("could not get checkpoint %s/%s: %w", namespace, CheckpointConfigMapName, err)
+	}
+	for name, raw := range cm.Data {
+		var checkpoint StepCheckpoint
+		if err := json.Unmarshal([]byte(raw), &checkpoint); err != nil {
+			logrus.WithError(err).Warnf("Ignoring malformed checkpoint for step %s.", name)
+			continue
+		}
+		c.steps[name] = checkpoint
+	}
+	return c, nil
+}
+
+func (c *configMapCheckpointer) Completed(ctx context.Context, step api.Step) bool {
+	if !verifiable(step) {
+		return false
+	}
+	c.lock.Lock()
+	checkpoint, recorded := c.steps[step.Name()]
+	c.lock.Unlock()
+	if !recorded || len(checkpoint.ImageStreamTags) == 0 {
+		return false
+	}
+	logger := logrus.WithField("step", step.Name())
+	current, err := c.checkpointFor(ctx, step)
+	if err != nil {
+		logger.WithError(err).Info("Outputs of the step completed in a previous execution can not be verified, running it again.")
+		return false
+	}
+	for istag, image := range checkpoint.ImageStreamTags {
+		if current.ImageStreamTags[istag] != image {
+			logger.Infof("ImageStreamTag %s changed since the step completed in a previous execution, running it again.", istag)
+			return false
+		}
+	}
+	for name, value := range checkpoint.Parameters {
+		if current.Parameters[name] != value {
+			logger.Infof("Parameter %s changed since the step completed in a previous execution, running it again.", name)
+			return false
+		}
+	}
+	return true
+}
+
+func (c *configMapCheckpointer) Record(ctx context.Context, step api.Step) error {
+	if !verifiable(step) {
+		return nil
+	}
+	checkpoint, err := c.checkpointFor(ctx, step)
+	if err != nil {
+		return fmt.Errorf("could not determine outputs of step %s: %w", step.Name(), err)
+	}
+	checkpoint.CompletedAt = time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.steps[step.Name()] = checkpoint
+	data := map[string]string{}
+	for name, s := range c.steps {
+		raw, err := json.Marshal(s)
+		if err != nil {
+			return fmt.Errorf("could not marshal checkpoint for step %s: %w", name, err)
+		}
+		data[name] = string(raw)
+	}
+	cm := &coreapi.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: CheckpointConfigMapName}}
+	if _, err := crcontrollerutil.CreateOrUpdate(ctx, c.client, cm, func() error {
+		cm.Data = data
+		return nil
+	}); err != nil {
+		return fmt.Errorf("could not update checkpoint %s/%s: %w", c.namespace, CheckpointConfigMapName, err)
+	}
+	return nil
+}
+
+// verifiable determines if the outputs of the step can be checked on resume:
+// only steps that create images in the test namespace qualify. Steps that
+// wrap others, like leases, always run again so that their resources are
+// acquired for the steps that depend on them.
+func verifiable(step api.Step) bool {
+	if step.Name() == "" {
+		return false
+	}
+	switch step.(type) {
+	case *leaseStep, *clusterClaimStep, *ipPoolStep:
+		return false
+	}
+	for _, link := range step.Creates() {
+		if _, _, ok := api.ImageStreamForLink(link); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkpointFor determines the current state of the images the step created
+// and the values of the parameters it provides. Parameters that can only be
+// resolved once the step ran make the step run again on resume.
+func (c *configMapCheckpointer) checkpointFor(ctx context.Context, step api.Step) (StepCheckpoint, error) {
+	checkpoint := StepCheckpoint{Name: step.Name()}
+	for name, value := range step.Provides() {
+		v, err := value()
+		if err != nil {
+			return checkpoint, fmt.Errorf("could not resolve parameter %s: %w", name, err)
+		}
+		if checkpoint.Parameters == nil {
+			checkpoint.Parameters = map[string]string{}
+		}
+		checkpoint.Parameters[name] = fmt.Sprintf("%v", v)
+	}
+	for _, link := range step.Creates() {
+		stream, tag, ok := api.ImageStreamForLink(link)
+		if !ok {
+			continue
+		}
+		if checkpoint.ImageStreamTags == nil {
+			checkpoint.ImageStreamTags = map[string]string{}
+		}
+		if tag != "" {
+			ist := &imagev1.ImageStreamTag{}
+			name := fmt.Sprintf("%s:%s", stream, tag)
+			if err := c.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: name}, ist); err != nil {
+				return checkpoint, fmt.Errorf("could not get ImageStreamTag %s: %w", name, err)
+			}
+			checkpoint.ImageStreamTags[name] = ist.Image.Name
+			continue
+		}
+		is := &imagev1.ImageStream{}
+		if err := c.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: c.namespace, Name: stream}, is); err != nil {
+			return checkpoint, fmt.Errorf("could not get ImageStream %s: %w", stream, err)
+		}
+		for _, tag := range is.Status.Tags {
+			if len(tag.Items) == 0 {
+				continue
+			}
+			checkpoint.ImageStreamTags[fmt.Sprintf("%s:%s", stream, tag.Tag)] = tag.Items[0].Image
+		}
+	}
+	return checkpoint, nil
+}
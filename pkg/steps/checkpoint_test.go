@@ -0,0 +1,170 @@
+package steps
+
+import (
+	"context"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func pipelineIST(tag, image string) *imagev1.ImageStreamTag {
+	return &imagev1.ImageStreamTag{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline:" + tag},
+		Image:      imagev1.Image{ObjectMeta: metav1.ObjectMeta{Name: image}},
+	}
+}
+
+type parameterStep struct {
+	fakeStep
+	params api.ParameterMap
+}
+
+func (s *parameterStep) Provides() api.ParameterMap { return s.params }
+
+func TestCheckpointer(t *testing.T) {
+	value := "a"
+	step := &parameterStep{
+		fakeStep: fakeStep{name: "src", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}},
+		params:   api.ParameterMap{"PARAM": func() (any, error) { return value, nil }},
+	}
+	var testCases = []struct {
+		name     string
+		step     api.Step
+		mutate   func(t *testing.T, client ctrlruntimeclient.Client)
+		changed  string
+		expected bool
+	}{
+		{
+			name:     "outputs intact",
+			step:     step,
+			expected: true,
+		},
+		{
+			name: "image stream tag deleted",
+			step: step,
+			mutate: func(t *testing.T, client ctrlruntimeclient.Client) {
+				if err := client.Delete(context.Background(), pipelineIST("src", "sha256:a")); err != nil {
+					t.Fatalf("failed to delete: %v", err)
+				}
+			},
+		},
+		{
+			name: "image stream tag points to other image",
+			step: step,
+			mutate: func(t *testing.T, client ctrlruntimeclient.Client) {
+				if err := client.Delete(context.Background(), pipelineIST("src", "sha256:a")); err != nil {
+					t.Fatalf("failed to delete: %v", err)
+				}
+				if err := client.Create(context.Background(), pipelineIST("src", "sha256:b")); err != nil {
+					t.Fatalf("failed to create: %v", err)
+				}
+			},
+		},
+		{
+			name:    "parameter changed",
+			step:    step,
+			changed: "b",
+		},
+		{
+			name: "step without images is never skipped",
+			step: &fakeStep{name: "unit"},
+		},
+		{
+			name: "lease is never skipped",
+			step: &leaseStep{wrapped: step},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value = "a"
+			ctx := context.Background()
+			client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects([]runtime.Object{pipelineIST("src", "sha256:a")}...).Build()
+			recorder, err := NewCheckpointer(ctx, client, "ns")
+			if err != nil {
+				t.Fatalf("failed to create checkpointer: %v", err)
+			}
+			if err := recorder.Record(ctx, tc.step); err != nil {
+				t.Fatalf("failed to record step: %v", err)
+			}
+			if tc.mutate != nil {
+				tc.mutate(t, client)
+			}
+			if tc.changed != "" {
+				value = tc.changed
+			}
+			// a new execution loads the checkpoint from the namespace
+			checkpointer, err := NewCheckpointer(ctx, client, "ns")
+			if err != nil {
+				t.Fatalf("failed to load checkpointer: %v", err)
+			}
+			if actual := checkpointer.Completed(ctx, tc.step); actual != tc.expected {
+				t.Errorf("expected completed to be %v, got %v", tc.expected, actual)
+			}
+			if checkpointer.Completed(ctx, &fakeStep{name: "other", creates: step.fakeStep.creates}) {
+				t.Error("expected step that was never recorded not to be completed")
+			}
+		})
+	}
+}
+
+func TestCheckpointerCreatesNoStateWithoutImages(t *testing.T) {
+	ctx := context.Background()
+	client := fakectrlruntimeclient.NewClientBuilder().Build()
+	checkpointer, err := NewCheckpointer(ctx, client, "ns")
+	if err != nil {
+		t.Fatalf("failed to create checkpointer: %v", err)
+	}
+	if err := checkpointer.Record(ctx, &fakeStep{name: "unit"}); err != nil {
+		t.Fatalf("failed to record step: %v", err)
+	}
+	cms := &coreapi.ConfigMapList{}
+	if err := client.List(ctx, cms); err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(cms.Items) != 0 {
+		t.Errorf("expected no checkpoint to be created, got %d", len(cms.Items))
+	}
+}
+
+func TestRunSkipsCompletedSteps(t *testing.T) {
+	ctx := context.Background()
+	client := fakectrlruntimeclient.NewClientBuilder().WithRuntimeObjects(pipelineIST("root", "sha256:a")).Build()
+	root := &fakeStep{name: "root", creates: []api.StepLink{api.InternalImageLink("root")}}
+	leaf := &fakeStep{name: "leaf", requires: []api.StepLink{api.InternalImageLink("root")}}
+
+	first, err := NewCheckpointer(ctx, client, "ns")
+	if err != nil {
+		t.Fatalf("failed to create checkpointer: %v", err)
+	}
+	if err := first.Record(ctx, root); err != nil {
+		t.Fatalf("failed to record step: %v", err)
+	}
+	checkpointer, err := NewCheckpointer(ctx, client, "ns")
+	if err != nil {
+		t.Fatalf("failed to load checkpointer: %v", err)
+	}
+	suites, _, errs := Run(ctx, api.BuildGraph([]api.Step{root, leaf}), nil, checkpointer)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if root.numRuns != 0 {
+		t.Errorf("expected completed step not to run, ran %d times", root.numRuns)
+	}
+	if leaf.numRuns != 1 {
+		t.Errorf("expected dependent step to run once, ran %d times", leaf.numRuns)
+	}
+	if suite := suites.Suites[0]; suite.NumTests != 2 || suite.NumSkipped != 1 {
+		t.Errorf("unexpected junit output: %#v", suite)
+	}
+	if checkpointer.Completed(ctx, leaf) {
+		t.Error("expected test step never to be considered completed")
+	}
+}
@@ -56,6 +56,12 @@ func (s *clusterClaimStep) Validate() error {
 	return nil
 }
 
+func (s *clusterClaimStep) Plan(plan *api.StepPlan) {
+	if reporter, ok := s.wrapped.(PlanReporter); ok {
+		reporter.Plan(plan)
+	}
+}
+
 func (s *clusterClaimStep) Name() string                        { return s.wrapped.Name() }
 func (s *clusterClaimStep) Description() string                 { return s.wrapped.Description() }
 func (s *clusterClaimStep) Requires() []api.StepLink            { return s.wrapped.Requires() }
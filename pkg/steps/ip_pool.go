@@ -73,6 +73,17 @@ func (s *ipPoolStep) Validate() error {
 	return nil
 }
 
+func (s *ipPoolStep) Plan(plan *api.StepPlan) {
+	if reporter, ok := s.wrapped.(PlanReporter); ok {
+		reporter.Plan(plan)
+	}
+	if s.profile != nil {
+		if l := s.ipPoolLeaseFunc(api.ClusterProfile(s.profile.Name), s.branch); l.ResourceType != "" {
+			plan.Leases = append(plan.Leases, l.StepLease)
+		}
+	}
+}
+
 func (s *ipPoolStep) Name() string                        { return s.wrapped.Name() }
 func (s *ipPoolStep) Description() string                 { return s.wrapped.Description() }
 func (s *ipPoolStep) Requires() []api.StepLink            { return s.wrapped.Requires() }
@@ -79,6 +79,15 @@ func (s *leaseStep) Validate() error {
 	return nil
 }
 
+func (s *leaseStep) Plan(plan *api.StepPlan) {
+	if reporter, ok := s.wrapped.(PlanReporter); ok {
+		reporter.Plan(plan)
+	}
+	for _, l := range s.leases {
+		plan.Leases = append(plan.Leases, l.StepLease)
+	}
+}
+
 func (s *leaseStep) Name() string                        { return s.wrapped.Name() }
 func (s *leaseStep) Description() string                 { return s.wrapped.Description() }
 func (s *leaseStep) Requires() []api.StepLink            { return s.wrapped.Requires() }
@@ -162,7 +171,7 @@ func (s *leaseStep) run(ctx context.Context) error {
 	if err := s.acquireLeases(ctx, cancel); err != nil {
 		return err
 	}
-	wrappedErr := results.ForReason("executing_test").ForError(s.wrapped.Run(ctx))
+	wrappedErr := results.WithDetails(results.ForReason("executing_test").ForError(s.wrapped.Run(ctx)), results.Details{LeaseTypes: types})
 	logrus.Infof("Releasing leases for test %s", s.Name())
 	releaseErr := results.ForReason("releasing_lease").ForError(releaseLeases(client, s.metricsAgent, s.leases...))
 
@@ -195,7 +204,7 @@ func (s *leaseStep) acquireLeases(ctx context.Context, cancel context.CancelFunc
 			if err == lease.ErrNotFound {
 				printResourceMetrics(client, l.ResourceType)
 			}
-			errs = append(errs, results.ForReason(results.Reason("acquiring_lease")).WithError(err).Errorf("failed to acquire lease for %q: %v", l.ResourceType, err))
+			errs = append(errs, results.ForReason(results.Reason("acquiring_lease")).WithError(results.WithDetails(err, results.Details{LeaseTypes: []string{l.ResourceType}})).Errorf("failed to acquire lease for %q: %v", l.ResourceType, err))
 			break
 		}
 
@@ -1,13 +1,22 @@
 package steps
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
 
+	"sigs.k8s.io/boskos/common"
+
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/lease"
 )
@@ -92,6 +101,86 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestLeaseProxyWithLocalBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leases.json")
+	raw, err := json.Marshal(lease.LocalState{Resources: []common.Resource{
+		{Name: "us-east-1--aws-quota-slice-0", Type: "aws-quota-slice", State: "free"},
+		{Name: "us-east-1--aws-quota-slice-1", Type: "aws-quota-slice", State: "free"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to marshal state: %v", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	client := lease.NewClientWithBackend(lease.NewFileBackend("ci-op-1234", path), 0, time.Minute)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	step := LeaseProxyStep(logrus.NewEntry(logrus.StandardLogger()), server.URL, mux, &client)
+	if err := step.Run(context.Background()); err != nil {
+		t.Fatalf("failed to run step: %v", err)
+	}
+
+	resp, err := http.Post(server.URL+"/lease/acquire?type=aws-quota-slice&count=2", "", nil)
+	if err != nil {
+		t.Fatalf("failed to acquire leases: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status acquiring leases: %s", resp.Status)
+	}
+	var acquired struct {
+		Names []string `json:"names"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&acquired); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(acquired.Names) != 2 {
+		t.Fatalf("expected two leases, got %v", acquired.Names)
+	}
+	if metrics, err := client.Metrics("aws-quota-slice"); err != nil || metrics.Leased != 2 {
+		t.Errorf("expected both resources to be leased, got %+v: %v", metrics, err)
+	}
+
+	resp, err = http.Post(server.URL+"/lease/acquire?type=gcp-quota-slice", "", nil)
+	if err != nil {
+		t.Fatalf("failed to acquire leases: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected unknown type to not be found, got %s", resp.Status)
+	}
+
+	body, err := json.Marshal(map[string][]string{"names": acquired.Names})
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+	resp, err = http.Post(server.URL+"/lease/release", "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatalf("failed to release leases: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("unexpected status releasing leases: %s", resp.Status)
+	}
+
+	raw, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read state: %v", err)
+	}
+	var state lease.LocalState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		t.Fatalf("failed to unmarshal state: %v", err)
+	}
+	for _, r := range state.Resources {
+		if r.State != "free" || r.Owner != "" {
+			t.Errorf("expected %s to be released, got state %q owned by %q", r.Name, r.State, r.Owner)
+		}
+	}
+}
+
 func cmpError(t *testing.T, want, got error) {
 	if got != nil && want == nil {
 		t.Errorf("want err nil but got: %v", got)
@@ -58,6 +58,7 @@ func (s *multiStageTestStep) generateObservers(
 type generatePodOptions struct {
 	IsObserver                  bool
 	enableSecretsStoreCSIDriver bool
+	quarantineAddress           string
 }
 
 func defaultGeneratePodOptions() *generatePodOptions {
@@ -251,6 +252,8 @@ func (s *multiStageTestStep) generatePods(
 		}
 		addSharedDirSecret(s.name, pod)
 		addCredentials(step.Credentials, pod, genPodOpts.enableSecretsStoreCSIDriver)
+		censorCredentials(step.Credentials, pod)
+		forgiveQuarantinedTests(genPodOpts.quarantineAddress, pod)
 		if step.RunAsScript != nil && *step.RunAsScript {
 			addCommandScript(commandConfigMapForTest(s.name), pod)
 		}
@@ -502,6 +505,8 @@ func addProfile(name string, profile api.ClusterProfileLiteral, stsEnabled bool,
 		Name:      profileVolumeName,
 		MountPath: ClusterProfileMountPath,
 	})
+	// the profile holds cloud credentials, which must not leak into the artifacts
+	container.Args = append([]string{"--censor-secret-dir", ClusterProfileMountPath}, container.Args...)
 	container.Env = append(container.Env, []coreapi.EnvVar{{
 		Name:  "CLUSTER_PROFILE_NAME",
 		Value: profile.Name,
@@ -665,6 +670,36 @@ func addCredentials(credentials []api.CredentialReference, pod *coreapi.Pod, use
 	}
 }
 
+// censorCredentials configures the entrypoint wrapper to censor the content
+// of the credentials mounted in the step from its artifacts before they are
+// uploaded. The flags are passed before the wrapped command.
+func censorCredentials(credentials []api.CredentialReference, pod *coreapi.Pod) {
+	mountPaths := sets.New[string]()
+	for _, credential := range credentials {
+		mountPaths.Insert(credential.MountPath)
+	}
+	if mountPaths.Len() == 0 {
+		return
+	}
+	var args []string
+	for _, mountPath := range sets.List(mountPaths) {
+		args = append(args, "--censor-secret-dir", mountPath)
+	}
+	container := &pod.Spec.Containers[0]
+	container.Args = append(args, container.Args...)
+}
+
+// forgiveQuarantinedTests configures the entrypoint wrapper to mark the
+// quarantined tests as informing in the JUnit artifacts of the step and to
+// not fail the step when only they failed.
+func forgiveQuarantinedTests(address string, pod *coreapi.Pod) {
+	if address == "" {
+		return
+	}
+	container := &pod.Spec.Containers[0]
+	container.Args = append([]string{"--quarantine-address", address}, container.Args...)
+}
+
 func commandConfigMapForTest(testName string) string {
 	return fmt.Sprintf("%s-commands", testName)
 }
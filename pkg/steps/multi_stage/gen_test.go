@@ -96,6 +96,11 @@ func TestGeneratePods(t *testing.T) {
 							As:       "step1",
 							From:     "image1",
 							Commands: "command1",
+							Credentials: []api.CredentialReference{
+								{Namespace: "test-credentials", Name: "creds", MountPath: "/var/run/creds"},
+								{Namespace: "test-credentials", Name: "more-creds", MountPath: "/var/run/creds"},
+								{Namespace: "test-credentials", Name: "other-creds", MountPath: "/var/run/other"},
+							},
 						}, {
 							As: "step2", From: "stable-initial:installer", Commands: "command2", RunAsScript: ptr.To(true),
 						}, {
@@ -215,7 +220,7 @@ func TestGeneratePods(t *testing.T) {
 			}
 
 			js := jobSpec()
-			step := newMultiStageTestStep(tc.config.Tests[0], tc.config, params, nil, &js, nil, "node-name", "", nil, false, nil, tc.leaseProxyServerAvailable, wait.Backoff{})
+			step := newMultiStageTestStep(tc.config.Tests[0], tc.config, params, nil, &js, nil, "node-name", "", nil, false, nil, tc.leaseProxyServerAvailable, wait.Backoff{}, "")
 			step.stsHomeRoleARN = tc.stsHomeRoleARN
 			step.stsHubRoleARN = tc.stsHubRoleARN
 			step.stsTargetRoleARN = tc.stsTargetRoleARN
@@ -290,7 +295,7 @@ func TestGenerateObservers(t *testing.T) {
 		},
 	}
 	jobSpec.SetNamespace("namespace")
-	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, false, wait.Backoff{})
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, false, wait.Backoff{}, "")
 	ret, err := step.generateObservers(observers, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
@@ -364,7 +369,7 @@ func TestGeneratePodsEnvironment(t *testing.T) {
 					Test:        test,
 					Environment: tc.env,
 				},
-			}, &api.ReleaseBuildConfiguration{}, fakeStepParams{}, nil, &jobSpec, nil, "node-name", "", nil, false, nil, false, wait.Backoff{})
+			}, &api.ReleaseBuildConfiguration{}, fakeStepParams{}, nil, &jobSpec, nil, "node-name", "", nil, false, nil, false, wait.Backoff{}, "")
 			pods, _, err := step.(*multiStageTestStep).generatePods(test, nil, nil, nil, nil)
 			if err != nil {
 				t.Fatal(err)
@@ -432,7 +437,7 @@ func TestGeneratePodBestEffort(t *testing.T) {
 		},
 	}
 	jobSpec.SetNamespace("namespace")
-	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, false, wait.Backoff{})
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, false, wait.Backoff{}, "")
 	_, bestEffortSteps, err := step.generatePods(config.Tests[0].MultiStageTestConfigurationLiteral.Post, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
@@ -448,6 +453,55 @@ func TestGeneratePodBestEffort(t *testing.T) {
 	}
 }
 
+func TestCensorCredentials(t *testing.T) {
+	pod := &coreapi.Pod{Spec: coreapi.PodSpec{Containers: []coreapi.Container{{
+		Args: []string{"--dry-run=false", "/bin/bash", "-c", "command"},
+	}}}}
+	censorCredentials([]api.CredentialReference{
+		{Namespace: "test-credentials", Name: "b", MountPath: "/var/run/b"},
+		{Namespace: "test-credentials", Name: "a", MountPath: "/var/run/a"},
+		{Namespace: "test-credentials", Name: "other", MountPath: "/var/run/a"},
+	}, pod)
+	expected := []string{"--censor-secret-dir", "/var/run/a", "--censor-secret-dir", "/var/run/b", "--dry-run=false", "/bin/bash", "-c", "command"}
+	if diff := cmp.Diff(expected, pod.Spec.Containers[0].Args); diff != "" {
+		t.Errorf("unexpected args: %s", diff)
+	}
+
+	withoutCredentials := &coreapi.Pod{Spec: coreapi.PodSpec{Containers: []coreapi.Container{{Args: []string{"command"}}}}}
+	censorCredentials(nil, withoutCredentials)
+	if diff := cmp.Diff([]string{"command"}, withoutCredentials.Spec.Containers[0].Args); diff != "" {
+		t.Errorf("unexpected args without credentials: %s", diff)
+	}
+}
+
+func TestAddProfileCensorsIt(t *testing.T) {
+	pod := &coreapi.Pod{Spec: coreapi.PodSpec{Containers: []coreapi.Container{{
+		Args: []string{"--dry-run=false", "/bin/bash", "-c", "command"},
+	}}}}
+	addProfile("profile-secret", api.ClusterProfileLiteral{Name: "aws", ClusterType: "aws"}, false, pod)
+	expected := []string{"--censor-secret-dir", ClusterProfileMountPath, "--dry-run=false", "/bin/bash", "-c", "command"}
+	if diff := cmp.Diff(expected, pod.Spec.Containers[0].Args); diff != "" {
+		t.Errorf("unexpected args: %s", diff)
+	}
+}
+
+func TestForgiveQuarantinedTests(t *testing.T) {
+	pod := &coreapi.Pod{Spec: coreapi.PodSpec{Containers: []coreapi.Container{{
+		Args: []string{"--dry-run=false", "/bin/bash", "-c", "command"},
+	}}}}
+	forgiveQuarantinedTests("http://quarantine", pod)
+	expected := []string{"--quarantine-address", "http://quarantine", "--dry-run=false", "/bin/bash", "-c", "command"}
+	if diff := cmp.Diff(expected, pod.Spec.Containers[0].Args); diff != "" {
+		t.Errorf("unexpected args: %s", diff)
+	}
+
+	withoutAddress := &coreapi.Pod{Spec: coreapi.PodSpec{Containers: []coreapi.Container{{Args: []string{"command"}}}}}
+	forgiveQuarantinedTests("", withoutAddress)
+	if diff := cmp.Diff([]string{"command"}, withoutAddress.Spec.Containers[0].Args); diff != "" {
+		t.Errorf("unexpected args without an address: %s", diff)
+	}
+}
+
 func TestAddCredentials(t *testing.T) {
 	var testCases = []struct {
 		name        string
@@ -800,3 +854,76 @@ func TestSetSecurityContexts(t *testing.T) {
 		})
 	}
 }
+
+func TestPlan(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{
+			As: "test",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				AllowSkipOnSuccess:       ptr.To(true),
+				AllowBestEffortPostSteps: ptr.To(true),
+				Pre: []api.LiteralTestStep{{
+					As: "install", From: "installer", Commands: "install",
+					Credentials: []api.CredentialReference{{Namespace: "ns", Name: "creds", MountPath: "/creds"}},
+				}},
+				Test: []api.LiteralTestStep{{
+					As: "e2e", From: "src", Commands: "test",
+					Dependencies: []api.StepDependency{{Name: "stable:installer", Env: "INSTALLER"}},
+				}},
+				Post: []api.LiteralTestStep{{
+					As: "gather", From: "src", Commands: "gather",
+					BestEffort: ptr.To(true), OptionalOnSuccess: ptr.To(true),
+				}},
+			},
+		}},
+	}
+	jobSpec := api.JobSpec{
+		JobSpec: prowdapi.JobSpec{
+			Job:       "job",
+			BuildID:   "build id",
+			ProwJobID: "prow job id",
+			Type:      "periodic",
+			DecorationConfig: &prowapi.DecorationConfig{
+				UtilityImages: &prowapi.UtilityImages{Sidecar: "sidecar", Entrypoint: "entrypoint"},
+			},
+		},
+	}
+	jobSpec.SetNamespace("namespace")
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false, nil, false, wait.Backoff{}, "")
+	plan := api.StepPlan{}
+	step.Plan(&plan)
+	if plan.PodsError != "" {
+		t.Fatalf("unexpected error generating pods: %s", plan.PodsError)
+	}
+	if diff := cmp.Diff([]api.CredentialReference{{Namespace: "ns", Name: "creds", MountPath: "/creds"}}, plan.Credentials); diff != "" {
+		t.Errorf("unexpected credentials: %s", diff)
+	}
+	type pod struct {
+		phase, step          string
+		bestEffort, optional bool
+	}
+	var pods []pod
+	for _, p := range plan.Pods {
+		pods = append(pods, pod{phase: p.Phase, step: p.Step, bestEffort: p.BestEffort, optional: p.OptionalOnSuccess})
+	}
+	expected := []pod{
+		{phase: "pre", step: "install"},
+		{phase: "test", step: "e2e"},
+		{phase: "post", step: "gather", bestEffort: true, optional: true},
+	}
+	if diff := cmp.Diff(expected, pods, cmp.AllowUnexported(pod{})); diff != "" {
+		t.Errorf("unexpected pods: %s", diff)
+	}
+	var installer string
+	for _, env := range plan.Pods[1].Spec.Containers[0].Env {
+		if env.Name == "INSTALLER" {
+			installer = env.Value
+		}
+	}
+	if installer != "stable:installer" {
+		t.Errorf("expected unresolved dependency to reference its image stream tag, got %q", installer)
+	}
+	if deps := config.Tests[0].MultiStageTestConfigurationLiteral.Test[0].Dependencies; deps[0].PullSpec != "" {
+		t.Errorf("planning must not mutate the configuration, got pull spec %q", deps[0].PullSpec)
+	}
+}
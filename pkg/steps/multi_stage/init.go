@@ -269,7 +269,7 @@ func (s *multiStageTestStep) setupRBAC(ctx context.Context) error {
 		}, {
 			APIGroups:     []string{""},
 			Resources:     []string{"secrets"},
-			ResourceNames: []string{s.name, api.EphemeralClusterTestDoneSignalSecretName},
+			ResourceNames: []string{s.name, api.EphemeralClusterTestDoneSignalSecretName, api.EphemeralClusterHibernateSignalSecretName},
 			Verbs:         []string{"get", "update"},
 		}, {
 			APIGroups: []string{"", "image.openshift.io"},
@@ -281,7 +281,7 @@ func TestSetupRBAC(t *testing.T) {
 							Verbs:         []string{"get", "update"},
 							APIGroups:     []string{""},
 							Resources:     []string{"secrets"},
-							ResourceNames: []string{"nested-podman", "test-done-signal"},
+							ResourceNames: []string{"nested-podman", "test-done-signal", "hibernate-signal"},
 						},
 						{
 							Verbs:     []string{"get"},
@@ -0,0 +1,386 @@
+// Package local executes multi-stage tests on the local machine with a
+// podman-compatible container runtime instead of in a build farm namespace.
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/prow/pkg/entrypoint"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/steps/multi_stage"
+)
+
+const (
+	// ArtifactMountPath is where the artifact directory of a step is mounted,
+	// matching the location in pods run by ci-operator
+	ArtifactMountPath = "/logs/artifacts"
+	// HomeMountPath is the writable home directory of a step
+	HomeMountPath = "/alabama"
+)
+
+// Mount is a directory on the host mounted into a container.
+type Mount struct {
+	Source      string
+	Destination string
+	ReadOnly    bool
+}
+
+// Container is the specification of a single step to run.
+type Container struct {
+	Name    string
+	Image   string
+	Command []string
+	Env     []coreapi.EnvVar
+	Mounts  []Mount
+	// GracePeriod is the time a container is given to stop after it is interrupted
+	GracePeriod time.Duration
+	// Architecture is the architecture of the image to use, if set
+	Architecture string
+	DNSServers   []string
+	DNSSearches  []string
+}
+
+// Runtime runs containers to completion.
+type Runtime interface {
+	Run(ctx context.Context, container Container, output io.Writer) error
+}
+
+// Config holds everything that is provided by ci-operator and the build farm
+// when a test runs in the cluster and needs to be provided locally instead.
+type Config struct {
+	// Images maps images used by steps, either pipeline images like `src` or
+	// dependencies like `release:latest`, to pull specs
+	Images map[string]string
+	// CredentialsDir contains a directory for every credential steps use, as
+	// `<namespace>/<name>` or `<collection>/<group>`
+	CredentialsDir string
+	// ClusterProfileDir is mounted as the cluster profile, if set
+	ClusterProfileDir string
+	// WorkDir holds the shared and artifact directories for the test
+	WorkDir string
+	// Namespace is exposed to steps as $NAMESPACE
+	Namespace string
+	// Output receives the output of all steps
+	Output io.Writer
+}
+
+// Executor runs multi-stage tests with a container runtime.
+type Executor struct {
+	runtime Runtime
+	config  Config
+}
+
+// NewExecutor creates an executor running steps with the given runtime.
+func NewExecutor(runtime Runtime, config Config) *Executor {
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	if config.Namespace == "" {
+		config.Namespace = "local"
+	}
+	return &Executor{runtime: runtime, config: config}
+}
+
+// test holds the state of a single execution of a multi-stage test
+type test struct {
+	name                string
+	literal             api.MultiStageTestConfigurationLiteral
+	sharedDir           string
+	allowSkipOnSuccess  bool
+	allowBestEffortPost bool
+	hasPrevErrs         bool
+	testCases           []*junit.TestCase
+}
+
+// Run executes the test the same way ci-operator would: pre steps run first,
+// test steps run if all pre steps succeeded and post steps run regardless.
+// Every step and phase is reported as a test case.
+func (e *Executor) Run(ctx context.Context, name string, literal api.MultiStageTestConfigurationLiteral) (*junit.TestSuite, error) {
+	t := &test{
+		name:                name,
+		literal:             literal,
+		sharedDir:           filepath.Join(e.config.WorkDir, name, "shared"),
+		allowSkipOnSuccess:  literal.AllowSkipOnSuccess != nil && *literal.AllowSkipOnSuccess,
+		allowBestEffortPost: literal.AllowBestEffortPostSteps != nil && *literal.AllowBestEffortPostSteps,
+	}
+	if err := os.MkdirAll(t.sharedDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create shared directory: %w", err)
+	}
+	if len(literal.Observers) > 0 {
+		logrus.Warnf("Observers are not supported when running locally, ignoring %d observer(s).", len(literal.Observers))
+	}
+
+	var errs []error
+	if err := e.runPhase(ctx, t, "pre", literal.Pre, true); err != nil {
+		errs = append(errs, fmt.Errorf("%q pre steps failed: %w", name, err))
+	} else if err := e.runPhase(ctx, t, "test", literal.Test, true); err != nil {
+		errs = append(errs, fmt.Errorf("%q test steps failed: %w", name, err))
+	}
+	if err := e.runPhase(context.Background(), t, "post", literal.Post, false); err != nil {
+		errs = append(errs, fmt.Errorf("%q post steps failed: %w", name, err))
+	}
+
+	suite := &junit.TestSuite{Name: name}
+	for _, testCase := range t.testCases {
+		switch {
+		case testCase.FailureOutput != nil:
+			suite.NumFailed++
+		case testCase.SkipMessage != nil:
+			suite.NumSkipped++
+		}
+		suite.NumTests++
+		suite.Duration += testCase.Duration
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return suite, utilerrors.NewAggregate(errs)
+}
+
+func (e *Executor) runPhase(ctx context.Context, t *test, phase string, steps []api.LiteralTestStep, shortCircuit bool) error {
+	start := time.Now()
+	logrus.Infof("Running multi-stage phase %s", phase)
+	var errs []error
+	for _, step := range steps {
+		if shortCircuit && len(errs) > 0 {
+			break
+		}
+		name := fmt.Sprintf("%s-%s", t.name, step.As)
+		testCase := &junit.TestCase{Name: fmt.Sprintf("Run multi-stage test %s - %s container test", phase, name)}
+		if o := step.OptionalOnSuccess; o != nil && *o && t.allowSkipOnSuccess && !t.hasPrevErrs {
+			logrus.Infof("Skipping optional step %s", name)
+			testCase.SkipMessage = &junit.SkipMessage{Message: "Optional step skipped as all previous steps succeeded."}
+			t.testCases = append(t.testCases, testCase)
+			continue
+		}
+		stepStart := time.Now()
+		err := e.runStep(ctx, t, step)
+		testCase.Duration = time.Since(stepStart).Seconds()
+		t.testCases = append(t.testCases, testCase)
+		if err == nil {
+			continue
+		}
+		testCase.FailureOutput = &junit.FailureOutput{Output: err.Error()}
+		if t.allowBestEffortPost && step.BestEffort != nil && *step.BestEffort {
+			logrus.Infof("Step %s is running in best-effort mode, ignoring the failure...", name)
+			continue
+		}
+		errs = append(errs, err)
+	}
+	if ctx.Err() != nil {
+		errs = append(errs, errors.New("cancelled"))
+	}
+
+	err := utilerrors.NewAggregate(errs)
+	if err != nil {
+		t.hasPrevErrs = true
+	}
+	testCase := &junit.TestCase{
+		Name:      fmt.Sprintf("Run multi-stage test %s phase", phase),
+		Duration:  time.Since(start).Seconds(),
+		SystemOut: fmt.Sprintf("The collected steps of multi-stage phase %s.", phase),
+	}
+	verb := "succeeded"
+	if err != nil {
+		verb = "failed"
+		testCase.FailureOutput = &junit.FailureOutput{Output: err.Error()}
+	}
+	t.testCases = append(t.testCases, testCase)
+	logrus.Infof("Step phase %s %s after %s.", phase, verb, time.Since(start).Truncate(time.Second))
+	return err
+}
+
+func (e *Executor) runStep(ctx context.Context, t *test, step api.LiteralTestStep) error {
+	name := fmt.Sprintf("%s-%s", t.name, step.As)
+	container, err := e.containerFor(t, step)
+	if err != nil {
+		return fmt.Errorf("could not prepare step %s: %w", name, err)
+	}
+	timeout := entrypoint.DefaultTimeout
+	if step.Timeout != nil {
+		timeout = step.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	logrus.Infof("Running step %s.", name)
+	start := time.Now()
+	err = e.runtime.Run(ctx, container, e.config.Output)
+	duration := time.Since(start).Truncate(time.Second)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("step timed out after %s: %w", timeout, err)
+		}
+		logrus.Infof("Step %s failed after %s.", name, duration)
+		return fmt.Errorf("%q step failed: %w", name, err)
+	}
+	logrus.Infof("Step %s succeeded after %s.", name, duration)
+	return nil
+}
+
+// containerFor generates the container for a step, mirroring the pod
+// ci-operator creates for it in the cluster
+func (e *Executor) containerFor(t *test, step api.LiteralTestStep) (Container, error) {
+	name := fmt.Sprintf("%s-%s", t.name, step.As)
+	var errs []error
+	image, err := e.imageFor(step)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	stepDir := filepath.Join(e.config.WorkDir, t.name, step.As)
+	artifactDir, homeDir := filepath.Join(stepDir, "artifacts"), filepath.Join(stepDir, "home")
+	for _, dir := range []string{artifactDir, homeDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return Container{}, fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	container := Container{
+		Name:  name,
+		Image: image,
+		Mounts: []Mount{
+			{Source: artifactDir, Destination: ArtifactMountPath},
+			{Source: homeDir, Destination: HomeMountPath},
+			{Source: t.sharedDir, Destination: multi_stage.SecretMountPath},
+		},
+		Env: []coreapi.EnvVar{
+			{Name: "ARTIFACT_DIR", Value: ArtifactMountPath},
+			{Name: multi_stage.SecretMountEnv, Value: multi_stage.SecretMountPath},
+			{Name: "NAMESPACE", Value: e.config.Namespace},
+			{Name: "JOB_NAME_SAFE", Value: strings.Replace(t.name, "_", "-", -1)},
+		},
+		GracePeriod: entrypoint.DefaultGracePeriod,
+	}
+	if step.GracePeriod != nil {
+		container.GracePeriod = step.GracePeriod.Duration
+	}
+	if step.RunAsScript != nil && *step.RunAsScript {
+		scriptDir := filepath.Join(stepDir, "commands")
+		if err := os.MkdirAll(scriptDir, 0755); err != nil {
+			return Container{}, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(scriptDir, step.As), []byte(step.Commands), 0755); err != nil {
+			return Container{}, fmt.Errorf("failed to write commands: %w", err)
+		}
+		container.Mounts = append(container.Mounts, Mount{Source: scriptDir, Destination: multi_stage.CommandScriptMountPath, ReadOnly: true})
+		container.Command = []string{fmt.Sprintf("%s/%s", multi_stage.CommandScriptMountPath, step.As)}
+	} else {
+		container.Command = []string{"/bin/bash", "-c", multi_stage.CommandPrefix + step.Commands}
+	}
+
+	if step.NoKubeconfig == nil || !*step.NoKubeconfig {
+		container.Env = append(container.Env, []coreapi.EnvVar{
+			{Name: "KUBECONFIG", Value: filepath.Join(multi_stage.SecretMountPath, "kubeconfig")},
+			{Name: "KUBECONFIGMINIMAL", Value: filepath.Join(multi_stage.SecretMountPath, "kubeconfig-minimal")},
+			{Name: "KUBEADMIN_PASSWORD_FILE", Value: filepath.Join(multi_stage.SecretMountPath, "kubeadmin-password")},
+		}...)
+	}
+	if e.config.ClusterProfileDir != "" {
+		container.Mounts = append(container.Mounts, Mount{Source: e.config.ClusterProfileDir, Destination: multi_stage.ClusterProfileMountPath, ReadOnly: true})
+		container.Env = append(container.Env, coreapi.EnvVar{Name: multi_stage.ClusterProfileMountEnv, Value: multi_stage.ClusterProfileMountPath})
+	}
+	for _, credential := range step.Credentials {
+		source, err := e.credentialDir(credential)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		container.Mounts = append(container.Mounts, Mount{Source: source, Destination: credential.MountPath, ReadOnly: true})
+	}
+
+	container.Env = append(container.Env, envForParameters(step.Environment, t.literal.Environment)...)
+	dependencies, err := e.envForDependencies(step, t.literal.DependencyOverrides)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	container.Env = append(container.Env, dependencies...)
+
+	if step.NodeArchitecture != nil {
+		container.Architecture = string(*step.NodeArchitecture)
+	}
+	if step.DNSConfig != nil {
+		container.DNSServers = step.DNSConfig.Nameservers
+		container.DNSSearches = step.DNSConfig.Searches
+	}
+	return container, utilerrors.NewAggregate(errs)
+}
+
+// imageFor determines the pull spec of the image the step runs in
+func (e *Executor) imageFor(step api.LiteralTestStep) (string, error) {
+	if step.FromImage != nil {
+		if image, ok := e.config.Images[step.FromImage.ISTagName()]; ok {
+			return image, nil
+		}
+		return api.QuayImageReference(*step.FromImage), nil
+	}
+	if image, ok := e.config.Images[step.From]; ok {
+		return image, nil
+	}
+	return "", fmt.Errorf("no pull spec provided for image %q", step.From)
+}
+
+// credentialDir determines the directory holding the credential
+func (e *Executor) credentialDir(credential api.CredentialReference) (string, error) {
+	var dir string
+	switch {
+	case credential.Namespace != "" && credential.Name != "":
+		dir = filepath.Join(e.config.CredentialsDir, credential.Namespace, credential.Name)
+	case credential.Collection != "":
+		dir = filepath.Join(e.config.CredentialsDir, credential.Collection, credential.Group)
+	default:
+		return "", fmt.Errorf("credential mounted at %s does not identify a secret", credential.MountPath)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("credential mounted at %s is not available locally: %w", credential.MountPath, err)
+	}
+	return dir, nil
+}
+
+// envForParameters resolves step parameters, where test-level values take
+// precedence over the defaults
+func envForParameters(params []api.StepParameter, overrides api.TestEnvironment) []coreapi.EnvVar {
+	var ret []coreapi.EnvVar
+	for _, param := range params {
+		value := ""
+		if param.Default != nil {
+			value = *param.Default
+		}
+		if v, ok := overrides[param.Name]; ok {
+			value = v
+		}
+		ret = append(ret, coreapi.EnvVar{Name: param.Name, Value: value})
+	}
+	return ret
+}
+
+func (e *Executor) envForDependencies(step api.LiteralTestStep, overrides api.DependencyOverrides) ([]coreapi.EnvVar, error) {
+	var env []coreapi.EnvVar
+	var errs []error
+	for _, dependency := range step.Dependencies {
+		ref := dependency.PullSpec
+		if override, ok := overrides[dependency.Env]; ok {
+			ref = override
+		}
+		if ref == "" {
+			ref = e.config.Images[dependency.Name]
+		}
+		if ref == "" {
+			errs = append(errs, fmt.Errorf("no pull spec provided for dependency %q of step %s", dependency.Name, step.As))
+			continue
+		}
+		env = append(env, coreapi.EnvVar{Name: dependency.Env, Value: ref})
+	}
+	return env, utilerrors.NewAggregate(errs)
+}
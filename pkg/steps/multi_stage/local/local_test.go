@@ -0,0 +1,232 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/prow/pkg/entrypoint"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+type fakeRuntime struct {
+	fail       map[string]bool
+	containers []Container
+}
+
+func (r *fakeRuntime) Run(_ context.Context, container Container, _ io.Writer) error {
+	r.containers = append(r.containers, container)
+	if r.fail[container.Name] {
+		return errors.New("exit status 1")
+	}
+	return nil
+}
+
+func (r *fakeRuntime) ran() []string {
+	var names []string
+	for _, c := range r.containers {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestExecutorRun(t *testing.T) {
+	step := func(as string) api.LiteralTestStep {
+		return api.LiteralTestStep{As: as, From: "src", Commands: "true"}
+	}
+	optional := step("optional")
+	optional.OptionalOnSuccess = utilpointer.Bool(true)
+	bestEffort := step("best-effort")
+	bestEffort.BestEffort = utilpointer.Bool(true)
+
+	var testCases = []struct {
+		name          string
+		literal       api.MultiStageTestConfigurationLiteral
+		fail          []string
+		expectedRan   []string
+		expectedError bool
+	}{
+		{
+			name: "all phases succeed",
+			literal: api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{step("install")},
+				Test: []api.LiteralTestStep{step("e2e")},
+				Post: []api.LiteralTestStep{step("gather"), step("deprovision")},
+			},
+			expectedRan: []string{"test-install", "test-e2e", "test-gather", "test-deprovision"},
+		},
+		{
+			name: "pre step fails, test steps are skipped and post steps run",
+			literal: api.MultiStageTestConfigurationLiteral{
+				Pre:  []api.LiteralTestStep{step("install"), step("configure")},
+				Test: []api.LiteralTestStep{step("e2e")},
+				Post: []api.LiteralTestStep{step("gather"), step("deprovision")},
+			},
+			fail:          []string{"test-install"},
+			expectedRan:   []string{"test-install", "test-gather", "test-deprovision"},
+			expectedError: true,
+		},
+		{
+			name: "post steps do not short-circuit",
+			literal: api.MultiStageTestConfigurationLiteral{
+				Post: []api.LiteralTestStep{step("gather"), step("deprovision")},
+			},
+			fail:          []string{"test-gather"},
+			expectedRan:   []string{"test-gather", "test-deprovision"},
+			expectedError: true,
+		},
+		{
+			name: "optional step skipped on success",
+			literal: api.MultiStageTestConfigurationLiteral{
+				AllowSkipOnSuccess: utilpointer.Bool(true),
+				Test:               []api.LiteralTestStep{step("e2e")},
+				Post:               []api.LiteralTestStep{optional, step("deprovision")},
+			},
+			expectedRan: []string{"test-e2e", "test-deprovision"},
+		},
+		{
+			name: "optional step runs on failure",
+			literal: api.MultiStageTestConfigurationLiteral{
+				AllowSkipOnSuccess: utilpointer.Bool(true),
+				Test:               []api.LiteralTestStep{step("e2e")},
+				Post:               []api.LiteralTestStep{optional, step("deprovision")},
+			},
+			fail:          []string{"test-e2e"},
+			expectedRan:   []string{"test-e2e", "test-optional", "test-deprovision"},
+			expectedError: true,
+		},
+		{
+			name: "optional step runs when skipping is not allowed",
+			literal: api.MultiStageTestConfigurationLiteral{
+				Post: []api.LiteralTestStep{optional},
+			},
+			expectedRan: []string{"test-optional"},
+		},
+		{
+			name: "best effort step failure is ignored",
+			literal: api.MultiStageTestConfigurationLiteral{
+				AllowBestEffortPostSteps: utilpointer.Bool(true),
+				Post:                     []api.LiteralTestStep{bestEffort, step("deprovision")},
+			},
+			fail:        []string{"test-best-effort"},
+			expectedRan: []string{"test-best-effort", "test-deprovision"},
+		},
+		{
+			name: "best effort step failure is not ignored when not allowed",
+			literal: api.MultiStageTestConfigurationLiteral{
+				Post: []api.LiteralTestStep{bestEffort},
+			},
+			fail:          []string{"test-best-effort"},
+			expectedRan:   []string{"test-best-effort"},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runtime := &fakeRuntime{fail: map[string]bool{}}
+			for _, name := range tc.fail {
+				runtime.fail[name] = true
+			}
+			executor := NewExecutor(runtime, Config{Images: map[string]string{"src": "quay.io/org/src:latest"}, WorkDir: t.TempDir(), Output: io.Discard})
+			suite, err := executor.Run(context.Background(), "test", tc.literal)
+			if (err != nil) != tc.expectedError {
+				t.Errorf("expected error: %v, got %v", tc.expectedError, err)
+			}
+			if diff := cmp.Diff(tc.expectedRan, runtime.ran()); diff != "" {
+				t.Errorf("unexpected steps ran: %s", diff)
+			}
+			if suite.NumFailed == 0 && tc.expectedError {
+				t.Error("expected failures to be reported in the test suite")
+			}
+		})
+	}
+}
+
+func TestContainerFor(t *testing.T) {
+	workDir := t.TempDir()
+	credentials := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(credentials, "test-credentials", "aws"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	executor := NewExecutor(&fakeRuntime{}, Config{
+		Images:         map[string]string{"src": "quay.io/org/src:latest", "release:latest": "quay.io/org/release:latest"},
+		CredentialsDir: credentials,
+		WorkDir:        workDir,
+	})
+	literal := api.MultiStageTestConfigurationLiteral{
+		Environment:         api.TestEnvironment{"OVERRIDDEN": "test"},
+		DependencyOverrides: api.DependencyOverrides{"OVERRIDE_IMAGE": "quay.io/org/override:latest"},
+	}
+	step := api.LiteralTestStep{
+		As:           "e2e",
+		From:         "src",
+		Commands:     "make e2e",
+		NoKubeconfig: utilpointer.Bool(true),
+		Credentials:  []api.CredentialReference{{Namespace: "test-credentials", Name: "aws", MountPath: "/var/run/aws"}},
+		Environment: []api.StepParameter{
+			{Name: "DEFAULTED", Default: utilpointer.String("default")},
+			{Name: "OVERRIDDEN", Default: utilpointer.String("default")},
+		},
+		Dependencies: []api.StepDependency{
+			{Name: "release:latest", Env: "RELEASE_IMAGE"},
+			{Name: "pipeline:other", Env: "OVERRIDE_IMAGE"},
+		},
+	}
+	container, err := executor.containerFor(&test{name: "test", literal: literal, sharedDir: filepath.Join(workDir, "test", "shared")}, step)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Container{
+		Name:    "test-e2e",
+		Image:   "quay.io/org/src:latest",
+		Command: []string{"/bin/bash", "-c", "#!/bin/bash\nset -eu\nmake e2e"},
+		Env: []coreapi.EnvVar{
+			{Name: "ARTIFACT_DIR", Value: "/logs/artifacts"},
+			{Name: "SHARED_DIR", Value: "/var/run/secrets/ci.openshift.io/multi-stage"},
+			{Name: "NAMESPACE", Value: "local"},
+			{Name: "JOB_NAME_SAFE", Value: "test"},
+			{Name: "DEFAULTED", Value: "default"},
+			{Name: "OVERRIDDEN", Value: "test"},
+			{Name: "RELEASE_IMAGE", Value: "quay.io/org/release:latest"},
+			{Name: "OVERRIDE_IMAGE", Value: "quay.io/org/override:latest"},
+		},
+		Mounts: []Mount{
+			{Source: filepath.Join(workDir, "test", "e2e", "artifacts"), Destination: "/logs/artifacts"},
+			{Source: filepath.Join(workDir, "test", "e2e", "home"), Destination: "/alabama"},
+			{Source: filepath.Join(workDir, "test", "shared"), Destination: "/var/run/secrets/ci.openshift.io/multi-stage"},
+			{Source: filepath.Join(credentials, "test-credentials", "aws"), Destination: "/var/run/aws", ReadOnly: true},
+		},
+		GracePeriod: entrypoint.DefaultGracePeriod,
+	}
+	if diff := cmp.Diff(expected, container); diff != "" {
+		t.Errorf("unexpected container: %s", diff)
+	}
+}
+
+func TestContainerForMissingInputs(t *testing.T) {
+	executor := NewExecutor(&fakeRuntime{}, Config{WorkDir: t.TempDir(), CredentialsDir: t.TempDir()})
+	step := api.LiteralTestStep{
+		As:           "e2e",
+		From:         "src",
+		Credentials:  []api.CredentialReference{{Namespace: "test-credentials", Name: "aws", MountPath: "/var/run/aws"}},
+		Dependencies: []api.StepDependency{{Name: "release:latest", Env: "RELEASE_IMAGE"}},
+	}
+	_, err := executor.containerFor(&test{name: "test"}, step)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, expected := range []string{`no pull spec provided for image "src"`, "credential mounted at /var/run/aws is not available locally", `no pull spec provided for dependency "release:latest"`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
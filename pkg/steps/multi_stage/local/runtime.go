@@ -0,0 +1,73 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// cliRuntime runs containers with a CLI compatible with `podman run`
+type cliRuntime struct {
+	binary string
+}
+
+// NewCLIRuntime creates a runtime that runs containers with the given binary,
+// which must accept the arguments of `podman run`, like podman or docker.
+func NewCLIRuntime(binary string) Runtime {
+	return &cliRuntime{binary: binary}
+}
+
+func (r *cliRuntime) Run(ctx context.Context, container Container, output io.Writer) error {
+	cmd := exec.Command(r.binary, runArgs(container)...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", r.binary, err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		logrus.Infof("Stopping container %s...", container.Name)
+		grace := strconv.Itoa(int(container.GracePeriod.Seconds()))
+		if out, err := exec.Command(r.binary, "stop", "--time", grace, container.Name).CombinedOutput(); err != nil {
+			logrus.WithError(err).Warnf("Failed to stop container %s: %s", container.Name, string(out))
+		}
+		if err := <-done; err != nil {
+			return err
+		}
+		return ctx.Err()
+	}
+}
+
+// runArgs generates the arguments for `podman run`
+func runArgs(container Container) []string {
+	args := []string{"run", "--rm", "--name", container.Name}
+	for _, mount := range container.Mounts {
+		volume := fmt.Sprintf("%s:%s", mount.Source, mount.Destination)
+		if mount.ReadOnly {
+			volume += ":ro"
+		}
+		args = append(args, "--volume", volume)
+	}
+	for _, env := range container.Env {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", env.Name, env.Value))
+	}
+	if container.Architecture != "" {
+		args = append(args, "--arch", container.Architecture)
+	}
+	for _, server := range container.DNSServers {
+		args = append(args, "--dns", server)
+	}
+	for _, search := range container.DNSSearches {
+		args = append(args, "--dns-search", search)
+	}
+	args = append(args, "--entrypoint", container.Command[0], container.Image)
+	return append(args, container.Command[1:]...)
+}
@@ -0,0 +1,36 @@
+package local
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+)
+
+func TestRunArgs(t *testing.T) {
+	container := Container{
+		Name:         "test-e2e",
+		Image:        "quay.io/org/src:latest",
+		Command:      []string{"/bin/bash", "-c", "make e2e"},
+		Env:          []coreapi.EnvVar{{Name: "SHARED_DIR", Value: "/shared"}},
+		Mounts:       []Mount{{Source: "/tmp/shared", Destination: "/shared"}, {Source: "/tmp/aws", Destination: "/var/run/aws", ReadOnly: true}},
+		Architecture: "arm64",
+		DNSServers:   []string{"10.0.0.1"},
+		DNSSearches:  []string{"example.com"},
+	}
+	expected := []string{
+		"run", "--rm", "--name", "test-e2e",
+		"--volume", "/tmp/shared:/shared",
+		"--volume", "/tmp/aws:/var/run/aws:ro",
+		"--env", "SHARED_DIR=/shared",
+		"--arch", "arm64",
+		"--dns", "10.0.0.1",
+		"--dns-search", "example.com",
+		"--entrypoint", "/bin/bash", "quay.io/org/src:latest",
+		"-c", "make e2e",
+	}
+	if diff := cmp.Diff(expected, runArgs(container)); diff != "" {
+		t.Errorf("unexpected arguments: %s", diff)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -136,6 +137,9 @@ type multiStageTestStep struct {
 	stsHomeRoleARN                   string
 	stsHubRoleARN                    string
 	stsTargetRoleARN                 string
+	// quarantineAddress is the address of the flaky test quarantine service the
+	// test phase uses to not fail when only quarantined tests failed
+	quarantineAddress string
 }
 
 func MultiStageTestStep(
@@ -152,9 +156,10 @@ func MultiStageTestStep(
 	gsmConfig *GSMConfiguration,
 	leaseProxyServerAvailable bool,
 	leaseProxyClientConfigMapBackoff wait.Backoff,
+	quarantineAddress string,
 ) api.Step {
 	return newMultiStageTestStep(testConfig, config, params, client, jobSpec, leases, nodeName, targetAdditionalSuffix,
-		cancelObservers, enableSecretsStoreCSIDriver, gsmConfig, leaseProxyServerAvailable, leaseProxyClientConfigMapBackoff)
+		cancelObservers, enableSecretsStoreCSIDriver, gsmConfig, leaseProxyServerAvailable, leaseProxyClientConfigMapBackoff, quarantineAddress)
 }
 
 func newMultiStageTestStep(
@@ -171,6 +176,7 @@ func newMultiStageTestStep(
 	gsmConfig *GSMConfiguration,
 	leaseProxyServerAvailable bool,
 	leaseProxyClientConfigMapBackoff wait.Backoff,
+	quarantineAddress string,
 ) *multiStageTestStep {
 	ms := testConfig.MultiStageTestConfigurationLiteral
 	var flags stepFlag
@@ -204,6 +210,7 @@ func newMultiStageTestStep(
 		gsm:                              gsmConfig,
 		leaseProxyServerAvailable:        leaseProxyServerAvailable,
 		leaseProxyClientConfigMapBackoff: leaseProxyClientConfigMapBackoff,
+		quarantineAddress:                quarantineAddress,
 	}
 	s.requireNestedPodman = stepRequiresNestedPodman(s)
 
@@ -395,6 +402,61 @@ func (s *multiStageTestStep) SubSteps() []api.CIOperatorStepDetailInfo {
 	return s.subSteps
 }
 
+// Plan describes the pods created for every phase of the test. Dependencies
+// are not resolved, the pods reference their image stream tags instead.
+func (s *multiStageTestStep) Plan(plan *api.StepPlan) {
+	var claimRelease *api.ClaimRelease
+	if s.clusterClaim != nil {
+		claimRelease = s.clusterClaim.ClaimRelease(s.name)
+	}
+	var errs []error
+	for _, phase := range []struct {
+		name  string
+		steps []api.LiteralTestStep
+	}{{name: "pre", steps: s.pre}, {name: "test", steps: s.test}, {name: "post", steps: s.post}} {
+		for _, step := range phase.steps {
+			plan.Credentials = append(plan.Credentials, step.Credentials...)
+			if s.jobSpec.DecorationConfig == nil {
+				continue
+			}
+			// optional steps would be skipped when generating pods
+			optional := step.OptionalOnSuccess != nil && *step.OptionalOnSuccess
+			step.OptionalOnSuccess = nil
+			step.Dependencies = slices.Clone(step.Dependencies)
+			for i, dependency := range step.Dependencies {
+				if dependency.PullSpec == "" {
+					stream, tag, _ := s.config.DependencyParts(dependency, claimRelease)
+					step.Dependencies[i].PullSpec = fmt.Sprintf("%s:%s", stream, tag)
+				}
+			}
+			opts := defaultGeneratePodOptions()
+			opts.enableSecretsStoreCSIDriver = s.enableSecretsStoreCSIDriver
+			if phase.name == "test" {
+				opts.quarantineAddress = s.quarantineAddress
+			}
+			pods, bestEffort, err := s.generatePods([]api.LiteralTestStep{step}, nil, nil, nil, opts)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, pod := range pods {
+				plan.Pods = append(plan.Pods, api.PodPlan{
+					Phase:             phase.name,
+					Step:              step.As,
+					BestEffort:        bestEffort.Has(pod.Name),
+					OptionalOnSuccess: optional,
+					Spec:              pod.Spec,
+				})
+			}
+		}
+	}
+	if s.jobSpec.DecorationConfig == nil {
+		plan.PodsError = "the job spec has no decoration config, pods can not be generated"
+	} else if err := utilerrors.NewAggregate(errs); err != nil {
+		plan.PodsError = err.Error()
+	}
+}
+
 func (s *multiStageTestStep) Requires() (ret []api.StepLink) {
 	var claimRelease *api.ClaimRelease
 	if s.clusterClaim != nil {
@@ -19,6 +19,7 @@ import (
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
 	base_steps "github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/util"
 )
@@ -33,9 +34,13 @@ func (s *multiStageTestStep) runSteps(
 ) error {
 	start := time.Now()
 	logrus.Infof("Running multi-stage phase %s", phase)
-	pods, bestEffortSteps, err := s.generatePods(steps, env, secretVolumes, secretVolumeMounts, &generatePodOptions{
+	genPodOpts := &generatePodOptions{
 		enableSecretsStoreCSIDriver: s.enableSecretsStoreCSIDriver,
-	})
+	}
+	if phase == "test" {
+		genPodOpts.quarantineAddress = s.quarantineAddress
+	}
+	pods, bestEffortSteps, err := s.generatePods(steps, env, secretVolumes, secretVolumeMounts, genPodOpts)
 	if err != nil {
 		s.flags |= hasPrevErrs
 		return err
@@ -46,7 +51,7 @@ func (s *multiStageTestStep) runSteps(
 			s.flags |= hasPrevErrs
 		}
 	}()
-	if err := s.runPods(ctx, pods, bestEffortSteps); err != nil {
+	if err := s.runPods(ctx, phase, pods, bestEffortSteps); err != nil {
 		errs = append(errs, err)
 	}
 	select {
@@ -81,7 +86,7 @@ func (s *multiStageTestStep) runSteps(
 	return err
 }
 
-func (s *multiStageTestStep) runPods(ctx context.Context, pods []coreapi.Pod, bestEffortSteps sets.Set[string]) error {
+func (s *multiStageTestStep) runPods(ctx context.Context, phase string, pods []coreapi.Pod, bestEffortSteps sets.Set[string]) error {
 	var errs []error
 	for _, pod := range pods {
 		err := s.runPod(ctx, &pod, base_steps.NewTestCaseNotifier(util.NopNotifier), util.WaitForPodFlag(0))
@@ -92,7 +97,11 @@ func (s *multiStageTestStep) runPods(ctx context.Context, pods []coreapi.Pod, be
 			logrus.Infof("Pod %s is running in best-effort mode, ignoring the failure...", pod.Name)
 			continue
 		}
-		errs = append(errs, err)
+		errs = append(errs, results.WithDetails(err, results.Details{
+			Step:   strings.TrimPrefix(pod.Name, s.name+"-"),
+			Phase:  phase,
+			Images: containerImages(&pod),
+		}))
 		if s.flags&shortCircuit != 0 {
 			break
 		}
@@ -100,6 +109,26 @@ func (s *multiStageTestStep) runPods(ctx context.Context, pods []coreapi.Pod, be
 	return utilerrors.NewAggregate(errs)
 }
 
+// containerImages determines the images the containers of the pod ran, by
+// digest where the kubelet reported the image it pulled
+func containerImages(pod *coreapi.Pod) map[string]string {
+	images := map[string]string{}
+	for _, container := range pod.Spec.Containers {
+		images[container.Name] = container.Image
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		// image IDs may carry the container runtime's scheme, e.g. docker-pullable://
+		imageID := status.ImageID
+		if i := strings.Index(imageID, "://"); i != -1 {
+			imageID = imageID[i+len("://"):]
+		}
+		if _, isSpecified := images[status.Name]; isSpecified && strings.Contains(imageID, "@sha256:") {
+			images[status.Name] = imageID
+		}
+	}
+	return images
+}
+
 func (s *multiStageTestStep) runObservers(ctx, textCtx context.Context, pods []coreapi.Pod, done chan<- struct{}) {
 	wg := sync.WaitGroup{}
 	wg.Add(len(pods))
@@ -146,7 +175,8 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	}
 	newPod, err := util.WaitForPodCompletion(ctx, client, pod.Namespace, pod.Name, notifier, flags)
 	if newPod != nil {
-		pod = newPod
+		// the caller inspects the status of the pod, e.g. for the images it ran
+		*pod = *newPod
 	}
 
 	// If we got an error and the Pod is still pending (failed to schedule or failed to start all containers),
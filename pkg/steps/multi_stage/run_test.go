@@ -487,7 +487,7 @@ Link to job on registry info site: https://steps.ci.openshift.org/job?org=&repo=
 				PendingTimeout:  30 * time.Minute,
 				FakePodExecutor: crclient,
 			}
-			step := MultiStageTestStep(*tc.testConfig, &api.ReleaseBuildConfiguration{}, fakeStepParams{}, client, &jobSpec, nil, "node-name", "", func(cf context.CancelFunc) {}, false, nil, false, tc.leaseProxyClientConfigMapBackoff)
+			step := MultiStageTestStep(*tc.testConfig, &api.ReleaseBuildConfiguration{}, fakeStepParams{}, client, &jobSpec, nil, "node-name", "", func(cf context.CancelFunc) {}, false, nil, false, tc.leaseProxyClientConfigMapBackoff, "")
 
 			gotErr := step.Run(context.Background())
 
@@ -672,7 +672,7 @@ func TestJUnit(t *testing.T) {
 					Test: []api.LiteralTestStep{{As: "test0"}, {As: "test1"}},
 					Post: []api.LiteralTestStep{{As: "post0"}, {As: "post1"}},
 				},
-			}, &api.ReleaseBuildConfiguration{}, fakeStepParams{}, client, &jobSpec, nil, "node-name", "", nil, false, nil, false, wait.Backoff{})
+			}, &api.ReleaseBuildConfiguration{}, fakeStepParams{}, client, &jobSpec, nil, "node-name", "", nil, false, nil, false, wait.Backoff{}, "")
 			if err := step.Run(context.Background()); tc.failures == nil && err != nil {
 				t.Error(err)
 				return
@@ -748,7 +748,7 @@ func TestRunPodDeletesPendingPodsOnError(t *testing.T) {
 			Post:               []api.LiteralTestStep{{As: "post0"}},
 			AllowSkipOnSuccess: &yes,
 		},
-	}, &api.ReleaseBuildConfiguration{}, fakeStepParams{}, client, &jobSpec, nil, "node-name", "", func(cf context.CancelFunc) {}, false, nil, false, wait.Backoff{})
+	}, &api.ReleaseBuildConfiguration{}, fakeStepParams{}, client, &jobSpec, nil, "node-name", "", func(cf context.CancelFunc) {}, false, nil, false, wait.Backoff{}, "")
 
 	// Use a context with timeout to ensure the test doesn't hang
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -775,3 +775,23 @@ func TestRunPodDeletesPendingPodsOnError(t *testing.T) {
 		}
 	}
 }
+
+func TestContainerImages(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "test", Image: "registry.ci.openshift.org/ci/tests:latest"},
+			{Name: "sidecar", Image: "gcr.io/k8s-prow/sidecar:latest"},
+		}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "test", ImageID: "docker-pullable://registry.ci.openshift.org/ci/tests@sha256:abc"},
+			{Name: "sidecar", ImageID: ""},
+		}},
+	}
+	expected := map[string]string{
+		"test":    "registry.ci.openshift.org/ci/tests@sha256:abc",
+		"sidecar": "gcr.io/k8s-prow/sidecar:latest",
+	}
+	if diff := cmp.Diff(expected, containerImages(pod)); diff != "" {
+		t.Errorf("unexpected images: %s", diff)
+	}
+}
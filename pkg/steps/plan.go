@@ -0,0 +1,61 @@
+package steps
+
+import (
+	"sort"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// PlanReporter may be implemented by steps that can describe, without
+// running, what they will consume and create beyond their links.
+type PlanReporter interface {
+	Plan(plan *api.StepPlan)
+}
+
+// Plan describes the execution of the ordered steps and the promotion steps
+// without running any of them.
+func Plan(targets []string, nodes api.OrderedStepList, promotionSteps []api.Step) *api.ExecutionPlan {
+	plan := &api.ExecutionPlan{Targets: targets, Steps: []api.StepPlan{}}
+	for i, node := range nodes {
+		step := planFor(node.Step)
+		for _, requirement := range node.Step.Requires() {
+			// only the steps before this one can fulfill its requirements
+			for _, other := range nodes[:i] {
+				if api.HasAnyLinks([]api.StepLink{requirement}, other.Step.Creates()) {
+					step.Dependencies = append(step.Dependencies, other.Step.Name())
+				}
+			}
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	for _, promotionStep := range promotionSteps {
+		plan.PromotionSteps = append(plan.PromotionSteps, planFor(promotionStep))
+	}
+	return plan
+}
+
+func planFor(step api.Step) api.StepPlan {
+	plan := api.StepPlan{
+		Name:        step.Name(),
+		Description: step.Description(),
+	}
+	if inputs, err := step.Inputs(); err != nil {
+		plan.InputsError = err.Error()
+	} else {
+		plan.Inputs = inputs
+	}
+	for _, link := range step.Requires() {
+		plan.Requires = append(plan.Requires, api.DescribeLink(link))
+	}
+	for _, link := range step.Creates() {
+		plan.Creates = append(plan.Creates, api.DescribeLink(link))
+	}
+	for name := range step.Provides() {
+		plan.Provides = append(plan.Provides, name)
+	}
+	sort.Strings(plan.Provides)
+	if reporter, ok := step.(PlanReporter); ok {
+		reporter.Plan(&plan)
+	}
+	return plan
+}
@@ -0,0 +1,58 @@
+package steps
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+type plannedStep struct {
+	parameterStep
+	leases []api.StepLease
+}
+
+func (s *plannedStep) Plan(plan *api.StepPlan) { plan.Leases = s.leases }
+
+func TestPlan(t *testing.T) {
+	src := &fakeStep{name: "src", creates: []api.StepLink{api.InternalImageLink(api.PipelineImageStreamTagReferenceSource)}}
+	release := &fakeStep{name: "[release-inputs]", creates: []api.StepLink{api.ReleaseImagesLink(api.LatestReleaseName)}}
+	e2e := &plannedStep{
+		parameterStep: parameterStep{
+			fakeStep: fakeStep{name: "e2e", requires: []api.StepLink{
+				api.InternalImageLink(api.PipelineImageStreamTagReferenceSource),
+				api.ReleaseImagesLink(api.LatestReleaseName),
+			}},
+			params: api.ParameterMap{"B": nil, "A": nil},
+		},
+		leases: []api.StepLease{{ResourceType: "aws-quota-slice", Env: "LEASED_RESOURCE", Count: 1}},
+	}
+	graph := api.BuildGraph([]api.Step{src, release, e2e})
+	nodes, errs := graph.TopologicalSort()
+	if len(errs) != 0 {
+		t.Fatalf("failed to sort graph: %v", errs)
+	}
+	promote := &fakeStep{name: "promotion"}
+
+	plan := Plan([]string{"e2e"}, nodes, []api.Step{promote})
+	expected := &api.ExecutionPlan{
+		Targets: []string{"e2e"},
+		Steps: []api.StepPlan{
+			{Name: "src", Description: "src", Creates: []string{"pipeline:src"}},
+			{Name: "[release-inputs]", Description: "[release-inputs]", Creates: []string{"stable"}},
+			{
+				Name:         "e2e",
+				Description:  "e2e",
+				Requires:     []string{"pipeline:src", "stable"},
+				Dependencies: []string{"src", "[release-inputs]"},
+				Provides:     []string{"A", "B"},
+				Leases:       []api.StepLease{{ResourceType: "aws-quota-slice", Env: "LEASED_RESOURCE", Count: 1}},
+			},
+		},
+		PromotionSteps: []api.StepPlan{{Name: "promotion", Description: "promotion"}},
+	}
+	if diff := cmp.Diff(expected, plan); diff != "" {
+		t.Errorf("unexpected plan: %s", diff)
+	}
+}
@@ -45,6 +45,8 @@ type promotionStep struct {
 	mirrorFunc        func(source, target string, tag api.ImageStreamTagReference, date string, imageMirror map[string]string)
 	targetNameFunc    func(string, api.PromotionTarget) string
 	nodeArchitectures []string
+	// auditClient returns a client for app.ci to record the promotion into the history of ImageStreams
+	auditClient func(ctx context.Context) (ctrlruntimeclient.Client, error)
 }
 
 func (s *promotionStep) Inputs() (api.InputDefinition, error) {
@@ -110,9 +112,11 @@ func (s *promotionStep) run(ctx context.Context) error {
 		return fmt.Errorf("resolve promotion cli image: %w", err)
 	}
 
+	audit := s.startAudit(ctx, logger, promotedImageStreamTags(imageMirrorTarget, s.registry, timeStr))
 	if _, err := steps.RunPod(ctx, s.client, getPromotionPod(imageMirrorTarget, timeStr, s.jobSpec.Namespace(), s.name, cliImage, s.nodeArchitectures), false); err != nil {
 		return fmt.Errorf("unable to run promotion pod: %w", err)
 	}
+	audit.finish(ctx, promotionRecord(s.jobSpec, time.Now()))
 	return nil
 }
 
@@ -829,7 +833,7 @@ func PromotionStep(
 	targetNameFunc func(string, api.PromotionTarget) string,
 	nodeArchitectures []string,
 ) api.Step {
-	return &promotionStep{
+	s := &promotionStep{
 		name:              name,
 		configuration:     configuration,
 		requiredImages:    requiredImages,
@@ -841,4 +845,6 @@ func PromotionStep(
 		targetNameFunc:    targetNameFunc,
 		nodeArchitectures: nodeArchitectures,
 	}
+	s.auditClient = s.appCIClient
+	return s
 }
@@ -0,0 +1,179 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/promotionhistory"
+)
+
+// promotedImageStreamTags returns the ImageStream tags on app.ci, in namespace/name:tag form, which
+// the promotion updates. Images mirrored into the app.ci registry update the ImageStream of their
+// repository, other targets without a registry are tagged directly.
+func promotedImageStreamTags(imageMirrorTarget map[string]string, registry, timeStr string) []string {
+	var tags []string
+	for target := range imageMirrorTarget {
+		if strings.Contains(target, fmt.Sprintf("%s_prune_", timeStr)) || strings.Contains(target, api.ComponentFormatReplacement) {
+			continue
+		}
+		if registry == api.ServiceDomainAPPCIRegistry && strings.HasPrefix(target, registry+"/") {
+			tags = append(tags, strings.TrimPrefix(target, registry+"/"))
+			continue
+		}
+		if host := strings.Split(target, "/")[0]; strings.ContainsAny(host, ".:") {
+			continue
+		}
+		tags = append(tags, target)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// splitImageStreamTag splits namespace/name:tag into the ImageStream key and the tag
+func splitImageStreamTag(isTag string) (ctrlruntimeclient.ObjectKey, string, error) {
+	namespace, nameAndTag, ok := strings.Cut(isTag, "/")
+	if !ok {
+		return ctrlruntimeclient.ObjectKey{}, "", fmt.Errorf("%s is not in namespace/name:tag form", isTag)
+	}
+	name, tag, ok := strings.Cut(nameAndTag, ":")
+	if !ok {
+		return ctrlruntimeclient.ObjectKey{}, "", fmt.Errorf("%s is not in namespace/name:tag form", isTag)
+	}
+	return ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: name}, tag, nil
+}
+
+// snapshotImageStreamTags returns the pull specs the tags point to, grouped by ImageStream
+func snapshotImageStreamTags(ctx context.Context, client ctrlruntimeclient.Client, isTags []string) (map[ctrlruntimeclient.ObjectKey]map[string]string, error) {
+	snapshot := map[ctrlruntimeclient.ObjectKey]map[string]string{}
+	streams := map[ctrlruntimeclient.ObjectKey]*imagev1.ImageStream{}
+	var errs []error
+	for _, isTag := range isTags {
+		key, tag, err := splitImageStreamTag(isTag)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		is, ok := streams[key]
+		if !ok {
+			is = &imagev1.ImageStream{}
+			if err := client.Get(ctx, key, is); ctrlruntimeclient.IgnoreNotFound(err) != nil {
+				errs = append(errs, fmt.Errorf("could not get imagestream %s: %w", key, err))
+				continue
+			}
+			streams[key] = is
+			snapshot[key] = map[string]string{}
+		}
+		snapshot[key][tag] = promotionhistory.TagPullSpec(is, tag)
+	}
+	return snapshot, utilerrors.NewAggregate(errs)
+}
+
+// recordPromotion adds the tags that changed between the snapshots to the promotion history of their ImageStreams
+func recordPromotion(ctx context.Context, client ctrlruntimeclient.Client, record promotionhistory.Record, before, after map[ctrlruntimeclient.ObjectKey]map[string]string) error {
+	var errs []error
+	for key, tags := range after {
+		changes := promotionhistory.TagChanges(before[key], tags)
+		if len(changes) == 0 {
+			continue
+		}
+		record.Tags = changes
+		if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			is := &imagev1.ImageStream{}
+			if err := client.Get(ctx, key, is); err != nil {
+				return err
+			}
+			if err := promotionhistory.AddRecord(is, record); err != nil {
+				return err
+			}
+			return client.Update(ctx, is)
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("could not record the promotion into imagestream %s: %w", key, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// promotionRecord describes the job running the promotion
+func promotionRecord(jobSpec *api.JobSpec, now time.Time) promotionhistory.Record {
+	record := promotionhistory.Record{Time: meta.NewTime(now), ProwJobID: jobSpec.ProwJobID, Job: jobSpec.Job}
+	if refs := mainRefs(jobSpec.Refs, jobSpec.ExtraRefs); refs != nil {
+		record.Source = &promotionhistory.Source{Org: refs.Org, Repo: refs.Repo, Branch: refs.BaseRef, Commit: refs.BaseSHA}
+		for _, pull := range refs.Pulls {
+			record.Source.PullRequests = append(record.Source.PullRequests, pull.Number)
+		}
+	}
+	return record
+}
+
+// appCIClient creates a client for app.ci from the kubeconfig the promotion pod uses to tag images
+func (s *promotionStep) appCIClient(ctx context.Context) (ctrlruntimeclient.Client, error) {
+	secret := &coreapi.Secret{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.PromotionQuayTaggerKubeconfigSecret}, secret); err != nil {
+		return nil, fmt.Errorf("could not get secret %s: %w", api.PromotionQuayTaggerKubeconfigSecret, err)
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(secret.Data["kubeconfig"])
+	if err != nil {
+		return nil, fmt.Errorf("could not load kubeconfig from secret %s: %w", api.PromotionQuayTaggerKubeconfigSecret, err)
+	}
+	scheme := runtime.NewScheme()
+	if err := imagev1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("could not add imagev1 to scheme: %w", err)
+	}
+	return ctrlruntimeclient.New(config, ctrlruntimeclient.Options{Scheme: scheme})
+}
+
+// promotionAudit records the promotion into the history of the ImageStreams it updates. Auditing is
+// best effort: failing to do so is logged and does not fail the promotion.
+type promotionAudit struct {
+	client ctrlruntimeclient.Client
+	tags   []string
+	before map[ctrlruntimeclient.ObjectKey]map[string]string
+	logger *logrus.Entry
+}
+
+func (s *promotionStep) startAudit(ctx context.Context, logger *logrus.Entry, isTags []string) *promotionAudit {
+	if len(isTags) == 0 {
+		return nil
+	}
+	client, err := s.auditClient(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Promotion will not be audited.")
+		return nil
+	}
+	before, err := snapshotImageStreamTags(ctx, client, isTags)
+	if err != nil {
+		logger.WithError(err).Warn("Promotion will not be audited.")
+		return nil
+	}
+	return &promotionAudit{client: client, tags: isTags, before: before, logger: logger}
+}
+
+func (a *promotionAudit) finish(ctx context.Context, record promotionhistory.Record) {
+	if a == nil {
+		return
+	}
+	after, err := snapshotImageStreamTags(ctx, a.client, a.tags)
+	if err != nil {
+		a.logger.WithError(err).Warn("Failed to audit the promotion.")
+		return
+	}
+	if err := recordPromotion(ctx, a.client, record, a.before, after); err != nil {
+		a.logger.WithError(err).Warn("Failed to audit the promotion.")
+	}
+}
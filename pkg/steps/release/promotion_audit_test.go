@@ -0,0 +1,136 @@
+package release
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	imageapi "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/promotionhistory"
+)
+
+func TestPromotedImageStreamTags(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		targets  map[string]string
+		registry string
+		expected []string
+	}{
+		{
+			name: "app.ci registry",
+			targets: map[string]string{
+				"registry.ci.openshift.org/ocp/4.22:cli":                    "src",
+				"registry.ci.openshift.org/ocp/4.22:cli_20261017_prune_abc": "src",
+			},
+			registry: api.ServiceDomainAPPCIRegistry,
+			expected: []string{"ocp/4.22:cli"},
+		},
+		{
+			name: "quay",
+			targets: map[string]string{
+				"quay.io/openshift/ci:ocp_4.22_cli":      "src",
+				"ocp/4.22:cli":                           "src",
+				"ci/${component}-quay:latest":            "src",
+				"registry.other.org/ocp/4.22:cli":        "src",
+				"localhost:5000/ocp/4.22:unrelated-host": "src",
+			},
+			registry: api.QuayOpenShiftCIRepo,
+			expected: []string{"ocp/4.22:cli"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if diff := cmp.Diff(tc.expected, promotedImageStreamTags(tc.targets, tc.registry, "20261017")); diff != "" {
+				t.Errorf("unexpected tags: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRecordPromotion(t *testing.T) {
+	t.Parallel()
+	scheme := runtime.NewScheme()
+	if err := imageapi.Install(scheme); err != nil {
+		t.Fatal(err)
+	}
+	stream := func(tags map[string]string) *imageapi.ImageStream {
+		is := &imageapi.ImageStream{ObjectMeta: meta.ObjectMeta{Namespace: "ocp", Name: "4.22"}}
+		for tag, pullSpec := range tags {
+			is.Status.Tags = append(is.Status.Tags, imageapi.NamedTagEventList{Tag: tag, Items: []imageapi.TagEvent{{DockerImageReference: pullSpec}}})
+		}
+		return is
+	}
+	ctx := context.Background()
+	key := ctrlruntimeclient.ObjectKey{Namespace: "ocp", Name: "4.22"}
+	isTags := []string{"ocp/4.22:cli", "ocp/4.22:tests", "ocp/4.22:unchanged"}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(stream(map[string]string{
+		"cli":       "registry/cli@sha256:1",
+		"unchanged": "registry/unchanged@sha256:1",
+	})).Build()
+	before, err := snapshotImageStreamTags(ctx, client, isTags)
+	if err != nil {
+		t.Fatalf("failed to snapshot: %v", err)
+	}
+
+	promoted := stream(map[string]string{
+		"cli":       "registry/cli@sha256:2",
+		"tests":     "registry/tests@sha256:1",
+		"unchanged": "registry/unchanged@sha256:1",
+	})
+	current := &imageapi.ImageStream{}
+	if err := client.Get(ctx, key, current); err != nil {
+		t.Fatalf("failed to get imagestream: %v", err)
+	}
+	current.Status = promoted.Status
+	if err := client.Update(ctx, current); err != nil {
+		t.Fatalf("failed to update imagestream: %v", err)
+	}
+	after, err := snapshotImageStreamTags(ctx, client, isTags)
+	if err != nil {
+		t.Fatalf("failed to snapshot: %v", err)
+	}
+
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	jobSpec := &api.JobSpec{}
+	jobSpec.ProwJobID = "pj-1"
+	jobSpec.Job = "branch-ci-org-repo-main-images"
+	jobSpec.Refs = &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc"}
+	if err := recordPromotion(ctx, client, promotionRecord(jobSpec, now), before, after); err != nil {
+		t.Fatalf("failed to record promotion: %v", err)
+	}
+
+	is := &imageapi.ImageStream{}
+	if err := client.Get(ctx, key, is); err != nil {
+		t.Fatalf("failed to get imagestream: %v", err)
+	}
+	history, err := promotionhistory.History(is)
+	if err != nil {
+		t.Fatalf("failed to read history: %v", err)
+	}
+	expected := []promotionhistory.Record{{
+		Time:      meta.NewTime(now),
+		ProwJobID: "pj-1",
+		Job:       "branch-ci-org-repo-main-images",
+		Source:    &promotionhistory.Source{Org: "org", Repo: "repo", Branch: "main", Commit: "abc"},
+		Tags: []promotionhistory.TagChange{
+			{Tag: "cli", Old: "registry/cli@sha256:1", New: "registry/cli@sha256:2"},
+			{Tag: "tests", New: "registry/tests@sha256:1"},
+		},
+	}}
+	if diff := cmp.Diff(expected, history); diff != "" {
+		t.Errorf("unexpected history: %s", diff)
+	}
+}
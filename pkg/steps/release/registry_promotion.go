@@ -0,0 +1,177 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/kubernetes/pkg/credentialprovider"
+	"github.com/openshift/ci-tools/pkg/manifestpusher"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+// registryPromotionStep pushes built images directly to the OCI registries
+// configured as registry targets of the promotion.
+type registryPromotionStep struct {
+	configuration *api.ReleaseBuildConfiguration
+	jobSpec       *api.JobSpec
+	client        kubernetes.PodClient
+	// sourceDockercfgPath holds the credentials for the registry of the build cluster
+	sourceDockercfgPath string
+	newCopier           func(logger *logrus.Entry, sourceAuths, targetAuths credentialprovider.DockerConfig) manifestpusher.ImageCopier
+	now                 func() time.Time
+}
+
+func (s *registryPromotionStep) Inputs() (api.InputDefinition, error) {
+	return nil, nil
+}
+
+func (*registryPromotionStep) Validate() error { return nil }
+
+func (s *registryPromotionStep) Run(ctx context.Context) error {
+	return results.ForReason("promoting_images").ForError(s.run(ctx))
+}
+
+func (s *registryPromotionStep) run(ctx context.Context) error {
+	logger := logrus.WithField("name", api.PromotionRegistriesStepName)
+	pipeline := &imagev1.ImageStream{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: api.PipelineImageStream}, pipeline); err != nil {
+		return fmt.Errorf("could not resolve pipeline imagestream: %w", err)
+	}
+	sourceAuths, err := s.sourceAuths()
+	if err != nil {
+		return err
+	}
+	var commit string
+	if refs := mainRefs(s.jobSpec.Refs, s.jobSpec.ExtraRefs); refs != nil {
+		commit = refs.BaseSHA
+	}
+	date := s.now().Format("20060102")
+
+	var errs []error
+	for _, target := range api.RegistryPromotionTargets(s.configuration.PromotionConfiguration) {
+		if commit == "" && usesCommit(target) {
+			errs = append(errs, fmt.Errorf("cannot push to %s/%s: its tags refer to the commit, which is not known", target.Registry, target.Repository))
+			continue
+		}
+		targetAuths, err := s.targetAuths(ctx, target.Credentials)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		copier := s.newCopier(logger, sourceAuths, targetAuths)
+		options := manifestpusher.CopyOptions{PinDigest: target.PinDigest, Signatures: target.CopySignatures, Attestations: target.CopyAttestations}
+		for _, image := range api.RegistryTargetImages(target, s.configuration) {
+			source := findDockerImageReference(pipeline, image)
+			if source == "" {
+				logger.WithField("image", image).Info("Image was not built, not pushing it.")
+				continue
+			}
+			pullSpecs := target.PullSpecs(image, commit, date)
+			logger.Infof("Pushing %s to %s", image, strings.Join(pullSpecs, ", "))
+			if _, err := copier.CopyImage(ctx, source, pullSpecs, options); err != nil {
+				errs = append(errs, fmt.Errorf("failed to push %s: %w", image, err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func usesCommit(target api.RegistryPromotionTarget) bool {
+	for _, tag := range target.Tags {
+		if strings.Contains(tag, api.CommitFormatReplacement) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *registryPromotionStep) sourceAuths() (credentialprovider.DockerConfig, error) {
+	if s.sourceDockercfgPath == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(s.sourceDockercfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.sourceDockercfgPath, err)
+	}
+	var dockercfg credentialprovider.DockerConfigJSON
+	if err := json.Unmarshal(raw, &dockercfg); err != nil {
+		return nil, fmt.Errorf("failed to deserialize %s: %w", s.sourceDockercfgPath, err)
+	}
+	return dockercfg.Auths, nil
+}
+
+func (s *registryPromotionStep) targetAuths(ctx context.Context, credentials api.RegistryCredentials) (credentialprovider.DockerConfig, error) {
+	// the configuration is validated, but never read secrets from other namespaces regardless
+	if credentials.Namespace != api.RegistryCredentialsNamespace {
+		return nil, fmt.Errorf("refusing to read secret %s/%s: credentials must be in the %s namespace", credentials.Namespace, credentials.Name, api.RegistryCredentialsNamespace)
+	}
+	secret := &coreapi.Secret{}
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: credentials.Namespace, Name: credentials.Name}, secret); err != nil {
+		return nil, fmt.Errorf("could not get secret %s/%s: %w", credentials.Namespace, credentials.Name, err)
+	}
+	var dockercfg credentialprovider.DockerConfigJSON
+	if err := json.Unmarshal(secret.Data[coreapi.DockerConfigJsonKey], &dockercfg); err != nil {
+		return nil, fmt.Errorf("failed to deserialize secret %s/%s: %w", credentials.Namespace, credentials.Name, err)
+	}
+	return dockercfg.Auths, nil
+}
+
+func (s *registryPromotionStep) Requires() []api.StepLink {
+	return []api.StepLink{api.AllStepsLink()}
+}
+
+func (s *registryPromotionStep) Creates() []api.StepLink {
+	return []api.StepLink{}
+}
+
+func (s *registryPromotionStep) Provides() api.ParameterMap {
+	return nil
+}
+
+func (s *registryPromotionStep) Name() string {
+	return fmt.Sprintf("[%s]", api.PromotionRegistriesStepName)
+}
+
+func (s *registryPromotionStep) Description() string {
+	var targets []string
+	for _, target := range api.RegistryPromotionTargets(s.configuration.PromotionConfiguration) {
+		targets = append(targets, fmt.Sprintf("%s/%s", target.Registry, target.Repository))
+	}
+	return fmt.Sprintf("Push built images to the registries: %s", strings.Join(targets, ", "))
+}
+
+func (s *registryPromotionStep) Objects() []ctrlruntimeclient.Object {
+	return s.client.Objects()
+}
+
+// RegistryPromotionStep pushes images from the pipeline image stream to the registry targets
+// defined in the promotion config. Images which were not built are skipped.
+func RegistryPromotionStep(
+	configuration *api.ReleaseBuildConfiguration,
+	jobSpec *api.JobSpec,
+	client kubernetes.PodClient,
+	sourceDockercfgPath string,
+) api.Step {
+	return &registryPromotionStep{
+		configuration:       configuration,
+		jobSpec:             jobSpec,
+		client:              client,
+		sourceDockercfgPath: sourceDockercfgPath,
+		newCopier:           manifestpusher.NewImageCopier,
+		now:                 time.Now,
+	}
+}
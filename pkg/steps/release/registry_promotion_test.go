@@ -0,0 +1,176 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	imageapi "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/kubernetes"
+	"github.com/openshift/ci-tools/pkg/kubernetes/pkg/credentialprovider"
+	"github.com/openshift/ci-tools/pkg/manifestpusher"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+type copyCall struct {
+	Source   string
+	Targets  []string
+	Options  manifestpusher.CopyOptions
+	Username string
+}
+
+type fakeImageCopier struct {
+	auths credentialprovider.DockerConfig
+	calls *[]copyCall
+	err   error
+}
+
+func (c *fakeImageCopier) CopyImage(_ context.Context, source string, targets []string, options manifestpusher.CopyOptions) (string, error) {
+	*c.calls = append(*c.calls, copyCall{Source: source, Targets: targets, Options: options, Username: c.auths["quay.io"].Username})
+	return "sha256:1", c.err
+}
+
+func TestRegistryPromotionStep(t *testing.T) {
+	t.Parallel()
+	scheme := runtime.NewScheme()
+	if err := imageapi.Install(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := coreapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	pipeline := &imageapi.ImageStream{
+		ObjectMeta: meta.ObjectMeta{Namespace: "ci-op-1234", Name: api.PipelineImageStream},
+		Status: imageapi.ImageStreamStatus{Tags: []imageapi.NamedTagEventList{
+			{Tag: "cli", Items: []imageapi.TagEvent{{DockerImageReference: "registry.svc:5000/ci-op-1234/pipeline@sha256:cli"}}},
+			{Tag: "operator", Items: []imageapi.TagEvent{{DockerImageReference: "registry.svc:5000/ci-op-1234/pipeline@sha256:operator"}}},
+		}},
+	}
+	secret := &coreapi.Secret{
+		ObjectMeta: meta.ObjectMeta{Namespace: "test-credentials", Name: "quay-push"},
+		Data:       map[string][]byte{coreapi.DockerConfigJsonKey: []byte(`{"auths":{"quay.io":{"auth":"cm9ib3Q6dG9rZW4="}}}`)},
+	}
+	credentials := api.RegistryCredentials{Namespace: "test-credentials", Name: "quay-push"}
+	images := api.ImageConfiguration{Items: []api.ProjectDirectoryImageBuildStepConfiguration{{To: "cli"}, {To: "operator"}, {To: "bundle"}}}
+
+	testCases := []struct {
+		name        string
+		targets     []api.RegistryPromotionTarget
+		refs        *prowapi.Refs
+		copyErr     error
+		expected    []copyCall
+		expectedErr error
+	}{
+		{
+			name: "images are pushed with rendered tags",
+			targets: []api.RegistryPromotionTarget{{
+				Registry:       "quay.io",
+				Repository:     "org/${component}",
+				Tags:           []string{"latest", "${commit}", "v1-${date}"},
+				Credentials:    credentials,
+				PinDigest:      true,
+				CopySignatures: true,
+			}},
+			refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseSHA: "abc"},
+			expected: []copyCall{
+				{
+					Source:   "registry.svc:5000/ci-op-1234/pipeline@sha256:cli",
+					Targets:  []string{"quay.io/org/cli:latest", "quay.io/org/cli:abc", "quay.io/org/cli:v1-20261017"},
+					Options:  manifestpusher.CopyOptions{PinDigest: true, Signatures: true},
+					Username: "robot",
+				},
+				{
+					Source:   "registry.svc:5000/ci-op-1234/pipeline@sha256:operator",
+					Targets:  []string{"quay.io/org/operator:latest", "quay.io/org/operator:abc", "quay.io/org/operator:v1-20261017"},
+					Options:  manifestpusher.CopyOptions{PinDigest: true, Signatures: true},
+					Username: "robot",
+				},
+			},
+		},
+		{
+			name: "only listed images are pushed",
+			targets: []api.RegistryPromotionTarget{{
+				Registry:         "quay.io",
+				Repository:       "org/images",
+				Tags:             []string{"${component}"},
+				Images:           []string{"operator"},
+				Credentials:      credentials,
+				CopyAttestations: true,
+			}},
+			expected: []copyCall{{
+				Source:   "registry.svc:5000/ci-op-1234/pipeline@sha256:operator",
+				Targets:  []string{"quay.io/org/images:operator"},
+				Options:  manifestpusher.CopyOptions{Attestations: true},
+				Username: "robot",
+			}},
+		},
+		{
+			name:        "commit is required by the tags",
+			targets:     []api.RegistryPromotionTarget{{Registry: "quay.io", Repository: "org/images", Tags: []string{"${component}-${commit}"}, Credentials: credentials}},
+			expectedErr: errors.New("cannot push to quay.io/org/images: its tags refer to the commit, which is not known"),
+		},
+		{
+			name:        "missing credentials",
+			targets:     []api.RegistryPromotionTarget{{Registry: "quay.io", Repository: "org/images", Tags: []string{"${component}"}, Credentials: api.RegistryCredentials{Namespace: "test-credentials", Name: "missing"}}},
+			expectedErr: errors.New(`could not get secret test-credentials/missing: secrets "missing" not found`),
+		},
+		{
+			name:        "credentials outside of the test-credentials namespace",
+			targets:     []api.RegistryPromotionTarget{{Registry: "quay.io", Repository: "org/images", Tags: []string{"${component}"}, Credentials: api.RegistryCredentials{Namespace: "ci", Name: "quay-push"}}},
+			expectedErr: errors.New("refusing to read secret ci/quay-push: credentials must be in the test-credentials namespace"),
+		},
+		{
+			name:    "push failure",
+			targets: []api.RegistryPromotionTarget{{Registry: "quay.io", Repository: "org/images", Tags: []string{"${component}"}, Images: []string{"cli"}, Credentials: credentials}},
+			copyErr: errors.New("unauthorized"),
+			expected: []copyCall{{
+				Source:   "registry.svc:5000/ci-op-1234/pipeline@sha256:cli",
+				Targets:  []string{"quay.io/org/images:cli"},
+				Username: "robot",
+			}},
+			expectedErr: errors.New("failed to push cli: unauthorized"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pipeline.DeepCopy(), secret.DeepCopy()).Build()
+			jobSpec := &api.JobSpec{}
+			jobSpec.SetNamespace("ci-op-1234")
+			jobSpec.Refs = tc.refs
+			var calls []copyCall
+			step := &registryPromotionStep{
+				configuration: &api.ReleaseBuildConfiguration{
+					Images:                 images,
+					PromotionConfiguration: &api.PromotionConfiguration{RegistryTargets: tc.targets},
+				},
+				jobSpec: jobSpec,
+				client:  kubernetes.NewPodClient(loggingclient.New(client, nil), nil, nil, 0, nil),
+				newCopier: func(_ *logrus.Entry, _, targetAuths credentialprovider.DockerConfig) manifestpusher.ImageCopier {
+					return &fakeImageCopier{auths: targetAuths, calls: &calls, err: tc.copyErr}
+				},
+				now: func() time.Time { return time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC) },
+			}
+			err := step.run(context.Background())
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, calls); diff != "" {
+				t.Errorf("unexpected pushes: %s", diff)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/metrics"
@@ -20,7 +22,9 @@ type message struct {
 	stepDetails     api.CIOperatorStepDetails
 }
 
-func Run(ctx context.Context, graph api.StepGraph, agent *metrics.MetricsAgent) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
+// Run executes the graph. When a checkpointer is given, steps that completed in a
+// previous execution are not run again and every completed step is recorded.
+func Run(ctx context.Context, graph api.StepGraph, agent *metrics.MetricsAgent, checkpointer Checkpointer) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
 	var seen []api.StepLink
 	executionResults := make(chan message)
 	done := make(chan bool)
@@ -35,7 +39,7 @@ func Run(ctx context.Context, graph api.StepGraph, agent *metrics.MetricsAgent)
 
 	start := time.Now()
 	for _, root := range graph {
-		go runStep(ctx, root, executionResults, agent)
+		go runStep(ctx, root, executionResults, agent, checkpointer)
 	}
 
 	suites := &junit.TestSuites{
@@ -59,7 +63,7 @@ func Run(ctx context.Context, graph api.StepGraph, agent *metrics.MetricsAgent)
 			stepDetails = append(stepDetails, out.stepDetails)
 			if out.err != nil {
 				testCase.FailureOutput = &junit.FailureOutput{Output: out.err.Error()}
-				executionErrors = append(executionErrors, results.ForReason("step_failed").WithError(out.err).Errorf("step %s failed: %v", out.node.Step.Name(), out.err))
+				executionErrors = append(executionErrors, results.ForReason("step_failed").WithError(results.WithDetails(out.err, results.Details{Step: out.node.Step.Name()})).Errorf("step %s failed: %v", out.node.Step.Name(), out.err))
 			} else {
 				seen = append(seen, out.node.Step.Creates()...)
 				if !interrupted {
@@ -71,7 +75,7 @@ func Run(ctx context.Context, graph api.StepGraph, agent *metrics.MetricsAgent)
 						// when the last of its parents finishes.
 						if api.HasAllLinks(child.Step.Requires(), seen) {
 							wg.Add(1)
-							go runStep(ctx, child, executionResults, agent)
+							go runStep(ctx, child, executionResults, agent, checkpointer)
 						}
 					}
 				}
@@ -117,9 +121,19 @@ type SubStepReporter interface {
 	SubSteps() []api.CIOperatorStepDetailInfo
 }
 
-func runStep(ctx context.Context, node *api.StepNode, out chan<- message, agent *metrics.MetricsAgent) {
+func runStep(ctx context.Context, node *api.StepNode, out chan<- message, agent *metrics.MetricsAgent, checkpointer Checkpointer) {
 	start := time.Now()
+	if checkpointer != nil && checkpointer.Completed(ctx, node.Step) {
+		logrus.Infof("Skipping %s, it completed in a previous execution", node.Step.Name())
+		out <- resumedStepMessage(node, start)
+		return
+	}
 	err := node.Step.Run(ctx)
+	if err == nil && checkpointer != nil {
+		if recordErr := checkpointer.Record(ctx, node.Step); recordErr != nil {
+			logrus.WithError(recordErr).Warnf("Failed to record completion of step %s.", node.Step.Name())
+		}
+	}
 	var additionalTests []*junit.TestCase
 	if reporter, ok := node.Step.(SubtestReporter); ok {
 		additionalTests = reporter.SubTests()
@@ -156,3 +170,26 @@ func runStep(ctx context.Context, node *api.StepNode, out chan<- message, agent
 		agent.RecordStepEvent(node.Step, objects, start, finishedAt, err)
 	}
 }
+
+func resumedStepMessage(node *api.StepNode, start time.Time) message {
+	var duration time.Duration
+	failed := false
+	return message{
+		node:     node,
+		duration: duration,
+		additionalTests: []*junit.TestCase{{
+			Name:        node.Step.Description(),
+			SkipMessage: &junit.SkipMessage{Message: "Step completed in a previous execution."},
+		}},
+		stepDetails: api.CIOperatorStepDetails{
+			CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{
+				StepName:    node.Step.Name(),
+				Description: node.Step.Description(),
+				StartedAt:   &start,
+				FinishedAt:  &start,
+				Duration:    &duration,
+				Failed:      &failed,
+			},
+		},
+	}
+}
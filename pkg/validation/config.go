@@ -195,6 +195,7 @@ func (v *Validator) validateConfiguration(ctx *configContext, config *api.Releas
 				len(api.ImageTargets(config)) > 0,
 				config.ReleaseTagConfiguration,
 				config.Releases)...)
+		validationErrors = append(validationErrors, validateRegistryPromotionTargets("promotion", *config.PromotionConfiguration, config)...)
 	}
 
 	validationErrors = append(validationErrors, validateReleases("releases", config.Releases, config.ReleaseTagConfiguration != nil)...)
@@ -553,6 +554,74 @@ func validatePromotionConfiguration(fieldRoot string, input api.PromotionConfigu
 	return validationErrors
 }
 
+var (
+	registryHostRegexp       = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]+)?$`)
+	registryRepositoryRegexp = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+|__[a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+|__[a-z0-9]+)*)*$`)
+	registryTagRegexp        = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+)
+
+func validateRegistryPromotionTargets(fieldRoot string, input api.PromotionConfiguration, config *api.ReleaseBuildConfiguration) []error {
+	var validationErrors []error
+	builtImages := sets.New[string]()
+	for _, image := range config.Images.Items {
+		builtImages.Insert(string(image.To))
+	}
+	// render the templates with values of the right form to validate the resulting pull specs
+	const commit, date = "0123456789abcdef0123456789abcdef01234567", "20060102"
+	pushedBy := map[string]string{}
+	for i, target := range input.RegistryTargets {
+		thisFieldRoot := fmt.Sprintf("%s.registry_targets[%d]", fieldRoot, i)
+		if target.Registry == "" {
+			validationErrors = append(validationErrors, fmt.Errorf("%s: no registry defined", thisFieldRoot))
+		} else if !registryHostRegexp.MatchString(target.Registry) {
+			validationErrors = append(validationErrors, fmt.Errorf("%s: registry %q must be a host name with an optional port", thisFieldRoot, target.Registry))
+		}
+		if target.Repository == "" {
+			validationErrors = append(validationErrors, fmt.Errorf("%s: no repository defined", thisFieldRoot))
+		}
+		if len(target.Tags) == 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("%s: no tags defined", thisFieldRoot))
+		}
+		if target.Credentials.Namespace == "" || target.Credentials.Name == "" {
+			validationErrors = append(validationErrors, fmt.Errorf("%s: credentials must define both namespace and name", thisFieldRoot))
+		} else if target.Credentials.Namespace != api.RegistryCredentialsNamespace {
+			validationErrors = append(validationErrors, fmt.Errorf("%s: credentials must be in the %s namespace, not %s", thisFieldRoot, api.RegistryCredentialsNamespace, target.Credentials.Namespace))
+		}
+		images := api.RegistryTargetImages(target, config)
+		if len(images) == 0 {
+			validationErrors = append(validationErrors, fmt.Errorf("%s: no images to push", thisFieldRoot))
+		}
+		for _, image := range target.Images {
+			if !builtImages.Has(image) {
+				validationErrors = append(validationErrors, fmt.Errorf("%s: image %s is not built by this configuration", thisFieldRoot, image))
+			}
+		}
+		if target.Registry == "" || target.Repository == "" || len(target.Tags) == 0 || len(images) == 0 {
+			continue
+		}
+
+		if repository := strings.ReplaceAll(target.Repository, api.ComponentFormatReplacement, "component"); !registryRepositoryRegexp.MatchString(repository) {
+			validationErrors = append(validationErrors, fmt.Errorf("%s: repository %q is not valid, the only variable allowed is %s", thisFieldRoot, target.Repository, api.ComponentFormatReplacement))
+		}
+		replacer := strings.NewReplacer(api.ComponentFormatReplacement, "component", api.CommitFormatReplacement, commit, api.DateFormatReplacement, date)
+		for _, tag := range target.Tags {
+			if !registryTagRegexp.MatchString(replacer.Replace(tag)) {
+				validationErrors = append(validationErrors, fmt.Errorf("%s: tag %q is not valid, the variables allowed are %s, %s and %s", thisFieldRoot, tag, api.ComponentFormatReplacement, api.CommitFormatReplacement, api.DateFormatReplacement))
+			}
+		}
+		for _, image := range images {
+			for _, pullSpec := range target.PullSpecs(image, commit, date) {
+				if other, ok := pushedBy[pullSpec]; ok {
+					validationErrors = append(validationErrors, fmt.Errorf("%s: image %s is pushed to %s by %s as well", thisFieldRoot, image, pullSpec, other))
+					continue
+				}
+				pushedBy[pullSpec] = fmt.Sprintf("%s (image %s)", thisFieldRoot, image)
+			}
+		}
+	}
+	return validationErrors
+}
+
 func validateReleaseTagConfiguration(fieldRoot string, input api.ReleaseTagConfiguration) []error {
 	var validationErrors []error
 
@@ -492,6 +492,82 @@ func TestValidatePromotion(t *testing.T) {
 	}
 }
 
+func TestValidateRegistryPromotionTargets(t *testing.T) {
+	config := &api.ReleaseBuildConfiguration{Images: api.ImageConfiguration{Items: []api.ProjectDirectoryImageBuildStepConfiguration{{To: "cli"}, {To: "operator"}}}}
+	credentials := api.RegistryCredentials{Namespace: "test-credentials", Name: "quay-push"}
+	var testCases = []struct {
+		name     string
+		targets  []api.RegistryPromotionTarget
+		expected []error
+	}{
+		{
+			name: "valid targets",
+			targets: []api.RegistryPromotionTarget{
+				{Registry: "quay.io", Repository: "org/${component}", Tags: []string{"latest", "${commit}", "v1-${date}"}, Credentials: credentials},
+				{Registry: "registry.example.com:5000", Repository: "org/images", Tags: []string{"${component}"}, Images: []string{"cli"}, Credentials: credentials, PinDigest: true},
+			},
+		},
+		{
+			name:    "missing fields",
+			targets: []api.RegistryPromotionTarget{{}},
+			expected: []error{
+				errors.New("promotion.registry_targets[0]: no registry defined"),
+				errors.New("promotion.registry_targets[0]: no repository defined"),
+				errors.New("promotion.registry_targets[0]: no tags defined"),
+				errors.New("promotion.registry_targets[0]: credentials must define both namespace and name"),
+			},
+		},
+		{
+			name:    "credentials outside of the test-credentials namespace",
+			targets: []api.RegistryPromotionTarget{{Registry: "quay.io", Repository: "org/${component}", Tags: []string{"latest"}, Credentials: api.RegistryCredentials{Namespace: "ci", Name: "registry-push-credentials"}}},
+			expected: []error{
+				errors.New("promotion.registry_targets[0]: credentials must be in the test-credentials namespace, not ci"),
+			},
+		},
+		{
+			name:    "invalid registry and image not built",
+			targets: []api.RegistryPromotionTarget{{Registry: "https://quay.io", Repository: "org/images", Tags: []string{"${component}"}, Images: []string{"bundle"}, Credentials: credentials}},
+			expected: []error{
+				errors.New(`promotion.registry_targets[0]: registry "https://quay.io" must be a host name with an optional port`),
+				errors.New("promotion.registry_targets[0]: image bundle is not built by this configuration"),
+			},
+		},
+		{
+			name:    "unknown variables",
+			targets: []api.RegistryPromotionTarget{{Registry: "quay.io", Repository: "org/${commit}", Tags: []string{"${component}-${branch}"}, Credentials: credentials}},
+			expected: []error{
+				errors.New(`promotion.registry_targets[0]: repository "org/${commit}" is not valid, the only variable allowed is ${component}`),
+				errors.New(`promotion.registry_targets[0]: tag "${component}-${branch}" is not valid, the variables allowed are ${component}, ${commit} and ${date}`),
+			},
+		},
+		{
+			name:    "images overwrite each other",
+			targets: []api.RegistryPromotionTarget{{Registry: "quay.io", Repository: "org/images", Tags: []string{"latest"}, Credentials: credentials}},
+			expected: []error{
+				errors.New("promotion.registry_targets[0]: image operator is pushed to quay.io/org/images:latest by promotion.registry_targets[0] (image cli) as well"),
+			},
+		},
+		{
+			name: "targets overwrite each other",
+			targets: []api.RegistryPromotionTarget{
+				{Registry: "quay.io", Repository: "org/${component}", Tags: []string{"latest"}, Images: []string{"cli"}, Credentials: credentials},
+				{Registry: "quay.io", Repository: "org/cli", Tags: []string{"latest"}, Images: []string{"cli"}, Credentials: credentials},
+			},
+			expected: []error{
+				errors.New("promotion.registry_targets[1]: image cli is pushed to quay.io/org/cli:latest by promotion.registry_targets[0] (image cli) as well"),
+			},
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			actual := validateRegistryPromotionTargets("promotion", api.PromotionConfiguration{RegistryTargets: test.targets}, config)
+			if diff := cmp.Diff(test.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("%s: got incorrect errors: %v", test.name, diff)
+			}
+		})
+	}
+}
+
 func TestValidateReleaseTagConfiguration(t *testing.T) {
 	var testCases = []struct {
 		name     string
@@ -624,6 +624,11 @@ func (v *Validator) validateTestConfigurationType(
 			clusterCount++
 			validationErrors = append(validationErrors, v.validateClusterProfile(fieldRoot, string(testConfig.ClusterProfile), test.As, metadata)...)
 		}
+		if testConfig.Workflow != nil {
+			if err := validateRegistryVersion(*testConfig.Workflow); err != nil {
+				validationErrors = append(validationErrors, fmt.Errorf("%s.workflow: %w", fieldRoot, err))
+			}
+		}
 		context := newContext(fieldPath(fieldRoot), testConfig.Environment, releases, inputImagesSeen)
 		validationErrors = append(validationErrors, validateLeases(context.addField("leases"), testConfig.Leases)...)
 		if testConfig.NodeArchitecture != nil {
@@ -690,26 +695,50 @@ func validateTestStep(context *context, step api.TestStep) (ret []error) {
 		return
 	}
 	if step.Reference != nil {
+		name, _ := api.SplitRegistryVersion(*step.Reference)
 		if len(*step.Reference) == 0 {
 			ret = append(ret, context.addField("ref").errorf("length cannot be 0"))
-		} else if context.namesSeen.Has(*step.Reference) {
-			ret = append(ret, context.addField("ref").errorf("duplicated name %q", *step.Reference))
+		} else if err := validateRegistryVersion(*step.Reference); err != nil {
+			ret = append(ret, context.addField("ref").errorf("%v", err))
+		} else if context.namesSeen.Has(name) {
+			ret = append(ret, context.addField("ref").errorf("duplicated name %q", name))
 		} else {
-			context.namesSeen.Insert(*step.Reference)
+			context.namesSeen.Insert(name)
 		}
 	}
 	if step.Chain != nil {
+		name, _ := api.SplitRegistryVersion(*step.Chain)
 		if len(*step.Chain) == 0 {
 			ret = append(ret, context.addField("chain").errorf("length cannot be 0"))
-		} else if context.namesSeen.Has(*step.Chain) {
-			ret = append(ret, context.addField("chain").errorf("duplicated name %q", *step.Chain))
+		} else if err := validateRegistryVersion(*step.Chain); err != nil {
+			ret = append(ret, context.addField("chain").errorf("%v", err))
+		} else if context.namesSeen.Has(name) {
+			ret = append(ret, context.addField("chain").errorf("duplicated name %q", name))
 		} else {
-			context.namesSeen.Insert(*step.Chain)
+			context.namesSeen.Insert(name)
 		}
 	}
 	return
 }
 
+var registryVersionRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// validateRegistryVersion verifies the syntax of a reference to a registry
+// component which may be pinned to a registry version, e.g. ipi-install@v3
+func validateRegistryVersion(reference string) error {
+	if !strings.Contains(reference, api.RegistryVersionSeparator) {
+		return nil
+	}
+	name, version := api.SplitRegistryVersion(reference)
+	if name == "" {
+		return fmt.Errorf("%q does not name a registry component", reference)
+	}
+	if !registryVersionRegex.MatchString(version) {
+		return fmt.Errorf("%q must be pinned to a registry version matching %s", reference, registryVersionRegex)
+	}
+	return nil
+}
+
 func (v *Validator) validateLiteralTestStep(context *context, stage testStage, step api.LiteralTestStep, claimRelease *api.ClaimRelease) (ret []error) {
 	if len(step.As) == 0 {
 		ret = append(ret, context.errorf("`as` is required"))
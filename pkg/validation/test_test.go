@@ -754,6 +754,9 @@ func TestValidateTestSteps(t *testing.T) {
 	// string pointers in golang are annoying
 	myReference := "my-reference"
 	asReference := "as"
+	pinnedReference, currentReference := "ipi-install@v3", "ipi-install"
+	pinnedChain := "ipi-deprovision@0123abc"
+	missingVersion, missingName, twoVersions := "ipi-install@", "@v3", "ipi-conf@v3@v4"
 	yes := true
 	defaultDuration := &prowv1.Duration{Duration: 1 * time.Minute}
 	for _, tc := range []struct {
@@ -1004,6 +1007,33 @@ func TestValidateTestSteps(t *testing.T) {
 		errs: []error{
 			errors.New("test[1].ref: duplicated name \"as\""),
 		},
+	}, {
+		name: "Pinned reference and chain",
+		steps: []api.TestStep{
+			{Reference: &pinnedReference},
+			{Chain: &pinnedChain},
+		},
+	}, {
+		name: "Invalid registry versions",
+		steps: []api.TestStep{
+			{Reference: &missingVersion},
+			{Chain: &missingName},
+			{Reference: &twoVersions},
+		},
+		errs: []error{
+			errors.New(`test[0].ref: "ipi-install@" must be pinned to a registry version matching ^[a-zA-Z0-9][a-zA-Z0-9._-]*$`),
+			errors.New(`test[1].chain: "@v3" does not name a registry component`),
+			errors.New(`test[2].ref: "ipi-conf@v3@v4" must be pinned to a registry version matching ^[a-zA-Z0-9][a-zA-Z0-9._-]*$`),
+		},
+	}, {
+		name: "Same step pinned to different versions",
+		steps: []api.TestStep{
+			{Reference: &pinnedReference},
+			{Reference: &currentReference},
+		},
+		errs: []error{
+			errors.New(`test[1].ref: duplicated name "ipi-install"`),
+		},
 	}, {
 		name: "Test step with forbidden parameter",
 
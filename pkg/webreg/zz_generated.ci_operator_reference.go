@@ -312,6 +312,43 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"    # should *not* be used in common test workflows. The CI chat\n" +
 	"    # bot uses this option to facilitate image sharing.\n" +
 	"    registry_override: ' '\n" +
+	"    # RegistryTargets configure OCI registries outside of the\n" +
+	"    # OpenShift CI image streams that built images are pushed to\n" +
+	"    # directly, e.g. to publish artifacts of projects outside of\n" +
+	"    # the OpenShift organization.\n" +
+	"    registry_targets:\n" +
+	"        - # CopyAttestations copies the sigstore attestations of the\n" +
+	"          # images, stored in the sha256-<digest>.att tags, when they exist.\n" +
+	"          copy_attestations: true\n" +
+	"          # CopySignatures copies the sigstore signatures of the images,\n" +
+	"          # stored in the sha256-<digest>.sig tags, when they exist.\n" +
+	"          copy_signatures: true\n" +
+	"          # Credentials references a kubernetes.io/dockerconfigjson secret\n" +
+	"          # holding the credentials to push into the registry. The secret\n" +
+	"          # must exist in the test-credentials namespace.\n" +
+	"          credentials:\n" +
+	"            # Name is the name of the secret.\n" +
+	"            name: ' '\n" +
+	"            # Namespace is where the secret exists.\n" +
+	"            namespace: ' '\n" +
+	"          # Images are the names of the images to push. All images built\n" +
+	"          # by this configuration are pushed if not set.\n" +
+	"          images:\n" +
+	"            - \"\"\n" +
+	"          # PinDigest refuses to move a tag which already points to a\n" +
+	"          # different digest, so that published tags are immutable.\n" +
+	"          pin_digest: true\n" +
+	"          # Registry is the host of the registry, e.g. quay.io.\n" +
+	"          registry: ' '\n" +
+	"          # Repository is the repository in the registry images are\n" +
+	"          # pushed to. ${component} is replaced by the name of the image.\n" +
+	"          repository: ' '\n" +
+	"          # Tags are templates of the tags pushed for every image.\n" +
+	"          # ${component} is replaced by the name of the image, ${commit}\n" +
+	"          # by the commit the image was built from and ${date} by the\n" +
+	"          # date of the promotion in the YYYYMMDD form.\n" +
+	"          tags:\n" +
+	"            - \"\"\n" +
 	"    # Targets configure a set of images to be pushed to\n" +
 	"    # a registry.\n" +
 	"    to:\n" +
@@ -1215,7 +1252,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - as: ' '\n" +
 	"                  best_effort: false\n" +
-	"                  # Chain is the name of a step chain reference.\n" +
+	"                  # Chain is the name of a step chain reference. Like references, chains\n" +
+	"                  # can be pinned to a registry version.\n" +
 	"                  chain: \"\"\n" +
 	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"                  # will be injected into this step.\n" +
@@ -1268,7 +1306,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
-	"                  # Reference is the name of a step reference.\n" +
+	"                  # Reference is the name of a step reference. The name can be followed by\n" +
+	"                  # `@` and a registry version to pin the step to that version, e.g. `ipi-install@v3`.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
@@ -1286,7 +1325,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - as: ' '\n" +
 	"                  best_effort: false\n" +
-	"                  # Chain is the name of a step chain reference.\n" +
+	"                  # Chain is the name of a step chain reference. Like references, chains\n" +
+	"                  # can be pinned to a registry version.\n" +
 	"                  chain: \"\"\n" +
 	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"                  # will be injected into this step.\n" +
@@ -1339,7 +1379,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
-	"                  # Reference is the name of a step reference.\n" +
+	"                  # Reference is the name of a step reference. The name can be followed by\n" +
+	"                  # `@` and a registry version to pin the step to that version, e.g. `ipi-install@v3`.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
@@ -1357,7 +1398,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - as: ' '\n" +
 	"                  best_effort: false\n" +
-	"                  # Chain is the name of a step chain reference.\n" +
+	"                  # Chain is the name of a step chain reference. Like references, chains\n" +
+	"                  # can be pinned to a registry version.\n" +
 	"                  chain: \"\"\n" +
 	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"                  # will be injected into this step.\n" +
@@ -1410,7 +1452,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
-	"                  # Reference is the name of a step reference.\n" +
+	"                  # Reference is the name of a step reference. The name can be followed by\n" +
+	"                  # `@` and a registry version to pin the step to that version, e.g. `ipi-install@v3`.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
@@ -1425,6 +1468,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  timeout: 0s\n" +
 	"            # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"            # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
+	"            # The workflow can be pinned to a registry version, e.g. `ipi-aws@v3`, in which case all of its\n" +
+	"            # steps are taken from that version.\n" +
 	"            workflow: \"\"\n" +
 	"        # Timeout overrides maximum prowjob duration\n" +
 	"        timeout: 0s\n" +
@@ -2161,7 +2206,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
 	"            - as: ' '\n" +
 	"              best_effort: false\n" +
-	"              # Chain is the name of a step chain reference.\n" +
+	"              # Chain is the name of a step chain reference. Like references, chains\n" +
+	"              # can be pinned to a registry version.\n" +
 	"              chain: \"\"\n" +
 	"              # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"              # will be injected into this step.\n" +
@@ -2214,7 +2260,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
-	"              # Reference is the name of a step reference.\n" +
+	"              # Reference is the name of a step reference. The name can be followed by\n" +
+	"              # `@` and a registry version to pin the step to that version, e.g. `ipi-install@v3`.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
@@ -2232,7 +2279,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
 	"            - as: ' '\n" +
 	"              best_effort: false\n" +
-	"              # Chain is the name of a step chain reference.\n" +
+	"              # Chain is the name of a step chain reference. Like references, chains\n" +
+	"              # can be pinned to a registry version.\n" +
 	"              chain: \"\"\n" +
 	"              # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"              # will be injected into this step.\n" +
@@ -2285,7 +2333,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
-	"              # Reference is the name of a step reference.\n" +
+	"              # Reference is the name of a step reference. The name can be followed by\n" +
+	"              # `@` and a registry version to pin the step to that version, e.g. `ipi-install@v3`.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
@@ -2303,7 +2352,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
 	"            - as: ' '\n" +
 	"              best_effort: false\n" +
-	"              # Chain is the name of a step chain reference.\n" +
+	"              # Chain is the name of a step chain reference. Like references, chains\n" +
+	"              # can be pinned to a registry version.\n" +
 	"              chain: \"\"\n" +
 	"              # Cli is the (optional) name of the release from which the `oc` binary\n" +
 	"              # will be injected into this step.\n" +
@@ -2356,7 +2406,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
-	"              # Reference is the name of a step reference.\n" +
+	"              # Reference is the name of a step reference. The name can be followed by\n" +
+	"              # `@` and a registry version to pin the step to that version, e.g. `ipi-install@v3`.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
@@ -2371,6 +2422,8 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              timeout: 0s\n" +
 	"        # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"        # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
+	"        # The workflow can be pinned to a registry version, e.g. `ipi-aws@v3`, in which case all of its\n" +
+	"        # steps are taken from that version.\n" +
 	"        workflow: \"\"\n" +
 	"      # Timeout overrides maximum prowjob duration\n" +
 	"      timeout: 0s\n" +